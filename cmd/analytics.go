@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/behzadon/vote/internal/analytics"
+	"github.com/behzadon/vote/internal/lifecycle"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/storage/postgres"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var analyticsConsumerCmd = &cobra.Command{
+	Use:   "analytics-consumer",
+	Short: "Start the analytics consumer",
+	Long:  `Start the consumer that builds the analytics projections from poll and vote events.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		cfg := GetConfig()
+
+		zapLogger, err := zap.NewProduction()
+		if err != nil {
+			return fmt.Errorf("create logger: %w", err)
+		}
+		defer func() {
+			if err := zapLogger.Sync(); err != nil {
+				zapLogger.Error("Failed to sync logger", zap.Error(err))
+			}
+		}()
+
+		logger := logging.NewLogger(zapLogger)
+
+		db, err := connectPostgres(cfg.Postgres, cfg.Startup, zapLogger)
+		if err != nil {
+			return fmt.Errorf("connect to postgres: %w", err)
+		}
+		defer func() {
+			if err := db.Close(); err != nil {
+				logger.Error("Failed to close database connection", err)
+			}
+		}()
+
+		redisClient, err := connectRedis(cfg.Redis, cfg.Startup, zapLogger)
+		if err != nil {
+			return fmt.Errorf("connect to redis: %w", err)
+		}
+		defer func() {
+			if err := redisClient.Close(); err != nil {
+				logger.Error("Failed to close Redis connection", err)
+			}
+		}()
+
+		repo := postgres.NewRepository(db, redisClient, zapLogger)
+		projector := analytics.NewProjector(repo, zapLogger)
+
+		consumer, err := connectConsumer(cfg, "analytics_events", projector, zapLogger)
+		if err != nil {
+			return fmt.Errorf("create RabbitMQ consumer: %w", err)
+		}
+
+		if err := consumer.Start(ctx); err != nil {
+			if closeErr := consumer.Close(); closeErr != nil {
+				logger.Error("Failed to close RabbitMQ consumer", closeErr)
+			}
+			return fmt.Errorf("start consumer: %w", err)
+		}
+
+		logger.Info("Analytics consumer started")
+
+		manager := lifecycle.NewManager(cfg.Server.ShutdownTimeout, zapLogger)
+		manager.OnShutdown(reportShutdown(cfg.Metrics, zapLogger))
+		addMetricsServer(manager, cfg.Metrics, zapLogger)
+		manager.Add(lifecycle.Component{
+			Name:     "consumer",
+			Stop:     consumer.Stop,
+			InFlight: consumer.InFlight,
+		})
+
+		if err := manager.Run(ctx); err != nil {
+			return fmt.Errorf("consumer shutdown: %w", err)
+		}
+
+		logger.Info("Analytics consumer exited properly")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(analyticsConsumerCmd)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/behzadon/vote/internal/middleware"
 	"github.com/behzadon/vote/internal/postgres"
 	"github.com/behzadon/vote/internal/service"
+	"github.com/behzadon/vote/internal/storage/cache"
 )
 
 func main() {
@@ -36,11 +37,11 @@ func main() {
 		logger.Fatal("Failed to load configuration", zap.Error(err))
 	}
 
-	rdb := redis.NewClient(&redis.Options{
+	rdb := cache.Instrument(redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port),
 		Password: cfg.Redis.Password,
 		DB:       cfg.Redis.DB,
-	})
+	}))
 	defer rdb.Close()
 
 	ctx := context.Background()
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/behzadon/vote/internal/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var configRedacted bool
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+}
+
+var configPrintCmd = &cobra.Command{
+	Use:   "print",
+	Short: "Print the effective configuration",
+	Long: `Print the configuration after merging the defaults, config.yaml, the
+config.{env}.yaml overlay and environment variables.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings, files, err := config.Effective(cfgFile, configRedacted)
+		if err != nil {
+			return err
+		}
+		out, err := yaml.Marshal(settings)
+		if err != nil {
+			return fmt.Errorf("marshal config: %w", err)
+		}
+
+		for _, file := range files {
+			fmt.Fprintf(cmd.OutOrStdout(), "# %s\n", file)
+		}
+		_, err = cmd.OutOrStdout().Write(out)
+		return err
+	},
+}
+
+func init() {
+	configPrintCmd.Flags().BoolVar(&configRedacted, "redacted", false, "mask passwords, keys and tokens")
+	configCmd.AddCommand(configPrintCmd)
+	rootCmd.AddCommand(configCmd)
+}
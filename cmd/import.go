@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/storage/cache"
+	"github.com/behzadon/vote/internal/storage/postgres"
+	"github.com/behzadon/vote/internal/voteimport"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	importFile      string
+	importFormat    string
+	importBatchSize int
+)
+
+var importVotesCmd = &cobra.Command{
+	Use:   "import-votes",
+	Short: "Import historical votes",
+	Long: `Import votes cast in another system from a CSV or NDJSON file, in the
+format written by the poll vote export. Imported votes update stats and
+analytics but send no notifications.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		cfg := GetConfig()
+
+		format := importFormat
+		if format == "" {
+			switch strings.ToLower(filepath.Ext(importFile)) {
+			case ".csv":
+				format = "csv"
+			case ".ndjson", ".jsonl":
+				format = "ndjson"
+			default:
+				return fmt.Errorf("cannot infer the format of %q; set --format", importFile)
+			}
+		}
+
+		var input io.Reader = os.Stdin
+		if importFile != "-" {
+			file, err := os.Open(importFile)
+			if err != nil {
+				return fmt.Errorf("open %s: %w", importFile, err)
+			}
+			defer file.Close()
+			input = file
+		}
+		src, err := voteimport.NewSource(input, format)
+		if err != nil {
+			return err
+		}
+
+		zapLogger, err := zap.NewProduction()
+		if err != nil {
+			return fmt.Errorf("create logger: %w", err)
+		}
+		defer func() {
+			if err := zapLogger.Sync(); err != nil {
+				zapLogger.Error("Failed to sync logger", zap.Error(err))
+			}
+		}()
+
+		logger := logging.NewLogger(zapLogger)
+
+		db, err := connectPostgres(cfg.Postgres, cfg.Startup, zapLogger)
+		if err != nil {
+			return fmt.Errorf("connect to postgres: %w", err)
+		}
+		defer func() {
+			if err := db.Close(); err != nil {
+				logger.Error("Failed to close database connection", err)
+			}
+		}()
+
+		redisClient, err := connectRedis(cfg.Redis, cfg.Startup, zapLogger)
+		if err != nil {
+			return fmt.Errorf("connect to redis: %w", err)
+		}
+		defer func() {
+			if err := redisClient.Close(); err != nil {
+				logger.Error("Failed to close Redis connection", err)
+			}
+		}()
+
+		repo := postgres.NewRepository(db, redisClient, zapLogger)
+		importer := voteimport.NewImporter(repo, zapLogger,
+			voteimport.WithStatsWatcher(cache.NewStatsVersions(redisClient, zapLogger)),
+			voteimport.WithBatchSize(importBatchSize),
+		)
+
+		result, err := importer.Import(ctx, src)
+		for _, rowErr := range result.Errors {
+			fmt.Fprintln(cmd.ErrOrStderr(), rowErr.Error())
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "imported %d, duplicates %d, rejected %d\n",
+			result.Imported, result.Duplicates, result.Rejected)
+		if err != nil {
+			return fmt.Errorf("import votes: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	importVotesCmd.Flags().StringVar(&importFile, "file", "", `file to import, or "-" for stdin`)
+	importVotesCmd.Flags().StringVar(&importFormat, "format", "", "csv or ndjson (default inferred from the file extension)")
+	importVotesCmd.Flags().IntVar(&importBatchSize, "batch-size", voteimport.DefaultBatchSize, "votes stored per transaction")
+	_ = importVotesCmd.MarkFlagRequired("file")
+	rootCmd.AddCommand(importVotesCmd)
+}
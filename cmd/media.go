@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/behzadon/vote/internal/config"
+	"github.com/behzadon/vote/internal/storage/blob"
+	"github.com/gin-gonic/gin"
+)
+
+// newBlobStore returns nil when no storage driver is configured.
+func newBlobStore(cfg config.StorageConfig) (blob.Store, error) {
+	switch cfg.Driver {
+	case "":
+		return nil, nil
+	case "local":
+		return blob.NewLocal(cfg.Local.Dir, cfg.Local.BaseURL, cfg.Local.Secret)
+	case "s3":
+		endpoint := cfg.S3.Endpoint
+		if endpoint == "" {
+			endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.S3.Region)
+		}
+		return blob.NewS3(blob.S3Config{
+			Endpoint:  endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			PathStyle: cfg.S3.PathStyle,
+		})
+	case "gcs":
+		return blob.NewGCS(blob.GCSConfig{
+			Bucket:    cfg.GCS.Bucket,
+			AccessKey: cfg.GCS.AccessKey,
+			SecretKey: cfg.GCS.SecretKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
+
+// mountLocalMedia serves signed URLs of the local driver from the path of
+// its base URL.
+func mountLocalMedia(engine *gin.Engine, store *blob.LocalStore, baseURL string) error {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("parse storage.local.base_url: %w", err)
+	}
+	prefix := strings.TrimRight(u.Path, "/")
+
+	var maxSize int64
+	for _, kind := range blob.Kinds {
+		if size := kind.Policy().MaxSize; size > maxSize {
+			maxSize = size
+		}
+	}
+	h := gin.WrapH(http.StripPrefix(prefix+"/", store.Handler(maxSize)))
+	engine.GET(prefix+"/*key", h)
+	engine.PUT(prefix+"/*key", h)
+	return nil
+}
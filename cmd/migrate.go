@@ -65,7 +65,7 @@ func runMigrations(direction string) error {
 		}
 	}()
 
-	db, err := connectPostgres(cfg.Postgres)
+	db, err := connectPostgres(cfg.Postgres, cfg.Startup, logger)
 	if err != nil {
 		return fmt.Errorf("connect to database: %w", err)
 	}
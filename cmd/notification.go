@@ -3,13 +3,12 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"os"
-	"os/signal"
-	"syscall"
 
+	"github.com/behzadon/vote/internal/config"
+	"github.com/behzadon/vote/internal/lifecycle"
 	"github.com/behzadon/vote/internal/logging"
 	"github.com/behzadon/vote/internal/notification"
-	"github.com/behzadon/vote/internal/storage/events"
+	"github.com/behzadon/vote/internal/storage/postgres"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
@@ -34,43 +33,63 @@ var notificationConsumerCmd = &cobra.Command{
 
 		logger := logging.NewLogger(zapLogger)
 
-		// TODO: Implement a real notification service
-		mockNotificationService := &notification.MockNotificationService{
-			Logger: zapLogger,
+		db, err := connectPostgres(cfg.Postgres, cfg.Startup, zapLogger)
+		if err != nil {
+			return fmt.Errorf("connect to postgres: %w", err)
 		}
+		defer func() {
+			if err := db.Close(); err != nil {
+				logger.Error("Failed to close database connection", err)
+			}
+		}()
 
-		handler := notification.NewNotificationHandler(mockNotificationService, zapLogger)
-
-		consumer, err := events.NewRabbitMQConsumer(
-			cfg.RabbitMQ.Host,
-			cfg.RabbitMQ.Port,
-			cfg.RabbitMQ.User,
-			cfg.RabbitMQ.Password,
-			cfg.RabbitMQ.VHost,
-			"vote_events",
-			handler,
-			zapLogger,
-		)
+		redisClient, err := connectRedis(cfg.Redis, cfg.Startup, zapLogger)
 		if err != nil {
-			return fmt.Errorf("create RabbitMQ consumer: %w", err)
+			return fmt.Errorf("connect to redis: %w", err)
 		}
 		defer func() {
-			if err := consumer.Close(); err != nil {
-				logger.Error("Failed to close RabbitMQ consumer", err)
+			if err := redisClient.Close(); err != nil {
+				logger.Error("Failed to close Redis connection", err)
 			}
 		}()
 
+		repo := postgres.NewRepository(db, redisClient, zapLogger)
+		notifier, err := newNotifier(cfg.Notification, repo, zapLogger)
+		if err != nil {
+			return fmt.Errorf("create notifier: %w", err)
+		}
+		handler := notification.NewNotificationHandler(notifier, repo, zapLogger,
+			notification.WithCreatorNotifications(repo, cfg.Notification.VoteMilestones),
+		)
+
+		consumer, err := connectConsumer(cfg, "vote_events", handler, zapLogger)
+		if err != nil {
+			return fmt.Errorf("create RabbitMQ consumer: %w", err)
+		}
+
 		if err := consumer.Start(ctx); err != nil {
+			if closeErr := consumer.Close(); closeErr != nil {
+				logger.Error("Failed to close RabbitMQ consumer", closeErr)
+			}
 			return fmt.Errorf("start consumer: %w", err)
 		}
 
 		logger.Info("Notification consumer started")
 
-		quit := make(chan os.Signal, 1)
-		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-		<-quit
+		manager := lifecycle.NewManager(cfg.Server.ShutdownTimeout, zapLogger)
+		manager.OnShutdown(reportShutdown(cfg.Metrics, zapLogger))
+		addMetricsServer(manager, cfg.Metrics, zapLogger)
+		manager.Add(lifecycle.Component{
+			Name:     "consumer",
+			Stop:     consumer.Stop,
+			InFlight: consumer.InFlight,
+		})
 
-		logger.Info("Shutting down notification consumer...")
+		if err := manager.Run(ctx); err != nil {
+			return fmt.Errorf("consumer shutdown: %w", err)
+		}
+
+		logger.Info("Notification consumer exited properly")
 		return nil
 	},
 }
@@ -78,3 +97,47 @@ var notificationConsumerCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(notificationConsumerCmd)
 }
+
+// newNotifier sends notifications on the channels enabled in cfg.
+func newNotifier(cfg config.NotificationConfig, users notification.UserLookup, logger *zap.Logger) (*notification.Dispatcher, error) {
+	var channels []notification.Channel
+	if e := cfg.Email; e.Enabled {
+		channels = append(channels, notification.NewEmailChannel(notification.SMTPConfig{
+			Host:     e.Host,
+			Port:     e.Port,
+			Username: e.Username,
+			Password: e.Password,
+			From:     e.From,
+			Timeout:  e.Timeout,
+		}, users))
+	}
+	if w := cfg.Webhook; w.Enabled {
+		channels = append(channels, notification.NewWebhookChannel(notification.WebhookConfig{
+			URL:     w.URL,
+			Secret:  w.Secret,
+			Timeout: w.Timeout,
+		}))
+	}
+	if p := cfg.Push; p.Enabled {
+		push, err := notification.NewPushChannel(notification.PushConfig{
+			CredentialsFile: p.CredentialsFile,
+			Timeout:         p.Timeout,
+		})
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, push)
+	}
+
+	names := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		names = append(names, channel.Name())
+	}
+	logger.Info("Notification channels configured", zap.Strings("channels", names))
+
+	return notification.NewDispatcher(channels, notification.RetryPolicy{
+		Attempts: cfg.Retry.Attempts,
+		Initial:  cfg.Retry.InitialBackoff,
+		Max:      cfg.Retry.MaxBackoff,
+	}, logger), nil
+}
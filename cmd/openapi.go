@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/behzadon/vote/internal/api"
+	"github.com/spf13/cobra"
+)
+
+var openAPIOutput string
+
+var openAPICmd = &cobra.Command{
+	Use:   "openapi",
+	Short: "Print the OpenAPI document of the HTTP API",
+	Long: `Print the OpenAPI 3 document the server serves at /api/openapi.json.
+Run "make openapi" after changing a route to refresh docs/openapi.json.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		spec, err := api.OpenAPISpec()
+		if err != nil {
+			return fmt.Errorf("build openapi document: %w", err)
+		}
+		if openAPIOutput == "" {
+			_, err = cmd.OutOrStdout().Write(spec)
+			return err
+		}
+		return os.WriteFile(openAPIOutput, spec, 0o644)
+	},
+}
+
+func init() {
+	openAPICmd.Flags().StringVarP(&openAPIOutput, "output", "o", "", "file to write instead of stdout")
+	rootCmd.AddCommand(openAPICmd)
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/behzadon/vote/internal/config"
+	"github.com/behzadon/vote/internal/lifecycle"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/search"
+	"github.com/behzadon/vote/internal/storage/postgres"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+const searchBackfillBatch = 100
+
+var searchBackfill bool
+
+var searchIndexerCmd = &cobra.Command{
+	Use:   "search-indexer",
+	Short: "Start the search indexer",
+	Long:  `Start the consumer that keeps the poll search index up to date with poll events.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		cfg := GetConfig()
+		if !cfg.Search.Enabled {
+			return fmt.Errorf("search is disabled; set search.enabled to run the indexer")
+		}
+
+		zapLogger, err := zap.NewProduction()
+		if err != nil {
+			return fmt.Errorf("create logger: %w", err)
+		}
+		defer func() {
+			if err := zapLogger.Sync(); err != nil {
+				zapLogger.Error("Failed to sync logger", zap.Error(err))
+			}
+		}()
+
+		logger := logging.NewLogger(zapLogger)
+
+		db, err := connectPostgres(cfg.Postgres, cfg.Startup, zapLogger)
+		if err != nil {
+			return fmt.Errorf("connect to postgres: %w", err)
+		}
+		defer func() {
+			if err := db.Close(); err != nil {
+				logger.Error("Failed to close database connection", err)
+			}
+		}()
+
+		redisClient, err := connectRedis(cfg.Redis, cfg.Startup, zapLogger)
+		if err != nil {
+			return fmt.Errorf("connect to redis: %w", err)
+		}
+		defer func() {
+			if err := redisClient.Close(); err != nil {
+				logger.Error("Failed to close Redis connection", err)
+			}
+		}()
+
+		repo := postgres.NewRepository(db, redisClient, zapLogger)
+		client := newSearchClient(cfg.Search)
+		if err := client.EnsureIndex(ctx); err != nil {
+			return fmt.Errorf("ensure search index: %w", err)
+		}
+		indexer := search.NewIndexer(client, repo, zapLogger)
+
+		if searchBackfill {
+			indexed, err := indexer.Backfill(ctx, repo, searchBackfillBatch)
+			if err != nil {
+				return fmt.Errorf("backfill search index: %w", err)
+			}
+			logger.Info("Search index backfilled", zap.Int("polls", indexed))
+		}
+
+		consumer, err := connectConsumer(cfg, "search_index", indexer, zapLogger)
+		if err != nil {
+			return fmt.Errorf("create RabbitMQ consumer: %w", err)
+		}
+
+		if err := consumer.Start(ctx); err != nil {
+			if closeErr := consumer.Close(); closeErr != nil {
+				logger.Error("Failed to close RabbitMQ consumer", closeErr)
+			}
+			return fmt.Errorf("start consumer: %w", err)
+		}
+
+		logger.Info("Search indexer started")
+
+		manager := lifecycle.NewManager(cfg.Server.ShutdownTimeout, zapLogger)
+		manager.OnShutdown(reportShutdown(cfg.Metrics, zapLogger))
+		addMetricsServer(manager, cfg.Metrics, zapLogger)
+		manager.Add(lifecycle.Component{
+			Name:     "consumer",
+			Stop:     consumer.Stop,
+			InFlight: consumer.InFlight,
+		})
+
+		if err := manager.Run(ctx); err != nil {
+			return fmt.Errorf("indexer shutdown: %w", err)
+		}
+
+		logger.Info("Search indexer exited properly")
+		return nil
+	},
+}
+
+func init() {
+	searchIndexerCmd.Flags().BoolVar(&searchBackfill, "backfill", false, "index all existing polls before consuming events")
+	rootCmd.AddCommand(searchIndexerCmd)
+}
+
+func newSearchClient(cfg config.SearchConfig) *search.Client {
+	return search.NewClient(search.Config{
+		URL:      cfg.URL,
+		Index:    cfg.Index,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		Timeout:  cfg.Timeout,
+	})
+}
@@ -3,23 +3,48 @@ package cmd
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
+	"sync/atomic"
 	"time"
 
 	"github.com/behzadon/vote/internal/api"
 	"github.com/behzadon/vote/internal/auth"
 	"github.com/behzadon/vote/internal/config"
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/election"
+	pubevents "github.com/behzadon/vote/internal/events"
+	"github.com/behzadon/vote/internal/feedstream"
+	"github.com/behzadon/vote/internal/geo"
+	grpcapi "github.com/behzadon/vote/internal/grpc"
+	"github.com/behzadon/vote/internal/health"
+	"github.com/behzadon/vote/internal/lifecycle"
 	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/metrics"
+	"github.com/behzadon/vote/internal/moderation"
+	"github.com/behzadon/vote/internal/notification"
+	"github.com/behzadon/vote/internal/pollhook"
+	"github.com/behzadon/vote/internal/publicid"
+	"github.com/behzadon/vote/internal/quota"
+	"github.com/behzadon/vote/internal/results"
+	"github.com/behzadon/vote/internal/scheduler"
+	"github.com/behzadon/vote/internal/search"
 	"github.com/behzadon/vote/internal/service"
+	"github.com/behzadon/vote/internal/storage/blob"
+	"github.com/behzadon/vote/internal/storage/cache"
 	"github.com/behzadon/vote/internal/storage/events"
 	"github.com/behzadon/vote/internal/storage/postgres"
+	"github.com/behzadon/vote/internal/validation"
+	"github.com/behzadon/vote/internal/voteimport"
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
@@ -44,7 +69,7 @@ var serverCmd = &cobra.Command{
 
 		logger := logging.NewLogger(zapLogger)
 
-		db, err := connectPostgres(cfg.Postgres)
+		db, err := connectPostgres(cfg.Postgres, cfg.Startup, zapLogger)
 		if err != nil {
 			return fmt.Errorf("connect to postgres: %w", err)
 		}
@@ -64,7 +89,7 @@ var serverCmd = &cobra.Command{
 			logger.Info("Auto-migration is disabled, skipping migrations")
 		}
 
-		redisClient, err := connectRedis(cfg.Redis)
+		redisClient, err := connectRedis(cfg.Redis, cfg.Startup, zapLogger)
 		if err != nil {
 			return fmt.Errorf("connect to redis: %w", err)
 		}
@@ -83,60 +108,299 @@ var serverCmd = &cobra.Command{
 		}
 		logger.Info("Successfully connected to Redis")
 
-		publisher, err := events.NewRabbitMQPublisher(
+		var publisher *events.RabbitMQPublisher
+		err = lifecycle.Retry(ctx, "rabbitmq", startupBackoff(cfg.Startup), zapLogger, func(context.Context) error {
+			publisher, err = events.NewRabbitMQPublisher(
+				cfg.RabbitMQ.Host,
+				cfg.RabbitMQ.Port,
+				cfg.RabbitMQ.User,
+				cfg.RabbitMQ.Password,
+				cfg.RabbitMQ.VHost,
+				zapLogger,
+			)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("create RabbitMQ publisher: %w", err)
+		}
+
+		manager := lifecycle.NewManager(cfg.Server.ShutdownTimeout, zapLogger)
+		manager.OnShutdown(reportShutdown(cfg.Metrics, zapLogger))
+		manager.Add(lifecycle.Component{
+			Name: "publisher",
+			Stop: func(ctx context.Context) error {
+				return publisher.Close()
+			},
+		})
+
+		healthStatus := health.NewStatus()
+		supervisorCfg := health.SupervisorConfig{
+			Interval: cfg.Health.CheckInterval,
+			Timeout:  cfg.Health.CheckTimeout,
+			Backoff: lifecycle.Backoff{
+				Initial: cfg.Health.ReconnectInitialBackoff,
+				Max:     cfg.Health.ReconnectMaxBackoff,
+			},
+		}
+		for _, dep := range []health.Dependency{
+			{
+				Name:  "redis",
+				Check: func(ctx context.Context) error { return redisClient.Ping(ctx).Err() },
+			},
+			{
+				Name:      "rabbitmq",
+				Check:     publisher.Check,
+				Reconnect: publisher.Reconnect,
+			},
+		} {
+			manager.Add(lifecycle.Component{
+				Name: dep.Name + "-supervisor",
+				Run:  health.NewSupervisor(dep, healthStatus, supervisorCfg, zapLogger).Run,
+			})
+		}
+
+		var repoOpts []postgres.Option
+		if cfg.Cache.Local.Enabled {
+			localCache := cache.NewLocalCache(redisClient, cfg.Cache.Local.Size, cfg.Cache.Local.TTL, zapLogger)
+			repoOpts = append(repoOpts, postgres.WithLocalCache(localCache))
+			manager.Add(lifecycle.Component{
+				Name: "local-cache",
+				Run:  localCache.Run,
+			})
+		}
+		if cfg.Cache.Feed.Enabled {
+			repoOpts = append(repoOpts, postgres.WithFeedCache(cache.NewFeedCache(redisClient, cfg.Cache.Feed.TTL)))
+		}
+		repo := postgres.NewRepository(db, redisClient, zapLogger, repoOpts...)
+		// Registered ahead of the async publisher so the report counts what it
+		// moved to the outbox while draining.
+		manager.Add(lifecycle.Component{
+			Name:     "outbox",
+			InFlight: repo.CountOutboxEvents,
+		})
+		var svcPublisher pubevents.Publisher = publisher
+		if cfg.Events.PublishMode == "async" {
+			asyncPublisher := pubevents.NewAsyncPublisher(publisher, repo, cfg.Events.QueueSize, zapLogger)
+			asyncPublisher.Start(cfg.Events.Workers)
+			manager.Add(lifecycle.Component{
+				Name:     "async-publisher",
+				Stop:     asyncPublisher.Stop,
+				InFlight: asyncPublisher.InFlight,
+			})
+			svcPublisher = asyncPublisher
+		}
+		if cfg.Events.Relay.Enabled {
+			manager.Add(lifecycle.Component{
+				Name: "outbox-relay",
+				Run: pubevents.NewRelay(repo, publisher, pubevents.RelayConfig{
+					Interval: cfg.Events.Relay.Interval,
+					Batch:    cfg.Events.Relay.Batch,
+					Lease:    cfg.Events.Relay.Lease,
+				}, zapLogger).Run,
+			})
+		}
+		validator, err := newPollValidator(cfg.Validation)
+		if err != nil {
+			return fmt.Errorf("create poll validator: %w", err)
+		}
+		svcOpts := []service.Option{service.WithPollValidator(validator)}
+		if cfg.CreationLimits.Enabled {
+			svcOpts = append(svcOpts, service.WithCreationLimiter(quota.NewCreationThrottle(redisClient, quota.CreationLimits{
+				User:                  cfg.CreationLimits.UserDaily,
+				Organization:          cfg.CreationLimits.OrganizationDaily,
+				VerifiedOrganization:  cfg.CreationLimits.VerifiedOrganizationDaily,
+				VerifiedOrganizations: parseUUIDs(cfg.CreationLimits.VerifiedOrganizations),
+			}, zapLogger)))
+		}
+		if cfg.Search.Enabled {
+			searcher := search.NewSearcher(newSearchClient(cfg.Search), repo)
+			svcOpts = append(svcOpts, service.WithPollSearcher(searcher))
+		}
+		scorer, err := newContentScorer(cfg.Moderation.Scoring)
+		if err != nil {
+			return fmt.Errorf("create content scorer: %w", err)
+		}
+		if scorer != nil {
+			svcOpts = append(svcOpts, service.WithContentScorer(scorer, cfg.Moderation.Scoring.Threshold))
+		}
+		mediaStore, err := newBlobStore(cfg.Storage)
+		if err != nil {
+			return fmt.Errorf("create media store: %w", err)
+		}
+		if mediaStore != nil {
+			svcOpts = append(svcOpts, service.WithMediaStore(mediaStore, cfg.Storage.URLTTL))
+		}
+		statsVersions := cache.NewStatsVersions(redisClient, zapLogger)
+		manager.Add(lifecycle.Component{
+			Name: "stats-versions",
+			Run:  statsVersions.Run,
+		})
+		svcOpts = append(svcOpts, service.WithStatsWatcher(statsVersions))
+		svcOpts = append(svcOpts, service.WithTieBreak(domain.TieBreakPolicy(cfg.Results.TieBreak), cfg.Results.TieBreakSeed))
+		svcOpts = append(svcOpts, service.WithResultCertificates(cfg.Results.SigningKey))
+		svcOpts = append(svcOpts, service.WithConsentVersions(domain.ConsentVersions{
+			Terms:   cfg.Consent.TermsVersion,
+			Privacy: cfg.Consent.PrivacyVersion,
+		}))
+		svcOpts = append(svcOpts, service.WithEmailVerification(cfg.EmailVerification.TokenTTL, cfg.EmailVerification.RequiredToVote))
+		svcOpts = append(svcOpts, service.WithPreviewCards(cfg.PreviewCards.RefreshShare))
+		svcOpts = append(svcOpts, service.WithStatsRefresh(cfg.Cache.Stats.RefreshWindow))
+		svcOpts = append(svcOpts, service.WithReceiptSigning(cfg.Receipts.SigningKey))
+		svcOpts = append(svcOpts, service.WithPasswordValidator(newPasswordValidator(cfg.PasswordPolicy)))
+		svc := service.NewInstrumentedService(service.NewStandingService(
+			service.NewService(repo, svcPublisher, zapLogger, svcOpts...), repo,
+		))
+
+		if cfg.Cache.Warmup.Enabled {
+			warmCache(ctx, cfg.Cache.Warmup, repo, zapLogger)
+		}
+
+		if cfg.Scheduler.Enabled {
+			certifier := election.NewCertifier(repo, cfg.Election.SigningKey, zapLogger)
+			snapshotter := results.NewSnapshotter(repo, domain.TieBreakPolicy(cfg.Results.TieBreak), cfg.Results.TieBreakSeed, zapLogger)
+			var resultCertifier *results.Certifier
+			if cfg.Results.SigningKey != "" {
+				resultCertifier = results.NewCertifier(repo, cfg.Results.SigningKey, zapLogger)
+			}
+			notifier, err := newNotifier(cfg.Notification, repo, zapLogger)
+			if err != nil {
+				return fmt.Errorf("create notifier: %w", err)
+			}
+			pollHooks := pollhook.NewDeliverer(repo, pollhook.Config{
+				Timeout:        cfg.PollWebhooks.Timeout,
+				MaxAttempts:    cfg.PollWebhooks.MaxAttempts,
+				InitialBackoff: cfg.PollWebhooks.InitialBackoff,
+				MaxBackoff:     cfg.PollWebhooks.MaxBackoff,
+			}, zapLogger)
+			jobScheduler, err := newScheduler(cfg.Scheduler, repo, repo, repo, publisher, redisClient, certifier, snapshotter, resultCertifier, notifier, pollHooks, mediaStore, cfg.Storage.GCGrace, zapLogger)
+			if err != nil {
+				return fmt.Errorf("create scheduler: %w", err)
+			}
+			jobScheduler.Start(ctx)
+			logger.Info("Scheduler started", zap.Strings("jobs", jobScheduler.Jobs()))
+			manager.Add(lifecycle.Component{
+				Name: "scheduler",
+				Stop: jobScheduler.Stop,
+			})
+		}
+
+		jwtManager := auth.NewJWTManager(cfg.JWT.SecretKey, cfg.JWT.TokenDuration,
+			auth.WithRefreshTokens(repo, cfg.JWT.RefreshTokenDuration),
+		)
+		authHandler := api.NewAuthHandler(svc, jwtManager, zapLogger)
+		var handlerOpts []api.HandlerOption
+		var grpcOpts []grpcapi.ServerOption
+		if cfg.Quota.Enabled {
+			quotas := quota.NewManager(redisClient, repo, quotaDefaults(cfg.Quota), zapLogger)
+			handlerOpts = append(handlerOpts, api.WithQuotaManager(quotas))
+			grpcOpts = append(grpcOpts, grpcapi.WithQuotaManager(quotas))
+		}
+		if cfg.GeoIP.Enabled {
+			locator, err := geo.Open(cfg.GeoIP.Database)
+			if err != nil {
+				return fmt.Errorf("open geoip database: %w", err)
+			}
+			handlerOpts = append(handlerOpts, api.WithGeoLocator(locator))
+		}
+		handlerOpts = append(handlerOpts,
+			api.WithModerators(parseUUIDs(cfg.Moderation.Moderators)),
+			api.WithAdmins(parseUUIDs(cfg.Moderation.Admins)),
+			api.WithPublicURL(cfg.Server.PublicURL),
+			api.WithIDCodec(publicIDCodec(cfg.PublicIDs)),
+			api.WithRateLimits(rateLimitPolicy(cfg.RateLimit.Rate), rateLimitPolicy(cfg.RateLimit.Burst)),
+			api.WithHealth(healthStatus),
+			api.WithVoteImporter(voteimport.NewImporter(repo, zapLogger, voteimport.WithStatsWatcher(statsVersions))),
+		)
+		feedHub := feedstream.NewHub(zapLogger)
+		feedConsumer, err := events.NewRabbitMQBroadcastConsumer(
 			cfg.RabbitMQ.Host,
 			cfg.RabbitMQ.Port,
 			cfg.RabbitMQ.User,
 			cfg.RabbitMQ.Password,
 			cfg.RabbitMQ.VHost,
+			"poll.created",
+			feedHub,
 			zapLogger,
 		)
 		if err != nil {
-			return fmt.Errorf("create RabbitMQ publisher: %w", err)
+			return fmt.Errorf("create feed stream consumer: %w", err)
 		}
-		defer func() {
-			if err := publisher.Close(); err != nil {
-				logger.Error("Failed to close RabbitMQ publisher", err)
+		if err := feedConsumer.Start(ctx); err != nil {
+			if closeErr := feedConsumer.Close(); closeErr != nil {
+				logger.Error("Failed to close feed stream consumer", closeErr)
 			}
-		}()
-
-		repo := postgres.NewRepository(db, redisClient, zapLogger)
-		svc := service.NewService(repo, publisher, zapLogger)
-
-		jwtManager := auth.NewJWTManager(cfg.JWT.SecretKey, cfg.JWT.TokenDuration)
-		authHandler := api.NewAuthHandler(svc, jwtManager, zapLogger)
-		handler := api.NewHandler(svc, redisClient, zapLogger, authHandler)
+			return fmt.Errorf("start feed stream consumer: %w", err)
+		}
+		manager.Add(lifecycle.Component{
+			Name:     "feed-stream",
+			Stop:     feedConsumer.Stop,
+			InFlight: feedConsumer.InFlight,
+		})
+		handlerOpts = append(handlerOpts, api.WithFeedStream(feedHub))
+		handler := api.NewHandler(svc, redisClient, zapLogger, authHandler, handlerOpts...)
 
+		var requests atomic.Int64
 		engine := gin.New()
+		engine.Use(func(c *gin.Context) {
+			requests.Add(1)
+			defer requests.Add(-1)
+			c.Next()
+		})
 		engine.Use(gin.Recovery())
+		engine.Use(logging.Tracing())
 		engine.Use(logger.GinLogger())
 		engine.Use(handler.Middleware())
-		handler.RegisterRoutes(engine, jwtManager)
+		if local, ok := mediaStore.(*blob.LocalStore); ok {
+			if err := mountLocalMedia(engine, local, cfg.Storage.Local.BaseURL); err != nil {
+				return err
+			}
+		}
+		handler.RegisterRoutes(engine, jwtManager,
+			auth.WithFailureAlert(cfg.JWT.FailureAlertThreshold, cfg.JWT.FailureAlertWindow, zapLogger),
+		)
 
 		server := &http.Server{
 			Addr:    fmt.Sprintf(":%d", cfg.Server.Port),
 			Handler: engine,
 		}
-
-		go func() {
-			logger.Info("Starting server",
-				zap.Int("port", cfg.Server.Port),
+		server.RegisterOnShutdown(feedHub.Close)
+		manager.Add(lifecycle.Component{
+			Name: "http",
+			Run: func(ctx context.Context) error {
+				logger.Info("Starting server",
+					zap.Int("port", cfg.Server.Port),
+				)
+				if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					return fmt.Errorf("listen: %w", err)
+				}
+				return nil
+			},
+			Stop: server.Shutdown,
+			InFlight: func(context.Context) (int, error) {
+				return int(requests.Load()), nil
+			},
+		})
+		if cfg.GRPC.Port != 0 {
+			grpcOpts = append(grpcOpts,
+				grpcapi.WithModerators(parseUUIDs(cfg.Moderation.Moderators)),
+				grpcapi.WithAdmins(parseUUIDs(cfg.Moderation.Admins)),
 			)
-			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-				logger.Fatal("Failed to start server", err)
-			}
-		}()
-
-		quit := make(chan os.Signal, 1)
-		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-		<-quit
-
-		logger.Info("Shutting down server...")
-		shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
-		defer cancel()
+			grpcServer := grpcapi.NewServer(svc, jwtManager, zapLogger, grpcOpts...)
+			manager.Add(lifecycle.Component{
+				Name: "grpc",
+				Run: func(ctx context.Context) error {
+					logger.Info("Starting gRPC server",
+						zap.Int("port", cfg.GRPC.Port),
+					)
+					return grpcServer.ListenAndServe(cfg.GRPC.Port)
+				},
+				Stop:     grpcServer.Stop,
+				InFlight: grpcServer.InFlight,
+			})
+		}
 
-		if err := server.Shutdown(shutdownCtx); err != nil {
-			logger.Error("Server forced to shutdown", err)
+		if err := manager.Run(ctx); err != nil {
+			logger.Error("Server shutdown finished with errors", err)
 			return fmt.Errorf("server shutdown: %w", err)
 		}
 
@@ -149,7 +413,258 @@ func init() {
 	rootCmd.AddCommand(serverCmd)
 }
 
-func connectPostgres(cfg config.PostgresConfig) (*sql.DB, error) {
+func quotaDefaults(cfg config.QuotaConfig) []domain.Quota {
+	var quotas []domain.Quota
+	for action, limits := range cfg.Limits {
+		quotas = append(quotas,
+			domain.Quota{Action: domain.QuotaAction(action), Period: domain.QuotaDaily, Limit: limits.Daily},
+			domain.Quota{Action: domain.QuotaAction(action), Period: domain.QuotaMonthly, Limit: limits.Monthly},
+		)
+	}
+	return quotas
+}
+
+// warmCache runs before the HTTP server starts. A failed or slow warm-up only
+// costs cache hits, so it is logged rather than aborting startup.
+func warmCache(ctx context.Context, cfg config.CacheWarmupConfig, repo domain.Repository, logger *zap.Logger) {
+	warmCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	warmed, err := cache.Warmup(warmCtx, repo, cache.WarmupOptions{
+		Polls:       cfg.Polls,
+		Window:      cfg.Window,
+		Concurrency: cfg.Concurrency,
+	}, logger)
+	if err != nil {
+		logger.Warn("Cache warm-up incomplete",
+			zap.Int("polls", warmed),
+			zap.Duration("duration", time.Since(start)),
+			zap.Error(err),
+		)
+		return
+	}
+	logger.Info("Cache warmed",
+		zap.Int("polls", warmed),
+		zap.Duration("duration", time.Since(start)),
+	)
+}
+
+// userIDs expects IDs already checked by config validation.
+func parseUUIDs(raw []string) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(raw))
+	for _, id := range raw {
+		ids = append(ids, uuid.MustParse(id))
+	}
+	return ids
+}
+
+func publicIDCodec(cfg config.PublicIDsConfig) publicid.Codec {
+	if cfg.Encoding == "sqid" {
+		if cfg.AcceptUUIDs {
+			return publicid.AcceptUUIDs(publicid.NewSqids(cfg.Secret))
+		}
+		return publicid.NewSqids(cfg.Secret)
+	}
+	return publicid.UUIDs{}
+}
+
+func rateLimitPolicy(cfg config.RateLimitPolicyConfig) api.RateLimitPolicy {
+	return api.RateLimitPolicy{Limit: cfg.Limit, Window: cfg.Window, Shadow: cfg.Shadow}
+}
+
+func newPollValidator(cfg config.ValidationConfig) (*validation.PollValidator, error) {
+	limits := validation.Limits{
+		MinOptions:      cfg.MinOptions,
+		MaxOptions:      cfg.MaxOptions,
+		MaxTitleLength:  cfg.MaxTitleLength,
+		MaxOptionLength: cfg.MaxOptionLength,
+		MaxTags:         cfg.MaxTags,
+		MaxTagLength:    cfg.MaxTagLength,
+
+		MaxAltTextLength: cfg.MaxAltTextLength,
+		MaxEmojiLength:   domain.MaxEmojiLength,
+	}
+	if !cfg.ProfanityFilter.Enabled {
+		return validation.NewPollValidator(limits, nil), nil
+	}
+	words, err := validation.LoadWordList(cfg.ProfanityFilter.WordList)
+	if err != nil {
+		return nil, err
+	}
+	return validation.NewPollValidator(limits, words), nil
+}
+
+func newPasswordValidator(cfg config.PasswordPolicyConfig) *validation.PasswordValidator {
+	policy := validation.PasswordPolicy{
+		MinLength:     cfg.MinLength,
+		MaxLength:     cfg.MaxLength,
+		RequireLower:  cfg.RequireLower,
+		RequireUpper:  cfg.RequireUpper,
+		RequireDigit:  cfg.RequireDigit,
+		RequireSymbol: cfg.RequireSymbol,
+	}
+	var breaches validation.BreachChecker
+	if cfg.BreachCheck.Enabled {
+		breaches = validation.NewPwnedPasswords(validation.PwnedPasswordsConfig{
+			URL:     cfg.BreachCheck.URL,
+			Timeout: cfg.BreachCheck.Timeout,
+		})
+	}
+	return validation.NewPasswordValidator(policy, breaches)
+}
+
+// newContentScorer returns nil when scoring is disabled.
+func newContentScorer(cfg config.ScoringConfig) (domain.ContentScorer, error) {
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case "http":
+		return moderation.NewHTTPScorer(moderation.HTTPConfig{
+			URL:     cfg.URL,
+			Token:   cfg.Token,
+			Timeout: cfg.Timeout,
+		}), nil
+	}
+	if cfg.WordList == "" {
+		return moderation.NewHeuristic(nil), nil
+	}
+	words, err := validation.LoadWordList(cfg.WordList)
+	if err != nil {
+		return nil, err
+	}
+	return moderation.NewHeuristic(words), nil
+}
+
+func newScheduler(cfg config.SchedulerConfig, repo domain.Repository, runs scheduler.RunStore, outbox pubevents.OutboxStore, publisher pubevents.Publisher, redisClient *redis.Client, certifier *election.Certifier, snapshotter *results.Snapshotter, resultCertifier *results.Certifier, notifier notification.NotificationService, pollHooks *pollhook.Deliverer, media blob.Store, mediaGrace time.Duration, logger *zap.Logger) (*scheduler.Scheduler, error) {
+	locker := scheduler.NewRedisLocker(redisClient, uuid.New().String())
+	s := scheduler.New(locker, cfg.LockTTL, logger, scheduler.WithRunStore(runs))
+
+	jobs := map[string]func(ctx context.Context) error{
+		scheduler.JobStatsRollup:       scheduler.StatsRollup(repo, logger),
+		scheduler.JobRetentionPrune:    scheduler.RetentionPrune(repo, cfg.Retention, logger),
+		scheduler.JobTrendingRecompute: scheduler.TrendingRecompute(repo),
+		scheduler.JobDigestSend:        scheduler.DigestSend(repo, notifier, logger),
+		scheduler.JobElectionCertify:   scheduler.ElectionCertify(certifier, logger),
+		scheduler.JobResultSnapshot:    scheduler.ResultSnapshot(snapshotter, logger),
+		scheduler.JobOutboxRelay:       scheduler.OutboxRelay(outbox, publisher, logger),
+		scheduler.JobVoteWindowRepair:  scheduler.VoteWindowRepair(repo, logger),
+		scheduler.JobPollWebhooks:      scheduler.PollWebhooks(pollHooks, logger),
+		scheduler.JobBusinessMetrics:   scheduler.BusinessMetrics(repo, logger),
+	}
+	if resultCertifier != nil {
+		jobs[scheduler.JobResultCertify] = scheduler.ResultCertify(resultCertifier, logger)
+	}
+	if media != nil {
+		jobs[scheduler.JobMediaGC] = scheduler.MediaGC(media, repo, mediaGrace, logger)
+	}
+	for name, run := range jobs {
+		jobCfg, enabled := cfg.Job(name)
+		if !enabled {
+			logger.Info("Scheduled job disabled", zap.String("job", name))
+			continue
+		}
+		schedule, err := jobSchedule(cfg, jobCfg)
+		if err != nil {
+			return nil, fmt.Errorf("schedule job %s: %w", name, err)
+		}
+		s.Register(scheduler.Job{
+			Name:     name,
+			Schedule: schedule,
+			Run:      run,
+		})
+	}
+
+	return s, nil
+}
+
+func jobSchedule(cfg config.SchedulerConfig, job config.JobConfig) (scheduler.Schedule, error) {
+	if job.Cron == "" {
+		return scheduler.Every(job.Interval), nil
+	}
+	loc, err := cfg.Location(job)
+	if err != nil {
+		return nil, err
+	}
+	return scheduler.Cron(job.Cron, loc)
+}
+
+// startupBackoff is how connect* retry dependencies that are not up yet.
+func startupBackoff(cfg config.StartupConfig) lifecycle.Backoff {
+	return lifecycle.Backoff{
+		Initial: cfg.RetryInitialBackoff,
+		Max:     cfg.RetryMaxBackoff,
+		MaxWait: cfg.RetryMaxWait,
+	}
+}
+
+// shutdownPushTimeout bounds the final metrics push, which runs after the
+// shutdown deadline has been spent.
+const shutdownPushTimeout = 5 * time.Second
+
+// reportShutdown records the shutdown report in the shutdown metrics and, when
+// a Pushgateway is configured, pushes every metric there one last time, since
+// the instance is gone before the next scrape.
+func reportShutdown(cfg config.MetricsConfig, logger *zap.Logger) func(lifecycle.Report) {
+	return func(report lifecycle.Report) {
+		for _, component := range report.Components {
+			metrics.ShutdownInFlight.WithLabelValues(component.Name, "before").Set(float64(component.InFlight))
+			metrics.ShutdownInFlight.WithLabelValues(component.Name, "after").Set(float64(component.Remaining))
+		}
+		drained := 0.0
+		if report.Drained {
+			drained = 1
+		}
+		metrics.ShutdownDrained.Set(drained)
+		metrics.ShutdownDuration.Set(report.Duration.Seconds())
+
+		if cfg.PushURL == "" {
+			return
+		}
+		instance, err := os.Hostname()
+		if err != nil {
+			instance = "unknown"
+		}
+		err = push.New(cfg.PushURL, cfg.PushJob).
+			Grouping("instance", instance).
+			Gatherer(prometheus.DefaultGatherer).
+			Client(&http.Client{Timeout: shutdownPushTimeout}).
+			Push()
+		if err != nil {
+			logger.Error("Failed to push shutdown metrics", zap.Error(err), zap.String("url", cfg.PushURL))
+			return
+		}
+		logger.Info("Pushed shutdown metrics", zap.String("url", cfg.PushURL))
+	}
+}
+
+// addMetricsServer serves /metrics on cfg.Port for the consumers, which have no
+// API server of their own to expose it on.
+func addMetricsServer(manager *lifecycle.Manager, cfg config.MetricsConfig, logger *zap.Logger) {
+	if cfg.Port == 0 {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	manager.Add(lifecycle.Component{
+		Name: "metrics",
+		Run: func(ctx context.Context) error {
+			logger.Info("Serving metrics", zap.Int("port", cfg.Port))
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("serve metrics: %w", err)
+			}
+			return nil
+		},
+		Stop: server.Shutdown,
+	})
+}
+
+func connectPostgres(cfg config.PostgresConfig, startup config.StartupConfig, logger *zap.Logger) (*sql.DB, error) {
 	dsn := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host,
@@ -165,7 +680,13 @@ func connectPostgres(cfg config.PostgresConfig) (*sql.DB, error) {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
 
-	if err := db.Ping(); err != nil {
+	err = lifecycle.Retry(context.Background(), "postgres", startupBackoff(startup), logger, func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	})
+	if err != nil {
+		if closeErr := db.Close(); closeErr != nil {
+			logger.Error("Failed to close database connection", zap.Error(closeErr))
+		}
 		return nil, fmt.Errorf("ping database: %w", err)
 	}
 
@@ -176,14 +697,40 @@ func connectPostgres(cfg config.PostgresConfig) (*sql.DB, error) {
 	return db, nil
 }
 
-func connectRedis(cfg config.RedisConfig) (*redis.Client, error) {
-	client := redis.NewClient(&redis.Options{
+// connectConsumer creates a consumer of queue, waiting for RabbitMQ to come up.
+func connectConsumer(cfg *config.Config, queue string, handler events.EventHandler, logger *zap.Logger) (*events.RabbitMQConsumer, error) {
+	var consumer *events.RabbitMQConsumer
+	err := lifecycle.Retry(context.Background(), "rabbitmq", startupBackoff(cfg.Startup), logger, func(context.Context) error {
+		var err error
+		consumer, err = events.NewRabbitMQConsumer(
+			cfg.RabbitMQ.Host,
+			cfg.RabbitMQ.Port,
+			cfg.RabbitMQ.User,
+			cfg.RabbitMQ.Password,
+			cfg.RabbitMQ.VHost,
+			queue,
+			handler,
+			logger,
+		)
+		return err
+	})
+	return consumer, err
+}
+
+func connectRedis(cfg config.RedisConfig, startup config.StartupConfig, logger *zap.Logger) (*redis.Client, error) {
+	client := cache.Instrument(redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
 		Password: cfg.Password,
 		DB:       cfg.DB,
-	})
+	}))
 
-	if err := client.Ping(context.Background()).Err(); err != nil {
+	err := lifecycle.Retry(context.Background(), "redis", startupBackoff(startup), logger, func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	})
+	if err != nil {
+		if closeErr := client.Close(); closeErr != nil {
+			logger.Error("Failed to close Redis connection", zap.Error(closeErr))
+		}
 		return nil, fmt.Errorf("ping redis: %w", err)
 	}
 
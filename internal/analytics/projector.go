@@ -0,0 +1,124 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/metrics"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Store is where the projector reads polls and writes the projections.
+type Store interface {
+	GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Poll, error)
+	ApplyAnalyticsDelta(ctx context.Context, delta domain.AnalyticsDelta) (bool, error)
+}
+
+// Projector turns poll events into the analytics projections: daily votes
+// per tag, hourly votes per poll and daily activity per user. Each event is
+// applied at most once, so redeliveries are harmless.
+type Projector struct {
+	store  Store
+	logger *zap.Logger
+}
+
+func NewProjector(store Store, logger *zap.Logger) *Projector {
+	return &Projector{store: store, logger: logger}
+}
+
+func (p *Projector) HandlePollCreated(ctx context.Context, poll *domain.Poll) error {
+	if poll.CreatedBy == nil {
+		return nil
+	}
+	return p.apply(ctx, domain.AnalyticsDelta{
+		EventKey:     "poll.created:" + poll.ID.String(),
+		PollID:       poll.ID,
+		UserID:       *poll.CreatedBy,
+		At:           poll.CreatedAt,
+		PollsCreated: 1,
+	})
+}
+
+func (p *Projector) HandlePollVoted(ctx context.Context, vote *domain.Vote) error {
+	return p.applyVote(ctx, "poll.voted:", vote, 1)
+}
+
+// HandleVoteDeleted takes the vote back out of the buckets it was counted
+// in, so the projections track the votes that still stand.
+func (p *Projector) HandleVoteDeleted(ctx context.Context, vote *domain.Vote) error {
+	return p.applyVote(ctx, "poll.vote.deleted:", vote, -1)
+}
+
+func (p *Projector) HandlePollSkipped(ctx context.Context, skip *domain.Skip) error {
+	return p.apply(ctx, domain.AnalyticsDelta{
+		EventKey: "poll.skipped:" + skip.ID.String(),
+		PollID:   skip.PollID,
+		UserID:   skip.UserID,
+		At:       skip.CreatedAt,
+		Skips:    1,
+	})
+}
+
+func (p *Projector) HandlePollUpdated(ctx context.Context, poll *domain.Poll) error {
+	return nil
+}
+
+func (p *Projector) HandleVoteUpdated(ctx context.Context, vote *domain.Vote) error {
+	return nil
+}
+
+func (p *Projector) HandleCollaboratorInvited(ctx context.Context, collaborator *domain.Collaborator) error {
+	return nil
+}
+
+func (p *Projector) HandlePollStatusChanged(ctx context.Context, change *domain.PollStatusChange) error {
+	return nil
+}
+
+// applyVote counts the vote under the poll's current tags. Votes on polls
+// that no longer exist still count towards the poll and the voter.
+func (p *Projector) applyVote(ctx context.Context, kind string, vote *domain.Vote, votes int) error {
+	var tags []string
+	poll, err := p.store.GetPollByID(ctx, vote.PollID)
+	switch {
+	case err == nil:
+		tags = poll.Tags
+	case !errors.Is(err, domain.ErrNotFound):
+		return fmt.Errorf("get poll: %w", err)
+	}
+
+	return p.apply(ctx, domain.AnalyticsDelta{
+		EventKey: kind + vote.ID.String(),
+		PollID:   vote.PollID,
+		UserID:   vote.UserID,
+		Tags:     tags,
+		At:       vote.CreatedAt,
+		Votes:    votes,
+	})
+}
+
+func (p *Projector) apply(ctx context.Context, delta domain.AnalyticsDelta) error {
+	applied, err := p.store.ApplyAnalyticsDelta(ctx, delta)
+	if err != nil {
+		return fmt.Errorf("apply %s: %w", delta.EventKey, err)
+	}
+	if !applied {
+		logging.For(ctx, p.logger).Debug("Skipping already projected event", zap.String("event", delta.EventKey))
+		return nil
+	}
+
+	switch {
+	case delta.Votes > 0:
+		metrics.AnalyticsVotes.WithLabelValues("cast").Add(float64(delta.Votes))
+	case delta.Votes < 0:
+		metrics.AnalyticsVotes.WithLabelValues("deleted").Add(float64(-delta.Votes))
+	}
+	if delta.PollsCreated > 0 {
+		metrics.AnalyticsPollsCreated.Add(float64(delta.PollsCreated))
+	}
+	return nil
+}
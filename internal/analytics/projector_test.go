@@ -0,0 +1,91 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/metrics"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeStore struct {
+	polls   map[uuid.UUID]*domain.Poll
+	applied map[string]domain.AnalyticsDelta
+}
+
+func (s *fakeStore) GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Poll, error) {
+	if poll, ok := s.polls[id]; ok {
+		return poll, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (s *fakeStore) ApplyAnalyticsDelta(ctx context.Context, delta domain.AnalyticsDelta) (bool, error) {
+	if _, ok := s.applied[delta.EventKey]; ok {
+		return false, nil
+	}
+	s.applied[delta.EventKey] = delta
+	return true, nil
+}
+
+func TestProjector(t *testing.T) {
+	creatorID := uuid.New()
+	poll := &domain.Poll{ID: uuid.New(), Tags: []string{"food", "lunch"}, CreatedBy: &creatorID,
+		CreatedAt: time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)}
+	store := &fakeStore{
+		polls:   map[uuid.UUID]*domain.Poll{poll.ID: poll},
+		applied: map[string]domain.AnalyticsDelta{},
+	}
+	projector := NewProjector(store, zap.NewNop())
+	ctx := context.Background()
+	cast := testutil.ToFloat64(metrics.AnalyticsVotes.WithLabelValues("cast"))
+	deleted := testutil.ToFloat64(metrics.AnalyticsVotes.WithLabelValues("deleted"))
+	created := testutil.ToFloat64(metrics.AnalyticsPollsCreated)
+
+	vote := &domain.Vote{ID: uuid.New(), PollID: poll.ID, UserID: uuid.New(),
+		CreatedAt: time.Date(2024, 6, 1, 9, 30, 0, 0, time.UTC)}
+	orphan := &domain.Vote{ID: uuid.New(), PollID: uuid.New(), UserID: vote.UserID, CreatedAt: vote.CreatedAt}
+	skip := &domain.Skip{ID: uuid.New(), PollID: poll.ID, UserID: vote.UserID, CreatedAt: vote.CreatedAt}
+
+	require.NoError(t, projector.HandlePollCreated(ctx, poll))
+	require.NoError(t, projector.HandlePollVoted(ctx, vote))
+	require.NoError(t, projector.HandlePollVoted(ctx, vote))
+	require.NoError(t, projector.HandlePollVoted(ctx, orphan))
+	require.NoError(t, projector.HandleVoteDeleted(ctx, vote))
+	require.NoError(t, projector.HandleVoteUpdated(ctx, vote))
+	require.NoError(t, projector.HandlePollSkipped(ctx, skip))
+
+	assert.Equal(t, map[string]domain.AnalyticsDelta{
+		"poll.created:" + poll.ID.String(): {
+			EventKey: "poll.created:" + poll.ID.String(), PollID: poll.ID, UserID: creatorID,
+			At: poll.CreatedAt, PollsCreated: 1,
+		},
+		"poll.voted:" + vote.ID.String(): {
+			EventKey: "poll.voted:" + vote.ID.String(), PollID: poll.ID, UserID: vote.UserID,
+			Tags: poll.Tags, At: vote.CreatedAt, Votes: 1,
+		},
+		"poll.voted:" + orphan.ID.String(): {
+			EventKey: "poll.voted:" + orphan.ID.String(), PollID: orphan.PollID, UserID: vote.UserID,
+			At: vote.CreatedAt, Votes: 1,
+		},
+		"poll.vote.deleted:" + vote.ID.String(): {
+			EventKey: "poll.vote.deleted:" + vote.ID.String(), PollID: poll.ID, UserID: vote.UserID,
+			Tags: poll.Tags, At: vote.CreatedAt, Votes: -1,
+		},
+		"poll.skipped:" + skip.ID.String(): {
+			EventKey: "poll.skipped:" + skip.ID.String(), PollID: poll.ID, UserID: vote.UserID,
+			At: vote.CreatedAt, Skips: 1,
+		},
+	}, store.applied)
+
+	// Redelivered events are not counted again.
+	assert.Equal(t, cast+2, testutil.ToFloat64(metrics.AnalyticsVotes.WithLabelValues("cast")))
+	assert.Equal(t, deleted+1, testutil.ToFloat64(metrics.AnalyticsVotes.WithLabelValues("deleted")))
+	assert.Equal(t, created+1, testutil.ToFloat64(metrics.AnalyticsPollsCreated))
+}
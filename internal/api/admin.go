@@ -0,0 +1,150 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// WithAdmins grants the given users access to the admin endpoints.
+func WithAdmins(ids []uuid.UUID) HandlerOption {
+	return func(h *Handler) {
+		h.admins = make(map[uuid.UUID]struct{}, len(ids))
+		for _, id := range ids {
+			h.admins[id] = struct{}{}
+		}
+	}
+}
+
+// grantConfiguredRoles raises the role of users listed in moderation.admins
+// or moderation.moderators, so they keep their access without a role in
+// the database.
+func (h *Handler) grantConfiguredRoles() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.CurrentUser(c)
+		if !ok || principal.Role == auth.RoleAdmin {
+			c.Next()
+			return
+		}
+		role := principal.Role
+		if _, ok := h.admins[principal.ID]; ok {
+			role = auth.RoleAdmin
+		} else if _, ok := h.moderators[principal.ID]; ok {
+			role = auth.RoleModerator
+		}
+		if role != principal.Role {
+			principal.Role = role
+			auth.SetCurrentUser(c, principal)
+		}
+		c.Next()
+	}
+}
+
+func (h *Handler) isAdmin(c *gin.Context) bool {
+	principal, ok := auth.CurrentUser(c)
+	return ok && principal.Role == auth.RoleAdmin
+}
+
+func (h *Handler) recountPollStats(c *gin.Context) error {
+	pollID, err := h.uuidParam(c, "id", "Invalid poll ID")
+	if err != nil {
+		return err
+	}
+
+	recount, err := h.service.RecountPollStats(c.Request.Context(), pollID)
+	if err != nil {
+		return describe(err, domain.ErrNotFound, "Poll not found")
+	}
+
+	logging.For(c.Request.Context(), h.logger).Info("poll stats recounted",
+		zap.String("poll_id", pollID.String()),
+		zap.Int("discrepancies", len(recount.Discrepancies)),
+	)
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"recount": recount,
+	})
+	return nil
+}
+
+func (h *Handler) setUserStanding(c *gin.Context) error {
+	userID, err := h.uuidParam(c, "id", "Invalid user ID")
+	if err != nil {
+		return err
+	}
+
+	var req domain.UserStandingRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.service.SetUserStanding(c.Request.Context(), userID, req.Standing); err != nil {
+		err = describe(err, domain.ErrInvalidInput, "Standing must be active, banned or shadow_banned")
+		return describe(err, domain.ErrNotFound, "User not found")
+	}
+
+	principal, _ := auth.CurrentUser(c)
+	logging.For(c.Request.Context(), h.logger).Info("user standing changed",
+		zap.String("user_id", userID.String()),
+		zap.String("standing", string(req.Standing)),
+		zap.String("admin_id", principal.ID.String()),
+	)
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"standing": req.Standing,
+	})
+	return nil
+}
+
+func (h *Handler) searchUsers(c *gin.Context) error {
+	var query domain.UserSearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		return badRequest("Invalid query parameters")
+	}
+
+	users, err := h.service.SearchUsers(c.Request.Context(), &query)
+	if err != nil {
+		return describe(err, domain.ErrInvalidInput, "Invalid role or standing")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   users,
+	})
+	return nil
+}
+
+func (h *Handler) forceDeletePoll(c *gin.Context) error {
+	pollID, err := h.uuidParam(c, "id", "Invalid poll ID")
+	if err != nil {
+		return err
+	}
+
+	principal, _ := auth.CurrentUser(c)
+	if err := h.service.ForceDeletePoll(c.Request.Context(), pollID, principal.ID); err != nil {
+		return describe(err, domain.ErrNotFound, "Poll not found")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+	})
+	return nil
+}
+
+func (h *Handler) getPlatformStats(c *gin.Context) error {
+	stats, err := h.service.GetPlatformStats(c.Request.Context())
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   stats,
+	})
+	return nil
+}
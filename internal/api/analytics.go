@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	analyticsDay = 24 * time.Hour
+
+	defaultDailyAnalyticsSpan  = 30 * analyticsDay
+	maxDailyAnalyticsSpan      = 366 * analyticsDay
+	defaultHourlyAnalyticsSpan = 7 * analyticsDay
+	maxHourlyAnalyticsSpan     = 31 * analyticsDay
+)
+
+// parseAnalyticsRange reads the from and to query parameters and widens them
+// to whole buckets of the given unit. Without them the range ends now and
+// spans defaultSpan; like the vote history filter, a plain "to" date covers
+// the whole day.
+func parseAnalyticsRange(c *gin.Context, unit, defaultSpan, maxSpan time.Duration) (time.Time, time.Time, error) {
+	to := time.Now().UTC()
+	if raw := c.Query("to"); raw != "" {
+		parsed, dateOnly, err := parseDateParam(raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+		}
+		to = parsed.UTC()
+		if dateOnly {
+			to = to.Add(analyticsDay)
+		}
+	}
+	from := to.Add(-defaultSpan)
+	if raw := c.Query("from"); raw != "" {
+		parsed, _, err := parseDateParam(raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+		}
+		from = parsed.UTC()
+	}
+
+	from = from.Truncate(unit)
+	if aligned := to.Truncate(unit); !aligned.Equal(to) {
+		to = aligned.Add(unit)
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+	if to.Sub(from) > maxSpan {
+		return time.Time{}, time.Time{}, fmt.Errorf("range must not exceed %d days", int(maxSpan/analyticsDay))
+	}
+	return from, to, nil
+}
+
+func (h *Handler) getTagVoteTrend(c *gin.Context) error {
+	from, to, err := parseAnalyticsRange(c, analyticsDay, defaultDailyAnalyticsSpan, maxDailyAnalyticsSpan)
+	if err != nil {
+		return badRequest(err.Error())
+	}
+
+	buckets, err := h.service.GetTagVoteTrend(c.Request.Context(), c.Param("tag"), from, to)
+	if err != nil {
+		return describe(err, domain.ErrInvalidInput, "Invalid tag")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"from":   from,
+		"to":     to,
+		"days":   buckets,
+	})
+	return nil
+}
+
+func (h *Handler) getPollVoteTimeline(c *gin.Context) error {
+	userID, pollID, err := h.pollManagementParams(c)
+	if err != nil {
+		return err
+	}
+
+	from, to, err := parseAnalyticsRange(c, time.Hour, defaultHourlyAnalyticsSpan, maxHourlyAnalyticsSpan)
+	if err != nil {
+		return badRequest(err.Error())
+	}
+
+	buckets, err := h.service.GetPollVoteTimeline(c.Request.Context(), pollID, userID, from, to)
+	if err != nil {
+		return pollManagementError(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"from":   from,
+		"to":     to,
+		"hours":  buckets,
+	})
+	return nil
+}
+
+func (h *Handler) getUserActivity(c *gin.Context) error {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		return unauthenticated()
+	}
+
+	from, to, err := parseAnalyticsRange(c, analyticsDay, defaultDailyAnalyticsSpan, maxDailyAnalyticsSpan)
+	if err != nil {
+		return badRequest(err.Error())
+	}
+
+	activity, err := h.service.GetUserActivity(c.Request.Context(), principal.ID, from, to)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"from":     from,
+		"to":       to,
+		"activity": activity,
+	})
+	return nil
+}
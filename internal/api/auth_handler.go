@@ -1,14 +1,15 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/behzadon/vote/internal/auth"
 	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
 	"github.com/behzadon/vote/internal/service"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -31,6 +32,14 @@ func (h *AuthHandler) RegisterRoutes(r *gin.Engine) {
 	{
 		auth.POST("/register", h.Register)
 		auth.POST("/login", h.Login)
+		auth.POST("/refresh", h.Refresh)
+		auth.POST("/logout", h.Logout)
+		auth.GET("/verify", h.VerifyEmail)
+		auth.POST("/verify/resend", h.AuthMiddleware(), h.ResendVerification)
+		auth.GET("/email/confirm", h.ConfirmEmailChange)
+		auth.POST("/email/change", h.AuthMiddleware(), h.RequestEmailChange)
+		auth.GET("/email/change", h.AuthMiddleware(), h.GetEmailChange)
+		auth.DELETE("/email/change", h.AuthMiddleware(), h.CancelEmailChange)
 		auth.GET("/profile", h.AuthMiddleware(), h.GetProfile)
 	}
 }
@@ -52,6 +61,16 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	if err := h.service.CreateUser(c.Request.Context(), user); err != nil {
+		var weak *domain.PasswordPolicyError
+		if errors.As(err, &weak) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":     "error",
+				"code":       "weak_password",
+				"message":    domain.ErrWeakPassword.Error(),
+				"violations": weak.Violations,
+			})
+			return
+		}
 		if err == domain.ErrEmailAlreadyExists {
 			c.JSON(http.StatusConflict, gin.H{
 				"status":  "error",
@@ -59,7 +78,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 			})
 			return
 		}
-		h.logger.Error("failed to create user", zap.Error(err))
+		logging.For(c.Request.Context(), h.logger).Error("failed to create user", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "failed to create user",
@@ -91,7 +110,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			})
 			return
 		}
-		h.logger.Error("failed to get user", zap.Error(err))
+		logging.For(c.Request.Context(), h.logger).Error("failed to get user", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "failed to login",
@@ -101,7 +120,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	token, err := h.jwtManager.GenerateToken(user)
 	if err != nil {
-		h.logger.Error("failed to generate token", zap.Error(err))
+		logging.For(c.Request.Context(), h.logger).Error("failed to generate token", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "failed to generate token",
@@ -109,15 +128,285 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	refreshToken, err := h.jwtManager.IssueRefreshToken(c.Request.Context(), user.ID)
+	if err != nil {
+		logging.For(c.Request.Context(), h.logger).Error("failed to issue refresh token", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "failed to generate token",
+		})
+		return
+	}
+
+	if err := h.service.RecordLogin(c.Request.Context(), user); err != nil {
+		logging.For(c.Request.Context(), h.logger).Warn("failed to record login", zap.Error(err))
+	}
+
+	response := gin.H{
 		"status": "success",
 		"token":  token,
+	}
+	if refreshToken != "" {
+		response["refreshToken"] = refreshToken
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req domain.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	pair, err := h.jwtManager.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrRefreshDisabled):
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"message": err.Error(),
+			})
+		case errors.Is(err, auth.ErrInvalidToken), errors.Is(err, auth.ErrExpiredToken):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"status":  "error",
+				"message": err.Error(),
+			})
+		default:
+			logging.For(c.Request.Context(), h.logger).Error("failed to refresh token", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "failed to refresh token",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":       "success",
+		"token":        pair.AccessToken,
+		"refreshToken": pair.RefreshToken,
+	})
+}
+
+// Logout revokes the refresh token. Access tokens already issued stay valid
+// until they expire.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req domain.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.jwtManager.RevokeRefreshToken(c.Request.Context(), req.RefreshToken); err != nil {
+		logging.For(c.Request.Context(), h.logger).Error("failed to revoke refresh token", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "failed to logout",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+	})
+}
+
+// VerifyEmail confirms the address of the user the link in the verification
+// mail was sent to.
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	err := h.service.VerifyEmail(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": err.Error(),
+			})
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"message": "verification link is invalid or has expired",
+			})
+		default:
+			logging.For(c.Request.Context(), h.logger).Error("failed to verify email", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "failed to verify email",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+	})
+}
+
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "unauthorized",
+		})
+		return
+	}
+
+	err := h.service.ResendEmailVerification(c.Request.Context(), principal.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			c.JSON(http.StatusConflict, gin.H{
+				"status":  "error",
+				"message": err.Error(),
+			})
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"message": "user not found",
+			})
+		default:
+			logging.For(c.Request.Context(), h.logger).Error("failed to resend email verification", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "failed to resend verification",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status": "success",
 	})
 }
 
+// RequestEmailChange starts moving the caller to a new address. It takes
+// effect once the links mailed to the current and the new address are both
+// opened.
+func (h *AuthHandler) RequestEmailChange(c *gin.Context) {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "unauthorized",
+		})
+		return
+	}
+
+	var req domain.EmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	change, err := h.service.RequestEmailChange(c.Request.Context(), principal.ID, &req)
+	if err != nil {
+		h.respondEmailChangeError(c, err, "failed to request email change")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status": "success",
+		"change": change,
+	})
+}
+
+func (h *AuthHandler) GetEmailChange(c *gin.Context) {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "unauthorized",
+		})
+		return
+	}
+
+	change, err := h.service.GetEmailChange(c.Request.Context(), principal.ID)
+	if err != nil {
+		h.respondEmailChangeError(c, err, "failed to get email change")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"change": change,
+	})
+}
+
+// ConfirmEmailChange records that one of the links of an email change was
+// opened. It needs no session, as the link may be opened on another device.
+func (h *AuthHandler) ConfirmEmailChange(c *gin.Context) {
+	change, err := h.service.ConfirmEmailChange(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		h.respondEmailChangeError(c, err, "failed to confirm email change")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"change": change,
+	})
+}
+
+func (h *AuthHandler) CancelEmailChange(c *gin.Context) {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "unauthorized",
+		})
+		return
+	}
+
+	if err := h.service.CancelEmailChange(c.Request.Context(), principal.ID); err != nil {
+		h.respondEmailChangeError(c, err, "failed to cancel email change")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+	})
+}
+
+func (h *AuthHandler) respondEmailChangeError(c *gin.Context, err error, failure string) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	case errors.Is(err, domain.ErrEmailAlreadyExists):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "no email change in progress, or the link is invalid or has expired",
+		})
+	default:
+		logging.For(c.Request.Context(), h.logger).Error(failure, zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": failure,
+		})
+	}
+}
+
 func (h *AuthHandler) GetProfile(c *gin.Context) {
-	userID, exists := c.Get("userID")
-	if !exists {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"status":  "error",
 			"message": "unauthorized",
@@ -125,7 +414,7 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	user, err := h.service.GetUserByID(c.Request.Context(), userID.(uuid.UUID))
+	user, err := h.service.GetUserByID(c.Request.Context(), principal.ID)
 	if err != nil {
 		if err == domain.ErrNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -134,7 +423,7 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 			})
 			return
 		}
-		h.logger.Error("failed to get user", zap.Error(err))
+		logging.For(c.Request.Context(), h.logger).Error("failed to get user", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "failed to get user profile",
@@ -142,15 +431,21 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
+	data := gin.H{
+		"id":            user.ID.String(),
+		"email":         user.Email,
+		"username":      user.Username,
+		"emailVerified": user.EmailVerified,
+		"createdAt":     user.CreatedAt.Format(time.RFC3339),
+		"updatedAt":     user.UpdatedAt.Format(time.RFC3339),
+	}
+	if user.AvatarURL != "" {
+		data["avatarUrl"] = user.AvatarURL
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
-		"data": gin.H{
-			"id":        user.ID.String(),
-			"email":     user.Email,
-			"username":  user.Username,
-			"createdAt": user.CreatedAt.Format(time.RFC3339),
-			"updatedAt": user.UpdatedAt.Format(time.RFC3339),
-		},
+		"data":   data,
 	})
 }
 
@@ -176,7 +471,7 @@ func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		c.Set("userID", claims.UserID)
+		auth.SetCurrentUser(c, claims.Principal())
 		c.Next()
 	}
 }
@@ -90,6 +90,38 @@ func TestAuthHandler_Register(t *testing.T) {
 	}
 }
 
+func TestAuthHandler_RegisterWeakPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(service.MockService)
+	logger, _ := zap.NewDevelopment()
+	handler := NewAuthHandler(mockService, new(auth.MockJWTManager), logger)
+	mockService.On("CreateUser", mock.Anything, mock.Anything).Return(&domain.PasswordPolicyError{
+		Violations: []*domain.ValidationError{
+			{Field: "password", Reason: "must be at least 8 characters"},
+			{Field: "password", Reason: "must contain a digit"},
+		},
+	})
+
+	body, _ := json.Marshal(domain.RegisterRequest{Email: "test@example.com", Password: "short", Username: "testuser"})
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router := gin.New()
+	router.POST("/api/auth/register", handler.Register)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"status": "error",
+		"code": "weak_password",
+		"message": "password does not meet the password policy",
+		"violations": [
+			{"field": "password", "reason": "must be at least 8 characters"},
+			{"field": "password", "reason": "must contain a digit"}
+		]
+	}`, w.Body.String())
+}
+
 func TestAuthHandler_Login(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := new(service.MockService)
@@ -121,11 +153,14 @@ func TestAuthHandler_Login(t *testing.T) {
 			mockSetup: func() {
 				mockService.On("GetUserByEmail", mock.Anything, "test@example.com").Return(user, nil)
 				mockJWTManager.On("GenerateToken", user).Return("test-token", nil)
+				mockJWTManager.On("IssueRefreshToken", mock.Anything, userID).Return("test-refresh-token", nil)
+				mockService.On("RecordLogin", mock.Anything, user).Return(nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
-				"status": "success",
-				"token":  "test-token",
+				"status":       "success",
+				"token":        "test-token",
+				"refreshToken": "test-refresh-token",
 			},
 		},
 		{
@@ -168,6 +203,171 @@ func TestAuthHandler_Login(t *testing.T) {
 	}
 }
 
+func TestAuthHandler_Refresh(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(service.MockService)
+	mockJWTManager := new(auth.MockJWTManager)
+	logger, _ := zap.NewDevelopment()
+	handler := NewAuthHandler(mockService, mockJWTManager, logger)
+
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func()
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name: "successful refresh",
+			body: `{"refreshToken": "valid"}`,
+			mockSetup: func() {
+				mockJWTManager.On("Refresh", mock.Anything, "valid").Return(&auth.TokenPair{
+					AccessToken:  "new-token",
+					RefreshToken: "new-refresh-token",
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"status":       "success",
+				"token":        "new-token",
+				"refreshToken": "new-refresh-token",
+			},
+		},
+		{
+			name: "reused token",
+			body: `{"refreshToken": "used"}`,
+			mockSetup: func() {
+				mockJWTManager.On("Refresh", mock.Anything, "used").Return(nil, auth.ErrRefreshTokenReused)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody: map[string]interface{}{
+				"status":  "error",
+				"message": auth.ErrRefreshTokenReused.Error(),
+			},
+		},
+		{
+			name: "expired token",
+			body: `{"refreshToken": "expired"}`,
+			mockSetup: func() {
+				mockJWTManager.On("Refresh", mock.Anything, "expired").Return(nil, auth.ErrExpiredToken)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody: map[string]interface{}{
+				"status":  "error",
+				"message": auth.ErrExpiredToken.Error(),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/auth/refresh", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router := gin.New()
+			router.POST("/api/auth/refresh", handler.Refresh)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedBody, response)
+		})
+	}
+}
+
+func TestAuthHandler_Logout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(service.MockService)
+	mockJWTManager := new(auth.MockJWTManager)
+	logger, _ := zap.NewDevelopment()
+	handler := NewAuthHandler(mockService, mockJWTManager, logger)
+	mockJWTManager.On("RevokeRefreshToken", mock.Anything, "valid").Return(nil)
+
+	router := gin.New()
+	router.POST("/api/auth/logout", handler.Logout)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/logout", bytes.NewBufferString(`{"refreshToken": "valid"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockJWTManager.AssertExpectations(t)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/api/auth/logout", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAuthHandler_VerifyEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(service.MockService)
+	mockJWTManager := new(auth.MockJWTManager)
+	logger, _ := zap.NewDevelopment()
+	handler := NewAuthHandler(mockService, mockJWTManager, logger)
+	mockService.On("VerifyEmail", mock.Anything, "good").Return(nil)
+	mockService.On("VerifyEmail", mock.Anything, "stale").Return(domain.ErrNotFound)
+	mockService.On("VerifyEmail", mock.Anything, "").Return(domain.ErrInvalidInput)
+
+	router := gin.New()
+	router.GET("/api/auth/verify", handler.VerifyEmail)
+
+	for token, status := range map[string]int{
+		"good":  http.StatusOK,
+		"stale": http.StatusNotFound,
+		"":      http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/auth/verify?token="+token, nil))
+		assert.Equal(t, status, w.Code, token)
+	}
+}
+
+func TestAuthHandler_EmailChange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(service.MockService)
+	logger, _ := zap.NewDevelopment()
+	handler := NewAuthHandler(mockService, new(auth.MockJWTManager), logger)
+	userID := uuid.New()
+	change := &domain.EmailChange{ID: uuid.New(), UserID: userID, NewEmail: "new@example.com", Status: domain.EmailChangePending}
+	mockService.On("RequestEmailChange", mock.Anything, userID, &domain.EmailChangeRequest{Email: "new@example.com"}).Return(change, nil)
+	mockService.On("RequestEmailChange", mock.Anything, userID, &domain.EmailChangeRequest{Email: "taken@example.com"}).Return(nil, domain.ErrEmailAlreadyExists)
+	mockService.On("ConfirmEmailChange", mock.Anything, "good").Return(change, nil)
+	mockService.On("ConfirmEmailChange", mock.Anything, "stale").Return(nil, domain.ErrNotFound)
+	mockService.On("CancelEmailChange", mock.Anything, userID).Return(domain.ErrNotFound)
+
+	router := gin.New()
+	signedIn := func(c *gin.Context) { auth.SetCurrentUser(c, auth.Principal{ID: userID}) }
+	router.POST("/api/auth/email/change", signedIn, handler.RequestEmailChange)
+	router.DELETE("/api/auth/email/change", signedIn, handler.CancelEmailChange)
+	router.GET("/api/auth/email/confirm", handler.ConfirmEmailChange)
+
+	for _, tt := range []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodPost, "/api/auth/email/change", `{"email": "new@example.com"}`, http.StatusAccepted},
+		{http.MethodPost, "/api/auth/email/change", `{"email": "taken@example.com"}`, http.StatusConflict},
+		{http.MethodPost, "/api/auth/email/change", `{"email": "not an address"}`, http.StatusBadRequest},
+		{http.MethodDelete, "/api/auth/email/change", "", http.StatusNotFound},
+		{http.MethodGet, "/api/auth/email/confirm?token=good", "", http.StatusOK},
+		{http.MethodGet, "/api/auth/email/confirm?token=stale", "", http.StatusNotFound},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tt.status, w.Code, tt.method+" "+tt.path+" "+tt.body)
+	}
+}
+
 func TestAuthHandler_GetProfile(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := new(service.MockService)
@@ -201,11 +401,12 @@ func TestAuthHandler_GetProfile(t *testing.T) {
 			expectedBody: map[string]interface{}{
 				"status": "success",
 				"data": map[string]interface{}{
-					"id":        user.ID.String(),
-					"email":     user.Email,
-					"username":  user.Username,
-					"createdAt": user.CreatedAt.Format(time.RFC3339),
-					"updatedAt": user.UpdatedAt.Format(time.RFC3339),
+					"id":            user.ID.String(),
+					"email":         user.Email,
+					"username":      user.Username,
+					"emailVerified": false,
+					"createdAt":     user.CreatedAt.Format(time.RFC3339),
+					"updatedAt":     user.UpdatedAt.Format(time.RFC3339),
 				},
 			},
 		},
@@ -232,7 +433,7 @@ func TestAuthHandler_GetProfile(t *testing.T) {
 
 			router := gin.New()
 			router.GET("/api/auth/profile", func(c *gin.Context) {
-				c.Set("userID", tt.userID)
+				auth.SetCurrentUser(c, auth.Principal{ID: tt.userID})
 				handler.GetProfile(c)
 			})
 
@@ -275,6 +476,7 @@ func TestAuthHandler_AuthMiddleware(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
 				"status": "success",
+				"userId": userID.String(),
 			},
 		},
 		{
@@ -314,7 +516,10 @@ func TestAuthHandler_AuthMiddleware(t *testing.T) {
 
 			router := gin.New()
 			router.GET("/api/auth/profile", handler.AuthMiddleware(), func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{"status": "success"})
+				// The principal must also reach code that only sees the
+				// request context.
+				principal, _ := auth.CurrentUser(c.Request.Context())
+				c.JSON(http.StatusOK, gin.H{"status": "success", "userId": principal.ID.String()})
 			})
 
 			router.ServeHTTP(w, req)
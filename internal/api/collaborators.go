@@ -0,0 +1,213 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) updatePoll(c *gin.Context) error {
+	userID, pollID, err := h.pollManagementParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.UpdatePollRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	req.ActorID = userID
+	req.Admin = h.isAdmin(c)
+
+	poll, err := h.service.UpdatePoll(c.Request.Context(), pollID, &req)
+	if err != nil {
+		return pollManagementError(err)
+	}
+
+	poll.Links = h.urls.PollLinks(poll)
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"poll":   poll,
+	})
+	return nil
+}
+
+func (h *Handler) updatePollTags(c *gin.Context) error {
+	userID, pollID, err := h.pollManagementParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.UpdatePollTagsRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	req.ActorID = userID
+	req.Admin = h.isAdmin(c)
+
+	poll, err := h.service.UpdatePollTags(c.Request.Context(), pollID, &req)
+	if err != nil {
+		return pollManagementError(err)
+	}
+
+	poll.Links = h.urls.PollLinks(poll)
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"poll":   poll,
+	})
+	return nil
+}
+
+func (h *Handler) reorderOptions(c *gin.Context) error {
+	userID, pollID, err := h.pollManagementParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.ReorderOptionsRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	req.ActorID = userID
+	req.Admin = h.isAdmin(c)
+
+	poll, err := h.service.ReorderOptions(c.Request.Context(), pollID, &req)
+	if err != nil {
+		return pollManagementError(err)
+	}
+
+	poll.Links = h.urls.PollLinks(poll)
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"poll":   poll,
+	})
+	return nil
+}
+
+func (h *Handler) closePoll(c *gin.Context) error {
+	userID, pollID, err := h.pollManagementParams(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.ClosePoll(c.Request.Context(), pollID, userID, h.isAdmin(c)); err != nil {
+		return pollManagementError(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+	})
+	return nil
+}
+
+func (h *Handler) changePollStatus(c *gin.Context) error {
+	userID, pollID, err := h.pollManagementParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.PollStatusRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	req.ActorID = userID
+	req.Admin = h.isAdmin(c)
+
+	poll, err := h.service.ChangePollStatus(c.Request.Context(), pollID, &req)
+	if err != nil {
+		return pollManagementError(err)
+	}
+
+	poll.Links = h.urls.PollLinks(poll)
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"poll":   poll,
+	})
+	return nil
+}
+
+func (h *Handler) getPollOwnerStats(c *gin.Context) error {
+	userID, pollID, err := h.pollManagementParams(c)
+	if err != nil {
+		return err
+	}
+
+	stats, err := h.service.GetPollOwnerStats(c.Request.Context(), pollID, userID)
+	if err != nil {
+		return pollManagementError(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"stats":  stats,
+	})
+	return nil
+}
+
+func (h *Handler) addPollCollaborator(c *gin.Context) error {
+	userID, pollID, err := h.pollManagementParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.AddCollaboratorRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	req.ActorID = userID
+
+	collaborator, err := h.service.AddPollCollaborator(c.Request.Context(), pollID, &req)
+	if err != nil {
+		return pollManagementError(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":       "success",
+		"collaborator": collaborator,
+	})
+	return nil
+}
+
+func (h *Handler) removePollCollaborator(c *gin.Context) error {
+	userID, pollID, err := h.pollManagementParams(c)
+	if err != nil {
+		return err
+	}
+
+	collaboratorID, err := h.uuidParam(c, "userId", "Invalid user ID")
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.RemovePollCollaborator(c.Request.Context(), pollID, collaboratorID, userID); err != nil {
+		return pollManagementError(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+	})
+	return nil
+}
+
+// pollManagementParams returns the caller and the poll named in the path of
+// a poll management route.
+func (h *Handler) pollManagementParams(c *gin.Context) (uuid.UUID, uuid.UUID, error) {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, unauthenticated()
+	}
+
+	pollID, err := h.uuidParam(c, "id", "Invalid poll ID")
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return principal.ID, pollID, nil
+}
+
+func pollManagementError(err error) error {
+	return describe(err, domain.ErrForbidden, "Not allowed to manage this poll")
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// RequireConsent answers 428 Precondition Required until the user has
+// accepted the current terms of service and privacy policy.
+func (h *Handler) RequireConsent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.CurrentUser(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		status, err := h.service.GetConsentStatus(c.Request.Context(), principal.ID)
+		if err != nil {
+			// Every user passed the check when they last accepted, so a
+			// database outage lets the request through instead of locking
+			// everyone out.
+			logging.For(c.Request.Context(), h.logger).Warn("consent check failed, allowing request",
+				zap.Error(err),
+				zap.String("user_id", principal.ID.String()),
+			)
+			c.Next()
+			return
+		}
+		if len(status.Pending) > 0 {
+			c.AbortWithStatusJSON(http.StatusPreconditionRequired, gin.H{
+				"status":  "error",
+				"message": domain.ErrConsentRequired.Error(),
+				"consent": gin.H{
+					"required": status.Required,
+					"pending":  status.Pending,
+				},
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+func (h *Handler) getUserConsents(c *gin.Context) error {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		return unauthenticated()
+	}
+
+	return h.respondConsentStatus(c, principal.ID)
+}
+
+func (h *Handler) acceptConsents(c *gin.Context) error {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		return unauthenticated()
+	}
+
+	var req domain.AcceptConsentRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	req.UserID = principal.ID
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	status, err := h.service.AcceptConsents(c.Request.Context(), &req)
+	if err != nil {
+		return describe(err, domain.ErrInvalidInput, "Only the current terms and privacy policy versions can be accepted")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"consent": status,
+	})
+	return nil
+}
+
+func (h *Handler) getUserConsentHistory(c *gin.Context) error {
+	userID, err := h.uuidParam(c, "id", "Invalid user ID")
+	if err != nil {
+		return err
+	}
+
+	return h.respondConsentStatus(c, userID)
+}
+
+func (h *Handler) respondConsentStatus(c *gin.Context, userID uuid.UUID) error {
+	status, err := h.service.GetConsentStatus(c.Request.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"consent": status,
+	})
+	return nil
+}
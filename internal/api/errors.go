@@ -0,0 +1,152 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// errorHandler is a handler that reports failure by returning an error.
+// handle turns the error into the response.
+type errorHandler func(c *gin.Context) error
+
+// handle adapts fn to gin, answering the error it returns with
+// respondError.
+func (h *Handler) handle(fn errorHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := fn(c); err != nil {
+			h.respondError(c, err)
+		}
+	}
+}
+
+// statusByClass answers each class of domain.ErrorKinds.
+var statusByClass = map[domain.ErrorClass]int{
+	domain.ClassInvalid:              http.StatusBadRequest,
+	domain.ClassUnauthenticated:      http.StatusUnauthorized,
+	domain.ClassForbidden:            http.StatusForbidden,
+	domain.ClassNotFound:             http.StatusNotFound,
+	domain.ClassAlreadyExists:        http.StatusConflict,
+	domain.ClassConflict:             http.StatusConflict,
+	domain.ClassTooLarge:             http.StatusRequestEntityTooLarge,
+	domain.ClassUnsupportedType:      http.StatusUnsupportedMediaType,
+	domain.ClassPreconditionRequired: http.StatusPreconditionRequired,
+	domain.ClassLimited:              http.StatusTooManyRequests,
+	domain.ClassUnavailable:          http.StatusServiceUnavailable,
+}
+
+// apiError is an error answered with its own status or message, for
+// problems found by the handler itself and for messages that name the
+// resource involved.
+type apiError struct {
+	status  int
+	code    string
+	message string
+	err     error
+}
+
+func (e *apiError) Error() string {
+	if e.err != nil {
+		return e.message + ": " + e.err.Error()
+	}
+	return e.message
+}
+
+func (e *apiError) Unwrap() error {
+	return e.err
+}
+
+func badRequest(message string) error {
+	return &apiError{status: http.StatusBadRequest, code: "invalid_input", message: message}
+}
+
+func unauthenticated() error {
+	return &apiError{status: http.StatusUnauthorized, code: "unauthenticated", message: "user not authenticated"}
+}
+
+// uuidParam parses the path parameter key, given in the public ID form,
+// answering with invalid when it is not one.
+func (h *Handler) uuidParam(c *gin.Context, key, invalid string) (uuid.UUID, error) {
+	id, err := h.ids.Decode(c.Param(key))
+	if err != nil {
+		return uuid.Nil, badRequest(invalid)
+	}
+	return id, nil
+}
+
+func bindJSON(c *gin.Context, obj interface{}) error {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		return badRequest("Invalid request body")
+	}
+	return nil
+}
+
+// bindOptionalJSON is bindJSON for requests whose body may be left out. It
+// reports whether there was a body, telling an empty one by reading it, as
+// chunked requests have no Content-Length.
+func bindOptionalJSON(c *gin.Context, obj interface{}) (bool, error) {
+	if c.Request.Body == nil {
+		return false, nil
+	}
+	if err := c.ShouldBindJSON(obj); err != nil {
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		return false, badRequest("Invalid request body")
+	}
+	return true, nil
+}
+
+// describe answers err with message instead of the default one when it is
+// or wraps target.
+func describe(err, target error, message string) error {
+	if err == nil || !errors.Is(err, target) {
+		return err
+	}
+	return &apiError{message: message, err: err}
+}
+
+// respondError answers err with the status of its class and its code in
+// domain.ErrorKinds. Anything unclassified is logged and answered with 500.
+func (h *Handler) respondError(c *gin.Context, err error) {
+	status, code, message := http.StatusInternalServerError, "internal", "Internal server error"
+
+	var apiErr *apiError
+	hasAPIErr := errors.As(err, &apiErr)
+	if hasAPIErr && apiErr.status != 0 {
+		status, code, message = apiErr.status, apiErr.code, apiErr.message
+	} else {
+		if kind, ok := domain.ClassifyError(err); ok {
+			status, code, message = statusByClass[kind.Class], kind.Code, kind.Message
+			if message == "" {
+				message = err.Error()
+			}
+		}
+		if hasAPIErr && status != http.StatusInternalServerError {
+			message = apiErr.message
+		}
+	}
+
+	if status >= http.StatusInternalServerError {
+		fields := []zap.Field{
+			zap.Error(err),
+			zap.String("route", c.FullPath()),
+		}
+		for _, param := range c.Params {
+			fields = append(fields, zap.String(param.Key, param.Value))
+		}
+		logging.For(c.Request.Context(), h.logger).Error("request failed", fields...)
+	}
+
+	c.JSON(status, gin.H{
+		"status":  "error",
+		"code":    code,
+		"message": message,
+	})
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+const geoLocationKey = "geo_location"
+
+func WithGeoLocator(l domain.GeoLocator) HandlerOption {
+	return func(h *Handler) {
+		h.geo = l
+	}
+}
+
+// GeoIP resolves the client address to a coarse location for the handlers
+// after it. The address itself is not kept.
+func (h *Handler) GeoIP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.geo == nil {
+			c.Next()
+			return
+		}
+		if ip := net.ParseIP(c.ClientIP()); ip != nil {
+			if location, ok := h.geo.Locate(ip); ok {
+				c.Set(geoLocationKey, location)
+			}
+		}
+		c.Next()
+	}
+}
+
+func geoLocation(c *gin.Context) *domain.GeoLocation {
+	value, exists := c.Get(geoLocationKey)
+	if !exists {
+		return nil
+	}
+	location, ok := value.(domain.GeoLocation)
+	if !ok {
+		return nil
+	}
+	return &location
+}
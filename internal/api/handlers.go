@@ -1,17 +1,25 @@
 package api
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
 	"io"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/behzadon/vote/internal/auth"
 	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/health"
+	"github.com/behzadon/vote/internal/logging"
 	"github.com/behzadon/vote/internal/metrics"
+	"github.com/behzadon/vote/internal/middleware"
+	"github.com/behzadon/vote/internal/publicid"
+	"github.com/behzadon/vote/internal/quota"
 	"github.com/behzadon/vote/internal/service"
+	"github.com/behzadon/vote/internal/voteimport"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -23,102 +31,259 @@ type Handler struct {
 	logger      *zap.Logger
 	rateLimiter *RateLimiter
 	authHandler *AuthHandler
+	quotas      *quota.Manager
+	moderators  map[uuid.UUID]struct{}
+	admins      map[uuid.UUID]struct{}
+	geo         domain.GeoLocator
+	urls        URLBuilder
+	ids         publicid.Codec
+	feedStream  FeedStream
+	importer    *voteimport.Importer
+	health      *health.Status
 }
 
-func NewHandler(service service.Service, redis RedisClient, logger *zap.Logger, authHandler *AuthHandler) *Handler {
-	return &Handler{
+func NewHandler(service service.Service, redis RedisClient, logger *zap.Logger, authHandler *AuthHandler, opts ...HandlerOption) *Handler {
+	h := &Handler{
 		service:     service,
 		logger:      logger,
 		rateLimiter: NewRateLimiter(redis, logger),
 		authHandler: authHandler,
+		urls:        NewURLBuilder(""),
+		ids:         publicid.UUIDs{},
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
-func (h *Handler) RegisterRoutes(r *gin.Engine, jwtManager *auth.JWTManager) {
+func (h *Handler) RegisterRoutes(r *gin.Engine, jwtManager *auth.JWTManager, authOpts ...auth.MiddlewareOption) {
 	r.Use(metrics.MetricsMiddleware())
+	if h.encodesIDs() {
+		r.Use(h.publicIDs())
+	}
 
 	r.POST("/api/auth/register", h.authHandler.Register)
 	r.POST("/api/auth/login", h.authHandler.Login)
-	r.GET("/api/polls/:id/stats", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.getPollStats)
+	r.POST("/api/auth/refresh", h.authHandler.Refresh)
+	r.POST("/api/auth/logout", h.authHandler.Logout)
+	r.GET("/api/auth/verify", h.authHandler.VerifyEmail)
+	r.GET("/api/auth/email/confirm", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.authHandler.ConfirmEmailChange)
+	r.GET("/api/polls/:id/stats", auth.OptionalAuthMiddleware(jwtManager), h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getPollStats))
+	r.GET("/api/polls/:id/stats/wait", auth.OptionalAuthMiddleware(jwtManager), h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.waitPollStats))
+	r.GET("/api/polls/:id/results", h.handle(h.getPublicResults))
+	r.GET("/api/polls/:id/og", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getPollPreview))
+	r.GET("/api/polls/:id/og/image.png", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getPollPreviewImage))
+	r.GET("/api/polls/:id/winner", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getPollWinner))
+	r.GET("/api/results/key", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getResultSigningKey))
 
 	api := r.Group("/api")
-	api.Use(auth.AuthMiddleware(jwtManager))
+	api.Use(auth.AuthMiddleware(jwtManager, authOpts...), h.grantConfiguredRoles())
+	api.POST("/auth/verify/resend", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.authHandler.ResendVerification)
+	api.POST("/auth/email/change", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.authHandler.RequestEmailChange)
+	api.GET("/auth/email/change", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.authHandler.GetEmailChange)
+	api.DELETE("/auth/email/change", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.authHandler.CancelEmailChange)
+	// The consent routes come before RequireConsent so users can still read
+	// and accept the current terms once a version bump locks them out.
+	api.GET("/users/me/consents", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getUserConsents))
+	api.POST("/users/me/consents", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.acceptConsents))
+	api.Use(h.RequireConsent())
 	{
-		api.POST("/polls", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.createPoll)
-		api.GET("/polls", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.getPollsForFeed)
-		api.GET("/polls/:id", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.getPollByID)
-		api.POST("/polls/:id/vote", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.voteOnPoll)
-		api.POST("/polls/:id/skip", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.skipPoll)
-		api.GET("/users/me/votes", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.getUserVotes)
-		api.PUT("/users/me/votes/:voteId", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.updateVote)
-		api.DELETE("/users/me/votes/:voteId", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.deleteVote)
+		api.POST("/polls", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.QuotaLimit(domain.QuotaPollsCreated), h.handle(h.createPoll))
+		api.POST("/polls/validate", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.validatePoll))
+		api.GET("/polls", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getPollsForFeed))
+		api.GET("/feed/stream", h.rateLimiter.RateLimit(), h.streamFeed)
+		api.GET("/polls/search", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.searchPolls))
+		api.GET("/polls/:id", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getPollByID))
+		api.POST("/polls/:id/vote", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.QuotaLimit(domain.QuotaVotesCast), h.GeoIP(), h.handle(h.voteOnPoll))
+		api.POST("/polls/:id/skip", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.skipPoll))
+		api.GET("/users/me/votes", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getUserVotes))
+		api.PUT("/users/me/votes/:voteId", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.updateVote))
+		api.DELETE("/users/me/votes/:voteId", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.deleteVote))
+		api.GET("/votes/:id/verify", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.verifyVote))
+		api.GET("/users/me/quotas", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.getUserQuotas)
+		api.GET("/users/me/limits", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.getUserLimits)
+		api.GET("/users/me/activity", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getUserActivity))
+		api.GET("/users/me/preferences", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getUserPreferences))
+		api.PUT("/users/me/preferences", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.updateUserPreferences))
+		api.PUT("/users/me/avatar", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.uploadAvatar))
+		api.GET("/polls/:id/tally", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getElectionTally))
+		api.GET("/polls/:id/certificate", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getResultCertificate))
+		api.PATCH("/polls/:id", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.updatePoll))
+		api.PATCH("/polls/:id/tags", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.updatePollTags))
+		api.POST("/polls/:id/close", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.closePoll))
+		api.PUT("/polls/:id/options/:index/image", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.uploadOptionImage))
+		api.PATCH("/polls/:id/options/order", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.reorderOptions))
+		api.PATCH("/polls/:id/options/:index", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.updateOption))
+		api.POST("/uploads/sign", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.signUpload))
+		api.POST("/uploads/confirm", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.confirmUpload))
+		api.PUT("/polls/:id/status", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.changePollStatus))
+		api.GET("/polls/:id/votes/export", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.exportPollVotes)
+		api.GET("/polls/:id/owner-stats", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getPollOwnerStats))
+		api.GET("/polls/:id/analytics/hourly", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getPollVoteTimeline))
+		api.POST("/polls/:id/collaborators", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.addPollCollaborator))
+		api.DELETE("/polls/:id/collaborators/:userId", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.removePollCollaborator))
+		api.POST("/orgs", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.createOrganization))
+		api.POST("/orgs/:id/members", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.addOrganizationMember))
+		api.POST("/polls/:id/organization-vote", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.GeoIP(), h.handle(h.castOrganizationVote))
+		api.GET("/tags/aliases", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getTagAliases))
+		api.GET("/tags/rules", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getTagRules))
+
+		moderation := api.Group("/moderation", middleware.RequireRole(auth.RoleModerator))
+		moderation.POST("/tags/aliases", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.createTagAlias))
+		moderation.POST("/tags/merge", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.mergeTags))
+		moderation.GET("/flags", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getModerationFlags))
+		moderation.POST("/flags/:id/resolve", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.resolveModerationFlag))
+
+		admin := api.Group("/admin", middleware.RequireRole(auth.RoleAdmin))
+		admin.POST("/polls/:id/recount", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.recountPollStats))
+		admin.POST("/votes/import", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.importVotes)
+		admin.GET("/users", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.searchUsers))
+		admin.PUT("/users/:id/standing", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.setUserStanding))
+		admin.DELETE("/polls/:id", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.forceDeletePoll))
+		admin.GET("/stats", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getPlatformStats))
+		admin.GET("/users/:id/consents", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getUserConsentHistory))
+		admin.GET("/analytics/tags/:tag", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.getTagVoteTrend))
+		admin.PUT("/tags/:tag/rule", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.setTagRule))
+		admin.DELETE("/tags/:tag/rule", h.rateLimiter.RateLimit(), h.rateLimiter.BurstLimit(), h.handle(h.deleteTagRule))
 	}
 
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	r.GET("/healthz", h.liveness)
+	r.GET("/readyz", h.readiness)
+	r.GET("/api/openapi.json", h.openAPI)
+	r.GET("/docs", h.docs)
 }
 
-func (h *Handler) createPoll(c *gin.Context) {
-	var req struct {
-		Title   string   `json:"title" binding:"required"`
-		Options []string `json:"options" binding:"required,min=2"`
-		Tags    []string `json:"tags" binding:"required,min=1"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "Invalid request body",
-		})
-		return
+// createPollBody is the body of a poll creation request.
+type createPollBody struct {
+	Title      string   `json:"title" binding:"required"`
+	Options    []string `json:"options" binding:"required,min=2"`
+	Tags       []string `json:"tags" binding:"required,min=1"`
+	AccessCode string   `json:"accessCode"`
+
+	OptionEmojis []string `json:"optionEmojis"`
+
+	OrganizationID *uuid.UUID `json:"organizationId"`
+	EligibleEmails []string   `json:"eligibleEmails"`
+
+	Kind     domain.PollKind `json:"kind"`
+	StartsAt *time.Time      `json:"startsAt"`
+	EndsAt   *time.Time      `json:"endsAt"`
+
+	PublicResults     bool                     `json:"publicResults"`
+	ResultsVisibility domain.ResultsVisibility `json:"resultsVisibility"`
+	VoteChange        domain.VoteChangePolicy  `json:"voteChange"`
+	OrganizationVotes bool                     `json:"organizationVotes"`
+	Draft             bool                     `json:"draft"`
+
+	VoteChangeCooldownSeconds int `json:"voteChangeCooldownSeconds"`
+
+	Anonymous        bool     `json:"anonymous"`
+	AllowedCountries []string `json:"allowedCountries"`
+	DefaultOption    *int     `json:"defaultOption"`
+
+	Webhook *domain.PollWebhookRequest `json:"webhook"`
+}
+
+func (h *Handler) createPoll(c *gin.Context) error {
+	var req createPollBody
+	if err := bindJSON(c, &req); err != nil {
+		return err
 	}
 
 	serviceReq := &domain.CreatePollRequest{
-		Title:   req.Title,
-		Options: req.Options,
-		Tags:    req.Tags,
+		Title:      req.Title,
+		Options:    req.Options,
+		Tags:       req.Tags,
+		AccessCode: req.AccessCode,
+
+		OptionEmojis: req.OptionEmojis,
+
+		OrganizationID: req.OrganizationID,
+		EligibleEmails: req.EligibleEmails,
+
+		Kind:     req.Kind,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+
+		PublicResults:     req.PublicResults,
+		ResultsVisibility: req.ResultsVisibility,
+		VoteChange:        req.VoteChange,
+		OrganizationVotes: req.OrganizationVotes,
+		Draft:             req.Draft,
+
+		VoteChangeCooldownSeconds: req.VoteChangeCooldownSeconds,
+
+		Anonymous:        req.Anonymous,
+		AllowedCountries: req.AllowedCountries,
+		DefaultOption:    req.DefaultOption,
+
+		Webhook: req.Webhook,
+	}
+	if principal, ok := auth.CurrentUser(c); ok {
+		serviceReq.CreatorID = principal.ID
+	}
+	poll, err := h.service.CreatePoll(c.Request.Context(), serviceReq)
+	if errors.Is(err, domain.ErrCreationLimitExceeded) {
+		h.rejectCreationLimit(c, err)
+		return nil
 	}
-	pollID, err := h.service.CreatePoll(c.Request.Context(), serviceReq)
 	if err != nil {
-		h.logger.Error("failed to create poll",
-			zap.Error(err),
-			zap.String("title", req.Title),
-		)
-		switch {
-		case errors.Is(err, domain.ErrInvalidInput):
-			c.JSON(http.StatusBadRequest, gin.H{
-				"status":  "error",
-				"message": err.Error(),
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status":  "error",
-				"message": "Failed to create poll",
-			})
-		}
-		return
+		return describe(err, domain.ErrForbidden, "Only organization admins can create organization polls")
 	}
+	poll.Links = h.urls.PollLinks(poll)
+	c.Header("Location", poll.Links.Self)
 	c.JSON(http.StatusCreated, gin.H{
 		"status":  "success",
-		"poll_id": pollID.String(),
+		"poll_id": poll.ID.String(),
+		"poll":    poll,
 	})
+	return nil
 }
 
-func (h *Handler) getPollsForFeed(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  "error",
-			"message": "user not authenticated",
-		})
-		return
+// validatePoll checks a draft against the creation rules without saving it.
+// The body is decoded without binding tags so that missing fields come back
+// as field errors rather than a bare 400.
+func (h *Handler) validatePoll(c *gin.Context) error {
+	var req domain.CreatePollRequest
+	if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+		return badRequest("Invalid request body")
+	}
+	if principal, ok := auth.CurrentUser(c); ok {
+		req.CreatorID = principal.ID
 	}
 
-	userUUID, ok := userID.(uuid.UUID)
+	errs, err := h.service.ValidatePoll(c.Request.Context(), &req)
+	if err != nil {
+		return err
+	}
+	if errs == nil {
+		errs = []*domain.ValidationError{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"valid":  len(errs) == 0,
+		"errors": errs,
+	})
+	return nil
+}
+
+// feedData is a feed page. The total is left out when the client asked
+// for none.
+type feedData struct {
+	Polls          []domain.Poll `json:"polls"`
+	Page           int           `json:"page"`
+	Limit          int           `json:"limit"`
+	NextCursor     string        `json:"nextCursor"`
+	Total          *int          `json:"total,omitempty"`
+	TotalEstimated *bool         `json:"totalEstimated,omitempty"`
+}
+
+func (h *Handler) getPollsForFeed(c *gin.Context) error {
+	principal, ok := auth.CurrentUser(c)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "invalid user id",
-		})
-		return
+		return unauthenticated()
 	}
 
 	tag := c.Query("tag")
@@ -127,314 +292,325 @@ func (h *Handler) getPollsForFeed(c *gin.Context) {
 
 	page, err := strconv.Atoi(pageStr)
 	if err != nil || page < 1 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "invalid page number",
-		})
-		return
+		return badRequest("invalid page number")
 	}
 
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil || limit < 1 || limit > domain.MaxPageSize {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "invalid limit",
-		})
-		return
+		return badRequest("invalid limit")
+	}
+
+	query := domain.FeedQuery{UserID: principal.ID, Tag: tag, Page: page, Limit: limit}
+	switch c.Query("total") {
+	case "", "true":
+	case "false":
+		query.Total = domain.FeedTotalNone
+	case "estimate":
+		query.Total = domain.FeedTotalEstimate
+	default:
+		return badRequest("invalid total")
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		query.After, err = domain.DecodeFeedCursor(cursor)
+		if err != nil {
+			return badRequest("invalid cursor")
+		}
+	}
+	switch lang := c.Query("lang"); lang {
+	case "":
+	case "all":
+		query.AllLanguages = true
+	default:
+		query.Languages = strings.Split(lang, ",")
 	}
 
-	response, err := h.service.GetPollsForFeed(c.Request.Context(), userUUID, tag, page, limit)
+	response, err := h.service.GetPollsForFeed(c.Request.Context(), query)
 	if err != nil {
-		h.logger.Error("failed to get polls for feed",
-			zap.Error(err),
-			zap.String("userId", userUUID.String()),
-			zap.String("tag", tag),
-		)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Failed to get polls",
-		})
-		return
+		return err
 	}
 
+	h.linkPolls(response.Polls)
+	data := feedData{
+		Polls:      response.Polls,
+		Page:       response.Page,
+		Limit:      response.Limit,
+		NextCursor: response.NextCursor,
+	}
+	if query.Total != domain.FeedTotalNone {
+		data.Total = &response.Total
+		data.TotalEstimated = &response.TotalEstimated
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
-		"data": gin.H{
-			"polls": response.Polls,
-			"total": response.Total,
-			"page":  response.Page,
-			"limit": response.Limit,
-		},
+		"data":   data,
 	})
+	return nil
 }
 
-func (h *Handler) getPollByID(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
+func (h *Handler) getPollByID(c *gin.Context) error {
+	id, err := h.uuidParam(c, "id", "invalid poll id")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "invalid poll id",
-		})
-		return
+		return err
 	}
 
 	poll, err := h.service.GetPollByID(c.Request.Context(), id)
 	if err != nil {
-		h.logger.Error("failed to get poll",
-			zap.Error(err),
-			zap.String("pollId", id.String()),
-		)
-		switch {
-		case errors.Is(err, domain.ErrNotFound):
-			c.JSON(http.StatusNotFound, gin.H{
-				"status":  "error",
-				"message": "poll not found",
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status":  "error",
-				"message": "failed to get poll",
-			})
-		}
-		return
+		return describe(err, domain.ErrNotFound, "poll not found")
 	}
 
+	poll.Links = h.urls.PollLinks(poll)
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data":   poll,
 	})
+	return nil
 }
 
-func (h *Handler) getPollStats(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
+type pollStatsData struct {
+	PollID     string                     `json:"poll_id"`
+	Votes      []domain.OptionStats       `json:"votes"`
+	TotalVotes int                        `json:"total_votes"`
+	ComputedAt time.Time                  `json:"computed_at"`
+	MyOption   string                     `json:"my_option,omitempty"`
+	Results    *domain.PollResultSnapshot `json:"results,omitempty"`
+}
+
+func (h *Handler) getPollStats(c *gin.Context) error {
+	id, err := h.uuidParam(c, "id", "Invalid poll ID")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "Invalid poll ID",
-		})
-		return
+		return err
 	}
-	stats, err := h.service.GetPollStats(c.Request.Context(), id)
+
+	var query domain.StatsQuery
+	if maxAgeStr, ok := c.GetQuery("maxAge"); ok {
+		seconds, err := strconv.Atoi(maxAgeStr)
+		if err != nil || seconds < 0 {
+			return badRequest("maxAge must be a non-negative number of seconds")
+		}
+		maxAge := time.Duration(seconds) * time.Second
+		query.MaxAge = &maxAge
+	}
+	if principal, ok := auth.CurrentUser(c); ok {
+		query.ActorID = principal.ID
+	} else if query.MaxAge != nil && *query.MaxAge == 0 {
+		return &apiError{
+			status:  http.StatusUnauthorized,
+			code:    "unauthenticated",
+			message: "authentication is required to bypass the stats cache",
+		}
+	}
+
+	stats, err := h.service.GetPollStats(c.Request.Context(), id, query)
 	if err != nil {
-		h.logger.Error("failed to get poll stats",
-			zap.Error(err),
-			zap.String("pollId", id.String()),
-		)
-		switch {
-		case errors.Is(err, domain.ErrNotFound):
-			c.JSON(http.StatusNotFound, gin.H{
-				"status":  "error",
-				"message": "Poll not found",
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status":  "error",
-				"message": "Failed to get poll stats",
-			})
+		err = describe(err, domain.ErrNotFound, "Poll not found")
+		return describe(err, domain.ErrForbidden, "Only the poll owner can bypass the stats cache")
+	}
+	if !stats.ComputedAt.IsZero() {
+		age := time.Since(stats.ComputedAt)
+		if age < 0 {
+			age = 0
 		}
-		return
+		c.Header("Age", strconv.Itoa(int(age.Seconds())))
+	}
+	data := pollStatsData{
+		PollID:     stats.PollID.String(),
+		Votes:      stats.Votes,
+		TotalVotes: stats.TotalVotes,
+		ComputedAt: stats.ComputedAt,
+		MyOption:   stats.MyOption,
+		Results:    stats.Results,
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
-		"data": gin.H{
-			"poll_id": stats.PollID.String(),
-			"votes":   stats.Votes,
-		},
+		"data":   data,
 	})
+	return nil
 }
 
-type VoteOnPollRequest struct {
-	UserID      string `json:"userId" binding:"required"`
-	OptionIndex *int   `json:"optionIndex" binding:"required,min=0"`
+const (
+	defaultStatsWaitTimeout = 30 * time.Second
+	maxStatsWaitSeconds     = 60
+)
+
+// statsWaitData carries the votes only when the stats changed.
+type statsWaitData struct {
+	PollID     string               `json:"poll_id"`
+	Version    int64                `json:"version"`
+	Changed    bool                 `json:"changed"`
+	Votes      []domain.OptionStats `json:"votes,omitempty"`
+	ComputedAt *time.Time           `json:"computed_at,omitempty"`
 }
 
-func (h *Handler) voteOnPoll(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
-			Error: "user not authenticated",
-		})
-		return
+// waitPollStats holds the request until the poll's stats version moves past
+// the one the client passes, or the timeout runs out.
+func (h *Handler) waitPollStats(c *gin.Context) error {
+	id, err := h.uuidParam(c, "id", "Invalid poll ID")
+	if err != nil {
+		return err
 	}
 
-	var req struct {
-		OptionIndex *int `json:"optionIndex" binding:"required,min=0"`
+	version, err := strconv.ParseInt(c.DefaultQuery("version", "0"), 10, 64)
+	if err != nil || version < 0 {
+		return badRequest("version must be a non-negative integer")
 	}
-	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "Invalid poll ID",
-		})
-		return
-	}
-
-	var rawBody []byte
-	if c.Request.Body != nil {
-		rawBody, _ = io.ReadAll(c.Request.Body)
-		c.Request.Body = io.NopCloser(bytes.NewBuffer(rawBody))
-		h.logger.Info("voteOnPoll: raw request body", zap.ByteString("rawBody", rawBody))
-
-		var directReq map[string]interface{}
-		if err := json.Unmarshal(rawBody, &directReq); err != nil {
-			h.logger.Error("voteOnPoll: failed to unmarshal request body directly", zap.Error(err))
-		} else {
-			h.logger.Info("voteOnPoll: direct unmarshal result", zap.Any("directReq", directReq))
+
+	timeout := defaultStatsWaitTimeout
+	if timeoutStr, ok := c.GetQuery("timeout"); ok {
+		seconds, err := strconv.Atoi(timeoutStr)
+		if err != nil || seconds < 1 || seconds > maxStatsWaitSeconds {
+			return badRequest("timeout must be between 1 and 60 seconds")
 		}
+		timeout = time.Duration(seconds) * time.Second
 	}
 
-	if err := c.BindJSON(&req); err != nil {
-		h.logger.Error("voteOnPoll: failed to bind JSON",
-			zap.Error(err),
-			zap.Any("req", req),
-			zap.Any("rawBody", string(rawBody)),
-			zap.Any("contentType", c.GetHeader("Content-Type")))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "Invalid request body",
-		})
-		return
+	var actorID uuid.UUID
+	if principal, ok := auth.CurrentUser(c); ok {
+		actorID = principal.ID
 	}
 
-	h.logger.Info("voteOnPoll: successfully bound request", zap.Any("req", req))
-
-	serviceReq := &domain.VoteRequest{
-		UserID:      userID.(uuid.UUID),
-		OptionIndex: *req.OptionIndex,
-	}
-	err = h.service.VoteOnPoll(c.Request.Context(), id, serviceReq)
+	update, err := h.service.WaitPollStats(c.Request.Context(), id, actorID, version, timeout)
 	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrAlreadyVoted):
-			h.logger.Info("user attempted to vote again on poll",
-				zap.String("pollId", id.String()),
-				zap.String("userId", serviceReq.UserID.String()),
-			)
-			c.JSON(http.StatusConflict, gin.H{
-				"status":  "error",
-				"message": err.Error(),
-			})
-		case errors.Is(err, domain.ErrDailyVoteLimitExceeded):
-			h.logger.Info("user exceeded daily vote limit",
-				zap.String("pollId", id.String()),
-				zap.String("userId", serviceReq.UserID.String()),
-			)
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"status":  "error",
-				"message": err.Error(),
-			})
-		case errors.Is(err, domain.ErrInvalidOption):
-			h.logger.Error("invalid option selected for vote",
-				zap.Error(err),
-				zap.String("pollId", id.String()),
-				zap.String("userId", serviceReq.UserID.String()),
-				zap.Int("optionIndex", *req.OptionIndex),
-			)
-			c.JSON(http.StatusBadRequest, gin.H{
-				"status":  "error",
-				"message": err.Error(),
-			})
-		case errors.Is(err, domain.ErrNotFound):
-			h.logger.Error("poll not found for vote",
-				zap.Error(err),
-				zap.String("pollId", id.String()),
-				zap.String("userId", serviceReq.UserID.String()),
-			)
-			c.JSON(http.StatusNotFound, gin.H{
-				"status":  "error",
-				"message": "Poll not found",
-			})
-		default:
-			h.logger.Error("failed to vote on poll",
-				zap.Error(err),
-				zap.String("pollId", id.String()),
-				zap.String("userId", serviceReq.UserID.String()),
-			)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status":  "error",
-				"message": "Failed to vote on poll",
-			})
+		if c.Request.Context().Err() != nil {
+			// The client went away; there is no one left to answer.
+			return nil
 		}
-		return
+		return describe(err, domain.ErrNotFound, "Poll not found")
+	}
+
+	data := statsWaitData{
+		PollID:  id.String(),
+		Version: update.Version,
+		Changed: update.Changed,
+	}
+	if update.Stats != nil {
+		data.Votes = update.Stats.Votes
+		data.ComputedAt = &update.Stats.ComputedAt
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
+		"data":   data,
 	})
+	return nil
+}
+
+// voteBody is the body of a vote. Either field picks the option; with
+// neither the poll's default option is voted for.
+type voteBody struct {
+	OptionID    *uuid.UUID `json:"optionId"`
+	OptionIndex *int       `json:"optionIndex" binding:"omitempty,min=0"`
+	AccessCode  string     `json:"accessCode"`
 }
 
-func (h *Handler) skipPoll(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
-			Error: "user not authenticated",
-		})
-		return
+func (h *Handler) voteOnPoll(c *gin.Context) error {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		return unauthenticated()
 	}
 
-	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
+	var req voteBody
+	id, err := h.uuidParam(c, "id", "Invalid poll ID")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "Invalid poll ID",
-		})
-		return
+		return err
 	}
 
-	serviceReq := &domain.SkipRequest{
-		UserID: userID.(uuid.UUID),
+	// The request body may carry a poll access code, so it is never logged.
+	// "Tap to vote" clients send no body at all; a body has to name the
+	// option.
+	hasBody, err := bindOptionalJSON(c, &req)
+	if err != nil {
+		return err
 	}
-	err = h.service.SkipPoll(c.Request.Context(), id, serviceReq)
+	if hasBody && req.OptionID == nil && req.OptionIndex == nil {
+		return badRequest("optionId or optionIndex is required")
+	}
+
+	serviceReq := &domain.VoteRequest{
+		UserID:     principal.ID,
+		OptionID:   req.OptionID,
+		AccessCode: req.AccessCode,
+		UseDefault: !hasBody,
+		Location:   geoLocation(c),
+	}
+	if req.OptionIndex != nil {
+		serviceReq.OptionIndex = *req.OptionIndex
+	}
+	receipt, err := h.service.VoteOnPoll(c.Request.Context(), id, serviceReq)
 	if err != nil {
-		h.logger.Error("failed to skip poll",
-			zap.Error(err),
-			zap.String("pollId", id.String()),
-			zap.String("userId", serviceReq.UserID.String()),
-		)
-		switch {
-		case errors.Is(err, domain.ErrAlreadySkipped):
+		var voted *domain.AlreadyVotedError
+		if errors.As(err, &voted) {
 			c.JSON(http.StatusConflict, gin.H{
-				"status":  "error",
-				"message": "Already skipped this poll",
-			})
-		case errors.Is(err, domain.ErrNotFound):
-			c.JSON(http.StatusNotFound, gin.H{
-				"status":  "error",
-				"message": "Poll not found",
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status":  "error",
-				"message": "Failed to skip poll",
+				"status":      "error",
+				"code":        "already_voted",
+				"message":     voted.Error(),
+				"voteId":      voted.VoteID,
+				"optionIndex": voted.OptionIndex,
 			})
+			return nil
 		}
-		return
+		return describe(err, domain.ErrNotFound, "Poll not found")
+	}
+
+	response := gin.H{
+		"status":  "success",
+		"voteId":  receipt.VoteID,
+		"receipt": receipt,
+	}
+	allowance, err := h.service.GetVoteAllowance(c.Request.Context(), serviceReq.UserID)
+	if err != nil {
+		logging.For(c.Request.Context(), h.logger).Warn("failed to get vote allowance",
+			zap.Error(err),
+			zap.String("userId", serviceReq.UserID.String()),
+		)
+	} else {
+		response["dailyVotes"] = allowance
+	}
+	c.Header("Location", h.urls.Vote(receipt.VoteID))
+	c.JSON(http.StatusCreated, response)
+	return nil
+}
+
+func (h *Handler) skipPoll(c *gin.Context) error {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		return unauthenticated()
+	}
+
+	id, err := h.uuidParam(c, "id", "Invalid poll ID")
+	if err != nil {
+		return err
+	}
+
+	// The body is optional; a skip without one has no reason.
+	var serviceReq domain.SkipRequest
+	if err := c.ShouldBindJSON(&serviceReq); err != nil && !errors.Is(err, io.EOF) {
+		return badRequest("Invalid request body")
+	}
+	serviceReq.UserID = principal.ID
+	if err := h.service.SkipPoll(c.Request.Context(), id, &serviceReq); err != nil {
+		err = describe(err, domain.ErrAlreadySkipped, "Already skipped this poll")
+		err = describe(err, domain.ErrInvalidInput, "Invalid skip reason")
+		return describe(err, domain.ErrNotFound, "Poll not found")
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 	})
+	return nil
 }
 
-func (h *Handler) getUserVotes(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  "error",
-			"message": "user not authenticated",
-		})
-		return
+func (h *Handler) getUserVotes(c *gin.Context) error {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		return unauthenticated()
 	}
 
-	userUUID, ok := userID.(uuid.UUID)
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "invalid user id",
-		})
-		return
+	filter, err := parseVoteFilter(c)
+	if err != nil {
+		return badRequest(err.Error())
+	}
+
+	if wantsCSV(c) {
+		h.exportUserVotes(c, principal.ID, filter)
+		return nil
 	}
 
 	page := c.DefaultQuery("page", "1")
@@ -450,160 +626,138 @@ func (h *Handler) getUserVotes(c *gin.Context) {
 		limitNum = domain.DefaultLimit
 	}
 
-	response, err := h.service.GetUserVotes(c.Request.Context(), userUUID, pageNum, limitNum)
+	response, err := h.service.GetUserVotes(c.Request.Context(), principal.ID, filter, pageNum, limitNum)
 	if err != nil {
-		h.logger.Error("failed to get user votes",
-			zap.Error(err),
-			zap.String("userId", userUUID.String()),
-		)
-		switch {
-		case errors.Is(err, domain.ErrNotFound):
-			c.JSON(http.StatusNotFound, gin.H{
-				"status":  "error",
-				"message": "user not found",
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status":  "error",
-				"message": "failed to get user votes",
-			})
-		}
-		return
+		return describe(err, domain.ErrNotFound, "user not found")
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data":   response,
 	})
+	return nil
 }
 
-func (h *Handler) updateVote(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  "error",
-			"message": "user not authenticated",
-		})
-		return
+type updateVoteBody struct {
+	OptionID    *uuid.UUID `json:"optionId"`
+	OptionIndex *int       `json:"optionIndex" binding:"omitempty,min=0"`
+}
+
+func (h *Handler) updateVote(c *gin.Context) error {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		return unauthenticated()
 	}
 
-	voteIDStr := c.Param("voteId")
-	voteID, err := uuid.Parse(voteIDStr)
+	voteID, err := h.uuidParam(c, "voteId", "invalid vote id")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "invalid vote id",
-		})
-		return
+		return err
 	}
 
-	var req struct {
-		OptionIndex int `json:"optionIndex" binding:"required,min=0"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "invalid request body",
-		})
-		return
+	var req updateVoteBody
+	if err := c.ShouldBindJSON(&req); err != nil || (req.OptionID == nil && req.OptionIndex == nil) {
+		return badRequest("invalid request body")
 	}
 
 	serviceReq := &domain.UpdateVoteRequest{
-		UserID:      userID.(uuid.UUID),
-		OptionIndex: req.OptionIndex,
+		UserID:   principal.ID,
+		OptionID: req.OptionID,
+	}
+	if req.OptionIndex != nil {
+		serviceReq.OptionIndex = *req.OptionIndex
 	}
 
-	err = h.service.UpdateVote(c.Request.Context(), voteID, serviceReq)
-	if err != nil {
-		h.logger.Error("failed to update vote",
-			zap.Error(err),
-			zap.String("voteId", voteID.String()),
-			zap.String("userId", serviceReq.UserID.String()),
-		)
-		switch {
-		case errors.Is(err, domain.ErrUnauthorized):
-			c.JSON(http.StatusForbidden, gin.H{
-				"status":  "error",
-				"message": "unauthorized to update this vote",
-			})
-		case errors.Is(err, domain.ErrNotFound):
-			c.JSON(http.StatusNotFound, gin.H{
-				"status":  "error",
-				"message": "vote not found",
-			})
-		case errors.Is(err, domain.ErrInvalidOption):
-			c.JSON(http.StatusBadRequest, gin.H{
-				"status":  "error",
-				"message": err.Error(),
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status":  "error",
-				"message": "failed to update vote",
-			})
+	if err := h.service.UpdateVote(c.Request.Context(), voteID, serviceReq); err != nil {
+		var cooldown *domain.VoteCooldownError
+		if errors.As(err, &cooldown) {
+			retryAfter := int(math.Ceil(time.Until(cooldown.RetryAt).Seconds()))
+			c.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
 		}
-		return
+		err = describe(err, domain.ErrUnauthorized, "unauthorized to update this vote")
+		return describe(err, domain.ErrNotFound, "vote not found")
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 	})
+	return nil
 }
 
-func (h *Handler) deleteVote(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  "error",
-			"message": "user not authenticated",
-		})
-		return
+func (h *Handler) deleteVote(c *gin.Context) error {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		return unauthenticated()
 	}
 
-	voteIDStr := c.Param("voteId")
-	voteID, err := uuid.Parse(voteIDStr)
+	voteID, err := h.uuidParam(c, "voteId", "invalid vote id")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "invalid vote id",
-		})
-		return
+		return err
+	}
+
+	if err := h.service.DeleteVote(c.Request.Context(), voteID, principal.ID); err != nil {
+		err = describe(err, domain.ErrUnauthorized, "unauthorized to delete this vote")
+		return describe(err, domain.ErrNotFound, "vote not found")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+	})
+	return nil
+}
+
+func (h *Handler) verifyVote(c *gin.Context) error {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		return unauthenticated()
 	}
 
-	err = h.service.DeleteVote(c.Request.Context(), voteID, userID.(uuid.UUID))
+	voteID, err := h.uuidParam(c, "id", "invalid vote id")
 	if err != nil {
-		h.logger.Error("failed to delete vote",
-			zap.Error(err),
-			zap.String("voteId", voteID.String()),
-			zap.String("userId", userID.(uuid.UUID).String()),
-		)
-		switch {
-		case errors.Is(err, domain.ErrUnauthorized):
-			c.JSON(http.StatusForbidden, gin.H{
-				"status":  "error",
-				"message": "unauthorized to delete this vote",
-			})
-		case errors.Is(err, domain.ErrNotFound):
-			c.JSON(http.StatusNotFound, gin.H{
-				"status":  "error",
-				"message": "vote not found",
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status":  "error",
-				"message": "failed to delete vote",
-			})
-		}
-		return
+		return err
+	}
+	signature := c.Query("signature")
+	if signature == "" {
+		return badRequest("signature is required")
+	}
+
+	verification, err := h.service.VerifyVoteReceipt(c.Request.Context(), voteID, principal.ID, signature)
+	if err != nil {
+		return describe(err, domain.ErrNotFound, "vote not found")
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
+		"data":   verification,
+	})
+	return nil
+}
+
+func (h *Handler) getElectionTally(c *gin.Context) error {
+	pollID, err := h.uuidParam(c, "id", "Invalid poll ID")
+	if err != nil {
+		return err
+	}
+
+	cert, err := h.service.GetElectionCertification(c.Request.Context(), pollID)
+	if err != nil {
+		return describe(err, domain.ErrNotFound, "Election results not certified")
+	}
+
+	// The tally is returned exactly as signed.
+	keepStoredIDs(c)
+	c.JSON(http.StatusOK, gin.H{
+		"status":        "success",
+		"certification": cert,
 	})
+	return nil
 }
 
 func (h *Handler) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.WithClient(c.Request.Context(), auth.Client{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		}))
 		c.Next()
 	}
 }
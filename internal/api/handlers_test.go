@@ -1,11 +1,17 @@
 package api
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strconv"
 	"strings"
 	"testing"
@@ -13,11 +19,17 @@ import (
 
 	"github.com/behzadon/vote/internal/auth"
 	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/health"
+	"github.com/behzadon/vote/internal/middleware"
+	"github.com/behzadon/vote/internal/publicid"
+	"github.com/behzadon/vote/internal/storage/blob"
+	"github.com/behzadon/vote/internal/voteimport"
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -25,9 +37,20 @@ type MockService struct {
 	mock.Mock
 }
 
-func (m *MockService) CreatePoll(ctx context.Context, req *domain.CreatePollRequest) (uuid.UUID, error) {
+func (m *MockService) CreatePoll(ctx context.Context, req *domain.CreatePollRequest) (*domain.Poll, error) {
 	args := m.Called(ctx, req)
-	return args.Get(0).(uuid.UUID), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Poll), args.Error(1)
+}
+
+func (m *MockService) ValidatePoll(ctx context.Context, req *domain.CreatePollRequest) ([]*domain.ValidationError, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ValidationError), args.Error(1)
 }
 
 func (m *MockService) GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Poll, error) {
@@ -38,440 +61,2461 @@ func (m *MockService) GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Po
 	return args.Get(0).(*domain.Poll), args.Error(1)
 }
 
-func (m *MockService) GetPollsForFeed(ctx context.Context, userID uuid.UUID, tag string, page, limit int) (*domain.PollFeedResponse, error) {
-	args := m.Called(ctx, userID, tag, page, limit)
+func (m *MockService) GetPollsForFeed(ctx context.Context, q domain.FeedQuery) (*domain.PollFeedResponse, error) {
+	args := m.Called(ctx, q)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*domain.PollFeedResponse), args.Error(1)
 }
 
-func (m *MockService) GetPollStats(ctx context.Context, pollID uuid.UUID) (*domain.PollStats, error) {
-	args := m.Called(ctx, pollID)
+func (m *MockService) SearchPolls(ctx context.Context, q domain.PollSearchQuery) (*domain.PollSearchResult, error) {
+	args := m.Called(ctx, q)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*domain.PollStats), args.Error(1)
+	return args.Get(0).(*domain.PollSearchResult), args.Error(1)
 }
 
-func (m *MockService) VoteOnPoll(ctx context.Context, pollID uuid.UUID, req *domain.VoteRequest) error {
-	args := m.Called(ctx, pollID, req)
-	return args.Error(0)
+func (m *MockService) SetOptionImage(ctx context.Context, pollID uuid.UUID, optionIndex int, actorID uuid.UUID, upload *domain.MediaUpload) (*domain.Poll, error) {
+	args := m.Called(ctx, pollID, optionIndex, actorID, upload)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Poll), args.Error(1)
 }
 
-func (m *MockService) SkipPoll(ctx context.Context, pollID uuid.UUID, req *domain.SkipRequest) error {
-	args := m.Called(ctx, pollID, req)
-	return args.Error(0)
+func (m *MockService) UpdateOption(ctx context.Context, pollID uuid.UUID, optionIndex int, req *domain.UpdateOptionRequest) (*domain.Poll, error) {
+	args := m.Called(ctx, pollID, optionIndex, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Poll), args.Error(1)
 }
 
-func (m *MockService) CreateUser(ctx context.Context, user *domain.User) error {
-	args := m.Called(ctx, user)
-	return args.Error(0)
+func (m *MockService) SetUserAvatar(ctx context.Context, userID uuid.UUID, upload *domain.MediaUpload) (*domain.User, error) {
+	args := m.Called(ctx, userID, upload)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
 }
 
-func (m *MockService) GetUserByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
-	args := m.Called(ctx, id)
+func (m *MockService) SignUpload(ctx context.Context, userID uuid.UUID, req *domain.SignUploadRequest) (*domain.SignedUpload, error) {
+	args := m.Called(ctx, userID, req)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*domain.User), args.Error(1)
+	return args.Get(0).(*domain.SignedUpload), args.Error(1)
 }
 
-func (m *MockService) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
-	args := m.Called(ctx, email)
+func (m *MockService) ConfirmUpload(ctx context.Context, userID uuid.UUID, req *domain.ConfirmUploadRequest) (*domain.UploadConfirmation, error) {
+	args := m.Called(ctx, userID, req)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*domain.User), args.Error(1)
+	return args.Get(0).(*domain.UploadConfirmation), args.Error(1)
 }
 
-func (m *MockService) UpdateUser(ctx context.Context, user *domain.User) error {
-	args := m.Called(ctx, user)
+func (m *MockService) SetUserStanding(ctx context.Context, userID uuid.UUID, standing domain.UserStanding) error {
+	args := m.Called(ctx, userID, standing)
 	return args.Error(0)
 }
 
-func (m *MockService) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	args := m.Called(ctx, id)
-	return args.Error(0)
+func (m *MockService) SearchUsers(ctx context.Context, q *domain.UserSearchQuery) (*domain.UserList, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserList), args.Error(1)
 }
 
-func (m *MockService) GetUserVotes(ctx context.Context, userID uuid.UUID, page, limit int) (*domain.UserVotesResponse, error) {
-	args := m.Called(ctx, userID, page, limit)
+func (m *MockService) GetPlatformStats(ctx context.Context) (*domain.PlatformStats, error) {
+	args := m.Called(ctx)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*domain.UserVotesResponse), args.Error(1)
+	return args.Get(0).(*domain.PlatformStats), args.Error(1)
 }
 
-func (m *MockService) DeleteVote(ctx context.Context, voteID uuid.UUID, userID uuid.UUID) error {
-	args := m.Called(ctx, voteID, userID)
+func (m *MockService) ForceDeletePoll(ctx context.Context, pollID, actorID uuid.UUID) error {
+	args := m.Called(ctx, pollID, actorID)
 	return args.Error(0)
 }
 
-func (m *MockService) UpdateVote(ctx context.Context, voteID uuid.UUID, req *domain.UpdateVoteRequest) error {
-	args := m.Called(ctx, voteID, req)
-	return args.Error(0)
+func (m *MockService) GetConsentStatus(ctx context.Context, userID uuid.UUID) (*domain.ConsentStatus, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ConsentStatus), args.Error(1)
 }
 
-type MockRedis struct {
-	*redis.Client
-	counters map[string]int64
-	windows  map[string]int64
+func (m *MockService) AcceptConsents(ctx context.Context, req *domain.AcceptConsentRequest) (*domain.ConsentStatus, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ConsentStatus), args.Error(1)
 }
 
-func NewMockRedis() *MockRedis {
-	return &MockRedis{
-		Client:   redis.NewClient(&redis.Options{}),
-		counters: make(map[string]int64),
-		windows:  make(map[string]int64),
+func (m *MockService) GetPollWinner(ctx context.Context, pollID uuid.UUID) (*domain.PollWinner, error) {
+	args := m.Called(ctx, pollID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
+	return args.Get(0).(*domain.PollWinner), args.Error(1)
 }
 
-func (m *MockRedis) Incr(ctx context.Context, key string) *redis.IntCmd {
-	m.counters[key]++
-	return redis.NewIntResult(m.counters[key], nil)
+func (m *MockService) WaitPollStats(ctx context.Context, pollID, actorID uuid.UUID, since int64, timeout time.Duration) (*domain.StatsUpdate, error) {
+	args := m.Called(ctx, pollID, actorID, since, timeout)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.StatsUpdate), args.Error(1)
 }
 
-func (m *MockRedis) Get(ctx context.Context, key string) *redis.StringCmd {
-	if strings.HasSuffix(key, ":count") {
-		if count, exists := m.counters[key]; exists {
-			return redis.NewStringResult(strconv.FormatInt(count, 10), nil)
-		}
-		return redis.NewStringResult("0", nil)
+func (m *MockService) GetPollStats(ctx context.Context, pollID uuid.UUID, q domain.StatsQuery) (*domain.PollStats, error) {
+	args := m.Called(ctx, pollID, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
-	if strings.HasSuffix(key, ":window") {
-		if window, exists := m.windows[key]; exists {
-			return redis.NewStringResult(strconv.FormatInt(window, 10), nil)
-		}
-		return redis.NewStringResult(strconv.FormatInt(time.Now().Unix(), 10), nil)
+	return args.Get(0).(*domain.PollStats), args.Error(1)
+}
+
+func (m *MockService) UpdatePollTags(ctx context.Context, pollID uuid.UUID, req *domain.UpdatePollTagsRequest) (*domain.Poll, error) {
+	args := m.Called(ctx, pollID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
-	return redis.NewStringResult("", redis.Nil)
+	return args.Get(0).(*domain.Poll), args.Error(1)
 }
 
-func (m *MockRedis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
-	if strings.HasSuffix(key, ":window") {
-		if val, ok := value.(int64); ok {
-			m.windows[key] = val
-		}
+func (m *MockService) ReorderOptions(ctx context.Context, pollID uuid.UUID, req *domain.ReorderOptionsRequest) (*domain.Poll, error) {
+	args := m.Called(ctx, pollID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
-	return redis.NewStatusResult("OK", nil)
+	return args.Get(0).(*domain.Poll), args.Error(1)
 }
 
-func (m *MockRedis) Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd {
-	return redis.NewBoolResult(true, nil)
+func (m *MockService) UpdatePoll(ctx context.Context, pollID uuid.UUID, req *domain.UpdatePollRequest) (*domain.Poll, error) {
+	args := m.Called(ctx, pollID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Poll), args.Error(1)
 }
 
-func (m *MockRedis) Pipeline() redis.Pipeliner {
-	return &MockPipeline{mockRedis: m}
+func (m *MockService) ClosePoll(ctx context.Context, pollID, actorID uuid.UUID, admin bool) error {
+	args := m.Called(ctx, pollID, actorID, admin)
+	return args.Error(0)
 }
 
-type MockPipeline struct {
-	redis.Pipeliner
-	mockRedis *MockRedis
+func (m *MockService) ChangePollStatus(ctx context.Context, pollID uuid.UUID, req *domain.PollStatusRequest) (*domain.Poll, error) {
+	args := m.Called(ctx, pollID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Poll), args.Error(1)
 }
 
-func (m *MockPipeline) Exec(ctx context.Context) ([]redis.Cmder, error) {
-	return nil, nil
+func (m *MockService) GetPollOwnerStats(ctx context.Context, pollID, actorID uuid.UUID) (*domain.PollOwnerStats, error) {
+	args := m.Called(ctx, pollID, actorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PollOwnerStats), args.Error(1)
 }
 
-func (m *MockPipeline) Get(ctx context.Context, key string) *redis.StringCmd {
-	return m.mockRedis.Get(ctx, key)
+func (m *MockService) AddPollCollaborator(ctx context.Context, pollID uuid.UUID, req *domain.AddCollaboratorRequest) (*domain.Collaborator, error) {
+	args := m.Called(ctx, pollID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Collaborator), args.Error(1)
 }
 
-func (m *MockPipeline) Incr(ctx context.Context, key string) *redis.IntCmd {
-	return m.mockRedis.Incr(ctx, key)
+func (m *MockService) RemovePollCollaborator(ctx context.Context, pollID, userID, actorID uuid.UUID) error {
+	args := m.Called(ctx, pollID, userID, actorID)
+	return args.Error(0)
 }
 
-func (m *MockPipeline) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
-	return m.mockRedis.Set(ctx, key, value, expiration)
+func (m *MockService) CreateTagAlias(ctx context.Context, req *domain.TagAliasRequest) (*domain.TagAlias, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TagAlias), args.Error(1)
 }
 
-func setupTest(t *testing.T) (*gin.Engine, *MockService, *Handler, *AuthHandler, *auth.JWTManager) {
-	gin.SetMode(gin.TestMode)
-	r := gin.New()
+func (m *MockService) GetTagAliases(ctx context.Context) ([]domain.TagAlias, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.TagAlias), args.Error(1)
+}
 
-	mockService := new(MockService)
-	logger, _ := zap.NewDevelopment()
+func (m *MockService) MergeTags(ctx context.Context, req *domain.MergeTagsRequest) (*domain.TagMergeResult, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TagMergeResult), args.Error(1)
+}
 
-	mockRedis := NewMockRedis()
+func (m *MockService) GetTagRules(ctx context.Context) ([]domain.TagRule, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.TagRule), args.Error(1)
+}
 
-	jwtManager := auth.NewJWTManager("test-secret", 24*time.Hour)
-	authHandler := NewAuthHandler(mockService, jwtManager, logger)
-	handler := NewHandler(mockService, mockRedis, logger, authHandler)
+func (m *MockService) SetTagRule(ctx context.Context, tag string, req *domain.TagRuleRequest) (*domain.TagRule, error) {
+	args := m.Called(ctx, tag, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TagRule), args.Error(1)
+}
 
-	testAuthMiddleware := func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"status":  "error",
-				"message": "unauthorized",
-			})
-			c.Abort()
-			return
-		}
+func (m *MockService) DeleteTagRule(ctx context.Context, tag string) error {
+	args := m.Called(ctx, tag)
+	return args.Error(0)
+}
 
-		token := authHeader
-		if strings.HasPrefix(authHeader, "Bearer ") {
-			token = strings.TrimPrefix(authHeader, "Bearer ")
-		}
+func (m *MockService) GetModerationQueue(ctx context.Context, status domain.FlagStatus, page, limit int) (*domain.ModerationQueue, error) {
+	args := m.Called(ctx, status, page, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ModerationQueue), args.Error(1)
+}
 
-		claims, err := jwtManager.ValidateToken(token)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"status":  "error",
-				"message": "invalid token",
-			})
-			c.Abort()
-			return
-		}
+func (m *MockService) ResolveModerationFlag(ctx context.Context, flagID uuid.UUID, req *domain.ResolveFlagRequest) (*domain.ModerationFlag, error) {
+	args := m.Called(ctx, flagID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ModerationFlag), args.Error(1)
+}
 
-		c.Set("user_id", claims.UserID)
-		c.Next()
+func (m *MockService) GetVoteAllowance(ctx context.Context, userID uuid.UUID) (*domain.VoteAllowance, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
+	return args.Get(0).(*domain.VoteAllowance), args.Error(1)
+}
 
-	api := r.Group("/api")
-	api.Use(testAuthMiddleware)
-	{
-		api.POST("/polls", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.createPoll)
-		api.GET("/polls", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.getPollsForFeed)
-		api.GET("/polls/:id", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.getPollByID)
-		api.POST("/polls/:id/vote", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.voteOnPoll)
-		api.POST("/polls/:id/skip", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.skipPoll)
+func (m *MockService) RecountPollStats(ctx context.Context, pollID uuid.UUID) (*domain.PollRecount, error) {
+	args := m.Called(ctx, pollID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
+	return args.Get(0).(*domain.PollRecount), args.Error(1)
+}
 
-	r.POST("/api/auth/register", authHandler.Register)
-	r.POST("/api/auth/login", authHandler.Login)
-	r.GET("/api/polls/:id/stats", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.getPollStats)
+func (m *MockService) GetPublicResults(ctx context.Context, pollID uuid.UUID) (*domain.PollResults, error) {
+	args := m.Called(ctx, pollID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PollResults), args.Error(1)
+}
 
-	return r, mockService, handler, authHandler, jwtManager
+func (m *MockService) GetPollPreview(ctx context.Context, pollID uuid.UUID) (*domain.PollPreview, error) {
+	args := m.Called(ctx, pollID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PollPreview), args.Error(1)
 }
 
-func TestCreatePoll(t *testing.T) {
-	t.Run("success", func(t *testing.T) {
-		r, mockService, _, _, jwtManager := setupTest(t)
-		userID := uuid.New()
-		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+func (m *MockService) GetPollPreviewImage(ctx context.Context, pollID uuid.UUID) (*domain.PollPreviewImage, error) {
+	args := m.Called(ctx, pollID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PollPreviewImage), args.Error(1)
+}
 
-		req := domain.CreatePollRequest{
-			Title:   "Test Poll",
-			Options: []string{"Option 1", "Option 2"},
-			Tags:    []string{"test"},
-		}
+func (m *MockService) CreateOrganization(ctx context.Context, req *domain.CreateOrganizationRequest) (*domain.Organization, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Organization), args.Error(1)
+}
 
-		pollID := uuid.New()
-		mockService.On("CreatePoll", mock.Anything, &req).Return(pollID, nil)
+func (m *MockService) AddOrganizationMember(ctx context.Context, orgID uuid.UUID, req *domain.AddMemberRequest) error {
+	args := m.Called(ctx, orgID, req)
+	return args.Error(0)
+}
 
-		w := httptest.NewRecorder()
-		body, _ := json.Marshal(req)
-		request, _ := http.NewRequest("POST", "/api/polls", bytes.NewBuffer(body))
-		request.Header.Set("Authorization", "Bearer "+token)
-		r.ServeHTTP(w, request)
+func (m *MockService) CastOrganizationVote(ctx context.Context, pollID uuid.UUID, req *domain.OrganizationVoteRequest) (*domain.OrganizationVote, error) {
+	args := m.Called(ctx, pollID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OrganizationVote), args.Error(1)
+}
 
-		assert.Equal(t, http.StatusCreated, w.Code)
-		var response map[string]interface{}
+func (m *MockService) GetResultCertificate(ctx context.Context, pollID uuid.UUID) (*domain.ResultCertificate, error) {
+	args := m.Called(ctx, pollID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ResultCertificate), args.Error(1)
+}
+
+func (m *MockService) GetResultSigningKey(ctx context.Context) (*domain.ResultSigningKey, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ResultSigningKey), args.Error(1)
+}
+
+func (m *MockService) GetElectionCertification(ctx context.Context, pollID uuid.UUID) (*domain.ElectionCertification, error) {
+	args := m.Called(ctx, pollID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ElectionCertification), args.Error(1)
+}
+
+func (m *MockService) GetUserPreferences(ctx context.Context, userID uuid.UUID) (*domain.UserPreferences, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserPreferences), args.Error(1)
+}
+
+func (m *MockService) UpdateUserPreferences(ctx context.Context, userID uuid.UUID, prefs *domain.UserPreferences) (*domain.UserPreferences, error) {
+	args := m.Called(ctx, userID, prefs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserPreferences), args.Error(1)
+}
+
+func (m *MockService) VoteOnPoll(ctx context.Context, pollID uuid.UUID, req *domain.VoteRequest) (*domain.VoteReceipt, error) {
+	args := m.Called(ctx, pollID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.VoteReceipt), args.Error(1)
+}
+
+func (m *MockService) SkipPoll(ctx context.Context, pollID uuid.UUID, req *domain.SkipRequest) error {
+	args := m.Called(ctx, pollID, req)
+	return args.Error(0)
+}
+
+func (m *MockService) CreateUser(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockService) GetUserByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockService) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockService) UpdateUser(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockService) RecordLogin(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockService) VerifyEmail(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockService) ResendEmailVerification(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockService) RequestEmailChange(ctx context.Context, userID uuid.UUID, req *domain.EmailChangeRequest) (*domain.EmailChange, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.EmailChange), args.Error(1)
+}
+
+func (m *MockService) GetEmailChange(ctx context.Context, userID uuid.UUID) (*domain.EmailChange, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.EmailChange), args.Error(1)
+}
+
+func (m *MockService) ConfirmEmailChange(ctx context.Context, token string) (*domain.EmailChange, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.EmailChange), args.Error(1)
+}
+
+func (m *MockService) CancelEmailChange(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockService) GetUserVotes(ctx context.Context, userID uuid.UUID, filter domain.VoteFilter, page, limit int) (*domain.UserVotesResponse, error) {
+	args := m.Called(ctx, userID, filter, page, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserVotesResponse), args.Error(1)
+}
+
+func (m *MockService) DeleteVote(ctx context.Context, voteID uuid.UUID, userID uuid.UUID) error {
+	args := m.Called(ctx, voteID, userID)
+	return args.Error(0)
+}
+
+func (m *MockService) VerifyVoteReceipt(ctx context.Context, voteID, userID uuid.UUID, signature string) (*domain.VoteVerification, error) {
+	args := m.Called(ctx, voteID, userID, signature)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.VoteVerification), args.Error(1)
+}
+
+func (m *MockService) ExportUserVotes(ctx context.Context, userID uuid.UUID, filter domain.VoteFilter, fn func(*domain.VoteResponse) error) error {
+	args := m.Called(ctx, userID, filter, fn)
+	return args.Error(0)
+}
+
+func (m *MockService) ExportPollVotes(ctx context.Context, pollID uuid.UUID, q domain.VoteExportQuery, fn func(*domain.ExportedVote) error) error {
+	args := m.Called(ctx, pollID, q, fn)
+	return args.Error(0)
+}
+
+func (m *MockService) GetTagVoteTrend(ctx context.Context, tag string, from, to time.Time) ([]domain.VoteBucket, error) {
+	args := m.Called(ctx, tag, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.VoteBucket), args.Error(1)
+}
+
+func (m *MockService) GetPollVoteTimeline(ctx context.Context, pollID, actorID uuid.UUID, from, to time.Time) ([]domain.VoteBucket, error) {
+	args := m.Called(ctx, pollID, actorID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.VoteBucket), args.Error(1)
+}
+
+func (m *MockService) GetUserActivity(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]domain.UserActivity, error) {
+	args := m.Called(ctx, userID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.UserActivity), args.Error(1)
+}
+
+func (m *MockService) UpdateVote(ctx context.Context, voteID uuid.UUID, req *domain.UpdateVoteRequest) error {
+	args := m.Called(ctx, voteID, req)
+	return args.Error(0)
+}
+
+type MockRedis struct {
+	*redis.Client
+	counters map[string]int64
+	windows  map[string]int64
+}
+
+func NewMockRedis() *MockRedis {
+	return &MockRedis{
+		Client:   redis.NewClient(&redis.Options{}),
+		counters: make(map[string]int64),
+		windows:  make(map[string]int64),
+	}
+}
+
+func (m *MockRedis) Incr(ctx context.Context, key string) *redis.IntCmd {
+	m.counters[key]++
+	return redis.NewIntResult(m.counters[key], nil)
+}
+
+func (m *MockRedis) Get(ctx context.Context, key string) *redis.StringCmd {
+	if strings.HasSuffix(key, ":count") {
+		if count, exists := m.counters[key]; exists {
+			return redis.NewStringResult(strconv.FormatInt(count, 10), nil)
+		}
+		return redis.NewStringResult("0", nil)
+	}
+	if strings.HasSuffix(key, ":window") {
+		if window, exists := m.windows[key]; exists {
+			return redis.NewStringResult(strconv.FormatInt(window, 10), nil)
+		}
+		return redis.NewStringResult(strconv.FormatInt(time.Now().Unix(), 10), nil)
+	}
+	return redis.NewStringResult("", redis.Nil)
+}
+
+func (m *MockRedis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	if strings.HasSuffix(key, ":window") {
+		if val, ok := value.(int64); ok {
+			m.windows[key] = val
+		}
+	}
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (m *MockRedis) Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd {
+	return redis.NewBoolResult(true, nil)
+}
+
+func (m *MockRedis) Pipeline() redis.Pipeliner {
+	return &MockPipeline{mockRedis: m}
+}
+
+type MockPipeline struct {
+	redis.Pipeliner
+	mockRedis *MockRedis
+}
+
+func (m *MockPipeline) Exec(ctx context.Context) ([]redis.Cmder, error) {
+	return nil, nil
+}
+
+func (m *MockPipeline) Get(ctx context.Context, key string) *redis.StringCmd {
+	return m.mockRedis.Get(ctx, key)
+}
+
+func (m *MockPipeline) Incr(ctx context.Context, key string) *redis.IntCmd {
+	return m.mockRedis.Incr(ctx, key)
+}
+
+func (m *MockPipeline) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	return m.mockRedis.Set(ctx, key, value, expiration)
+}
+
+func setupTest(t *testing.T) (*gin.Engine, *MockService, *Handler, *AuthHandler, *auth.JWTManager) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	mockService := new(MockService)
+	logger, _ := zap.NewDevelopment()
+
+	mockRedis := NewMockRedis()
+
+	jwtManager := auth.NewJWTManager("test-secret", 24*time.Hour)
+	authHandler := NewAuthHandler(mockService, jwtManager, logger)
+	handler := NewHandler(mockService, mockRedis, logger, authHandler)
+
+	testAuthMiddleware := func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"status":  "error",
+				"message": "unauthorized",
+			})
+			c.Abort()
+			return
+		}
+
+		token := authHeader
+		if strings.HasPrefix(authHeader, "Bearer ") {
+			token = strings.TrimPrefix(authHeader, "Bearer ")
+		}
+
+		claims, err := jwtManager.ValidateToken(token)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"status":  "error",
+				"message": "invalid token",
+			})
+			c.Abort()
+			return
+		}
+
+		auth.SetCurrentUser(c, claims.Principal())
+		c.Next()
+	}
+
+	api := r.Group("/api")
+	api.Use(testAuthMiddleware, handler.grantConfiguredRoles())
+	{
+		api.POST("/polls", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.handle(handler.createPoll))
+		api.POST("/polls/validate", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.handle(handler.validatePoll))
+		api.GET("/polls", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.handle(handler.getPollsForFeed))
+		api.GET("/feed/stream", handler.rateLimiter.RateLimit(), handler.streamFeed)
+		api.GET("/polls/search", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.handle(handler.searchPolls))
+		api.GET("/polls/:id", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.handle(handler.getPollByID))
+		api.POST("/polls/:id/vote", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.GeoIP(), handler.handle(handler.voteOnPoll))
+		api.POST("/polls/:id/skip", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.handle(handler.skipPoll))
+		api.GET("/users/me/limits", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.getUserLimits)
+		api.GET("/users/me/votes", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.handle(handler.getUserVotes))
+		api.GET("/polls/:id/votes/export", handler.exportPollVotes)
+		api.GET("/users/me/activity", handler.handle(handler.getUserActivity))
+		api.GET("/polls/:id/analytics/hourly", handler.handle(handler.getPollVoteTimeline))
+		api.PUT("/users/me/avatar", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.handle(handler.uploadAvatar))
+		api.PUT("/polls/:id/options/:index/image", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.handle(handler.uploadOptionImage))
+		api.PATCH("/polls/:id/options/order", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.handle(handler.reorderOptions))
+		api.PATCH("/polls/:id/options/:index", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.handle(handler.updateOption))
+		api.PATCH("/polls/:id/tags", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.handle(handler.updatePollTags))
+		api.POST("/uploads/sign", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.handle(handler.signUpload))
+		api.POST("/uploads/confirm", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.handle(handler.confirmUpload))
+		api.GET("/users/me/consents", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.handle(handler.getUserConsents))
+		api.POST("/users/me/consents", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.handle(handler.acceptConsents))
+		api.GET("/consented/limits", handler.RequireConsent(), handler.getUserLimits)
+		api.POST("/admin/votes/import", handler.importVotes)
+		api.GET("/moderation/flags", middleware.RequireRole(auth.RoleModerator), handler.handle(handler.getModerationFlags))
+		api.GET("/admin/users", handler.handle(handler.searchUsers))
+		api.DELETE("/admin/polls/:id", handler.handle(handler.forceDeletePoll))
+		api.PUT("/admin/tags/:tag/rule", handler.handle(handler.setTagRule))
+		api.POST("/polls/:id/organization-vote", handler.handle(handler.castOrganizationVote))
+		api.PUT("/users/me/votes/:voteId", handler.handle(handler.updateVote))
+		api.GET("/votes/:id/verify", handler.handle(handler.verifyVote))
+		api.GET("/polls/:id/certificate", handler.handle(handler.getResultCertificate))
+	}
+
+	r.POST("/api/auth/register", authHandler.Register)
+	r.POST("/api/auth/login", authHandler.Login)
+	r.GET("/api/polls/:id/stats", auth.OptionalAuthMiddleware(jwtManager), handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), handler.handle(handler.getPollStats))
+	r.GET("/api/polls/:id/stats/wait", handler.handle(handler.waitPollStats))
+	r.GET("/api/polls/:id/results", handler.handle(handler.getPublicResults))
+	r.GET("/api/polls/:id/og", handler.handle(handler.getPollPreview))
+	r.GET("/api/polls/:id/og/image.png", handler.handle(handler.getPollPreviewImage))
+	r.GET("/api/polls/:id/winner", handler.handle(handler.getPollWinner))
+	r.GET("/api/results/key", handler.handle(handler.getResultSigningKey))
+	r.GET("/readyz", handler.readiness)
+
+	return r, mockService, handler, authHandler, jwtManager
+}
+
+func TestCreatePoll(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+
+		req := domain.CreatePollRequest{
+			Title:   "Test Poll",
+			Options: []string{"Option 1", "Option 2"},
+			Tags:    []string{"test"},
+		}
+
+		pollID := uuid.New()
+		expected := req
+		expected.CreatorID = userID
+		optionID := uuid.New()
+		mockService.On("CreatePoll", mock.Anything, &expected).Return(&domain.Poll{
+			ID:      pollID,
+			Title:   req.Title,
+			Options: []domain.Option{{ID: optionID, PollID: pollID, OptionText: "Option 1"}},
+		}, nil)
+
+		w := httptest.NewRecorder()
+		body, _ := json.Marshal(req)
+		request, _ := http.NewRequest("POST", "/api/polls", bytes.NewBuffer(body))
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response map[string]interface{}
 		json.Unmarshal(w.Body.Bytes(), &response)
 		assert.Equal(t, "success", response["status"])
-		assert.Equal(t, pollID.String(), response["poll_id"])
+		assert.Equal(t, pollID.String(), response["poll_id"])
+		assert.Equal(t, "/api/polls/"+pollID.String(), w.Header().Get("Location"))
+		poll := response["poll"].(map[string]interface{})
+		assert.Equal(t, optionID.String(), poll["options"].([]interface{})[0].(map[string]interface{})["id"])
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		r, _, _, _, _ := setupTest(t)
+		req := domain.CreatePollRequest{
+			Title:   "Test Poll",
+			Options: []string{"Option 1", "Option 2"},
+			Tags:    []string{"test"},
+		}
+
+		w := httptest.NewRecorder()
+		body, _ := json.Marshal(req)
+		request, _ := http.NewRequest("POST", "/api/polls", bytes.NewBuffer(body))
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("creation limit", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: uuid.New()})
+		resetAt := time.Now().Add(90 * time.Minute)
+		mockService.On("CreatePoll", mock.Anything, mock.Anything).
+			Return(nil, &domain.CreationLimitError{Limit: 20, ResetAt: resetAt})
+
+		req := domain.CreatePollRequest{
+			Title:   "Test Poll",
+			Options: []string{"Option 1", "Option 2"},
+			Tags:    []string{"test"},
+		}
+		w := httptest.NewRecorder()
+		body, _ := json.Marshal(req)
+		request, _ := http.NewRequest("POST", "/api/polls", bytes.NewBuffer(body))
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+		require.NoError(t, err)
+		assert.InDelta(t, 5400, retryAfter, 5)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, float64(20), response["limit"])
+	})
+}
+
+func TestRateLimitShadowMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	serve := func(policy RateLimitPolicy) []*httptest.ResponseRecorder {
+		handler := NewHandler(new(MockService), NewMockRedis(), zap.NewNop(), nil, WithRateLimits(policy, policy))
+		r := gin.New()
+		r.GET("/limited", handler.rateLimiter.RateLimit(), handler.rateLimiter.BurstLimit(), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		var responses []*httptest.ResponseRecorder
+		for i := 0; i < 3; i++ {
+			w := httptest.NewRecorder()
+			request, _ := http.NewRequest("GET", "/limited?userId=alice", nil)
+			r.ServeHTTP(w, request)
+			responses = append(responses, w)
+		}
+		return responses
+	}
+
+	t.Run("enforced", func(t *testing.T) {
+		responses := serve(RateLimitPolicy{Limit: 2, Window: time.Minute})
+		assert.Equal(t, http.StatusOK, responses[1].Code)
+		assert.Equal(t, "2", responses[1].Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, http.StatusTooManyRequests, responses[2].Code)
+	})
+
+	t.Run("shadow", func(t *testing.T) {
+		responses := serve(RateLimitPolicy{Limit: 2, Window: time.Minute, Shadow: true})
+		for _, w := range responses {
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+		}
+	})
+}
+
+func TestValidatePoll(t *testing.T) {
+	r, mockService, _, _, jwtManager := setupTest(t)
+	userID := uuid.New()
+	token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+
+	mockService.On("ValidatePoll", mock.Anything, mock.MatchedBy(func(req *domain.CreatePollRequest) bool {
+		return req.CreatorID == userID && req.Title == "" && len(req.Options) == 1
+	})).Return([]*domain.ValidationError{
+		{Field: "title", Reason: "must not be empty"},
+		{Field: "options", Reason: "at least 2 options are required"},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/polls/validate", bytes.NewBufferString(`{"options": ["Go"]}`))
+	request.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(w, request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Valid  bool                      `json:"valid"`
+		Errors []*domain.ValidationError `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.False(t, response.Valid)
+	require.Len(t, response.Errors, 2)
+	assert.Equal(t, "title", response.Errors[0].Field)
+	assert.Equal(t, "options", response.Errors[1].Field)
+	mockService.AssertExpectations(t)
+}
+
+func TestVoteOnPoll(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		pollID := uuid.New()
+
+		req := domain.VoteRequest{
+			UserID:      userID,
+			OptionIndex: 0,
+		}
+
+		voteID := uuid.New()
+		mockService.On("VoteOnPoll", mock.Anything, pollID, &req).Return(&domain.VoteReceipt{VoteID: voteID, PollID: pollID}, nil)
+		mockService.On("GetVoteAllowance", mock.Anything, userID).
+			Return(&domain.VoteAllowance{Limit: domain.MaxDailyVotes, Used: 97, Remaining: 3}, nil)
+
+		w := httptest.NewRecorder()
+		body, _ := json.Marshal(req)
+		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/vote", bytes.NewBuffer(body))
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var result map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &result)
+		assert.Equal(t, "success", result["status"])
+		assert.Equal(t, float64(3), result["dailyVotes"].(map[string]interface{})["remaining"])
+		assert.Equal(t, voteID.String(), result["voteId"])
+		assert.Equal(t, "/api/users/me/votes/"+voteID.String(), w.Header().Get("Location"))
+	})
+
+	t.Run("already voted", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		pollID := uuid.New()
+
+		req := domain.VoteRequest{
+			UserID:      userID,
+			OptionIndex: 0,
+		}
+
+		voteID := uuid.New()
+		mockService.On("VoteOnPoll", mock.Anything, pollID, &req).Return(nil, &domain.AlreadyVotedError{VoteID: voteID, OptionIndex: 1})
+
+		w := httptest.NewRecorder()
+		body, _ := json.Marshal(req)
+		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/vote", bytes.NewBuffer(body))
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "already_voted", resp["code"])
+		assert.Equal(t, voteID.String(), resp["voteId"])
+		assert.Equal(t, float64(1), resp["optionIndex"])
+	})
+
+	t.Run("banned", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		pollID := uuid.New()
+
+		req := domain.VoteRequest{
+			UserID:      userID,
+			OptionIndex: 0,
+		}
+
+		mockService.On("VoteOnPoll", mock.Anything, pollID, &req).Return(nil, domain.ErrBanned)
+
+		w := httptest.NewRecorder()
+		body, _ := json.Marshal(req)
+		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/vote", bytes.NewBuffer(body))
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		r, _, _, _, _ := setupTest(t)
+		pollID := uuid.New()
+		req := domain.VoteRequest{
+			OptionIndex: 0,
+		}
+
+		w := httptest.NewRecorder()
+		body, _ := json.Marshal(req)
+		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/vote", bytes.NewBuffer(body))
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("ignores client user id", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		pollID := uuid.New()
+
+		mockService.On("VoteOnPoll", mock.Anything, pollID, &domain.VoteRequest{
+			UserID:      userID,
+			OptionIndex: 1,
+		}).Return(&domain.VoteReceipt{VoteID: uuid.New(), PollID: pollID}, nil)
+		mockService.On("GetVoteAllowance", mock.Anything, userID).Return(nil, errors.New("redis down"))
+
+		w := httptest.NewRecorder()
+		body, _ := json.Marshal(gin.H{"userId": uuid.New(), "optionIndex": 1})
+		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/vote", bytes.NewBuffer(body))
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("by option id", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		pollID, optionID := uuid.New(), uuid.New()
+
+		mockService.On("VoteOnPoll", mock.Anything, pollID, &domain.VoteRequest{
+			UserID:   userID,
+			OptionID: &optionID,
+		}).Return(&domain.VoteReceipt{VoteID: uuid.New(), PollID: pollID}, nil)
+		mockService.On("GetVoteAllowance", mock.Anything, userID).Return(nil, errors.New("redis down"))
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/vote", strings.NewReader(`{"optionId":"`+optionID.String()+`"}`))
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("missing option", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: uuid.New()})
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/api/polls/"+uuid.New().String()+"/vote", strings.NewReader(`{}`))
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "VoteOnPoll", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("access code without option", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: uuid.New()})
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/api/polls/"+uuid.New().String()+"/vote", strings.NewReader(`{"accessCode":"1234"}`))
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "VoteOnPoll", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("empty body without default option", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		pollID := uuid.New()
+
+		mockService.On("VoteOnPoll", mock.Anything, pollID, &domain.VoteRequest{UserID: userID, UseDefault: true}).
+			Return(nil, domain.ErrOptionRequired)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/vote", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "option_required", resp["code"])
+	})
+
+	t.Run("empty body votes for the default option", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		pollID := uuid.New()
+
+		mockService.On("VoteOnPoll", mock.Anything, pollID, &domain.VoteRequest{UserID: userID, UseDefault: true}).
+			Return(&domain.VoteReceipt{VoteID: uuid.New(), PollID: pollID}, nil)
+		mockService.On("GetVoteAllowance", mock.Anything, userID).Return(&domain.VoteAllowance{}, nil)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/vote", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("empty chunked body votes for the default option", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		pollID := uuid.New()
+
+		mockService.On("VoteOnPoll", mock.Anything, pollID, &domain.VoteRequest{UserID: userID, UseDefault: true}).
+			Return(&domain.VoteReceipt{VoteID: uuid.New(), PollID: pollID}, nil)
+		mockService.On("GetVoteAllowance", mock.Anything, userID).Return(&domain.VoteAllowance{}, nil)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/vote", io.NopCloser(strings.NewReader("")))
+		request.ContentLength = -1
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("chunked body names the option", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		pollID := uuid.New()
+
+		mockService.On("VoteOnPoll", mock.Anything, pollID, &domain.VoteRequest{UserID: userID, OptionIndex: 1}).
+			Return(&domain.VoteReceipt{VoteID: uuid.New(), PollID: pollID}, nil)
+		mockService.On("GetVoteAllowance", mock.Anything, userID).Return(&domain.VoteAllowance{}, nil)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/vote", io.NopCloser(strings.NewReader(`{"optionIndex": 1}`)))
+		request.ContentLength = -1
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestRespondError(t *testing.T) {
+	h := &Handler{logger: zap.NewNop()}
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    string
+		message string
+	}{
+		{"mapped", fmt.Errorf("vote: %w", domain.ErrAlreadyVoted), http.StatusConflict, "already_voted", "vote: " + domain.ErrAlreadyVoted.Error()},
+		{"fixed message", domain.ErrBanned, http.StatusForbidden, "banned", "Account is banned"},
+		{"hidden results", domain.ErrResultsHidden, http.StatusForbidden, "results_hidden", domain.ErrResultsHidden.Error()},
+		{"restricted tag", &domain.TagRestrictedError{Tag: "elections"}, http.StatusForbidden, "tag_restricted", `only verified users can post to "elections"`},
+		{"described", describe(domain.ErrNotFound, domain.ErrNotFound, "Poll not found"), http.StatusNotFound, "not_found", "Poll not found"},
+		{"described other error", describe(domain.ErrPollNotOpen, domain.ErrNotFound, "Poll not found"), http.StatusConflict, "poll_not_open", domain.ErrPollNotOpen.Error()},
+		{"handler error", badRequest("Invalid poll ID"), http.StatusBadRequest, "invalid_input", "Invalid poll ID"},
+		{"unmapped", describe(errors.New("db down"), errors.New("other"), "hidden"), http.StatusInternalServerError, "internal", "Internal server error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+			h.respondError(c, tt.err)
+
+			assert.Equal(t, tt.status, w.Code)
+			var result map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+			assert.Equal(t, "error", result["status"])
+			assert.Equal(t, tt.code, result["code"])
+			assert.Equal(t, tt.message, result["message"])
+		})
+	}
+}
+
+func TestGetUserLimits(t *testing.T) {
+	r, mockService, _, _, jwtManager := setupTest(t)
+	userID := uuid.New()
+	token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+	resetAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	mockService.On("GetVoteAllowance", mock.Anything, userID).
+		Return(&domain.VoteAllowance{Limit: domain.MaxDailyVotes, Used: 100, ResetAt: &resetAt}, nil)
+
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/users/me/limits", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(w, request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var result struct {
+		Limits struct {
+			DailyVotes domain.VoteAllowance `json:"dailyVotes"`
+		} `json:"limits"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 0, result.Limits.DailyVotes.Remaining)
+	require.NotNil(t, result.Limits.DailyVotes.ResetAt)
+	assert.True(t, resetAt.Equal(*result.Limits.DailyVotes.ResetAt))
+}
+
+func TestGetUserVotes(t *testing.T) {
+	r, mockService, _, _, jwtManager := setupTest(t)
+	userID := uuid.New()
+	token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 5, 8, 0, 0, 0, 0, time.UTC)
+	filter := domain.VoteFilter{From: &from, To: &to, IncludeDeleted: true}
+
+	doRequest := func(query, accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/users/me/votes"+query, nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		if accept != "" {
+			request.Header.Set("Accept", accept)
+		}
+		r.ServeHTTP(w, request)
+		return w
+	}
+
+	t.Run("json with filters", func(t *testing.T) {
+		mockService.On("GetUserVotes", mock.Anything, userID, filter, 1, 10).
+			Return(&domain.UserVotesResponse{Page: 1, Limit: 10}, nil).Once()
+
+		w := doRequest("?from=2024-05-01&to=2024-05-07&include_deleted=true", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("csv export", func(t *testing.T) {
+		deletedAt := time.Date(2024, 5, 3, 12, 0, 0, 0, time.UTC)
+		vote := domain.VoteResponse{
+			ID:          uuid.New(),
+			PollID:      uuid.New(),
+			OptionID:    uuid.New(),
+			CreatedAt:   time.Date(2024, 5, 2, 9, 30, 0, 0, time.UTC),
+			PollTitle:   "Lunch, today?",
+			PollStatus:  domain.PollStatusClosed,
+			OptionText:  "Pizza",
+			OptionIndex: 2,
+			DeletedAt:   &deletedAt,
+		}
+		mockService.On("ExportUserVotes", mock.Anything, userID, filter, mock.Anything).
+			Run(func(args mock.Arguments) {
+				fn := args.Get(3).(func(*domain.VoteResponse) error)
+				require.NoError(t, fn(&vote))
+			}).
+			Return(nil).Once()
+
+		w := doRequest("?from=2024-05-01&to=2024-05-07&include_deleted=true", "text/csv")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "votes.csv")
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.Equal(t, "vote_id,poll_id,poll_title,poll_status,option_id,option_index,option_text,created_at,deleted_at", lines[0])
+		assert.Contains(t, lines[1], `"Lunch, today?",closed,`)
+		assert.Contains(t, lines[1], ",2,Pizza,")
+		assert.True(t, strings.HasSuffix(lines[1], ",2024-05-02T09:30:00Z,2024-05-03T12:00:00Z"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("csv export failure", func(t *testing.T) {
+		mockService.On("ExportUserVotes", mock.Anything, userID, domain.VoteFilter{}, mock.Anything).
+			Return(errors.New("db down")).Once()
+
+		w := doRequest("", "text/csv")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Disposition"))
+	})
+
+	for _, query := range []string{"?from=yesterday", "?to=2024-13-01", "?from=2024-05-08&to=2024-05-01", "?include_deleted=maybe"} {
+		t.Run("bad query "+query, func(t *testing.T) {
+			w := doRequest(query, "")
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestExportPollVotes(t *testing.T) {
+	r, mockService, handler, _, jwtManager := setupTest(t)
+	userID := uuid.New()
+	token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+	pollID := uuid.New()
+
+	voterID := uuid.New()
+	votes := []domain.ExportedVote{
+		{
+			VoteID:      uuid.New(),
+			PollID:      pollID,
+			VoterID:     &voterID,
+			OptionID:    uuid.New(),
+			OptionIndex: 1,
+			OptionText:  "Tea, black",
+			CreatedAt:   time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			VoteID:     uuid.New(),
+			PollID:     pollID,
+			OptionID:   uuid.New(),
+			OptionText: "Coffee",
+			CreatedAt:  time.Date(2024, 6, 1, 9, 5, 0, 0, time.UTC),
+		},
+	}
+	streamVotes := func(args mock.Arguments) {
+		fn := args.Get(3).(func(*domain.ExportedVote) error)
+		for i := range votes {
+			require.NoError(t, fn(&votes[i]))
+		}
+	}
+
+	doRequest := func(query, accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String()+"/votes/export"+query, nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		if accept != "" {
+			request.Header.Set("Accept", accept)
+		}
+		r.ServeHTTP(w, request)
+		return w
+	}
+
+	t.Run("ndjson by default", func(t *testing.T) {
+		mockService.On("ExportPollVotes", mock.Anything, pollID, domain.VoteExportQuery{ActorID: userID}, mock.Anything).
+			Run(streamVotes).Return(nil).Once()
+
+		w := doRequest("", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "votes.ndjson")
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		require.Len(t, lines, 2)
+		var first, second map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+		assert.Equal(t, voterID.String(), first["voterId"])
+		assert.NotContains(t, second, "voterId")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		mockService.On("ExportPollVotes", mock.Anything, pollID, domain.VoteExportQuery{ActorID: userID}, mock.Anything).
+			Run(streamVotes).Return(nil).Twice()
+
+		for _, w := range []*httptest.ResponseRecorder{doRequest("?format=csv", ""), doRequest("", "text/csv")} {
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv"))
+			lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+			require.Len(t, lines, 3)
+			assert.Equal(t, "vote_id,poll_id,voter_id,option_id,option_index,option_text,created_at", lines[0])
+			assert.Contains(t, lines[1], ","+voterID.String()+",")
+			assert.Contains(t, lines[1], `,1,"Tea, black",2024-06-01T09:00:00Z`)
+			assert.Contains(t, lines[2], pollID.String()+",,")
+		}
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		handler.admins = map[uuid.UUID]struct{}{userID: {}}
+		defer func() { handler.admins = nil }()
+		mockService.On("ExportPollVotes", mock.Anything, pollID, domain.VoteExportQuery{ActorID: userID, Admin: true}, mock.Anything).
+			Return(nil).Once()
+
+		w := doRequest("", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.String())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("not the creator", func(t *testing.T) {
+		mockService.On("ExportPollVotes", mock.Anything, pollID, mock.Anything, mock.Anything).
+			Return(domain.ErrForbidden).Once()
+
+		w := doRequest("", "")
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("bad format", func(t *testing.T) {
+		w := doRequest("?format=xml", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAnalytics(t *testing.T) {
+	r, mockService, _, _, jwtManager := setupTest(t)
+	userID := uuid.New()
+	token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+
+	doRequest := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", path, nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+		return w
+	}
+
+	t.Run("user activity over whole days", func(t *testing.T) {
+		from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2024, 6, 8, 0, 0, 0, 0, time.UTC)
+		mockService.On("GetUserActivity", mock.Anything, userID, from, to).
+			Return([]domain.UserActivity{{Day: from, Votes: 3, Skips: 1}}, nil).Once()
+
+		w := doRequest("/api/users/me/activity?from=2024-06-01T10:00:00Z&to=2024-06-07")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		activity := response["activity"].([]interface{})
+		require.Len(t, activity, 1)
+		assert.Equal(t, float64(3), activity[0].(map[string]interface{})["votes"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("poll timeline rounds up to the hour", func(t *testing.T) {
+		pollID := uuid.New()
+		from := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+		to := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+		mockService.On("GetPollVoteTimeline", mock.Anything, pollID, userID, from, to).
+			Return([]domain.VoteBucket{{Start: from, Votes: 7}}, nil).Once()
+
+		w := doRequest("/api/polls/" + pollID.String() + "/analytics/hourly?from=2024-06-01T09:15:00Z&to=2024-06-01T11:30:00Z")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("poll timeline for a stranger", func(t *testing.T) {
+		mockService.On("GetPollVoteTimeline", mock.Anything, mock.Anything, userID, mock.Anything, mock.Anything).
+			Return(nil, domain.ErrForbidden).Once()
+
+		w := doRequest("/api/polls/" + uuid.New().String() + "/analytics/hourly")
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	for _, query := range []string{"?from=yesterday", "?from=2024-06-08&to=2024-06-01", "?from=2023-01-01&to=2024-06-01"} {
+		t.Run("bad range "+query, func(t *testing.T) {
+			w := doRequest("/api/users/me/activity" + query)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestGetPollStats(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		r, mockService, _, _, _ := setupTest(t)
+		pollID := uuid.New()
+
+		stats := &domain.PollStats{
+			PollID: pollID,
+			Votes: []domain.OptionStats{
+				{Option: "Option 1", Count: 10, Percentage: 66.67},
+				{Option: "Option 2", Count: 5, Percentage: 33.33},
+			},
+			TotalVotes: 15,
+			MyOption:   "Option 2",
+		}
+
+		mockService.On("GetPollStats", mock.Anything, mock.MatchedBy(func(id uuid.UUID) bool {
+			return id == pollID
+		}), domain.StatsQuery{}).Return(stats, nil).Once()
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String()+"/stats", nil)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "success", response["status"])
+
+		data, ok := response["data"].(map[string]interface{})
+		assert.True(t, ok, "data field should be a map")
+		assert.Equal(t, pollID.String(), data["poll_id"])
+		assert.Equal(t, float64(15), data["total_votes"])
+		assert.Equal(t, "Option 2", data["my_option"])
+
+		votes, ok := data["votes"].([]interface{})
+		assert.True(t, ok, "votes field should be an array")
+		assert.Equal(t, 2, len(votes))
+
+		vote1, ok := votes[0].(map[string]interface{})
+		assert.True(t, ok, "first vote should be a map")
+		assert.Equal(t, "Option 1", vote1["option"])
+		assert.Equal(t, float64(10), vote1["count"])
+		assert.Equal(t, 66.67, vote1["percentage"])
+
+		vote2, ok := votes[1].(map[string]interface{})
+		assert.True(t, ok, "second vote should be a map")
+		assert.Equal(t, "Option 2", vote2["option"])
+		assert.Equal(t, float64(5), vote2["count"])
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		r, mockService, _, _, _ := setupTest(t)
+		pollID := uuid.New()
+
+		mockService.On("GetPollStats", mock.Anything, mock.MatchedBy(func(id uuid.UUID) bool {
+			return id == pollID
+		}), domain.StatsQuery{}).Return(nil, domain.ErrNotFound).Once()
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String()+"/stats", nil)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "error", response["status"])
+		assert.Equal(t, "Poll not found", response["message"])
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid poll ID", func(t *testing.T) {
+		r, _, _, _, _ := setupTest(t)
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/invalid-id/stats", nil)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "error", response["status"])
+		assert.Equal(t, "Invalid poll ID", response["message"])
+	})
+
+	t.Run("max age is passed to the service", func(t *testing.T) {
+		r, mockService, _, _, _ := setupTest(t)
+		pollID := uuid.New()
+		maxAge := 30 * time.Second
+
+		mockService.On("GetPollStats", mock.Anything, pollID, domain.StatsQuery{MaxAge: &maxAge}).
+			Return(&domain.PollStats{PollID: pollID, ComputedAt: time.Now().Add(-10 * time.Second)}, nil).Once()
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String()+"/stats?maxAge=30", nil)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "10", w.Header().Get("Age"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid max age", func(t *testing.T) {
+		r, _, _, _, _ := setupTest(t)
+		for _, maxAge := range []string{"-1", "soon"} {
+			w := httptest.NewRecorder()
+			request, _ := http.NewRequest("GET", "/api/polls/"+uuid.New().String()+"/stats?maxAge="+maxAge, nil)
+			r.ServeHTTP(w, request)
+			assert.Equal(t, http.StatusBadRequest, w.Code, maxAge)
+		}
+	})
+
+	t.Run("bypassing the cache requires authentication", func(t *testing.T) {
+		r, _, _, _, _ := setupTest(t)
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+uuid.New().String()+"/stats?maxAge=0", nil)
+		r.ServeHTTP(w, request)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("bypassing the cache as the owner", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		pollID := uuid.New()
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		zero := time.Duration(0)
+
+		mockService.On("GetPollStats", mock.Anything, pollID, domain.StatsQuery{MaxAge: &zero, ActorID: userID}).
+			Return(&domain.PollStats{PollID: pollID, ComputedAt: time.Now()}, nil).Once()
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String()+"/stats?maxAge=0", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("bypassing the cache as another user", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		pollID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: uuid.New()})
+
+		mockService.On("GetPollStats", mock.Anything, pollID, mock.Anything).Return(nil, domain.ErrForbidden).Once()
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String()+"/stats?maxAge=0", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestWaitPollStats(t *testing.T) {
+	t.Run("changed", func(t *testing.T) {
+		r, mockService, _, _, _ := setupTest(t)
+		pollID := uuid.New()
+		stats := &domain.PollStats{PollID: pollID, Votes: []domain.OptionStats{{Option: "Yes", Count: 3}}}
+
+		mockService.On("WaitPollStats", mock.Anything, pollID, uuid.Nil, int64(4), 10*time.Second).
+			Return(&domain.StatsUpdate{Version: 5, Changed: true, Stats: stats}, nil).Once()
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String()+"/stats/wait?version=4&timeout=10", nil)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, float64(5), data["version"])
+		assert.Equal(t, true, data["changed"])
+		assert.Len(t, data["votes"], 1)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("timed out", func(t *testing.T) {
+		r, mockService, _, _, _ := setupTest(t)
+		pollID := uuid.New()
+
+		mockService.On("WaitPollStats", mock.Anything, pollID, uuid.Nil, int64(0), 30*time.Second).
+			Return(&domain.StatsUpdate{}, nil).Once()
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String()+"/stats/wait", nil)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, false, data["changed"])
+		assert.NotContains(t, data, "votes")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		r, mockService, _, _, _ := setupTest(t)
+		mockService.On("WaitPollStats", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, domain.ErrStatsWaitUnavailable).Once()
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+uuid.New().String()+"/stats/wait", nil)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("bad query", func(t *testing.T) {
+		r, _, _, _, _ := setupTest(t)
+		for _, query := range []string{"version=-1", "version=x", "timeout=0", "timeout=61", "timeout=soon"} {
+			w := httptest.NewRecorder()
+			request, _ := http.NewRequest("GET", "/api/polls/"+uuid.New().String()+"/stats/wait?"+query, nil)
+			r.ServeHTTP(w, request)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
+
+func TestGetPollWinner(t *testing.T) {
+	t.Run("closed poll", func(t *testing.T) {
+		r, mockService, _, _, _ := setupTest(t)
+		pollID := uuid.New()
+		mockService.On("GetPollWinner", mock.Anything, pollID).Return(&domain.PollWinner{
+			PollID:   pollID,
+			Winner:   "B",
+			Votes:    2,
+			Total:    5,
+			Tied:     []string{"A", "B"},
+			TieBreak: domain.TieBreakEarliestLead,
+		}, nil).Once()
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String()+"/winner", nil)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, finalResultsCacheControl, w.Header().Get("Cache-Control"))
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, "B", data["winner"])
+		assert.Equal(t, "earliest_lead", data["tieBreak"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("open poll", func(t *testing.T) {
+		r, mockService, _, _, _ := setupTest(t)
+		mockService.On("GetPollWinner", mock.Anything, mock.Anything).Return(nil, domain.ErrPollNotClosed).Once()
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+uuid.New().String()+"/winner", nil)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		r, mockService, _, _, _ := setupTest(t)
+		mockService.On("GetPollWinner", mock.Anything, mock.Anything).Return(nil, domain.ErrNotFound).Once()
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+uuid.New().String()+"/winner", nil)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestGetPublicResults(t *testing.T) {
+	t.Run("final results are cacheable and revalidate", func(t *testing.T) {
+		r, mockService, _, _, _ := setupTest(t)
+		pollID := uuid.New()
+		endsAt := time.Now().Add(-time.Hour).UTC()
+
+		results := &domain.PollResults{
+			PollID: pollID,
+			Title:  "Favorite language",
+			EndsAt: &endsAt,
+			Final:  true,
+			Votes:  []domain.OptionStats{{Option: "Go", Count: 3}},
+			Total:  3,
+		}
+		mockService.On("GetPublicResults", mock.Anything, pollID).Return(results, nil).Twice()
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String()+"/results", nil)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, finalResultsCacheControl, w.Header().Get("Cache-Control"))
+		etag := w.Header().Get("ETag")
+		assert.NotEmpty(t, etag)
+
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, true, data["final"])
+		assert.Equal(t, float64(3), data["total"])
+
+		w = httptest.NewRecorder()
+		request, _ = http.NewRequest("GET", "/api/polls/"+pollID.String()+"/results", nil)
+		request.Header.Set("If-None-Match", etag)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.Bytes())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("not public", func(t *testing.T) {
+		r, mockService, _, _, _ := setupTest(t)
+		pollID := uuid.New()
+		mockService.On("GetPublicResults", mock.Anything, pollID).Return(nil, domain.ErrNotFound).Once()
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String()+"/results", nil)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestGetPollPreview(t *testing.T) {
+	t.Run("metadata", func(t *testing.T) {
+		r, mockService, _, _, _ := setupTest(t)
+		pollID := uuid.New()
+		preview := &domain.PollPreview{
+			PollID:      pollID,
+			Title:       "Favorite language",
+			Description: "3 votes so far across 2 options. Cast your vote.",
+			Status:      domain.PollStatusLive,
+			OptionCount: 2,
+			TotalVotes:  3,
+		}
+		mockService.On("GetPollPreview", mock.Anything, pollID).Return(preview, nil).Once()
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String()+"/og", nil)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, "Favorite language", data["title"])
+		assert.Equal(t, float64(3), data["totalVotes"])
+		assert.Equal(t, "/api/polls/"+pollID.String(), data["url"])
+		assert.Equal(t, "/api/polls/"+pollID.String()+"/og/image.png", data["imageUrl"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("public ids", func(t *testing.T) {
+		r, mockService, handler, _, _ := setupTest(t)
+		codec := publicid.NewSqids("test-secret")
+		WithIDCodec(publicid.AcceptUUIDs(codec))(handler)
+		pollID := uuid.New()
+		publicID := codec.Encode(pollID)
+		mockService.On("GetPollPreview", mock.Anything, pollID).Return(&domain.PollPreview{PollID: pollID}, nil).Twice()
+
+		for _, id := range []string{publicID, pollID.String()} {
+			w := httptest.NewRecorder()
+			request, _ := http.NewRequest("GET", "/api/polls/"+id+"/og", nil)
+			r.ServeHTTP(w, request)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			var response map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			data := response["data"].(map[string]interface{})
+			assert.Equal(t, "/api/polls/"+publicID, data["url"])
+		}
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+publicID[1:]+"/og", nil)
+		r.ServeHTTP(w, request)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("stored image", func(t *testing.T) {
+		r, mockService, _, _, _ := setupTest(t)
+		pollID := uuid.New()
+		image := &domain.PollPreviewImage{URL: "https://media.example.com/poll-cards/abc?sig=1"}
+		mockService.On("GetPollPreviewImage", mock.Anything, pollID).Return(image, nil).Once()
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String()+"/og/image.png", nil)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, image.URL, w.Header().Get("Location"))
+	})
+
+	t.Run("rendered image", func(t *testing.T) {
+		r, mockService, _, _, _ := setupTest(t)
+		pollID := uuid.New()
+		image := &domain.PollPreviewImage{PNG: []byte("\x89PNG\r\n\x1a\n")}
+		mockService.On("GetPollPreviewImage", mock.Anything, pollID).Return(image, nil).Once()
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String()+"/og/image.png", nil)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+		assert.Equal(t, image.PNG, w.Body.Bytes())
+	})
+
+	t.Run("not previewable", func(t *testing.T) {
+		r, mockService, _, _, _ := setupTest(t)
+		pollID := uuid.New()
+		mockService.On("GetPollPreview", mock.Anything, pollID).Return(nil, domain.ErrNotFound).Once()
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String()+"/og", nil)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+type fakeFeedStream struct {
+	polls chan *domain.Poll
+	prefs *domain.UserPreferences
+}
+
+func (s *fakeFeedStream) Subscribe(_ uuid.UUID, prefs *domain.UserPreferences) (<-chan *domain.Poll, func()) {
+	s.prefs = prefs
+	return s.polls, func() {}
+}
+
+func TestStreamFeed(t *testing.T) {
+	t.Run("pushes created polls", func(t *testing.T) {
+		r, mockService, handler, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		prefs := &domain.UserPreferences{FollowedTags: []string{"go"}}
+		mockService.On("GetUserPreferences", mock.Anything, userID).Return(prefs, nil)
+		stream := &fakeFeedStream{polls: make(chan *domain.Poll, 1)}
+		handler.feedStream = stream
+
+		server := httptest.NewServer(r)
+		defer server.Close()
+		request, _ := http.NewRequest("GET", server.URL+"/api/feed/stream", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		assert.Same(t, prefs, stream.prefs)
+
+		pollID := uuid.New()
+		stream.polls <- &domain.Poll{ID: pollID, Title: "Favourite IDE?", Tags: []string{"go"}}
+		close(stream.polls)
+
+		reader := bufio.NewReader(resp.Body)
+		event, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "event:poll.created\n", event)
+		data, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		assert.Contains(t, data, pollID.String())
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		r, _, _, _, jwtManager := setupTest(t)
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: uuid.New()})
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/feed/stream", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+func TestGetPollsForFeed(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+
+		pollID := uuid.New()
+		option1ID := uuid.New()
+		option2ID := uuid.New()
+		response := &domain.PollFeedResponse{
+			Polls: []domain.Poll{
+				{
+					ID:    pollID,
+					Title: "Test Poll",
+					Options: []domain.Option{
+						{ID: option1ID, OptionText: "Option 1"},
+						{ID: option2ID, OptionText: "Option 2"},
+					},
+					Tags:      []string{"test"},
+					CreatedAt: time.Now(),
+					UpdatedAt: time.Now(),
+				},
+			},
+			Total: 1,
+			Page:  1,
+			Limit: 10,
+		}
+
+		query := domain.FeedQuery{UserID: userID, Page: 1, Limit: 10}
+		mockService.On("GetPollsForFeed", mock.Anything, query).Return(response, nil)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls?page=1&limit=10", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &result)
+		assert.NoError(t, err)
+		assert.Equal(t, "success", result["status"])
+
+		data, ok := result["data"].(map[string]interface{})
+		assert.True(t, ok)
+		assert.Equal(t, float64(1), data["total"])
+		assert.Equal(t, float64(1), data["page"])
+		assert.Equal(t, float64(10), data["limit"])
+
+		polls, ok := data["polls"].([]interface{})
+		assert.True(t, ok)
+		assert.Equal(t, 1, len(polls))
+
+		poll, ok := polls[0].(map[string]interface{})
+		assert.True(t, ok)
+		assert.Equal(t, pollID.String(), poll["id"])
+		assert.Equal(t, "Test Poll", poll["title"])
+	})
+
+	t.Run("cursor", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+
+		after := &domain.FeedCursor{CreatedAt: time.Now().UTC().Truncate(time.Second), ID: uuid.New()}
+		next := domain.FeedCursor{CreatedAt: after.CreatedAt.Add(-time.Minute), ID: uuid.New()}.Encode()
+		query := domain.FeedQuery{UserID: userID, Page: 1, Limit: 10, After: after}
+		mockService.On("GetPollsForFeed", mock.Anything, query).
+			Return(&domain.PollFeedResponse{Page: 1, Limit: 10, NextCursor: next}, nil)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls?cursor="+after.Encode(), nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		data := result["data"].(map[string]interface{})
+		assert.Equal(t, next, data["nextCursor"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("total modes", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+
+		none := domain.FeedQuery{UserID: userID, Page: 1, Limit: 10, Total: domain.FeedTotalNone}
+		mockService.On("GetPollsForFeed", mock.Anything, none).
+			Return(&domain.PollFeedResponse{Page: 1, Limit: 10}, nil)
+		estimate := domain.FeedQuery{UserID: userID, Page: 1, Limit: 10, Total: domain.FeedTotalEstimate}
+		mockService.On("GetPollsForFeed", mock.Anything, estimate).
+			Return(&domain.PollFeedResponse{Total: 1200, TotalEstimated: true, Page: 1, Limit: 10}, nil)
+
+		get := func(total string) map[string]interface{} {
+			w := httptest.NewRecorder()
+			request, _ := http.NewRequest("GET", "/api/polls?total="+total, nil)
+			request.Header.Set("Authorization", "Bearer "+token)
+			r.ServeHTTP(w, request)
+			require.Equal(t, http.StatusOK, w.Code)
+			var result map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+			return result["data"].(map[string]interface{})
+		}
+
+		data := get("false")
+		assert.NotContains(t, data, "total")
+		assert.NotContains(t, data, "totalEstimated")
+
+		data = get("estimate")
+		assert.Equal(t, float64(1200), data["total"])
+		assert.Equal(t, true, data["totalEstimated"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("languages", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+
+		listed := domain.FeedQuery{UserID: userID, Page: 1, Limit: 10, Languages: []string{"en", "fr"}}
+		mockService.On("GetPollsForFeed", mock.Anything, listed).
+			Return(&domain.PollFeedResponse{Page: 1, Limit: 10}, nil).Once()
+		all := domain.FeedQuery{UserID: userID, Page: 1, Limit: 10, AllLanguages: true}
+		mockService.On("GetPollsForFeed", mock.Anything, all).
+			Return(&domain.PollFeedResponse{Page: 1, Limit: 10}, nil).Once()
+
+		for _, lang := range []string{"en,fr", "all"} {
+			w := httptest.NewRecorder()
+			request, _ := http.NewRequest("GET", "/api/polls?lang="+lang, nil)
+			request.Header.Set("Authorization", "Bearer "+token)
+			r.ServeHTTP(w, request)
+			assert.Equal(t, http.StatusOK, w.Code)
+		}
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid total", func(t *testing.T) {
+		r, _, _, _, jwtManager := setupTest(t)
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: uuid.New()})
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls?total=maybe", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		r, _, _, _, jwtManager := setupTest(t)
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: uuid.New()})
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls?cursor=garbage", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 
 	t.Run("unauthorized", func(t *testing.T) {
 		r, _, _, _, _ := setupTest(t)
-		req := domain.CreatePollRequest{
-			Title:   "Test Poll",
-			Options: []string{"Option 1", "Option 2"},
-			Tags:    []string{"test"},
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls", nil)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestSearchPolls(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: uuid.New()})
+
+		pollID := uuid.New()
+		query := domain.PollSearchQuery{Query: "pizza", Tags: []string{"food"}, Page: 1, Limit: 10}
+		mockService.On("SearchPolls", mock.Anything, query).Return(&domain.PollSearchResult{
+			Polls:  []domain.Poll{{ID: pollID, Title: "Best pizza"}},
+			Total:  1,
+			Facets: []domain.TagFacet{{Tag: "food", Count: 1}},
+			Page:   1,
+			Limit:  10,
+		}, nil)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/search?q=pizza&tag=food", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result struct {
+			Data domain.PollSearchResult `json:"data"`
 		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		require.Len(t, result.Data.Polls, 1)
+		assert.Equal(t, pollID, result.Data.Polls[0].ID)
+		assert.Equal(t, []domain.TagFacet{{Tag: "food", Count: 1}}, result.Data.Facets)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: uuid.New()})
+		mockService.On("SearchPolls", mock.Anything, mock.Anything).Return(nil, domain.ErrInvalidInput)
 
 		w := httptest.NewRecorder()
-		body, _ := json.Marshal(req)
-		request, _ := http.NewRequest("POST", "/api/polls", bytes.NewBuffer(body))
+		request, _ := http.NewRequest("GET", "/api/polls/search?q=x", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		r, _, _, _, jwtManager := setupTest(t)
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: uuid.New()})
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/search?q=x&limit=500", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+type stubLocator map[string]domain.GeoLocation
+
+func (s stubLocator) Locate(ip net.IP) (domain.GeoLocation, bool) {
+	location, ok := s[ip.String()]
+	return location, ok
+}
+
+func TestVoteOnPollGeoIP(t *testing.T) {
+	t.Run("passes location", func(t *testing.T) {
+		r, mockService, handler, _, jwtManager := setupTest(t)
+		handler.geo = stubLocator{"192.0.2.1": {Country: "GB", Region: "ENG"}}
+		userID := uuid.New()
+		pollID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+
+		mockService.On("VoteOnPoll", mock.Anything, pollID, mock.MatchedBy(func(req *domain.VoteRequest) bool {
+			return req.Location != nil && *req.Location == domain.GeoLocation{Country: "GB", Region: "ENG"}
+		})).Return(&domain.VoteReceipt{VoteID: uuid.New(), PollID: pollID}, nil)
+		mockService.On("GetVoteAllowance", mock.Anything, userID).Return(&domain.VoteAllowance{}, nil)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/vote", strings.NewReader(`{"optionIndex":0}`))
+		request.RemoteAddr = "192.0.2.1:4321"
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("restricted", func(t *testing.T) {
+		r, mockService, handler, _, jwtManager := setupTest(t)
+		handler.geo = stubLocator{}
+		userID := uuid.New()
+		pollID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+
+		mockService.On("VoteOnPoll", mock.Anything, pollID, mock.MatchedBy(func(req *domain.VoteRequest) bool {
+			return req.Location == nil
+		})).Return(nil, domain.ErrGeoRestricted)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/vote", strings.NewReader(`{"optionIndex":0}`))
+		request.RemoteAddr = "198.51.100.7:4321"
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestUploadMedia(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	t.Run("avatar", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+
+		mockService.On("SetUserAvatar", mock.Anything, userID, mock.MatchedBy(func(u *domain.MediaUpload) bool {
+			return u.Size == int64(len(png)) && u.ContentType == "image/png"
+		})).Return(&domain.User{ID: userID, AvatarURL: "https://media.example.com/avatars/a"}, nil)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("PUT", "/api/users/me/avatar", bytes.NewReader(png))
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("Content-Type", "image/png")
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "https://media.example.com/avatars/a")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		r, _, _, _, jwtManager := setupTest(t)
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: uuid.New()})
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("PUT", "/api/users/me/avatar", bytes.NewReader(make([]byte, 3<<20)))
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("Content-Type", "image/png")
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		mockService.On("SetUserAvatar", mock.Anything, userID, mock.Anything).Return(nil, blob.ErrUnsupportedType)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("PUT", "/api/users/me/avatar", strings.NewReader("<svg/>"))
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("Content-Type", "image/svg+xml")
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	t.Run("option image", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		pollID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		mockService.On("SetOptionImage", mock.Anything, pollID, 1, userID, mock.Anything).Return(&domain.Poll{ID: pollID}, nil)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("PUT", "/api/polls/"+pollID.String()+"/options/1/image", bytes.NewReader(png))
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("Content-Type", "image/png")
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid option", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		pollID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		mockService.On("SetOptionImage", mock.Anything, pollID, 7, userID, mock.Anything).Return(nil, domain.ErrInvalidOption)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("PUT", "/api/polls/"+pollID.String()+"/options/7/image", bytes.NewReader(png))
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("Content-Type", "image/png")
 		r.ServeHTTP(w, request)
 
-		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 }
 
-func TestVoteOnPoll(t *testing.T) {
+func TestReorderOptions(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		r, mockService, _, _, jwtManager := setupTest(t)
 		userID := uuid.New()
-		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
 		pollID := uuid.New()
-
-		req := domain.VoteRequest{
-			UserID:      userID,
-			OptionIndex: 0,
-		}
-
-		mockService.On("VoteOnPoll", mock.Anything, pollID, &req).Return(nil)
+		first, second := uuid.New(), uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		mockService.On("ReorderOptions", mock.Anything, pollID, &domain.ReorderOptionsRequest{
+			OptionIDs: []uuid.UUID{second, first},
+			ActorID:   userID,
+		}).Return(&domain.Poll{ID: pollID}, nil)
 
 		w := httptest.NewRecorder()
-		body, _ := json.Marshal(req)
-		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/vote", bytes.NewBuffer(body))
+		body := `{"optionIds": ["` + second.String() + `", "` + first.String() + `"]}`
+		request, _ := http.NewRequest("PATCH", "/api/polls/"+pollID.String()+"/options/order", bytes.NewBufferString(body))
 		request.Header.Set("Authorization", "Bearer "+token)
 		r.ServeHTTP(w, request)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		var result map[string]interface{}
-		json.Unmarshal(w.Body.Bytes(), &result)
-		assert.Equal(t, "success", result["status"])
+		mockService.AssertExpectations(t)
 	})
 
-	t.Run("already voted", func(t *testing.T) {
+	t.Run("published poll", func(t *testing.T) {
 		r, mockService, _, _, jwtManager := setupTest(t)
-		userID := uuid.New()
-		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
 		pollID := uuid.New()
-
-		req := domain.VoteRequest{
-			UserID:      userID,
-			OptionIndex: 0,
-		}
-
-		mockService.On("VoteOnPoll", mock.Anything, pollID, &req).Return(domain.ErrAlreadyVoted)
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: uuid.New()})
+		mockService.On("ReorderOptions", mock.Anything, pollID, mock.Anything).Return(nil, domain.ErrPollPublished)
 
 		w := httptest.NewRecorder()
-		body, _ := json.Marshal(req)
-		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/vote", bytes.NewBuffer(body))
+		body := `{"optionIds": ["` + uuid.New().String() + `"]}`
+		request, _ := http.NewRequest("PATCH", "/api/polls/"+pollID.String()+"/options/order", bytes.NewBufferString(body))
 		request.Header.Set("Authorization", "Bearer "+token)
 		r.ServeHTTP(w, request)
 
 		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "poll_published")
 	})
+}
 
-	t.Run("unauthorized", func(t *testing.T) {
-		r, _, _, _, _ := setupTest(t)
+func TestUpdatePollTags(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
 		pollID := uuid.New()
-		req := domain.VoteRequest{
-			OptionIndex: 0,
-		}
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		mockService.On("UpdatePollTags", mock.Anything, pollID, &domain.UpdatePollTagsRequest{
+			Add:     []string{"go"},
+			Remove:  []string{"java"},
+			ActorID: userID,
+		}).Return(&domain.Poll{ID: pollID, Tags: []string{"go"}}, nil)
 
 		w := httptest.NewRecorder()
-		body, _ := json.Marshal(req)
-		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/vote", bytes.NewBuffer(body))
+		request, _ := http.NewRequest("PATCH", "/api/polls/"+pollID.String()+"/tags", bytes.NewBufferString(`{"add": ["go"], "remove": ["java"]}`))
+		request.Header.Set("Authorization", "Bearer "+token)
 		r.ServeHTTP(w, request)
 
-		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"tags":["go"]`)
 	})
-}
 
-func TestGetPollStats(t *testing.T) {
-	t.Run("success", func(t *testing.T) {
-		r, mockService, _, _, _ := setupTest(t)
+	t.Run("not an editor", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
 		pollID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: uuid.New()})
+		mockService.On("UpdatePollTags", mock.Anything, pollID, mock.Anything).Return(nil, domain.ErrForbidden)
 
-		stats := &domain.PollStats{
-			PollID: pollID,
-			Votes: []domain.OptionStats{
-				{Option: "Option 1", Count: 10},
-				{Option: "Option 2", Count: 5},
-			},
-		}
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("PATCH", "/api/polls/"+pollID.String()+"/tags", bytes.NewBufferString(`{"add": ["go"]}`))
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
 
-		mockService.On("GetPollStats", mock.Anything, mock.MatchedBy(func(id uuid.UUID) bool {
-			return id == pollID
-		})).Return(stats, nil).Once()
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		adminID := uuid.New()
+		pollID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: adminID, Role: domain.RoleAdmin})
+		mockService.On("UpdatePollTags", mock.Anything, pollID, &domain.UpdatePollTagsRequest{
+			Add:     []string{"go"},
+			ActorID: adminID,
+			Admin:   true,
+		}).Return(&domain.Poll{ID: pollID, Tags: []string{"go"}}, nil)
 
 		w := httptest.NewRecorder()
-		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String()+"/stats", nil)
+		request, _ := http.NewRequest("PATCH", "/api/polls/"+pollID.String()+"/tags", bytes.NewBufferString(`{"add": ["go"]}`))
+		request.Header.Set("Authorization", "Bearer "+token)
 		r.ServeHTTP(w, request)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		var response map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err)
-		assert.Equal(t, "success", response["status"])
+	})
+}
 
-		data, ok := response["data"].(map[string]interface{})
-		assert.True(t, ok, "data field should be a map")
-		assert.Equal(t, pollID.String(), data["poll_id"])
+func TestSetTagRule(t *testing.T) {
+	r, mockService, _, _, jwtManager := setupTest(t)
+	adminID := uuid.New()
+	token, _ := jwtManager.GenerateToken(&domain.User{ID: adminID, Role: domain.RoleAdmin})
+	mockService.On("SetTagRule", mock.Anything, "elections", &domain.TagRuleRequest{
+		AutoModerated: true,
+		ActorID:       adminID,
+	}).Return(&domain.TagRule{Tag: "elections", AutoModerated: true}, nil)
+
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/admin/tags/elections/rule", bytes.NewBufferString(`{"autoModerated": true}`))
+	request.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(w, request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"autoModerated":true`)
+}
 
-		votes, ok := data["votes"].([]interface{})
-		assert.True(t, ok, "votes field should be an array")
-		assert.Equal(t, 2, len(votes))
+func TestUpdateOption(t *testing.T) {
+	r, mockService, _, _, jwtManager := setupTest(t)
+	userID := uuid.New()
+	pollID := uuid.New()
+	token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+	mockService.On("UpdateOption", mock.Anything, pollID, 1, mock.MatchedBy(func(req *domain.UpdateOptionRequest) bool {
+		return req.ActorID == userID && *req.AltText == "A dog" && req.Emoji == nil
+	})).Return(&domain.Poll{ID: pollID, Options: []domain.Option{{}, {AltText: "A dog"}}}, nil)
+
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest("PATCH", "/api/polls/"+pollID.String()+"/options/1", bytes.NewBufferString(`{"altText": "A dog"}`))
+	request.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(w, request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"altText":"A dog"`)
+	mockService.AssertExpectations(t)
+}
 
-		vote1, ok := votes[0].(map[string]interface{})
-		assert.True(t, ok, "first vote should be a map")
-		assert.Equal(t, "Option 1", vote1["option"])
-		assert.Equal(t, float64(10), vote1["count"])
+func TestSignedUploads(t *testing.T) {
+	t.Run("sign", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
 
-		vote2, ok := votes[1].(map[string]interface{})
-		assert.True(t, ok, "second vote should be a map")
-		assert.Equal(t, "Option 2", vote2["option"])
-		assert.Equal(t, float64(5), vote2["count"])
+		req := &domain.SignUploadRequest{
+			UploadTarget: domain.UploadTarget{Target: domain.UploadTargetAvatar},
+			ContentType:  "image/png",
+			Size:         1024,
+		}
+		mockService.On("SignUpload", mock.Anything, userID, req).Return(&domain.SignedUpload{
+			Key:    "avatars/abc",
+			URL:    "https://media.example.com/avatars/abc?signature=x",
+			Method: http.MethodPut,
+		}, nil)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/api/uploads/sign", strings.NewReader(`{"target":"avatar","contentType":"image/png","size":1024}`))
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, request)
 
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result struct {
+			Upload domain.SignedUpload `json:"upload"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, "avatars/abc", result.Upload.Key)
 		mockService.AssertExpectations(t)
 	})
 
-	t.Run("not found", func(t *testing.T) {
-		r, mockService, _, _, _ := setupTest(t)
+	t.Run("sign too large", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: uuid.New()})
+		mockService.On("SignUpload", mock.Anything, mock.Anything, mock.Anything).Return(nil, blob.ErrTooLarge)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/api/uploads/sign", strings.NewReader(`{"target":"avatar","contentType":"image/png","size":99999999}`))
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("confirm option image", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
 		pollID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
 
-		mockService.On("GetPollStats", mock.Anything, mock.MatchedBy(func(id uuid.UUID) bool {
-			return id == pollID
-		})).Return(nil, domain.ErrNotFound).Once()
+		index := 0
+		req := &domain.ConfirmUploadRequest{
+			UploadTarget: domain.UploadTarget{Target: domain.UploadTargetOptionImage, PollID: &pollID, OptionIndex: &index},
+			Key:          "option-images/abc",
+		}
+		mockService.On("ConfirmUpload", mock.Anything, userID, req).Return(&domain.UploadConfirmation{
+			Key:  "option-images/abc",
+			Poll: &domain.Poll{ID: pollID},
+		}, nil)
 
+		body := `{"target":"option_image","pollId":"` + pollID.String() + `","optionIndex":0,"key":"option-images/abc"}`
 		w := httptest.NewRecorder()
-		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String()+"/stats", nil)
+		request, _ := http.NewRequest("POST", "/api/uploads/confirm", strings.NewReader(body))
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("Content-Type", "application/json")
 		r.ServeHTTP(w, request)
 
-		assert.Equal(t, http.StatusNotFound, w.Code)
-		var response map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err)
-		assert.Equal(t, "error", response["status"])
-		assert.Equal(t, "Poll not found", response["message"])
-
+		assert.Equal(t, http.StatusOK, w.Code)
 		mockService.AssertExpectations(t)
 	})
 
-	t.Run("invalid poll ID", func(t *testing.T) {
-		r, _, _, _, _ := setupTest(t)
+	t.Run("confirm missing upload", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: uuid.New()})
+		mockService.On("ConfirmUpload", mock.Anything, mock.Anything, mock.Anything).Return(nil, domain.ErrNotFound)
+
 		w := httptest.NewRecorder()
-		request, _ := http.NewRequest("GET", "/api/polls/invalid-id/stats", nil)
+		request, _ := http.NewRequest("POST", "/api/uploads/confirm", strings.NewReader(`{"target":"avatar","key":"avatars/abc"}`))
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("Content-Type", "application/json")
 		r.ServeHTTP(w, request)
 
-		assert.Equal(t, http.StatusBadRequest, w.Code)
-		var response map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err)
-		assert.Equal(t, "error", response["status"])
-		assert.Equal(t, "Invalid poll ID", response["message"])
+		assert.Equal(t, http.StatusNotFound, w.Code)
 	})
 }
 
-func TestGetPollsForFeed(t *testing.T) {
+func TestGetPollByID(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		r, mockService, _, _, jwtManager := setupTest(t)
 		userID := uuid.New()
 		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
-
 		pollID := uuid.New()
 		option1ID := uuid.New()
 		option2ID := uuid.New()
-		response := &domain.PollFeedResponse{
-			Polls: []domain.Poll{
-				{
-					ID:    pollID,
-					Title: "Test Poll",
-					Options: []domain.Option{
-						{ID: option1ID, OptionText: "Option 1"},
-						{ID: option2ID, OptionText: "Option 2"},
-					},
-					Tags:      []string{"test"},
-					CreatedAt: time.Now(),
-					UpdatedAt: time.Now(),
-				},
+
+		poll := &domain.Poll{
+			ID:    pollID,
+			Title: "Test Poll",
+			Options: []domain.Option{
+				{ID: option1ID, OptionText: "Option 1"},
+				{ID: option2ID, OptionText: "Option 2"},
 			},
-			Total: 1,
-			Page:  1,
-			Limit: 10,
+			Tags:      []string{"test"},
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
 		}
 
-		mockService.On("GetPollsForFeed", mock.Anything, userID, "", 1, 10).Return(response, nil)
+		mockService.On("GetPollByID", mock.Anything, pollID).Return(poll, nil)
 
 		w := httptest.NewRecorder()
-		request, _ := http.NewRequest("GET", "/api/polls?page=1&limit=10", nil)
+		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String(), nil)
 		request.Header.Set("Authorization", "Bearer "+token)
 		r.ServeHTTP(w, request)
 
@@ -483,162 +2527,237 @@ func TestGetPollsForFeed(t *testing.T) {
 
 		data, ok := result["data"].(map[string]interface{})
 		assert.True(t, ok)
-		assert.Equal(t, float64(1), data["total"])
-		assert.Equal(t, float64(1), data["page"])
-		assert.Equal(t, float64(10), data["limit"])
+		assert.Equal(t, pollID.String(), data["id"])
+		assert.Equal(t, "Test Poll", data["title"])
+		links := data["links"].(map[string]interface{})
+		assert.Equal(t, "/api/polls/"+pollID.String()+"/vote", links["vote"])
+		assert.NotContains(t, links, "share")
+	})
 
-		polls, ok := data["polls"].([]interface{})
-		assert.True(t, ok)
-		assert.Equal(t, 1, len(polls))
+	t.Run("not found", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		pollID := uuid.New()
 
-		poll, ok := polls[0].(map[string]interface{})
-		assert.True(t, ok)
-		assert.Equal(t, pollID.String(), poll["id"])
-		assert.Equal(t, "Test Poll", poll["title"])
+		mockService.On("GetPollByID", mock.Anything, pollID).Return(nil, domain.ErrNotFound)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String(), nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		var result map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &result)
+		assert.NoError(t, err)
+		assert.Equal(t, "error", result["status"])
+		assert.Equal(t, "poll not found", result["message"])
 	})
 
 	t.Run("unauthorized", func(t *testing.T) {
 		r, _, _, _, _ := setupTest(t)
+		pollID := uuid.New()
 		w := httptest.NewRecorder()
-		request, _ := http.NewRequest("GET", "/api/polls", nil)
+		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String(), nil)
 		r.ServeHTTP(w, request)
 
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
 }
 
-func TestGetPollByID(t *testing.T) {
+func TestSkipPoll(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		r, mockService, _, _, jwtManager := setupTest(t)
 		userID := uuid.New()
 		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
 		pollID := uuid.New()
-		option1ID := uuid.New()
-		option2ID := uuid.New()
 
-		poll := &domain.Poll{
-			ID:    pollID,
-			Title: "Test Poll",
-			Options: []domain.Option{
-				{ID: option1ID, OptionText: "Option 1"},
-				{ID: option2ID, OptionText: "Option 2"},
-			},
-			Tags:      []string{"test"},
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+		req := domain.SkipRequest{
+			UserID: userID,
+		}
+
+		mockService.On("SkipPoll", mock.Anything, pollID, &req).Return(nil)
+
+		w := httptest.NewRecorder()
+		body, _ := json.Marshal(req)
+		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/skip", bytes.NewBuffer(body))
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &result)
+		assert.Equal(t, "success", result["status"])
+	})
+
+	t.Run("already skipped", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		pollID := uuid.New()
+
+		req := domain.SkipRequest{
+			UserID: userID,
 		}
 
-		mockService.On("GetPollByID", mock.Anything, pollID).Return(poll, nil)
+		mockService.On("SkipPoll", mock.Anything, pollID, &req).Return(domain.ErrAlreadySkipped)
+
+		w := httptest.NewRecorder()
+		body, _ := json.Marshal(req)
+		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/skip", bytes.NewBuffer(body))
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("with reason", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		pollID := uuid.New()
+
+		mockService.On("SkipPoll", mock.Anything, pollID, &domain.SkipRequest{
+			UserID: userID,
+			Reason: domain.SkipOffensive,
+		}).Return(nil)
 
 		w := httptest.NewRecorder()
-		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String(), nil)
+		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/skip", bytes.NewBufferString(`{"reason": "offensive"}`))
 		request.Header.Set("Authorization", "Bearer "+token)
 		r.ServeHTTP(w, request)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		var result map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &result)
-		assert.NoError(t, err)
-		assert.Equal(t, "success", result["status"])
-
-		data, ok := result["data"].(map[string]interface{})
-		assert.True(t, ok)
-		assert.Equal(t, pollID.String(), data["id"])
-		assert.Equal(t, "Test Poll", data["title"])
+		mockService.AssertExpectations(t)
 	})
 
-	t.Run("not found", func(t *testing.T) {
+	t.Run("without body", func(t *testing.T) {
 		r, mockService, _, _, jwtManager := setupTest(t)
 		userID := uuid.New()
 		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
 		pollID := uuid.New()
 
-		mockService.On("GetPollByID", mock.Anything, pollID).Return(nil, domain.ErrNotFound)
+		mockService.On("SkipPoll", mock.Anything, pollID, &domain.SkipRequest{UserID: userID}).Return(nil)
 
 		w := httptest.NewRecorder()
-		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String(), nil)
+		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/skip", http.NoBody)
 		request.Header.Set("Authorization", "Bearer "+token)
 		r.ServeHTTP(w, request)
 
-		assert.Equal(t, http.StatusNotFound, w.Code)
-		var result map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &result)
-		assert.NoError(t, err)
-		assert.Equal(t, "error", result["status"])
-		assert.Equal(t, "poll not found", result["message"])
+		assert.Equal(t, http.StatusOK, w.Code)
 	})
 
 	t.Run("unauthorized", func(t *testing.T) {
 		r, _, _, _, _ := setupTest(t)
 		pollID := uuid.New()
+		req := domain.SkipRequest{}
+
 		w := httptest.NewRecorder()
-		request, _ := http.NewRequest("GET", "/api/polls/"+pollID.String(), nil)
+		body, _ := json.Marshal(req)
+		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/skip", bytes.NewBuffer(body))
 		r.ServeHTTP(w, request)
 
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
 }
 
-func TestSkipPoll(t *testing.T) {
-	t.Run("success", func(t *testing.T) {
+func TestConsent(t *testing.T) {
+	required := domain.ConsentVersions{Terms: "2024-06", Privacy: "2024-05"}
+
+	t.Run("pending consent blocks requests", func(t *testing.T) {
 		r, mockService, _, _, jwtManager := setupTest(t)
 		userID := uuid.New()
 		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
-		pollID := uuid.New()
-
-		req := domain.SkipRequest{
-			UserID: userID,
-		}
 
-		mockService.On("SkipPoll", mock.Anything, pollID, &req).Return(nil)
+		mockService.On("GetConsentStatus", mock.Anything, userID).Return(&domain.ConsentStatus{
+			Required: required,
+			Pending:  []domain.ConsentDocument{domain.ConsentTerms},
+		}, nil)
 
 		w := httptest.NewRecorder()
-		body, _ := json.Marshal(req)
-		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/skip", bytes.NewBuffer(body))
+		request, _ := http.NewRequest("GET", "/api/consented/limits", nil)
 		request.Header.Set("Authorization", "Bearer "+token)
 		r.ServeHTTP(w, request)
 
-		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusPreconditionRequired, w.Code)
 		var result map[string]interface{}
 		json.Unmarshal(w.Body.Bytes(), &result)
-		assert.Equal(t, "success", result["status"])
+		consent := result["consent"].(map[string]interface{})
+		assert.Equal(t, []string{"terms"}, toStringSlice(consent["pending"]))
+		mockService.AssertNotCalled(t, "GetVoteAllowance", mock.Anything, mock.Anything)
 	})
 
-	t.Run("already skipped", func(t *testing.T) {
+	t.Run("accepted consent passes", func(t *testing.T) {
 		r, mockService, _, _, jwtManager := setupTest(t)
 		userID := uuid.New()
 		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
-		pollID := uuid.New()
 
-		req := domain.SkipRequest{
-			UserID: userID,
-		}
+		mockService.On("GetConsentStatus", mock.Anything, userID).Return(&domain.ConsentStatus{
+			Required: required,
+			Pending:  []domain.ConsentDocument{},
+		}, nil)
+		mockService.On("GetVoteAllowance", mock.Anything, userID).Return(&domain.VoteAllowance{}, nil)
 
-		mockService.On("SkipPoll", mock.Anything, pollID, &req).Return(domain.ErrAlreadySkipped)
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/consented/limits", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("accept", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+
+		mockService.On("AcceptConsents", mock.Anything, mock.MatchedBy(func(req *domain.AcceptConsentRequest) bool {
+			return req.UserID == userID && req.Terms == "2024-06" && req.UserAgent == "test-agent"
+		})).Return(&domain.ConsentStatus{Required: required, Pending: []domain.ConsentDocument{}}, nil)
 
 		w := httptest.NewRecorder()
-		body, _ := json.Marshal(req)
-		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/skip", bytes.NewBuffer(body))
+		request, _ := http.NewRequest("POST", "/api/users/me/consents", strings.NewReader(`{"terms":"2024-06"}`))
 		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("User-Agent", "test-agent")
 		r.ServeHTTP(w, request)
 
-		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
 	})
 
-	t.Run("unauthorized", func(t *testing.T) {
-		r, _, _, _, _ := setupTest(t)
-		pollID := uuid.New()
-		req := domain.SkipRequest{}
+	t.Run("stale version", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		userID := uuid.New()
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+
+		mockService.On("AcceptConsents", mock.Anything, mock.Anything).Return(nil, domain.ErrInvalidInput)
 
 		w := httptest.NewRecorder()
-		body, _ := json.Marshal(req)
-		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/skip", bytes.NewBuffer(body))
+		request, _ := http.NewRequest("POST", "/api/users/me/consents", strings.NewReader(`{"terms":"2023-01"}`))
+		request.Header.Set("Authorization", "Bearer "+token)
 		r.ServeHTTP(w, request)
 
-		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 }
 
+func TestPollLinks(t *testing.T) {
+	pollID := uuid.New()
+	urls := NewURLBuilder("https://vote.example.com/")
+
+	links := urls.PollLinks(&domain.Poll{ID: pollID, PublicResults: true})
+	assert.Equal(t, &domain.PollLinks{
+		Self:  "https://vote.example.com/api/polls/" + pollID.String(),
+		Stats: "https://vote.example.com/api/polls/" + pollID.String() + "/stats",
+		Vote:  "https://vote.example.com/api/polls/" + pollID.String() + "/vote",
+		Skip:  "https://vote.example.com/api/polls/" + pollID.String() + "/skip",
+		Share: "https://vote.example.com/api/polls/" + pollID.String() + "/results",
+	}, links)
+
+	assert.Empty(t, urls.PollLinks(&domain.Poll{ID: pollID}).Share)
+}
+
 func toStringSlice(v interface{}) []string {
 	if v == nil {
 		return nil
@@ -655,3 +2774,489 @@ func toStringSlice(v interface{}) []string {
 	}
 	return nil
 }
+
+type importStore struct {
+	poll *domain.Poll
+}
+
+func (s *importStore) GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Poll, error) {
+	if id == s.poll.ID {
+		return s.poll, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (s *importStore) ImportVotes(ctx context.Context, votes []domain.Vote) (*domain.VoteImportBatch, error) {
+	return &domain.VoteImportBatch{Imported: len(votes)}, nil
+}
+
+func (s *importStore) InvalidatePollStatsCache(ctx context.Context, pollID uuid.UUID) error {
+	return nil
+}
+
+func TestImportVotes(t *testing.T) {
+	r, _, handler, _, jwtManager := setupTest(t)
+	token, _ := jwtManager.GenerateToken(&domain.User{ID: uuid.New()})
+	poll := &domain.Poll{ID: uuid.New(), Options: []domain.Option{{ID: uuid.New()}, {ID: uuid.New()}}}
+
+	doRequest := func(query, contentType, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/api/admin/votes/import"+query, strings.NewReader(body))
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("Content-Type", contentType)
+		r.ServeHTTP(w, request)
+		return w
+	}
+	csvBody := "poll_id,voter_id,option_index,created_at\n" +
+		poll.ID.String() + "," + uuid.NewString() + ",1,2024-06-01T09:00:00Z\n" +
+		uuid.NewString() + "," + uuid.NewString() + ",0,2024-06-01T09:00:00Z\n"
+
+	t.Run("not configured", func(t *testing.T) {
+		w := doRequest("", "text/csv", csvBody)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	handler.importer = voteimport.NewImporter(&importStore{poll: poll}, zap.NewNop())
+
+	t.Run("csv", func(t *testing.T) {
+		w := doRequest("", "text/csv", csvBody)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Result domain.VoteImportResult `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Result.Imported)
+		assert.Equal(t, 1, response.Result.Rejected)
+		assert.Equal(t, []domain.VoteImportError{{Line: 3, Reason: "unknown poll"}}, response.Result.Errors)
+	})
+
+	t.Run("bad header", func(t *testing.T) {
+		w := doRequest("?format=csv", "application/octet-stream", "poll_id,created_at\n")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "missing voter_id column")
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		w := doRequest("?format=xml", "", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestReadiness(t *testing.T) {
+	r, _, handler, _, _ := setupTest(t)
+	status := health.NewStatus()
+	status.Set("redis", true)
+	status.Set("rabbitmq", true)
+	handler.health = status
+
+	doRequest := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/readyz", nil)
+		r.ServeHTTP(w, request)
+		return w
+	}
+
+	w := doRequest()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"rabbitmq":"up"`)
+
+	status.Set("rabbitmq", false)
+	w = doRequest()
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), `"rabbitmq":"down"`)
+	assert.Contains(t, w.Body.String(), `"status":"degraded"`)
+}
+
+func TestRequireRole(t *testing.T) {
+	r, mockService, handler, _, jwtManager := setupTest(t)
+	mockService.On("GetModerationQueue", mock.Anything, domain.FlagOpen, 1, 10).Return(&domain.ModerationQueue{}, nil)
+	configured := uuid.New()
+	handler.moderators = map[uuid.UUID]struct{}{configured: {}}
+
+	tests := []struct {
+		name string
+		user *domain.User
+		want int
+	}{
+		{"user", &domain.User{ID: uuid.New(), Role: domain.RoleUser}, http.StatusForbidden},
+		{"token without role", &domain.User{ID: uuid.New()}, http.StatusForbidden},
+		{"moderator", &domain.User{ID: uuid.New(), Role: domain.RoleModerator}, http.StatusOK},
+		{"admin", &domain.User{ID: uuid.New(), Role: domain.RoleAdmin}, http.StatusOK},
+		{"configured moderator", &domain.User{ID: configured}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, _ := jwtManager.GenerateToken(tt.user)
+
+			w := httptest.NewRecorder()
+			request, _ := http.NewRequest("GET", "/api/moderation/flags", nil)
+			request.Header.Set("Authorization", "Bearer "+token)
+			r.ServeHTTP(w, request)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestAdminUsersAndPolls(t *testing.T) {
+	r, mockService, _, _, jwtManager := setupTest(t)
+	adminID := uuid.New()
+	token, _ := jwtManager.GenerateToken(&domain.User{ID: adminID, Role: domain.RoleAdmin})
+
+	t.Run("search users", func(t *testing.T) {
+		userID := uuid.New()
+		mockService.On("SearchUsers", mock.Anything, &domain.UserSearchQuery{Query: "bob", Standing: domain.StandingBanned, Page: 1, Limit: 10}).
+			Return(&domain.UserList{
+				Users: []domain.AdminUser{{ID: userID, Username: "bob", Standing: domain.StandingBanned}},
+				Total: 1, Page: 1, Limit: 10,
+			}, nil)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/admin/users?q=bob&standing=banned", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"standing":"banned"`)
+	})
+
+	t.Run("force-delete poll", func(t *testing.T) {
+		pollID := uuid.New()
+		mockService.On("ForceDeletePoll", mock.Anything, pollID, adminID).Return(nil)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("DELETE", "/api/admin/polls/"+pollID.String(), nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("force-delete missing poll", func(t *testing.T) {
+		pollID := uuid.New()
+		mockService.On("ForceDeletePoll", mock.Anything, pollID, adminID).Return(domain.ErrNotFound)
+
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("DELETE", "/api/admin/polls/"+pollID.String(), nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestCastOrganizationVote(t *testing.T) {
+	pollID, orgID := uuid.New(), uuid.New()
+	userID := uuid.New()
+
+	post := func(r http.Handler, token, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/api/polls/"+pollID.String()+"/organization-vote", strings.NewReader(body))
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, request)
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		mockService.On("CastOrganizationVote", mock.Anything, pollID, &domain.OrganizationVoteRequest{
+			OrganizationID: orgID,
+			OptionIndex:    1,
+			ActorID:        userID,
+		}).Return(&domain.OrganizationVote{PollID: pollID, OrganizationID: orgID, CastBy: &userID, OptionText: "No"}, nil)
+
+		w := post(r, token, `{"organizationId":"`+orgID.String()+`","optionIndex":1}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		vote := result["vote"].(map[string]interface{})
+		assert.Equal(t, userID.String(), vote["castBy"])
+		assert.Equal(t, "No", vote["optionText"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("organization required", func(t *testing.T) {
+		r, _, _, _, jwtManager := setupTest(t)
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+
+		w := post(r, token, `{"optionIndex":1}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("not an organization admin", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		mockService.On("CastOrganizationVote", mock.Anything, pollID, mock.Anything).Return(nil, domain.ErrForbidden)
+
+		w := post(r, token, `{"organizationId":"`+orgID.String()+`","optionIndex":0}`)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "Only organization admins can vote for the organization")
+	})
+
+	t.Run("poll not designated", func(t *testing.T) {
+		r, mockService, _, _, jwtManager := setupTest(t)
+		token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+		mockService.On("CastOrganizationVote", mock.Anything, pollID, mock.Anything).Return(nil, domain.ErrNoOrganizationVotes)
+
+		w := post(r, token, `{"organizationId":"`+orgID.String()+`","optionIndex":0}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "no_organization_votes")
+	})
+}
+
+func TestVerifyVote(t *testing.T) {
+	r, mockService, _, _, jwtManager := setupTest(t)
+	userID, voteID := uuid.New(), uuid.New()
+	token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+	mockService.On("VerifyVoteReceipt", mock.Anything, voteID, userID, "abc123").
+		Return(&domain.VoteVerification{VoteID: voteID, Valid: true, Recorded: &domain.VoteReceipt{VoteID: voteID, OptionText: "Yes"}}, nil)
+	mockService.On("VerifyVoteReceipt", mock.Anything, voteID, userID, "other").Return(nil, domain.ErrNotFound)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/votes/"+voteID.String()+"/verify"+query, nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+		return w
+	}
+
+	w := get("?signature=abc123")
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data domain.VoteVerification `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Data.Valid)
+	assert.Equal(t, "Yes", response.Data.Recorded.OptionText)
+
+	assert.Equal(t, http.StatusNotFound, get("?signature=other").Code)
+	assert.Equal(t, http.StatusBadRequest, get("").Code)
+}
+
+func TestResultCertificate(t *testing.T) {
+	r, mockService, _, _, jwtManager := setupTest(t)
+	pollID := uuid.New()
+	token, _ := jwtManager.GenerateToken(&domain.User{ID: uuid.New()})
+	cert := &domain.ResultCertificate{
+		PollID:    pollID,
+		Results:   domain.CertifiedResults{PollID: pollID, Title: "Ship it?", Total: 4, Winner: "Yes"},
+		Payload:   "eyJ9",
+		Algorithm: "Ed25519",
+		PublicKey: "cHVibGlj",
+		Signature: "c2ln",
+	}
+	mockService.On("GetResultCertificate", mock.Anything, pollID).Return(cert, nil).Once()
+	mockService.On("GetResultCertificate", mock.Anything, pollID).Return(nil, domain.ErrPollNotClosed).Once()
+	mockService.On("GetResultSigningKey", mock.Anything).Return(&domain.ResultSigningKey{Algorithm: "Ed25519", PublicKey: "cHVibGlj"}, nil).Once()
+	mockService.On("GetResultSigningKey", mock.Anything).Return(nil, domain.ErrCertificatesDisabled).Once()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", path, nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, request)
+		return w
+	}
+
+	w := get("/api/polls/" + pollID.String() + "/certificate")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `attachment; filename="poll-`+pollID.String()+`-results.json"`, w.Header().Get("Content-Disposition"))
+	var downloaded domain.ResultCertificate
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &downloaded))
+	assert.Equal(t, *cert, downloaded)
+	assert.Equal(t, http.StatusConflict, get("/api/polls/"+pollID.String()+"/certificate").Code)
+
+	w = get("/api/results/key")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"publicKey":"cHVibGlj"`)
+	w = get("/api/results/key")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "certificates_unavailable")
+	mockService.AssertExpectations(t)
+}
+
+func TestUpdateVoteCooldown(t *testing.T) {
+	r, mockService, _, _, jwtManager := setupTest(t)
+	userID, voteID := uuid.New(), uuid.New()
+	token, _ := jwtManager.GenerateToken(&domain.User{ID: userID})
+	retryAt := time.Now().Add(90 * time.Second)
+	mockService.On("UpdateVote", mock.Anything, voteID, mock.Anything).Return(&domain.VoteCooldownError{RetryAt: retryAt})
+
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/users/me/votes/"+voteID.String(), strings.NewReader(`{"optionIndex":1}`))
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, request)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	assert.InDelta(t, 90, retryAfter, 2)
+	assert.Contains(t, w.Body.String(), "vote_change_cooldown")
+}
+
+func TestPublicIDs(t *testing.T) {
+	_, mockService, handler, _, _ := setupTest(t)
+	codec := publicid.NewSqids("test-secret")
+	WithIDCodec(codec)(handler)
+	userID, pollID, optionID := uuid.New(), uuid.New(), uuid.New()
+
+	r := gin.New()
+	r.Use(handler.publicIDs(), func(c *gin.Context) {
+		auth.SetCurrentUser(c, auth.Principal{ID: userID})
+	})
+	r.GET("/api/polls/:id", handler.handle(handler.getPollByID))
+	r.POST("/api/polls/:id/vote", handler.handle(handler.voteOnPoll))
+	r.GET("/api/polls/:id/certificate", handler.handle(handler.getResultCertificate))
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		r.ServeHTTP(w, request)
+		return w
+	}
+
+	t.Run("responses carry public ids", func(t *testing.T) {
+		title := uuid.NewString()
+		mockService.On("GetPollByID", mock.Anything, pollID).Return(&domain.Poll{
+			ID:        pollID,
+			Title:     title,
+			Options:   []domain.Option{{ID: optionID, PollID: pollID, OptionText: "Yes"}},
+			CreatedBy: &userID,
+		}, nil).Once()
+
+		w := serve("GET", "/api/polls/"+codec.Encode(pollID), "")
+		require.Equal(t, http.StatusOK, w.Code)
+		for _, id := range []uuid.UUID{pollID, optionID, userID} {
+			assert.NotContains(t, w.Body.String(), id.String())
+		}
+		var response struct {
+			Data struct {
+				ID        string `json:"id"`
+				Title     string `json:"title"`
+				CreatedBy string `json:"createdBy"`
+				Options   []struct {
+					ID     string `json:"id"`
+					PollID string `json:"pollId"`
+				} `json:"options"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, codec.Encode(pollID), response.Data.ID)
+		assert.Equal(t, title, response.Data.Title, "user content is not an ID")
+		assert.Equal(t, codec.Encode(userID), response.Data.CreatedBy)
+		assert.Equal(t, codec.Encode(optionID), response.Data.Options[0].ID)
+		assert.Equal(t, codec.Encode(pollID), response.Data.Options[0].PollID)
+	})
+
+	t.Run("request bodies take public ids", func(t *testing.T) {
+		voteID := uuid.New()
+		mockService.On("VoteOnPoll", mock.Anything, pollID, &domain.VoteRequest{UserID: userID, OptionID: &optionID}).
+			Return(&domain.VoteReceipt{VoteID: voteID, PollID: pollID, OptionID: optionID}, nil).Once()
+		mockService.On("GetVoteAllowance", mock.Anything, userID).Return(&domain.VoteAllowance{}, nil).Once()
+
+		w := serve("POST", "/api/polls/"+codec.Encode(pollID)+"/vote", `{"optionId":"`+codec.Encode(optionID)+`"}`)
+		require.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"voteId":"`+codec.Encode(voteID)+`"`)
+		assert.Equal(t, "/api/users/me/votes/"+codec.Encode(voteID), w.Header().Get("Location"))
+	})
+
+	t.Run("stored uuids rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve("GET", "/api/polls/"+pollID.String(), "").Code)
+		w := serve("POST", "/api/polls/"+codec.Encode(pollID)+"/vote", `{"optionId":"`+optionID.String()+`"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "optionId")
+	})
+
+	t.Run("certificates keep stored ids", func(t *testing.T) {
+		mockService.On("GetResultCertificate", mock.Anything, pollID).Return(&domain.ResultCertificate{
+			PollID:  pollID,
+			Results: domain.CertifiedResults{PollID: pollID, CreatedBy: &userID},
+		}, nil).Once()
+
+		w := serve("GET", "/api/polls/"+codec.Encode(pollID)+"/certificate", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"pollId":"`+pollID.String()+`"`)
+		assert.Contains(t, w.Header().Get("Content-Disposition"), codec.Encode(pollID))
+	})
+	mockService.AssertExpectations(t)
+}
+
+func TestEncodeIDs(t *testing.T) {
+	codec := publicid.NewSqids("test-secret")
+	id := uuid.New()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"value", `{"id":"` + id.String() + `"}`, `{"id":"` + codec.Encode(id) + `"}`},
+		{"suffixed keys", `{"pollId":"` + id.String() + `", "createdBy" : "` + id.String() + `"}`, `{"pollId":"` + codec.Encode(id) + `", "createdBy" : "` + codec.Encode(id) + `"}`},
+		{"id array", `{"optionIds":["` + id.String() + `","x"],"tags":["` + id.String() + `"]}`, `{"optionIds":["` + codec.Encode(id) + `","x"],"tags":["` + id.String() + `"]}`},
+		{"nested", `{"poll":{"title":"x","options":[{"id":"` + id.String() + `"}]}}`, `{"poll":{"title":"x","options":[{"id":"` + codec.Encode(id) + `"}]}}`},
+		{"uuid title", `{"id":"` + id.String() + `","title":"` + id.String() + `"}`, `{"id":"` + codec.Encode(id) + `","title":"` + id.String() + `"}`},
+		{"map key", `{"` + id.String() + `":1}`, `{"` + id.String() + `":1}`},
+		{"inside text", `{"title":"vote ` + id.String() + `"}`, `{"title":"vote ` + id.String() + `"}`},
+		{"escaped quote", `{"title":"a\"` + id.String() + `\""}`, `{"title":"a\"` + id.String() + `\""}`},
+		{"event stream", "event:poll.created\ndata:{\"id\":\"" + id.String() + "\"}\n\n", "event:poll.created\ndata:{\"id\":\"" + codec.Encode(id) + "\"}\n\n"},
+		{"unterminated", `{"id":"` + id.String(), `{"id":"` + id.String()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(encodeIDs([]byte(tt.in), codec)))
+		})
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	_, _, handler, _, jwtManager := setupTest(t)
+	r := gin.New()
+	handler.RegisterRoutes(r, jwtManager)
+
+	t.Run("documents every route", func(t *testing.T) {
+		var registered, documented []string
+		for _, route := range r.Routes() {
+			registered = append(registered, route.Method+" "+route.Path)
+		}
+		for _, op := range operations {
+			documented = append(documented, op.method+" "+op.path)
+		}
+		assert.ElementsMatch(t, registered, documented)
+	})
+
+	t.Run("serves the document", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/openapi.json", nil)
+		r.ServeHTTP(w, request)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var doc struct {
+			OpenAPI string                     `json:"openapi"`
+			Paths   map[string]json.RawMessage `json:"paths"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+		assert.Equal(t, "3.0.3", doc.OpenAPI)
+		assert.Contains(t, doc.Paths, "/api/polls/{id}/vote")
+
+		w = httptest.NewRecorder()
+		request, _ = http.NewRequest("GET", "/docs", nil)
+		r.ServeHTTP(w, request)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "/api/openapi.json")
+	})
+
+	t.Run("matches docs/openapi.json", func(t *testing.T) {
+		spec, err := OpenAPISpec()
+		require.NoError(t, err)
+		committed, err := os.ReadFile("../../docs/openapi.json")
+		require.NoError(t, err)
+		assert.True(t, bytes.Equal(spec, committed), "docs/openapi.json is out of date; run make openapi")
+	})
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/behzadon/vote/internal/health"
+	"github.com/gin-gonic/gin"
+)
+
+// WithHealth reports the state of the given dependencies on /readyz.
+func WithHealth(status *health.Status) HandlerOption {
+	return func(h *Handler) {
+		h.health = status
+	}
+}
+
+// liveness answers as long as the process serves requests. It does not look
+// at dependencies, so an outage makes the instance unready rather than
+// getting it restarted.
+func (h *Handler) liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// readiness answers 503 while a dependency is down, so load balancers send
+// traffic elsewhere until it is reconnected.
+func (h *Handler) readiness(c *gin.Context) {
+	ready, deps := true, map[string]bool{}
+	if h.health != nil {
+		ready, deps = h.health.Ready()
+	}
+
+	states := make(map[string]string, len(deps))
+	for name, up := range deps {
+		states[name] = "down"
+		if up {
+			states[name] = "up"
+		}
+	}
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":       "degraded",
+			"dependencies": states,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":       "ready",
+		"dependencies": states,
+	})
+}
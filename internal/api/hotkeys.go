@@ -0,0 +1,80 @@
+package api
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/behzadon/vote/internal/metrics"
+)
+
+const (
+	DefaultHotKeyCount    = 10
+	DefaultHotKeyInterval = 30 * time.Second
+)
+
+type hotKeyTracker struct {
+	mu        sync.Mutex
+	counts    map[string]map[string]int64
+	lastFlush time.Time
+	interval  time.Duration
+	top       int
+	now       func() time.Time
+}
+
+func newHotKeyTracker(top int, interval time.Duration) *hotKeyTracker {
+	return &hotKeyTracker{
+		counts:    make(map[string]map[string]int64),
+		lastFlush: time.Now(),
+		interval:  interval,
+		top:       top,
+		now:       time.Now,
+	}
+}
+
+func (t *hotKeyTracker) Record(limiter, key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.counts[limiter] == nil {
+		t.counts[limiter] = make(map[string]int64)
+	}
+	t.counts[limiter][key]++
+
+	if now := t.now(); now.Sub(t.lastFlush) >= t.interval {
+		t.flush()
+		t.lastFlush = now
+	}
+}
+
+func (t *hotKeyTracker) flush() {
+	metrics.RateLimitHotKeys.Reset()
+	for limiter, keys := range t.counts {
+		for _, hk := range topKeys(keys, t.top) {
+			metrics.RateLimitHotKeys.WithLabelValues(limiter, hk.key).Set(float64(hk.count))
+		}
+	}
+	t.counts = make(map[string]map[string]int64)
+}
+
+type keyCount struct {
+	key   string
+	count int64
+}
+
+func topKeys(counts map[string]int64, n int) []keyCount {
+	all := make([]keyCount, 0, len(counts))
+	for k, c := range counts {
+		all = append(all, keyCount{key: k, count: c})
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].count == all[j].count {
+			return all[i].key < all[j].key
+		}
+		return all[i].count > all[j].count
+	})
+	if len(all) > n {
+		all = all[:n]
+	}
+	return all
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/publicid"
+	"github.com/google/uuid"
+)
+
+// URLBuilder builds the URLs of API resources so route templates live in
+// one place. URLs are relative unless a public URL is configured.
+type URLBuilder struct {
+	base string
+	ids  publicid.Codec
+}
+
+func NewURLBuilder(publicURL string) URLBuilder {
+	return URLBuilder{base: strings.TrimRight(publicURL, "/"), ids: publicid.UUIDs{}}
+}
+
+// WithPublicURL makes links and Location headers absolute, e.g.
+// "https://vote.example.com".
+func WithPublicURL(publicURL string) HandlerOption {
+	return func(h *Handler) {
+		h.urls.base = strings.TrimRight(publicURL, "/")
+	}
+}
+
+// WithIDCodec shows IDs in responses, links and Location headers in the
+// codec's form and reads that form from paths and request bodies. Wrap it in
+// publicid.AcceptUUIDs to accept stored UUIDs as well.
+func WithIDCodec(codec publicid.Codec) HandlerOption {
+	return func(h *Handler) {
+		h.ids = codec
+		h.urls.ids = codec
+	}
+}
+
+func (b URLBuilder) Poll(pollID uuid.UUID) string {
+	return b.base + "/api/polls/" + b.ids.Encode(pollID)
+}
+
+func (b URLBuilder) PollStats(pollID uuid.UUID) string {
+	return b.Poll(pollID) + "/stats"
+}
+
+func (b URLBuilder) PollVote(pollID uuid.UUID) string {
+	return b.Poll(pollID) + "/vote"
+}
+
+func (b URLBuilder) PollSkip(pollID uuid.UUID) string {
+	return b.Poll(pollID) + "/skip"
+}
+
+func (b URLBuilder) PollResults(pollID uuid.UUID) string {
+	return b.Poll(pollID) + "/results"
+}
+
+func (b URLBuilder) PollPreview(pollID uuid.UUID) string {
+	return b.Poll(pollID) + "/og"
+}
+
+func (b URLBuilder) PollPreviewImage(pollID uuid.UUID) string {
+	return b.PollPreview(pollID) + "/image.png"
+}
+
+func (b URLBuilder) Vote(voteID uuid.UUID) string {
+	return b.base + "/api/users/me/votes/" + b.ids.Encode(voteID)
+}
+
+// PollLinks links a poll to itself and the actions on it. The share link
+// is the unauthenticated results page, so it is only set for polls with
+// public results.
+func (b URLBuilder) PollLinks(poll *domain.Poll) *domain.PollLinks {
+	links := &domain.PollLinks{
+		Self:  b.Poll(poll.ID),
+		Stats: b.PollStats(poll.ID),
+		Vote:  b.PollVote(poll.ID),
+		Skip:  b.PollSkip(poll.ID),
+	}
+	if poll.PublicResults {
+		links.Share = b.PollResults(poll.ID)
+	}
+	return links
+}
+
+func (h *Handler) linkPolls(polls []domain.Poll) {
+	for i := range polls {
+		polls[i].Links = h.urls.PollLinks(&polls[i])
+	}
+}
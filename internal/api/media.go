@@ -0,0 +1,175 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/storage/blob"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) uploadAvatar(c *gin.Context) error {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		return unauthenticated()
+	}
+
+	upload, ok := mediaUpload(c, blob.KindAvatar)
+	if !ok {
+		return nil
+	}
+
+	user, err := h.service.SetUserAvatar(c.Request.Context(), principal.ID, upload)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"avatarUrl": user.AvatarURL,
+	})
+	return nil
+}
+
+func (h *Handler) uploadOptionImage(c *gin.Context) error {
+	userID, pollID, err := h.pollManagementParams(c)
+	if err != nil {
+		return err
+	}
+
+	index, err := optionIndexParam(c)
+	if err != nil {
+		return err
+	}
+
+	upload, ok := mediaUpload(c, blob.KindOptionImage)
+	if !ok {
+		return nil
+	}
+
+	poll, err := h.service.SetOptionImage(c.Request.Context(), pollID, index, userID, upload)
+	if err != nil {
+		return pollManagementError(describe(err, domain.ErrInvalidOption, "Invalid option index"))
+	}
+
+	poll.Links = h.urls.PollLinks(poll)
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"poll":   poll,
+	})
+	return nil
+}
+
+func (h *Handler) updateOption(c *gin.Context) error {
+	userID, pollID, err := h.pollManagementParams(c)
+	if err != nil {
+		return err
+	}
+
+	index, err := optionIndexParam(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.UpdateOptionRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	req.ActorID = userID
+
+	poll, err := h.service.UpdateOption(c.Request.Context(), pollID, index, &req)
+	if err != nil {
+		return pollManagementError(describe(err, domain.ErrInvalidOption, "Invalid option index"))
+	}
+
+	poll.Links = h.urls.PollLinks(poll)
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"poll":   poll,
+	})
+	return nil
+}
+
+func (h *Handler) signUpload(c *gin.Context) error {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		return unauthenticated()
+	}
+
+	var req domain.SignUploadRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	upload, err := h.service.SignUpload(c.Request.Context(), principal.ID, &req)
+	if err != nil {
+		return pollManagementError(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"upload": upload,
+	})
+	return nil
+}
+
+func (h *Handler) confirmUpload(c *gin.Context) error {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		return unauthenticated()
+	}
+
+	var req domain.ConfirmUploadRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	confirmation, err := h.service.ConfirmUpload(c.Request.Context(), principal.ID, &req)
+	if err != nil {
+		return pollManagementError(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   confirmation,
+	})
+	return nil
+}
+
+func optionIndexParam(c *gin.Context) (int, error) {
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil || index < 0 {
+		return 0, badRequest("Invalid option index")
+	}
+	return index, nil
+}
+
+// mediaUpload wraps the raw request body. Uploads must declare their length
+// so oversized files are refused before anything is read. It answers the
+// request itself when it returns false.
+func mediaUpload(c *gin.Context, kind blob.Kind) (*domain.MediaUpload, bool) {
+	size := c.Request.ContentLength
+	if size < 0 {
+		c.JSON(http.StatusLengthRequired, gin.H{
+			"status":  "error",
+			"message": "Content-Length is required",
+		})
+		return nil, false
+	}
+	if size > kind.Policy().MaxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"status":  "error",
+			"message": "File is too large",
+			"maxSize": kind.Policy().MaxSize,
+		})
+		return nil, false
+	}
+
+	return &domain.MediaUpload{
+		Body:        http.MaxBytesReader(c.Writer, c.Request.Body, size),
+		Size:        size,
+		ContentType: c.ContentType(),
+	}, true
+}
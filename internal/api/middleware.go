@@ -9,9 +9,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/metrics"
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
-	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -22,18 +24,86 @@ const (
 	DefaultCleanupWindow = 3600
 )
 
+const (
+	limiterRate  = "rate"
+	limiterBurst = "burst"
+)
+
+// RateLimitPolicy is how many requests a limiter lets each user make to a
+// path per window. In shadow mode requests over the limit are logged and
+// counted but still served, so a new limit can be tried before it is
+// enforced.
+type RateLimitPolicy struct {
+	Limit  int
+	Window time.Duration
+	Shadow bool
+}
+
 type RateLimiter struct {
-	redis  RedisClient
-	logger *zap.Logger
+	redis   RedisClient
+	logger  *zap.Logger
+	hotKeys *hotKeyTracker
+	rate    RateLimitPolicy
+	burst   RateLimitPolicy
 }
 
 func NewRateLimiter(redis RedisClient, logger *zap.Logger) *RateLimiter {
 	return &RateLimiter{
-		redis:  redis,
-		logger: logger,
+		redis:   redis,
+		logger:  logger,
+		hotKeys: newHotKeyTracker(DefaultHotKeyCount, DefaultHotKeyInterval),
+		rate:    RateLimitPolicy{Limit: DefaultRateLimit, Window: DefaultRateWindow * time.Second},
+		burst:   RateLimitPolicy{Limit: DefaultBurstLimit, Window: time.Second},
 	}
 }
 
+// WithRateLimits replaces the per-minute and burst policies. Zero limits and
+// windows keep the defaults.
+func WithRateLimits(rate, burst RateLimitPolicy) HandlerOption {
+	return func(h *Handler) {
+		h.rateLimiter.rate = mergePolicy(h.rateLimiter.rate, rate)
+		h.rateLimiter.burst = mergePolicy(h.rateLimiter.burst, burst)
+	}
+}
+
+func mergePolicy(current, p RateLimitPolicy) RateLimitPolicy {
+	if p.Limit > 0 {
+		current.Limit = p.Limit
+	}
+	if p.Window >= time.Second {
+		current.Window = p.Window
+	}
+	current.Shadow = p.Shadow
+	return current
+}
+
+// overLimit answers a request over policy with 429 and reports true. In
+// shadow mode it only counts the request, logs the first one over the limit
+// in each window, and lets it through.
+func (rl *RateLimiter) overLimit(c *gin.Context, limiter string, policy RateLimitPolicy, userID string, first bool, message string) bool {
+	if !policy.Shadow {
+		metrics.RecordRateLimitDecision(limiter, c.FullPath(), "limited")
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"status":  "error",
+			"message": message,
+		})
+		c.Abort()
+		return true
+	}
+
+	metrics.RecordRateLimitDecision(limiter, c.FullPath(), "shadow_limited")
+	if first {
+		logging.For(c.Request.Context(), rl.logger).Warn("Request over rate limit in shadow mode",
+			zap.String("limiter", limiter),
+			zap.String("user_id", userID),
+			zap.String("path", c.Request.URL.Path),
+			zap.Int("limit", policy.Limit),
+			zap.Duration("window", policy.Window),
+		)
+	}
+	return false
+}
+
 func (rl *RateLimiter) RateLimit() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if strings.HasPrefix(c.Request.URL.Path, "/api/polls/") && strings.HasSuffix(c.Request.URL.Path, "/stats") {
@@ -41,27 +111,27 @@ func (rl *RateLimiter) RateLimit() gin.HandlerFunc {
 			return
 		}
 
-		userID, exists := c.Get("user_id")
-		if !exists {
-			if c.Request.Method == http.MethodGet {
-				userID = c.Query("userId")
-			} else if c.Request.Method == http.MethodPost {
-				if c.Request.Body != nil {
-					body, err := io.ReadAll(c.Request.Body)
-					if err != nil {
-						rl.logger.Error("failed to read request body in rate limit middleware",
-							zap.Error(err),
-						)
-					} else {
-						c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
-					}
+		userIDStr := ""
+		if principal, ok := auth.CurrentUser(c); ok {
+			userIDStr = principal.ID.String()
+		} else if c.Request.Method == http.MethodGet {
+			userIDStr = c.Query("userId")
+		} else if c.Request.Method == http.MethodPost {
+			if c.Request.Body != nil {
+				body, err := io.ReadAll(c.Request.Body)
+				if err != nil {
+					logging.For(c.Request.Context(), rl.logger).Error("failed to read request body in rate limit middleware",
+						zap.Error(err),
+					)
+				} else {
+					c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
 				}
-				c.Next()
-				return
 			}
+			c.Next()
+			return
 		}
 
-		if userID == "" {
+		if userIDStr == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"status":  "error",
 				"message": "User ID is required",
@@ -70,21 +140,6 @@ func (rl *RateLimiter) RateLimit() gin.HandlerFunc {
 			return
 		}
 
-		userIDStr := ""
-		switch v := userID.(type) {
-		case string:
-			userIDStr = v
-		case uuid.UUID:
-			userIDStr = v.String()
-		default:
-			c.JSON(http.StatusBadRequest, gin.H{
-				"status":  "error",
-				"message": "Invalid user ID type",
-			})
-			c.Abort()
-			return
-		}
-
 		key := "rate_limit:" + userIDStr + ":" + c.Request.URL.Path
 
 		ctx := c.Request.Context()
@@ -97,11 +152,12 @@ func (rl *RateLimiter) RateLimit() gin.HandlerFunc {
 		getWindow := pipe.Get(ctx, windowKey)
 
 		if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
-			rl.logger.Error("failed to get rate limit info",
+			logging.For(c.Request.Context(), rl.logger).Error("failed to get rate limit info",
 				zap.Error(err),
 				zap.String("user_id", userIDStr),
 				zap.String("path", c.Request.URL.Path),
 			)
+			metrics.RecordRateLimitDecision(limiterRate, c.FullPath(), "error")
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"status":  "error",
 				"message": "Rate limit check failed",
@@ -114,7 +170,7 @@ func (rl *RateLimiter) RateLimit() gin.HandlerFunc {
 		window := now
 		if countStr, err := getCount.Result(); err == nil {
 			if count, err = strconv.Atoi(countStr); err != nil {
-				rl.logger.Error("failed to parse count",
+				logging.For(c.Request.Context(), rl.logger).Error("failed to parse count",
 					zap.Error(err),
 					zap.String("count", countStr),
 				)
@@ -122,41 +178,45 @@ func (rl *RateLimiter) RateLimit() gin.HandlerFunc {
 		}
 		if windowStr, err := getWindow.Result(); err == nil {
 			if window, err = strconv.ParseInt(windowStr, 10, 64); err != nil {
-				rl.logger.Error("failed to parse window",
+				logging.For(c.Request.Context(), rl.logger).Error("failed to parse window",
 					zap.Error(err),
 					zap.String("window", windowStr),
 				)
 			}
 		}
 
-		if now-window >= DefaultRateWindow {
+		windowSeconds := int64(rl.rate.Window / time.Second)
+		if now-window >= windowSeconds {
 			count = 0
 			window = now
 		}
 
-		if count >= DefaultRateLimit {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"status":  "error",
-				"message": "Rate limit exceeded",
-			})
-			c.Abort()
+		rl.hotKeys.Record(limiterRate, userIDStr)
+
+		over := count >= rl.rate.Limit
+		if over && rl.overLimit(c, limiterRate, rl.rate, userIDStr, count == rl.rate.Limit, "Rate limit exceeded") {
 			return
 		}
 
 		pipe = rl.redis.Pipeline()
 		pipe.Incr(ctx, countKey)
-		pipe.Set(ctx, windowKey, window, DefaultCleanupWindow*time.Second)
+		pipe.Set(ctx, windowKey, window, max(DefaultCleanupWindow*time.Second, 2*rl.rate.Window))
 		if _, err := pipe.Exec(ctx); err != nil {
-			rl.logger.Error("failed to update rate limit",
+			logging.For(c.Request.Context(), rl.logger).Error("failed to update rate limit",
 				zap.Error(err),
 				zap.String("user_id", userIDStr),
 				zap.String("path", c.Request.URL.Path),
 			)
 		}
 
-		c.Header("X-RateLimit-Limit", strconv.Itoa(DefaultRateLimit))
-		c.Header("X-RateLimit-Remaining", strconv.Itoa(DefaultRateLimit-count-1))
-		c.Header("X-RateLimit-Reset", strconv.FormatInt(window+DefaultRateWindow, 10))
+		if !over {
+			metrics.RecordRateLimitDecision(limiterRate, c.FullPath(), "allowed")
+		}
+		if !rl.rate.Shadow {
+			c.Header("X-RateLimit-Limit", strconv.Itoa(rl.rate.Limit))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(rl.rate.Limit-count-1))
+			c.Header("X-RateLimit-Reset", strconv.FormatInt(window+windowSeconds, 10))
+		}
 
 		c.Next()
 	}
@@ -169,27 +229,27 @@ func (rl *RateLimiter) BurstLimit() gin.HandlerFunc {
 			return
 		}
 
-		userID, exists := c.Get("user_id")
-		if !exists {
-			if c.Request.Method == http.MethodGet {
-				userID = c.Query("userId")
-			} else if c.Request.Method == http.MethodPost {
-				if c.Request.Body != nil {
-					body, err := io.ReadAll(c.Request.Body)
-					if err != nil {
-						rl.logger.Error("failed to read request body in burst limit middleware",
-							zap.Error(err),
-						)
-					} else {
-						c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
-					}
+		userIDStr := ""
+		if principal, ok := auth.CurrentUser(c); ok {
+			userIDStr = principal.ID.String()
+		} else if c.Request.Method == http.MethodGet {
+			userIDStr = c.Query("userId")
+		} else if c.Request.Method == http.MethodPost {
+			if c.Request.Body != nil {
+				body, err := io.ReadAll(c.Request.Body)
+				if err != nil {
+					logging.For(c.Request.Context(), rl.logger).Error("failed to read request body in burst limit middleware",
+						zap.Error(err),
+					)
+				} else {
+					c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
 				}
-				c.Next()
-				return
 			}
+			c.Next()
+			return
 		}
 
-		if userID == "" {
+		if userIDStr == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"status":  "error",
 				"message": "User ID is required",
@@ -198,30 +258,16 @@ func (rl *RateLimiter) BurstLimit() gin.HandlerFunc {
 			return
 		}
 
-		userIDStr := ""
-		switch v := userID.(type) {
-		case string:
-			userIDStr = v
-		case uuid.UUID:
-			userIDStr = v.String()
-		default:
-			c.JSON(http.StatusBadRequest, gin.H{
-				"status":  "error",
-				"message": "Invalid user ID type",
-			})
-			c.Abort()
-			return
-		}
-
 		key := "burst_limit:" + userIDStr + ":" + c.Request.URL.Path
 		ctx := c.Request.Context()
 		count, err := rl.redis.Incr(ctx, key).Result()
 		if err != nil {
-			rl.logger.Error("failed to increment burst limit",
+			logging.For(c.Request.Context(), rl.logger).Error("failed to increment burst limit",
 				zap.Error(err),
 				zap.String("user_id", userIDStr),
 				zap.String("path", c.Request.URL.Path),
 			)
+			metrics.RecordRateLimitDecision(limiterBurst, c.FullPath(), "error")
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"status":  "error",
 				"message": "Burst limit check failed",
@@ -231,8 +277,8 @@ func (rl *RateLimiter) BurstLimit() gin.HandlerFunc {
 		}
 
 		if count == 1 {
-			if err := rl.redis.Expire(ctx, key, time.Second).Err(); err != nil {
-				rl.logger.Error("failed to set burst limit expiry",
+			if err := rl.redis.Expire(ctx, key, rl.burst.Window).Err(); err != nil {
+				logging.For(c.Request.Context(), rl.logger).Error("failed to set burst limit expiry",
 					zap.Error(err),
 					zap.String("user_id", userIDStr),
 					zap.String("path", c.Request.URL.Path),
@@ -240,17 +286,21 @@ func (rl *RateLimiter) BurstLimit() gin.HandlerFunc {
 			}
 		}
 
-		if count > DefaultBurstLimit {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"status":  "error",
-				"message": "Burst limit exceeded",
-			})
-			c.Abort()
+		rl.hotKeys.Record(limiterBurst, userIDStr)
+
+		limit := int64(rl.burst.Limit)
+		over := count > limit
+		if over && rl.overLimit(c, limiterBurst, rl.burst, userIDStr, count == limit+1, "Burst limit exceeded") {
 			return
 		}
 
-		c.Header("X-BurstLimit-Limit", strconv.Itoa(DefaultBurstLimit))
-		c.Header("X-BurstLimit-Remaining", strconv.FormatInt(DefaultBurstLimit-count, 10))
+		if !over {
+			metrics.RecordRateLimitDecision(limiterBurst, c.FullPath(), "allowed")
+		}
+		if !rl.burst.Shadow {
+			c.Header("X-BurstLimit-Limit", strconv.Itoa(rl.burst.Limit))
+			c.Header("X-BurstLimit-Remaining", strconv.FormatInt(limit-count, 10))
+		}
 
 		c.Next()
 	}
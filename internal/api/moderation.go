@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) getModerationFlags(c *gin.Context) error {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = domain.DefaultPage
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > domain.MaxPageSize {
+		limit = domain.DefaultLimit
+	}
+	status := domain.FlagStatus(c.DefaultQuery("status", string(domain.FlagOpen)))
+
+	queue, err := h.service.GetModerationQueue(c.Request.Context(), status, page, limit)
+	if err != nil {
+		return describe(err, domain.ErrInvalidInput, "Invalid flag status")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   queue,
+	})
+	return nil
+}
+
+func (h *Handler) resolveModerationFlag(c *gin.Context) error {
+	flagID, err := h.uuidParam(c, "id", "Invalid flag ID")
+	if err != nil {
+		return err
+	}
+
+	var req domain.ResolveFlagRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	principal, _ := auth.CurrentUser(c)
+	req.ActorID = principal.ID
+
+	flag, err := h.service.ResolveModerationFlag(c.Request.Context(), flagID, &req)
+	if err != nil {
+		err = describe(err, domain.ErrInvalidInput, "Status must be dismissed or upheld")
+		return describe(err, domain.ErrNotFound, "Open flag not found")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"flag":   flag,
+	})
+	return nil
+}
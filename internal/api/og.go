@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// getPollPreview serves the metadata link unfurls show for a poll, without
+// authentication so crawlers can fetch it.
+func (h *Handler) getPollPreview(c *gin.Context) error {
+	pollID, err := h.uuidParam(c, "id", "Invalid poll ID")
+	if err != nil {
+		return err
+	}
+
+	preview, err := h.service.GetPollPreview(c.Request.Context(), pollID)
+	if err != nil {
+		return describe(err, domain.ErrNotFound, "Poll not found")
+	}
+	preview.URL = h.urls.Poll(pollID)
+	preview.ImageURL = h.urls.PollPreviewImage(pollID)
+
+	c.Header("Cache-Control", liveResultsCacheControl)
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   preview,
+	})
+	return nil
+}
+
+// getPollPreviewImage redirects to the card kept in the media store, so the
+// image URL in the metadata stays stable while the card is re-rendered, or
+// serves it directly when no store is configured.
+func (h *Handler) getPollPreviewImage(c *gin.Context) error {
+	pollID, err := h.uuidParam(c, "id", "Invalid poll ID")
+	if err != nil {
+		return err
+	}
+
+	image, err := h.service.GetPollPreviewImage(c.Request.Context(), pollID)
+	if err != nil {
+		return describe(err, domain.ErrNotFound, "Poll not found")
+	}
+
+	c.Header("Cache-Control", liveResultsCacheControl)
+	if image.URL != "" {
+		c.Redirect(http.StatusFound, image.URL)
+		return nil
+	}
+	c.Data(http.StatusOK, "image/png", image.PNG)
+	return nil
+}
@@ -0,0 +1,508 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	openAPIVersion = "3.0.3"
+	apiTitle       = "Vote API"
+	apiVersion     = "1.0"
+)
+
+// authMode is how an operation authenticates its caller.
+type authMode int
+
+const (
+	authNone authMode = iota
+	// authOptional routes answer anonymous callers but tailor the answer
+	// to signed-in ones.
+	authOptional
+	authBearer
+)
+
+// param documents a path or query parameter. Kind is the OpenAPI type and
+// defaults to string.
+type param struct {
+	name        string
+	kind        string
+	format      string
+	description string
+	required    bool
+}
+
+// envelope lists the fields sent next to "status" in a success response.
+// Values are samples of the Go type sent; a nested envelope is an inline
+// object and nil a free-form one.
+type envelope map[string]interface{}
+
+// operation documents one route of RegisterRoutes.
+type operation struct {
+	method  string
+	path    string
+	id      string
+	tag     string
+	summary string
+	auth    authMode
+
+	// query documents the query parameters, queryType adds those bound
+	// from a struct's form tags.
+	query     []param
+	queryType interface{}
+
+	// body is a sample of the JSON body, rawBody the media types of a
+	// body read as is.
+	body         interface{}
+	bodyOptional bool
+	rawBody      []string
+
+	// status defaults to 200. The response is a JSON envelope when
+	// produces is empty or response is set; produces names the media types
+	// of bodies written as is.
+	status   int
+	response envelope
+	produces []string
+	// document is a sample of a JSON body sent without the envelope.
+	document interface{}
+}
+
+// pathParams documents the path parameters by name.
+var pathParams = map[string]param{
+	"id":     {description: "Resource ID in its public form"},
+	"voteId": {description: "Vote ID in its public form"},
+	"userId": {description: "User ID in its public form"},
+	"index":  {kind: "integer", description: "Zero-based option index"},
+	"tag":    {description: "Tag name"},
+}
+
+var openAPIDocument = sync.OnceValues(func() ([]byte, error) {
+	return OpenAPISpec()
+})
+
+// OpenAPISpec builds the OpenAPI document of the routes RegisterRoutes
+// serves.
+func OpenAPISpec() ([]byte, error) {
+	b := newSchemaBuilder()
+	paths := make(map[string]map[string]interface{})
+	for _, op := range operations {
+		path, item, err := b.operation(op)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", op.method, op.path, err)
+		}
+		if paths[path] == nil {
+			paths[path] = make(map[string]interface{})
+		}
+		paths[path][strings.ToLower(op.method)] = item
+	}
+
+	b.schemas["Error"] = map[string]interface{}{
+		"type":     "object",
+		"required": []string{"status", "message"},
+		"properties": map[string]interface{}{
+			"status":  map[string]interface{}{"type": "string", "enum": []string{"error"}},
+			"code":    map[string]interface{}{"type": "string"},
+			"message": map[string]interface{}{"type": "string"},
+		},
+	}
+	doc := map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":   apiTitle,
+			"version": apiVersion,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": b.schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+			},
+			"responses": map[string]interface{}{
+				"Error": map[string]interface{}{
+					"description": "Error",
+					"content": map[string]interface{}{
+						gin.MIMEJSON: map[string]interface{}{"schema": schemaRef("Error")},
+					},
+				},
+			},
+		},
+	}
+	body, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(body, '\n'), nil
+}
+
+func (h *Handler) openAPI(c *gin.Context) {
+	body, err := openAPIDocument()
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// docsPage loads Swagger UI from a CDN and points it at the spec.
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>` + apiTitle + `</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
+
+func (h *Handler) docs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaBuilder turns Go types into schemas. Named structs become
+// components, referenced wherever they are used.
+type schemaBuilder struct {
+	schemas map[string]interface{}
+	names   map[reflect.Type]string
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{
+		schemas: make(map[string]interface{}),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+func (b *schemaBuilder) operation(op operation) (string, map[string]interface{}, error) {
+	item := map[string]interface{}{
+		"operationId": op.id,
+		"summary":     op.summary,
+		"tags":        []string{op.tag},
+	}
+	switch op.auth {
+	case authBearer:
+		item["security"] = []interface{}{map[string]interface{}{"bearerAuth": []string{}}}
+	case authOptional:
+		item["security"] = []interface{}{map[string]interface{}{}, map[string]interface{}{"bearerAuth": []string{}}}
+	}
+
+	var params []interface{}
+	segments := strings.Split(op.path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		name := segment[1:]
+		p, ok := pathParams[name]
+		if !ok {
+			return "", nil, fmt.Errorf("undocumented path parameter %q", name)
+		}
+		p.name, p.required = name, true
+		params = append(params, p.spec("path"))
+		segments[i] = "{" + name + "}"
+	}
+	for _, p := range op.query {
+		params = append(params, p.spec("query"))
+	}
+	if op.queryType != nil {
+		for _, p := range formParams(reflect.TypeOf(op.queryType)) {
+			params = append(params, p.spec("query"))
+		}
+	}
+	if len(params) > 0 {
+		item["parameters"] = params
+	}
+
+	if op.body != nil || len(op.rawBody) > 0 {
+		content := make(map[string]interface{})
+		if op.body != nil {
+			content[gin.MIMEJSON] = map[string]interface{}{"schema": b.schema(reflect.TypeOf(op.body))}
+		}
+		for _, mediaType := range op.rawBody {
+			content[mediaType] = map[string]interface{}{"schema": binarySchema()}
+		}
+		item["requestBody"] = map[string]interface{}{
+			"required": !op.bodyOptional,
+			"content":  content,
+		}
+	}
+
+	status := op.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	content := make(map[string]interface{})
+	if len(op.produces) == 0 || op.response != nil {
+		content[gin.MIMEJSON] = map[string]interface{}{"schema": b.envelope(op.response)}
+	}
+	for _, mediaType := range op.produces {
+		schema := binarySchema()
+		if mediaType == gin.MIMEJSON {
+			schema = map[string]interface{}{"type": "object"}
+			if op.document != nil {
+				schema = b.schema(reflect.TypeOf(op.document))
+			}
+		}
+		content[mediaType] = map[string]interface{}{"schema": schema}
+	}
+	item["responses"] = map[string]interface{}{
+		fmt.Sprint(status): map[string]interface{}{
+			"description": http.StatusText(status),
+			"content":     content,
+		},
+		"default": map[string]interface{}{"$ref": "#/components/responses/Error"},
+	}
+	return strings.Join(segments, "/"), item, nil
+}
+
+func (p param) spec(in string) map[string]interface{} {
+	kind := p.kind
+	if kind == "" {
+		kind = "string"
+	}
+	schema := map[string]interface{}{"type": kind}
+	if p.format != "" {
+		schema["format"] = p.format
+	}
+	spec := map[string]interface{}{
+		"name":   p.name,
+		"in":     in,
+		"schema": schema,
+	}
+	if p.description != "" {
+		spec["description"] = p.description
+	}
+	if p.required {
+		spec["required"] = true
+	}
+	return spec
+}
+
+// formParams documents the query parameters bound from t's form tags.
+func formParams(t reflect.Type) []param {
+	var params []param
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("form"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		p := param{name: name, required: hasRule(f.Tag.Get("binding"), "required")}
+		switch f.Type.Kind() {
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			p.kind = "integer"
+		case reflect.Bool:
+			p.kind = "boolean"
+		}
+		params = append(params, p)
+	}
+	return params
+}
+
+func (b *schemaBuilder) envelope(fields envelope) map[string]interface{} {
+	props := map[string]interface{}{
+		"status": map[string]interface{}{"type": "string"},
+	}
+	for _, name := range sortedKeys(fields) {
+		props[name] = b.field(fields[name])
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"required":   []string{"status"},
+		"properties": props,
+	}
+}
+
+func (b *schemaBuilder) field(sample interface{}) map[string]interface{} {
+	switch sample := sample.(type) {
+	case nil:
+		return map[string]interface{}{"type": "object"}
+	case envelope:
+		props := make(map[string]interface{}, len(sample))
+		for _, name := range sortedKeys(sample) {
+			props[name] = b.field(sample[name])
+		}
+		return map[string]interface{}{"type": "object", "properties": props}
+	}
+	return b.schema(reflect.TypeOf(sample))
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) map[string]interface{} {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t, nullable = t.Elem(), true
+	}
+	schema := b.typeSchema(t)
+	if nullable && schema["$ref"] == nil {
+		schema["nullable"] = true
+	}
+	return schema
+}
+
+func (b *schemaBuilder) typeSchema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case uuidType:
+		return map[string]interface{}{"type": "string", "format": "uuid"}
+	case rawType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		return schemaRef(b.component(t))
+	}
+	return map[string]interface{}{}
+}
+
+// component registers the schema of the named struct t and returns its
+// name. Types of different packages sharing a name are told apart by the
+// package.
+func (b *schemaBuilder) component(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	name := exportedName(t.Name())
+	if _, taken := b.schemas[name]; taken {
+		pkg := t.PkgPath()
+		name = exportedName(pkg[strings.LastIndex(pkg, "/")+1:]) + name
+	}
+	b.names[t] = name
+	// The placeholder lets recursive types refer to themselves.
+	b.schemas[name] = map[string]interface{}{}
+	b.schemas[name] = b.object(t)
+	return name
+}
+
+func (b *schemaBuilder) object(t reflect.Type) map[string]interface{} {
+	props := make(map[string]interface{})
+	var required []string
+	b.fields(t, props, &required)
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// fields adds the JSON fields of struct t to props, flattening embedded
+// structs as encoding/json does.
+func (b *schemaBuilder) fields(t reflect.Type, props map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.fields(embedded, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = b.schema(f.Type)
+		if hasRule(f.Tag.Get("binding"), "required") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func hasRule(binding, rule string) bool {
+	for _, r := range strings.Split(binding, ",") {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys keeps the order components are named in stable.
+func sortedKeys(fields envelope) []string {
+	keys := make([]string, 0, len(fields))
+	for name := range fields {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func schemaRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func binarySchema() map[string]interface{} {
+	return map[string]interface{}{"type": "string", "format": "binary"}
+}
+
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
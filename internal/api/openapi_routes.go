@@ -0,0 +1,222 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Query parameters shared by several routes.
+var (
+	pageParam  = param{name: "page", kind: "integer", description: "Page number, from 1"}
+	limitParam = param{name: "limit", kind: "integer", description: "Page size"}
+	fromParam  = param{name: "from", description: "Start of the range, an RFC 3339 timestamp or a date"}
+	toParam    = param{name: "to", description: "End of the range, an RFC 3339 timestamp or a date"}
+	tokenParam = param{name: "token", required: true, description: "Token from the email link"}
+)
+
+// operations documents every route RegisterRoutes serves, in the same
+// order. A test keeps the two in step; `vote openapi` writes the document
+// they make to docs/openapi.json.
+var operations = []operation{
+	{method: http.MethodPost, path: "/api/auth/register", id: "register", tag: "auth", summary: "Register a user",
+		body: domain.RegisterRequest{}, status: http.StatusCreated},
+	{method: http.MethodPost, path: "/api/auth/login", id: "login", tag: "auth", summary: "Log in",
+		body: domain.LoginRequest{}, response: envelope{"token": "", "refreshToken": ""}},
+	{method: http.MethodPost, path: "/api/auth/refresh", id: "refreshToken", tag: "auth", summary: "Exchange a refresh token for a new token pair",
+		body: domain.RefreshTokenRequest{}, response: envelope{"token": "", "refreshToken": ""}},
+	{method: http.MethodPost, path: "/api/auth/logout", id: "logout", tag: "auth", summary: "Revoke a refresh token",
+		body: domain.RefreshTokenRequest{}},
+	{method: http.MethodGet, path: "/api/auth/verify", id: "verifyEmail", tag: "auth", summary: "Verify an email address",
+		query: []param{tokenParam}},
+	{method: http.MethodGet, path: "/api/auth/email/confirm", id: "confirmEmailChange", tag: "auth", summary: "Confirm an email change",
+		query: []param{tokenParam}, response: envelope{"change": domain.EmailChange{}}},
+	{method: http.MethodGet, path: "/api/polls/:id/stats", id: "getPollStats", tag: "results", summary: "Get a poll's vote counts",
+		auth:     authOptional,
+		query:    []param{{name: "maxAge", kind: "integer", description: "Oldest cached stats accepted, in seconds; 0 bypasses the cache for the poll owner"}},
+		response: envelope{"data": pollStatsData{}}},
+	{method: http.MethodGet, path: "/api/polls/:id/stats/wait", id: "waitPollStats", tag: "results", summary: "Wait for a poll's vote counts to change",
+		auth: authOptional,
+		query: []param{
+			{name: "version", kind: "integer", description: "Stats version the client already has"},
+			{name: "timeout", kind: "integer", description: "Seconds to wait, from 1 to 60"},
+		},
+		response: envelope{"data": statsWaitData{}}},
+	{method: http.MethodGet, path: "/api/polls/:id/results", id: "getPublicResults", tag: "results", summary: "Get a poll's public results",
+		response: envelope{"data": domain.PollResults{}}},
+	{method: http.MethodGet, path: "/api/polls/:id/og", id: "getPollPreview", tag: "results", summary: "Get a poll's link preview",
+		response: envelope{"data": domain.PollPreview{}}},
+	{method: http.MethodGet, path: "/api/polls/:id/og/image.png", id: "getPollPreviewImage", tag: "results", summary: "Get a poll's link preview image",
+		produces: []string{"image/png"}},
+	{method: http.MethodGet, path: "/api/polls/:id/winner", id: "getPollWinner", tag: "results", summary: "Get the winner of a closed poll",
+		response: envelope{"data": domain.PollWinner{}}},
+	{method: http.MethodGet, path: "/api/results/key", id: "getResultSigningKey", tag: "results", summary: "Get the public key result certificates are signed with",
+		response: envelope{"key": domain.ResultSigningKey{}}},
+
+	{method: http.MethodPost, path: "/api/auth/verify/resend", id: "resendVerification", tag: "auth", summary: "Resend the email verification",
+		auth: authBearer, status: http.StatusAccepted},
+	{method: http.MethodPost, path: "/api/auth/email/change", id: "requestEmailChange", tag: "auth", summary: "Request an email change",
+		auth: authBearer, body: domain.EmailChangeRequest{}, status: http.StatusAccepted, response: envelope{"change": domain.EmailChange{}}},
+	{method: http.MethodGet, path: "/api/auth/email/change", id: "getEmailChange", tag: "auth", summary: "Get the pending email change",
+		auth: authBearer, response: envelope{"change": domain.EmailChange{}}},
+	{method: http.MethodDelete, path: "/api/auth/email/change", id: "cancelEmailChange", tag: "auth", summary: "Cancel the pending email change",
+		auth: authBearer},
+	{method: http.MethodGet, path: "/api/users/me/consents", id: "getUserConsents", tag: "users", summary: "Get the caller's consents",
+		auth: authBearer, response: envelope{"consent": domain.ConsentStatus{}}},
+	{method: http.MethodPost, path: "/api/users/me/consents", id: "acceptConsents", tag: "users", summary: "Accept the current terms and privacy policy",
+		auth: authBearer, body: domain.AcceptConsentRequest{}, response: envelope{"consent": domain.ConsentStatus{}}},
+
+	{method: http.MethodPost, path: "/api/polls", id: "createPoll", tag: "polls", summary: "Create a poll",
+		auth: authBearer, body: createPollBody{}, status: http.StatusCreated,
+		response: envelope{"poll_id": "", "poll": domain.Poll{}}},
+	{method: http.MethodPost, path: "/api/polls/validate", id: "validatePoll", tag: "polls", summary: "Check a poll against the creation rules without saving it",
+		auth: authBearer, body: domain.CreatePollRequest{},
+		response: envelope{"valid": false, "errors": []*domain.ValidationError{}}},
+	{method: http.MethodGet, path: "/api/polls", id: "getPollsForFeed", tag: "feed", summary: "Get the caller's feed",
+		auth: authBearer,
+		query: []param{
+			{name: "tag", description: "Only polls with this tag"},
+			pageParam,
+			limitParam,
+			{name: "total", description: "true, false or estimate"},
+			{name: "cursor", description: "nextCursor of the previous page"},
+			{name: "lang", description: "Comma-separated languages, or all"},
+		},
+		response: envelope{"data": feedData{}}},
+	{method: http.MethodGet, path: "/api/feed/stream", id: "streamFeed", tag: "feed", summary: "Stream new feed polls as server-sent events",
+		auth: authBearer, produces: []string{"text/event-stream"}},
+	{method: http.MethodGet, path: "/api/polls/search", id: "searchPolls", tag: "polls", summary: "Search polls",
+		auth: authBearer, queryType: domain.PollSearchQuery{}, response: envelope{"data": domain.PollSearchResult{}}},
+	{method: http.MethodGet, path: "/api/polls/:id", id: "getPoll", tag: "polls", summary: "Get a poll",
+		auth: authBearer, response: envelope{"data": domain.Poll{}}},
+	{method: http.MethodPost, path: "/api/polls/:id/vote", id: "voteOnPoll", tag: "votes", summary: "Vote on a poll; without a body, for its default option",
+		auth: authBearer, body: voteBody{}, bodyOptional: true, status: http.StatusCreated,
+		response: envelope{"voteId": uuid.UUID{}, "receipt": domain.VoteReceipt{}, "dailyVotes": domain.VoteAllowance{}}},
+	{method: http.MethodPost, path: "/api/polls/:id/skip", id: "skipPoll", tag: "votes", summary: "Skip a poll",
+		auth: authBearer, body: domain.SkipRequest{}, bodyOptional: true},
+	{method: http.MethodGet, path: "/api/users/me/votes", id: "getUserVotes", tag: "votes", summary: "List or export the caller's votes",
+		auth:     authBearer,
+		query:    []param{pageParam, limitParam, fromParam, toParam, {name: "include_deleted", kind: "boolean"}},
+		response: envelope{"data": domain.UserVotesResponse{}},
+		produces: []string{mimeCSV}},
+	{method: http.MethodPut, path: "/api/users/me/votes/:voteId", id: "updateVote", tag: "votes", summary: "Change a vote",
+		auth: authBearer, body: updateVoteBody{}},
+	{method: http.MethodDelete, path: "/api/users/me/votes/:voteId", id: "deleteVote", tag: "votes", summary: "Delete a vote",
+		auth: authBearer},
+	{method: http.MethodGet, path: "/api/votes/:id/verify", id: "verifyVote", tag: "votes", summary: "Verify a vote receipt",
+		auth:     authBearer,
+		query:    []param{{name: "signature", required: true, description: "Receipt signature"}},
+		response: envelope{"data": domain.VoteVerification{}}},
+	{method: http.MethodGet, path: "/api/users/me/quotas", id: "getUserQuotas", tag: "users", summary: "Get the caller's quota usage",
+		auth: authBearer, response: envelope{"quotas": []domain.QuotaUsage{}}},
+	{method: http.MethodGet, path: "/api/users/me/limits", id: "getUserLimits", tag: "users", summary: "Get the caller's vote allowance",
+		auth: authBearer, response: envelope{"limits": envelope{"dailyVotes": domain.VoteAllowance{}}}},
+	{method: http.MethodGet, path: "/api/users/me/activity", id: "getUserActivity", tag: "users", summary: "Get the caller's daily activity",
+		auth: authBearer, query: []param{fromParam, toParam},
+		response: envelope{"from": "", "to": "", "activity": []domain.UserActivity{}}},
+	{method: http.MethodGet, path: "/api/users/me/preferences", id: "getUserPreferences", tag: "users", summary: "Get the caller's preferences",
+		auth: authBearer, response: envelope{"preferences": domain.UserPreferences{}}},
+	{method: http.MethodPut, path: "/api/users/me/preferences", id: "updateUserPreferences", tag: "users", summary: "Replace the caller's preferences",
+		auth: authBearer, body: domain.UserPreferences{}, response: envelope{"preferences": domain.UserPreferences{}}},
+	{method: http.MethodPut, path: "/api/users/me/avatar", id: "uploadAvatar", tag: "users", summary: "Upload the caller's avatar",
+		auth: authBearer, rawBody: []string{"image/*"}, response: envelope{"avatarUrl": ""}},
+	{method: http.MethodGet, path: "/api/polls/:id/tally", id: "getElectionTally", tag: "results", summary: "Get the certified tally of an election",
+		auth: authBearer, response: envelope{"certification": domain.ElectionCertification{}}},
+	{method: http.MethodGet, path: "/api/polls/:id/certificate", id: "getResultCertificate", tag: "results", summary: "Download the signed results of a closed poll",
+		auth: authBearer, produces: []string{gin.MIMEJSON}, document: domain.ResultCertificate{}},
+	{method: http.MethodPatch, path: "/api/polls/:id", id: "updatePoll", tag: "polls", summary: "Edit a poll",
+		auth: authBearer, body: domain.UpdatePollRequest{}, response: envelope{"poll": domain.Poll{}}},
+	{method: http.MethodPatch, path: "/api/polls/:id/tags", id: "updatePollTags", tag: "polls", summary: "Replace a poll's tags",
+		auth: authBearer, body: domain.UpdatePollTagsRequest{}, response: envelope{"poll": domain.Poll{}}},
+	{method: http.MethodPost, path: "/api/polls/:id/close", id: "closePoll", tag: "polls", summary: "Close a poll",
+		auth: authBearer},
+	{method: http.MethodPut, path: "/api/polls/:id/options/:index/image", id: "uploadOptionImage", tag: "polls", summary: "Upload an option's image",
+		auth: authBearer, rawBody: []string{"image/*"}, response: envelope{"poll": domain.Poll{}}},
+	{method: http.MethodPatch, path: "/api/polls/:id/options/order", id: "reorderOptions", tag: "polls", summary: "Reorder a poll's options",
+		auth: authBearer, body: domain.ReorderOptionsRequest{}, response: envelope{"poll": domain.Poll{}}},
+	{method: http.MethodPatch, path: "/api/polls/:id/options/:index", id: "updateOption", tag: "polls", summary: "Edit an option",
+		auth: authBearer, body: domain.UpdateOptionRequest{}, response: envelope{"poll": domain.Poll{}}},
+	{method: http.MethodPost, path: "/api/uploads/sign", id: "signUpload", tag: "media", summary: "Get a signed URL to upload an image to",
+		auth: authBearer, body: domain.SignUploadRequest{}, response: envelope{"upload": domain.SignedUpload{}}},
+	{method: http.MethodPost, path: "/api/uploads/confirm", id: "confirmUpload", tag: "media", summary: "Attach an image uploaded to a signed URL",
+		auth: authBearer, body: domain.ConfirmUploadRequest{}, response: envelope{"data": domain.UploadConfirmation{}}},
+	{method: http.MethodPut, path: "/api/polls/:id/status", id: "changePollStatus", tag: "polls", summary: "Publish, schedule or close a poll",
+		auth: authBearer, body: domain.PollStatusRequest{}, response: envelope{"poll": domain.Poll{}}},
+	{method: http.MethodGet, path: "/api/polls/:id/votes/export", id: "exportPollVotes", tag: "votes", summary: "Export a poll's votes",
+		auth: authBearer,
+		query: []param{
+			{name: "format", description: "csv or ndjson"},
+			fromParam,
+			toParam,
+			{name: "include_deleted", kind: "boolean"},
+		},
+		produces: []string{mimeNDJSON, mimeCSV}},
+	{method: http.MethodGet, path: "/api/polls/:id/owner-stats", id: "getPollOwnerStats", tag: "results", summary: "Get a poll's stats for its owner",
+		auth: authBearer, response: envelope{"stats": domain.PollOwnerStats{}}},
+	{method: http.MethodGet, path: "/api/polls/:id/analytics/hourly", id: "getPollVoteTimeline", tag: "analytics", summary: "Get a poll's votes by hour",
+		auth: authBearer, query: []param{fromParam, toParam},
+		response: envelope{"from": "", "to": "", "hours": []domain.VoteBucket{}}},
+	{method: http.MethodPost, path: "/api/polls/:id/collaborators", id: "addPollCollaborator", tag: "polls", summary: "Add a collaborator to a poll",
+		auth: authBearer, body: domain.AddCollaboratorRequest{}, response: envelope{"collaborator": domain.Collaborator{}}},
+	{method: http.MethodDelete, path: "/api/polls/:id/collaborators/:userId", id: "removePollCollaborator", tag: "polls", summary: "Remove a collaborator from a poll",
+		auth: authBearer},
+	{method: http.MethodPost, path: "/api/orgs", id: "createOrganization", tag: "organizations", summary: "Create an organization",
+		auth: authBearer, body: domain.CreateOrganizationRequest{}, status: http.StatusCreated,
+		response: envelope{"organization": domain.Organization{}}},
+	{method: http.MethodPost, path: "/api/orgs/:id/members", id: "addOrganizationMember", tag: "organizations", summary: "Add a member to an organization",
+		auth: authBearer, body: domain.AddMemberRequest{}},
+	{method: http.MethodPost, path: "/api/polls/:id/organization-vote", id: "castOrganizationVote", tag: "organizations", summary: "Cast an organization's official vote",
+		auth: authBearer, body: organizationVoteBody{}, response: envelope{"vote": domain.OrganizationVote{}}},
+	{method: http.MethodGet, path: "/api/tags/aliases", id: "getTagAliases", tag: "tags", summary: "List tag aliases",
+		auth: authBearer, response: envelope{"aliases": []domain.TagAlias{}}},
+	{method: http.MethodGet, path: "/api/tags/rules", id: "getTagRules", tag: "tags", summary: "List tag posting rules",
+		auth: authBearer, response: envelope{"rules": []domain.TagRule{}}},
+
+	{method: http.MethodPost, path: "/api/moderation/tags/aliases", id: "createTagAlias", tag: "moderation", summary: "Alias one tag to another",
+		auth: authBearer, body: domain.TagAliasRequest{}, status: http.StatusCreated, response: envelope{"alias": domain.TagAlias{}}},
+	{method: http.MethodPost, path: "/api/moderation/tags/merge", id: "mergeTags", tag: "moderation", summary: "Merge one tag into another",
+		auth: authBearer, body: domain.MergeTagsRequest{}, response: envelope{"merge": domain.TagMergeResult{}}},
+	{method: http.MethodGet, path: "/api/moderation/flags", id: "getModerationFlags", tag: "moderation", summary: "List moderation flags",
+		auth:     authBearer,
+		query:    []param{pageParam, limitParam, {name: "status", description: "Flag status, open by default"}},
+		response: envelope{"data": domain.ModerationQueue{}}},
+	{method: http.MethodPost, path: "/api/moderation/flags/:id/resolve", id: "resolveModerationFlag", tag: "moderation", summary: "Resolve a moderation flag",
+		auth: authBearer, body: domain.ResolveFlagRequest{}, response: envelope{"flag": domain.ModerationFlag{}}},
+
+	{method: http.MethodPost, path: "/api/admin/polls/:id/recount", id: "recountPollStats", tag: "admin", summary: "Recount a poll's votes",
+		auth: authBearer, response: envelope{"recount": domain.PollRecount{}}},
+	{method: http.MethodPost, path: "/api/admin/votes/import", id: "importVotes", tag: "admin", summary: "Import votes",
+		auth:     authBearer,
+		query:    []param{{name: "format", description: "csv or ndjson"}},
+		rawBody:  []string{mimeNDJSON, mimeCSV},
+		response: envelope{"result": domain.VoteImportResult{}}},
+	{method: http.MethodGet, path: "/api/admin/users", id: "searchUsers", tag: "admin", summary: "Search users",
+		auth: authBearer, queryType: domain.UserSearchQuery{}, response: envelope{"data": domain.UserList{}}},
+	{method: http.MethodPut, path: "/api/admin/users/:id/standing", id: "setUserStanding", tag: "admin", summary: "Suspend, ban or reinstate a user",
+		auth: authBearer, body: domain.UserStandingRequest{}, response: envelope{"standing": domain.UserStanding("")}},
+	{method: http.MethodDelete, path: "/api/admin/polls/:id", id: "forceDeletePoll", tag: "admin", summary: "Delete any poll",
+		auth: authBearer},
+	{method: http.MethodGet, path: "/api/admin/stats", id: "getPlatformStats", tag: "admin", summary: "Get platform stats",
+		auth: authBearer, response: envelope{"data": domain.PlatformStats{}}},
+	{method: http.MethodGet, path: "/api/admin/users/:id/consents", id: "getUserConsentHistory", tag: "admin", summary: "Get a user's consents",
+		auth: authBearer, response: envelope{"consent": domain.ConsentStatus{}}},
+	{method: http.MethodGet, path: "/api/admin/analytics/tags/:tag", id: "getTagVoteTrend", tag: "analytics", summary: "Get a tag's votes by day",
+		auth: authBearer, query: []param{fromParam, toParam},
+		response: envelope{"from": "", "to": "", "days": []domain.VoteBucket{}}},
+	{method: http.MethodPut, path: "/api/admin/tags/:tag/rule", id: "setTagRule", tag: "admin", summary: "Set a tag's posting rule",
+		auth: authBearer, body: domain.TagRuleRequest{}, response: envelope{"rule": domain.TagRule{}}},
+	{method: http.MethodDelete, path: "/api/admin/tags/:tag/rule", id: "deleteTagRule", tag: "admin", summary: "Remove a tag's posting rule",
+		auth: authBearer},
+
+	{method: http.MethodGet, path: "/metrics", id: "metrics", tag: "ops", summary: "Prometheus metrics",
+		produces: []string{"text/plain"}},
+	{method: http.MethodGet, path: "/healthz", id: "liveness", tag: "ops", summary: "Liveness probe"},
+	{method: http.MethodGet, path: "/readyz", id: "readiness", tag: "ops", summary: "Readiness probe; 503 while a dependency is down",
+		response: envelope{"dependencies": map[string]string{}}},
+	{method: http.MethodGet, path: "/api/openapi.json", id: "getOpenAPI", tag: "ops", summary: "This document",
+		produces: []string{gin.MIMEJSON}},
+	{method: http.MethodGet, path: "/docs", id: "docs", tag: "ops", summary: "Swagger UI",
+		produces: []string{"text/html"}},
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) createOrganization(c *gin.Context) error {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		return unauthenticated()
+	}
+
+	var req domain.CreateOrganizationRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	req.OwnerID = principal.ID
+
+	org, err := h.service.CreateOrganization(c.Request.Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":       "success",
+		"organization": org,
+	})
+	return nil
+}
+
+func (h *Handler) addOrganizationMember(c *gin.Context) error {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		return unauthenticated()
+	}
+
+	orgID, err := h.uuidParam(c, "id", "Invalid organization ID")
+	if err != nil {
+		return err
+	}
+
+	var req domain.AddMemberRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	req.ActorID = principal.ID
+
+	if err := h.service.AddOrganizationMember(c.Request.Context(), orgID, &req); err != nil {
+		err = describe(err, domain.ErrForbidden, "Only organization admins can add members")
+		return describe(err, domain.ErrNotFound, "User not found")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+	})
+	return nil
+}
+
+type organizationVoteBody struct {
+	OrganizationID uuid.UUID  `json:"organizationId" binding:"required"`
+	OptionID       *uuid.UUID `json:"optionId"`
+	OptionIndex    *int       `json:"optionIndex" binding:"omitempty,min=0"`
+	AccessCode     string     `json:"accessCode"`
+}
+
+func (h *Handler) castOrganizationVote(c *gin.Context) error {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		return unauthenticated()
+	}
+
+	pollID, err := h.uuidParam(c, "id", "Invalid poll ID")
+	if err != nil {
+		return err
+	}
+
+	var req organizationVoteBody
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	if req.OptionID == nil && req.OptionIndex == nil {
+		return badRequest("optionId or optionIndex is required")
+	}
+
+	serviceReq := &domain.OrganizationVoteRequest{
+		OrganizationID: req.OrganizationID,
+		OptionID:       req.OptionID,
+		ActorID:        principal.ID,
+		AccessCode:     req.AccessCode,
+		Location:       geoLocation(c),
+	}
+	if req.OptionIndex != nil {
+		serviceReq.OptionIndex = *req.OptionIndex
+	}
+	vote, err := h.service.CastOrganizationVote(c.Request.Context(), pollID, serviceReq)
+	if err != nil {
+		err = describe(err, domain.ErrForbidden, "Only organization admins can vote for the organization")
+		return describe(err, domain.ErrNotFound, "Poll not found")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"vote":   vote,
+	})
+	return nil
+}
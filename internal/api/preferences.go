@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) getUserPreferences(c *gin.Context) error {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		return unauthenticated()
+	}
+
+	prefs, err := h.service.GetUserPreferences(c.Request.Context(), principal.ID)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":      "success",
+		"preferences": prefs,
+	})
+	return nil
+}
+
+func (h *Handler) updateUserPreferences(c *gin.Context) error {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		return unauthenticated()
+	}
+
+	var req domain.UserPreferences
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	prefs, err := h.service.UpdateUserPreferences(c.Request.Context(), principal.ID, &req)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":      "success",
+		"preferences": prefs,
+	})
+	return nil
+}
@@ -0,0 +1,250 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/behzadon/vote/internal/publicid"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// storedIDsKey marks responses sent with the IDs as stored, for
+	// documents whose bytes are signed.
+	storedIDsKey = "stored_ids"
+	// maxIDBodyBytes bounds the request bodies whose IDs are decoded.
+	// Larger ones, such as vote imports, are passed on untouched.
+	maxIDBodyBytes = 1 << 20
+)
+
+func (h *Handler) encodesIDs() bool {
+	_, stored := h.ids.(publicid.UUIDs)
+	return !stored
+}
+
+// publicIDs shows the ID fields of JSON responses, NDJSON exports and event
+// streams in the public form, and reads that form from the ID fields of JSON
+// request bodies. ID fields are "id" and keys ending in "Id", "Ids" or "By".
+func (h *Handler) publicIDs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := h.decodeBodyIDs(c); err != nil {
+			h.respondError(c, err)
+			c.Abort()
+			return
+		}
+		c.Writer = &idEncodingWriter{ResponseWriter: c.Writer, c: c, ids: h.ids}
+		c.Next()
+	}
+}
+
+// keepStoredIDs sends the response with the IDs as stored.
+func keepStoredIDs(c *gin.Context) {
+	c.Set(storedIDsKey, true)
+}
+
+func (h *Handler) decodeBodyIDs(c *gin.Context) error {
+	if c.Request.Body == nil || c.ContentType() != "application/json" {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIDBodyBytes+1))
+	if err != nil {
+		return badRequest("Invalid request body")
+	}
+	if len(body) > maxIDBodyBytes {
+		c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+		return nil
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	// Bodies that aren't a single JSON document are left to the handler to
+	// reject.
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil || dec.More() {
+		return nil
+	}
+	found, err := h.decodeIDs(doc)
+	if err != nil || !found {
+		return err
+	}
+	if body, err = json.Marshal(doc); err != nil {
+		return badRequest("Invalid request body")
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	c.Request.ContentLength = int64(len(body))
+	return nil
+}
+
+// decodeIDs replaces the public IDs in the ID fields of doc with the stored
+// UUIDs and reports whether it found any ID field.
+func (h *Handler) decodeIDs(doc interface{}) (bool, error) {
+	found := false
+	switch doc := doc.(type) {
+	case map[string]interface{}:
+		for key, value := range doc {
+			if !isIDKey(key) {
+				nested, err := h.decodeIDs(value)
+				if err != nil {
+					return false, err
+				}
+				found = found || nested
+				continue
+			}
+			decoded, err := h.decodeIDValue(key, value)
+			if err != nil {
+				return false, err
+			}
+			doc[key], found = decoded, true
+		}
+	case []interface{}:
+		for _, value := range doc {
+			nested, err := h.decodeIDs(value)
+			if err != nil {
+				return false, err
+			}
+			found = found || nested
+		}
+	}
+	return found, nil
+}
+
+func (h *Handler) decodeIDValue(key string, value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case string:
+		id, err := h.ids.Decode(value)
+		if err != nil {
+			return nil, badRequest("Invalid " + key)
+		}
+		return id.String(), nil
+	case []interface{}:
+		for i, element := range value {
+			if s, ok := element.(string); ok {
+				id, err := h.ids.Decode(s)
+				if err != nil {
+					return nil, badRequest("Invalid " + key)
+				}
+				value[i] = id.String()
+			}
+		}
+	}
+	return value, nil
+}
+
+func isIDKey(key string) bool {
+	return key == "id" || strings.HasSuffix(key, "Id") || strings.HasSuffix(key, "Ids") || strings.HasSuffix(key, "By")
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// idEncodingWriter encodes the IDs in each write. JSON, NDJSON and event
+// stream bodies are written a whole document at a time, so no ID is split
+// across writes.
+type idEncodingWriter struct {
+	gin.ResponseWriter
+	c   *gin.Context
+	ids publicid.Codec
+}
+
+func (w *idEncodingWriter) Write(data []byte) (int, error) {
+	if !w.encodes() {
+		return w.ResponseWriter.Write(data)
+	}
+	if _, err := w.ResponseWriter.Write(encodeIDs(data, w.ids)); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (w *idEncodingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *idEncodingWriter) encodes() bool {
+	if w.c.GetBool(storedIDsKey) {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	switch mediaType {
+	case "application/json", mimeNDJSON, "text/event-stream":
+		return true
+	}
+	return false
+}
+
+// uuidLength is the length of a UUID in its canonical form.
+const uuidLength = 36
+
+// idScope is an object or array encodeIDs is in. For an object, ids tells
+// whether the member being read is an ID field; for an array, whether it is
+// the value of one.
+type idScope struct {
+	array bool
+	ids   bool
+}
+
+// encodeIDs replaces the UUIDs in the ID fields of the JSON documents in
+// data with their public form, leaving the rest of data as it is. Other
+// strings, such as poll titles, are not touched even when they hold a UUID.
+func encodeIDs(data []byte, ids publicid.Codec) []byte {
+	out := make([]byte, 0, len(data))
+	var scopes []idScope
+	inIDField := func() bool {
+		return len(scopes) > 0 && scopes[len(scopes)-1].ids
+	}
+	for i := 0; i < len(data); {
+		switch data[i] {
+		case '{':
+			scopes = append(scopes, idScope{})
+		case '[':
+			scopes = append(scopes, idScope{array: true, ids: inIDField()})
+		case '}', ']':
+			if len(scopes) > 0 {
+				scopes = scopes[:len(scopes)-1]
+			}
+		}
+		if data[i] != '"' {
+			out = append(out, data[i])
+			i++
+			continue
+		}
+		end := i + 1
+		for end < len(data) && data[end] != '"' {
+			if data[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(data) {
+			return append(out, data[i:]...)
+		}
+		if n := len(scopes); n > 0 && !scopes[n-1].array && isMemberName(data[end+1:]) {
+			scopes[n-1].ids = isIDKey(string(data[i+1 : end]))
+		} else if inIDField() && end-i-1 == uuidLength {
+			if id, err := uuid.Parse(string(data[i+1 : end])); err == nil {
+				out = append(out, '"')
+				out = append(out, ids.Encode(id)...)
+				out = append(out, '"')
+				i = end + 1
+				continue
+			}
+		}
+		out = append(out, data[i:end+1]...)
+		i = end + 1
+	}
+	return out
+}
+
+// isMemberName reports whether the string rest follows is an object
+// member's name rather than its value.
+func isMemberName(rest []byte) bool {
+	rest = bytes.TrimLeft(rest, " \t\r\n")
+	return len(rest) > 0 && rest[0] == ':'
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/metrics"
+	"github.com/behzadon/vote/internal/quota"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	headerQuotaLimit     = "X-Quota-Limit"
+	headerQuotaRemaining = "X-Quota-Remaining"
+	headerQuotaReset     = "X-Quota-Reset"
+	headerQuotaPeriod    = "X-Quota-Period"
+)
+
+type HandlerOption func(*Handler)
+
+func WithQuotaManager(m *quota.Manager) HandlerOption {
+	return func(h *Handler) {
+		h.quotas = m
+	}
+}
+
+func setQuotaHeaders(c *gin.Context, usage domain.QuotaUsage) {
+	c.Header(headerQuotaLimit, strconv.Itoa(usage.Limit))
+	c.Header(headerQuotaRemaining, strconv.Itoa(usage.Remaining()))
+	c.Header(headerQuotaReset, strconv.FormatInt(usage.ResetAt.Unix(), 10))
+	c.Header(headerQuotaPeriod, string(usage.Period))
+}
+
+func (h *Handler) QuotaLimit(action domain.QuotaAction) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.quotas == nil {
+			c.Next()
+			return
+		}
+
+		principal, ok := auth.CurrentUser(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		res, err := h.quotas.Reserve(c.Request.Context(), principal.ID, action)
+		var exceeded *domain.QuotaExceededError
+		if errors.As(err, &exceeded) {
+			h.rejectQuota(c, exceeded.Usage)
+			return
+		}
+		if err != nil {
+			// Quotas are an accounting limit, not a protection mechanism, so a
+			// Redis or database outage lets the request through.
+			logging.For(c.Request.Context(), h.logger).Warn("quota check failed, allowing request",
+				zap.Error(err),
+				zap.String("user_id", principal.ID.String()),
+				zap.String("action", string(action)),
+			)
+			c.Next()
+			return
+		}
+
+		if usage, ok := res.Tightest(); ok {
+			setQuotaHeaders(c, usage)
+		}
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusBadRequest {
+			h.quotas.Release(c.Request.Context(), res)
+			return
+		}
+		h.quotas.Commit(c.Request.Context(), res)
+	}
+}
+
+func (h *Handler) rejectQuota(c *gin.Context, usage domain.QuotaUsage) {
+	metrics.QuotaExceeded.WithLabelValues(string(usage.Action), string(usage.Period)).Inc()
+
+	setQuotaHeaders(c, usage)
+	retryAfter := int(math.Ceil(time.Until(usage.ResetAt).Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+
+	status := http.StatusTooManyRequests
+	if usage.Period == domain.QuotaMonthly {
+		status = http.StatusPaymentRequired
+	}
+	c.AbortWithStatusJSON(status, gin.H{
+		"status":  "error",
+		"message": (&domain.QuotaExceededError{Usage: usage}).Error(),
+		"quota": gin.H{
+			"action":  usage.Action,
+			"period":  usage.Period,
+			"limit":   usage.Limit,
+			"resetAt": usage.ResetAt.UTC().Format(time.RFC3339),
+		},
+	})
+}
+
+func (h *Handler) rejectCreationLimit(c *gin.Context, err error) {
+	response := gin.H{
+		"status":  "error",
+		"message": err.Error(),
+	}
+	var exceeded *domain.CreationLimitError
+	if errors.As(err, &exceeded) {
+		retryAfter := int(math.Ceil(time.Until(exceeded.ResetAt).Seconds()))
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		response["limit"] = exceeded.Limit
+		response["resetAt"] = exceeded.ResetAt.UTC().Format(time.RFC3339)
+	}
+	c.JSON(http.StatusTooManyRequests, response)
+}
+
+func (h *Handler) getUserQuotas(c *gin.Context) {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "user not authenticated",
+		})
+		return
+	}
+
+	if h.quotas == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"quotas": []domain.QuotaUsage{},
+		})
+		return
+	}
+
+	usages, err := h.quotas.Usage(c.Request.Context(), principal.ID)
+	if err != nil {
+		logging.For(c.Request.Context(), h.logger).Error("failed to get user quotas", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to get quotas",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"quotas": usages,
+	})
+}
+
+func (h *Handler) getUserLimits(c *gin.Context) {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "user not authenticated",
+		})
+		return
+	}
+
+	allowance, err := h.service.GetVoteAllowance(c.Request.Context(), principal.ID)
+	if err != nil {
+		logging.For(c.Request.Context(), h.logger).Error("failed to get vote allowance", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to get limits",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"limits": gin.H{
+			"dailyVotes": allowance,
+		},
+	})
+}
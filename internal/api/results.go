@@ -0,0 +1,125 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	liveResultsCacheControl  = "public, max-age=30, stale-while-revalidate=60"
+	finalResultsCacheControl = "public, max-age=31536000, immutable"
+)
+
+// getPollWinner reports the winner recorded when the poll closed, which
+// never changes afterwards.
+func (h *Handler) getPollWinner(c *gin.Context) error {
+	pollID, err := h.uuidParam(c, "id", "Invalid poll ID")
+	if err != nil {
+		return err
+	}
+
+	winner, err := h.service.GetPollWinner(c.Request.Context(), pollID)
+	if err != nil {
+		return describe(err, domain.ErrNotFound, "Poll not found")
+	}
+
+	c.Header("Cache-Control", finalResultsCacheControl)
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   winner,
+	})
+	return nil
+}
+
+// getPublicResults serves results without authentication so share links work
+// for logged-out visitors. Responses carry a content ETag; closed polls can
+// never change and are cached for a year.
+func (h *Handler) getPublicResults(c *gin.Context) error {
+	pollID, err := h.uuidParam(c, "id", "Invalid poll ID")
+	if err != nil {
+		return err
+	}
+
+	results, err := h.service.GetPublicResults(c.Request.Context(), pollID)
+	if err != nil {
+		return describe(err, domain.ErrNotFound, "Results not found")
+	}
+
+	body, err := json.Marshal(gin.H{
+		"status": "success",
+		"data":   results,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal public results: %w", err)
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	c.Header("ETag", etag)
+	if results.Final {
+		c.Header("Cache-Control", finalResultsCacheControl)
+	} else {
+		c.Header("Cache-Control", liveResultsCacheControl)
+	}
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return nil
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	return nil
+}
+
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// getResultCertificate downloads the signed results of a closed poll. The
+// certificate is sent bare, without the usual envelope, so the file can be
+// archived as is.
+func (h *Handler) getResultCertificate(c *gin.Context) error {
+	pollID, err := h.uuidParam(c, "id", "Invalid poll ID")
+	if err != nil {
+		return err
+	}
+
+	cert, err := h.service.GetResultCertificate(c.Request.Context(), pollID)
+	if err != nil {
+		return describe(err, domain.ErrNotFound, "Poll not found")
+	}
+
+	// Certificates are archived and verified byte for byte, so they keep
+	// the stored IDs their payload was signed with.
+	keepStoredIDs(c)
+	c.Header("Content-Disposition", `attachment; filename="poll-`+h.ids.Encode(cert.PollID)+`-results.json"`)
+	c.JSON(http.StatusOK, cert)
+	return nil
+}
+
+// getResultSigningKey publishes the key result certificates are signed
+// with, for archives to check certificates against.
+func (h *Handler) getResultSigningKey(c *gin.Context) error {
+	key, err := h.service.GetResultSigningKey(c.Request.Context())
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"key":    key,
+	})
+	return nil
+}
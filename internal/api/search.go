@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) searchPolls(c *gin.Context) error {
+	var query domain.PollSearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		return badRequest("invalid search parameters")
+	}
+
+	result, err := h.service.SearchPolls(c.Request.Context(), query)
+	if err != nil {
+		return describe(err, domain.ErrInvalidInput, "invalid search query")
+	}
+
+	h.linkPolls(result.Polls)
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   result,
+	})
+	return nil
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// feedStreamHeartbeat keeps idle streams from being cut by proxies.
+const feedStreamHeartbeat = 25 * time.Second
+
+type FeedStream interface {
+	Subscribe(userID uuid.UUID, prefs *domain.UserPreferences) (<-chan *domain.Poll, func())
+}
+
+func WithFeedStream(s FeedStream) HandlerOption {
+	return func(h *Handler) {
+		h.feedStream = s
+	}
+}
+
+func (h *Handler) streamFeed(c *gin.Context) {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "user not authenticated",
+		})
+		return
+	}
+
+	if h.feedStream == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":  "error",
+			"message": "Feed stream is not available",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	prefs, err := h.service.GetUserPreferences(ctx, principal.ID)
+	if err != nil {
+		logging.For(ctx, h.logger).Error("failed to get user preferences", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to open feed stream",
+		})
+		return
+	}
+
+	polls, unsubscribe := h.feedStream.Subscribe(principal.ID, prefs)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(feedStreamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case poll, ok := <-polls:
+			if !ok {
+				return
+			}
+			// The poll is shared with the other streams.
+			linked := *poll
+			linked.Links = h.urls.PollLinks(poll)
+			c.SSEvent("poll.created", &linked)
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": keepalive\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
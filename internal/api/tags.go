@@ -0,0 +1,114 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WithModerators grants the given users access to the moderation endpoints.
+func WithModerators(ids []uuid.UUID) HandlerOption {
+	return func(h *Handler) {
+		h.moderators = make(map[uuid.UUID]struct{}, len(ids))
+		for _, id := range ids {
+			h.moderators[id] = struct{}{}
+		}
+	}
+}
+
+func (h *Handler) getTagAliases(c *gin.Context) error {
+	aliases, err := h.service.GetTagAliases(c.Request.Context())
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"aliases": aliases,
+	})
+	return nil
+}
+
+func (h *Handler) createTagAlias(c *gin.Context) error {
+	var req domain.TagAliasRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	alias, err := h.service.CreateTagAlias(c.Request.Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"alias":  alias,
+	})
+	return nil
+}
+
+func (h *Handler) mergeTags(c *gin.Context) error {
+	var req domain.MergeTagsRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	result, err := h.service.MergeTags(c.Request.Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"merge":  result,
+	})
+	return nil
+}
+
+func (h *Handler) getTagRules(c *gin.Context) error {
+	rules, err := h.service.GetTagRules(c.Request.Context())
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"rules":  rules,
+	})
+	return nil
+}
+
+func (h *Handler) setTagRule(c *gin.Context) error {
+	var req domain.TagRuleRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	principal, _ := auth.CurrentUser(c)
+	req.ActorID = principal.ID
+
+	rule, err := h.service.SetTagRule(c.Request.Context(), c.Param("tag"), &req)
+	if err != nil {
+		return describe(err, domain.ErrInvalidInput, "Invalid tag")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"rule":   rule,
+	})
+	return nil
+}
+
+func (h *Handler) deleteTagRule(c *gin.Context) error {
+	if err := h.service.DeleteTagRule(c.Request.Context(), c.Param("tag")); err != nil {
+		err = describe(err, domain.ErrInvalidInput, "Invalid tag")
+		return describe(err, domain.ErrNotFound, "Tag has no rule")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+	})
+	return nil
+}
@@ -0,0 +1,335 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/publicid"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	mimeCSV    = "text/csv"
+	mimeNDJSON = "application/x-ndjson"
+
+	// exportFlushEvery is how many rows are buffered before an export is
+	// flushed to the client.
+	exportFlushEvery = 100
+
+	dateLayout = "2006-01-02"
+)
+
+var (
+	voteCSVHeader     = []string{"vote_id", "poll_id", "poll_title", "poll_status", "option_id", "option_index", "option_text", "created_at", "deleted_at"}
+	pollVoteCSVHeader = []string{"vote_id", "poll_id", "voter_id", "option_id", "option_index", "option_text", "created_at"}
+)
+
+func wantsCSV(c *gin.Context) bool {
+	return c.NegotiateFormat(gin.MIMEJSON, mimeCSV) == mimeCSV
+}
+
+// parseVoteFilter reads the from, to and include_deleted query parameters.
+// Dates may be RFC 3339 timestamps or plain dates; a plain "to" date covers
+// the whole day.
+func parseVoteFilter(c *gin.Context) (domain.VoteFilter, error) {
+	var filter domain.VoteFilter
+
+	if raw := c.Query("from"); raw != "" {
+		from, _, err := parseDateParam(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from: %w", err)
+		}
+		filter.From = &from
+	}
+	if raw := c.Query("to"); raw != "" {
+		to, dateOnly, err := parseDateParam(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to: %w", err)
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, errors.New("from must be before to")
+	}
+
+	if raw := c.Query("include_deleted"); raw != "" {
+		include, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, errors.New("invalid include_deleted")
+		}
+		filter.IncludeDeleted = include
+	}
+
+	return filter, nil
+}
+
+func parseDateParam(raw string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse(dateLayout, raw)
+	if err != nil {
+		return time.Time{}, false, errors.New("expected RFC 3339 timestamp or YYYY-MM-DD date")
+	}
+	return t, true, nil
+}
+
+// exportUserVotes streams the user's vote history as CSV. Rows are flushed in
+// batches so long histories are never held in memory.
+func (h *Handler) exportUserVotes(c *gin.Context, userID uuid.UUID, filter domain.VoteFilter) {
+	w := csv.NewWriter(c.Writer)
+	started := false
+	start := func() error {
+		started = true
+		c.Header("Content-Type", mimeCSV+"; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="votes.csv"`)
+		c.Status(http.StatusOK)
+		return w.Write(voteCSVHeader)
+	}
+
+	rows := 0
+	err := h.service.ExportUserVotes(c.Request.Context(), userID, filter, func(vote *domain.VoteResponse) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		deletedAt := ""
+		if vote.DeletedAt != nil {
+			deletedAt = vote.DeletedAt.UTC().Format(time.RFC3339)
+		}
+		err := w.Write([]string{
+			h.ids.Encode(vote.ID),
+			h.ids.Encode(vote.PollID),
+			vote.PollTitle,
+			string(vote.PollStatus),
+			h.ids.Encode(vote.OptionID),
+			strconv.Itoa(vote.OptionIndex),
+			vote.OptionText,
+			vote.CreatedAt.UTC().Format(time.RFC3339),
+			deletedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		rows++
+		if rows%exportFlushEvery == 0 {
+			w.Flush()
+			if err := w.Error(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil {
+		w.Flush()
+		err = w.Error()
+	}
+	if err != nil {
+		logging.For(c.Request.Context(), h.logger).Error("failed to export user votes",
+			zap.Error(err),
+			zap.String("userId", userID.String()),
+			zap.Int("rows", rows),
+		)
+		if c.Writer.Written() {
+			// The response is already partly sent; all we can do is stop.
+			c.Abort()
+			return
+		}
+		c.Writer.Header().Del("Content-Disposition")
+		c.Writer.Header().Del("Content-Type")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "failed to export user votes",
+		})
+	}
+}
+
+// pollVoteWriter encodes a poll's exported votes in one output format.
+type pollVoteWriter interface {
+	contentType() string
+	filename() string
+	header() error
+	write(vote *domain.ExportedVote) error
+	flush() error
+}
+
+type csvPollVoteWriter struct {
+	w   *csv.Writer
+	ids publicid.Codec
+}
+
+func (w csvPollVoteWriter) contentType() string { return mimeCSV + "; charset=utf-8" }
+func (w csvPollVoteWriter) filename() string    { return "votes.csv" }
+func (w csvPollVoteWriter) header() error       { return w.w.Write(pollVoteCSVHeader) }
+
+func (w csvPollVoteWriter) write(vote *domain.ExportedVote) error {
+	voterID := ""
+	if vote.VoterID != nil {
+		voterID = w.ids.Encode(*vote.VoterID)
+	}
+	return w.w.Write([]string{
+		w.ids.Encode(vote.VoteID),
+		w.ids.Encode(vote.PollID),
+		voterID,
+		w.ids.Encode(vote.OptionID),
+		strconv.Itoa(vote.OptionIndex),
+		vote.OptionText,
+		vote.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+}
+
+func (w csvPollVoteWriter) flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+type ndjsonPollVoteWriter struct {
+	enc *json.Encoder
+}
+
+func (w ndjsonPollVoteWriter) contentType() string { return mimeNDJSON }
+func (w ndjsonPollVoteWriter) filename() string    { return "votes.ndjson" }
+func (w ndjsonPollVoteWriter) header() error       { return nil }
+func (w ndjsonPollVoteWriter) flush() error        { return nil }
+
+func (w ndjsonPollVoteWriter) write(vote *domain.ExportedVote) error {
+	return w.enc.Encode(vote)
+}
+
+// newPollVoteWriter picks the export format from the format query parameter,
+// falling back to the Accept header and then NDJSON.
+func (h *Handler) newPollVoteWriter(c *gin.Context) (pollVoteWriter, error) {
+	format := c.Query("format")
+	if format == "" && c.NegotiateFormat(mimeNDJSON, mimeCSV) == mimeCSV {
+		format = "csv"
+	}
+	switch format {
+	case "", "ndjson":
+		return ndjsonPollVoteWriter{enc: json.NewEncoder(c.Writer)}, nil
+	case "csv":
+		return csvPollVoteWriter{w: csv.NewWriter(c.Writer), ids: h.ids}, nil
+	default:
+		return nil, errors.New("format must be csv or ndjson")
+	}
+}
+
+// exportPollVotes streams every vote on a poll for auditing. Only admins and
+// the poll's creator may export it.
+func (h *Handler) exportPollVotes(c *gin.Context) {
+	principal, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "user not authenticated",
+		})
+		return
+	}
+
+	pollID, err := h.ids.Decode(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid poll ID",
+		})
+		return
+	}
+
+	w, err := h.newPollVoteWriter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	started := false
+	start := func() error {
+		started = true
+		c.Header("Content-Type", w.contentType())
+		c.Header("Content-Disposition", `attachment; filename="`+w.filename()+`"`)
+		c.Status(http.StatusOK)
+		return w.header()
+	}
+
+	q := domain.VoteExportQuery{ActorID: principal.ID, Admin: h.isAdmin(c)}
+	rows := 0
+	err = h.service.ExportPollVotes(c.Request.Context(), pollID, q, func(vote *domain.ExportedVote) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := w.write(vote); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%exportFlushEvery == 0 {
+			if err := w.flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil {
+		err = w.flush()
+	}
+	if err == nil {
+		return
+	}
+
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Poll not found",
+		})
+	case errors.Is(err, domain.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Only admins and the poll creator can export its votes",
+		})
+	default:
+		logging.For(c.Request.Context(), h.logger).Error("failed to export poll votes",
+			zap.Error(err),
+			zap.String("poll_id", pollID.String()),
+			zap.Int("rows", rows),
+		)
+		if c.Writer.Written() {
+			// The response is already partly sent; all we can do is stop.
+			c.Abort()
+			return
+		}
+		c.Writer.Header().Del("Content-Disposition")
+		c.Writer.Header().Del("Content-Type")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to export poll votes",
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/voteimport"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxVoteImportBytes caps an uploaded import; larger files go through the
+// import-votes command.
+const maxVoteImportBytes = 50 << 20
+
+func WithVoteImporter(i *voteimport.Importer) HandlerOption {
+	return func(h *Handler) {
+		h.importer = i
+	}
+}
+
+// importFormat picks the import format from the format query parameter,
+// falling back to the Content-Type and then NDJSON.
+func importFormat(c *gin.Context) string {
+	if format := c.Query("format"); format != "" {
+		return format
+	}
+	if strings.HasPrefix(c.ContentType(), mimeCSV) {
+		return "csv"
+	}
+	return "ndjson"
+}
+
+func (h *Handler) importVotes(c *gin.Context) {
+	if h.importer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":  "error",
+			"message": "Vote import is not available",
+		})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxVoteImportBytes)
+	src, err := voteimport.NewSource(c.Request.Body, importFormat(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	principal, _ := auth.CurrentUser(c)
+	result, err := h.importer.Import(c.Request.Context(), src)
+	if err != nil {
+		// Batches stored before the failure stay, so the partial result is
+		// returned too.
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"status":  "error",
+				"message": "Import is too large; use the import-votes command",
+				"result":  result,
+			})
+			return
+		}
+		logging.For(c.Request.Context(), h.logger).Error("failed to import votes",
+			zap.Error(err),
+			zap.String("admin_id", principal.ID.String()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to import votes",
+			"result":  result,
+		})
+		return
+	}
+
+	logging.For(c.Request.Context(), h.logger).Info("votes imported",
+		zap.String("admin_id", principal.ID.String()),
+		zap.Int("imported", result.Imported),
+		zap.Int("duplicates", result.Duplicates),
+		zap.Int("rejected", result.Rejected),
+	)
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"result": result,
+	})
+}
@@ -1,7 +1,9 @@
 package auth
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/behzadon/vote/internal/domain"
@@ -10,39 +12,68 @@ import (
 )
 
 var (
-	ErrInvalidToken = errors.New("invalid token")
-	ErrExpiredToken = errors.New("token has expired")
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrExpiredToken     = errors.New("token has expired")
+	ErrMalformedToken   = fmt.Errorf("%w: malformed", ErrInvalidToken)
+	ErrInvalidSignature = fmt.Errorf("%w: signature is invalid", ErrInvalidToken)
 )
 
 type Claims struct {
 	UserID   uuid.UUID `json:"userId"`
 	Username string    `json:"username"`
+	Role     Role      `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Principal returns the user the claims were issued to. Tokens issued
+// before roles existed carry none and are treated as plain users.
+func (c *Claims) Principal() Principal {
+	role := c.Role
+	if role == "" {
+		role = RoleUser
+	}
+	return Principal{
+		ID:         c.UserID,
+		Username:   c.Username,
+		Role:       role,
+		AuthMethod: AuthMethodJWT,
+	}
+}
+
 type JWTManager struct {
 	secretKey     []byte
 	tokenDuration time.Duration
+
+	refreshStore    RefreshTokenStore
+	refreshDuration time.Duration
 }
 
 type JWTManagerInterface interface {
 	GenerateToken(user *domain.User) (string, error)
 	ValidateToken(token string) (*Claims, error)
+	IssueRefreshToken(ctx context.Context, userID uuid.UUID) (string, error)
+	Refresh(ctx context.Context, refreshToken string) (*TokenPair, error)
+	RevokeRefreshToken(ctx context.Context, refreshToken string) error
 }
 
 var _ JWTManagerInterface = (*JWTManager)(nil)
 
-func NewJWTManager(secretKey string, tokenDuration time.Duration) *JWTManager {
-	return &JWTManager{
+func NewJWTManager(secretKey string, tokenDuration time.Duration, opts ...ManagerOption) *JWTManager {
+	m := &JWTManager{
 		secretKey:     []byte(secretKey),
 		tokenDuration: tokenDuration,
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 func (m *JWTManager) GenerateToken(user *domain.User) (string, error) {
 	claims := &Claims{
 		UserID:   user.ID,
 		Username: user.Username,
+		Role:     user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.tokenDuration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -65,8 +96,13 @@ func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 	})
 
 	if err != nil {
-		if errors.Is(err, jwt.ErrTokenExpired) {
+		switch {
+		case errors.Is(err, jwt.ErrTokenExpired):
 			return nil, ErrExpiredToken
+		case errors.Is(err, jwt.ErrTokenMalformed):
+			return nil, ErrMalformedToken
+		case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+			return nil, ErrInvalidSignature
 		}
 		return nil, ErrInvalidToken
 	}
@@ -1,78 +1,156 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/metrics"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-func AuthMiddleware(jwtManager *JWTManager) gin.HandlerFunc {
+const (
+	FailureMissing          = "missing"
+	FailureMalformed        = "malformed"
+	FailureExpired          = "expired"
+	FailureInvalidSignature = "invalid_signature"
+	FailureInvalid          = "invalid"
+)
+
+func FailureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrExpiredToken):
+		return FailureExpired
+	case errors.Is(err, ErrMalformedToken):
+		return FailureMalformed
+	case errors.Is(err, ErrInvalidSignature):
+		return FailureInvalidSignature
+	}
+	return FailureInvalid
+}
+
+type MiddlewareOption func(*middlewareOptions)
+
+type middlewareOptions struct {
+	alert *failureAlert
+}
+
+// WithFailureAlert logs a warning when more than threshold requests fail
+// authentication within window. A non-positive threshold disables the alert.
+func WithFailureAlert(threshold int, window time.Duration, logger *zap.Logger) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		if threshold <= 0 || window <= 0 {
+			return
+		}
+		o.alert = &failureAlert{
+			threshold: threshold,
+			window:    window,
+			logger:    logger,
+			now:       time.Now,
+		}
+	}
+}
+
+type failureAlert struct {
+	mu          sync.Mutex
+	threshold   int
+	window      time.Duration
+	logger      *zap.Logger
+	now         func() time.Time
+	windowStart time.Time
+	count       int
+	fired       bool
+}
+
+func (a *failureAlert) record(reason string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	if now.Sub(a.windowStart) >= a.window {
+		a.windowStart = now
+		a.count = 0
+		a.fired = false
+	}
+	a.count++
+
+	if a.count > a.threshold && !a.fired {
+		a.fired = true
+		metrics.AuthFailureSpikes.Inc()
+		a.logger.Warn("auth middleware: authentication failure spike detected",
+			zap.Int("failures", a.count),
+			zap.Duration("window", a.window),
+			zap.String("last_reason", reason),
+		)
+	}
+}
+
+// OptionalAuthMiddleware sets the current user when the request carries a
+// valid bearer token and lets anonymous requests through unchanged. A bad
+// token is treated as no token rather than rejected.
+func OptionalAuthMiddleware(jwtManager *JWTManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		logger := zap.L().With(
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			if claims, err := jwtManager.ValidateToken(parts[1]); err == nil {
+				SetCurrentUser(c, claims.Principal())
+			}
+		}
+		c.Next()
+	}
+}
+
+func AuthMiddleware(jwtManager *JWTManager, opts ...MiddlewareOption) gin.HandlerFunc {
+	var options middlewareOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	reject := func(c *gin.Context, status int, reason, message string) {
+		metrics.AuthFailures.WithLabelValues(reason).Inc()
+		if options.alert != nil {
+			options.alert.record(reason)
+		}
+		zap.L().Debug("auth middleware: rejected request",
+			zap.String("reason", reason),
 			zap.String("path", c.Request.URL.Path),
 			zap.String("method", c.Request.Method),
 			zap.String("ip", c.ClientIP()),
-			zap.Strings("headers", c.Request.Header["Authorization"]),
 		)
+		c.JSON(status, domain.ErrorResponse{
+			Error: message,
+		})
+		c.Abort()
+	}
 
-		logger.Info("auth middleware: processing request")
-
+	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			logger.Info("auth middleware: missing authorization header")
-			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
-				Error: "authorization header is required",
-			})
-			c.Abort()
+			reject(c, http.StatusUnauthorized, FailureMissing, "authorization header is required")
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			logger.Info("auth middleware: invalid authorization header format",
-				zap.String("header", authHeader),
-				zap.Int("parts", len(parts)),
-				zap.Strings("parts", parts),
-			)
-			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
-				Error: "invalid authorization header format",
-			})
-			c.Abort()
+			reject(c, http.StatusUnauthorized, FailureMalformed, "invalid authorization header format")
 			return
 		}
 
-		token := parts[1]
-		logger.Info("auth middleware: validating token",
-			zap.String("token_prefix", token[:10]+"..."),
-		)
-
-		claims, err := jwtManager.ValidateToken(token)
+		claims, err := jwtManager.ValidateToken(parts[1])
 		if err != nil {
-			logger.Info("auth middleware: token validation failed",
-				zap.Error(err),
-				zap.String("token_prefix", token[:10]+"..."),
-			)
 			status := http.StatusUnauthorized
-			if err == ErrExpiredToken {
+			if errors.Is(err, ErrExpiredToken) {
 				status = http.StatusForbidden
 			}
-			c.JSON(status, domain.ErrorResponse{
-				Error: err.Error(),
-			})
-			c.Abort()
+			reject(c, status, FailureReason(err), err.Error())
 			return
 		}
 
-		logger.Info("auth middleware: token validated successfully",
-			zap.String("user_id", claims.UserID.String()),
-			zap.String("username", claims.Username),
-		)
-
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
+		SetCurrentUser(c, claims.Principal())
 		c.Next()
 	}
 }
@@ -1,7 +1,10 @@
 package auth
 
 import (
+	"context"
+
 	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -21,3 +24,21 @@ func (m *MockJWTManager) ValidateToken(token string) (*Claims, error) {
 	}
 	return args.Get(0).(*Claims), args.Error(1)
 }
+
+func (m *MockJWTManager) IssueRefreshToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockJWTManager) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	args := m.Called(ctx, refreshToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*TokenPair), args.Error(1)
+}
+
+func (m *MockJWTManager) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	args := m.Called(ctx, refreshToken)
+	return args.Error(0)
+}
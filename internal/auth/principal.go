@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Role = domain.UserRole
+
+const (
+	RoleUser      = domain.RoleUser
+	RoleModerator = domain.RoleModerator
+	RoleAdmin     = domain.RoleAdmin
+)
+
+type AuthMethod string
+
+const AuthMethodJWT AuthMethod = "jwt"
+
+// Principal is the authenticated user a request is made on behalf of.
+type Principal struct {
+	ID         uuid.UUID
+	Username   string
+	Role       Role
+	AuthMethod AuthMethod
+}
+
+type principalKey struct{}
+
+// principalGinKey stores the principal on the gin context, whose Value
+// does not reach the request context unless ContextWithFallback is set.
+const principalGinKey = "auth.principal"
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// SetCurrentUser makes p the current user of the request, for handlers
+// through the gin context and for everything below them through the
+// request context.
+func SetCurrentUser(c *gin.Context, p Principal) {
+	c.Set(principalGinKey, p)
+	if c.Request != nil {
+		c.Request = c.Request.WithContext(WithPrincipal(c.Request.Context(), p))
+	}
+}
+
+// CurrentUser returns the authenticated user of ctx, which may be a gin
+// context or a request context. It reports false for anonymous requests.
+func CurrentUser(ctx context.Context) (Principal, bool) {
+	if c, ok := ctx.(*gin.Context); ok {
+		if v, exists := c.Get(principalGinKey); exists {
+			p, ok := v.(Principal)
+			return p, ok
+		}
+		if c.Request == nil {
+			return Principal{}, false
+		}
+		ctx = c.Request.Context()
+	}
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRefreshDisabled    = errors.New("refresh tokens are not enabled")
+	ErrRefreshTokenReused = fmt.Errorf("%w: refresh token was already used", ErrInvalidToken)
+)
+
+type RefreshTokenStore interface {
+	CreateRefreshToken(ctx context.Context, token *domain.RefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (*domain.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, oldID uuid.UUID, next *domain.RefreshToken) error
+	RevokeRefreshToken(ctx context.Context, tokenHash string, at time.Time) error
+	RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID, at time.Time) error
+	GetUserByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+}
+
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+type ManagerOption func(*JWTManager)
+
+// WithRefreshTokens issues refresh tokens valid for ttl alongside access
+// tokens, keeping their hashes in store.
+func WithRefreshTokens(store RefreshTokenStore, ttl time.Duration) ManagerOption {
+	return func(m *JWTManager) {
+		m.refreshStore = store
+		m.refreshDuration = ttl
+	}
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (m *JWTManager) newRefreshToken(userID uuid.UUID) (string, *domain.RefreshToken, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("generate refresh token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	now := time.Now().UTC()
+	return token, &domain.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		TokenHash: hashRefreshToken(token),
+		ExpiresAt: now.Add(m.refreshDuration),
+		CreatedAt: now,
+	}, nil
+}
+
+// IssueRefreshToken starts a refresh token chain for the user. It returns
+// an empty token when refresh tokens are not enabled.
+func (m *JWTManager) IssueRefreshToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	if m.refreshStore == nil {
+		return "", nil
+	}
+	token, record, err := m.newRefreshToken(userID)
+	if err != nil {
+		return "", err
+	}
+	if err := m.refreshStore.CreateRefreshToken(ctx, record); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh
+// token; the presented one cannot be used again. Presenting a token that was
+// already used revokes all of the user's refresh tokens, as it means the
+// token leaked to someone else.
+func (m *JWTManager) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	if m.refreshStore == nil {
+		return nil, ErrRefreshDisabled
+	}
+
+	current, err := m.refreshStore.GetRefreshToken(ctx, hashRefreshToken(refreshToken))
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if current.RevokedAt != nil {
+		return nil, m.revokeReused(ctx, current.UserID, now)
+	}
+	if !now.Before(current.ExpiresAt) {
+		return nil, ErrExpiredToken
+	}
+
+	user, err := m.refreshStore.GetUserByID(ctx, current.UserID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token, next, err := m.newRefreshToken(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	err = m.refreshStore.RotateRefreshToken(ctx, current.ID, next)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, m.revokeReused(ctx, current.UserID, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := m.GenerateToken(user)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{AccessToken: access, RefreshToken: token}, nil
+}
+
+func (m *JWTManager) revokeReused(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	if err := m.refreshStore.RevokeUserRefreshTokens(ctx, userID, at); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// RevokeRefreshToken makes a refresh token unusable. Unknown and already
+// revoked tokens are ignored.
+func (m *JWTManager) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	if m.refreshStore == nil {
+		return nil
+	}
+	return m.refreshStore.RevokeRefreshToken(ctx, hashRefreshToken(refreshToken), time.Now().UTC())
+}
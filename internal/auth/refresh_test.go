@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryRefreshStore struct {
+	tokens map[string]*domain.RefreshToken
+	users  map[uuid.UUID]*domain.User
+}
+
+func newMemoryRefreshStore(users ...*domain.User) *memoryRefreshStore {
+	s := &memoryRefreshStore{
+		tokens: make(map[string]*domain.RefreshToken),
+		users:  make(map[uuid.UUID]*domain.User),
+	}
+	for _, user := range users {
+		s.users[user.ID] = user
+	}
+	return s
+}
+
+func (s *memoryRefreshStore) CreateRefreshToken(_ context.Context, token *domain.RefreshToken) error {
+	s.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (s *memoryRefreshStore) GetRefreshToken(_ context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	token, ok := s.tokens[tokenHash]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	copied := *token
+	return &copied, nil
+}
+
+func (s *memoryRefreshStore) RotateRefreshToken(_ context.Context, oldID uuid.UUID, next *domain.RefreshToken) error {
+	for _, token := range s.tokens {
+		if token.ID == oldID && token.RevokedAt == nil {
+			token.RevokedAt = &next.CreatedAt
+			s.tokens[next.TokenHash] = next
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (s *memoryRefreshStore) RevokeRefreshToken(_ context.Context, tokenHash string, at time.Time) error {
+	if token, ok := s.tokens[tokenHash]; ok && token.RevokedAt == nil {
+		token.RevokedAt = &at
+	}
+	return nil
+}
+
+func (s *memoryRefreshStore) RevokeUserRefreshTokens(_ context.Context, userID uuid.UUID, at time.Time) error {
+	for _, token := range s.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func (s *memoryRefreshStore) GetUserByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return user, nil
+}
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New(), Username: "alice"}
+	store := newMemoryRefreshStore(user)
+	manager := NewJWTManager("secret", 15*time.Minute, WithRefreshTokens(store, time.Hour))
+
+	first, err := manager.IssueRefreshToken(ctx, user.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, first)
+	assert.NotContains(t, store.tokens, first, "only the hash is stored")
+
+	pair, err := manager.Refresh(ctx, first)
+	require.NoError(t, err)
+	claims, err := manager.ValidateToken(pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+	assert.NotEqual(t, first, pair.RefreshToken)
+
+	// Replaying the first token revokes the one issued in its place too.
+	_, err = manager.Refresh(ctx, first)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	_, err = manager.Refresh(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+}
+
+func TestRefreshRejectsExpiredAndRevokedTokens(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New()}
+	store := newMemoryRefreshStore(user)
+	manager := NewJWTManager("secret", 15*time.Minute, WithRefreshTokens(store, time.Hour))
+
+	_, err := manager.Refresh(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	token, err := manager.IssueRefreshToken(ctx, user.ID)
+	require.NoError(t, err)
+	store.tokens[hashRefreshToken(token)].ExpiresAt = time.Now().Add(-time.Minute)
+	_, err = manager.Refresh(ctx, token)
+	assert.ErrorIs(t, err, ErrExpiredToken)
+
+	token, err = manager.IssueRefreshToken(ctx, user.ID)
+	require.NoError(t, err)
+	require.NoError(t, manager.RevokeRefreshToken(ctx, token))
+	_, err = manager.Refresh(ctx, token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestRefreshDisabled(t *testing.T) {
+	manager := NewJWTManager("secret", time.Hour)
+
+	token, err := manager.IssueRefreshToken(context.Background(), uuid.New())
+	require.NoError(t, err)
+	assert.Empty(t, token)
+
+	_, err = manager.Refresh(context.Background(), "token")
+	assert.ErrorIs(t, err, ErrRefreshDisabled)
+}
@@ -1,24 +1,60 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server    ServerConfig    `mapstructure:"server"`
-	Postgres  PostgresConfig  `mapstructure:"postgres"`
-	Redis     RedisConfig     `mapstructure:"redis"`
-	RabbitMQ  RabbitMQConfig  `mapstructure:"rabbitmq"`
-	Migration MigrationConfig `mapstructure:"migration"`
-	JWT       JWTConfig       `mapstructure:"jwt"`
+	Server     ServerConfig     `mapstructure:"server"`
+	Postgres   PostgresConfig   `mapstructure:"postgres"`
+	Redis      RedisConfig      `mapstructure:"redis"`
+	RabbitMQ   RabbitMQConfig   `mapstructure:"rabbitmq"`
+	Events     EventsConfig     `mapstructure:"events"`
+	Startup    StartupConfig    `mapstructure:"startup"`
+	Health     HealthConfig     `mapstructure:"health"`
+	Migration  MigrationConfig  `mapstructure:"migration"`
+	JWT        JWTConfig        `mapstructure:"jwt"`
+	Scheduler  SchedulerConfig  `mapstructure:"scheduler"`
+	Quota      QuotaConfig      `mapstructure:"quota"`
+	Election   ElectionConfig   `mapstructure:"election"`
+	Receipts   ReceiptsConfig   `mapstructure:"receipts"`
+	Validation ValidationConfig `mapstructure:"validation"`
+	Moderation ModerationConfig `mapstructure:"moderation"`
+	Cache      CacheConfig      `mapstructure:"cache"`
+	Search     SearchConfig     `mapstructure:"search"`
+	Storage    StorageConfig    `mapstructure:"storage"`
+	GeoIP      GeoIPConfig      `mapstructure:"geoip"`
+	Consent    ConsentConfig    `mapstructure:"consent"`
+	Results    ResultsConfig    `mapstructure:"results"`
+	PublicIDs  PublicIDsConfig  `mapstructure:"public_ids"`
+	Metrics    MetricsConfig    `mapstructure:"metrics"`
+	GRPC       GRPCConfig       `mapstructure:"grpc"`
+
+	PollWebhooks PollWebhooksConfig `mapstructure:"poll_webhooks"`
+
+	Notification      NotificationConfig      `mapstructure:"notification"`
+	CreationLimits    CreationLimitsConfig    `mapstructure:"creation_limits"`
+	EmailVerification EmailVerificationConfig `mapstructure:"email_verification"`
+	PreviewCards      PreviewCardsConfig      `mapstructure:"preview_cards"`
+	RateLimit         RateLimitConfig         `mapstructure:"rate_limit"`
+	PasswordPolicy    PasswordPolicyConfig    `mapstructure:"password_policy"`
 }
 
 type ServerConfig struct {
-	Port int    `mapstructure:"port"`
-	Env  string `mapstructure:"env"`
+	Port            int           `mapstructure:"port"`
+	Env             string        `mapstructure:"env"`
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	PublicURL       string        `mapstructure:"public_url"`
 }
 
 type PostgresConfig struct {
@@ -45,28 +81,558 @@ type RabbitMQConfig struct {
 	VHost    string `mapstructure:"vhost"`
 }
 
+// EventsConfig sets how events reach the broker. In "async" mode they go
+// through a queue of QueueSize events drained by Workers goroutines, and
+// events the queue can't take are kept in the outbox; "sync" publishes
+// within the request.
+type EventsConfig struct {
+	PublishMode string            `mapstructure:"publish_mode"`
+	QueueSize   int               `mapstructure:"queue_size"`
+	Workers     int               `mapstructure:"workers"`
+	Relay       OutboxRelayConfig `mapstructure:"relay"`
+}
+
+// OutboxRelayConfig sets the worker that publishes the outbox. Every
+// Interval it claims up to Batch events for Lease; events it doesn't
+// delete within the lease can be claimed again, by it or another instance.
+type OutboxRelayConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Interval time.Duration `mapstructure:"interval"`
+	Batch    int           `mapstructure:"batch"`
+	Lease    time.Duration `mapstructure:"lease"`
+}
+
+// StartupConfig sets how long commands wait for Postgres, Redis and RabbitMQ
+// to come up. Attempts are retried after RetryInitialBackoff, doubling up to
+// RetryMaxBackoff, for up to RetryMaxWait per dependency; a zero
+// RetryMaxWait fails on the first error.
+type StartupConfig struct {
+	RetryMaxWait        time.Duration `mapstructure:"retry_max_wait"`
+	RetryInitialBackoff time.Duration `mapstructure:"retry_initial_backoff"`
+	RetryMaxBackoff     time.Duration `mapstructure:"retry_max_backoff"`
+}
+
+// HealthConfig sets how the server watches Redis and RabbitMQ once running.
+// Each is checked every CheckInterval; a dropped connection is retried after
+// ReconnectInitialBackoff, doubling up to ReconnectMaxBackoff, for as long
+// as it stays down.
+type HealthConfig struct {
+	CheckInterval           time.Duration `mapstructure:"check_interval"`
+	CheckTimeout            time.Duration `mapstructure:"check_timeout"`
+	ReconnectInitialBackoff time.Duration `mapstructure:"reconnect_initial_backoff"`
+	ReconnectMaxBackoff     time.Duration `mapstructure:"reconnect_max_backoff"`
+}
+
 type MigrationConfig struct {
 	AutoMigrate bool `mapstructure:"auto_migrate"`
 }
 
 type JWTConfig struct {
-	SecretKey     string        `mapstructure:"secret_key"`
-	TokenDuration time.Duration `mapstructure:"token_duration"`
+	SecretKey             string        `mapstructure:"secret_key"`
+	TokenDuration         time.Duration `mapstructure:"token_duration"`
+	RefreshTokenDuration  time.Duration `mapstructure:"refresh_token_duration"`
+	FailureAlertThreshold int           `mapstructure:"failure_alert_threshold"`
+	FailureAlertWindow    time.Duration `mapstructure:"failure_alert_window"`
+}
+
+type SchedulerConfig struct {
+	Enabled   bool                 `mapstructure:"enabled"`
+	LockTTL   time.Duration        `mapstructure:"lock_ttl"`
+	Retention time.Duration        `mapstructure:"retention"`
+	Timezone  string               `mapstructure:"timezone"`
+	Jobs      map[string]JobConfig `mapstructure:"jobs"`
+}
+
+// JobConfig schedules a job either every Interval or by a cron expression,
+// which takes precedence and is read in Timezone (the scheduler's timezone
+// when empty).
+type JobConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Interval time.Duration `mapstructure:"interval"`
+	Cron     string        `mapstructure:"cron"`
+	Timezone string        `mapstructure:"timezone"`
+}
+
+func (c SchedulerConfig) Job(name string) (JobConfig, bool) {
+	job, ok := c.Jobs[name]
+	return job, ok && job.Enabled && (job.Interval > 0 || job.Cron != "")
+}
+
+// Location returns the timezone the job's cron expression is read in.
+func (c SchedulerConfig) Location(job JobConfig) (*time.Location, error) {
+	name := job.Timezone
+	if name == "" {
+		name = c.Timezone
+	}
+	return time.LoadLocation(name)
+}
+
+type QuotaConfig struct {
+	Enabled bool                   `mapstructure:"enabled"`
+	Limits  map[string]QuotaLimits `mapstructure:"limits"`
+}
+
+type QuotaLimits struct {
+	Daily   int `mapstructure:"daily"`
+	Monthly int `mapstructure:"monthly"`
+}
+
+// CreationLimitsConfig caps how many polls can be created a day: per user
+// for personal polls and per organization for organization polls, with a
+// higher limit for the listed verified organizations. Zero means unlimited.
+type CreationLimitsConfig struct {
+	Enabled                   bool     `mapstructure:"enabled"`
+	UserDaily                 int      `mapstructure:"user_daily"`
+	OrganizationDaily         int      `mapstructure:"organization_daily"`
+	VerifiedOrganizationDaily int      `mapstructure:"verified_organization_daily"`
+	VerifiedOrganizations     []string `mapstructure:"verified_organizations"`
+}
+
+type ElectionConfig struct {
+	SigningKey string `mapstructure:"signing_key"`
+}
+
+// ReceiptsConfig holds the key vote receipts are signed with. Receipts are
+// left unsigned when it is empty.
+type ReceiptsConfig struct {
+	SigningKey string `mapstructure:"signing_key"`
+}
+
+type ValidationConfig struct {
+	MinOptions      int                   `mapstructure:"min_options"`
+	MaxOptions      int                   `mapstructure:"max_options"`
+	MaxTitleLength  int                   `mapstructure:"max_title_length"`
+	MaxOptionLength int                   `mapstructure:"max_option_length"`
+	MaxTags         int                   `mapstructure:"max_tags"`
+	MaxTagLength    int                   `mapstructure:"max_tag_length"`
+	ProfanityFilter ProfanityFilterConfig `mapstructure:"profanity_filter"`
+
+	MaxAltTextLength int `mapstructure:"max_alt_text_length"`
+}
+
+// ModerationConfig lists the IDs of users allowed to use the moderation and
+// admin APIs.
+type ModerationConfig struct {
+	Moderators []string      `mapstructure:"moderators"`
+	Admins     []string      `mapstructure:"admins"`
+	Scoring    ScoringConfig `mapstructure:"scoring"`
+}
+
+// ScoringConfig selects how poll content is scored for spam and abuse:
+// "heuristic", "http" to call an external classifier, or empty to disable
+// scoring. Content scoring at or above Threshold is queued for moderators.
+type ScoringConfig struct {
+	Backend   string        `mapstructure:"backend"`
+	Threshold float64       `mapstructure:"threshold"`
+	WordList  string        `mapstructure:"word_list"`
+	URL       string        `mapstructure:"url"`
+	Token     string        `mapstructure:"token"`
+	Timeout   time.Duration `mapstructure:"timeout"`
+}
+
+// NotificationConfig holds the vote counts at which poll creators are
+// notified and the channels notifications are sent on.
+type NotificationConfig struct {
+	VoteMilestones []int                   `mapstructure:"vote_milestones"`
+	Retry          NotificationRetryConfig `mapstructure:"retry"`
+	Email          EmailChannelConfig      `mapstructure:"email"`
+	Webhook        WebhookChannelConfig    `mapstructure:"webhook"`
+	Push           PushChannelConfig       `mapstructure:"push"`
+}
+
+// NotificationRetryConfig sets how often a channel is tried before a
+// notification is given up on, waiting from InitialBackoff, doubling, up to
+// MaxBackoff between attempts.
+type NotificationRetryConfig struct {
+	Attempts       int           `mapstructure:"attempts"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+}
+
+// EmailChannelConfig sends notifications over SMTP to users' verified
+// addresses.
+type EmailChannelConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Host     string        `mapstructure:"host"`
+	Port     int           `mapstructure:"port"`
+	Username string        `mapstructure:"username"`
+	Password string        `mapstructure:"password"`
+	From     string        `mapstructure:"from"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+}
+
+// WebhookChannelConfig posts notifications to URL, signed with Secret when
+// it is set.
+type WebhookChannelConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	URL     string        `mapstructure:"url"`
+	Secret  string        `mapstructure:"secret"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// PushChannelConfig sends notifications through Firebase Cloud Messaging
+// with the service account key in CredentialsFile.
+type PushChannelConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	CredentialsFile string        `mapstructure:"credentials_file"`
+	Timeout         time.Duration `mapstructure:"timeout"`
+}
+
+type CacheConfig struct {
+	Warmup CacheWarmupConfig `mapstructure:"warmup"`
+	Local  LocalCacheConfig  `mapstructure:"local"`
+	Feed   FeedCacheConfig   `mapstructure:"feed"`
+	Stats  StatsCacheConfig  `mapstructure:"stats"`
+}
+
+// StatsCacheConfig sets how long before cached poll stats expire they are
+// recomputed in the background, while readers keep getting the cached copy.
+// Zero disables the refresh.
+type StatsCacheConfig struct {
+	RefreshWindow time.Duration `mapstructure:"refresh_window"`
+}
+
+// FeedCacheConfig controls caching each user's feed pages in Redis. Pages
+// are dropped when the user votes or skips and otherwise live for TTL.
+type FeedCacheConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	TTL     time.Duration `mapstructure:"ttl"`
+}
+
+// LocalCacheConfig sizes the in-process cache kept in front of Redis for
+// poll and stats reads.
+type LocalCacheConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Size    int           `mapstructure:"size"`
+	TTL     time.Duration `mapstructure:"ttl"`
+}
+
+// CacheWarmupConfig controls preloading recently active polls into Redis
+// before the server starts accepting requests.
+type CacheWarmupConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	Polls       int           `mapstructure:"polls"`
+	Window      time.Duration `mapstructure:"window"`
+	Concurrency int           `mapstructure:"concurrency"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+}
+
+// SearchConfig points poll search at an Elasticsearch or OpenSearch
+// cluster. When disabled, search runs on Postgres full-text search.
+type SearchConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	URL      string        `mapstructure:"url"`
+	Index    string        `mapstructure:"index"`
+	Username string        `mapstructure:"username"`
+	Password string        `mapstructure:"password"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+}
+
+// StorageConfig selects where uploaded media is kept. Driver is one of
+// local, s3 or gcs; leaving it empty disables uploads.
+type StorageConfig struct {
+	Driver  string             `mapstructure:"driver"`
+	URLTTL  time.Duration      `mapstructure:"url_ttl"`
+	GCGrace time.Duration      `mapstructure:"gc_grace"`
+	Local   LocalStorageConfig `mapstructure:"local"`
+	S3      S3StorageConfig    `mapstructure:"s3"`
+	GCS     GCSStorageConfig   `mapstructure:"gcs"`
+}
+
+// LocalStorageConfig stores media on disk and serves it from the API
+// server under BaseURL, using Secret to sign URLs.
+type LocalStorageConfig struct {
+	Dir     string `mapstructure:"dir"`
+	BaseURL string `mapstructure:"base_url"`
+	Secret  string `mapstructure:"secret"`
+}
+
+type S3StorageConfig struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	PathStyle bool   `mapstructure:"path_style"`
+}
+
+// GCSStorageConfig uses Cloud Storage HMAC keys.
+type GCSStorageConfig struct {
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+}
+
+// GeoIPConfig points at a MaxMind DB file, such as GeoLite2-Country or
+// GeoLite2-City, used to locate voters.
+type GeoIPConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Database string `mapstructure:"database"`
+}
+
+// ConsentConfig holds the current terms of service and privacy policy
+// versions. Users must accept a version before using the API again; an
+// empty version is not enforced.
+type ConsentConfig struct {
+	TermsVersion   string `mapstructure:"terms_version"`
+	PrivacyVersion string `mapstructure:"privacy_version"`
+}
+
+// EmailVerificationConfig sets how long verification links stay valid and
+// whether users must verify their email address before voting.
+type EmailVerificationConfig struct {
+	TokenTTL       time.Duration `mapstructure:"token_ttl"`
+	RequiredToVote bool          `mapstructure:"required_to_vote"`
+}
+
+// RateLimitConfig sets the per-user request limits on API routes: Rate per
+// window, usually a minute, and Burst per window of about a second.
+type RateLimitConfig struct {
+	Rate  RateLimitPolicyConfig `mapstructure:"rate"`
+	Burst RateLimitPolicyConfig `mapstructure:"burst"`
+}
+
+// RateLimitPolicyConfig is one limiter's policy. Shadow logs and counts
+// requests over the limit without rejecting them, for trying out a new limit.
+type RateLimitPolicyConfig struct {
+	Limit  int           `mapstructure:"limit"`
+	Window time.Duration `mapstructure:"window"`
+	Shadow bool          `mapstructure:"shadow"`
+}
+
+// PasswordPolicyConfig sets the rules new passwords must follow at
+// registration and when they are changed. A zero MaxLength means no maximum.
+type PasswordPolicyConfig struct {
+	MinLength     int               `mapstructure:"min_length"`
+	MaxLength     int               `mapstructure:"max_length"`
+	RequireLower  bool              `mapstructure:"require_lower"`
+	RequireUpper  bool              `mapstructure:"require_upper"`
+	RequireDigit  bool              `mapstructure:"require_digit"`
+	RequireSymbol bool              `mapstructure:"require_symbol"`
+	BreachCheck   BreachCheckConfig `mapstructure:"breach_check"`
+}
+
+// BreachCheckConfig turns on rejecting passwords found in known breaches,
+// looked up in a Pwned Passwords compatible range API at URL.
+type BreachCheckConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	URL     string        `mapstructure:"url"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// PreviewCardsConfig sets how far, as a fraction of the votes, an option's
+// share has to move before a poll's stored preview card is redrawn.
+type PreviewCardsConfig struct {
+	RefreshShare float64 `mapstructure:"refresh_share"`
+}
+
+// ResultsConfig sets how a tied lead is decided when a closed poll's results
+// are snapshotted: "reported", "earliest_lead" or "random". The random draw
+// is seeded with TieBreakSeed so it can be reproduced. Closed polls' results
+// are certified with SigningKey; no certificates are issued when it is empty.
+type ResultsConfig struct {
+	TieBreak     string `mapstructure:"tie_break"`
+	TieBreakSeed string `mapstructure:"tie_break_seed"`
+	SigningKey   string `mapstructure:"signing_key"`
+}
+
+// PollWebhooksConfig sets how results are posted to the webhooks creators
+// attach to polls: each delivery may take Timeout, and a failed one is retried
+// after InitialBackoff, doubling up to MaxBackoff, until MaxAttempts have
+// failed.
+type PollWebhooksConfig struct {
+	Timeout        time.Duration `mapstructure:"timeout"`
+	MaxAttempts    int           `mapstructure:"max_attempts"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+}
+
+// MetricsConfig sets where the final metrics are pushed on shutdown, so the
+// drain of an instance that is gone before the next scrape is still recorded.
+// Nothing is pushed when PushURL is empty. The consumers, which serve no API,
+// expose /metrics on Port for scraping; zero leaves them unscraped.
+type MetricsConfig struct {
+	PushURL string `mapstructure:"push_url"`
+	PushJob string `mapstructure:"push_job"`
+	Port    int    `mapstructure:"port"`
+}
+
+// GRPCConfig sets the port of the gRPC API the server runs alongside the HTTP
+// API, for other services in the platform; zero turns it off.
+type GRPCConfig struct {
+	Port int `mapstructure:"port"`
+}
+
+// PublicIDsConfig sets how IDs appear in the API: "uuid" shows them as
+// stored, "sqid" as short strings encrypted with Secret. With sqid, stored
+// UUIDs are only accepted in paths and request bodies when AcceptUUIDs is
+// set, for clients holding links from before the encoding was switched on.
+type PublicIDsConfig struct {
+	Encoding    string `mapstructure:"encoding"`
+	Secret      string `mapstructure:"secret"`
+	AcceptUUIDs bool   `mapstructure:"accept_uuids"`
+}
+
+type ProfanityFilterConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	WordList string `mapstructure:"word_list"`
 }
 
 func Load(configFile string) (*Config, error) {
+	v, _, err := read(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+
+	if err := validateConfig(&cfg); err != nil {
+		return nil, fmt.Errorf("validate config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// read loads the defaults, the config file, its overlay for the environment
+// and the environment variables, each overriding the ones before. It also
+// returns the files that were read.
+func read(configFile string) (*viper.Viper, []string, error) {
 	v := viper.New()
 
 	v.SetDefault("server.port", 8080)
 	v.SetDefault("server.env", "development")
+	v.SetDefault("server.shutdown_timeout", 10*time.Second)
 	v.SetDefault("postgres.port", 5432)
 	v.SetDefault("postgres.sslmode", "disable")
 	v.SetDefault("redis.port", 6379)
 	v.SetDefault("redis.db", 0)
 	v.SetDefault("rabbitmq.port", 5672)
 	v.SetDefault("rabbitmq.vhost", "/")
+	v.SetDefault("events.publish_mode", "async")
+	v.SetDefault("events.queue_size", 1000)
+	v.SetDefault("events.workers", 4)
+	v.SetDefault("events.relay.enabled", true)
+	v.SetDefault("events.relay.interval", time.Second)
+	v.SetDefault("events.relay.batch", 100)
+	v.SetDefault("events.relay.lease", 30*time.Second)
+	v.SetDefault("startup.retry_max_wait", time.Minute)
+	v.SetDefault("startup.retry_initial_backoff", 500*time.Millisecond)
+	v.SetDefault("startup.retry_max_backoff", 10*time.Second)
+	v.SetDefault("health.check_interval", 5*time.Second)
+	v.SetDefault("health.check_timeout", 2*time.Second)
+	v.SetDefault("health.reconnect_initial_backoff", time.Second)
+	v.SetDefault("health.reconnect_max_backoff", 30*time.Second)
 	v.SetDefault("migration.auto_migrate", false)
-	v.SetDefault("jwt.token_duration", 24*time.Hour)
+	v.SetDefault("jwt.token_duration", 15*time.Minute)
+	v.SetDefault("jwt.refresh_token_duration", 30*24*time.Hour)
+	v.SetDefault("jwt.failure_alert_threshold", 0)
+	v.SetDefault("jwt.failure_alert_window", time.Minute)
+	v.SetDefault("scheduler.enabled", true)
+	v.SetDefault("scheduler.lock_ttl", 5*time.Minute)
+	v.SetDefault("scheduler.retention", 90*24*time.Hour)
+	v.SetDefault("scheduler.timezone", "UTC")
+	v.SetDefault("scheduler.jobs.stats_rollup.enabled", true)
+	v.SetDefault("scheduler.jobs.stats_rollup.interval", 15*time.Minute)
+	v.SetDefault("scheduler.jobs.retention_prune.enabled", true)
+	v.SetDefault("scheduler.jobs.retention_prune.cron", "30 3 * * *")
+	v.SetDefault("scheduler.jobs.trending_recompute.enabled", true)
+	v.SetDefault("scheduler.jobs.trending_recompute.interval", 5*time.Minute)
+	v.SetDefault("scheduler.jobs.digest_send.enabled", false)
+	v.SetDefault("scheduler.jobs.digest_send.cron", "0 9 * * 1")
+	v.SetDefault("scheduler.jobs.election_certify.enabled", true)
+	v.SetDefault("scheduler.jobs.election_certify.interval", time.Minute)
+	v.SetDefault("scheduler.jobs.result_snapshot.enabled", true)
+	v.SetDefault("scheduler.jobs.result_snapshot.interval", time.Minute)
+	v.SetDefault("scheduler.jobs.result_certify.enabled", true)
+	v.SetDefault("scheduler.jobs.result_certify.interval", time.Minute)
+	v.SetDefault("quota.enabled", false)
+	v.SetDefault("quota.limits.polls_created.daily", 50)
+	v.SetDefault("quota.limits.polls_created.monthly", 500)
+	v.SetDefault("quota.limits.votes_cast.daily", 0)
+	v.SetDefault("quota.limits.votes_cast.monthly", 3000)
+	v.SetDefault("creation_limits.enabled", true)
+	v.SetDefault("email_verification.token_ttl", 48*time.Hour)
+	v.SetDefault("email_verification.required_to_vote", false)
+	v.SetDefault("preview_cards.refresh_share", 0.02)
+	v.SetDefault("rate_limit.rate.limit", 1000)
+	v.SetDefault("rate_limit.rate.window", time.Minute)
+	v.SetDefault("rate_limit.rate.shadow", false)
+	v.SetDefault("rate_limit.burst.limit", 500)
+	v.SetDefault("rate_limit.burst.window", time.Second)
+	v.SetDefault("rate_limit.burst.shadow", false)
+	v.SetDefault("password_policy.min_length", 8)
+	v.SetDefault("password_policy.max_length", 128)
+	v.SetDefault("password_policy.breach_check.enabled", false)
+	v.SetDefault("password_policy.breach_check.url", "https://api.pwnedpasswords.com/range")
+	v.SetDefault("password_policy.breach_check.timeout", 2*time.Second)
+	v.SetDefault("creation_limits.user_daily", 20)
+	v.SetDefault("creation_limits.organization_daily", 100)
+	v.SetDefault("creation_limits.verified_organization_daily", 1000)
+	v.SetDefault("validation.min_options", 2)
+	v.SetDefault("validation.max_options", 10)
+	v.SetDefault("validation.max_title_length", 255)
+	v.SetDefault("validation.max_option_length", 200)
+	v.SetDefault("validation.max_tags", 10)
+	v.SetDefault("validation.max_tag_length", 50)
+	v.SetDefault("validation.max_alt_text_length", 250)
+	v.SetDefault("validation.profanity_filter.enabled", false)
+	v.SetDefault("moderation.scoring.backend", "heuristic")
+	v.SetDefault("moderation.scoring.threshold", 0.6)
+	v.SetDefault("moderation.scoring.timeout", 2*time.Second)
+	v.SetDefault("notification.vote_milestones", []int{10, 100, 1000})
+	v.SetDefault("notification.retry.attempts", 3)
+	v.SetDefault("notification.retry.initial_backoff", 500*time.Millisecond)
+	v.SetDefault("notification.retry.max_backoff", 5*time.Second)
+	v.SetDefault("notification.email.enabled", false)
+	v.SetDefault("notification.email.port", 587)
+	v.SetDefault("notification.email.timeout", 10*time.Second)
+	v.SetDefault("notification.webhook.enabled", false)
+	v.SetDefault("notification.webhook.timeout", 5*time.Second)
+	v.SetDefault("notification.push.enabled", false)
+	v.SetDefault("notification.push.timeout", 5*time.Second)
+	v.SetDefault("cache.warmup.enabled", false)
+	v.SetDefault("cache.warmup.polls", 500)
+	v.SetDefault("cache.warmup.window", 24*time.Hour)
+	v.SetDefault("cache.warmup.concurrency", 8)
+	v.SetDefault("cache.warmup.timeout", 30*time.Second)
+	v.SetDefault("cache.local.enabled", false)
+	v.SetDefault("cache.local.size", 1000)
+	v.SetDefault("cache.local.ttl", 5*time.Second)
+	v.SetDefault("cache.feed.enabled", false)
+	v.SetDefault("cache.feed.ttl", 30*time.Second)
+	v.SetDefault("cache.stats.refresh_window", 30*time.Second)
+	v.SetDefault("search.enabled", false)
+	v.SetDefault("search.url", "http://localhost:9200")
+	v.SetDefault("search.index", "polls")
+	v.SetDefault("search.timeout", 2*time.Second)
+	v.SetDefault("storage.url_ttl", 15*time.Minute)
+	v.SetDefault("storage.gc_grace", 24*time.Hour)
+	v.SetDefault("storage.local.dir", "./data/media")
+	v.SetDefault("storage.local.base_url", "http://localhost:8080/media")
+	v.SetDefault("storage.s3.region", "us-east-1")
+	v.SetDefault("scheduler.jobs.media_gc.enabled", true)
+	v.SetDefault("geoip.enabled", false)
+	v.SetDefault("results.tie_break", "reported")
+	v.SetDefault("public_ids.encoding", "uuid")
+	v.SetDefault("public_ids.accept_uuids", false)
+	v.SetDefault("metrics.push_job", "vote")
+	v.SetDefault("grpc.port", 50051)
+	v.SetDefault("scheduler.jobs.media_gc.interval", 6*time.Hour)
+	v.SetDefault("scheduler.jobs.outbox_relay.enabled", true)
+	v.SetDefault("scheduler.jobs.outbox_relay.interval", 30*time.Second)
+	v.SetDefault("scheduler.jobs.vote_window_repair.enabled", true)
+	v.SetDefault("scheduler.jobs.vote_window_repair.cron", "0 4 * * *")
+	v.SetDefault("scheduler.jobs.poll_webhooks.enabled", true)
+	v.SetDefault("scheduler.jobs.poll_webhooks.interval", time.Minute)
+	v.SetDefault("scheduler.jobs.business_metrics.enabled", true)
+	v.SetDefault("scheduler.jobs.business_metrics.interval", time.Minute)
+	v.SetDefault("poll_webhooks.timeout", 10*time.Second)
+	v.SetDefault("poll_webhooks.max_attempts", 5)
+	v.SetDefault("poll_webhooks.initial_backoff", time.Minute)
+	v.SetDefault("poll_webhooks.max_backoff", time.Hour)
 
 	v.SetConfigName("config")
 	v.SetConfigType("yaml")
@@ -78,46 +644,141 @@ func Load(configFile string) (*Config, error) {
 	}
 
 	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("read config file: %w", err)
+		return nil, nil, fmt.Errorf("read config file: %w", err)
 	}
+	files := []string{v.ConfigFileUsed()}
+
+	overlay, err := mergeOverlay(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	if overlay != "" {
+		files = append(files, overlay)
+	}
+
 	if err := bindEnvs(v); err != nil {
-		return nil, fmt.Errorf("bind env vars: %w", err)
+		return nil, nil, fmt.Errorf("bind env vars: %w", err)
 	}
+	return v, files, nil
+}
 
-	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("unmarshal config: %w", err)
+// mergeOverlay merges config.{env}.yaml, next to the config file read, over
+// it. The environment is VOTE_SERVER_ENV, or server.env from the config file
+// when unset. Nested sections are merged key by key; lists and other values
+// in the overlay replace the base ones. A missing overlay is not an error.
+func mergeOverlay(v *viper.Viper) (string, error) {
+	env := os.Getenv("VOTE_SERVER_ENV")
+	if env == "" {
+		env = v.GetString("server.env")
+	}
+	if env == "" {
+		return "", nil
 	}
 
-	if err := validateConfig(&cfg); err != nil {
-		return nil, fmt.Errorf("validate config: %w", err)
+	base := v.ConfigFileUsed()
+	ext := filepath.Ext(base)
+	overlay := strings.TrimSuffix(base, ext) + "." + env + ext
+	if _, err := os.Stat(overlay); errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("stat config overlay: %w", err)
 	}
 
-	return &cfg, nil
+	v.SetConfigFile(overlay)
+	if err := v.MergeInConfig(); err != nil {
+		return "", fmt.Errorf("merge config overlay %s: %w", overlay, err)
+	}
+	return overlay, nil
 }
 
 func bindEnvs(v *viper.Viper) error {
 	bindings := map[string]string{
-		"server.port":            "VOTE_SERVER_PORT",
-		"server.env":             "VOTE_SERVER_ENV",
-		"postgres.host":          "VOTE_POSTGRES_HOST",
-		"postgres.port":          "VOTE_POSTGRES_PORT",
-		"postgres.user":          "VOTE_POSTGRES_USER",
-		"postgres.password":      "VOTE_POSTGRES_PASSWORD",
-		"postgres.dbname":        "VOTE_POSTGRES_DBNAME",
-		"postgres.sslmode":       "VOTE_POSTGRES_SSLMODE",
-		"redis.host":             "VOTE_REDIS_HOST",
-		"redis.port":             "VOTE_REDIS_PORT",
-		"redis.password":         "VOTE_REDIS_PASSWORD",
-		"redis.db":               "VOTE_REDIS_DB",
-		"rabbitmq.host":          "VOTE_RABBITMQ_HOST",
-		"rabbitmq.port":          "VOTE_RABBITMQ_PORT",
-		"rabbitmq.user":          "VOTE_RABBITMQ_USER",
-		"rabbitmq.password":      "VOTE_RABBITMQ_PASSWORD",
-		"rabbitmq.vhost":         "VOTE_RABBITMQ_VHOST",
-		"migration.auto_migrate": "VOTE_MIGRATION_AUTO_MIGRATE",
-		"jwt.secret_key":         "VOTE_JWT_SECRET_KEY",
-		"jwt.token_duration":     "VOTE_JWT_TOKEN_DURATION",
+		"server.port":                "VOTE_SERVER_PORT",
+		"server.env":                 "VOTE_SERVER_ENV",
+		"server.shutdown_timeout":    "VOTE_SERVER_SHUTDOWN_TIMEOUT",
+		"server.public_url":          "VOTE_SERVER_PUBLIC_URL",
+		"postgres.host":              "VOTE_POSTGRES_HOST",
+		"postgres.port":              "VOTE_POSTGRES_PORT",
+		"postgres.user":              "VOTE_POSTGRES_USER",
+		"postgres.password":          "VOTE_POSTGRES_PASSWORD",
+		"postgres.dbname":            "VOTE_POSTGRES_DBNAME",
+		"postgres.sslmode":           "VOTE_POSTGRES_SSLMODE",
+		"redis.host":                 "VOTE_REDIS_HOST",
+		"redis.port":                 "VOTE_REDIS_PORT",
+		"redis.password":             "VOTE_REDIS_PASSWORD",
+		"redis.db":                   "VOTE_REDIS_DB",
+		"rabbitmq.host":              "VOTE_RABBITMQ_HOST",
+		"rabbitmq.port":              "VOTE_RABBITMQ_PORT",
+		"rabbitmq.user":              "VOTE_RABBITMQ_USER",
+		"rabbitmq.password":          "VOTE_RABBITMQ_PASSWORD",
+		"rabbitmq.vhost":             "VOTE_RABBITMQ_VHOST",
+		"events.publish_mode":        "VOTE_EVENTS_PUBLISH_MODE",
+		"events.relay.enabled":       "VOTE_EVENTS_RELAY_ENABLED",
+		"startup.retry_max_wait":     "VOTE_STARTUP_RETRY_MAX_WAIT",
+		"migration.auto_migrate":     "VOTE_MIGRATION_AUTO_MIGRATE",
+		"jwt.secret_key":             "VOTE_JWT_SECRET_KEY",
+		"jwt.token_duration":         "VOTE_JWT_TOKEN_DURATION",
+		"jwt.refresh_token_duration": "VOTE_JWT_REFRESH_TOKEN_DURATION",
+		"scheduler.enabled":          "VOTE_SCHEDULER_ENABLED",
+		"scheduler.timezone":         "VOTE_SCHEDULER_TIMEZONE",
+		"quota.enabled":              "VOTE_QUOTA_ENABLED",
+		"creation_limits.enabled":    "VOTE_CREATION_LIMITS_ENABLED",
+		"election.signing_key":       "VOTE_ELECTION_SIGNING_KEY",
+		"receipts.signing_key":       "VOTE_RECEIPTS_SIGNING_KEY",
+
+		"validation.profanity_filter.enabled":   "VOTE_VALIDATION_PROFANITY_FILTER_ENABLED",
+		"validation.profanity_filter.word_list": "VOTE_VALIDATION_PROFANITY_FILTER_WORD_LIST",
+		"moderation.moderators":                 "VOTE_MODERATION_MODERATORS",
+		"moderation.admins":                     "VOTE_MODERATION_ADMINS",
+		"moderation.scoring.backend":            "VOTE_MODERATION_SCORING_BACKEND",
+		"moderation.scoring.word_list":          "VOTE_MODERATION_SCORING_WORD_LIST",
+		"moderation.scoring.url":                "VOTE_MODERATION_SCORING_URL",
+		"moderation.scoring.token":              "VOTE_MODERATION_SCORING_TOKEN",
+		"notification.vote_milestones":          "VOTE_NOTIFICATION_VOTE_MILESTONES",
+		"notification.email.enabled":            "VOTE_NOTIFICATION_EMAIL_ENABLED",
+		"notification.email.host":               "VOTE_NOTIFICATION_EMAIL_HOST",
+		"notification.email.username":           "VOTE_NOTIFICATION_EMAIL_USERNAME",
+		"notification.email.password":           "VOTE_NOTIFICATION_EMAIL_PASSWORD",
+		"notification.email.from":               "VOTE_NOTIFICATION_EMAIL_FROM",
+		"notification.webhook.enabled":          "VOTE_NOTIFICATION_WEBHOOK_ENABLED",
+		"notification.webhook.url":              "VOTE_NOTIFICATION_WEBHOOK_URL",
+		"notification.webhook.secret":           "VOTE_NOTIFICATION_WEBHOOK_SECRET",
+		"notification.push.enabled":             "VOTE_NOTIFICATION_PUSH_ENABLED",
+		"notification.push.credentials_file":    "VOTE_NOTIFICATION_PUSH_CREDENTIALS_FILE",
+		"cache.warmup.enabled":                  "VOTE_CACHE_WARMUP_ENABLED",
+		"cache.local.enabled":                   "VOTE_CACHE_LOCAL_ENABLED",
+		"cache.feed.enabled":                    "VOTE_CACHE_FEED_ENABLED",
+		"search.enabled":                        "VOTE_SEARCH_ENABLED",
+		"search.url":                            "VOTE_SEARCH_URL",
+		"search.username":                       "VOTE_SEARCH_USERNAME",
+		"search.password":                       "VOTE_SEARCH_PASSWORD",
+		"storage.driver":                        "VOTE_STORAGE_DRIVER",
+		"storage.local.dir":                     "VOTE_STORAGE_LOCAL_DIR",
+		"storage.local.base_url":                "VOTE_STORAGE_LOCAL_BASE_URL",
+		"storage.local.secret":                  "VOTE_STORAGE_LOCAL_SECRET",
+		"storage.s3.endpoint":                   "VOTE_STORAGE_S3_ENDPOINT",
+		"storage.s3.region":                     "VOTE_STORAGE_S3_REGION",
+		"storage.s3.bucket":                     "VOTE_STORAGE_S3_BUCKET",
+		"storage.s3.access_key":                 "VOTE_STORAGE_S3_ACCESS_KEY",
+		"storage.s3.secret_key":                 "VOTE_STORAGE_S3_SECRET_KEY",
+		"storage.gcs.bucket":                    "VOTE_STORAGE_GCS_BUCKET",
+		"storage.gcs.access_key":                "VOTE_STORAGE_GCS_ACCESS_KEY",
+		"storage.gcs.secret_key":                "VOTE_STORAGE_GCS_SECRET_KEY",
+		"geoip.enabled":                         "VOTE_GEOIP_ENABLED",
+		"geoip.database":                        "VOTE_GEOIP_DATABASE",
+		"consent.terms_version":                 "VOTE_CONSENT_TERMS_VERSION",
+		"consent.privacy_version":               "VOTE_CONSENT_PRIVACY_VERSION",
+		"results.tie_break":                     "VOTE_RESULTS_TIE_BREAK",
+		"results.tie_break_seed":                "VOTE_RESULTS_TIE_BREAK_SEED",
+		"results.signing_key":                   "VOTE_RESULTS_SIGNING_KEY",
+		"public_ids.encoding":                   "VOTE_PUBLIC_IDS_ENCODING",
+		"public_ids.secret":                     "VOTE_PUBLIC_IDS_SECRET",
+		"public_ids.accept_uuids":               "VOTE_PUBLIC_IDS_ACCEPT_UUIDS",
+		"metrics.push_url":                      "VOTE_METRICS_PUSH_URL",
+		"metrics.push_job":                      "VOTE_METRICS_PUSH_JOB",
+		"metrics.port":                          "VOTE_METRICS_PORT",
+		"grpc.port":                             "VOTE_GRPC_PORT",
+		"password_policy.breach_check.enabled":  "VOTE_PASSWORD_POLICY_BREACH_CHECK_ENABLED",
 	}
 
 	for key, env := range bindings {
@@ -136,6 +797,14 @@ func validateConfig(cfg *Config) error {
 	if cfg.Server.Env == "" {
 		return fmt.Errorf("server.env is required")
 	}
+	if cfg.Server.ShutdownTimeout <= 0 {
+		return fmt.Errorf("server.shutdown_timeout must be greater than 0")
+	}
+	if cfg.Server.PublicURL != "" {
+		if u, err := url.Parse(cfg.Server.PublicURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("server.public_url must be an absolute URL, got %q", cfg.Server.PublicURL)
+		}
+	}
 
 	if cfg.Postgres.Host == "" {
 		return fmt.Errorf("postgres.host is required")
@@ -167,12 +836,232 @@ func validateConfig(cfg *Config) error {
 		return fmt.Errorf("rabbitmq.user is required")
 	}
 
+	switch cfg.Events.PublishMode {
+	case "sync":
+	case "async":
+		if cfg.Events.QueueSize <= 0 || cfg.Events.Workers <= 0 {
+			return fmt.Errorf("events.queue_size and events.workers must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("events.publish_mode must be sync or async, got %q", cfg.Events.PublishMode)
+	}
+	if cfg.Events.Relay.Enabled {
+		if cfg.Events.Relay.Interval <= 0 || cfg.Events.Relay.Batch <= 0 {
+			return fmt.Errorf("events.relay.interval and events.relay.batch must be greater than 0")
+		}
+		if cfg.Events.Relay.Lease <= 0 {
+			return fmt.Errorf("events.relay.lease must be greater than 0")
+		}
+	}
+
+	if cfg.Startup.RetryMaxWait < 0 {
+		return fmt.Errorf("startup.retry_max_wait must not be negative")
+	}
+	if cfg.Startup.RetryInitialBackoff <= 0 || cfg.Startup.RetryMaxBackoff < cfg.Startup.RetryInitialBackoff {
+		return fmt.Errorf("startup.retry_initial_backoff must be greater than 0 and at most startup.retry_max_backoff")
+	}
+	if cfg.Health.CheckInterval <= 0 || cfg.Health.CheckTimeout <= 0 {
+		return fmt.Errorf("health.check_interval and health.check_timeout must be greater than 0")
+	}
+	if cfg.Health.ReconnectInitialBackoff <= 0 || cfg.Health.ReconnectMaxBackoff < cfg.Health.ReconnectInitialBackoff {
+		return fmt.Errorf("health.reconnect_initial_backoff must be greater than 0 and at most health.reconnect_max_backoff")
+	}
+
 	if cfg.JWT.SecretKey == "" {
 		return fmt.Errorf("jwt.secret_key is required")
 	}
 	if cfg.JWT.TokenDuration <= 0 {
 		return fmt.Errorf("jwt.token_duration must be greater than 0")
 	}
+	if cfg.JWT.RefreshTokenDuration <= 0 {
+		return fmt.Errorf("jwt.refresh_token_duration must be greater than 0")
+	}
 
+	if cfg.Scheduler.Enabled && cfg.Scheduler.LockTTL <= 0 {
+		return fmt.Errorf("scheduler.lock_ttl must be greater than 0")
+	}
+	if cfg.Scheduler.Enabled {
+		for name, job := range cfg.Scheduler.Jobs {
+			if _, err := cfg.Scheduler.Location(job); err != nil {
+				return fmt.Errorf("scheduler.jobs.%s: unknown timezone: %w", name, err)
+			}
+		}
+	}
+	if _, enabled := cfg.Scheduler.Job("election_certify"); cfg.Scheduler.Enabled && enabled && cfg.Election.SigningKey == "" {
+		return fmt.Errorf("election.signing_key is required when election_certify is enabled")
+	}
+
+	for action, limits := range cfg.Quota.Limits {
+		if limits.Daily < 0 || limits.Monthly < 0 {
+			return fmt.Errorf("quota.limits.%s must not be negative", action)
+		}
+	}
+
+	if cfg.CreationLimits.UserDaily < 0 || cfg.CreationLimits.OrganizationDaily < 0 || cfg.CreationLimits.VerifiedOrganizationDaily < 0 {
+		return fmt.Errorf("creation_limits must not be negative")
+	}
+	for _, id := range cfg.CreationLimits.VerifiedOrganizations {
+		if _, err := uuid.Parse(id); err != nil {
+			return fmt.Errorf("creation_limits.verified_organizations: invalid organization ID %q", id)
+		}
+	}
+
+	if cfg.Validation.MinOptions < 2 {
+		return fmt.Errorf("validation.min_options must be at least 2")
+	}
+	if cfg.Validation.MaxOptions > 0 && cfg.Validation.MaxOptions < cfg.Validation.MinOptions {
+		return fmt.Errorf("validation.max_options must not be less than validation.min_options")
+	}
+	if cfg.Validation.MaxTitleLength < 0 || cfg.Validation.MaxOptionLength < 0 ||
+		cfg.Validation.MaxTags < 0 || cfg.Validation.MaxTagLength < 0 || cfg.Validation.MaxAltTextLength < 0 {
+		return fmt.Errorf("validation limits must not be negative")
+	}
+	if cfg.Validation.ProfanityFilter.Enabled && cfg.Validation.ProfanityFilter.WordList == "" {
+		return fmt.Errorf("validation.profanity_filter.word_list is required when the filter is enabled")
+	}
+
+	for _, id := range cfg.Moderation.Moderators {
+		if _, err := uuid.Parse(id); err != nil {
+			return fmt.Errorf("moderation.moderators: invalid user ID %q", id)
+		}
+	}
+	for _, id := range cfg.Moderation.Admins {
+		if _, err := uuid.Parse(id); err != nil {
+			return fmt.Errorf("moderation.admins: invalid user ID %q", id)
+		}
+	}
+	if err := validateScoring(cfg.Moderation.Scoring); err != nil {
+		return err
+	}
+
+	for _, milestone := range cfg.Notification.VoteMilestones {
+		if milestone <= 0 {
+			return fmt.Errorf("notification.vote_milestones must be greater than 0")
+		}
+	}
+	if r := cfg.Notification.Retry; r.Attempts <= 0 || r.InitialBackoff <= 0 || r.MaxBackoff < r.InitialBackoff {
+		return fmt.Errorf("notification.retry attempts and initial_backoff must be greater than 0 and max_backoff at least initial_backoff")
+	}
+	if e := cfg.Notification.Email; e.Enabled && (e.Host == "" || e.Port <= 0 || e.From == "" || e.Timeout <= 0) {
+		return fmt.Errorf("notification.email host, port, from and timeout are required when enabled")
+	}
+	if w := cfg.Notification.Webhook; w.Enabled && (w.URL == "" || w.Timeout <= 0) {
+		return fmt.Errorf("notification.webhook url and timeout are required when enabled")
+	}
+	if p := cfg.Notification.Push; p.Enabled && (p.CredentialsFile == "" || p.Timeout <= 0) {
+		return fmt.Errorf("notification.push credentials_file and timeout are required when enabled")
+	}
+
+	if w := cfg.Cache.Warmup; w.Enabled && (w.Polls <= 0 || w.Window <= 0 || w.Concurrency <= 0 || w.Timeout <= 0) {
+		return fmt.Errorf("cache.warmup polls, window, concurrency and timeout must be greater than 0")
+	}
+	if l := cfg.Cache.Local; l.Enabled && (l.Size <= 0 || l.TTL <= 0) {
+		return fmt.Errorf("cache.local size and ttl must be greater than 0")
+	}
+	if f := cfg.Cache.Feed; f.Enabled && f.TTL <= 0 {
+		return fmt.Errorf("cache.feed.ttl must be greater than 0")
+	}
+	if cfg.Cache.Stats.RefreshWindow < 0 {
+		return fmt.Errorf("cache.stats.refresh_window must not be negative")
+	}
+	if s := cfg.Search; s.Enabled && (s.URL == "" || s.Index == "" || s.Timeout <= 0) {
+		return fmt.Errorf("search url and index are required and timeout must be greater than 0")
+	}
+	if err := validateStorage(cfg.Storage); err != nil {
+		return err
+	}
+	if cfg.GeoIP.Enabled && cfg.GeoIP.Database == "" {
+		return fmt.Errorf("geoip.database is required when geoip is enabled")
+	}
+	if len(cfg.Consent.TermsVersion) > 64 || len(cfg.Consent.PrivacyVersion) > 64 {
+		return fmt.Errorf("consent versions must be at most 64 characters")
+	}
+	switch cfg.Results.TieBreak {
+	case "reported", "earliest_lead", "random":
+	default:
+		return fmt.Errorf("results.tie_break must be reported, earliest_lead or random")
+	}
+	if cfg.Results.TieBreak == "random" && cfg.Results.TieBreakSeed == "" {
+		return fmt.Errorf("results.tie_break_seed is required when results.tie_break is random")
+	}
+	switch cfg.PublicIDs.Encoding {
+	case "uuid":
+	case "sqid":
+		if cfg.PublicIDs.Secret == "" {
+			return fmt.Errorf("public_ids.secret is required when public_ids.encoding is sqid")
+		}
+	default:
+		return fmt.Errorf("public_ids.encoding must be uuid or sqid")
+	}
+	if w := cfg.PollWebhooks; w.Timeout <= 0 || w.MaxAttempts <= 0 || w.InitialBackoff <= 0 || w.MaxBackoff < w.InitialBackoff {
+		return fmt.Errorf("poll_webhooks timeout, max_attempts and initial_backoff must be positive, and max_backoff at least initial_backoff")
+	}
+	if m := cfg.Metrics; m.PushURL != "" {
+		if u, err := url.Parse(m.PushURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("metrics.push_url must be an absolute URL, got %q", m.PushURL)
+		}
+		if m.PushJob == "" {
+			return fmt.Errorf("metrics.push_job is required when metrics.push_url is set")
+		}
+	}
+	if p := cfg.Metrics.Port; p < 0 || p > 65535 {
+		return fmt.Errorf("metrics.port must be between 0 and 65535, got %d", p)
+	}
+	if p := cfg.GRPC.Port; p < 0 || p > 65535 {
+		return fmt.Errorf("grpc.port must be between 0 and 65535, got %d", p)
+	}
+	if cfg.GRPC.Port != 0 && cfg.GRPC.Port == cfg.Server.Port {
+		return fmt.Errorf("grpc.port must differ from server.port")
+	}
+	if p := cfg.PasswordPolicy; p.MinLength < 1 || p.MaxLength < 0 || (p.MaxLength > 0 && p.MaxLength < p.MinLength) {
+		return fmt.Errorf("password_policy.min_length must be at least 1 and not more than max_length")
+	}
+	if b := cfg.PasswordPolicy.BreachCheck; b.Enabled && (b.URL == "" || b.Timeout <= 0) {
+		return fmt.Errorf("password_policy.breach_check url is required and timeout must be greater than 0")
+	}
+
+	return nil
+}
+
+func validateScoring(s ScoringConfig) error {
+	switch s.Backend {
+	case "":
+		return nil
+	case "heuristic":
+	case "http":
+		if s.URL == "" || s.Timeout <= 0 {
+			return fmt.Errorf("moderation.scoring url is required and timeout must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("moderation.scoring.backend must be heuristic, http or empty, got %q", s.Backend)
+	}
+	if s.Threshold <= 0 || s.Threshold > 1 {
+		return fmt.Errorf("moderation.scoring.threshold must be greater than 0 and at most 1")
+	}
+	return nil
+}
+
+func validateStorage(s StorageConfig) error {
+	switch s.Driver {
+	case "":
+		return nil
+	case "local":
+		if s.Local.Dir == "" || s.Local.BaseURL == "" || s.Local.Secret == "" {
+			return fmt.Errorf("storage.local dir, base_url and secret are required")
+		}
+	case "s3":
+		if s.S3.Region == "" || s.S3.Bucket == "" || s.S3.AccessKey == "" || s.S3.SecretKey == "" {
+			return fmt.Errorf("storage.s3 region, bucket, access_key and secret_key are required")
+		}
+	case "gcs":
+		if s.GCS.Bucket == "" || s.GCS.AccessKey == "" || s.GCS.SecretKey == "" {
+			return fmt.Errorf("storage.gcs bucket, access_key and secret_key are required")
+		}
+	default:
+		return fmt.Errorf("storage.driver must be local, s3 or gcs")
+	}
+	if s.URLTTL <= 0 || s.GCGrace <= 0 {
+		return fmt.Errorf("storage url_ttl and gc_grace must be greater than 0")
+	}
 	return nil
 }
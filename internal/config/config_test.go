@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestEffectiveMergesOverlay(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.yaml")
+	writeFile(t, base, `
+server:
+  env: staging
+postgres:
+  host: localhost
+  user: vote
+  password: local
+moderation:
+  admins: [a, b]
+`)
+	writeFile(t, filepath.Join(dir, "config.production.yaml"), `
+postgres:
+  host: db.internal
+moderation:
+  admins: [c]
+`)
+	writeFile(t, filepath.Join(dir, "config.staging.yaml"), `
+postgres:
+  host: db.staging
+`)
+
+	t.Run("env variable selects the overlay", func(t *testing.T) {
+		t.Setenv("VOTE_SERVER_ENV", "production")
+
+		settings, files, err := Effective(base, false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{base, filepath.Join(dir, "config.production.yaml")}, files)
+
+		postgres := settings["postgres"].(map[string]interface{})
+		assert.Equal(t, "db.internal", postgres["host"])
+		assert.Equal(t, "vote", postgres["user"], "keys missing from the overlay are kept")
+		assert.Equal(t, []interface{}{"c"}, settings["moderation"].(map[string]interface{})["admins"])
+	})
+
+	t.Run("falls back to server.env", func(t *testing.T) {
+		settings, files, err := Effective(base, true)
+		require.NoError(t, err)
+		assert.Len(t, files, 2)
+
+		postgres := settings["postgres"].(map[string]interface{})
+		assert.Equal(t, "db.staging", postgres["host"])
+		assert.Equal(t, redactedValue, postgres["password"])
+		assert.Equal(t, "15m0s", settings["jwt"].(map[string]interface{})["token_duration"])
+	})
+
+	t.Run("missing overlay", func(t *testing.T) {
+		t.Setenv("VOTE_SERVER_ENV", "test")
+
+		_, files, err := Effective(base, false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{base}, files)
+	})
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const redactedValue = "[REDACTED]"
+
+// secretKeys are the setting names, in any section, whose values are masked
+// by Effective when redacting.
+var secretKeys = map[string]bool{
+	"password":    true,
+	"secret":      true,
+	"secret_key":  true,
+	"access_key":  true,
+	"signing_key": true,
+	"token":       true,
+}
+
+// Effective returns every setting as Load resolves it, nested by section as
+// in config.yaml, together with the config files that were read. With
+// redact, secrets that are set are replaced with a placeholder.
+func Effective(configFile string, redact bool) (map[string]interface{}, []string, error) {
+	v, files, err := read(configFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return normalize(v.AllSettings(), redact), files, nil
+}
+
+// normalize prints durations the way they are written in config.yaml and
+// masks secrets when redact is set.
+func normalize(settings map[string]interface{}, redact bool) map[string]interface{} {
+	out := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		switch val := value.(type) {
+		case map[string]interface{}:
+			out[key] = normalize(val, redact)
+		case time.Duration:
+			out[key] = val.String()
+		default:
+			if redact && secretKeys[key] && fmt.Sprint(val) != "" {
+				out[key] = redactedValue
+			} else {
+				out[key] = val
+			}
+		}
+	}
+	return out
+}
@@ -2,9 +2,17 @@ package domain
 
 import (
 	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRepositoryError_Error(t *testing.T) {
@@ -44,3 +52,121 @@ func TestDomainErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestUserPreferences_Mutes(t *testing.T) {
+	prefs := &UserPreferences{
+		MutedTags:     []string{"politics"},
+		MutedKeywords: []string{"election"},
+	}
+
+	tests := []struct {
+		name     string
+		poll     *Poll
+		expected bool
+	}{
+		{"muted tag", &Poll{Title: "Best pizza", Tags: []string{"food", "Politics"}}, true},
+		{"muted keyword", &Poll{Title: "Who wins the Election?", Tags: []string{"news"}}, true},
+		{"not muted", &Poll{Title: "Best pizza", Tags: []string{"food"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, prefs.Mutes(tt.poll))
+		})
+	}
+}
+
+func TestFeedCursor(t *testing.T) {
+	cursor := FeedCursor{
+		CreatedAt: time.Date(2024, 5, 20, 10, 30, 0, 123456789, time.UTC),
+		ID:        uuid.New(),
+	}
+	decoded, err := DecodeFeedCursor(cursor.Encode())
+	assert.NoError(t, err)
+	assert.True(t, cursor.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, cursor.ID, decoded.ID)
+
+	for _, raw := range []string{"!!!", "bm9waXBl", "MjAyNHxub3QtYS11dWlk"} {
+		_, err = DecodeFeedCursor(raw)
+		assert.ErrorIs(t, err, ErrInvalidInput, raw)
+	}
+}
+
+func TestNewPollResultSnapshot(t *testing.T) {
+	pollID := uuid.New()
+	closedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("winner and percentages", func(t *testing.T) {
+		stats := &PollStats{PollID: pollID, Votes: []OptionStats{
+			{Option: "A", Count: 1},
+			{Option: "B", Count: 2},
+			{Option: "C", Count: 0},
+		}}
+		snapshot := NewPollResultSnapshot(stats, closedAt, closedAt)
+
+		assert.Equal(t, 3, snapshot.Total)
+		assert.Equal(t, "B", snapshot.Winner)
+		assert.Equal(t, []OptionResult{
+			{Option: "A", Count: 1, Percentage: 33.33},
+			{Option: "B", Count: 2, Percentage: 66.67},
+			{Option: "C", Count: 0, Percentage: 0},
+		}, snapshot.Options)
+		assert.Equal(t, stats.Votes, snapshot.Stats().Votes)
+	})
+
+	t.Run("tie has no winner", func(t *testing.T) {
+		stats := &PollStats{PollID: pollID, Votes: []OptionStats{
+			{Option: "A", Count: 2},
+			{Option: "B", Count: 2},
+		}}
+		snapshot := NewPollResultSnapshot(stats, closedAt, closedAt)
+		assert.Empty(t, snapshot.Winner)
+		assert.Equal(t, []string{"A", "B"}, snapshot.Tied)
+		assert.Equal(t, 2, snapshot.Outcome().Votes)
+	})
+
+	t.Run("no votes", func(t *testing.T) {
+		stats := &PollStats{PollID: pollID, Votes: []OptionStats{{Option: "A"}, {Option: "B"}}}
+		snapshot := NewPollResultSnapshot(stats, closedAt, closedAt)
+		assert.Zero(t, snapshot.Total)
+		assert.Empty(t, snapshot.Winner)
+		assert.Empty(t, snapshot.Tied)
+	})
+}
+
+func TestErrorKindsCoverEveryError(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "errors.go", nil, 0)
+	require.NoError(t, err)
+
+	classified := make(map[string]bool, len(ErrorKinds))
+	for _, kind := range ErrorKinds {
+		classified[kind.Err.Error()] = true
+	}
+
+	var checked int
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+		for i, name := range spec.Names {
+			if !strings.HasPrefix(name.Name, "Err") || i >= len(spec.Values) {
+				continue
+			}
+			call, ok := spec.Values[i].(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 {
+				continue
+			}
+			lit, ok := call.Args[0].(*ast.BasicLit)
+			if !ok {
+				continue
+			}
+			msg, err := strconv.Unquote(lit.Value)
+			require.NoError(t, err)
+			assert.True(t, classified[msg], "%s has no entry in ErrorKinds", name.Name)
+			checked++
+		}
+		return false
+	})
+	assert.NotZero(t, checked)
+}
@@ -1,6 +1,13 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type RepositoryError struct {
 	Op  string
@@ -21,6 +28,7 @@ var (
 	ErrAlreadyVoted           = errors.New("user has already voted on this poll")
 	ErrAlreadySkipped         = errors.New("user has already skipped this poll")
 	ErrInvalidOption          = errors.New("invalid option index")
+	ErrOptionRequired         = errors.New("an option is required: the poll has no default option")
 	ErrDailyVoteLimitExceeded = errors.New("daily vote limit exceeded")
 	ErrInvalidUser            = errors.New("invalid user ID")
 	ErrInvalidPoll            = errors.New("invalid poll ID")
@@ -28,4 +36,241 @@ var (
 	ErrInvalidPageSize        = errors.New("invalid page size")
 	ErrEmailAlreadyExists     = errors.New("email already exists")
 	ErrUnauthorized           = errors.New("unauthorized")
+	ErrQuotaExceeded          = errors.New("quota exceeded")
+	ErrInvalidAccessCode      = errors.New("invalid poll access code")
+	ErrForbidden              = errors.New("forbidden")
+	ErrNotEligible            = errors.New("user is not eligible to vote on this poll")
+	ErrPollNotOpen            = errors.New("poll is not open for voting")
+	ErrVoteFinal              = errors.New("votes on this poll cannot be changed")
+	ErrInvalidTransition      = errors.New("invalid poll status transition")
+	ErrMediaUnavailable       = errors.New("media uploads are not configured")
+	ErrGeoRestricted          = errors.New("voting on this poll is not available in your country")
+	ErrBanned                 = errors.New("account is banned")
+	ErrConsentRequired        = errors.New("the current terms must be accepted")
+	ErrStatsWaitUnavailable   = errors.New("stats change notifications are not configured")
+	ErrReceiptsUnavailable    = errors.New("vote receipts are not configured")
+	ErrCertificatesDisabled   = errors.New("result certificates are not configured")
+	ErrPollNotClosed          = errors.New("poll has not closed yet")
+	ErrCreationLimitExceeded  = errors.New("poll creation limit exceeded")
+	ErrEmailNotVerified       = errors.New("email address is not verified")
+	ErrResultsHidden          = errors.New("results of this poll are not visible yet")
+	ErrWeakPassword           = errors.New("password does not meet the password policy")
+	ErrNoOrganizationVotes    = errors.New("poll does not accept organization votes")
+	ErrVoteChangeCooldown     = errors.New("vote was changed too recently")
+	ErrPollPublished          = errors.New("poll has already gone live")
+	ErrTagRestricted          = errors.New("tag is restricted")
+	ErrPollInReview           = errors.New("poll is waiting for moderator review")
+	ErrPollRejected           = errors.New("poll was rejected by a moderator")
+	ErrTooLarge               = errors.New("object exceeds the size limit")
+	ErrUnsupportedType        = errors.New("unsupported content type")
+)
+
+// ErrorClass is the kind of failure an error reports, which each transport
+// answers with its own status: a 404 and NOT_FOUND for ClassNotFound.
+type ErrorClass int
+
+const (
+	ClassInternal ErrorClass = iota
+	ClassInvalid
+	ClassUnauthenticated
+	ClassForbidden
+	ClassNotFound
+	ClassAlreadyExists
+	ClassConflict
+	ClassTooLarge
+	ClassUnsupportedType
+	ClassPreconditionRequired
+	ClassLimited
+	ClassUnavailable
 )
+
+// ErrorKind classifies one of the errors above. Code names it to clients and
+// in the service error metrics. Message, when set, is answered instead of
+// the error's own text.
+type ErrorKind struct {
+	Err     error
+	Class   ErrorClass
+	Code    string
+	Message string
+}
+
+// ErrorKinds is the one classification of the domain errors that the HTTP
+// and gRPC APIs and the metrics share. It is checked in order, so errors
+// that wrap others come first.
+var ErrorKinds = []ErrorKind{
+	{ErrBanned, ClassForbidden, "banned", "Account is banned"},
+	{ErrConsentRequired, ClassPreconditionRequired, "consent_required", ""},
+	{ErrEmailNotVerified, ClassForbidden, "email_not_verified", ""},
+	{ErrTagRestricted, ClassForbidden, "tag_restricted", ""},
+	{ErrPollRejected, ClassForbidden, "poll_rejected", ""},
+	{ErrNotEligible, ClassForbidden, "not_eligible", ""},
+	{ErrResultsHidden, ClassForbidden, "results_hidden", ""},
+	{ErrGeoRestricted, ClassForbidden, "geo_restricted", ""},
+	{ErrInvalidAccessCode, ClassForbidden, "invalid_access_code", ""},
+	{ErrUnauthorized, ClassForbidden, "forbidden", "Forbidden"},
+	{ErrForbidden, ClassForbidden, "forbidden", "Forbidden"},
+	{ErrInvalidCredentials, ClassUnauthenticated, "invalid_credentials", ""},
+	{ErrNotFound, ClassNotFound, "not_found", "Not found"},
+	{ErrInvalidOption, ClassInvalid, "invalid_option", ""},
+	{ErrOptionRequired, ClassInvalid, "option_required", ""},
+	{ErrWeakPassword, ClassInvalid, "weak_password", ""},
+	{ErrInvalidUser, ClassInvalid, "invalid_user", ""},
+	{ErrInvalidPoll, ClassInvalid, "invalid_poll", ""},
+	{ErrInvalidTag, ClassInvalid, "invalid_tag", ""},
+	{ErrInvalidPageSize, ClassInvalid, "invalid_page_size", ""},
+	{ErrInvalidInput, ClassInvalid, "invalid_input", ""},
+	{ErrEmailAlreadyExists, ClassAlreadyExists, "email_already_exists", ""},
+	{ErrAlreadyVoted, ClassAlreadyExists, "already_voted", ""},
+	{ErrAlreadySkipped, ClassAlreadyExists, "already_skipped", ""},
+	{ErrPollNotOpen, ClassConflict, "poll_not_open", ""},
+	{ErrPollNotClosed, ClassConflict, "poll_not_closed", ""},
+	{ErrPollPublished, ClassConflict, "poll_published", ""},
+	{ErrPollInReview, ClassConflict, "poll_in_review", ""},
+	{ErrVoteFinal, ClassConflict, "vote_final", ""},
+	{ErrNoOrganizationVotes, ClassConflict, "no_organization_votes", ""},
+	{ErrInvalidTransition, ClassConflict, "invalid_transition", ""},
+	{ErrTooLarge, ClassTooLarge, "too_large", ""},
+	{ErrUnsupportedType, ClassUnsupportedType, "unsupported_type", ""},
+	{ErrDailyVoteLimitExceeded, ClassLimited, "daily_vote_limit", ""},
+	{ErrVoteChangeCooldown, ClassLimited, "vote_change_cooldown", ""},
+	{ErrQuotaExceeded, ClassLimited, "quota_exceeded", ""},
+	{ErrCreationLimitExceeded, ClassLimited, "creation_limit", ""},
+	{ErrMediaUnavailable, ClassUnavailable, "media_unavailable", ""},
+	{ErrStatsWaitUnavailable, ClassUnavailable, "stats_wait_unavailable", ""},
+	{ErrReceiptsUnavailable, ClassUnavailable, "receipts_unavailable", ""},
+	{ErrCertificatesDisabled, ClassUnavailable, "certificates_unavailable", ""},
+}
+
+// ClassifyError returns the kind of the first of ErrorKinds that err is.
+func ClassifyError(err error) (ErrorKind, bool) {
+	for _, kind := range ErrorKinds {
+		if errors.Is(err, kind.Err) {
+			return kind, true
+		}
+	}
+	return ErrorKind{}, false
+}
+
+// TagRestrictedError is returned when a poll is posted to a restricted tag
+// by a user who may not post there.
+type TagRestrictedError struct {
+	Tag string
+}
+
+func (e *TagRestrictedError) Error() string {
+	return fmt.Sprintf("only verified users can post to %q", e.Tag)
+}
+
+func (e *TagRestrictedError) Unwrap() error {
+	return ErrTagRestricted
+}
+
+// VoteCooldownError is returned when a vote is changed again before the
+// poll's vote change cooldown has passed. RetryAt is when it may be.
+type VoteCooldownError struct {
+	RetryAt time.Time
+}
+
+func (e *VoteCooldownError) Error() string {
+	return fmt.Sprintf("vote can be changed again at %s", e.RetryAt.UTC().Format(time.RFC3339))
+}
+
+func (e *VoteCooldownError) Unwrap() error {
+	return ErrVoteChangeCooldown
+}
+
+// AlreadyVotedError is returned when a user votes again on a poll. It names
+// the vote they already cast so clients can offer to change it.
+type AlreadyVotedError struct {
+	VoteID      uuid.UUID
+	OptionIndex int
+}
+
+func (e *AlreadyVotedError) Error() string {
+	return ErrAlreadyVoted.Error()
+}
+
+func (e *AlreadyVotedError) Unwrap() error {
+	return ErrAlreadyVoted
+}
+
+type QuotaExceededError struct {
+	Usage QuotaUsage
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s %s quota of %d exceeded", e.Usage.Period, e.Usage.Action, e.Usage.Limit)
+}
+
+func (e *QuotaExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// CreationLimitError is returned when a creator has created as many polls
+// today as the creation throttle allows. ResetAt is when the count restarts.
+type CreationLimitError struct {
+	Limit   int
+	ResetAt time.Time
+}
+
+func (e *CreationLimitError) Error() string {
+	return fmt.Sprintf("daily poll creation limit of %d exceeded", e.Limit)
+}
+
+func (e *CreationLimitError) Unwrap() error {
+	return ErrCreationLimitExceeded
+}
+
+// ValidationError describes why a field of a request was rejected.
+type ValidationError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidInput
+}
+
+// PasswordPolicyError lists every password rule a new password breaks.
+type PasswordPolicyError struct {
+	Violations []*ValidationError
+}
+
+func (e *PasswordPolicyError) Error() string {
+	reasons := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		reasons[i] = v.Reason
+	}
+	return fmt.Sprintf("%s: %s", ErrWeakPassword, strings.Join(reasons, "; "))
+}
+
+func (e *PasswordPolicyError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+type TransitionError struct {
+	From PollStatus
+	To   PollStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("poll cannot move from %s to %s", e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// VoteImportError reports a row of a vote import that was rejected.
+type VoteImportError struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+func (e *VoteImportError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+}
@@ -1,18 +1,151 @@
 package domain
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Poll struct {
-	ID        uuid.UUID `json:"id"`
-	Title     string    `json:"title"`
-	Options   []Option  `json:"options"`
-	Tags      []string  `json:"tags"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID        uuid.UUID  `json:"id"`
+	Title     string     `json:"title"`
+	Options   []Option   `json:"options"`
+	Tags      []string   `json:"tags"`
+	Protected bool       `json:"protected"`
+	Status    PollStatus `json:"status"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+
+	CreatedBy      *uuid.UUID `json:"createdBy,omitempty"`
+	OrganizationID *uuid.UUID `json:"organizationId,omitempty"`
+	Electorate     Electorate `json:"electorate"`
+
+	Kind     PollKind   `json:"kind"`
+	StartsAt *time.Time `json:"startsAt,omitempty"`
+	EndsAt   *time.Time `json:"endsAt,omitempty"`
+
+	PublicResults     bool              `json:"publicResults"`
+	ResultsVisibility ResultsVisibility `json:"resultsVisibility"`
+	VoteChange        VoteChangePolicy  `json:"voteChange"`
+	// VoteChangeCooldownSeconds is how long a voter must wait after
+	// casting or changing their vote before changing it again.
+	VoteChangeCooldownSeconds int `json:"voteChangeCooldownSeconds,omitempty"`
+	// OrganizationVotes designates the poll for official organization
+	// votes, counted apart from personal votes.
+	OrganizationVotes bool `json:"organizationVotes"`
+
+	// Anonymous polls never record where votes come from.
+	Anonymous bool `json:"anonymous"`
+	// AllowedCountries, when set, limits voting to these ISO 3166-1 alpha-2
+	// countries.
+	AllowedCountries []string `json:"allowedCountries,omitempty"`
+	// Language is the ISO 639-1 code of the language detected from the
+	// poll's text when it was created, empty when it could not be told.
+	Language string `json:"language,omitempty"`
+
+	AccessCodeHash string   `json:"-"`
+	EligibleEmails []string `json:"-"`
+	// Webhook is only set when the poll is created; it is never read back
+	// with the poll.
+	Webhook *PollWebhook `json:"-"`
+
+	Links *PollLinks `json:"links,omitempty"`
+
+	// PendingReview is set on a poll that was just held for moderator
+	// review instead of going live.
+	PendingReview bool `json:"pendingReview,omitempty"`
+}
+
+// PollWebhook is an endpoint of the creator's that receives the poll's results
+// once it closes, signed with Secret when it is set.
+type PollWebhook struct {
+	PollID   uuid.UUID
+	URL      string
+	Secret   string
+	Attempts int
+}
+
+// DefaultOption returns the index of the poll's default option, if it has
+// one.
+func (p *Poll) DefaultOption() (int, bool) {
+	for i, option := range p.Options {
+		if option.Default {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// AllowsCountry reports whether voters located in country may vote. An
+// unknown country is only allowed on polls without a restriction.
+func (p *Poll) AllowsCountry(country string) bool {
+	if len(p.AllowedCountries) == 0 {
+		return true
+	}
+	for _, allowed := range p.AllowedCountries {
+		if allowed == country {
+			return true
+		}
+	}
+	return false
+}
+
+type VoteChangePolicy string
+
+// MaxVoteChangeCooldown is the longest cooldown a poll may put between
+// vote changes.
+const MaxVoteChangeCooldown = 7 * 24 * time.Hour
+
+const (
+	VoteChangeAllowed    VoteChangePolicy = "allowed"
+	VoteChangeDisallowed VoteChangePolicy = "disallowed"
+	VoteChangeUntilClose VoteChangePolicy = "until_close"
+)
+
+// Valid reports whether v is a known policy.
+func (v VoteChangePolicy) Valid() bool {
+	return v == VoteChangeAllowed || v == VoteChangeDisallowed || v == VoteChangeUntilClose
+}
+
+// ResultsVisibility decides who may see a poll's results before it closes.
+// The creator and collaborators with stats access always may.
+type ResultsVisibility string
+
+const (
+	ResultsAlways     ResultsVisibility = "always"
+	ResultsAfterVote  ResultsVisibility = "after_vote"
+	ResultsAfterClose ResultsVisibility = "after_close"
+)
+
+// Valid reports whether v is a known visibility.
+func (v ResultsVisibility) Valid() bool {
+	return v == ResultsAlways || v == ResultsAfterVote || v == ResultsAfterClose
+}
+
+type PollKind string
+
+const (
+	PollKindStandard PollKind = "standard"
+	PollKindElection PollKind = "election"
+)
+
+type Electorate string
+
+const (
+	ElectorateOpen         Electorate = "open"
+	ElectorateOrganization Electorate = "organization"
+	ElectorateList         Electorate = "list"
+)
+
+// Restricted reports whether only part of the user base may vote.
+func (e Electorate) Restricted() bool {
+	return e == ElectorateOrganization || e == ElectorateList
 }
 
 type Option struct {
@@ -21,32 +154,127 @@ type Option struct {
 	OptionText  string    `json:"optionText"`
 	OptionIndex int       `json:"optionIndex"`
 	CreatedAt   time.Time `json:"createdAt"`
+
+	ImageKey string `json:"imageKey,omitempty"`
+	ImageURL string `json:"imageUrl,omitempty"`
+	// AltText describes the option's image for screen readers; Emoji is
+	// shown next to the option text by clients.
+	AltText string `json:"altText,omitempty"`
+	Emoji   string `json:"emoji,omitempty"`
+	// Default marks the option a vote without one is recorded for. Only
+	// "tap to vote" polls have one.
+	Default bool `json:"default,omitempty"`
+}
+
+// PollLinks are the URLs of a poll and the actions on it. Share is only
+// set when the poll's results are public.
+type PollLinks struct {
+	Self  string `json:"self"`
+	Stats string `json:"stats"`
+	Vote  string `json:"vote"`
+	Skip  string `json:"skip"`
+	Share string `json:"share,omitempty"`
 }
 
 type Vote struct {
-	ID         uuid.UUID `json:"id"`
-	PollID     uuid.UUID `json:"pollId"`
-	UserID     uuid.UUID `json:"userId"`
-	OptionID   uuid.UUID `json:"optionId"`
-	CreatedAt  time.Time `json:"createdAt"`
-	PollTitle  string    `json:"pollTitle,omitempty"`
-	OptionText string    `json:"optionText,omitempty"`
+	ID         uuid.UUID  `json:"id"`
+	PollID     uuid.UUID  `json:"pollId"`
+	UserID     uuid.UUID  `json:"userId"`
+	OptionID   uuid.UUID  `json:"optionId"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	PollTitle  string     `json:"pollTitle,omitempty"`
+	OptionText string     `json:"optionText,omitempty"`
+	DeletedAt  *time.Time `json:"deletedAt,omitempty"`
+
+	OptionIndex  int        `json:"optionIndex"`
+	PollStatus   PollStatus `json:"pollStatus,omitempty"`
+	PollStartsAt *time.Time `json:"pollStartsAt,omitempty"`
+	PollEndsAt   *time.Time `json:"pollEndsAt,omitempty"`
+
+	Country string `json:"country,omitempty"`
+	Region  string `json:"region,omitempty"`
+}
+
+// VoteReceipt confirms a cast vote to the voter.
+type VoteReceipt struct {
+	VoteID      uuid.UUID `json:"voteId"`
+	PollID      uuid.UUID `json:"pollId"`
+	PollTitle   string    `json:"pollTitle"`
+	OptionID    uuid.UUID `json:"optionId"`
+	OptionIndex int       `json:"optionIndex"`
+	OptionText  string    `json:"optionText"`
+	CreatedAt   time.Time `json:"createdAt"`
+	// Signature is a hex HMAC-SHA256 of ReceiptPayload, set when vote
+	// receipts are signed.
+	Signature string `json:"signature,omitempty"`
+}
+
+// ReceiptPayload is what a vote receipt's signature covers. The time is
+// taken to the microsecond, the precision votes are stored with.
+func ReceiptPayload(voteID, pollID, optionID uuid.UUID, createdAt time.Time) []byte {
+	return []byte(fmt.Sprintf("%s|%s|%s|%d", voteID, pollID, optionID, createdAt.UnixMicro()))
+}
+
+// VoteVerification tells whether a receipt matches the vote as it is
+// recorded. A vote changed since the receipt was issued no longer matches.
+// Recorded never carries a signature.
+type VoteVerification struct {
+	VoteID   uuid.UUID    `json:"voteId"`
+	Valid    bool         `json:"valid"`
+	Recorded *VoteReceipt `json:"recorded"`
+}
+
+func (v *Vote) Receipt() *VoteReceipt {
+	return &VoteReceipt{
+		VoteID:      v.ID,
+		PollID:      v.PollID,
+		PollTitle:   v.PollTitle,
+		OptionID:    v.OptionID,
+		OptionIndex: v.OptionIndex,
+		OptionText:  v.OptionText,
+		CreatedAt:   v.CreatedAt,
+	}
 }
 
 type VoteResponse struct {
-	ID         uuid.UUID `json:"id"`
-	PollID     uuid.UUID `json:"pollId"`
-	OptionID   uuid.UUID `json:"optionId"`
-	CreatedAt  time.Time `json:"createdAt"`
-	PollTitle  string    `json:"pollTitle,omitempty"`
-	OptionText string    `json:"optionText,omitempty"`
+	ID         uuid.UUID  `json:"id"`
+	PollID     uuid.UUID  `json:"pollId"`
+	OptionID   uuid.UUID  `json:"optionId"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	PollTitle  string     `json:"pollTitle,omitempty"`
+	OptionText string     `json:"optionText,omitempty"`
+	DeletedAt  *time.Time `json:"deletedAt,omitempty"`
+
+	OptionIndex int        `json:"optionIndex"`
+	PollStatus  PollStatus `json:"pollStatus"`
+	PollOpen    bool       `json:"pollOpen"`
+	PollEndsAt  *time.Time `json:"pollEndsAt,omitempty"`
+}
+
+// SkipReason says why a user passed on a poll. It is optional, so skips
+// without one have an empty reason.
+type SkipReason string
+
+const (
+	SkipNotInterested SkipReason = "not_interested"
+	SkipSeenBefore    SkipReason = "seen_before"
+	SkipOffensive     SkipReason = "offensive"
+)
+
+func (r SkipReason) Valid() bool {
+	switch r {
+	case SkipNotInterested, SkipSeenBefore, SkipOffensive:
+		return true
+	}
+	return false
 }
 
 type Skip struct {
-	ID        uuid.UUID `json:"id"`
-	PollID    uuid.UUID `json:"pollId"`
-	UserID    uuid.UUID `json:"userId"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID        uuid.UUID  `json:"id"`
+	PollID    uuid.UUID  `json:"pollId"`
+	UserID    uuid.UUID  `json:"userId"`
+	Reason    SkipReason `json:"reason,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
 }
 
 type UserDailyVotes struct {
@@ -59,49 +287,333 @@ type UserDailyVotes struct {
 }
 
 type PollStats struct {
-	PollID uuid.UUID     `json:"pollId"`
-	Votes  []OptionStats `json:"votes"`
+	PollID  uuid.UUID     `json:"pollId"`
+	Votes   []OptionStats `json:"votes"`
+	Turnout *Turnout      `json:"turnout,omitempty"`
+	// TotalVotes sums Votes; organization votes are not included.
+	TotalVotes int `json:"totalVotes"`
+	// MyOption is the option the requester voted for, if they are signed
+	// in and voted. It is never cached.
+	MyOption string `json:"myOption,omitempty"`
+	// OrganizationVotes counts the official organization votes, which are
+	// not part of Votes. It is only set on polls that accept them.
+	OrganizationVotes []OptionStats `json:"organizationVotes,omitempty"`
+
+	// ComputedAt is when the counts were read from the votes table. Entries
+	// cached before it was recorded have the zero time.
+	ComputedAt time.Time `json:"computedAt"`
+
+	// Results is set when the stats come from a closed poll's snapshot.
+	Results *PollResultSnapshot `json:"results,omitempty"`
+}
+
+// StatsUpdate answers a wait for stats changes. Stats is only set when the
+// version moved past the one the client already had.
+type StatsUpdate struct {
+	Version int64      `json:"version"`
+	Changed bool       `json:"changed"`
+	Stats   *PollStats `json:"stats,omitempty"`
+}
+
+// MinStatsMaxAge is the smallest positive max age honoured; smaller values
+// are rounded up to it. Only a zero max age skips the cache outright.
+const MinStatsMaxAge = 5 * time.Second
+
+// StatsCacheTTL is how long computed stats stay cached.
+const StatsCacheTTL = 5 * time.Minute
+
+// StatsQuery controls how fresh GetPollStats results must be. A nil MaxAge
+// accepts whatever is cached; a zero MaxAge bypasses the cache and is only
+// allowed for users with stats access to the poll.
+type StatsQuery struct {
+	MaxAge  *time.Duration
+	ActorID uuid.UUID
+}
+
+type Turnout struct {
+	Voted    int `json:"voted"`
+	Eligible int `json:"eligible"`
 }
 
 type OptionStats struct {
 	Option string `json:"option"`
 	Count  int    `json:"count"`
+	// Percentage is the option's share of the poll's total votes. Only the
+	// stats served to clients fill it in; signed election tallies leave it
+	// out so their format stays the same.
+	Percentage float64 `json:"percentage,omitempty"`
+}
+
+// Sources of a StatsDiscrepancy.
+const (
+	StatsSourceCache       = "stats_cache"
+	StatsSourceDailyRollup = "daily_rollup"
+)
+
+// StatsDiscrepancy is a stored vote count that disagreed with the votes
+// table during a recount. Day is only set for daily rollup rows.
+type StatsDiscrepancy struct {
+	Source   string     `json:"source"`
+	Option   string     `json:"option"`
+	Day      *time.Time `json:"day,omitempty"`
+	Expected int        `json:"expected"`
+	Found    int        `json:"found"`
+}
+
+// PollRecount is the result of rebuilding a poll's stats from the votes
+// table, listing what was wrong before the repair.
+type PollRecount struct {
+	PollID        uuid.UUID          `json:"pollId"`
+	Stats         *PollStats         `json:"stats"`
+	Discrepancies []StatsDiscrepancy `json:"discrepancies"`
+	RecountedAt   time.Time          `json:"recountedAt"`
+}
+
+type TrendingPoll struct {
+	PollID    uuid.UUID `json:"pollId"`
+	Title     string    `json:"title"`
+	VoteCount int       `json:"voteCount"`
+}
+
+// AnalyticsDelta is what one event adds to the analytics projections.
+// EventKey identifies the event so a redelivered event is applied once.
+type AnalyticsDelta struct {
+	EventKey     string
+	PollID       uuid.UUID
+	UserID       uuid.UUID
+	Tags         []string
+	At           time.Time
+	Votes        int
+	Skips        int
+	PollsCreated int
+}
+
+// OutboxEvent is an event that couldn't be published right away and waits
+// in the outbox to be relayed to the broker.
+type OutboxEvent struct {
+	ID        int64
+	Type      string
+	Payload   []byte
+	CreatedAt time.Time
+}
+
+// VoteBucket counts the votes cast in the hour or day starting at Start.
+type VoteBucket struct {
+	Start time.Time `json:"start"`
+	Votes int       `json:"votes"`
+}
+
+// UserActivity is what a user did on one day.
+type UserActivity struct {
+	Day          time.Time `json:"day"`
+	Votes        int       `json:"votes"`
+	Skips        int       `json:"skips"`
+	PollsCreated int       `json:"pollsCreated"`
+}
+
+type QuotaAction string
+
+const (
+	QuotaPollsCreated QuotaAction = "polls_created"
+	QuotaVotesCast    QuotaAction = "votes_cast"
+)
+
+type QuotaPeriod string
+
+const (
+	QuotaDaily   QuotaPeriod = "daily"
+	QuotaMonthly QuotaPeriod = "monthly"
+)
+
+type Quota struct {
+	Action QuotaAction `json:"action"`
+	Period QuotaPeriod `json:"period"`
+	Limit  int         `json:"limit"`
+}
+
+type QuotaUsage struct {
+	Action  QuotaAction `json:"action"`
+	Period  QuotaPeriod `json:"period"`
+	Limit   int         `json:"limit"`
+	Used    int         `json:"used"`
+	ResetAt time.Time   `json:"resetAt"`
+}
+
+func (u QuotaUsage) Remaining() int {
+	if u.Used >= u.Limit {
+		return 0
+	}
+	return u.Limit - u.Used
 }
 
 type CreatePollRequest struct {
-	Title   string   `json:"title" binding:"required"`
-	Options []string `json:"options" binding:"required,min=2"`
-	Tags    []string `json:"tags" binding:"required,min=1"`
+	Title      string   `json:"title" binding:"required"`
+	Options    []string `json:"options" binding:"required,min=2"`
+	Tags       []string `json:"tags" binding:"required,min=1"`
+	AccessCode string   `json:"accessCode,omitempty"`
+	// OptionEmojis are matched to Options by index; blank entries leave an
+	// option without one.
+	OptionEmojis []string `json:"optionEmojis,omitempty"`
+	// DefaultOption is the index of the option votes naming none are
+	// recorded for, making the poll "tap to vote".
+	DefaultOption *int `json:"defaultOption,omitempty"`
+
+	CreatorID      uuid.UUID  `json:"-"`
+	OrganizationID *uuid.UUID `json:"organizationId,omitempty"`
+	EligibleEmails []string   `json:"eligibleEmails,omitempty"`
+
+	Kind     PollKind   `json:"kind,omitempty"`
+	StartsAt *time.Time `json:"startsAt,omitempty"`
+	EndsAt   *time.Time `json:"endsAt,omitempty"`
+
+	PublicResults     bool              `json:"publicResults,omitempty"`
+	ResultsVisibility ResultsVisibility `json:"resultsVisibility,omitempty"`
+	VoteChange        VoteChangePolicy  `json:"voteChange,omitempty"`
+	OrganizationVotes bool              `json:"organizationVotes,omitempty"`
+	Draft             bool              `json:"draft,omitempty"`
+
+	VoteChangeCooldownSeconds int `json:"voteChangeCooldownSeconds,omitempty"`
+
+	Anonymous        bool     `json:"anonymous,omitempty"`
+	AllowedCountries []string `json:"allowedCountries,omitempty"`
+
+	Webhook *PollWebhookRequest `json:"webhook,omitempty"`
 }
 
+type PollWebhookRequest struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret,omitempty"`
+}
+
+// VoteRequest picks the option by OptionID, which survives the options
+// being reordered, or by OptionIndex when OptionID is unset.
 type VoteRequest struct {
-	UserID      uuid.UUID `json:"userId" binding:"required"`
-	OptionIndex int       `json:"optionIndex" binding:"required,min=0"`
+	// UserID always comes from the authenticated session, never from the
+	// request body.
+	UserID      uuid.UUID  `json:"-"`
+	OptionID    *uuid.UUID `json:"optionId,omitempty"`
+	OptionIndex int        `json:"optionIndex" binding:"min=0"`
+	AccessCode  string     `json:"-"`
+	// UseDefault votes for the poll's default option instead of OptionID
+	// or OptionIndex.
+	UseDefault bool `json:"-"`
+	// Location is resolved from the client address when GeoIP is enabled.
+	Location *GeoLocation `json:"-"`
+}
+
+// GeoLocation is a coarse location: an ISO 3166-1 alpha-2 country and,
+// when known, the ISO 3166-2 subdivision code within it.
+type GeoLocation struct {
+	Country string `json:"country"`
+	Region  string `json:"region,omitempty"`
+}
+
+type CountryStat struct {
+	Country string       `json:"country"`
+	Votes   int          `json:"votes"`
+	Regions []RegionStat `json:"regions,omitempty"`
+}
+
+type RegionStat struct {
+	Region string `json:"region"`
+	Votes  int    `json:"votes"`
 }
 
 type SkipRequest struct {
-	UserID uuid.UUID `json:"userId" binding:"required"`
+	// UserID comes from the authenticated session, as on VoteRequest.
+	UserID uuid.UUID  `json:"-"`
+	Reason SkipReason `json:"reason"`
 }
 
 type FeedQuery struct {
 	Tag    string    `form:"tag"`
 	Page   int       `form:"page,default=1" binding:"min=1"`
 	Limit  int       `form:"limit,default=10" binding:"min=1,max=100"`
-	UserID uuid.UUID `form:"userId" binding:"required"`
+	UserID uuid.UUID `form:"-"`
+
+	// After switches the feed to keyset pagination: only polls after this
+	// cursor are returned and Page is ignored.
+	After *FeedCursor `form:"-"`
+	Total FeedTotal   `form:"-"`
+
+	// Languages limits the feed to polls in these languages and to polls
+	// whose language is unknown. When it is empty the user's preferred
+	// languages apply, unless AllLanguages is set.
+	Languages    []string `form:"-"`
+	AllLanguages bool     `form:"-"`
 }
 
-type PollFeedRequest struct {
-	UserID uuid.UUID `json:"userId" binding:"required"`
-	Tag    string    `json:"tag"`
-	Page   int       `json:"page" binding:"min=1"`
-	Limit  int       `json:"limit" binding:"min=1,max=50"`
+// FeedTotal controls how the feed's total is computed. Counting the feed
+// exactly runs the whole anti-joined query, which gets expensive for users
+// with long histories.
+type FeedTotal string
+
+const (
+	FeedTotalExact    FeedTotal = ""
+	FeedTotalEstimate FeedTotal = "estimate"
+	FeedTotalNone     FeedTotal = "none"
+)
+
+// FeedCursor identifies the last poll of a feed page. The feed is ordered by
+// creation time and then ID, both descending.
+type FeedCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (c FeedCursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeFeedCursor parses a cursor produced by Encode.
+func DecodeFeedCursor(s string) (*FeedCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidInput)
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidInput)
+	}
+	cursor := &FeedCursor{}
+	if cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidInput)
+	}
+	if cursor.ID, err = uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidInput)
+	}
+	return cursor, nil
 }
 
 type PollFeedResponse struct {
-	Polls []Poll `json:"polls"`
-	Total int    `json:"total"`
-	Page  int    `json:"page"`
-	Limit int    `json:"limit"`
+	Polls          []Poll `json:"polls"`
+	Total          int    `json:"total"`
+	TotalEstimated bool   `json:"totalEstimated,omitempty"`
+	Page           int    `json:"page"`
+	Limit          int    `json:"limit"`
+	NextCursor     string `json:"nextCursor,omitempty"`
+}
+
+type PollSearchQuery struct {
+	Query string   `form:"q"`
+	Tags  []string `form:"tag"`
+	Page  int      `form:"page,default=1" binding:"min=1"`
+	Limit int      `form:"limit,default=10" binding:"min=1,max=100"`
+}
+
+// TagFacet counts the polls matching a search that carry Tag.
+type TagFacet struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+type PollSearchResult struct {
+	Polls  []Poll     `json:"polls"`
+	Total  int        `json:"total"`
+	Facets []TagFacet `json:"facets"`
+	Page   int        `json:"page"`
+	Limit  int        `json:"limit"`
 }
 
 type ErrorResponse struct {
@@ -115,12 +627,165 @@ type User struct {
 	Password  string    `json:"-"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+
+	AvatarKey string `json:"-"`
+	AvatarURL string `json:"avatarUrl,omitempty"`
+
+	// Standing is never shown to the user, so a shadow ban goes unnoticed.
+	Standing UserStanding `json:"-"`
+	Role     UserRole     `json:"role,omitempty"`
+
+	EmailVerified bool `json:"emailVerified"`
+}
+
+// UserEvent records something done to an account, for the audit stream:
+// a registration, login, password change or deletion. ActorID is set when
+// someone other than the user, such as an admin, did it.
+type UserEvent struct {
+	UserID     uuid.UUID  `json:"userId"`
+	Username   string     `json:"username,omitempty"`
+	Email      string     `json:"email,omitempty"`
+	ActorID    *uuid.UUID `json:"actorId,omitempty"`
+	IP         string     `json:"ip,omitempty"`
+	UserAgent  string     `json:"userAgent,omitempty"`
+	OccurredAt time.Time  `json:"occurredAt"`
+}
+
+// UserStanding says what a user may do. Banned users can still sign in and
+// read, but every write is rejected. Shadow-banned users' writes succeed,
+// but their polls and votes are hidden from everyone else.
+type UserStanding string
+
+const (
+	StandingActive       UserStanding = "active"
+	StandingBanned       UserStanding = "banned"
+	StandingShadowBanned UserStanding = "shadow_banned"
+)
+
+func (s UserStanding) Valid() bool {
+	switch s {
+	case StandingActive, StandingBanned, StandingShadowBanned:
+		return true
+	}
+	return false
+}
+
+// UserRole says which privileged endpoints a user may call. Moderators
+// curate tags and resolve flags; admins can do anything.
+type UserRole string
+
+const (
+	RoleUser      UserRole = "user"
+	RoleModerator UserRole = "moderator"
+	RoleAdmin     UserRole = "admin"
+)
+
+func (r UserRole) Valid() bool {
+	switch r {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// UserSearchQuery filters the admin user list. Query matches usernames and
+// emails by substring, ignoring case.
+type UserSearchQuery struct {
+	Query    string       `form:"q"`
+	Role     UserRole     `form:"role"`
+	Standing UserStanding `form:"standing"`
+	Page     int          `form:"page,default=1" binding:"min=1"`
+	Limit    int          `form:"limit,default=10" binding:"min=1,max=100"`
+}
+
+// AdminUser is a user as admins see it, standing included.
+type AdminUser struct {
+	ID        uuid.UUID    `json:"id"`
+	Username  string       `json:"username"`
+	Email     string       `json:"email"`
+	Role      UserRole     `json:"role"`
+	Standing  UserStanding `json:"standing"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+type UserList struct {
+	Users []AdminUser `json:"users"`
+	Total int         `json:"total"`
+	Page  int         `json:"page"`
+	Limit int         `json:"limit"`
+}
+
+// PlatformStats counts users, polls and votes across the whole platform.
+// Deleted polls and withdrawn votes are left out.
+type PlatformStats struct {
+	Users        int64     `json:"users"`
+	Polls        int64     `json:"polls"`
+	Votes        int64     `json:"votes"`
+	VotesLast24h int64     `json:"votesLast24h"`
+	ComputedAt   time.Time `json:"computedAt"`
+}
+
+type UserStandingRequest struct {
+	Standing UserStanding `json:"standing" binding:"required"`
+}
+
+// MediaUpload is an image uploaded through the API. Size and ContentType
+// are as declared by the client and checked against the content.
+type MediaUpload struct {
+	Body        io.Reader
+	Size        int64
+	ContentType string
+}
+
+const (
+	UploadTargetAvatar      = "avatar"
+	UploadTargetOptionImage = "option_image"
+)
+
+// UploadTarget names what a directly uploaded object is for: the caller's
+// avatar, or the image of one option of a poll.
+type UploadTarget struct {
+	Target      string     `json:"target" binding:"required"`
+	PollID      *uuid.UUID `json:"pollId,omitempty"`
+	OptionIndex *int       `json:"optionIndex,omitempty"`
+}
+
+type SignUploadRequest struct {
+	UploadTarget
+	ContentType string `json:"contentType" binding:"required"`
+	Size        int64  `json:"size" binding:"required"`
+}
+
+// SignedUpload tells the client where and how to upload an object straight
+// to storage. The request must use Method and Headers and stay within the
+// constraints.
+type SignedUpload struct {
+	Key          string            `json:"key"`
+	URL          string            `json:"url"`
+	Method       string            `json:"method"`
+	Headers      map[string]string `json:"headers"`
+	ExpiresAt    time.Time         `json:"expiresAt"`
+	MaxSize      int64             `json:"maxSize"`
+	ContentTypes []string          `json:"contentTypes"`
+}
+
+type ConfirmUploadRequest struct {
+	UploadTarget
+	Key string `json:"key" binding:"required"`
+}
+
+// UploadConfirmation is returned once an uploaded object is attached. Poll
+// is set for option images.
+type UploadConfirmation struct {
+	Key  string `json:"key"`
+	URL  string `json:"url"`
+	Poll *Poll  `json:"poll,omitempty"`
 }
 
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 }
 
 type LoginRequest struct {
@@ -128,6 +793,100 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// RefreshTokenRequest carries a refresh token to exchange or revoke.
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// RefreshToken is a long-lived credential that is exchanged for new access
+// tokens. Only a hash of the token is stored.
+type RefreshToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// EmailVerificationToken proves a user received mail at their address. Only
+// a hash of the token is stored.
+type EmailVerificationToken struct {
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// EmailVerification asks the mailer to send a user their verification link.
+// It carries the raw token, so it goes only to the mail queue, never to the
+// audit stream.
+type EmailVerification struct {
+	UserID    uuid.UUID `json:"userId"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type EmailChangeStatus string
+
+const (
+	EmailChangePending      EmailChangeStatus = "pending"
+	EmailChangeOldConfirmed EmailChangeStatus = "old_confirmed"
+	EmailChangeNewConfirmed EmailChangeStatus = "new_confirmed"
+	EmailChangeCompleted    EmailChangeStatus = "completed"
+	EmailChangeCancelled    EmailChangeStatus = "cancelled"
+)
+
+// Open reports whether the change still waits on a confirmation.
+func (s EmailChangeStatus) Open() bool {
+	return s == EmailChangePending || s == EmailChangeOldConfirmed || s == EmailChangeNewConfirmed
+}
+
+// EmailChangeSide is which of the two addresses of an email change a
+// confirmation link was sent to.
+type EmailChangeSide string
+
+const (
+	EmailChangeOld EmailChangeSide = "old"
+	EmailChangeNew EmailChangeSide = "new"
+)
+
+// EmailChange moves an account to a new address once links sent to both
+// the old and the new address have been opened. Only hashes of the two
+// tokens are stored.
+type EmailChange struct {
+	ID           uuid.UUID         `json:"id"`
+	UserID       uuid.UUID         `json:"userId"`
+	OldEmail     string            `json:"oldEmail"`
+	NewEmail     string            `json:"newEmail"`
+	Status       EmailChangeStatus `json:"status"`
+	OldTokenHash string            `json:"-"`
+	NewTokenHash string            `json:"-"`
+	ExpiresAt    time.Time         `json:"expiresAt"`
+	CreatedAt    time.Time         `json:"createdAt"`
+	UpdatedAt    time.Time         `json:"updatedAt"`
+}
+
+type EmailChangeRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// EmailChangeConfirmation asks the mailer to send one of the two links
+// confirming an email change to Email, which is OldEmail or NewEmail
+// depending on Side. Like EmailVerification it carries the raw token.
+type EmailChangeConfirmation struct {
+	UserID    uuid.UUID       `json:"userId"`
+	Username  string          `json:"username"`
+	Email     string          `json:"email"`
+	Side      EmailChangeSide `json:"side"`
+	OldEmail  string          `json:"oldEmail"`
+	NewEmail  string          `json:"newEmail"`
+	Token     string          `json:"token"`
+	ExpiresAt time.Time       `json:"expiresAt"`
+}
+
 type LoginResponse struct {
 	Token string   `json:"token"`
 	User  UserInfo `json:"user"`
@@ -147,9 +906,88 @@ type UserVotesResponse struct {
 	Limit int            `json:"limit"`
 }
 
+// VoteFilter narrows a user's vote history. From is inclusive and To is
+// exclusive; deleted votes are only returned when IncludeDeleted is set.
+type VoteFilter struct {
+	From           *time.Time
+	To             *time.Time
+	IncludeDeleted bool
+}
+
+// VoteKey identifies a vote's place in a poll's votes, which are ordered by
+// creation time and then ID.
+type VoteKey struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// VoteExportQuery says who is exporting a poll's votes. Admins may export
+// any poll; everyone else only the polls they created.
+type VoteExportQuery struct {
+	ActorID uuid.UUID
+	Admin   bool
+}
+
+// ExportedVote is one row of a poll's vote export. VoterID is left out for
+// anonymous polls.
+type ExportedVote struct {
+	VoteID      uuid.UUID  `json:"voteId"`
+	PollID      uuid.UUID  `json:"pollId"`
+	VoterID     *uuid.UUID `json:"voterId,omitempty"`
+	OptionID    uuid.UUID  `json:"optionId"`
+	OptionIndex int        `json:"optionIndex"`
+	OptionText  string     `json:"optionText"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// ImportedVote is a historical vote from an external system. The fields
+// match a poll vote export, so an export can be imported elsewhere; the
+// option is picked by optionId if set, otherwise by optionIndex.
+type ImportedVote struct {
+	PollID      uuid.UUID  `json:"pollId"`
+	VoterID     uuid.UUID  `json:"voterId"`
+	OptionID    *uuid.UUID `json:"optionId,omitempty"`
+	OptionIndex *int       `json:"optionIndex,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+
+	// Line is where the vote was read from, for error reports.
+	Line int `json:"-"`
+}
+
+// VoteImportResult summarizes an import. Errors lists the first
+// MaxVoteImportErrors rejected rows; Rejected counts all of them.
+type VoteImportResult struct {
+	Imported   int               `json:"imported"`
+	Duplicates int               `json:"duplicates"`
+	Rejected   int               `json:"rejected"`
+	Errors     []VoteImportError `json:"errors"`
+}
+
+const MaxVoteImportErrors = 100
+
+// VoteImportBatch is what storing one batch of imported votes did. Votes
+// whose voter already voted on the poll, or does not exist, are left out and
+// listed by vote ID.
+type VoteImportBatch struct {
+	Imported      int
+	Duplicates    []uuid.UUID
+	UnknownVoters []uuid.UUID
+}
+
+// UpdateVoteRequest picks the new option the same way as VoteRequest.
 type UpdateVoteRequest struct {
-	UserID      uuid.UUID `json:"userId" binding:"required"`
-	OptionIndex int       `json:"optionIndex" binding:"required,min=0"`
+	UserID      uuid.UUID  `json:"-"`
+	OptionID    *uuid.UUID `json:"optionId,omitempty"`
+	OptionIndex int        `json:"optionIndex" binding:"min=0"`
+}
+
+// VoteAllowance is how many more votes a user may cast within the current
+// DailyVoteWindow. ResetAt is when the oldest counted vote leaves the window.
+type VoteAllowance struct {
+	Limit     int        `json:"limit"`
+	Used      int        `json:"used"`
+	Remaining int        `json:"remaining"`
+	ResetAt   *time.Time `json:"resetAt,omitempty"`
 }
 
 const (
@@ -157,4 +995,608 @@ const (
 	MaxPageSize   = 100
 	DefaultPage   = 1
 	DefaultLimit  = 10
+
+	// DailyVoteWindow is the rolling period MaxDailyVotes applies to.
+	DailyVoteWindow = 24 * time.Hour
+
+	MaxPreferenceValues      = 100
+	MaxPreferenceValueLength = 100
+
+	MaxTagLength = 50
+
+	MaxAltTextLength = 250
+	MaxEmojiLength   = 16
+
+	MaxSearchQueryLength = 200
+	// MaxTagFacets caps the number of tag facets returned with search results.
+	MaxTagFacets = 10
+
+	// MinGeoStatsVotes is the smallest number of votes from one country or
+	// region that per-country stats report, so they cannot single out voters.
+	MinGeoStatsVotes = 5
+)
+
+type OrganizationRole string
+
+const (
+	OrganizationAdmin  OrganizationRole = "admin"
+	OrganizationMember OrganizationRole = "member"
+)
+
+type Organization struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Membership struct {
+	OrganizationID uuid.UUID        `json:"organizationId"`
+	UserID         uuid.UUID        `json:"userId"`
+	Role           OrganizationRole `json:"role"`
+	CreatedAt      time.Time        `json:"createdAt"`
+}
+
+type CreateOrganizationRequest struct {
+	Name    string    `json:"name" binding:"required"`
+	OwnerID uuid.UUID `json:"-"`
+}
+
+type AddMemberRequest struct {
+	Email   string           `json:"email" binding:"required,email"`
+	Role    OrganizationRole `json:"role"`
+	ActorID uuid.UUID        `json:"-"`
+}
+
+// OrganizationVote is an organization's official vote on a poll. CastBy is
+// the admin who cast or last changed it.
+type OrganizationVote struct {
+	ID             uuid.UUID  `json:"id"`
+	PollID         uuid.UUID  `json:"pollId"`
+	OrganizationID uuid.UUID  `json:"organizationId"`
+	OptionID       uuid.UUID  `json:"optionId"`
+	CastBy         *uuid.UUID `json:"castBy,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+
+	OptionText string `json:"optionText,omitempty"`
+}
+
+// OrganizationVoteRequest picks the option like VoteRequest. ActorID must
+// be an admin of the organization.
+type OrganizationVoteRequest struct {
+	OrganizationID uuid.UUID  `json:"organizationId"`
+	OptionID       *uuid.UUID `json:"optionId,omitempty"`
+	OptionIndex    int        `json:"optionIndex"`
+	ActorID        uuid.UUID  `json:"-"`
+	AccessCode     string     `json:"-"`
+	// Location is resolved from the client address when GeoIP is enabled.
+	Location *GeoLocation `json:"-"`
+}
+
+type ElectionTally struct {
+	PollID      uuid.UUID     `json:"pollId"`
+	Title       string        `json:"title"`
+	Options     []OptionStats `json:"options"`
+	BallotsCast int           `json:"ballotsCast"`
+	Eligible    int           `json:"eligible"`
+	ClosedAt    time.Time     `json:"closedAt"`
+}
+
+// ElectionCertification is the signed tally of a closed election. Tally holds
+// the exact bytes that were signed so the export can be verified as-is.
+type ElectionCertification struct {
+	PollID      uuid.UUID       `json:"pollId"`
+	Tally       json.RawMessage `json:"tally"`
+	Algorithm   string          `json:"algorithm"`
+	Signature   string          `json:"signature"`
+	CertifiedAt time.Time       `json:"certifiedAt"`
+}
+
+// PollResults is the unauthenticated view of a poll's results. Final is set
+// once the poll has closed and the results can no longer change.
+type PollResults struct {
+	PollID   uuid.UUID     `json:"pollId"`
+	Title    string        `json:"title"`
+	Tags     []string      `json:"tags"`
+	Kind     PollKind      `json:"kind"`
+	StartsAt *time.Time    `json:"startsAt,omitempty"`
+	EndsAt   *time.Time    `json:"endsAt,omitempty"`
+	Final    bool          `json:"final"`
+	Votes    []OptionStats `json:"votes"`
+	Total    int           `json:"total"`
+	Turnout  *Turnout      `json:"turnout,omitempty"`
+}
+
+// PollPreview is what link unfurls show for a poll. Votes breaks the total
+// down by option only for polls with public results.
+type PollPreview struct {
+	PollID      uuid.UUID     `json:"pollId"`
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	Status      PollStatus    `json:"status"`
+	OptionCount int           `json:"optionCount"`
+	TotalVotes  int           `json:"totalVotes"`
+	Votes       []OptionStats `json:"votes,omitempty"`
+	URL         string        `json:"url"`
+	ImageURL    string        `json:"imageUrl"`
+}
+
+// PollPreviewCard records the rendered preview image stored for a poll and
+// the votes it was drawn from. Counts is empty when results are private, as
+// the card then shows none.
+type PollPreviewCard struct {
+	PollID      uuid.UUID
+	ImageKey    string
+	Counts      []int
+	OptionCount int
+	RenderedAt  time.Time
+}
+
+// PollPreviewImage is either a URL the stored card can be fetched from or,
+// without a media store, the rendered PNG itself.
+type PollPreviewImage struct {
+	URL string
+	PNG []byte
+}
+
+// PollResultSnapshot is the frozen outcome of a closed poll. Stats for closed
+// polls are served from it, so votes changed or deleted afterwards cannot
+// rewrite the recorded result.
+type PollResultSnapshot struct {
+	PollID  uuid.UUID      `json:"pollId"`
+	Options []OptionResult `json:"options"`
+	Total   int            `json:"total"`
+	// Winner is the option with the most votes. It is empty when nobody
+	// voted, or when the lead is tied and TieBreak is TieBreakReported.
+	Winner string `json:"winner,omitempty"`
+	// Tied lists the options sharing the lead, in poll order, and TieBreak
+	// the policy that was applied to them. Both are empty without a tie.
+	Tied      []string       `json:"tied,omitempty"`
+	TieBreak  TieBreakPolicy `json:"tieBreak,omitempty"`
+	Turnout   *Turnout       `json:"turnout,omitempty"`
+	ClosedAt  time.Time      `json:"closedAt"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+// CertifiedResults is what a result certificate vouches for: the poll as it
+// was defined and the outcome frozen when it closed.
+type CertifiedResults struct {
+	PollID         uuid.UUID      `json:"pollId"`
+	Title          string         `json:"title"`
+	Options        []string       `json:"options"`
+	Tags           []string       `json:"tags"`
+	Kind           PollKind       `json:"kind"`
+	CreatedBy      *uuid.UUID     `json:"createdBy,omitempty"`
+	OrganizationID *uuid.UUID     `json:"organizationId,omitempty"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	StartsAt       *time.Time     `json:"startsAt,omitempty"`
+	Tallies        []OptionResult `json:"tallies"`
+	Total          int            `json:"total"`
+	Winner         string         `json:"winner,omitempty"`
+	Tied           []string       `json:"tied,omitempty"`
+	TieBreak       TieBreakPolicy `json:"tieBreak,omitempty"`
+	Turnout        *Turnout       `json:"turnout,omitempty"`
+	ClosedAt       time.Time      `json:"closedAt"`
+}
+
+// ResultCertificate is a signed record of a closed poll's results that can
+// be archived and verified away from the platform. Payload is the base64 of
+// the exact bytes signed; Results is the same content, decoded for readers.
+type ResultCertificate struct {
+	PollID    uuid.UUID        `json:"pollId"`
+	Results   CertifiedResults `json:"results"`
+	Payload   string           `json:"payload"`
+	Algorithm string           `json:"algorithm"`
+	PublicKey string           `json:"publicKey"`
+	Signature string           `json:"signature"`
+	IssuedAt  time.Time        `json:"issuedAt"`
+}
+
+// ResultSigningKey is the public key result certificates are signed with,
+// base64 encoded.
+type ResultSigningKey struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"publicKey"`
+}
+
+// TieBreakPolicy decides the winner of a poll whose lead is tied.
+type TieBreakPolicy string
+
+const (
+	// TieBreakReported leaves a tie undecided and reports the tied options.
+	TieBreakReported TieBreakPolicy = "reported"
+	// TieBreakEarliestLead picks the tied option that reached the winning
+	// count first.
+	TieBreakEarliestLead TieBreakPolicy = "earliest_lead"
+	// TieBreakRandom draws one of the tied options with a seeded hash of the
+	// poll, so anyone holding the seed can reproduce the draw.
+	TieBreakRandom TieBreakPolicy = "random"
+)
+
+func (p TieBreakPolicy) Valid() bool {
+	return p == TieBreakReported || p == TieBreakEarliestLead || p == TieBreakRandom
+}
+
+// PollWinner is the outcome of a closed poll.
+type PollWinner struct {
+	PollID    uuid.UUID      `json:"pollId"`
+	Winner    string         `json:"winner,omitempty"`
+	Votes     int            `json:"votes"`
+	Total     int            `json:"total"`
+	Tied      []string       `json:"tied,omitempty"`
+	TieBreak  TieBreakPolicy `json:"tieBreak,omitempty"`
+	DecidedAt time.Time      `json:"decidedAt"`
+}
+
+type OptionResult struct {
+	Option     string  `json:"option"`
+	Count      int     `json:"count"`
+	Percentage float64 `json:"percentage"`
+}
+
+// Percentage returns count as a percentage of total, rounded to two
+// decimals. A total of zero gives zero.
+func Percentage(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(count)*10000/float64(total)) / 100
+}
+
+// NewPollResultSnapshot freezes stats as the result of a poll that closed at
+// closedAt. Percentages are rounded to two decimals. A tied lead is left
+// without a winner and listed in Tied for the caller to break.
+func NewPollResultSnapshot(stats *PollStats, closedAt, now time.Time) *PollResultSnapshot {
+	snapshot := &PollResultSnapshot{
+		PollID:    stats.PollID,
+		Options:   make([]OptionResult, len(stats.Votes)),
+		Turnout:   stats.Turnout,
+		ClosedAt:  closedAt,
+		CreatedAt: now,
+	}
+	for _, option := range stats.Votes {
+		snapshot.Total += option.Count
+	}
+
+	lead := 0
+	var leaders []string
+	for i, option := range stats.Votes {
+		snapshot.Options[i] = OptionResult{
+			Option:     option.Option,
+			Count:      option.Count,
+			Percentage: Percentage(option.Count, snapshot.Total),
+		}
+		switch {
+		case option.Count == 0:
+		case option.Count > lead:
+			lead = option.Count
+			leaders = []string{option.Option}
+		case option.Count == lead:
+			leaders = append(leaders, option.Option)
+		}
+	}
+	switch len(leaders) {
+	case 0:
+	case 1:
+		snapshot.Winner = leaders[0]
+	default:
+		snapshot.Tied = leaders
+	}
+	return snapshot
+}
+
+// Outcome returns the winner recorded in the snapshot.
+func (r *PollResultSnapshot) Outcome() *PollWinner {
+	winner := &PollWinner{
+		PollID:    r.PollID,
+		Winner:    r.Winner,
+		Total:     r.Total,
+		Tied:      r.Tied,
+		TieBreak:  r.TieBreak,
+		DecidedAt: r.CreatedAt,
+	}
+	leader := r.Winner
+	if len(r.Tied) > 0 {
+		leader = r.Tied[0]
+	}
+	for _, option := range r.Options {
+		if option.Option == leader {
+			winner.Votes = option.Count
+			break
+		}
+	}
+	return winner
+}
+
+// Stats returns the snapshot in the shape of live stats.
+func (r *PollResultSnapshot) Stats() *PollStats {
+	stats := &PollStats{
+		PollID:     r.PollID,
+		Votes:      make([]OptionStats, len(r.Options)),
+		Turnout:    r.Turnout,
+		ComputedAt: r.CreatedAt,
+		Results:    r,
+	}
+	for i, option := range r.Options {
+		stats.Votes[i] = OptionStats{Option: option.Option, Count: option.Count}
+	}
+	return stats
+}
+
+type UserPreferences struct {
+	FollowedTags  []string `json:"followedTags"`
+	MutedTags     []string `json:"mutedTags"`
+	MutedKeywords []string `json:"mutedKeywords"`
+	// Languages are the ISO 639-1 codes of the languages the user reads.
+	// When set, their feed leaves out polls detected in other languages.
+	Languages []string `json:"languages"`
+
+	// VoteReceipts opts the user into a notification whenever one of their
+	// votes is recorded, changed or deleted.
+	VoteReceipts bool `json:"voteReceipts"`
+}
+
+// Follows reports whether the poll carries one of the followed tags.
+func (p *UserPreferences) Follows(poll *Poll) bool {
+	for _, followed := range p.FollowedTags {
+		for _, tag := range poll.Tags {
+			if strings.EqualFold(tag, followed) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Mutes reports whether the poll has a muted tag or a muted keyword in its
+// title. Values are expected to be lower-cased.
+func (p *UserPreferences) Mutes(poll *Poll) bool {
+	for _, muted := range p.MutedTags {
+		for _, tag := range poll.Tags {
+			if strings.EqualFold(tag, muted) {
+				return true
+			}
+		}
+	}
+	title := strings.ToLower(poll.Title)
+	for _, keyword := range p.MutedKeywords {
+		if strings.Contains(title, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+type CollaboratorPermission string
+
+const (
+	CollaboratorEdit  CollaboratorPermission = "edit"
+	CollaboratorStats CollaboratorPermission = "stats"
+)
+
+// Allows reports whether p grants required; edit rights include stats.
+func (p CollaboratorPermission) Allows(required CollaboratorPermission) bool {
+	return p == required || p == CollaboratorEdit
+}
+
+type Collaborator struct {
+	PollID     uuid.UUID              `json:"pollId"`
+	UserID     uuid.UUID              `json:"userId"`
+	Permission CollaboratorPermission `json:"permission"`
+	InvitedBy  uuid.UUID              `json:"invitedBy"`
+	CreatedAt  time.Time              `json:"createdAt"`
+	PollTitle  string                 `json:"pollTitle,omitempty"`
+}
+
+type AddCollaboratorRequest struct {
+	Email      string                 `json:"email" binding:"required,email"`
+	Permission CollaboratorPermission `json:"permission"`
+	ActorID    uuid.UUID              `json:"-"`
+}
+
+// UpdateOptionRequest changes the accessibility metadata of one option. Nil
+// fields are left alone and empty strings clear them.
+type UpdateOptionRequest struct {
+	AltText *string   `json:"altText"`
+	Emoji   *string   `json:"emoji"`
+	ActorID uuid.UUID `json:"-"`
+}
+
+// ReorderOptionsRequest lists every option of a poll by ID in the order
+// they should be shown.
+type ReorderOptionsRequest struct {
+	OptionIDs []uuid.UUID `json:"optionIds" binding:"required"`
+	ActorID   uuid.UUID   `json:"-"`
+	Admin     bool        `json:"-"`
+}
+
+// UpdatePollTagsRequest adds and removes tags without touching the rest of
+// the poll.
+type UpdatePollTagsRequest struct {
+	Add     []string  `json:"add"`
+	Remove  []string  `json:"remove"`
+	ActorID uuid.UUID `json:"-"`
+	Admin   bool      `json:"-"`
+}
+
+// UpdatePollRequest changes a poll's title or tags. Admin is set for users in
+// moderation.admins, who may change any poll.
+type UpdatePollRequest struct {
+	Title   *string   `json:"title"`
+	Tags    []string  `json:"tags"`
+	ActorID uuid.UUID `json:"-"`
+	Admin   bool      `json:"-"`
+}
+
+// PollOwnerStats is the extended view of a poll's stats available to its
+// creator and collaborators.
+type PollOwnerStats struct {
+	PollStats
+	Skips         int            `json:"skips"`
+	Collaborators []Collaborator `json:"collaborators"`
+	// SkipReasons counts the skips that gave a reason.
+	SkipReasons map[SkipReason]int `json:"skipReasons"`
+	// Countries breaks votes down by voter location, leaving out places
+	// with fewer than MinGeoStatsVotes votes.
+	Countries []CountryStat `json:"countries,omitempty"`
+}
+
+// TagAlias makes Alias resolve to the canonical Tag wherever tags are read
+// or written.
+type TagAlias struct {
+	Alias     string    `json:"alias"`
+	Tag       string    `json:"tag"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type TagAliasRequest struct {
+	Alias string `json:"alias" binding:"required"`
+	Tag   string `json:"tag" binding:"required"`
+}
+
+type MergeTagsRequest struct {
+	From string `json:"from" binding:"required"`
+	To   string `json:"to" binding:"required"`
+}
+
+type TagMergeResult struct {
+	From          string `json:"from"`
+	To            string `json:"to"`
+	PollsRetagged int    `json:"pollsRetagged"`
+}
+
+// TagRule limits posting to Tag. Restricted tags only take polls from users
+// with a verified email address or a moderator or admin role. Polls posted
+// to auto-moderated tags wait for a moderator before they go live.
+type TagRule struct {
+	Tag           string     `json:"tag"`
+	Restricted    bool       `json:"restricted"`
+	AutoModerated bool       `json:"autoModerated"`
+	UpdatedBy     *uuid.UUID `json:"updatedBy,omitempty"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+type TagRuleRequest struct {
+	Restricted    bool      `json:"restricted"`
+	AutoModerated bool      `json:"autoModerated"`
+	ActorID       uuid.UUID `json:"-"`
+}
+
+type PollStatusRequest struct {
+	Status  PollStatus `json:"status" binding:"required"`
+	ActorID uuid.UUID  `json:"-"`
+	Admin   bool       `json:"-"`
+}
+
+// ContentKind names the kind of user-submitted text being scored.
+type ContentKind string
+
+const (
+	ContentPollTitle  ContentKind = "poll_title"
+	ContentPollOption ContentKind = "poll_option"
+	// ContentPollReview holds a poll posted to an auto-moderated tag.
+	// Dismissing the flag approves the poll and upholding it rejects it.
+	ContentPollReview ContentKind = "poll_review"
+)
+
+// Content is a piece of user-submitted text to score for spam and abuse.
+type Content struct {
+	Kind     ContentKind `json:"kind"`
+	Text     string      `json:"text"`
+	PollID   *uuid.UUID  `json:"pollId,omitempty"`
+	AuthorID *uuid.UUID  `json:"authorId,omitempty"`
+}
+
+// ContentScore rates content from 0 (clean) to 1 (certainly spam or
+// abuse), with the signals that contributed to it.
+type ContentScore struct {
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+type FlagStatus string
+
+const (
+	FlagOpen      FlagStatus = "open"
+	FlagDismissed FlagStatus = "dismissed"
+	FlagUpheld    FlagStatus = "upheld"
 )
+
+func (s FlagStatus) Valid() bool {
+	switch s {
+	case FlagOpen, FlagDismissed, FlagUpheld:
+		return true
+	}
+	return false
+}
+
+// ModerationFlag is an entry in the moderation queue for content that
+// scored at or above the flagging threshold.
+type ModerationFlag struct {
+	ID         uuid.UUID   `json:"id"`
+	Kind       ContentKind `json:"kind"`
+	Content    string      `json:"content"`
+	PollID     *uuid.UUID  `json:"pollId,omitempty"`
+	AuthorID   *uuid.UUID  `json:"authorId,omitempty"`
+	Score      float64     `json:"score"`
+	Reasons    []string    `json:"reasons"`
+	Status     FlagStatus  `json:"status"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	ResolvedBy *uuid.UUID  `json:"resolvedBy,omitempty"`
+	ResolvedAt *time.Time  `json:"resolvedAt,omitempty"`
+}
+
+type ModerationQueue struct {
+	Flags []ModerationFlag `json:"flags"`
+	Total int              `json:"total"`
+	Page  int              `json:"page"`
+	Limit int              `json:"limit"`
+}
+
+// ResolveFlagRequest closes an open flag as dismissed or upheld.
+type ResolveFlagRequest struct {
+	Status  FlagStatus `json:"status" binding:"required"`
+	ActorID uuid.UUID  `json:"-"`
+}
+
+type ConsentDocument string
+
+const (
+	ConsentTerms   ConsentDocument = "terms"
+	ConsentPrivacy ConsentDocument = "privacy"
+)
+
+// ConsentVersions are the versions of the terms of service and privacy
+// policy users must have accepted. An empty version requires nothing.
+type ConsentVersions struct {
+	Terms   string `json:"terms,omitempty"`
+	Privacy string `json:"privacy,omitempty"`
+}
+
+// Consent records a user accepting one version of a document, with where
+// it was accepted from as evidence.
+type Consent struct {
+	Document   ConsentDocument `json:"document"`
+	Version    string          `json:"version"`
+	AcceptedAt time.Time       `json:"acceptedAt"`
+	IPAddress  string          `json:"ipAddress,omitempty"`
+	UserAgent  string          `json:"userAgent,omitempty"`
+}
+
+// ConsentStatus lists the current versions, those the user has yet to
+// accept and every acceptance on record, newest first.
+type ConsentStatus struct {
+	Required ConsentVersions   `json:"required"`
+	Pending  []ConsentDocument `json:"pending"`
+	History  []Consent         `json:"history"`
+}
+
+// AcceptConsentRequest accepts the given versions, which must be the
+// current ones.
+type AcceptConsentRequest struct {
+	Terms     string    `json:"terms"`
+	Privacy   string    `json:"privacy"`
+	UserID    uuid.UUID `json:"-"`
+	IPAddress string    `json:"-"`
+	UserAgent string    `json:"-"`
+}
@@ -2,27 +2,98 @@ package domain
 
 import (
 	"context"
+	"net"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// PollSearcher finds the published, unrestricted polls matching a query.
+// The repository implements it with Postgres full-text search.
+type PollSearcher interface {
+	SearchPolls(ctx context.Context, query PollSearchQuery) (*PollSearchResult, error)
+}
+
+// GeoLocator resolves a client address to a coarse location. It reports
+// false for addresses it cannot place.
+type GeoLocator interface {
+	Locate(ip net.IP) (GeoLocation, bool)
+}
+
+// CreationLimiter throttles poll creation. Organization polls count against
+// the organization and personal polls against their creator. Reserve returns
+// a *CreationLimitError when the limit is reached; release gives the
+// reservation back if the poll isn't created after all.
+type CreationLimiter interface {
+	ReserveCreation(ctx context.Context, creatorID uuid.UUID, organizationID *uuid.UUID) (release func(), err error)
+}
+
+// ContentScorer rates user-submitted text for spam and abuse.
+type ContentScorer interface {
+	Score(ctx context.Context, content Content) (ContentScore, error)
+}
+
+// StatsWatcher keeps a per-poll version that changes whenever the poll's
+// vote counts do, so clients can wait for the next change instead of
+// polling.
+type StatsWatcher interface {
+	BumpStatsVersion(ctx context.Context, pollID uuid.UUID) (int64, error)
+	StatsVersion(ctx context.Context, pollID uuid.UUID) (int64, error)
+	WaitStatsVersion(ctx context.Context, pollID uuid.UUID, since int64) (int64, error)
+}
+
 type Repository interface {
+	// CreatePoll and CreateVote queue the poll.created and poll.voted events
+	// in the outbox in the same transaction as the change, for the outbox
+	// relay to publish.
 	CreatePoll(ctx context.Context, poll *Poll, options []string, tags []string) error
 	GetPollByID(ctx context.Context, id uuid.UUID) (*Poll, error)
-	GetPollsForFeed(ctx context.Context, userID uuid.UUID, tag string, page, limit int) ([]Poll, int, error)
+	GetPollAccessCodeHash(ctx context.Context, pollID uuid.UUID) (string, error)
+	GetPollsForFeed(ctx context.Context, query FeedQuery) ([]Poll, int, error)
 	GetPollStats(ctx context.Context, pollID uuid.UUID) (*PollStats, error)
+	UpdatePoll(ctx context.Context, poll *Poll) error
+	UpdatePollTags(ctx context.Context, pollID uuid.UUID, add, remove []string, updatedAt time.Time, check func(tags []string) error) ([]string, error)
+	ReorderPollOptions(ctx context.Context, pollID uuid.UUID, optionIDs []uuid.UUID, updatedAt time.Time) error
+	SetPollStatus(ctx context.Context, pollID uuid.UUID, status PollStatus, changedAt time.Time) error
+	CountSkips(ctx context.Context, pollID uuid.UUID) (int, error)
+	CountSkipReasons(ctx context.Context, pollID uuid.UUID) (map[SkipReason]int, error)
+	CountVotes(ctx context.Context, pollID uuid.UUID) (int, error)
+	RecordPollMilestone(ctx context.Context, pollID uuid.UUID, milestone int) (bool, error)
+
+	AddPollCollaborator(ctx context.Context, collaborator *Collaborator) error
+	RemovePollCollaborator(ctx context.Context, pollID, userID uuid.UUID) error
+	GetPollCollaborator(ctx context.Context, pollID, userID uuid.UUID) (*Collaborator, error)
+	GetPollCollaborators(ctx context.Context, pollID uuid.UUID) ([]Collaborator, error)
 
-	CreateVote(ctx context.Context, pollID, userID, optionID uuid.UUID) error
+	CreateVote(ctx context.Context, vote *Vote) error
+	// UpdateVote records the change in the vote's history.
 	UpdateVote(ctx context.Context, voteID, userID, optionID uuid.UUID) error
+	// GetLastVoteChange returns when the vote was last changed, or the zero
+	// time if it never was.
+	GetLastVoteChange(ctx context.Context, voteID uuid.UUID) (time.Time, error)
 	DeleteVote(ctx context.Context, voteID, userID uuid.UUID) error
 	HasVoted(ctx context.Context, pollID, userID uuid.UUID) (bool, error)
+	IsEligibleVoter(ctx context.Context, pollID, userID uuid.UUID) (bool, error)
 	GetUserDailyVoteCount(ctx context.Context, userID uuid.UUID, date time.Time) (int, error)
 	IncrementUserDailyVoteCount(ctx context.Context, userID uuid.UUID, date time.Time) error
-	GetUserVotes(ctx context.Context, userID uuid.UUID, page, limit int) ([]Vote, int, error)
+	GetRecentVoteTimes(ctx context.Context, userID uuid.UUID, since time.Time) ([]time.Time, error)
+	// ReserveRecentVote counts the vote against the user's limit of limit
+	// votes per window, or returns ErrDailyVoteLimitExceeded. Reserving the
+	// same vote again does not count it twice.
+	ReserveRecentVote(ctx context.Context, userID, voteID uuid.UUID, at time.Time, window time.Duration, limit int) error
+	ReleaseRecentVote(ctx context.Context, userID, voteID uuid.UUID) error
+	// ReconcileRecentVotes brings the users' vote windows back in line with
+	// the stored votes and returns how many had drifted.
+	ReconcileRecentVotes(ctx context.Context, window time.Duration) (int, error)
+	GetUserVotes(ctx context.Context, userID uuid.UUID, filter VoteFilter, page, limit int) ([]Vote, int, error)
+	StreamUserVotes(ctx context.Context, userID uuid.UUID, filter VoteFilter, fn func(*Vote) error) error
+	GetPollVotesAfter(ctx context.Context, pollID uuid.UUID, after *VoteKey, limit int) ([]Vote, error)
 	GetVoteByID(ctx context.Context, voteID uuid.UUID) (*Vote, error)
+	// GetUserPollVote returns the user's vote on the poll with its
+	// OptionIndex set, or ErrNotFound.
+	GetUserPollVote(ctx context.Context, pollID, userID uuid.UUID) (*Vote, error)
 
-	CreateSkip(ctx context.Context, pollID, userID uuid.UUID) error
+	CreateSkip(ctx context.Context, skip *Skip) error
 	HasSkipped(ctx context.Context, pollID, userID uuid.UUID) (bool, error)
 
 	GetCachedPollStats(ctx context.Context, pollID uuid.UUID) (*PollStats, error)
@@ -32,11 +103,120 @@ type Repository interface {
 	GetCachedPoll(ctx context.Context, id uuid.UUID) (*Poll, error)
 	SetCachedPoll(ctx context.Context, poll *Poll) error
 
+	RollupPollStats(ctx context.Context, day time.Time) (int64, error)
+	RepairPollStatsDaily(ctx context.Context, pollID uuid.UUID) ([]StatsDiscrepancy, error)
+	PruneUserDailyVotes(ctx context.Context, before time.Time) (int64, error)
+	GetTrendingPolls(ctx context.Context, since time.Time, limit int) ([]TrendingPoll, error)
+	GetTagDailyVotes(ctx context.Context, tag string, from, to time.Time) ([]VoteBucket, error)
+	GetPollHourlyVotes(ctx context.Context, pollID uuid.UUID, from, to time.Time) ([]VoteBucket, error)
+	GetUserActivity(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]UserActivity, error)
+	SetCachedTrendingPolls(ctx context.Context, polls []TrendingPoll) error
+	GetActiveUserIDs(ctx context.Context, since time.Time) ([]uuid.UUID, error)
+	GetRecentlyActivePollIDs(ctx context.Context, since time.Time, limit int) ([]uuid.UUID, error)
+	// CountOpenPolls counts the polls accepting votes at the given time.
+	CountOpenPolls(ctx context.Context, at time.Time) (int, error)
+	// CountActiveVoters counts the users with votes standing in the analytics
+	// projections for the UTC day of the given time.
+	CountActiveVoters(ctx context.Context, day time.Time) (int, error)
+
+	CreateOrganization(ctx context.Context, org *Organization, ownerID uuid.UUID) error
+	AddOrganizationMember(ctx context.Context, member *Membership) error
+	GetOrganizationRole(ctx context.Context, orgID, userID uuid.UUID) (OrganizationRole, error)
+	// CreateOrganizationVote returns ErrAlreadyVoted when the organization
+	// already voted on the poll.
+	CreateOrganizationVote(ctx context.Context, vote *OrganizationVote) error
+	GetOrganizationVote(ctx context.Context, pollID, orgID uuid.UUID) (*OrganizationVote, error)
+	UpdateOrganizationVote(ctx context.Context, vote *OrganizationVote) error
+	GetOrganizationVoteStats(ctx context.Context, pollID uuid.UUID) ([]OptionStats, error)
+
+	GetElectionsToCertify(ctx context.Context, closedBefore time.Time) ([]uuid.UUID, error)
+	SaveElectionCertification(ctx context.Context, cert *ElectionCertification) error
+	GetElectionCertification(ctx context.Context, pollID uuid.UUID) (*ElectionCertification, error)
+
+	// GetPollsToSnapshot lists closed polls that have no result snapshot yet.
+	GetPollsToSnapshot(ctx context.Context, closedBefore time.Time) ([]uuid.UUID, error)
+	// SavePollResultSnapshot keeps the first snapshot stored for a poll.
+	SavePollResultSnapshot(ctx context.Context, snapshot *PollResultSnapshot) error
+	GetPollResultSnapshot(ctx context.Context, pollID uuid.UUID) (*PollResultSnapshot, error)
+	// GetPollsToCertify lists polls with a result snapshot but no result
+	// certificate.
+	GetPollsToCertify(ctx context.Context) ([]uuid.UUID, error)
+	// SaveResultCertificate keeps the first certificate stored for a poll.
+	SaveResultCertificate(ctx context.Context, cert *ResultCertificate) error
+	GetResultCertificate(ctx context.Context, pollID uuid.UUID) (*ResultCertificate, error)
+	// GetLastVoteTimes returns, per option text, when the option received
+	// its most recent counted vote.
+	GetLastVoteTimes(ctx context.Context, pollID uuid.UUID) (map[string]time.Time, error)
+
+	GetUserPreferences(ctx context.Context, userID uuid.UUID) (*UserPreferences, error)
+	SetUserPreferences(ctx context.Context, userID uuid.UUID, prefs *UserPreferences) error
+	GetTagFollowers(ctx context.Context, tags []string) ([]uuid.UUID, error)
+
+	GetPollsByIDs(ctx context.Context, ids []uuid.UUID) ([]Poll, error)
+	SearchPolls(ctx context.Context, query PollSearchQuery) (*PollSearchResult, error)
+
+	SetOptionImage(ctx context.Context, pollID uuid.UUID, optionIndex int, key string) (string, error)
+	UpdateOptionMetadata(ctx context.Context, pollID uuid.UUID, optionIndex int, altText, emoji *string) error
+	UnreferencedMediaKeys(ctx context.Context, keys []string) ([]string, error)
+	GetPollPreviewCard(ctx context.Context, pollID uuid.UUID) (*PollPreviewCard, error)
+	SetPollPreviewCard(ctx context.Context, card *PollPreviewCard) error
+
+	RecordVoteLocation(ctx context.Context, pollID uuid.UUID, location GeoLocation) error
+	GetPollGeoStats(ctx context.Context, pollID uuid.UUID) ([]CountryStat, error)
+
+	CreateModerationFlag(ctx context.Context, flag *ModerationFlag) error
+	GetModerationFlags(ctx context.Context, status FlagStatus, page, limit int) ([]ModerationFlag, int, error)
+	ResolveModerationFlag(ctx context.Context, flagID uuid.UUID, status FlagStatus, resolvedBy uuid.UUID, resolvedAt time.Time) (*ModerationFlag, error)
+	GetPollReviewFlag(ctx context.Context, pollID uuid.UUID) (*ModerationFlag, error)
+
+	ResolveTags(ctx context.Context, tags []string) ([]string, error)
+	CreateTagAlias(ctx context.Context, alias *TagAlias) error
+	GetTagAliases(ctx context.Context) ([]TagAlias, error)
+	MergeTags(ctx context.Context, from, to string) (int, error)
+	GetTagRules(ctx context.Context) ([]TagRule, error)
+	GetTagRulesFor(ctx context.Context, tags []string) ([]TagRule, error)
+	SetTagRule(ctx context.Context, rule *TagRule) error
+	DeleteTagRule(ctx context.Context, tag string) error
+
+	GetUserQuotas(ctx context.Context, userID uuid.UUID) ([]Quota, error)
+	GetQuotaUsage(ctx context.Context, userID uuid.UUID, action QuotaAction, period QuotaPeriod, periodStart time.Time) (int, error)
+	IncrementQuotaUsage(ctx context.Context, userID uuid.UUID, action QuotaAction, period QuotaPeriod, periodStart time.Time) error
+
 	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 
 	CreateUser(ctx context.Context, user *User) error
 	GetUserByID(ctx context.Context, id uuid.UUID) (*User, error)
+	SetUserAvatar(ctx context.Context, userID uuid.UUID, key string) (string, error)
+	SetUserStanding(ctx context.Context, userID uuid.UUID, standing UserStanding) error
+	SearchUsers(ctx context.Context, q UserSearchQuery) ([]AdminUser, int, error)
+	GetPlatformStats(ctx context.Context, since time.Time) (*PlatformStats, error)
+	// PurgePoll deletes a poll and everything recorded about it for good.
+	PurgePoll(ctx context.Context, pollID uuid.UUID) error
+	RecordConsents(ctx context.Context, userID uuid.UUID, consents []Consent) error
+	GetConsents(ctx context.Context, userID uuid.UUID) ([]Consent, error)
 	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	CreateEmailVerificationToken(ctx context.Context, token *EmailVerificationToken) error
+	// VerifyEmail marks the owner of the unexpired token with tokenHash as
+	// verified and drops their outstanding tokens. It returns ErrNotFound for
+	// an unknown or expired token.
+	VerifyEmail(ctx context.Context, tokenHash string, now time.Time) (uuid.UUID, error)
+	// CreateEmailChange stores change, cancelling the user's change in
+	// progress if there is one.
+	CreateEmailChange(ctx context.Context, change *EmailChange) error
+	// GetOpenEmailChange returns the user's change in progress, expired or
+	// not, or ErrNotFound.
+	GetOpenEmailChange(ctx context.Context, userID uuid.UUID) (*EmailChange, error)
+	// GetEmailChangeByToken returns the change whose old or new token has
+	// tokenHash, or ErrNotFound.
+	GetEmailChangeByToken(ctx context.Context, tokenHash string) (*EmailChange, error)
+	// SetEmailChangeStatus moves a change from one status to another. It
+	// returns ErrNotFound when the change is no longer in from.
+	SetEmailChangeStatus(ctx context.Context, id uuid.UUID, from, to EmailChangeStatus, at time.Time) error
+	// CompleteEmailChange moves a change from from to completed, gives the
+	// user the new address, marked verified, and revokes their refresh
+	// tokens, all at once. It returns ErrNotFound when the change is no
+	// longer in from and ErrEmailAlreadyExists when the address was taken.
+	CompleteEmailChange(ctx context.Context, change *EmailChange, from EmailChangeStatus, at time.Time) error
 	UpdateUser(ctx context.Context, user *User) error
 	DeleteUser(ctx context.Context, id uuid.UUID) error
 }
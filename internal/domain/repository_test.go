@@ -40,8 +40,8 @@ func (m *MockRepository) GetPollStats(ctx context.Context, pollID uuid.UUID) (*P
 	return args.Get(0).(*PollStats), args.Error(1)
 }
 
-func (m *MockRepository) CreateVote(ctx context.Context, pollID, userID, optionID uuid.UUID) error {
-	args := m.Called(ctx, pollID, userID, optionID)
+func (m *MockRepository) CreateVote(ctx context.Context, vote *Vote) error {
+	args := m.Called(ctx, vote)
 	return args.Error(0)
 }
 
@@ -60,8 +60,8 @@ func (m *MockRepository) IncrementUserDailyVoteCount(ctx context.Context, userID
 	return args.Error(0)
 }
 
-func (m *MockRepository) CreateSkip(ctx context.Context, pollID, userID uuid.UUID) error {
-	args := m.Called(ctx, pollID, userID)
+func (m *MockRepository) CreateSkip(ctx context.Context, skip *Skip) error {
+	args := m.Called(ctx, skip)
 	return args.Error(0)
 }
 
@@ -193,16 +193,16 @@ func TestMockRepository_GetPollStats(t *testing.T) {
 func TestMockRepository_CreateVote(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockRepository)
-	pollID, userID, optionID := uuid.New(), uuid.New(), uuid.New()
+	vote := &Vote{ID: uuid.New(), PollID: uuid.New(), UserID: uuid.New(), OptionID: uuid.New()}
 
-	mockRepo.On("CreateVote", ctx, pollID, userID, optionID).Return(nil).Once()
+	mockRepo.On("CreateVote", ctx, vote).Return(nil).Once()
 
-	err := mockRepo.CreateVote(ctx, pollID, userID, optionID)
+	err := mockRepo.CreateVote(ctx, vote)
 	assert.NoError(t, err, "CreateVote should not return an error")
 	mockRepo.AssertExpectations(t)
 
-	mockRepo.On("CreateVote", ctx, pollID, userID, optionID).Return(ErrAlreadyVoted).Once()
-	err = mockRepo.CreateVote(ctx, pollID, userID, optionID)
+	mockRepo.On("CreateVote", ctx, vote).Return(ErrAlreadyVoted).Once()
+	err = mockRepo.CreateVote(ctx, vote)
 	assert.Equal(t, ErrAlreadyVoted, err, "CreateVote should return ErrAlreadyVoted")
 	mockRepo.AssertExpectations(t)
 }
@@ -267,16 +267,16 @@ func TestMockRepository_IncrementUserDailyVoteCount(t *testing.T) {
 func TestMockRepository_CreateSkip(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockRepository)
-	pollID, userID := uuid.New(), uuid.New()
+	skip := &Skip{ID: uuid.New(), PollID: uuid.New(), UserID: uuid.New(), Reason: SkipNotInterested}
 
-	mockRepo.On("CreateSkip", ctx, pollID, userID).Return(nil).Once()
+	mockRepo.On("CreateSkip", ctx, skip).Return(nil).Once()
 
-	err := mockRepo.CreateSkip(ctx, pollID, userID)
+	err := mockRepo.CreateSkip(ctx, skip)
 	assert.NoError(t, err, "CreateSkip should not return an error")
 	mockRepo.AssertExpectations(t)
 
-	mockRepo.On("CreateSkip", ctx, pollID, userID).Return(ErrAlreadySkipped).Once()
-	err = mockRepo.CreateSkip(ctx, pollID, userID)
+	mockRepo.On("CreateSkip", ctx, skip).Return(ErrAlreadySkipped).Once()
+	err = mockRepo.CreateSkip(ctx, skip)
 	assert.Equal(t, ErrAlreadySkipped, err, "CreateSkip should return ErrAlreadySkipped")
 	mockRepo.AssertExpectations(t)
 }
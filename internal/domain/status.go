@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PollStatus string
+
+const (
+	PollStatusDraft     PollStatus = "draft"
+	PollStatusScheduled PollStatus = "scheduled"
+	PollStatusLive      PollStatus = "live"
+	PollStatusClosed    PollStatus = "closed"
+	PollStatusArchived  PollStatus = "archived"
+	PollStatusDeleted   PollStatus = "deleted"
+)
+
+// pollTransitions lists the statuses each status may move to explicitly.
+// scheduled -> live and live -> closed also happen implicitly when the
+// voting window starts or ends.
+var pollTransitions = map[PollStatus][]PollStatus{
+	PollStatusDraft:     {PollStatusScheduled, PollStatusLive, PollStatusDeleted},
+	PollStatusScheduled: {PollStatusDraft, PollStatusLive, PollStatusClosed, PollStatusDeleted},
+	PollStatusLive:      {PollStatusClosed, PollStatusDeleted},
+	PollStatusClosed:    {PollStatusArchived, PollStatusDeleted},
+	PollStatusArchived:  {PollStatusDeleted},
+}
+
+func (s PollStatus) Valid() bool {
+	_, ok := pollTransitions[s]
+	return ok || s == PollStatusDeleted
+}
+
+// ValidatePollTransition returns ErrInvalidTransition unless a poll may move
+// from one status to the other.
+func ValidatePollTransition(from, to PollStatus) error {
+	for _, allowed := range pollTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return &TransitionError{From: from, To: to}
+}
+
+// PublishedStatus is the status a draft moves to when published at t.
+func (p *Poll) PublishedStatus(t time.Time) PollStatus {
+	if p.StartsAt != nil && t.Before(*p.StartsAt) {
+		return PollStatusScheduled
+	}
+	return PollStatusLive
+}
+
+// StatusAt returns the poll's status at t, advancing scheduled and live polls
+// whose voting window has started or ended. Polls cached before statuses were
+// introduced have none and are treated as live.
+func (p *Poll) StatusAt(t time.Time) PollStatus {
+	status := p.Status
+	if status == "" {
+		status = PollStatusLive
+	}
+	if status == PollStatusScheduled && (p.StartsAt == nil || !t.Before(*p.StartsAt)) {
+		status = PollStatusLive
+	}
+	if status == PollStatusLive && p.EndsAt != nil && !t.Before(*p.EndsAt) {
+		status = PollStatusClosed
+	}
+	return status
+}
+
+// IsOpen reports whether the poll accepts votes at t.
+func (p *Poll) IsOpen(t time.Time) bool {
+	return p.StatusAt(t) == PollStatusLive
+}
+
+// IsFinal reports whether the poll's results can no longer change at t.
+func (p *Poll) IsFinal(t time.Time) bool {
+	status := p.StatusAt(t)
+	return status == PollStatusClosed || status == PollStatusArchived
+}
+
+// PollStatusChange is published whenever a poll is explicitly moved to a new
+// status.
+type PollStatusChange struct {
+	PollID    uuid.UUID  `json:"pollId"`
+	From      PollStatus `json:"from"`
+	To        PollStatus `json:"to"`
+	ActorID   uuid.UUID  `json:"actorId"`
+	ChangedAt time.Time  `json:"changedAt"`
+
+	// Winner is set when the poll was closed.
+	Winner *PollWinner `json:"winner,omitempty"`
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePollTransition(t *testing.T) {
+	tests := []struct {
+		from, to PollStatus
+		allowed  bool
+	}{
+		{PollStatusDraft, PollStatusLive, true},
+		{PollStatusDraft, PollStatusClosed, false},
+		{PollStatusScheduled, PollStatusDraft, true},
+		{PollStatusLive, PollStatusClosed, true},
+		{PollStatusLive, PollStatusDraft, false},
+		{PollStatusClosed, PollStatusLive, false},
+		{PollStatusClosed, PollStatusArchived, true},
+		{PollStatusArchived, PollStatusDeleted, true},
+		{PollStatusDeleted, PollStatusLive, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			err := ValidatePollTransition(tt.from, tt.to)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidTransition)
+			}
+		})
+	}
+}
+
+func TestPoll_StatusAt(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		poll     Poll
+		expected PollStatus
+	}{
+		{"no status", Poll{}, PollStatusLive},
+		{"draft stays draft", Poll{Status: PollStatusDraft, EndsAt: &past}, PollStatusDraft},
+		{"scheduled before start", Poll{Status: PollStatusScheduled, StartsAt: &future}, PollStatusScheduled},
+		{"scheduled after start", Poll{Status: PollStatusScheduled, StartsAt: &past}, PollStatusLive},
+		{"scheduled after end", Poll{Status: PollStatusScheduled, StartsAt: &past, EndsAt: &past}, PollStatusClosed},
+		{"live after end", Poll{Status: PollStatusLive, EndsAt: &past}, PollStatusClosed},
+		{"archived", Poll{Status: PollStatusArchived, EndsAt: &past}, PollStatusArchived},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.poll.StatusAt(now))
+		})
+	}
+}
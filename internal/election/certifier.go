@@ -0,0 +1,121 @@
+package election
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const Algorithm = "HMAC-SHA256"
+
+type Certifier struct {
+	repo   domain.Repository
+	key    []byte
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+func NewCertifier(repo domain.Repository, signingKey string, logger *zap.Logger) *Certifier {
+	return &Certifier{
+		repo:   repo,
+		key:    []byte(signingKey),
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+func (c *Certifier) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (c *Certifier) Verify(cert *domain.ElectionCertification) bool {
+	if cert.Algorithm != Algorithm {
+		return false
+	}
+	expected, err := hex.DecodeString(cert.Signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(cert.Tally)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// Certify computes and signs the final tally of a closed election.
+func (c *Certifier) Certify(ctx context.Context, pollID uuid.UUID) (*domain.ElectionCertification, error) {
+	poll, err := c.repo.GetPollByID(ctx, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("get election: %w", err)
+	}
+	if poll.Kind != domain.PollKindElection || poll.EndsAt == nil {
+		return nil, domain.ErrInvalidPoll
+	}
+	now := c.now().UTC()
+	if !poll.IsFinal(now) {
+		return nil, domain.ErrInvalidPoll
+	}
+
+	stats, err := c.repo.GetPollStats(ctx, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("get election stats: %w", err)
+	}
+
+	tally := domain.ElectionTally{
+		PollID:   poll.ID,
+		Title:    poll.Title,
+		Options:  stats.Votes,
+		ClosedAt: poll.EndsAt.UTC(),
+	}
+	for _, option := range stats.Votes {
+		tally.BallotsCast += option.Count
+	}
+	if stats.Turnout != nil {
+		tally.Eligible = stats.Turnout.Eligible
+	}
+
+	payload, err := json.Marshal(tally)
+	if err != nil {
+		return nil, fmt.Errorf("marshal tally: %w", err)
+	}
+
+	cert := &domain.ElectionCertification{
+		PollID:      poll.ID,
+		Tally:       payload,
+		Algorithm:   Algorithm,
+		Signature:   c.Sign(payload),
+		CertifiedAt: now,
+	}
+	if err := c.repo.SaveElectionCertification(ctx, cert); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// CertifyClosed certifies every election that has ended without a
+// certification and returns how many were certified.
+func (c *Certifier) CertifyClosed(ctx context.Context) (int, error) {
+	ids, err := c.repo.GetElectionsToCertify(ctx, c.now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("get elections to certify: %w", err)
+	}
+
+	certified := 0
+	for _, id := range ids {
+		if _, err := c.Certify(ctx, id); err != nil {
+			return certified, fmt.Errorf("certify election %s: %w", id, err)
+		}
+		certified++
+		c.logger.Info("Certified election results", zap.String("poll_id", id.String()))
+	}
+	return certified, nil
+}
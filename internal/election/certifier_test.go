@@ -0,0 +1,102 @@
+package election
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeRepo struct {
+	domain.Repository
+	poll  *domain.Poll
+	stats *domain.PollStats
+	saved *domain.ElectionCertification
+}
+
+func (f *fakeRepo) GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Poll, error) {
+	return f.poll, nil
+}
+
+func (f *fakeRepo) GetPollStats(ctx context.Context, pollID uuid.UUID) (*domain.PollStats, error) {
+	return f.stats, nil
+}
+
+func (f *fakeRepo) SaveElectionCertification(ctx context.Context, cert *domain.ElectionCertification) error {
+	f.saved = cert
+	return nil
+}
+
+func TestCertify(t *testing.T) {
+	endsAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	pollID := uuid.New()
+
+	tests := []struct {
+		name    string
+		poll    *domain.Poll
+		now     time.Time
+		wantErr error
+	}{
+		{
+			name: "closed election",
+			poll: &domain.Poll{ID: pollID, Title: "Board", Kind: domain.PollKindElection, EndsAt: &endsAt},
+			now:  endsAt.Add(time.Minute),
+		},
+		{
+			name:    "election still open",
+			poll:    &domain.Poll{ID: pollID, Title: "Board", Kind: domain.PollKindElection, EndsAt: &endsAt},
+			now:     endsAt.Add(-time.Minute),
+			wantErr: domain.ErrInvalidPoll,
+		},
+		{
+			name:    "standard poll",
+			poll:    &domain.Poll{ID: pollID, Title: "Lunch", Kind: domain.PollKindStandard, EndsAt: &endsAt},
+			now:     endsAt.Add(time.Minute),
+			wantErr: domain.ErrInvalidPoll,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{
+				poll: tt.poll,
+				stats: &domain.PollStats{
+					PollID:  pollID,
+					Votes:   []domain.OptionStats{{Option: "Alice", Count: 3}, {Option: "Bob", Count: 2}},
+					Turnout: &domain.Turnout{Voted: 5, Eligible: 8},
+				},
+			}
+			c := NewCertifier(repo, "secret", zap.NewNop())
+			c.now = func() time.Time { return tt.now }
+
+			cert, err := c.Certify(context.Background(), pollID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, repo.saved)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Same(t, cert, repo.saved)
+			assert.JSONEq(t, `{
+				"pollId": "`+pollID.String()+`",
+				"title": "Board",
+				"options": [{"option": "Alice", "count": 3}, {"option": "Bob", "count": 2}],
+				"ballotsCast": 5,
+				"eligible": 8,
+				"closedAt": "2024-05-01T12:00:00Z"
+			}`, string(cert.Tally))
+			assert.True(t, c.Verify(cert))
+			assert.False(t, NewCertifier(repo, "other", zap.NewNop()).Verify(cert))
+
+			tampered := *cert
+			tampered.Tally = []byte(`{"ballotsCast": 6}`)
+			assert.False(t, c.Verify(&tampered))
+		})
+	}
+}
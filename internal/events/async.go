@@ -0,0 +1,367 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/metrics"
+	"go.uber.org/zap"
+)
+
+const (
+	EventPollCreated         = "poll.created"
+	EventPollUpdated         = "poll.updated"
+	EventPollVoted           = "poll.voted"
+	EventPollVoteUpdated     = "poll.vote.updated"
+	EventPollVoteDeleted     = "poll.vote.deleted"
+	EventPollSkipped         = "poll.skipped"
+	EventCollaboratorInvited = "poll.collaborator_invited"
+	EventPollStatusChanged   = "poll.status_changed"
+	EventUserRegistered      = "user.registered"
+	EventUserLoggedIn        = "user.login"
+	EventUserPasswordChanged = "user.password_changed"
+	EventUserDeleted         = "user.deleted"
+
+	EventEmailVerificationRequested = "mail.verification_requested"
+	EventEmailChangeRequested       = "mail.email_change_requested"
+)
+
+const (
+	asyncPublishTimeout     = 5 * time.Second
+	defaultOutboxRelayBatch = 100
+	defaultOutboxLease      = 30 * time.Second
+)
+
+// OutboxStore keeps events until they are relayed: those written with the
+// change they describe, and those the broker couldn't take.
+type OutboxStore interface {
+	AddOutboxEvent(ctx context.Context, eventType string, payload []byte) error
+	// ClaimOutboxEvents returns up to limit events, oldest first, that no
+	// other relay has claimed within the last lease.
+	ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error)
+	DeleteOutboxEvent(ctx context.Context, id int64) error
+}
+
+type pendingEvent struct {
+	ctx       context.Context
+	eventType string
+	data      interface{}
+}
+
+// AsyncPublisher takes publishing off the request path. Events go onto a
+// bounded queue drained by background workers. When the queue is full, or
+// the broker rejects an event, the event is written to the outbox instead,
+// from where RelayOutbox publishes it later.
+type AsyncPublisher struct {
+	next   Publisher
+	outbox OutboxStore
+	queue  chan pendingEvent
+	logger *zap.Logger
+
+	mu      sync.RWMutex
+	closed  bool
+	wg      sync.WaitGroup
+	pending atomic.Int64
+}
+
+func NewAsyncPublisher(next Publisher, outbox OutboxStore, queueSize int, logger *zap.Logger) *AsyncPublisher {
+	return &AsyncPublisher{
+		next:   next,
+		outbox: outbox,
+		queue:  make(chan pendingEvent, queueSize),
+		logger: logger,
+	}
+}
+
+// Start launches the workers that publish queued events.
+func (p *AsyncPublisher) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+}
+
+// Stop stops accepting events and waits for the queued ones to be published.
+// Events published after Stop go straight to the outbox.
+func (p *AsyncPublisher) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("drain publish queue: %w", ctx.Err())
+	}
+}
+
+// Close is a no-op: the wrapped publisher belongs to the caller, which closes
+// it after Stop.
+func (p *AsyncPublisher) Close() error {
+	return nil
+}
+
+func (p *AsyncPublisher) work() {
+	defer p.wg.Done()
+	for event := range p.queue {
+		metrics.EventQueueDepth.Set(float64(len(p.queue)))
+
+		p.publish(event)
+		p.pending.Add(-1)
+	}
+}
+
+func (p *AsyncPublisher) publish(event pendingEvent) {
+	ctx, cancel := context.WithTimeout(event.ctx, asyncPublishTimeout)
+	err := dispatch(ctx, p.next, event.eventType, event.data)
+	cancel()
+	if err != nil {
+		logging.For(event.ctx, p.logger).Warn("Failed to publish event, moving it to the outbox",
+			zap.Error(err),
+			zap.String("event_type", event.eventType),
+		)
+		p.shed(event, "failed")
+		return
+	}
+	metrics.EventPublishes.WithLabelValues(event.eventType, "published").Inc()
+}
+
+// InFlight reports the events queued or being published. Events already moved
+// to the outbox are not counted.
+func (p *AsyncPublisher) InFlight(context.Context) (int, error) {
+	return int(p.pending.Load()), nil
+}
+
+// enqueue never blocks the caller. The request context is kept for its values
+// only, since the request is usually over by the time the event is sent.
+func (p *AsyncPublisher) enqueue(ctx context.Context, eventType string, data interface{}) error {
+	event := pendingEvent{ctx: context.WithoutCancel(ctx), eventType: eventType, data: data}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return p.shed(event, "shed")
+	}
+	p.pending.Add(1)
+	select {
+	case p.queue <- event:
+		metrics.EventQueueDepth.Set(float64(len(p.queue)))
+		return nil
+	default:
+		p.pending.Add(-1)
+		return p.shed(event, "shed")
+	}
+}
+
+func (p *AsyncPublisher) shed(event pendingEvent, result string) error {
+	payload, err := json.Marshal(event.data)
+	if err == nil {
+		err = p.outbox.AddOutboxEvent(event.ctx, event.eventType, payload)
+	}
+	if err != nil {
+		metrics.EventPublishes.WithLabelValues(event.eventType, "dropped").Inc()
+		logging.For(event.ctx, p.logger).Error("Failed to write event to the outbox, dropping it",
+			zap.Error(err),
+			zap.String("event_type", event.eventType),
+		)
+		return fmt.Errorf("add %s to outbox: %w", event.eventType, err)
+	}
+	metrics.EventPublishes.WithLabelValues(event.eventType, result).Inc()
+	return nil
+}
+
+// The queued values are shallow copies, so callers changing the object they
+// published (handlers add links to polls, for instance) don't race with the
+// workers encoding it.
+
+func (p *AsyncPublisher) PublishPollCreated(ctx context.Context, poll *domain.Poll) error {
+	copied := *poll
+	return p.enqueue(ctx, EventPollCreated, &copied)
+}
+
+func (p *AsyncPublisher) PublishPollUpdated(ctx context.Context, poll *domain.Poll) error {
+	copied := *poll
+	return p.enqueue(ctx, EventPollUpdated, &copied)
+}
+
+func (p *AsyncPublisher) PublishPollVoted(ctx context.Context, vote *domain.Vote) error {
+	copied := *vote
+	return p.enqueue(ctx, EventPollVoted, &copied)
+}
+
+func (p *AsyncPublisher) PublishPollVoteUpdated(ctx context.Context, vote *domain.Vote) error {
+	copied := *vote
+	return p.enqueue(ctx, EventPollVoteUpdated, &copied)
+}
+
+func (p *AsyncPublisher) PublishPollVoteDeleted(ctx context.Context, vote *domain.Vote) error {
+	copied := *vote
+	return p.enqueue(ctx, EventPollVoteDeleted, &copied)
+}
+
+func (p *AsyncPublisher) PublishPollSkipped(ctx context.Context, skip *domain.Skip) error {
+	copied := *skip
+	return p.enqueue(ctx, EventPollSkipped, &copied)
+}
+
+func (p *AsyncPublisher) PublishCollaboratorInvited(ctx context.Context, collaborator *domain.Collaborator) error {
+	copied := *collaborator
+	return p.enqueue(ctx, EventCollaboratorInvited, &copied)
+}
+
+func (p *AsyncPublisher) PublishPollStatusChanged(ctx context.Context, change *domain.PollStatusChange) error {
+	copied := *change
+	return p.enqueue(ctx, EventPollStatusChanged, &copied)
+}
+
+func (p *AsyncPublisher) PublishUserRegistered(ctx context.Context, event *domain.UserEvent) error {
+	copied := *event
+	return p.enqueue(ctx, EventUserRegistered, &copied)
+}
+
+func (p *AsyncPublisher) PublishUserLoggedIn(ctx context.Context, event *domain.UserEvent) error {
+	copied := *event
+	return p.enqueue(ctx, EventUserLoggedIn, &copied)
+}
+
+func (p *AsyncPublisher) PublishUserPasswordChanged(ctx context.Context, event *domain.UserEvent) error {
+	copied := *event
+	return p.enqueue(ctx, EventUserPasswordChanged, &copied)
+}
+
+func (p *AsyncPublisher) PublishUserDeleted(ctx context.Context, event *domain.UserEvent) error {
+	copied := *event
+	return p.enqueue(ctx, EventUserDeleted, &copied)
+}
+
+func (p *AsyncPublisher) PublishEmailVerificationRequested(ctx context.Context, verification *domain.EmailVerification) error {
+	copied := *verification
+	return p.enqueue(ctx, EventEmailVerificationRequested, &copied)
+}
+
+func (p *AsyncPublisher) PublishEmailChangeRequested(ctx context.Context, confirmation *domain.EmailChangeConfirmation) error {
+	copied := *confirmation
+	return p.enqueue(ctx, EventEmailChangeRequested, &copied)
+}
+
+// RelayOutbox claims up to limit outbox events for lease and publishes them,
+// oldest first, deleting each once the broker has it. Delivery is at least
+// once: an event whose deletion fails, or whose relay dies before deleting
+// it, is published again after the lease. It stops at the first publish
+// failure, leaving the rest of the batch to be retried once the lease ends.
+func RelayOutbox(ctx context.Context, store OutboxStore, publisher Publisher, limit int, lease time.Duration, logger *zap.Logger) (int, error) {
+	if limit <= 0 {
+		limit = defaultOutboxRelayBatch
+	}
+	if lease <= 0 {
+		lease = defaultOutboxLease
+	}
+	pending, err := store.ClaimOutboxEvents(ctx, limit, lease)
+	if err != nil {
+		return 0, fmt.Errorf("claim outbox events: %w", err)
+	}
+
+	relayed := 0
+	for _, event := range pending {
+		data, err := decodeEvent(event.Type, event.Payload)
+		if err != nil {
+			// An event that can't be decoded will never publish; keeping it
+			// would block the outbox for good.
+			logger.Error("Discarding undecodable outbox event",
+				zap.Error(err),
+				zap.Int64("id", event.ID),
+				zap.String("event_type", event.Type),
+			)
+		} else if err := dispatch(ctx, publisher, event.Type, data); err != nil {
+			return relayed, fmt.Errorf("publish outbox event %d: %w", event.ID, err)
+		} else {
+			metrics.EventPublishes.WithLabelValues(event.Type, "relayed").Inc()
+		}
+
+		if err := store.DeleteOutboxEvent(ctx, event.ID); err != nil {
+			return relayed, fmt.Errorf("delete outbox event %d: %w", event.ID, err)
+		}
+		relayed++
+	}
+	return relayed, nil
+}
+
+func dispatch(ctx context.Context, publisher Publisher, eventType string, data interface{}) error {
+	switch eventType {
+	case EventPollCreated:
+		return publisher.PublishPollCreated(ctx, data.(*domain.Poll))
+	case EventPollUpdated:
+		return publisher.PublishPollUpdated(ctx, data.(*domain.Poll))
+	case EventPollVoted:
+		return publisher.PublishPollVoted(ctx, data.(*domain.Vote))
+	case EventPollVoteUpdated:
+		return publisher.PublishPollVoteUpdated(ctx, data.(*domain.Vote))
+	case EventPollVoteDeleted:
+		return publisher.PublishPollVoteDeleted(ctx, data.(*domain.Vote))
+	case EventPollSkipped:
+		return publisher.PublishPollSkipped(ctx, data.(*domain.Skip))
+	case EventCollaboratorInvited:
+		return publisher.PublishCollaboratorInvited(ctx, data.(*domain.Collaborator))
+	case EventPollStatusChanged:
+		return publisher.PublishPollStatusChanged(ctx, data.(*domain.PollStatusChange))
+	case EventUserRegistered:
+		return publisher.PublishUserRegistered(ctx, data.(*domain.UserEvent))
+	case EventUserLoggedIn:
+		return publisher.PublishUserLoggedIn(ctx, data.(*domain.UserEvent))
+	case EventUserPasswordChanged:
+		return publisher.PublishUserPasswordChanged(ctx, data.(*domain.UserEvent))
+	case EventUserDeleted:
+		return publisher.PublishUserDeleted(ctx, data.(*domain.UserEvent))
+	case EventEmailVerificationRequested:
+		return publisher.PublishEmailVerificationRequested(ctx, data.(*domain.EmailVerification))
+	case EventEmailChangeRequested:
+		return publisher.PublishEmailChangeRequested(ctx, data.(*domain.EmailChangeConfirmation))
+	default:
+		return fmt.Errorf("unknown event type: %s", eventType)
+	}
+}
+
+func decodeEvent(eventType string, payload []byte) (interface{}, error) {
+	var data interface{}
+	switch eventType {
+	case EventPollCreated, EventPollUpdated:
+		data = &domain.Poll{}
+	case EventPollVoted, EventPollVoteUpdated, EventPollVoteDeleted:
+		data = &domain.Vote{}
+	case EventPollSkipped:
+		data = &domain.Skip{}
+	case EventCollaboratorInvited:
+		data = &domain.Collaborator{}
+	case EventPollStatusChanged:
+		data = &domain.PollStatusChange{}
+	case EventUserRegistered, EventUserLoggedIn, EventUserPasswordChanged, EventUserDeleted:
+		data = &domain.UserEvent{}
+	case EventEmailVerificationRequested:
+		data = &domain.EmailVerification{}
+	case EventEmailChangeRequested:
+		data = &domain.EmailChangeConfirmation{}
+	default:
+		return nil, errors.New("unknown event type")
+	}
+	if err := json.Unmarshal(payload, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
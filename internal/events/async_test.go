@@ -0,0 +1,208 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakePublisher struct {
+	Publisher
+	mu      sync.Mutex
+	err     error
+	block   chan struct{}
+	votes   []*domain.Vote
+	changes []*domain.PollStatusChange
+}
+
+func (p *fakePublisher) PublishPollVoted(ctx context.Context, vote *domain.Vote) error {
+	if p.block != nil {
+		<-p.block
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.votes = append(p.votes, vote)
+	return nil
+}
+
+func (p *fakePublisher) PublishPollStatusChanged(ctx context.Context, change *domain.PollStatusChange) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.changes = append(p.changes, change)
+	return nil
+}
+
+type fakeOutbox struct {
+	mu     sync.Mutex
+	nextID int64
+	events []domain.OutboxEvent
+}
+
+func (o *fakeOutbox) AddOutboxEvent(ctx context.Context, eventType string, payload []byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.nextID++
+	o.events = append(o.events, domain.OutboxEvent{ID: o.nextID, Type: eventType, Payload: payload})
+	return nil
+}
+
+// ClaimOutboxEvents ignores the lease: the tests relay from one goroutine.
+func (o *fakeOutbox) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.events) > limit {
+		return append([]domain.OutboxEvent(nil), o.events[:limit]...), nil
+	}
+	return append([]domain.OutboxEvent(nil), o.events...), nil
+}
+
+func (o *fakeOutbox) DeleteOutboxEvent(ctx context.Context, id int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i, event := range o.events {
+		if event.ID == id {
+			o.events = append(o.events[:i], o.events[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (o *fakeOutbox) len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.events)
+}
+
+func newVote() *domain.Vote {
+	return &domain.Vote{ID: uuid.New(), PollID: uuid.New(), UserID: uuid.New(), OptionIndex: 1}
+}
+
+func TestAsyncPublisher_PublishesInBackground(t *testing.T) {
+	next := &fakePublisher{}
+	outbox := &fakeOutbox{}
+	p := NewAsyncPublisher(next, outbox, 10, zap.NewNop())
+	p.Start(2)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, p.PublishPollVoted(context.Background(), newVote()))
+	}
+	require.NoError(t, p.Stop(context.Background()))
+
+	assert.Len(t, next.votes, 5)
+	assert.Zero(t, outbox.len())
+}
+
+func TestAsyncPublisher_ShedsToOutboxWhenFull(t *testing.T) {
+	next := &fakePublisher{block: make(chan struct{})}
+	outbox := &fakeOutbox{}
+	p := NewAsyncPublisher(next, outbox, 1, zap.NewNop())
+	p.Start(1)
+
+	// The worker holds the first vote, the queue the second; the third
+	// doesn't fit.
+	require.NoError(t, p.PublishPollVoted(context.Background(), newVote()))
+	require.Eventually(t, func() bool { return len(p.queue) == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, p.PublishPollVoted(context.Background(), newVote()))
+	require.NoError(t, p.PublishPollVoted(context.Background(), newVote()))
+
+	assert.Equal(t, 1, outbox.len())
+	assert.Equal(t, EventPollVoted, outbox.events[0].Type)
+
+	close(next.block)
+	require.NoError(t, p.Stop(context.Background()))
+	assert.Len(t, next.votes, 2)
+}
+
+func TestAsyncPublisher_ShedsFailedPublishes(t *testing.T) {
+	next := &fakePublisher{err: errors.New("broker down")}
+	outbox := &fakeOutbox{}
+	p := NewAsyncPublisher(next, outbox, 10, zap.NewNop())
+	p.Start(1)
+
+	require.NoError(t, p.PublishPollVoted(context.Background(), newVote()))
+	require.NoError(t, p.Stop(context.Background()))
+
+	assert.Equal(t, 1, outbox.len())
+}
+
+func TestAsyncPublisher_ShedsAfterStop(t *testing.T) {
+	outbox := &fakeOutbox{}
+	p := NewAsyncPublisher(&fakePublisher{}, outbox, 10, zap.NewNop())
+	p.Start(1)
+	require.NoError(t, p.Stop(context.Background()))
+
+	require.NoError(t, p.PublishPollVoted(context.Background(), newVote()))
+	assert.Equal(t, 1, outbox.len())
+}
+
+func TestRelayOutbox(t *testing.T) {
+	outbox := &fakeOutbox{}
+	vote := newVote()
+	p := NewAsyncPublisher(&fakePublisher{}, outbox, 0, zap.NewNop())
+	require.NoError(t, p.Stop(context.Background()))
+	require.NoError(t, p.PublishPollVoted(context.Background(), vote))
+	require.NoError(t, p.PublishPollStatusChanged(context.Background(), &domain.PollStatusChange{PollID: vote.PollID}))
+	require.NoError(t, outbox.AddOutboxEvent(context.Background(), "poll.unknown", []byte(`{}`)))
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		relayed, err := RelayOutbox(context.Background(), outbox, &fakePublisher{err: errors.New("broker down")}, 10, time.Minute, zap.NewNop())
+		assert.Error(t, err)
+		assert.Zero(t, relayed)
+		assert.Equal(t, 3, outbox.len())
+	})
+
+	t.Run("publishes and deletes", func(t *testing.T) {
+		next := &fakePublisher{}
+		relayed, err := RelayOutbox(context.Background(), outbox, next, 10, time.Minute, zap.NewNop())
+		require.NoError(t, err)
+		assert.Equal(t, 3, relayed)
+		assert.Zero(t, outbox.len())
+		require.Len(t, next.votes, 1)
+		assert.Equal(t, vote.ID, next.votes[0].ID)
+		assert.Equal(t, vote.OptionIndex, next.votes[0].OptionIndex)
+		require.Len(t, next.changes, 1)
+		assert.Equal(t, vote.PollID, next.changes[0].PollID)
+	})
+}
+
+func TestRelay(t *testing.T) {
+	outbox := &fakeOutbox{}
+	for i := 0; i < 5; i++ {
+		payload, err := json.Marshal(newVote())
+		require.NoError(t, err)
+		require.NoError(t, outbox.AddOutboxEvent(context.Background(), EventPollVoted, payload))
+	}
+
+	next := &fakePublisher{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewRelay(outbox, next, RelayConfig{Interval: 10 * time.Millisecond, Batch: 2}, zap.NewNop()).Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool { return outbox.len() == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, outbox.AddOutboxEvent(context.Background(), EventPollVoted, []byte(`{}`)))
+	require.Eventually(t, func() bool { return outbox.len() == 0 }, time.Second, time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+	next.mu.Lock()
+	defer next.mu.Unlock()
+	assert.Len(t, next.votes, 6)
+}
@@ -12,10 +12,19 @@ import (
 
 type Publisher interface {
 	PublishPollCreated(ctx context.Context, poll *domain.Poll) error
+	PublishPollUpdated(ctx context.Context, poll *domain.Poll) error
 	PublishPollVoted(ctx context.Context, vote *domain.Vote) error
 	PublishPollVoteUpdated(ctx context.Context, vote *domain.Vote) error
 	PublishPollVoteDeleted(ctx context.Context, vote *domain.Vote) error
 	PublishPollSkipped(ctx context.Context, skip *domain.Skip) error
+	PublishCollaboratorInvited(ctx context.Context, collaborator *domain.Collaborator) error
+	PublishPollStatusChanged(ctx context.Context, change *domain.PollStatusChange) error
+	PublishUserRegistered(ctx context.Context, event *domain.UserEvent) error
+	PublishUserLoggedIn(ctx context.Context, event *domain.UserEvent) error
+	PublishUserPasswordChanged(ctx context.Context, event *domain.UserEvent) error
+	PublishUserDeleted(ctx context.Context, event *domain.UserEvent) error
+	PublishEmailVerificationRequested(ctx context.Context, verification *domain.EmailVerification) error
+	PublishEmailChangeRequested(ctx context.Context, confirmation *domain.EmailChangeConfirmation) error
 	Close() error
 }
 
@@ -57,6 +66,32 @@ func (p *RedisPublisher) PublishPollCreated(ctx context.Context, poll *domain.Po
 	return nil
 }
 
+func (p *RedisPublisher) PublishPollUpdated(ctx context.Context, poll *domain.Poll) error {
+	event := struct {
+		Type string       `json:"type"`
+		Data *domain.Poll `json:"data"`
+	}{
+		Type: "poll.updated",
+		Data: poll,
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal poll updated event: %w", err)
+	}
+
+	if err := p.client.Publish(ctx, "events", data).Err(); err != nil {
+		return fmt.Errorf("publish poll updated event: %w", err)
+	}
+
+	p.logger.Info("published poll updated event",
+		zap.String("poll_id", poll.ID.String()),
+		zap.String("title", poll.Title),
+	)
+
+	return nil
+}
+
 func (p *RedisPublisher) PublishPollVoted(ctx context.Context, vote *domain.Vote) error {
 	event := struct {
 		Type string       `json:"type"`
@@ -164,6 +199,151 @@ func (p *RedisPublisher) PublishPollSkipped(ctx context.Context, skip *domain.Sk
 	return nil
 }
 
+func (p *RedisPublisher) PublishCollaboratorInvited(ctx context.Context, collaborator *domain.Collaborator) error {
+	event := struct {
+		Type string               `json:"type"`
+		Data *domain.Collaborator `json:"data"`
+	}{
+		Type: "poll.collaborator_invited",
+		Data: collaborator,
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal collaborator invited event: %w", err)
+	}
+
+	if err := p.client.Publish(ctx, "events", data).Err(); err != nil {
+		return fmt.Errorf("publish collaborator invited event: %w", err)
+	}
+
+	p.logger.Info("published collaborator invited event",
+		zap.String("poll_id", collaborator.PollID.String()),
+		zap.String("user_id", collaborator.UserID.String()),
+	)
+
+	return nil
+}
+
+func (p *RedisPublisher) PublishPollStatusChanged(ctx context.Context, change *domain.PollStatusChange) error {
+	event := struct {
+		Type string                   `json:"type"`
+		Data *domain.PollStatusChange `json:"data"`
+	}{
+		Type: "poll.status_changed",
+		Data: change,
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal poll status changed event: %w", err)
+	}
+
+	if err := p.client.Publish(ctx, "events", data).Err(); err != nil {
+		return fmt.Errorf("publish poll status changed event: %w", err)
+	}
+
+	p.logger.Info("published poll status changed event",
+		zap.String("poll_id", change.PollID.String()),
+		zap.String("status", string(change.To)),
+	)
+
+	return nil
+}
+
+func (p *RedisPublisher) PublishUserRegistered(ctx context.Context, userEvent *domain.UserEvent) error {
+	return p.publishUserEvent(ctx, "user.registered", userEvent)
+}
+
+func (p *RedisPublisher) PublishUserLoggedIn(ctx context.Context, userEvent *domain.UserEvent) error {
+	return p.publishUserEvent(ctx, "user.login", userEvent)
+}
+
+func (p *RedisPublisher) PublishUserPasswordChanged(ctx context.Context, userEvent *domain.UserEvent) error {
+	return p.publishUserEvent(ctx, "user.password_changed", userEvent)
+}
+
+func (p *RedisPublisher) PublishUserDeleted(ctx context.Context, userEvent *domain.UserEvent) error {
+	return p.publishUserEvent(ctx, "user.deleted", userEvent)
+}
+
+func (p *RedisPublisher) publishUserEvent(ctx context.Context, eventType string, userEvent *domain.UserEvent) error {
+	event := struct {
+		Type string            `json:"type"`
+		Data *domain.UserEvent `json:"data"`
+	}{
+		Type: eventType,
+		Data: userEvent,
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal %s event: %w", eventType, err)
+	}
+
+	if err := p.client.Publish(ctx, "events", data).Err(); err != nil {
+		return fmt.Errorf("publish %s event: %w", eventType, err)
+	}
+
+	p.logger.Info("published user event",
+		zap.String("type", eventType),
+		zap.String("user_id", userEvent.UserID.String()),
+	)
+
+	return nil
+}
+
+func (p *RedisPublisher) PublishEmailVerificationRequested(ctx context.Context, verification *domain.EmailVerification) error {
+	event := struct {
+		Type string                    `json:"type"`
+		Data *domain.EmailVerification `json:"data"`
+	}{
+		Type: "mail.verification_requested",
+		Data: verification,
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal email verification event: %w", err)
+	}
+
+	if err := p.client.Publish(ctx, "events", data).Err(); err != nil {
+		return fmt.Errorf("publish email verification event: %w", err)
+	}
+
+	p.logger.Info("published email verification event",
+		zap.String("user_id", verification.UserID.String()),
+	)
+
+	return nil
+}
+
+func (p *RedisPublisher) PublishEmailChangeRequested(ctx context.Context, confirmation *domain.EmailChangeConfirmation) error {
+	event := struct {
+		Type string                          `json:"type"`
+		Data *domain.EmailChangeConfirmation `json:"data"`
+	}{
+		Type: "mail.email_change_requested",
+		Data: confirmation,
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal email change event: %w", err)
+	}
+
+	if err := p.client.Publish(ctx, "events", data).Err(); err != nil {
+		return fmt.Errorf("publish email change event: %w", err)
+	}
+
+	p.logger.Info("published email change event",
+		zap.String("user_id", confirmation.UserID.String()),
+		zap.String("side", string(confirmation.Side)),
+	)
+
+	return nil
+}
+
 func (p *RedisPublisher) Close() error {
 	return p.client.Close()
 }
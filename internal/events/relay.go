@@ -0,0 +1,64 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type RelayConfig struct {
+	Interval time.Duration
+	Batch    int
+	Lease    time.Duration
+}
+
+// Relay publishes the outbox continuously, so events written with the change
+// they describe reach the broker within about Interval. Several relays may
+// share an outbox; claims keep them from publishing the same events.
+type Relay struct {
+	store     OutboxStore
+	publisher Publisher
+	cfg       RelayConfig
+	logger    *zap.Logger
+}
+
+func NewRelay(store OutboxStore, publisher Publisher, cfg RelayConfig, logger *zap.Logger) *Relay {
+	if cfg.Batch <= 0 {
+		cfg.Batch = defaultOutboxRelayBatch
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		cfg:       cfg,
+		logger:    logger,
+	}
+}
+
+// Run relays the outbox until ctx is done. A full batch is followed at once
+// by the next, so a backlog drains without waiting out the interval.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		relayed, err := RelayOutbox(ctx, r.store, r.publisher, r.cfg.Batch, r.cfg.Lease, r.logger)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			r.logger.Warn("Failed to relay outbox events", zap.Error(err))
+		}
+		if err == nil && relayed >= r.cfg.Batch {
+			continue
+		}
+
+		timer := time.NewTimer(r.cfg.Interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
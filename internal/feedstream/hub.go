@@ -0,0 +1,137 @@
+package feedstream
+
+import (
+	"context"
+	"sync"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// subscriberBuffer is how many polls a slow client may fall behind before
+// further polls are dropped for it.
+const subscriberBuffer = 16
+
+type subscriber struct {
+	userID uuid.UUID
+	prefs  *domain.UserPreferences
+	polls  chan *domain.Poll
+}
+
+// Hub fans poll.created events out to the feed streams connected to this
+// instance. Each stream receives the live, open polls carrying one of the
+// user's followed tags, minus anything they muted and their own polls.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	closed      bool
+	logger      *zap.Logger
+}
+
+func NewHub(logger *zap.Logger) *Hub {
+	return &Hub{
+		subscribers: make(map[*subscriber]struct{}),
+		logger:      logger,
+	}
+}
+
+// Subscribe registers a stream for userID, filtered by prefs as they are
+// now. The returned function unsubscribes; the channel is closed when the
+// hub is.
+func (h *Hub) Subscribe(userID uuid.UUID, prefs *domain.UserPreferences) (<-chan *domain.Poll, func()) {
+	if prefs == nil {
+		prefs = &domain.UserPreferences{}
+	}
+	sub := &subscriber{
+		userID: userID,
+		prefs:  prefs,
+		polls:  make(chan *domain.Poll, subscriberBuffer),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(sub.polls)
+		return sub.polls, func() {}
+	}
+	h.subscribers[sub] = struct{}{}
+
+	return sub.polls, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subscribers[sub]; ok {
+			delete(h.subscribers, sub)
+			close(sub.polls)
+		}
+	}
+}
+
+// Close ends every stream so that HTTP shutdown does not wait on them.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subscribers {
+		delete(h.subscribers, sub)
+		close(sub.polls)
+	}
+}
+
+func (s *subscriber) wants(poll *domain.Poll) bool {
+	if poll.CreatedBy != nil && *poll.CreatedBy == s.userID {
+		return false
+	}
+	return s.prefs.Follows(poll) && !s.prefs.Mutes(poll)
+}
+
+func (h *Hub) HandlePollCreated(ctx context.Context, poll *domain.Poll) error {
+	if poll.Status != domain.PollStatusLive || poll.Electorate.Restricted() || len(poll.Tags) == 0 {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscribers {
+		if !sub.wants(poll) {
+			continue
+		}
+		select {
+		case sub.polls <- poll:
+		default:
+			h.logger.Warn("Feed stream is full, dropping poll",
+				zap.String("user_id", sub.userID.String()),
+				zap.String("poll_id", poll.ID.String()),
+			)
+		}
+	}
+	return nil
+}
+
+func (h *Hub) HandlePollUpdated(ctx context.Context, poll *domain.Poll) error {
+	return nil
+}
+
+func (h *Hub) HandlePollVoted(ctx context.Context, vote *domain.Vote) error {
+	return nil
+}
+
+func (h *Hub) HandleVoteUpdated(ctx context.Context, vote *domain.Vote) error {
+	return nil
+}
+
+func (h *Hub) HandleVoteDeleted(ctx context.Context, vote *domain.Vote) error {
+	return nil
+}
+
+func (h *Hub) HandlePollSkipped(ctx context.Context, skip *domain.Skip) error {
+	return nil
+}
+
+func (h *Hub) HandleCollaboratorInvited(ctx context.Context, collaborator *domain.Collaborator) error {
+	return nil
+}
+
+func (h *Hub) HandlePollStatusChanged(ctx context.Context, change *domain.PollStatusChange) error {
+	return nil
+}
@@ -0,0 +1,89 @@
+package feedstream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func livePoll(title string, tags ...string) *domain.Poll {
+	return &domain.Poll{ID: uuid.New(), Title: title, Tags: tags, Status: domain.PollStatusLive}
+}
+
+func received(polls <-chan *domain.Poll) []*domain.Poll {
+	var got []*domain.Poll
+	for {
+		select {
+		case poll := <-polls:
+			got = append(got, poll)
+		default:
+			return got
+		}
+	}
+}
+
+func TestHubFiltersByPreferences(t *testing.T) {
+	hub := NewHub(zap.NewNop())
+	userID := uuid.New()
+	polls, unsubscribe := hub.Subscribe(userID, &domain.UserPreferences{
+		FollowedTags:  []string{"go"},
+		MutedTags:     []string{"politics"},
+		MutedKeywords: []string{"spoiler"},
+	})
+	defer unsubscribe()
+
+	followed := livePoll("Favourite IDE?", "go")
+	own := livePoll("My poll", "go")
+	own.CreatedBy = &userID
+	draft := livePoll("Draft", "go")
+	draft.Status = domain.PollStatusDraft
+	restricted := livePoll("Board vote", "go")
+	restricted.Electorate = domain.ElectorateList
+
+	for _, poll := range []*domain.Poll{
+		followed,
+		own,
+		draft,
+		restricted,
+		livePoll("Unfollowed", "rust"),
+		livePoll("Muted tag", "go", "politics"),
+		livePoll("Spoiler ahead", "go"),
+	} {
+		require.NoError(t, hub.HandlePollCreated(context.Background(), poll))
+	}
+
+	got := received(polls)
+	require.Len(t, got, 1)
+	assert.Equal(t, followed.ID, got[0].ID)
+}
+
+func TestHubDropsPollsForSlowSubscribers(t *testing.T) {
+	hub := NewHub(zap.NewNop())
+	polls, unsubscribe := hub.Subscribe(uuid.New(), &domain.UserPreferences{FollowedTags: []string{"go"}})
+	defer unsubscribe()
+
+	for i := 0; i < subscriberBuffer+5; i++ {
+		require.NoError(t, hub.HandlePollCreated(context.Background(), livePoll("Poll", "go")))
+	}
+
+	assert.Len(t, received(polls), subscriberBuffer)
+}
+
+func TestHubClose(t *testing.T) {
+	hub := NewHub(zap.NewNop())
+	polls, unsubscribe := hub.Subscribe(uuid.New(), nil)
+
+	hub.Close()
+	_, open := <-polls
+	assert.False(t, open)
+	unsubscribe()
+
+	late, _ := hub.Subscribe(uuid.New(), nil)
+	_, open = <-late
+	assert.False(t, open)
+}
@@ -0,0 +1,328 @@
+// Package geo resolves client IP addresses to a coarse location using a
+// MaxMind DB file such as GeoLite2-Country or GeoLite2-City.
+package geo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+
+	"github.com/behzadon/vote/internal/domain"
+)
+
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+const (
+	maxMetadataSize = 128 << 10
+	dataSeparator   = 16
+)
+
+var errCorrupt = errors.New("corrupt MaxMind database")
+
+// Reader looks addresses up in a MaxMind DB held in memory. It implements
+// the format described at https://maxmind.github.io/MaxMind-DB/ and only
+// reads the country and first subdivision of each record.
+type Reader struct {
+	tree       []byte
+	data       []byte
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	ipv4Start  uint
+}
+
+func Open(path string) (*Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read geoip database: %w", err)
+	}
+	return New(buf)
+}
+
+func New(buf []byte) (*Reader, error) {
+	start := len(buf) - maxMetadataSize
+	if start < 0 {
+		start = 0
+	}
+	i := bytes.LastIndex(buf[start:], metadataMarker)
+	if i < 0 {
+		return nil, fmt.Errorf("%w: metadata not found", errCorrupt)
+	}
+	metaStart := start + i + len(metadataMarker)
+	meta, _, err := decoder{buf: buf[metaStart:]}.decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("decode metadata: %w", err)
+	}
+	fields, ok := meta.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: metadata is not a map", errCorrupt)
+	}
+
+	r := &Reader{
+		nodeCount:  uintField(fields, "node_count"),
+		recordSize: uintField(fields, "record_size"),
+		ipVersion:  uintField(fields, "ip_version"),
+	}
+	switch r.recordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("%w: unsupported record size %d", errCorrupt, r.recordSize)
+	}
+	if r.ipVersion != 4 && r.ipVersion != 6 {
+		return nil, fmt.Errorf("%w: unsupported ip version %d", errCorrupt, r.ipVersion)
+	}
+
+	treeSize := r.nodeCount * r.recordSize / 4
+	if treeSize+dataSeparator > uint(start+i) {
+		return nil, fmt.Errorf("%w: search tree exceeds file", errCorrupt)
+	}
+	r.tree = buf[:treeSize]
+	r.data = buf[treeSize+dataSeparator : start+i]
+
+	// IPv4 addresses live under ::/96 in IPv6 databases.
+	if r.ipVersion == 6 {
+		for n := 0; n < 96 && r.ipv4Start < r.nodeCount; n++ {
+			r.ipv4Start = r.record(r.ipv4Start, 0)
+		}
+	}
+	return r, nil
+}
+
+// Locate returns the country, and the region where the database has one,
+// for ip. It reports false for addresses the database does not cover.
+func (r *Reader) Locate(ip net.IP) (domain.GeoLocation, bool) {
+	record, err := r.lookup(ip)
+	if err != nil || record == nil {
+		return domain.GeoLocation{}, false
+	}
+	fields, _ := record.(map[string]any)
+	country, _ := fields["country"].(map[string]any)
+	code, _ := country["iso_code"].(string)
+	if code == "" {
+		return domain.GeoLocation{}, false
+	}
+	loc := domain.GeoLocation{Country: code}
+	if subdivisions, _ := fields["subdivisions"].([]any); len(subdivisions) > 0 {
+		first, _ := subdivisions[0].(map[string]any)
+		loc.Region, _ = first["iso_code"].(string)
+	}
+	return loc, true
+}
+
+func (r *Reader) lookup(ip net.IP) (any, error) {
+	node := uint(0)
+	bits := 128
+	if v4 := ip.To4(); v4 != nil {
+		ip, bits, node = v4, 32, r.ipv4Start
+	} else if ip = ip.To16(); ip == nil || r.ipVersion == 4 {
+		return nil, nil
+	}
+
+	for i := 0; i < bits && node < r.nodeCount; i++ {
+		bit := uint(ip[i/8]>>(7-i%8)) & 1
+		node = r.record(node, bit)
+	}
+	if node <= r.nodeCount {
+		return nil, nil
+	}
+	offset := node - r.nodeCount - dataSeparator
+	if offset >= uint(len(r.data)) {
+		return nil, errCorrupt
+	}
+	value, _, err := decoder{buf: r.data}.decode(offset, 0)
+	return value, err
+}
+
+func (r *Reader) record(node, bit uint) uint {
+	b := r.tree[node*r.recordSize/4:]
+	switch r.recordSize {
+	case 24:
+		b = b[bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(b[bit*4:]))
+	}
+}
+
+func uintField(fields map[string]any, name string) uint {
+	v, _ := fields[name].(uint64)
+	return uint(v)
+}
+
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// maxDepth bounds nesting so a malformed file cannot recurse forever.
+const maxDepth = 32
+
+type decoder struct {
+	buf []byte
+}
+
+// decode returns the value at offset and the offset just past it.
+func (d decoder) decode(offset uint, depth int) (any, uint, error) {
+	if depth > maxDepth {
+		return nil, 0, fmt.Errorf("%w: nesting too deep", errCorrupt)
+	}
+	ctrl, offset, err := d.byte(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	kind := uint(ctrl >> 5)
+	if kind == typeExtended {
+		var ext byte
+		if ext, offset, err = d.byte(offset); err != nil {
+			return nil, 0, err
+		}
+		kind = 7 + uint(ext)
+	}
+
+	if kind == typePointer {
+		pointer, next, err := d.pointer(ctrl, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(pointer, depth+1)
+		return value, next, err
+	}
+
+	size := uint(ctrl & 0x1f)
+	if size >= 29 {
+		n := size - 28
+		extra, err := d.bytes(offset, n)
+		if err != nil {
+			return nil, 0, err
+		}
+		offset += n
+		size = 0
+		for _, b := range extra {
+			size = size<<8 | uint(b)
+		}
+		size += [...]uint{0, 29, 285, 65821}[n]
+	}
+
+	switch kind {
+	case typeMap:
+		m := make(map[string]any, size)
+		for i := uint(0); i < size; i++ {
+			key, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("%w: map key is not a string", errCorrupt)
+			}
+			if m[name], offset, err = d.decode(next, depth+1); err != nil {
+				return nil, 0, err
+			}
+		}
+		return m, offset, nil
+	case typeArray:
+		a := make([]any, 0, size)
+		for i := uint(0); i < size; i++ {
+			var v any
+			if v, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			a = append(a, v)
+		}
+		return a, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	case typeEndMarker, typeContainer:
+		return nil, offset, nil
+	}
+
+	raw, err := d.bytes(offset, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset += size
+	switch kind {
+	case typeString:
+		return string(raw), offset, nil
+	case typeBytes, typeUint128:
+		return raw, offset, nil
+	case typeUint16, typeUint32, typeUint64:
+		var v uint64
+		for _, b := range raw {
+			v = v<<8 | uint64(b)
+		}
+		return v, offset, nil
+	case typeInt32:
+		var v uint32
+		for _, b := range raw {
+			v = v<<8 | uint32(b)
+		}
+		return int64(int32(v)), offset, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, errCorrupt
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(raw)), offset, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, errCorrupt
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(raw))), offset, nil
+	default:
+		return nil, 0, fmt.Errorf("%w: unknown type %d", errCorrupt, kind)
+	}
+}
+
+func (d decoder) pointer(ctrl byte, offset uint) (uint, uint, error) {
+	n := uint(ctrl>>3&0x3) + 1
+	raw, err := d.bytes(offset, n)
+	if err != nil {
+		return 0, 0, err
+	}
+	var p uint
+	if n < 4 {
+		p = uint(ctrl & 0x7)
+	}
+	for _, b := range raw {
+		p = p<<8 | uint(b)
+	}
+	p += [...]uint{0, 0, 2048, 526336, 0}[n]
+	return p, offset + n, nil
+}
+
+func (d decoder) byte(offset uint) (byte, uint, error) {
+	if offset >= uint(len(d.buf)) {
+		return 0, 0, errCorrupt
+	}
+	return d.buf[offset], offset + 1, nil
+}
+
+func (d decoder) bytes(offset, n uint) ([]byte, error) {
+	if offset+n > uint(len(d.buf)) || offset+n < offset {
+		return nil, errCorrupt
+	}
+	return d.buf[offset : offset+n], nil
+}
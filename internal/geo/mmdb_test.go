@@ -0,0 +1,193 @@
+package geo
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDB builds a MaxMind DB with 24-bit records, following the spec.
+type testDB struct {
+	ipVersion int
+	nodes     [][2]int
+	data      bytes.Buffer
+}
+
+const (
+	emptyRecord = -1
+	// Records below dataRecord point into the data section.
+	dataRecord = -2
+)
+
+func newTestDB(ipVersion int) *testDB {
+	return &testDB{ipVersion: ipVersion, nodes: [][2]int{{emptyRecord, emptyRecord}}}
+}
+
+func (db *testDB) insert(cidr string, offset int) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	ip := network.IP.To16()
+	ones, _ := network.Mask.Size()
+	if v4 := network.IP.To4(); v4 != nil {
+		if db.ipVersion == 4 {
+			ip = v4
+		} else {
+			ip = append(make(net.IP, 12), v4...)
+			ones += 96
+		}
+	}
+
+	node := 0
+	for i := 0; i < ones; i++ {
+		bit := int(ip[i/8]>>(7-i%8)) & 1
+		if i == ones-1 {
+			db.nodes[node][bit] = dataRecord - offset
+			return
+		}
+		next := db.nodes[node][bit]
+		if next < 0 {
+			db.nodes = append(db.nodes, [2]int{emptyRecord, emptyRecord})
+			next = len(db.nodes) - 1
+			db.nodes[node][bit] = next
+		}
+		node = next
+	}
+}
+
+func (db *testDB) bytes() []byte {
+	var out bytes.Buffer
+	count := len(db.nodes)
+	for _, n := range db.nodes {
+		for _, rec := range n {
+			switch {
+			case rec == emptyRecord:
+				rec = count
+			case rec <= dataRecord:
+				rec = count + dataSeparator + (dataRecord - rec)
+			}
+			out.Write([]byte{byte(rec >> 16), byte(rec >> 8), byte(rec)})
+		}
+	}
+	out.Write(make([]byte, dataSeparator))
+	out.Write(db.data.Bytes())
+	out.Write(metadataMarker)
+	out.Write(encodeMap(
+		"node_count", encodeUint(typeUint32, uint64(count)),
+		"record_size", encodeUint(typeUint16, 24),
+		"ip_version", encodeUint(typeUint16, uint64(db.ipVersion)),
+		"database_type", encodeString("Test-Country"),
+	))
+	return out.Bytes()
+}
+
+func (db *testDB) add(value []byte) int {
+	offset := db.data.Len()
+	db.data.Write(value)
+	return offset
+}
+
+func ctrl(kind, size int) []byte {
+	if kind > 7 {
+		return []byte{byte(size), byte(kind - 7)}
+	}
+	return []byte{byte(kind<<5 | size)}
+}
+
+func encodeString(s string) []byte {
+	return append(ctrl(typeString, len(s)), s...)
+}
+
+func encodeUint(kind int, v uint64) []byte {
+	var raw []byte
+	for ; v > 0; v >>= 8 {
+		raw = append([]byte{byte(v)}, raw...)
+	}
+	return append(ctrl(kind, len(raw)), raw...)
+}
+
+func encodeMap(pairs ...any) []byte {
+	out := ctrl(typeMap, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		out = append(out, encodeString(pairs[i].(string))...)
+		out = append(out, pairs[i+1].([]byte)...)
+	}
+	return out
+}
+
+func encodeArray(values ...[]byte) []byte {
+	out := ctrl(typeArray, len(values))
+	for _, v := range values {
+		out = append(out, v...)
+	}
+	return out
+}
+
+func encodePointer(offset int) []byte {
+	return []byte{byte(typePointer<<5 | (offset>>8)&0x7), byte(offset)}
+}
+
+func buildTestDB(t *testing.T, ipVersion int) *Reader {
+	db := newTestDB(ipVersion)
+	gb := db.add(encodeMap(
+		"country", encodeMap("iso_code", encodeString("GB")),
+		"subdivisions", encodeArray(encodeMap("iso_code", encodeString("ENG"))),
+	))
+	// Shares the country of the first record through a pointer.
+	shared := db.add(encodeMap("country", encodePointer(gb+1+len(encodeString("country")))))
+	us := db.add(encodeMap("country", encodeMap("iso_code", encodeString("US"))))
+	unknown := db.add(encodeMap("continent", encodeMap("code", encodeString("EU"))))
+
+	db.insert("81.2.69.0/24", gb)
+	db.insert("81.2.70.0/24", shared)
+	db.insert("216.160.83.0/28", us)
+	db.insert("89.160.20.0/24", unknown)
+	if ipVersion == 6 {
+		db.insert("2001:480::/32", us)
+	}
+
+	r, err := New(db.bytes())
+	require.NoError(t, err)
+	return r
+}
+
+func TestReaderLocate(t *testing.T) {
+	for _, ipVersion := range []int{4, 6} {
+		r := buildTestDB(t, ipVersion)
+
+		tests := []struct {
+			ip   string
+			want domain.GeoLocation
+			ok   bool
+		}{
+			{"81.2.69.160", domain.GeoLocation{Country: "GB", Region: "ENG"}, true},
+			{"81.2.70.1", domain.GeoLocation{Country: "GB"}, true},
+			{"216.160.83.10", domain.GeoLocation{Country: "US"}, true},
+			{"216.160.83.20", domain.GeoLocation{}, false},
+			{"89.160.20.1", domain.GeoLocation{}, false},
+			{"10.0.0.1", domain.GeoLocation{}, false},
+			{"2001:480::1", domain.GeoLocation{Country: "US"}, ipVersion == 6},
+		}
+		for _, tt := range tests {
+			got, ok := r.Locate(net.ParseIP(tt.ip))
+			assert.Equal(t, tt.ok, ok, "ipv%d %s", ipVersion, tt.ip)
+			if tt.ok {
+				assert.Equal(t, tt.want, got, "ipv%d %s", ipVersion, tt.ip)
+			}
+		}
+	}
+}
+
+func TestNewRejectsCorruptFiles(t *testing.T) {
+	_, err := New([]byte("not a database"))
+	assert.ErrorIs(t, err, errCorrupt)
+
+	db := newTestDB(4).bytes()
+	_, err = New(db[len(db)-20:])
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/metrics"
+	"go.uber.org/zap"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authenticate makes the user of the call's bearer token the current user,
+// with the roles the configuration grants them, and holds back users who
+// have not accepted the current terms, as the HTTP API does.
+func (s *Server) authenticate(ctx context.Context, req interface{}, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (interface{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, s.reject(info, auth.FailureMissing, "authorization metadata is required")
+	}
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || scheme != "Bearer" {
+		return nil, s.reject(info, auth.FailureMalformed, "invalid authorization metadata format")
+	}
+	claims, err := s.jwt.ValidateToken(token)
+	if err != nil {
+		return nil, s.reject(info, auth.FailureReason(err), err.Error())
+	}
+
+	principal := claims.Principal()
+	if principal.Role != auth.RoleAdmin {
+		if _, ok := s.admins[principal.ID]; ok {
+			principal.Role = auth.RoleAdmin
+		} else if _, ok := s.moderators[principal.ID]; ok {
+			principal.Role = auth.RoleModerator
+		}
+	}
+	ctx = auth.WithPrincipal(ctx, principal)
+
+	consent, err := s.service.GetConsentStatus(ctx, principal.ID)
+	if err != nil {
+		// Let the call through, like the HTTP API: everyone passed the
+		// check when they last accepted.
+		logging.For(ctx, s.logger).Warn("consent check failed, allowing call",
+			zap.Error(err),
+			zap.String("user_id", principal.ID.String()),
+		)
+	} else if len(consent.Pending) > 0 {
+		return nil, domain.ErrConsentRequired
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) reject(info *gogrpc.UnaryServerInfo, reason, message string) error {
+	metrics.AuthFailures.WithLabelValues(reason).Inc()
+	s.logger.Debug("grpc: rejected call",
+		zap.String("reason", reason),
+		zap.String("method", info.FullMethod),
+	)
+	return status.Error(codes.Unauthenticated, message)
+}
+
+// currentUser returns the user authenticate set on ctx.
+func currentUser(ctx context.Context) auth.Principal {
+	principal, _ := auth.CurrentUser(ctx)
+	return principal
+}
@@ -0,0 +1,183 @@
+package grpc
+
+import (
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/grpc/votepb"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// parseID parses a required ID field.
+func parseID(field, value string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, invalidArgument("invalid " + field)
+	}
+	return id, nil
+}
+
+// parseOptionalID parses an ID field that may be left empty.
+func parseOptionalID(field, value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := parseID(field, value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func idString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// optionalTime reads a timestamp field, checking it holds a valid time.
+func optionalTime(field string, ts *timestamppb.Timestamp) (*time.Time, error) {
+	if ts == nil {
+		return nil, nil
+	}
+	if err := ts.CheckValid(); err != nil {
+		return nil, invalidArgument("invalid " + field)
+	}
+	t := ts.AsTime()
+	return &t, nil
+}
+
+func toPoll(poll *domain.Poll) *votepb.Poll {
+	options := make([]*votepb.Option, len(poll.Options))
+	for i, option := range poll.Options {
+		options[i] = &votepb.Option{
+			Id:        option.ID.String(),
+			Text:      option.OptionText,
+			Index:     int32(option.OptionIndex),
+			Emoji:     option.Emoji,
+			ImageUrl:  option.ImageURL,
+			AltText:   option.AltText,
+			IsDefault: option.Default,
+		}
+	}
+	return &votepb.Poll{
+		Id:        poll.ID.String(),
+		Title:     poll.Title,
+		Options:   options,
+		Tags:      poll.Tags,
+		Protected: poll.Protected,
+		Status:    string(poll.Status),
+		CreatedAt: timestamp(poll.CreatedAt),
+		UpdatedAt: timestamp(poll.UpdatedAt),
+
+		CreatedBy:      idString(poll.CreatedBy),
+		OrganizationId: idString(poll.OrganizationID),
+		Electorate:     string(poll.Electorate),
+
+		Kind:     string(poll.Kind),
+		StartsAt: optionalTimestamp(poll.StartsAt),
+		EndsAt:   optionalTimestamp(poll.EndsAt),
+
+		PublicResults:             poll.PublicResults,
+		ResultsVisibility:         string(poll.ResultsVisibility),
+		VoteChange:                string(poll.VoteChange),
+		VoteChangeCooldownSeconds: int32(poll.VoteChangeCooldownSeconds),
+		OrganizationVotes:         poll.OrganizationVotes,
+
+		Anonymous:        poll.Anonymous,
+		AllowedCountries: poll.AllowedCountries,
+		Language:         poll.Language,
+		PendingReview:    poll.PendingReview,
+	}
+}
+
+func toOptionStats(stats []domain.OptionStats) []*votepb.OptionStats {
+	if stats == nil {
+		return nil
+	}
+	out := make([]*votepb.OptionStats, len(stats))
+	for i, s := range stats {
+		out[i] = &votepb.OptionStats{
+			Option:     s.Option,
+			Count:      int32(s.Count),
+			Percentage: s.Percentage,
+		}
+	}
+	return out
+}
+
+func toPollStats(stats *domain.PollStats) *votepb.PollStats {
+	out := &votepb.PollStats{
+		PollId:            stats.PollID.String(),
+		Votes:             toOptionStats(stats.Votes),
+		TotalVotes:        int32(stats.TotalVotes),
+		MyOption:          stats.MyOption,
+		OrganizationVotes: toOptionStats(stats.OrganizationVotes),
+		ComputedAt:        timestamp(stats.ComputedAt),
+	}
+	if stats.Turnout != nil {
+		out.Turnout = &votepb.Turnout{
+			Voted:    int32(stats.Turnout.Voted),
+			Eligible: int32(stats.Turnout.Eligible),
+		}
+	}
+	return out
+}
+
+func toVoteReceipt(receipt *domain.VoteReceipt) *votepb.VoteReceipt {
+	return &votepb.VoteReceipt{
+		VoteId:      receipt.VoteID.String(),
+		PollId:      receipt.PollID.String(),
+		PollTitle:   receipt.PollTitle,
+		OptionId:    receipt.OptionID.String(),
+		OptionIndex: int32(receipt.OptionIndex),
+		OptionText:  receipt.OptionText,
+		CreatedAt:   timestamp(receipt.CreatedAt),
+		Signature:   receipt.Signature,
+	}
+}
+
+func toVoteRecord(vote *domain.VoteResponse) *votepb.VoteRecord {
+	return &votepb.VoteRecord{
+		Id:          vote.ID.String(),
+		PollId:      vote.PollID.String(),
+		OptionId:    vote.OptionID.String(),
+		CreatedAt:   timestamp(vote.CreatedAt),
+		PollTitle:   vote.PollTitle,
+		OptionText:  vote.OptionText,
+		DeletedAt:   optionalTimestamp(vote.DeletedAt),
+		OptionIndex: int32(vote.OptionIndex),
+		PollStatus:  string(vote.PollStatus),
+		PollOpen:    vote.PollOpen,
+		PollEndsAt:  optionalTimestamp(vote.PollEndsAt),
+	}
+}
+
+func toUser(user *domain.User) *votepb.User {
+	return &votepb.User{
+		Id:            user.ID.String(),
+		Username:      user.Username,
+		Email:         user.Email,
+		CreatedAt:     timestamp(user.CreatedAt),
+		UpdatedAt:     timestamp(user.UpdatedAt),
+		AvatarUrl:     user.AvatarURL,
+		Role:          string(user.Role),
+		EmailVerified: user.EmailVerified,
+	}
+}
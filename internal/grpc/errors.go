@@ -0,0 +1,63 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"go.uber.org/zap"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// codeByClass answers each class of domain.ErrorKinds as the HTTP API does:
+// a 403 is PERMISSION_DENIED, a 409 FAILED_PRECONDITION or ALREADY_EXISTS
+// and a 429 RESOURCE_EXHAUSTED.
+var codeByClass = map[domain.ErrorClass]codes.Code{
+	domain.ClassInvalid:              codes.InvalidArgument,
+	domain.ClassUnauthenticated:      codes.Unauthenticated,
+	domain.ClassForbidden:            codes.PermissionDenied,
+	domain.ClassNotFound:             codes.NotFound,
+	domain.ClassAlreadyExists:        codes.AlreadyExists,
+	domain.ClassConflict:             codes.FailedPrecondition,
+	domain.ClassTooLarge:             codes.InvalidArgument,
+	domain.ClassUnsupportedType:      codes.InvalidArgument,
+	domain.ClassPreconditionRequired: codes.FailedPrecondition,
+	domain.ClassLimited:              codes.ResourceExhausted,
+	domain.ClassUnavailable:          codes.Unavailable,
+}
+
+// translateErrors answers the errors of the service with the code of their
+// class in domain.ErrorKinds. Anything unclassified is logged and answered
+// with INTERNAL.
+func (s *Server) translateErrors(ctx context.Context, req interface{}, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err == nil {
+		return resp, nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return nil, err
+	}
+	if kind, ok := domain.ClassifyError(err); ok {
+		message := kind.Message
+		if message == "" {
+			message = err.Error()
+		}
+		return nil, status.Error(codeByClass[kind.Class], message)
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return nil, status.FromContextError(err).Err()
+	}
+	logging.For(ctx, s.logger).Error("grpc call failed",
+		zap.Error(err),
+		zap.String("method", info.FullMethod),
+	)
+	return nil, status.Error(codes.Internal, "Internal server error")
+}
+
+// invalidArgument answers a malformed request.
+func invalidArgument(message string) error {
+	return status.Error(codes.InvalidArgument, message)
+}
@@ -0,0 +1,174 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/grpc/votepb"
+)
+
+type pollServer struct {
+	votepb.UnimplementedPollServiceServer
+	*Server
+}
+
+func (s *pollServer) CreatePoll(ctx context.Context, req *votepb.CreatePollRequest) (*votepb.Poll, error) {
+	organizationID, err := parseOptionalID("organization_id", req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+	startsAt, err := optionalTime("starts_at", req.StartsAt)
+	if err != nil {
+		return nil, err
+	}
+	endsAt, err := optionalTime("ends_at", req.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceReq := &domain.CreatePollRequest{
+		Title:      req.Title,
+		Options:    req.Options,
+		Tags:       req.Tags,
+		AccessCode: req.AccessCode,
+
+		OptionEmojis: req.OptionEmojis,
+
+		CreatorID:      currentUser(ctx).ID,
+		OrganizationID: organizationID,
+		EligibleEmails: req.EligibleEmails,
+
+		Kind:     domain.PollKind(req.Kind),
+		StartsAt: startsAt,
+		EndsAt:   endsAt,
+
+		PublicResults:     req.PublicResults,
+		ResultsVisibility: domain.ResultsVisibility(req.ResultsVisibility),
+		VoteChange:        domain.VoteChangePolicy(req.VoteChange),
+		OrganizationVotes: req.OrganizationVotes,
+		Draft:             req.Draft,
+
+		VoteChangeCooldownSeconds: int(req.VoteChangeCooldownSeconds),
+
+		Anonymous:        req.Anonymous,
+		AllowedCountries: req.AllowedCountries,
+	}
+	if req.DefaultOption != nil {
+		defaultOption := int(*req.DefaultOption)
+		serviceReq.DefaultOption = &defaultOption
+	}
+	// The HTTP API checks these with binding tags before the service sees
+	// the request.
+	if serviceReq.Title == "" || len(serviceReq.Options) < 2 || len(serviceReq.Tags) < 1 {
+		return nil, invalidArgument("title, at least two options and a tag are required")
+	}
+
+	poll, err := s.service.CreatePoll(ctx, serviceReq)
+	if err != nil {
+		return nil, err
+	}
+	return toPoll(poll), nil
+}
+
+func (s *pollServer) GetPoll(ctx context.Context, req *votepb.GetPollRequest) (*votepb.Poll, error) {
+	id, err := parseID("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	poll, err := s.service.GetPollByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toPoll(poll), nil
+}
+
+func (s *pollServer) ListFeed(ctx context.Context, req *votepb.ListFeedRequest) (*votepb.ListFeedResponse, error) {
+	query := domain.FeedQuery{
+		UserID:       currentUser(ctx).ID,
+		Tag:          req.Tag,
+		Page:         int(req.Page),
+		Limit:        int(req.Limit),
+		Languages:    req.Languages,
+		AllLanguages: req.AllLanguages,
+	}
+	if query.Page == 0 {
+		query.Page = domain.DefaultPage
+	}
+	if query.Limit == 0 {
+		query.Limit = domain.DefaultLimit
+	}
+	if query.Page < 1 {
+		return nil, invalidArgument("invalid page number")
+	}
+	if query.Limit < 1 || query.Limit > domain.MaxPageSize {
+		return nil, invalidArgument("invalid limit")
+	}
+	switch req.Total {
+	case votepb.FeedTotal_FEED_TOTAL_EXACT:
+	case votepb.FeedTotal_FEED_TOTAL_ESTIMATE:
+		query.Total = domain.FeedTotalEstimate
+	case votepb.FeedTotal_FEED_TOTAL_NONE:
+		query.Total = domain.FeedTotalNone
+	default:
+		return nil, invalidArgument("invalid total")
+	}
+	if req.Cursor != "" {
+		after, err := domain.DecodeFeedCursor(req.Cursor)
+		if err != nil {
+			return nil, invalidArgument("invalid cursor")
+		}
+		query.After = after
+	}
+
+	response, err := s.service.GetPollsForFeed(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	polls := make([]*votepb.Poll, len(response.Polls))
+	for i := range response.Polls {
+		polls[i] = toPoll(&response.Polls[i])
+	}
+	return &votepb.ListFeedResponse{
+		Polls:          polls,
+		Total:          int32(response.Total),
+		TotalEstimated: response.TotalEstimated,
+		Page:           int32(response.Page),
+		Limit:          int32(response.Limit),
+		NextCursor:     response.NextCursor,
+	}, nil
+}
+
+func (s *pollServer) GetPollStats(ctx context.Context, req *votepb.GetPollStatsRequest) (*votepb.PollStats, error) {
+	pollID, err := parseID("poll_id", req.PollId)
+	if err != nil {
+		return nil, err
+	}
+	query := domain.StatsQuery{ActorID: currentUser(ctx).ID}
+	if req.MaxAgeSeconds != nil {
+		if *req.MaxAgeSeconds < 0 {
+			return nil, invalidArgument("max_age_seconds must not be negative")
+		}
+		maxAge := time.Duration(*req.MaxAgeSeconds) * time.Second
+		query.MaxAge = &maxAge
+	}
+
+	stats, err := s.service.GetPollStats(ctx, pollID, query)
+	if err != nil {
+		return nil, err
+	}
+	return toPollStats(stats), nil
+}
+
+func (s *pollServer) ClosePoll(ctx context.Context, req *votepb.ClosePollRequest) (*votepb.ClosePollResponse, error) {
+	pollID, err := parseID("poll_id", req.PollId)
+	if err != nil {
+		return nil, err
+	}
+	principal := currentUser(ctx)
+	if err := s.service.ClosePoll(ctx, pollID, principal.ID, principal.Role == auth.RoleAdmin); err != nil {
+		return nil, err
+	}
+	return &votepb.ClosePollResponse{}, nil
+}
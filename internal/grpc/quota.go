@@ -0,0 +1,65 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/grpc/votepb"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/metrics"
+	"github.com/behzadon/vote/internal/quota"
+	"go.uber.org/zap"
+	gogrpc "google.golang.org/grpc"
+)
+
+// quotaActions are the calls counted against the per-user quotas, the same
+// as their routes in the HTTP API.
+var quotaActions = map[string]domain.QuotaAction{
+	votepb.PollService_CreatePoll_FullMethodName: domain.QuotaPollsCreated,
+	votepb.VoteService_CastVote_FullMethodName:   domain.QuotaVotesCast,
+}
+
+// WithQuotaManager counts poll creation and votes against the per-user
+// quotas, as api.WithQuotaManager does for the HTTP API.
+func WithQuotaManager(m *quota.Manager) ServerOption {
+	return func(s *Server) {
+		s.quotas = m
+	}
+}
+
+// limitQuota reserves a unit of the call's quota for the current user and
+// commits it when the call succeeds. An exhausted quota is answered with
+// RESOURCE_EXHAUSTED.
+func (s *Server) limitQuota(ctx context.Context, req interface{}, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (interface{}, error) {
+	action, ok := quotaActions[info.FullMethod]
+	if !ok || s.quotas == nil {
+		return handler(ctx, req)
+	}
+
+	principal := currentUser(ctx)
+	res, err := s.quotas.Reserve(ctx, principal.ID, action)
+	var exceeded *domain.QuotaExceededError
+	if errors.As(err, &exceeded) {
+		metrics.QuotaExceeded.WithLabelValues(string(exceeded.Usage.Action), string(exceeded.Usage.Period)).Inc()
+		return nil, err
+	}
+	if err != nil {
+		// Let the call through, like the HTTP API: quotas are an accounting
+		// limit, not a protection mechanism.
+		logging.For(ctx, s.logger).Warn("quota check failed, allowing call",
+			zap.Error(err),
+			zap.String("user_id", principal.ID.String()),
+			zap.String("action", string(action)),
+		)
+		return handler(ctx, req)
+	}
+
+	resp, err := handler(ctx, req)
+	if err != nil {
+		s.quotas.Release(ctx, res)
+		return nil, err
+	}
+	s.quotas.Commit(ctx, res)
+	return resp, nil
+}
@@ -0,0 +1,122 @@
+// Package grpc serves the poll, vote and user operations of service.Service
+// over gRPC, for other services in the platform. Its messages are defined in
+// proto/vote.proto and generated into votepb.
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/grpc/votepb"
+	"github.com/behzadon/vote/internal/quota"
+	"github.com/behzadon/vote/internal/service"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	gogrpc "google.golang.org/grpc"
+)
+
+// Server answers gRPC calls with the service, on behalf of the user named by
+// each call's access token.
+type Server struct {
+	service service.Service
+	jwt     *auth.JWTManager
+	logger  *zap.Logger
+
+	moderators map[uuid.UUID]struct{}
+	admins     map[uuid.UUID]struct{}
+	quotas     *quota.Manager
+
+	server *gogrpc.Server
+	calls  atomic.Int64
+}
+
+type ServerOption func(*Server)
+
+// WithModerators grants the given users the moderator role, as
+// moderation.moderators does for the HTTP API.
+func WithModerators(ids []uuid.UUID) ServerOption {
+	return func(s *Server) {
+		s.moderators = make(map[uuid.UUID]struct{}, len(ids))
+		for _, id := range ids {
+			s.moderators[id] = struct{}{}
+		}
+	}
+}
+
+// WithAdmins grants the given users the admin role, as moderation.admins
+// does for the HTTP API.
+func WithAdmins(ids []uuid.UUID) ServerOption {
+	return func(s *Server) {
+		s.admins = make(map[uuid.UUID]struct{}, len(ids))
+		for _, id := range ids {
+			s.admins[id] = struct{}{}
+		}
+	}
+}
+
+func NewServer(svc service.Service, jwtManager *auth.JWTManager, logger *zap.Logger, opts ...ServerOption) *Server {
+	s := &Server{
+		service: svc,
+		jwt:     jwtManager,
+		logger:  logger,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.server = gogrpc.NewServer(gogrpc.ChainUnaryInterceptor(s.track, s.translateErrors, s.authenticate, s.limitQuota))
+	votepb.RegisterPollServiceServer(s.server, &pollServer{Server: s})
+	votepb.RegisterVoteServiceServer(s.server, &voteServer{Server: s})
+	votepb.RegisterUserServiceServer(s.server, &userServer{Server: s})
+	return s
+}
+
+// Serve answers calls on lis until Stop is called.
+func (s *Server) Serve(lis net.Listener) error {
+	if err := s.server.Serve(lis); err != nil {
+		return fmt.Errorf("serve grpc: %w", err)
+	}
+	return nil
+}
+
+// ListenAndServe listens on the TCP port and answers calls until Stop is
+// called.
+func (s *Server) ListenAndServe(port int) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	return s.Serve(lis)
+}
+
+// InFlight counts the calls being answered.
+func (s *Server) InFlight(context.Context) (int, error) {
+	return int(s.calls.Load()), nil
+}
+
+func (s *Server) track(ctx context.Context, req interface{}, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (interface{}, error) {
+	s.calls.Add(1)
+	defer s.calls.Add(-1)
+	return handler(ctx, req)
+}
+
+// Stop stops accepting calls and waits for the running ones to finish. When
+// ctx is done first, the remaining calls are canceled.
+func (s *Server) Stop(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		<-done
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,506 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/grpc/votepb"
+	"github.com/behzadon/vote/internal/quota"
+	"github.com/behzadon/vote/internal/service"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+type testClient struct {
+	polls votepb.PollServiceClient
+	votes votepb.VoteServiceClient
+	users votepb.UserServiceClient
+	jwt   *auth.JWTManager
+}
+
+func newTestServer(t *testing.T, svc *service.MockService, opts ...ServerOption) *testClient {
+	t.Helper()
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+	server := NewServer(svc, jwtManager, zap.NewNop(), opts...)
+
+	lis := bufconn.Listen(1 << 20)
+	go server.Serve(lis)
+	t.Cleanup(func() { server.Stop(context.Background()) })
+
+	conn, err := gogrpc.DialContext(context.Background(), "bufnet",
+		gogrpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		gogrpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return &testClient{
+		polls: votepb.NewPollServiceClient(conn),
+		votes: votepb.NewVoteServiceClient(conn),
+		users: votepb.NewUserServiceClient(conn),
+		jwt:   jwtManager,
+	}
+}
+
+// as returns a context calling on behalf of user.
+func (c *testClient) as(t *testing.T, user *domain.User) context.Context {
+	t.Helper()
+	token, err := c.jwt.GenerateToken(user)
+	require.NoError(t, err)
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+// consented lets user through the consent check.
+func consented(svc *service.MockService, user *domain.User) {
+	svc.On("GetConsentStatus", mock.Anything, user.ID).Return(&domain.ConsentStatus{}, nil)
+}
+
+// callerIs matches a context carrying user as the current user.
+func callerIs(user *domain.User) interface{} {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		principal, ok := auth.CurrentUser(ctx)
+		return ok && principal.ID == user.ID
+	})
+}
+
+func TestAuthentication(t *testing.T) {
+	user := &domain.User{ID: uuid.New(), Username: "alice", Role: domain.RoleUser}
+	pollID := uuid.New()
+
+	t.Run("missing token", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+
+		_, err := client.polls.GetPoll(context.Background(), &votepb.GetPollRequest{Id: pollID.String()})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		svc.AssertNotCalled(t, "GetPollByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer not-a-token")
+		_, err := client.polls.GetPoll(ctx, &votepb.GetPollRequest{Id: pollID.String()})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("terms not accepted", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		svc.On("GetConsentStatus", mock.Anything, user.ID).
+			Return(&domain.ConsentStatus{Pending: []domain.ConsentDocument{domain.ConsentTerms}}, nil)
+
+		_, err := client.polls.GetPoll(client.as(t, user), &votepb.GetPollRequest{Id: pollID.String()})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		svc.AssertNotCalled(t, "GetPollByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("consent check failing lets the call through", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		svc.On("GetConsentStatus", mock.Anything, user.ID).Return(nil, errors.New("connection refused"))
+		svc.On("GetPollByID", callerIs(user), pollID).Return(&domain.Poll{ID: pollID}, nil)
+
+		_, err := client.polls.GetPoll(client.as(t, user), &votepb.GetPollRequest{Id: pollID.String()})
+		require.NoError(t, err)
+	})
+}
+
+func TestPollService(t *testing.T) {
+	user := &domain.User{ID: uuid.New(), Username: "alice", Role: domain.RoleUser}
+	pollID, optionID := uuid.New(), uuid.New()
+	createdAt := time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC)
+	poll := &domain.Poll{
+		ID:        pollID,
+		Title:     "Best language?",
+		Options:   []domain.Option{{ID: optionID, OptionText: "Go", Default: true}, {ID: uuid.New(), OptionText: "Rust", OptionIndex: 1}},
+		Tags:      []string{"programming"},
+		Status:    domain.PollStatusLive,
+		CreatedAt: createdAt,
+		CreatedBy: &user.ID,
+	}
+
+	t.Run("create poll", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		consented(svc, user)
+		svc.On("CreatePoll", callerIs(user), mock.MatchedBy(func(req *domain.CreatePollRequest) bool {
+			return req.CreatorID == user.ID && req.Title == "Best language?" &&
+				req.DefaultOption != nil && *req.DefaultOption == 0 && req.EndsAt != nil && req.EndsAt.Equal(createdAt.Add(time.Hour))
+		})).Return(poll, nil)
+
+		got, err := client.polls.CreatePoll(client.as(t, user), &votepb.CreatePollRequest{
+			Title:         "Best language?",
+			Options:       []string{"Go", "Rust"},
+			Tags:          []string{"programming"},
+			DefaultOption: proto.Int32(0),
+			EndsAt:        timestamp(createdAt.Add(time.Hour)),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, pollID.String(), got.Id)
+		assert.Equal(t, user.ID.String(), got.CreatedBy)
+		require.Len(t, got.Options, 2)
+		assert.Equal(t, optionID.String(), got.Options[0].Id)
+		assert.True(t, got.Options[0].IsDefault)
+		assert.Equal(t, int32(1), got.Options[1].Index)
+		assert.True(t, createdAt.Equal(got.CreatedAt.AsTime()))
+		assert.Nil(t, got.EndsAt)
+	})
+
+	t.Run("create poll without options", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		consented(svc, user)
+
+		_, err := client.polls.CreatePoll(client.as(t, user), &votepb.CreatePollRequest{Title: "Best language?", Tags: []string{"programming"}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		svc.AssertNotCalled(t, "CreatePoll", mock.Anything, mock.Anything)
+	})
+
+	t.Run("get poll", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		consented(svc, user)
+		svc.On("GetPollByID", callerIs(user), pollID).Return(poll, nil)
+
+		got, err := client.polls.GetPoll(client.as(t, user), &votepb.GetPollRequest{Id: pollID.String()})
+		require.NoError(t, err)
+		assert.Equal(t, "Best language?", got.Title)
+		assert.Equal(t, "live", got.Status)
+	})
+
+	t.Run("poll not found", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		consented(svc, user)
+		svc.On("GetPollByID", mock.Anything, pollID).Return(nil, domain.ErrNotFound)
+
+		_, err := client.polls.GetPoll(client.as(t, user), &votepb.GetPollRequest{Id: pollID.String()})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("invalid poll id", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		consented(svc, user)
+
+		_, err := client.polls.GetPoll(client.as(t, user), &votepb.GetPollRequest{Id: "nope"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("feed", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		consented(svc, user)
+		cursor := domain.FeedCursor{CreatedAt: createdAt, ID: pollID}
+		svc.On("GetPollsForFeed", mock.Anything, mock.MatchedBy(func(q domain.FeedQuery) bool {
+			return q.UserID == user.ID && q.Tag == "programming" && q.Page == 1 && q.Limit == 10 &&
+				q.Total == domain.FeedTotalNone && q.After != nil && q.After.ID == pollID
+		})).Return(&domain.PollFeedResponse{Polls: []domain.Poll{*poll}, Page: 1, Limit: 10, NextCursor: "next"}, nil)
+
+		got, err := client.polls.ListFeed(client.as(t, user), &votepb.ListFeedRequest{
+			Tag:    "programming",
+			Cursor: cursor.Encode(),
+			Total:  votepb.FeedTotal_FEED_TOTAL_NONE,
+		})
+		require.NoError(t, err)
+		require.Len(t, got.Polls, 1)
+		assert.Equal(t, pollID.String(), got.Polls[0].Id)
+		assert.Equal(t, "next", got.NextCursor)
+	})
+
+	t.Run("feed with invalid cursor", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		consented(svc, user)
+
+		_, err := client.polls.ListFeed(client.as(t, user), &votepb.ListFeedRequest{Cursor: "!!"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("stats bypassing the cache", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		consented(svc, user)
+		svc.On("GetPollStats", mock.Anything, pollID, mock.MatchedBy(func(q domain.StatsQuery) bool {
+			return q.ActorID == user.ID && q.MaxAge != nil && *q.MaxAge == 0
+		})).Return(&domain.PollStats{
+			PollID:     pollID,
+			Votes:      []domain.OptionStats{{Option: "Go", Count: 3, Percentage: 75}, {Option: "Rust", Count: 1, Percentage: 25}},
+			TotalVotes: 4,
+		}, nil)
+
+		got, err := client.polls.GetPollStats(client.as(t, user), &votepb.GetPollStatsRequest{PollId: pollID.String(), MaxAgeSeconds: proto.Int32(0)})
+		require.NoError(t, err)
+		assert.Equal(t, int32(4), got.TotalVotes)
+		require.Len(t, got.Votes, 2)
+		assert.Equal(t, 75.0, got.Votes[0].Percentage)
+		assert.Nil(t, got.ComputedAt)
+	})
+
+	t.Run("close poll as configured admin", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc, WithAdmins([]uuid.UUID{user.ID}))
+		consented(svc, user)
+		svc.On("ClosePoll", mock.Anything, pollID, user.ID, true).Return(nil)
+
+		_, err := client.polls.ClosePoll(client.as(t, user), &votepb.ClosePollRequest{PollId: pollID.String()})
+		require.NoError(t, err)
+		svc.AssertExpectations(t)
+	})
+
+	t.Run("close poll of someone else", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		consented(svc, user)
+		svc.On("ClosePoll", mock.Anything, pollID, user.ID, false).Return(domain.ErrForbidden)
+
+		_, err := client.polls.ClosePoll(client.as(t, user), &votepb.ClosePollRequest{PollId: pollID.String()})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+}
+
+func TestVoteService(t *testing.T) {
+	user := &domain.User{ID: uuid.New(), Username: "alice", Role: domain.RoleUser}
+	pollID, optionID, voteID := uuid.New(), uuid.New(), uuid.New()
+	receipt := &domain.VoteReceipt{VoteID: voteID, PollID: pollID, OptionID: optionID, OptionIndex: 1, OptionText: "Rust", CreatedAt: time.Now().UTC()}
+
+	t.Run("vote by index", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		consented(svc, user)
+		svc.On("VoteOnPoll", mock.Anything, pollID, mock.MatchedBy(func(req *domain.VoteRequest) bool {
+			return req.UserID == user.ID && req.OptionID == nil && req.OptionIndex == 1 && !req.UseDefault
+		})).Return(receipt, nil)
+
+		got, err := client.votes.CastVote(client.as(t, user), &votepb.CastVoteRequest{PollId: pollID.String(), OptionIndex: proto.Int32(1)})
+		require.NoError(t, err)
+		assert.Equal(t, voteID.String(), got.VoteId)
+		assert.Equal(t, "Rust", got.OptionText)
+	})
+
+	t.Run("vote by option id", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		consented(svc, user)
+		svc.On("VoteOnPoll", mock.Anything, pollID, mock.MatchedBy(func(req *domain.VoteRequest) bool {
+			return req.OptionID != nil && *req.OptionID == optionID && !req.UseDefault
+		})).Return(receipt, nil)
+
+		_, err := client.votes.CastVote(client.as(t, user), &votepb.CastVoteRequest{PollId: pollID.String(), OptionId: proto.String(optionID.String())})
+		require.NoError(t, err)
+	})
+
+	t.Run("vote without option uses the default", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		consented(svc, user)
+		svc.On("VoteOnPoll", mock.Anything, pollID, mock.MatchedBy(func(req *domain.VoteRequest) bool {
+			return req.UseDefault
+		})).Return(nil, domain.ErrOptionRequired)
+
+		_, err := client.votes.CastVote(client.as(t, user), &votepb.CastVoteRequest{PollId: pollID.String()})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("already voted", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		consented(svc, user)
+		svc.On("VoteOnPoll", mock.Anything, pollID, mock.Anything).
+			Return(nil, &domain.AlreadyVotedError{VoteID: voteID, OptionIndex: 0})
+
+		_, err := client.votes.CastVote(client.as(t, user), &votepb.CastVoteRequest{PollId: pollID.String(), OptionIndex: proto.Int32(1)})
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		consented(svc, user)
+		svc.On("SkipPoll", mock.Anything, pollID, &domain.SkipRequest{UserID: user.ID, Reason: domain.SkipNotInterested}).Return(nil)
+
+		_, err := client.votes.SkipPoll(client.as(t, user), &votepb.SkipPollRequest{PollId: pollID.String(), Reason: "not_interested"})
+		require.NoError(t, err)
+		svc.AssertExpectations(t)
+	})
+
+	t.Run("unexpected error", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		consented(svc, user)
+		svc.On("SkipPoll", mock.Anything, pollID, mock.Anything).Return(errors.New("pq: connection reset"))
+
+		_, err := client.votes.SkipPoll(client.as(t, user), &votepb.SkipPollRequest{PollId: pollID.String()})
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.NotContains(t, status.Convert(err).Message(), "pq")
+	})
+}
+
+func TestUserService(t *testing.T) {
+	user := &domain.User{ID: uuid.New(), Username: "alice", Email: "alice@example.com", Role: domain.RoleUser, EmailVerified: true}
+
+	t.Run("current user", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		consented(svc, user)
+		svc.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+
+		got, err := client.users.GetCurrentUser(client.as(t, user), &votepb.GetCurrentUserRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "alice", got.Username)
+		assert.Equal(t, "alice@example.com", got.Email)
+		assert.True(t, got.EmailVerified)
+	})
+
+	t.Run("votes", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		consented(svc, user)
+		from := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+		voteID := uuid.New()
+		svc.On("GetUserVotes", mock.Anything, user.ID, mock.MatchedBy(func(f domain.VoteFilter) bool {
+			return f.From != nil && f.From.Equal(from) && f.To == nil && f.IncludeDeleted
+		}), 2, 5).Return(&domain.UserVotesResponse{
+			Votes: []domain.VoteResponse{{ID: voteID, PollStatus: domain.PollStatusClosed}},
+			Total: 6,
+			Page:  2,
+			Limit: 5,
+		}, nil)
+
+		got, err := client.users.ListVotes(client.as(t, user), &votepb.ListVotesRequest{Page: 2, Limit: 5, From: timestamp(from), IncludeDeleted: true})
+		require.NoError(t, err)
+		require.Len(t, got.Votes, 1)
+		assert.Equal(t, voteID.String(), got.Votes[0].Id)
+		assert.Equal(t, "closed", got.Votes[0].PollStatus)
+		assert.Equal(t, int32(6), got.Total)
+	})
+
+	t.Run("votes with inverted range", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc)
+		consented(svc, user)
+		from := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+		_, err := client.users.ListVotes(client.as(t, user), &votepb.ListVotesRequest{From: timestamp(from), To: timestamp(from.Add(-time.Hour))})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestErrorCodes(t *testing.T) {
+	for _, kind := range domain.ErrorKinds {
+		_, ok := codeByClass[kind.Class]
+		assert.True(t, ok, "no code for %s", kind.Code)
+	}
+}
+
+// quotaCounter stands in for the Redis counters of the quota manager.
+type quotaCounter struct {
+	values map[string]int64
+}
+
+func (c *quotaCounter) IncrBy(ctx context.Context, key string, value int64) *redis.IntCmd {
+	c.values[key] += value
+	cmd := redis.NewIntCmd(ctx)
+	cmd.SetVal(c.values[key])
+	return cmd
+}
+
+func (c *quotaCounter) ExpireAt(ctx context.Context, key string, tm time.Time) *redis.BoolCmd {
+	cmd := redis.NewBoolCmd(ctx)
+	cmd.SetVal(true)
+	return cmd
+}
+
+// quotaRepo records no usage and no per-user overrides.
+type quotaRepo struct {
+	domain.Repository
+}
+
+func (quotaRepo) GetUserQuotas(ctx context.Context, userID uuid.UUID) ([]domain.Quota, error) {
+	return nil, nil
+}
+
+func (quotaRepo) GetQuotaUsage(ctx context.Context, userID uuid.UUID, action domain.QuotaAction, period domain.QuotaPeriod, periodStart time.Time) (int, error) {
+	return 0, nil
+}
+
+func (quotaRepo) IncrementQuotaUsage(ctx context.Context, userID uuid.UUID, action domain.QuotaAction, period domain.QuotaPeriod, periodStart time.Time) error {
+	return nil
+}
+
+func TestQuotas(t *testing.T) {
+	user := &domain.User{ID: uuid.New(), Username: "alice", Role: domain.RoleUser}
+	pollID := uuid.New()
+	receipt := &domain.VoteReceipt{VoteID: uuid.New(), PollID: pollID, OptionID: uuid.New(), CreatedAt: time.Now().UTC()}
+	newQuotas := func() *quota.Manager {
+		return quota.NewManager(&quotaCounter{values: make(map[string]int64)}, quotaRepo{}, []domain.Quota{
+			{Action: domain.QuotaVotesCast, Period: domain.QuotaDaily, Limit: 1},
+			{Action: domain.QuotaPollsCreated, Period: domain.QuotaDaily, Limit: 1},
+		}, zap.NewNop())
+	}
+
+	t.Run("votes over the quota", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc, WithQuotaManager(newQuotas()))
+		consented(svc, user)
+		svc.On("VoteOnPoll", mock.Anything, pollID, mock.Anything).Return(receipt, nil).Once()
+
+		req := &votepb.CastVoteRequest{PollId: pollID.String(), OptionIndex: proto.Int32(0)}
+		_, err := client.votes.CastVote(client.as(t, user), req)
+		require.NoError(t, err)
+
+		_, err = client.votes.CastVote(client.as(t, user), req)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		svc.AssertNumberOfCalls(t, "VoteOnPoll", 1)
+	})
+
+	t.Run("failed votes are not counted", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc, WithQuotaManager(newQuotas()))
+		consented(svc, user)
+		svc.On("VoteOnPoll", mock.Anything, pollID, mock.Anything).Return(nil, domain.ErrPollNotOpen).Once()
+		svc.On("VoteOnPoll", mock.Anything, pollID, mock.Anything).Return(receipt, nil).Once()
+
+		req := &votepb.CastVoteRequest{PollId: pollID.String(), OptionIndex: proto.Int32(0)}
+		_, err := client.votes.CastVote(client.as(t, user), req)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+		_, err = client.votes.CastVote(client.as(t, user), req)
+		require.NoError(t, err)
+	})
+
+	t.Run("polls over the quota", func(t *testing.T) {
+		svc := new(service.MockService)
+		client := newTestServer(t, svc, WithQuotaManager(newQuotas()))
+		consented(svc, user)
+		svc.On("CreatePoll", mock.Anything, mock.Anything).Return(&domain.Poll{ID: pollID, Title: "Languages", CreatedBy: &user.ID}, nil).Once()
+
+		req := &votepb.CreatePollRequest{Title: "Languages", Options: []string{"Go", "Rust"}, Tags: []string{"programming"}}
+		_, err := client.polls.CreatePoll(client.as(t, user), req)
+		require.NoError(t, err)
+
+		_, err = client.polls.CreatePoll(client.as(t, user), req)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		svc.AssertNumberOfCalls(t, "CreatePoll", 1)
+	})
+}
@@ -0,0 +1,53 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/grpc/votepb"
+)
+
+type userServer struct {
+	votepb.UnimplementedUserServiceServer
+	*Server
+}
+
+func (s *userServer) GetCurrentUser(ctx context.Context, req *votepb.GetCurrentUserRequest) (*votepb.User, error) {
+	user, err := s.service.GetUserByID(ctx, currentUser(ctx).ID)
+	if err != nil {
+		return nil, err
+	}
+	return toUser(user), nil
+}
+
+// ListVotes pages through the caller's votes, newest first. Page and limit
+// fall back to the defaults when they are out of range, as on the HTTP API.
+func (s *userServer) ListVotes(ctx context.Context, req *votepb.ListVotesRequest) (*votepb.ListVotesResponse, error) {
+	var filter domain.VoteFilter
+	var err error
+	if filter.From, err = optionalTime("from", req.From); err != nil {
+		return nil, err
+	}
+	if filter.To, err = optionalTime("to", req.To); err != nil {
+		return nil, err
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, invalidArgument("from must be before to")
+	}
+	filter.IncludeDeleted = req.IncludeDeleted
+
+	response, err := s.service.GetUserVotes(ctx, currentUser(ctx).ID, filter, int(req.Page), int(req.Limit))
+	if err != nil {
+		return nil, err
+	}
+	votes := make([]*votepb.VoteRecord, len(response.Votes))
+	for i := range response.Votes {
+		votes[i] = toVoteRecord(&response.Votes[i])
+	}
+	return &votepb.ListVotesResponse{
+		Votes: votes,
+		Total: int32(response.Total),
+		Page:  int32(response.Page),
+		Limit: int32(response.Limit),
+	}, nil
+}
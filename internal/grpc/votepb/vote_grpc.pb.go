@@ -0,0 +1,561 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: vote.proto
+
+// The gRPC API is for other services in the platform. It exposes the same
+// operations as the HTTP API on behalf of the user whose access token is
+// sent in the "authorization" metadata as "Bearer <token>". IDs are the
+// stored UUIDs, whatever public_ids.encoding the HTTP API uses.
+
+package votepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	PollService_CreatePoll_FullMethodName   = "/vote.v1.PollService/CreatePoll"
+	PollService_GetPoll_FullMethodName      = "/vote.v1.PollService/GetPoll"
+	PollService_ListFeed_FullMethodName     = "/vote.v1.PollService/ListFeed"
+	PollService_GetPollStats_FullMethodName = "/vote.v1.PollService/GetPollStats"
+	PollService_ClosePoll_FullMethodName    = "/vote.v1.PollService/ClosePoll"
+)
+
+// PollServiceClient is the client API for PollService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PollServiceClient interface {
+	CreatePoll(ctx context.Context, in *CreatePollRequest, opts ...grpc.CallOption) (*Poll, error)
+	// GetPoll returns NOT_FOUND for drafts the caller may not manage.
+	GetPoll(ctx context.Context, in *GetPollRequest, opts ...grpc.CallOption) (*Poll, error)
+	// ListFeed returns the caller's feed, newest first.
+	ListFeed(ctx context.Context, in *ListFeedRequest, opts ...grpc.CallOption) (*ListFeedResponse, error)
+	GetPollStats(ctx context.Context, in *GetPollStatsRequest, opts ...grpc.CallOption) (*PollStats, error)
+	// ClosePoll closes a poll early. Only its creator, editors and admins
+	// may close it.
+	ClosePoll(ctx context.Context, in *ClosePollRequest, opts ...grpc.CallOption) (*ClosePollResponse, error)
+}
+
+type pollServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPollServiceClient(cc grpc.ClientConnInterface) PollServiceClient {
+	return &pollServiceClient{cc}
+}
+
+func (c *pollServiceClient) CreatePoll(ctx context.Context, in *CreatePollRequest, opts ...grpc.CallOption) (*Poll, error) {
+	out := new(Poll)
+	err := c.cc.Invoke(ctx, PollService_CreatePoll_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pollServiceClient) GetPoll(ctx context.Context, in *GetPollRequest, opts ...grpc.CallOption) (*Poll, error) {
+	out := new(Poll)
+	err := c.cc.Invoke(ctx, PollService_GetPoll_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pollServiceClient) ListFeed(ctx context.Context, in *ListFeedRequest, opts ...grpc.CallOption) (*ListFeedResponse, error) {
+	out := new(ListFeedResponse)
+	err := c.cc.Invoke(ctx, PollService_ListFeed_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pollServiceClient) GetPollStats(ctx context.Context, in *GetPollStatsRequest, opts ...grpc.CallOption) (*PollStats, error) {
+	out := new(PollStats)
+	err := c.cc.Invoke(ctx, PollService_GetPollStats_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pollServiceClient) ClosePoll(ctx context.Context, in *ClosePollRequest, opts ...grpc.CallOption) (*ClosePollResponse, error) {
+	out := new(ClosePollResponse)
+	err := c.cc.Invoke(ctx, PollService_ClosePoll_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PollServiceServer is the server API for PollService service.
+// All implementations must embed UnimplementedPollServiceServer
+// for forward compatibility
+type PollServiceServer interface {
+	CreatePoll(context.Context, *CreatePollRequest) (*Poll, error)
+	// GetPoll returns NOT_FOUND for drafts the caller may not manage.
+	GetPoll(context.Context, *GetPollRequest) (*Poll, error)
+	// ListFeed returns the caller's feed, newest first.
+	ListFeed(context.Context, *ListFeedRequest) (*ListFeedResponse, error)
+	GetPollStats(context.Context, *GetPollStatsRequest) (*PollStats, error)
+	// ClosePoll closes a poll early. Only its creator, editors and admins
+	// may close it.
+	ClosePoll(context.Context, *ClosePollRequest) (*ClosePollResponse, error)
+	mustEmbedUnimplementedPollServiceServer()
+}
+
+// UnimplementedPollServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedPollServiceServer struct {
+}
+
+func (UnimplementedPollServiceServer) CreatePoll(context.Context, *CreatePollRequest) (*Poll, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePoll not implemented")
+}
+func (UnimplementedPollServiceServer) GetPoll(context.Context, *GetPollRequest) (*Poll, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPoll not implemented")
+}
+func (UnimplementedPollServiceServer) ListFeed(context.Context, *ListFeedRequest) (*ListFeedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFeed not implemented")
+}
+func (UnimplementedPollServiceServer) GetPollStats(context.Context, *GetPollStatsRequest) (*PollStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPollStats not implemented")
+}
+func (UnimplementedPollServiceServer) ClosePoll(context.Context, *ClosePollRequest) (*ClosePollResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClosePoll not implemented")
+}
+func (UnimplementedPollServiceServer) mustEmbedUnimplementedPollServiceServer() {}
+
+// UnsafePollServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PollServiceServer will
+// result in compilation errors.
+type UnsafePollServiceServer interface {
+	mustEmbedUnimplementedPollServiceServer()
+}
+
+func RegisterPollServiceServer(s grpc.ServiceRegistrar, srv PollServiceServer) {
+	s.RegisterService(&PollService_ServiceDesc, srv)
+}
+
+func _PollService_CreatePoll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePollRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PollServiceServer).CreatePoll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PollService_CreatePoll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PollServiceServer).CreatePoll(ctx, req.(*CreatePollRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PollService_GetPoll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPollRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PollServiceServer).GetPoll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PollService_GetPoll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PollServiceServer).GetPoll(ctx, req.(*GetPollRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PollService_ListFeed_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFeedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PollServiceServer).ListFeed(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PollService_ListFeed_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PollServiceServer).ListFeed(ctx, req.(*ListFeedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PollService_GetPollStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPollStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PollServiceServer).GetPollStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PollService_GetPollStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PollServiceServer).GetPollStats(ctx, req.(*GetPollStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PollService_ClosePoll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClosePollRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PollServiceServer).ClosePoll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PollService_ClosePoll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PollServiceServer).ClosePoll(ctx, req.(*ClosePollRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PollService_ServiceDesc is the grpc.ServiceDesc for PollService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PollService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "vote.v1.PollService",
+	HandlerType: (*PollServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreatePoll",
+			Handler:    _PollService_CreatePoll_Handler,
+		},
+		{
+			MethodName: "GetPoll",
+			Handler:    _PollService_GetPoll_Handler,
+		},
+		{
+			MethodName: "ListFeed",
+			Handler:    _PollService_ListFeed_Handler,
+		},
+		{
+			MethodName: "GetPollStats",
+			Handler:    _PollService_GetPollStats_Handler,
+		},
+		{
+			MethodName: "ClosePoll",
+			Handler:    _PollService_ClosePoll_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "vote.proto",
+}
+
+const (
+	VoteService_CastVote_FullMethodName         = "/vote.v1.VoteService/CastVote"
+	VoteService_SkipPoll_FullMethodName         = "/vote.v1.VoteService/SkipPoll"
+	VoteService_GetVoteAllowance_FullMethodName = "/vote.v1.VoteService/GetVoteAllowance"
+)
+
+// VoteServiceClient is the client API for VoteService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type VoteServiceClient interface {
+	CastVote(ctx context.Context, in *CastVoteRequest, opts ...grpc.CallOption) (*VoteReceipt, error)
+	SkipPoll(ctx context.Context, in *SkipPollRequest, opts ...grpc.CallOption) (*SkipPollResponse, error)
+	GetVoteAllowance(ctx context.Context, in *GetVoteAllowanceRequest, opts ...grpc.CallOption) (*VoteAllowance, error)
+}
+
+type voteServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewVoteServiceClient(cc grpc.ClientConnInterface) VoteServiceClient {
+	return &voteServiceClient{cc}
+}
+
+func (c *voteServiceClient) CastVote(ctx context.Context, in *CastVoteRequest, opts ...grpc.CallOption) (*VoteReceipt, error) {
+	out := new(VoteReceipt)
+	err := c.cc.Invoke(ctx, VoteService_CastVote_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *voteServiceClient) SkipPoll(ctx context.Context, in *SkipPollRequest, opts ...grpc.CallOption) (*SkipPollResponse, error) {
+	out := new(SkipPollResponse)
+	err := c.cc.Invoke(ctx, VoteService_SkipPoll_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *voteServiceClient) GetVoteAllowance(ctx context.Context, in *GetVoteAllowanceRequest, opts ...grpc.CallOption) (*VoteAllowance, error) {
+	out := new(VoteAllowance)
+	err := c.cc.Invoke(ctx, VoteService_GetVoteAllowance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// VoteServiceServer is the server API for VoteService service.
+// All implementations must embed UnimplementedVoteServiceServer
+// for forward compatibility
+type VoteServiceServer interface {
+	CastVote(context.Context, *CastVoteRequest) (*VoteReceipt, error)
+	SkipPoll(context.Context, *SkipPollRequest) (*SkipPollResponse, error)
+	GetVoteAllowance(context.Context, *GetVoteAllowanceRequest) (*VoteAllowance, error)
+	mustEmbedUnimplementedVoteServiceServer()
+}
+
+// UnimplementedVoteServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedVoteServiceServer struct {
+}
+
+func (UnimplementedVoteServiceServer) CastVote(context.Context, *CastVoteRequest) (*VoteReceipt, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CastVote not implemented")
+}
+func (UnimplementedVoteServiceServer) SkipPoll(context.Context, *SkipPollRequest) (*SkipPollResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SkipPoll not implemented")
+}
+func (UnimplementedVoteServiceServer) GetVoteAllowance(context.Context, *GetVoteAllowanceRequest) (*VoteAllowance, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVoteAllowance not implemented")
+}
+func (UnimplementedVoteServiceServer) mustEmbedUnimplementedVoteServiceServer() {}
+
+// UnsafeVoteServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to VoteServiceServer will
+// result in compilation errors.
+type UnsafeVoteServiceServer interface {
+	mustEmbedUnimplementedVoteServiceServer()
+}
+
+func RegisterVoteServiceServer(s grpc.ServiceRegistrar, srv VoteServiceServer) {
+	s.RegisterService(&VoteService_ServiceDesc, srv)
+}
+
+func _VoteService_CastVote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CastVoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VoteServiceServer).CastVote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VoteService_CastVote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VoteServiceServer).CastVote(ctx, req.(*CastVoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VoteService_SkipPoll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SkipPollRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VoteServiceServer).SkipPoll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VoteService_SkipPoll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VoteServiceServer).SkipPoll(ctx, req.(*SkipPollRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VoteService_GetVoteAllowance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVoteAllowanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VoteServiceServer).GetVoteAllowance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VoteService_GetVoteAllowance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VoteServiceServer).GetVoteAllowance(ctx, req.(*GetVoteAllowanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// VoteService_ServiceDesc is the grpc.ServiceDesc for VoteService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var VoteService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "vote.v1.VoteService",
+	HandlerType: (*VoteServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CastVote",
+			Handler:    _VoteService_CastVote_Handler,
+		},
+		{
+			MethodName: "SkipPoll",
+			Handler:    _VoteService_SkipPoll_Handler,
+		},
+		{
+			MethodName: "GetVoteAllowance",
+			Handler:    _VoteService_GetVoteAllowance_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "vote.proto",
+}
+
+const (
+	UserService_GetCurrentUser_FullMethodName = "/vote.v1.UserService/GetCurrentUser"
+	UserService_ListVotes_FullMethodName      = "/vote.v1.UserService/ListVotes"
+)
+
+// UserServiceClient is the client API for UserService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type UserServiceClient interface {
+	GetCurrentUser(ctx context.Context, in *GetCurrentUserRequest, opts ...grpc.CallOption) (*User, error)
+	ListVotes(ctx context.Context, in *ListVotesRequest, opts ...grpc.CallOption) (*ListVotesResponse, error)
+}
+
+type userServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUserServiceClient(cc grpc.ClientConnInterface) UserServiceClient {
+	return &userServiceClient{cc}
+}
+
+func (c *userServiceClient) GetCurrentUser(ctx context.Context, in *GetCurrentUserRequest, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, UserService_GetCurrentUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ListVotes(ctx context.Context, in *ListVotesRequest, opts ...grpc.CallOption) (*ListVotesResponse, error) {
+	out := new(ListVotesResponse)
+	err := c.cc.Invoke(ctx, UserService_ListVotes_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UserServiceServer is the server API for UserService service.
+// All implementations must embed UnimplementedUserServiceServer
+// for forward compatibility
+type UserServiceServer interface {
+	GetCurrentUser(context.Context, *GetCurrentUserRequest) (*User, error)
+	ListVotes(context.Context, *ListVotesRequest) (*ListVotesResponse, error)
+	mustEmbedUnimplementedUserServiceServer()
+}
+
+// UnimplementedUserServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedUserServiceServer struct {
+}
+
+func (UnimplementedUserServiceServer) GetCurrentUser(context.Context, *GetCurrentUserRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCurrentUser not implemented")
+}
+func (UnimplementedUserServiceServer) ListVotes(context.Context, *ListVotesRequest) (*ListVotesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVotes not implemented")
+}
+func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
+
+// UnsafeUserServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UserServiceServer will
+// result in compilation errors.
+type UnsafeUserServiceServer interface {
+	mustEmbedUnimplementedUserServiceServer()
+}
+
+func RegisterUserServiceServer(s grpc.ServiceRegistrar, srv UserServiceServer) {
+	s.RegisterService(&UserService_ServiceDesc, srv)
+}
+
+func _UserService_GetCurrentUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCurrentUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetCurrentUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetCurrentUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetCurrentUser(ctx, req.(*GetCurrentUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ListVotes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVotesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ListVotes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ListVotes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ListVotes(ctx, req.(*ListVotesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UserService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "vote.v1.UserService",
+	HandlerType: (*UserServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCurrentUser",
+			Handler:    _UserService_GetCurrentUser_Handler,
+		},
+		{
+			MethodName: "ListVotes",
+			Handler:    _UserService_ListVotes_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "vote.proto",
+}
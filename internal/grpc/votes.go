@@ -0,0 +1,76 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/grpc/votepb"
+)
+
+type voteServer struct {
+	votepb.UnimplementedVoteServiceServer
+	*Server
+}
+
+// CastVote votes for the named option, or for the poll's default option
+// when the request names none. Calls carry no client address, so polls
+// limited to some countries reject them.
+func (s *voteServer) CastVote(ctx context.Context, req *votepb.CastVoteRequest) (*votepb.VoteReceipt, error) {
+	pollID, err := parseID("poll_id", req.PollId)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceReq := &domain.VoteRequest{
+		UserID:     currentUser(ctx).ID,
+		AccessCode: req.AccessCode,
+		UseDefault: req.OptionId == nil && req.OptionIndex == nil,
+	}
+	if req.OptionId != nil {
+		optionID, err := parseID("option_id", *req.OptionId)
+		if err != nil {
+			return nil, err
+		}
+		serviceReq.OptionID = &optionID
+	}
+	if req.OptionIndex != nil {
+		if *req.OptionIndex < 0 {
+			return nil, invalidArgument("option_index must not be negative")
+		}
+		serviceReq.OptionIndex = int(*req.OptionIndex)
+	}
+
+	receipt, err := s.service.VoteOnPoll(ctx, pollID, serviceReq)
+	if err != nil {
+		return nil, err
+	}
+	return toVoteReceipt(receipt), nil
+}
+
+func (s *voteServer) SkipPoll(ctx context.Context, req *votepb.SkipPollRequest) (*votepb.SkipPollResponse, error) {
+	pollID, err := parseID("poll_id", req.PollId)
+	if err != nil {
+		return nil, err
+	}
+	err = s.service.SkipPoll(ctx, pollID, &domain.SkipRequest{
+		UserID: currentUser(ctx).ID,
+		Reason: domain.SkipReason(req.Reason),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &votepb.SkipPollResponse{}, nil
+}
+
+func (s *voteServer) GetVoteAllowance(ctx context.Context, req *votepb.GetVoteAllowanceRequest) (*votepb.VoteAllowance, error) {
+	allowance, err := s.service.GetVoteAllowance(ctx, currentUser(ctx).ID)
+	if err != nil {
+		return nil, err
+	}
+	return &votepb.VoteAllowance{
+		Limit:     int32(allowance.Limit),
+		Used:      int32(allowance.Used),
+		Remaining: int32(allowance.Remaining),
+		ResetAt:   optionalTimestamp(allowance.ResetAt),
+	}, nil
+}
@@ -0,0 +1,44 @@
+package health
+
+import (
+	"sync"
+
+	"github.com/behzadon/vote/internal/metrics"
+)
+
+// Status tracks which of an instance's dependencies are reachable. The
+// instance is ready while all of them are.
+type Status struct {
+	mu   sync.RWMutex
+	deps map[string]bool
+}
+
+func NewStatus() *Status {
+	return &Status{deps: make(map[string]bool)}
+}
+
+func (s *Status) Set(name string, up bool) {
+	s.mu.Lock()
+	s.deps[name] = up
+	s.mu.Unlock()
+
+	value := 0.0
+	if up {
+		value = 1
+	}
+	metrics.DependencyUp.WithLabelValues(name).Set(value)
+}
+
+// Ready reports whether every dependency is up, along with each one's state.
+func (s *Status) Ready() (bool, map[string]bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ready := true
+	deps := make(map[string]bool, len(s.deps))
+	for name, up := range s.deps {
+		deps[name] = up
+		ready = ready && up
+	}
+	return ready, deps
+}
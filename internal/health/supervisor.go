@@ -0,0 +1,121 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"github.com/behzadon/vote/internal/lifecycle"
+	"github.com/behzadon/vote/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// Dependency is a connection a Supervisor keeps alive.
+type Dependency struct {
+	Name string
+	// Check returns an error when the dependency is unreachable.
+	Check func(ctx context.Context) error
+	// Reconnect replaces a dropped connection. It may be nil for clients
+	// that reconnect on their own, which are then only checked.
+	Reconnect func(ctx context.Context) error
+}
+
+type SupervisorConfig struct {
+	Interval time.Duration
+	Timeout  time.Duration
+	// Backoff spaces reconnection attempts; its MaxWait is ignored, as
+	// attempts go on until the dependency is back or the supervisor stops.
+	Backoff lifecycle.Backoff
+}
+
+// Supervisor checks a dependency every Interval. When a check fails it marks
+// the dependency down, which makes the instance unready, and tries to
+// reconnect with backoff until it succeeds.
+type Supervisor struct {
+	dep    Dependency
+	status *Status
+	cfg    SupervisorConfig
+	logger *zap.Logger
+}
+
+func NewSupervisor(dep Dependency, status *Status, cfg SupervisorConfig, logger *zap.Logger) *Supervisor {
+	status.Set(dep.Name, true)
+	return &Supervisor{
+		dep:    dep,
+		status: status,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Run supervises the dependency until ctx is done.
+func (s *Supervisor) Run(ctx context.Context) error {
+	up := true
+	wait := s.cfg.Interval
+	var downSince time.Time
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		if up {
+			err := s.attempt(ctx, s.dep.Check)
+			if err == nil || ctx.Err() != nil {
+				continue
+			}
+			up = false
+			downSince = time.Now()
+			s.status.Set(s.dep.Name, false)
+			s.logger.Warn("Dependency connection lost, reconnecting",
+				zap.String("dependency", s.dep.Name),
+				zap.Error(err),
+			)
+			wait = s.cfg.Backoff.Initial
+			continue
+		}
+
+		err := s.reconnect(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			wait *= 2
+			if wait > s.cfg.Backoff.Max {
+				wait = s.cfg.Backoff.Max
+			}
+			s.logger.Warn("Failed to reconnect dependency",
+				zap.String("dependency", s.dep.Name),
+				zap.Duration("retry_in", wait),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		up = true
+		wait = s.cfg.Interval
+		s.status.Set(s.dep.Name, true)
+		metrics.DependencyReconnects.WithLabelValues(s.dep.Name).Inc()
+		s.logger.Info("Dependency reconnected",
+			zap.String("dependency", s.dep.Name),
+			zap.Duration("down_for", time.Since(downSince)),
+		)
+	}
+}
+
+func (s *Supervisor) reconnect(ctx context.Context) error {
+	if s.dep.Reconnect != nil {
+		if err := s.attempt(ctx, s.dep.Reconnect); err != nil {
+			return err
+		}
+	}
+	return s.attempt(ctx, s.dep.Check)
+}
+
+func (s *Supervisor) attempt(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	return fn(ctx)
+}
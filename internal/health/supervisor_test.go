@@ -0,0 +1,73 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/lifecycle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSupervisorReconnects(t *testing.T) {
+	var connected atomic.Bool
+	var reconnects, failures atomic.Int32
+	connected.Store(true)
+
+	status := NewStatus()
+	dep := Dependency{
+		Name: "rabbitmq",
+		Check: func(ctx context.Context) error {
+			if !connected.Load() {
+				return errors.New("connection closed")
+			}
+			return nil
+		},
+		Reconnect: func(ctx context.Context) error {
+			// The first attempt fails, as if the broker were still down.
+			if failures.Add(1) == 1 {
+				return errors.New("connection refused")
+			}
+			reconnects.Add(1)
+			connected.Store(true)
+			return nil
+		},
+	}
+	supervisor := NewSupervisor(dep, status, SupervisorConfig{
+		Interval: time.Millisecond,
+		Timeout:  time.Second,
+		Backoff:  lifecycle.Backoff{Initial: time.Millisecond, Max: 2 * time.Millisecond},
+	}, zap.NewNop())
+
+	ready, _ := status.Ready()
+	assert.True(t, ready)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- supervisor.Run(ctx) }()
+
+	connected.Store(false)
+	require.Eventually(t, func() bool { return reconnects.Load() == 1 }, time.Second, time.Millisecond)
+	require.Eventually(t, func() bool {
+		ready, deps := status.Ready()
+		return ready && deps["rabbitmq"]
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(2), failures.Load())
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestStatusReady(t *testing.T) {
+	status := NewStatus()
+	status.Set("redis", true)
+	status.Set("rabbitmq", false)
+
+	ready, deps := status.Ready()
+	assert.False(t, ready)
+	assert.Equal(t, map[string]bool{"redis": true, "rabbitmq": false}, deps)
+}
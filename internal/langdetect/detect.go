@@ -0,0 +1,159 @@
+// Package langdetect guesses the language of short texts such as poll
+// titles. Non-Latin scripts are told apart by their letters; Latin-script
+// languages by the common words they use. It only answers when the text is
+// clear enough and returns "" otherwise.
+package langdetect
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// minLetters is the least a text needs for its script to be trusted.
+	minLetters = 3
+	// minScriptShare is the share of the letters a non-Latin script needs
+	// for the text to count as written in it.
+	minScriptShare = 0.5
+)
+
+// scripts maps the non-Latin scripts to the language they are taken for.
+// Scripts shared by several languages are refined by refineScript.
+var scripts = []struct {
+	table    *unicode.RangeTable
+	language string
+}{
+	{unicode.Han, "zh"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Thai, "th"},
+}
+
+// stopwords lists frequent words of each Latin-script language. Words are
+// only counted for languages they are listed under, so a word common to
+// several languages adds to each of them.
+var stopwords = map[string][]string{
+	"en": {"the", "a", "an", "is", "are", "was", "what", "which", "who", "how", "do", "does", "you", "your", "of", "and", "or", "to", "in", "for", "with", "best", "should", "would", "will", "it", "this", "that", "be", "on", "my", "we", "favorite", "favourite"},
+	"es": {"el", "la", "los", "las", "es", "son", "qué", "que", "cuál", "cual", "quién", "cómo", "de", "del", "y", "o", "en", "por", "para", "con", "tu", "su", "un", "una", "mejor", "más", "debería", "favorito", "favorita"},
+	"fr": {"le", "la", "les", "est", "sont", "quel", "quelle", "quels", "qui", "que", "de", "des", "du", "et", "ou", "en", "pour", "avec", "votre", "ton", "un", "une", "meilleur", "meilleure", "plus", "faut", "préféré", "préférée"},
+	"de": {"der", "die", "das", "ist", "sind", "was", "welche", "welcher", "welches", "wer", "wie", "und", "oder", "zu", "in", "für", "mit", "dein", "deine", "ihr", "ein", "eine", "beste", "besten", "soll", "sollte", "lieblings"},
+	"it": {"il", "lo", "la", "gli", "le", "è", "sono", "quale", "qual", "chi", "che", "come", "di", "del", "della", "e", "o", "in", "per", "con", "tuo", "tua", "un", "una", "migliore", "più", "preferito", "preferita"},
+	"pt": {"o", "a", "os", "as", "é", "são", "qual", "quem", "que", "como", "de", "do", "da", "e", "ou", "em", "para", "com", "seu", "sua", "teu", "um", "uma", "melhor", "mais", "deveria", "favorito", "favorita"},
+	"nl": {"de", "het", "een", "is", "zijn", "wat", "welke", "wie", "hoe", "en", "of", "in", "voor", "met", "jouw", "je", "uw", "beste", "meer", "moet", "zou", "favoriete"},
+	"tr": {"bir", "ve", "veya", "ne", "hangi", "kim", "nasıl", "mi", "mı", "mu", "mü", "en", "iyi", "için", "ile", "bu", "senin", "sizin", "favori", "daha"},
+}
+
+// letterHints are letters only one of the Latin-script languages uses. Each
+// one found counts like a stopword.
+var letterHints = map[rune]string{
+	'ñ': "es", '¿': "es", '¡': "es",
+	'ß': "de",
+	'ã': "pt", 'õ': "pt",
+	'ğ': "tr", 'ş': "tr", 'ı': "tr",
+	'ĳ': "nl",
+}
+
+var words = func() map[string][]string {
+	index := make(map[string][]string)
+	for language, list := range stopwords {
+		for _, word := range list {
+			index[word] = append(index[word], language)
+		}
+	}
+	return index
+}()
+
+// Detect returns the ISO 639-1 code of the language text is written in, or
+// "" when it cannot tell.
+func Detect(text string) string {
+	text = strings.ToLower(text)
+
+	var letters, latin int
+	counts := make(map[string]int)
+	scores := make(map[string]int)
+	for _, r := range text {
+		if language, ok := letterHints[r]; ok {
+			scores[language]++
+		}
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.Is(unicode.Latin, r) {
+			latin++
+			continue
+		}
+		for _, script := range scripts {
+			if unicode.Is(script.table, r) {
+				counts[script.language]++
+				break
+			}
+		}
+	}
+	if letters < minLetters {
+		return ""
+	}
+
+	// Japanese mixes Han with kana, so any kana settles it.
+	if counts["ja"] > 0 {
+		counts["ja"] += counts["zh"]
+		delete(counts, "zh")
+	}
+	if language := dominant(counts, letters); language != "" {
+		return refineScript(language, text)
+	}
+	if float64(latin) < float64(letters)*minScriptShare {
+		return ""
+	}
+
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		for _, language := range words[word] {
+			scores[language]++
+		}
+	}
+	return dominant(scores, 0)
+}
+
+// dominant returns the language with the highest count, provided it beats
+// every other one and, when letters is set, makes up enough of them.
+func dominant(counts map[string]int, letters int) string {
+	var best string
+	var bestCount, runnerUp int
+	for language, count := range counts {
+		switch {
+		case count > bestCount:
+			best, bestCount, runnerUp = language, count, bestCount
+		case count > runnerUp:
+			runnerUp = count
+		}
+	}
+	if bestCount == 0 || bestCount == runnerUp {
+		return ""
+	}
+	if letters > 0 && float64(bestCount) < float64(letters)*minScriptShare {
+		return ""
+	}
+	return best
+}
+
+// refineScript tells apart languages sharing a script by the letters only
+// one of them uses.
+func refineScript(language, text string) string {
+	switch language {
+	case "ru":
+		if strings.ContainsAny(text, "іїєґ") {
+			return "uk"
+		}
+	case "ar":
+		if strings.ContainsAny(text, "پچژگ") {
+			return "fa"
+		}
+	}
+	return language
+}
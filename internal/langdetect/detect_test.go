@@ -0,0 +1,39 @@
+package langdetect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		text     string
+		language string
+	}{
+		{"What is your favorite color?", "en"},
+		{"¿Cuál es tu color favorito?", "es"},
+		{"Quel est ton film préféré ?", "fr"},
+		{"Welches ist dein Lieblingsessen?", "de"},
+		{"Qual è il tuo film preferito?", "it"},
+		{"Qual é o seu filme favorito?", "pt"},
+		{"Welke film is de beste?", "nl"},
+		{"En iyi futbol takımı hangisi?", "tr"},
+		{"Какой твой любимый цвет?", "ru"},
+		{"Який твій улюблений колір?", "uk"},
+		{"ما هو لونك المفضل؟", "ar"},
+		{"رنگ مورد علاقه شما چیست؟", "fa"},
+		{"你最喜欢什么颜色？", "zh"},
+		{"好きな色は何ですか？", "ja"},
+		{"가장 좋아하는 색은?", "ko"},
+		{"Ποιο είναι το αγαπημένο σου χρώμα;", "el"},
+		{"iPhone 15 vs Pixel 8", ""},
+		{"OK", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.language, Detect(tt.text))
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type Component struct {
+	Name string
+	// Run blocks until the component stops on its own or ctx is canceled.
+	// A non-nil error before shutdown triggers shutdown of every component.
+	Run func(ctx context.Context) error
+	// Stop drains the component within the deadline carried by ctx.
+	Stop func(ctx context.Context) error
+	// InFlight counts the work the component still holds: requests being
+	// served, messages being handled, events not yet published. It is called
+	// before and after Stop for the shutdown report.
+	InFlight func(ctx context.Context) (int, error)
+}
+
+// Report describes a shutdown: what each component held when it was asked to
+// stop, what it still held afterwards and whether everything was drained
+// before the deadline.
+type Report struct {
+	Reason     string
+	Deadline   time.Duration
+	Duration   time.Duration
+	Drained    bool
+	Components []ComponentReport
+}
+
+// ComponentReport is one component's part of a Report. InFlight and
+// Remaining are -1 when the component could not be counted.
+type ComponentReport struct {
+	Name      string
+	InFlight  int
+	Remaining int
+	Drained   bool
+	Duration  time.Duration
+	Err       error
+}
+
+// countTimeout bounds each InFlight call. Counting after Stop has to work even
+// when the shutdown deadline has already passed.
+const countTimeout = 2 * time.Second
+
+type Manager struct {
+	components      []Component
+	shutdownTimeout time.Duration
+	onShutdown      []func(Report)
+	logger          *zap.Logger
+}
+
+func NewManager(shutdownTimeout time.Duration, logger *zap.Logger) *Manager {
+	return &Manager{
+		shutdownTimeout: shutdownTimeout,
+		logger:          logger,
+	}
+}
+
+// Add registers a component. Components are stopped in reverse order of
+// registration, so register dependencies before their consumers.
+func (m *Manager) Add(component Component) {
+	m.components = append(m.components, component)
+}
+
+// OnShutdown registers fn to receive the shutdown report once every component
+// has been stopped.
+func (m *Manager) OnShutdown(fn func(Report)) {
+	m.onShutdown = append(m.onShutdown, fn)
+}
+
+func (m *Manager) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	runErrs := make(chan error, len(m.components))
+	for _, component := range m.components {
+		if component.Run == nil {
+			continue
+		}
+		go func(component Component) {
+			if err := component.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				runErrs <- fmt.Errorf("%s: %w", component.Name, err)
+			}
+		}(component)
+	}
+
+	var runErr error
+	reason := "signal"
+	select {
+	case <-ctx.Done():
+		m.logger.Info("Shutdown signal received")
+	case runErr = <-runErrs:
+		m.logger.Error("Component failed, shutting down", zap.Error(runErr))
+		reason = runErr.Error()
+	}
+
+	report, err := m.shutdown(reason)
+	m.logReport(report)
+	for _, fn := range m.onShutdown {
+		fn(report)
+	}
+	return errors.Join(runErr, err)
+}
+
+func (m *Manager) shutdown(reason string) (Report, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.shutdownTimeout)
+	defer cancel()
+
+	report := Report{Reason: reason, Deadline: m.shutdownTimeout, Drained: true}
+	shutdownStart := time.Now()
+	var errs []error
+	for i := len(m.components) - 1; i >= 0; i-- {
+		component := m.components[i]
+		if component.Stop == nil && component.InFlight == nil {
+			continue
+		}
+
+		result := ComponentReport{Name: component.Name, InFlight: m.count(component)}
+		start := time.Now()
+		if component.Stop != nil {
+			result.Err = component.Stop(ctx)
+		}
+		result.Duration = time.Since(start)
+		result.Remaining = m.count(component)
+		result.Drained = result.Err == nil && result.Remaining == 0
+		report.Drained = report.Drained && result.Drained
+		report.Components = append(report.Components, result)
+
+		if result.Err != nil {
+			m.logger.Error("Failed to stop component",
+				zap.Error(result.Err),
+				zap.String("component", component.Name),
+				zap.Int("in_flight", result.InFlight),
+				zap.Int("remaining", result.Remaining),
+			)
+			errs = append(errs, fmt.Errorf("stop %s: %w", component.Name, result.Err))
+			continue
+		}
+		m.logger.Info("Component stopped",
+			zap.String("component", component.Name),
+			zap.Duration("duration", result.Duration),
+			zap.Int("in_flight", result.InFlight),
+			zap.Int("remaining", result.Remaining),
+		)
+	}
+	report.Duration = time.Since(shutdownStart)
+
+	return report, errors.Join(errs...)
+}
+
+func (m *Manager) count(component Component) int {
+	if component.InFlight == nil {
+		return 0
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+	defer cancel()
+
+	n, err := component.InFlight(ctx)
+	if err != nil {
+		m.logger.Warn("Failed to count in-flight work",
+			zap.Error(err),
+			zap.String("component", component.Name),
+		)
+		return -1
+	}
+	return n
+}
+
+func (m *Manager) logReport(report Report) {
+	log := m.logger.Info
+	if !report.Drained {
+		log = m.logger.Warn
+	}
+	log("Shutdown report",
+		zap.String("reason", report.Reason),
+		zap.Bool("drained", report.Drained),
+		zap.Duration("duration", report.Duration),
+		zap.Duration("deadline", report.Deadline),
+		zap.Array("components", componentReports(report.Components)),
+	)
+}
+
+type componentReports []ComponentReport
+
+func (r componentReports) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, component := range r {
+		if err := enc.AppendObject(component); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r ComponentReport) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", r.Name)
+	enc.AddInt("in_flight", r.InFlight)
+	enc.AddInt("remaining", r.Remaining)
+	enc.AddBool("drained", r.Drained)
+	enc.AddDuration("duration", r.Duration)
+	if r.Err != nil {
+		enc.AddString("error", r.Err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestManager_StopsInReverseOrder(t *testing.T) {
+	var stopped []string
+	stopFn := func(name string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			stopped = append(stopped, name)
+			return nil
+		}
+	}
+
+	manager := NewManager(time.Second, zap.NewNop())
+	manager.Add(Component{Name: "publisher", Stop: stopFn("publisher")})
+	manager.Add(Component{Name: "scheduler", Stop: stopFn("scheduler")})
+	manager.Add(Component{
+		Name: "http",
+		Run: func(ctx context.Context) error {
+			return errors.New("bind: address already in use")
+		},
+		Stop: stopFn("http"),
+	})
+
+	err := manager.Run(context.Background())
+
+	assert.ErrorContains(t, err, "address already in use")
+	assert.Equal(t, []string{"http", "scheduler", "publisher"}, stopped)
+}
+
+func TestManager_StopErrorsAreReported(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	manager := NewManager(time.Second, zap.NewNop())
+	manager.Add(Component{
+		Name: "consumer",
+		Stop: func(ctx context.Context) error {
+			return context.DeadlineExceeded
+		},
+	})
+
+	err := manager.Run(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestManager_ReportsInFlightWork(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	requests := 3
+	manager := NewManager(50*time.Millisecond, zap.NewNop())
+	manager.Add(Component{
+		Name: "outbox",
+		InFlight: func(ctx context.Context) (int, error) {
+			return 0, errors.New("connection refused")
+		},
+	})
+	manager.Add(Component{
+		Name: "consumer",
+		Stop: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		InFlight: func(ctx context.Context) (int, error) { return 1, nil },
+	})
+	manager.Add(Component{
+		Name: "http",
+		Stop: func(ctx context.Context) error {
+			requests = 0
+			return nil
+		},
+		InFlight: func(ctx context.Context) (int, error) { return requests, nil },
+	})
+	var report Report
+	manager.OnShutdown(func(r Report) { report = r })
+
+	err := manager.Run(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, "signal", report.Reason)
+	assert.Equal(t, 50*time.Millisecond, report.Deadline)
+	assert.False(t, report.Drained)
+	if assert.Len(t, report.Components, 3) {
+		http, consumer, outbox := report.Components[0], report.Components[1], report.Components[2]
+		assert.Equal(t, ComponentReport{Name: "http", InFlight: 3, Remaining: 0, Drained: true, Duration: http.Duration}, http)
+		assert.Equal(t, 1, consumer.InFlight)
+		assert.Equal(t, 1, consumer.Remaining)
+		assert.False(t, consumer.Drained)
+		assert.ErrorIs(t, consumer.Err, context.DeadlineExceeded)
+		assert.Equal(t, -1, outbox.InFlight)
+		assert.False(t, outbox.Drained)
+	}
+}
+
+func TestManager_ReportsCleanDrain(t *testing.T) {
+	manager := NewManager(time.Second, zap.NewNop())
+	manager.Add(Component{
+		Name: "http",
+		Run: func(ctx context.Context) error {
+			return errors.New("bind: address already in use")
+		},
+		Stop:     func(ctx context.Context) error { return nil },
+		InFlight: func(ctx context.Context) (int, error) { return 0, nil },
+	})
+	var report Report
+	manager.OnShutdown(func(r Report) { report = r })
+
+	err := manager.Run(context.Background())
+
+	assert.Error(t, err)
+	assert.Contains(t, report.Reason, "address already in use")
+	assert.True(t, report.Drained)
+	assert.Len(t, report.Components, 1)
+}
@@ -0,0 +1,73 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Backoff says how to retry a dependency that is not up yet. The wait
+// between attempts starts at Initial and doubles up to Max. MaxWait bounds
+// the total time spent; zero makes a single attempt.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+	MaxWait time.Duration
+}
+
+// Retry calls connect until it succeeds, logging each failure with the time
+// until the next attempt. It gives up with the last error once b.MaxWait has
+// passed or ctx is done. The ctx passed to connect ends with MaxWait, so a
+// hanging attempt does not outlast it.
+func Retry(ctx context.Context, name string, b Backoff, logger *zap.Logger, connect func(ctx context.Context) error) error {
+	if b.MaxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.MaxWait)
+		defer cancel()
+	}
+
+	start := time.Now()
+	wait := b.Initial
+	for attempt := 1; ; attempt++ {
+		err := connect(ctx)
+		if err == nil {
+			if attempt > 1 {
+				logger.Info("Dependency is up",
+					zap.String("dependency", name),
+					zap.Int("attempts", attempt),
+					zap.Duration("waited", time.Since(start)),
+				)
+			}
+			return nil
+		}
+
+		// Give up when the next attempt would start after the deadline.
+		deadline, _ := ctx.Deadline()
+		if b.MaxWait <= 0 || ctx.Err() != nil || time.Until(deadline) < wait {
+			return fmt.Errorf("%s not available after %d attempts in %s: %w",
+				name, attempt, time.Since(start).Round(time.Millisecond), err)
+		}
+
+		logger.Warn("Dependency not available, retrying",
+			zap.String("dependency", name),
+			zap.Int("attempt", attempt),
+			zap.Duration("retry_in", wait),
+			zap.Duration("give_up_in", time.Until(deadline).Round(time.Second)),
+			zap.Error(err),
+		)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s not available after %d attempts: %w", name, attempt, err)
+		case <-timer.C:
+		}
+
+		wait *= 2
+		if wait > b.Max {
+			wait = b.Max
+		}
+	}
+}
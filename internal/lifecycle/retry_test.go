@@ -0,0 +1,54 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestRetry_SucceedsAfterFailures(t *testing.T) {
+	attempts := 0
+	b := Backoff{Initial: time.Millisecond, Max: 2 * time.Millisecond, MaxWait: time.Second}
+
+	err := Retry(context.Background(), "postgres", b, zap.NewNop(), func(ctx context.Context) error {
+		attempts++
+		if attempts < 4 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, attempts)
+}
+
+func TestRetry_GivesUpAfterMaxWait(t *testing.T) {
+	attempts := 0
+	b := Backoff{Initial: 10 * time.Millisecond, Max: 20 * time.Millisecond, MaxWait: 50 * time.Millisecond}
+
+	start := time.Now()
+	err := Retry(context.Background(), "redis", b, zap.NewNop(), func(ctx context.Context) error {
+		attempts++
+		return errors.New("connection refused")
+	})
+
+	assert.ErrorContains(t, err, "redis not available")
+	assert.ErrorContains(t, err, "connection refused")
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Greater(t, attempts, 1)
+}
+
+func TestRetry_SingleAttemptWithoutMaxWait(t *testing.T) {
+	attempts := 0
+	err := Retry(context.Background(), "rabbitmq", Backoff{Initial: time.Millisecond}, zap.NewNop(), func(ctx context.Context) error {
+		attempts++
+		return errors.New("connection refused")
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
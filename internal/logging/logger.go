@@ -33,7 +33,7 @@ func (l *Logger) GinLogger() gin.HandlerFunc {
 		method := c.Request.Method
 		errorMessage := c.Errors.ByType(gin.ErrorTypePrivate).String()
 
-		l.zapLogger.Info("incoming request",
+		For(c.Request.Context(), l.zapLogger).Info("incoming request",
 			zap.String("path", path),
 			zap.String("query", query),
 			zap.String("ip", clientIP),
@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// HeaderTraceparent carries the W3C trace context
+// (https://www.w3.org/TR/trace-context/) in HTTP requests, responses and
+// RabbitMQ message headers.
+const HeaderTraceparent = "traceparent"
+
+type spanKey struct{}
+
+// SpanContext identifies the span a piece of work belongs to. TraceID is 32
+// and SpanID 16 lowercase hex characters.
+type SpanContext struct {
+	TraceID string
+	SpanID  string
+}
+
+// Traceparent formats the span as a sampled traceparent header.
+func (sc SpanContext) Traceparent() string {
+	return "00-" + sc.TraceID + "-" + sc.SpanID + "-01"
+}
+
+// ParseTraceparent reads a traceparent header. Headers of a later version
+// are read as far as version 00 defines them, as the spec asks.
+func ParseTraceparent(header string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[3]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, false
+	}
+	sc := SpanContext{TraceID: parts[1], SpanID: parts[2]}
+	if !isHexID(sc.TraceID, 32) || !isHexID(sc.SpanID, 16) {
+		return SpanContext{}, false
+	}
+	return sc, true
+}
+
+func isHexID(s string, n int) bool {
+	if len(s) != n || strings.Trim(s, "0") == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// StartSpan returns a new span in the trace of the span in ctx, or in a new
+// trace when ctx has none.
+func StartSpan(ctx context.Context) (context.Context, SpanContext) {
+	sc := SpanContext{SpanID: randomHex(8)}
+	if parent, ok := SpanFromContext(ctx); ok {
+		sc.TraceID = parent.TraceID
+	} else {
+		sc.TraceID = randomHex(16)
+	}
+	return ContextWithSpan(ctx, sc), sc
+}
+
+func ContextWithSpan(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanKey{}, sc)
+}
+
+func SpanFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanKey{}).(SpanContext)
+	return sc, ok
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand doesn't fail on supported platforms; an all-zero ID
+		// would be invalid, so fall back to a fixed non-zero one.
+		b[n-1] = 1
+	}
+	return hex.EncodeToString(b)
+}
+
+// For returns logger with the trace_id and span_id of the span in ctx, so the
+// entry can be matched with the request or event it was logged for.
+func For(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	sc, ok := SpanFromContext(ctx)
+	if !ok {
+		return logger
+	}
+	return logger.With(zap.String("trace_id", sc.TraceID), zap.String("span_id", sc.SpanID))
+}
+
+// Tracing starts a span for each request, continuing the caller's trace when
+// the request has a traceparent header, and returns it in the response.
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if parent, ok := ParseTraceparent(c.GetHeader(HeaderTraceparent)); ok {
+			ctx = ContextWithSpan(ctx, parent)
+		}
+		ctx, sc := StartSpan(ctx)
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(HeaderTraceparent, sc.Traceparent())
+
+		c.Next()
+	}
+}
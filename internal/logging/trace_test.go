@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const (
+	testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID  = "00f067aa0ba902b7"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		ok     bool
+	}{
+		{"valid", "00-" + testTraceID + "-" + testSpanID + "-01", true},
+		{"later version with extra fields", "01-" + testTraceID + "-" + testSpanID + "-01-extra", true},
+		{"version 00 with extra fields", "00-" + testTraceID + "-" + testSpanID + "-01-extra", false},
+		{"invalid version", "ff-" + testTraceID + "-" + testSpanID + "-01", false},
+		{"all-zero trace ID", "00-00000000000000000000000000000000-" + testSpanID + "-01", false},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-" + testSpanID + "-01", false},
+		{"short span ID", "00-" + testTraceID + "-00f067aa-01", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := ParseTraceparent(tt.header)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, SpanContext{TraceID: testTraceID, SpanID: testSpanID}, sc)
+			}
+		})
+	}
+}
+
+func TestStartSpan(t *testing.T) {
+	ctx, root := StartSpan(context.Background())
+	assert.Len(t, root.TraceID, 32)
+	assert.Len(t, root.SpanID, 16)
+
+	_, child := StartSpan(ctx)
+	assert.Equal(t, root.TraceID, child.TraceID)
+	assert.NotEqual(t, root.SpanID, child.SpanID)
+}
+
+func TestFor(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(core)
+
+	For(context.Background(), logger).Info("untraced")
+	ctx := ContextWithSpan(context.Background(), SpanContext{TraceID: testTraceID, SpanID: testSpanID})
+	For(ctx, logger).Info("traced")
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	assert.Empty(t, entries[0].ContextMap())
+	assert.Equal(t, map[string]interface{}{"trace_id": testTraceID, "span_id": testSpanID}, entries[1].ContextMap())
+}
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Tracing())
+	var seen SpanContext
+	r.GET("/", func(c *gin.Context) {
+		seen, _ = SpanFromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	t.Run("continues the caller's trace", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(HeaderTraceparent, "00-"+testTraceID+"-"+testSpanID+"-01")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, testTraceID, seen.TraceID)
+		assert.NotEqual(t, testSpanID, seen.SpanID)
+		assert.Equal(t, seen.Traceparent(), w.Header().Get(HeaderTraceparent))
+	})
+
+	t.Run("starts a trace", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(HeaderTraceparent, "garbage")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Len(t, seen.TraceID, 32)
+		assert.NotEqual(t, testTraceID, seen.TraceID)
+		_, ok := ParseTraceparent(w.Header().Get(HeaderTraceparent))
+		assert.True(t, ok)
+	})
+}
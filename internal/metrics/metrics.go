@@ -66,6 +66,225 @@ var (
 		},
 		[]string{"operation", "status"},
 	)
+
+	AuthFailures = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_failures_total",
+			Help: "Total number of rejected authentication attempts by reason",
+		},
+		[]string{"reason"},
+	)
+
+	AuthFailureSpikes = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "auth_failure_spikes_total",
+			Help: "Total number of times authentication failures exceeded the alert threshold",
+		},
+	)
+
+	RedisCommandDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "redis_command_duration_seconds",
+			Help:    "Duration of Redis commands in seconds",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		},
+		[]string{"command", "status"},
+	)
+
+	CacheLookups = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_lookups_total",
+			Help: "Total number of cache reads by key family and result",
+		},
+		[]string{"family", "result"},
+	)
+
+	RateLimitDecisions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_limit_decisions_total",
+			Help: "Total number of rate limiter decisions by limiter type, route and outcome",
+		},
+		[]string{"limiter", "route", "decision"},
+	)
+
+	RateLimitHotKeys = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "rate_limit_hot_key_requests",
+			Help: "Requests seen in the last sampling period for the busiest rate limit keys",
+		},
+		[]string{"limiter", "key"},
+	)
+
+	ServiceDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "service_method_duration_seconds",
+			Help:    "Duration of service layer method calls in seconds",
+			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		},
+		[]string{"method", "status"},
+	)
+
+	ServiceErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "service_method_errors_total",
+			Help: "Total number of service layer errors by method and error type",
+		},
+		[]string{"method", "error"},
+	)
+
+	SchedulerJobRuns = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "scheduler_job_runs_total",
+			Help: "Total number of scheduled job runs by outcome",
+		},
+		[]string{"job", "status"},
+	)
+
+	SchedulerJobDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "scheduler_job_duration_seconds",
+			Help:    "Duration of scheduled job runs in seconds",
+			Buckets: []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+		},
+		[]string{"job"},
+	)
+
+	SchedulerJobLastSuccess = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "scheduler_job_last_success_timestamp_seconds",
+			Help: "Unix timestamp of the last successful run of each scheduled job",
+		},
+		[]string{"job"},
+	)
+
+	QuotaExceeded = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "quota_exceeded_total",
+			Help: "Total number of requests rejected because a user quota was exhausted",
+		},
+		[]string{"action", "period"},
+	)
+
+	ContentFlagged = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "content_flagged_total",
+			Help: "Total number of pieces of content queued for moderation by the content scorer",
+		},
+		[]string{"kind"},
+	)
+
+	EventQueueDepth = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "event_publish_queue_depth",
+			Help: "Number of events waiting in the in-process publish queue",
+		},
+	)
+
+	EventPublishes = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "event_publishes_total",
+			Help: "Total number of events handled by the async publisher by event type and result",
+		},
+		[]string{"type", "result"},
+	)
+
+	DependencyUp = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "dependency_up",
+			Help: "Whether a dependency such as Redis or RabbitMQ is reachable (1) or not (0)",
+		},
+		[]string{"dependency"},
+	)
+
+	DependencyReconnects = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dependency_reconnects_total",
+			Help: "Total number of times a dropped dependency connection was restored",
+		},
+		[]string{"dependency"},
+	)
+
+	PollReadsCoalesced = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "poll_reads_coalesced_total",
+			Help: "Total number of poll reads served by a concurrent read of the same poll",
+		},
+	)
+
+	PollStatsRefreshes = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "poll_stats_background_refreshes_total",
+			Help: "Total number of cached poll stats refreshed in the background, by result",
+		},
+		[]string{"result"},
+	)
+
+	NotificationDeliveries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_deliveries_total",
+			Help: "Total number of notifications handed to a channel, by outcome: sent, rejected or failed",
+		},
+		[]string{"channel", "outcome"},
+	)
+
+	NotificationSends = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_sends_total",
+			Help: "Total number of notifications sent, by result: delivered (on at least one channel), rejected, failed or dropped (no channel enabled)",
+		},
+		[]string{"result"},
+	)
+
+	AnalyticsVotes = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "analytics_votes_total",
+			Help: "Total number of votes counted by the analytics consumer, by event: cast or deleted",
+		},
+		[]string{"event"},
+	)
+
+	AnalyticsPollsCreated = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "analytics_polls_created_total",
+			Help: "Total number of poll creations counted by the analytics consumer",
+		},
+	)
+
+	PollsOpen = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "polls_open",
+			Help: "Number of polls accepting votes, as of the last business_metrics job run",
+		},
+	)
+
+	DailyActiveVoters = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "daily_active_voters",
+			Help: "Number of distinct users who voted during the current UTC day, as of the last business_metrics job run",
+		},
+	)
+
+	ShutdownInFlight = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "shutdown_in_flight",
+			Help: "Work a component held at shutdown, before (stage=before) and after (stage=after) it was stopped; -1 if it could not be counted",
+		},
+		[]string{"component", "stage"},
+	)
+
+	ShutdownDrained = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "shutdown_drained",
+			Help: "Whether the last shutdown drained every component within the deadline (1) or not (0)",
+		},
+	)
+
+	ShutdownDuration = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "shutdown_duration_seconds",
+			Help: "Time the last shutdown took to stop every component",
+		},
+	)
 )
 
 func MetricsMiddleware() gin.HandlerFunc {
@@ -110,6 +329,13 @@ func MetricsMiddleware() gin.HandlerFunc {
 	}
 }
 
+func RecordRateLimitDecision(limiter, route, decision string) {
+	if route == "" {
+		route = "unknown"
+	}
+	RateLimitDecisions.WithLabelValues(limiter, route, decision).Inc()
+}
+
 func RecordCacheOperation(operation string, hit bool) {
 	status := "miss"
 	if hit {
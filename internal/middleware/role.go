@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole lets a request through when its user holds one of roles.
+// Admins pass every role check. It must run after the auth middleware.
+func RequireRole(roles ...auth.Role) gin.HandlerFunc {
+	allowed := make(map[auth.Role]struct{}, len(roles)+1)
+	for _, role := range roles {
+		allowed[role] = struct{}{}
+	}
+	allowed[auth.RoleAdmin] = struct{}{}
+
+	return func(c *gin.Context) {
+		principal, ok := auth.CurrentUser(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"status":  "error",
+				"message": "user not authenticated",
+			})
+			return
+		}
+		if _, ok := allowed[principal.Role]; !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"status":  "error",
+				"message": "Insufficient role",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,148 @@
+// Package moderation scores user-submitted content for spam and abuse so it
+// can be queued for review.
+package moderation
+
+import (
+	"context"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/validation"
+)
+
+// Weights of the heuristic signals. A single strong signal or a couple of
+// weaker ones are enough to reach the default threshold.
+const (
+	weightProfanity       = 0.6
+	weightLink            = 0.3
+	weightContact         = 0.3
+	weightShouting        = 0.3
+	weightRepeatedWords   = 0.3
+	weightRepeatedLetters = 0.2
+
+	shoutingMinLetters  = 12
+	shoutingUpperRatio  = 0.7
+	repeatedLetterRun   = 5
+	repeatedWordsMin    = 4
+	repeatedWordsRatio  = 0.5
+	maxLinksCounted     = 2
+	minContactDomainLen = 3
+)
+
+// Heuristic scores content locally from a handful of signals common in
+// spam: links, contact details, shouting, repetition and listed words.
+type Heuristic struct {
+	filter validation.ProfanityFilter
+}
+
+// NewHeuristic returns a heuristic scorer. filter may be nil to score
+// without a word list.
+func NewHeuristic(filter validation.ProfanityFilter) *Heuristic {
+	return &Heuristic{filter: filter}
+}
+
+func (h *Heuristic) Score(_ context.Context, content domain.Content) (domain.ContentScore, error) {
+	var score domain.ContentScore
+	add := func(weight float64, reason string) {
+		score.Score += weight
+		score.Reasons = append(score.Reasons, reason)
+	}
+
+	text := content.Text
+	if h.filter != nil {
+		if _, ok := h.filter.Match(text); ok {
+			add(weightProfanity, "profanity")
+		}
+	}
+	words := strings.Fields(strings.ToLower(text))
+	if links := countLinks(words); links > 0 {
+		add(weightLink*float64(min(links, maxLinksCounted)), "links")
+	}
+	if hasContactDetails(words) {
+		add(weightContact, "contact details")
+	}
+	if isShouting(text) {
+		add(weightShouting, "shouting")
+	}
+	if hasRepeatedWords(words) {
+		add(weightRepeatedWords, "repeated words")
+	}
+	if hasRepeatedLetters(text) {
+		add(weightRepeatedLetters, "repeated characters")
+	}
+
+	score.Score = math.Min(score.Score, 1)
+	return score, nil
+}
+
+func countLinks(words []string) int {
+	links := 0
+	for _, word := range words {
+		if strings.Contains(word, "http://") || strings.Contains(word, "https://") || strings.HasPrefix(word, "www.") {
+			links++
+		}
+	}
+	return links
+}
+
+// hasContactDetails looks for something shaped like an email address.
+func hasContactDetails(words []string) bool {
+	for _, word := range words {
+		at := strings.IndexByte(word, '@')
+		if at <= 0 {
+			continue
+		}
+		domainPart := strings.Trim(word[at+1:], ".,;:!?)")
+		if len(domainPart) >= minContactDomainLen && strings.Contains(domainPart, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+func isShouting(text string) bool {
+	letters, upper := 0, 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	return letters >= shoutingMinLetters && float64(upper) >= shoutingUpperRatio*float64(letters)
+}
+
+// hasRepeatedWords reports text dominated by a single word, as in
+// "buy buy buy buy now".
+func hasRepeatedWords(words []string) bool {
+	if len(words) < repeatedWordsMin {
+		return false
+	}
+	counts := make(map[string]int, len(words))
+	for _, word := range words {
+		counts[word]++
+		if counts[word] >= repeatedWordsMin && float64(counts[word]) >= repeatedWordsRatio*float64(len(words)) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRepeatedLetters(text string) bool {
+	var last rune
+	run := 0
+	for _, r := range text {
+		if r == last && !unicode.IsSpace(r) {
+			run++
+			if run >= repeatedLetterRun {
+				return true
+			}
+			continue
+		}
+		last, run = r, 1
+	}
+	return false
+}
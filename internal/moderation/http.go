@@ -0,0 +1,70 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+)
+
+type HTTPConfig struct {
+	URL     string
+	Token   string
+	Timeout time.Duration
+}
+
+// HTTPScorer delegates scoring to an external service, such as an ML
+// classifier. It POSTs the domain.Content as JSON and expects a
+// domain.ContentScore back.
+type HTTPScorer struct {
+	url   string
+	token string
+	http  *http.Client
+}
+
+func NewHTTPScorer(cfg HTTPConfig) *HTTPScorer {
+	return &HTTPScorer{
+		url:   cfg.URL,
+		token: cfg.Token,
+		http:  &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (s *HTTPScorer) Score(ctx context.Context, content domain.Content) (domain.ContentScore, error) {
+	body, err := json.Marshal(content)
+	if err != nil {
+		return domain.ContentScore{}, fmt.Errorf("marshal content: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return domain.ContentScore{}, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return domain.ContentScore{}, fmt.Errorf("score content: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return domain.ContentScore{}, fmt.Errorf("score content: status %d: %s", resp.StatusCode, msg)
+	}
+	var score domain.ContentScore
+	if err := json.NewDecoder(resp.Body).Decode(&score); err != nil {
+		return domain.ContentScore{}, fmt.Errorf("decode score: %w", err)
+	}
+	if score.Score < 0 || score.Score > 1 {
+		return domain.ContentScore{}, fmt.Errorf("score content: score %v out of range", score.Score)
+	}
+	return score, nil
+}
@@ -0,0 +1,82 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeuristic_Score(t *testing.T) {
+	scorer := NewHeuristic(validation.NewWordList([]string{"scam"}))
+
+	tests := []struct {
+		name    string
+		text    string
+		score   float64
+		reasons []string
+	}{
+		{name: "clean", text: "What is your favourite colour?", score: 0},
+		{name: "listed word", text: "Is this a scam?", score: 0.6, reasons: []string{"profanity"}},
+		{name: "one link", text: "Vote at https://example.com", score: 0.3, reasons: []string{"links"}},
+		{name: "links are capped", text: "www.a.com www.b.com www.c.com", score: 0.6, reasons: []string{"links"}},
+		{name: "email address", text: "Write to deals@example.com!", score: 0.3, reasons: []string{"contact details"}},
+		{name: "shouting", text: "BEST PHONE EVER?", score: 0.3, reasons: []string{"shouting"}},
+		{name: "short acronym", text: "NASA or ESA?", score: 0},
+		{name: "repeated words", text: "buy buy buy buy now", score: 0.3, reasons: []string{"repeated words"}},
+		{name: "repeated letters", text: "Sooooo good?", score: 0.2, reasons: []string{"repeated characters"}},
+		{
+			name:    "capped at one",
+			text:    "SCAM SCAM SCAM SCAM HTTPS://X.IO WWW.Y.IO!!!!!",
+			score:   1,
+			reasons: []string{"profanity", "links", "shouting", "repeated words", "repeated characters"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, err := scorer.Score(context.Background(), domain.Content{Kind: domain.ContentPollTitle, Text: tt.text})
+			require.NoError(t, err)
+			assert.InDelta(t, tt.score, score.Score, 1e-9)
+			assert.Equal(t, tt.reasons, score.Reasons)
+		})
+	}
+}
+
+func TestHTTPScorer_Score(t *testing.T) {
+	var got domain.Content
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		switch got.Text {
+		case "spam":
+			_, _ = w.Write([]byte(`{"score":0.9,"reasons":["spam"]}`))
+		case "broken":
+			_, _ = w.Write([]byte(`{"score":7}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	scorer := NewHTTPScorer(HTTPConfig{URL: server.URL, Token: "secret", Timeout: time.Second})
+
+	score, err := scorer.Score(context.Background(), domain.Content{Kind: domain.ContentPollOption, Text: "spam"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.ContentScore{Score: 0.9, Reasons: []string{"spam"}}, score)
+	assert.Equal(t, domain.ContentPollOption, got.Kind)
+
+	_, err = scorer.Score(context.Background(), domain.Content{Text: "broken"})
+	assert.ErrorContains(t, err, "out of range")
+
+	_, err = scorer.Score(context.Background(), domain.Content{Text: "down"})
+	assert.ErrorContains(t, err, "status 503")
+}
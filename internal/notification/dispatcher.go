@@ -0,0 +1,171 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/metrics"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Notification is a message for one user.
+type Notification struct {
+	UserID  uuid.UUID
+	Title   string
+	Message string
+}
+
+// Channel delivers notifications through one medium, such as email.
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, n *Notification) error
+}
+
+// RetryPolicy says how often a channel is tried before a notification is
+// given up on. The wait between attempts starts at Initial and doubles up
+// to Max.
+type RetryPolicy struct {
+	Attempts int
+	Initial  time.Duration
+	Max      time.Duration
+}
+
+// permanentError is a failure that retrying will not fix, such as a request
+// the provider rejected or a user without an address for the channel. Poll
+// webhook deliveries share it.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks err as a failure that retrying will not fix.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err, or an error it wraps, was marked with
+// Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Dispatcher is the NotificationService that sends each notification on
+// every configured channel.
+type Dispatcher struct {
+	channels []Channel
+	retry    RetryPolicy
+	logger   *zap.Logger
+}
+
+func NewDispatcher(channels []Channel, retry RetryPolicy, logger *zap.Logger) *Dispatcher {
+	if retry.Attempts < 1 {
+		retry.Attempts = 1
+	}
+	return &Dispatcher{
+		channels: channels,
+		retry:    retry,
+		logger:   logger,
+	}
+}
+
+// SendNotification sends the notification on every channel, retrying
+// transient failures with backoff. It only fails when no channel delivered
+// the notification: the consumer redelivers events whose handling failed,
+// which would repeat the notification on the channels that worked. Rejected
+// notifications are logged and dropped.
+func (d *Dispatcher) SendNotification(ctx context.Context, userID string, title, message string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID %q: %w", userID, err)
+	}
+	n := &Notification{UserID: id, Title: title, Message: message}
+	logger := logging.For(ctx, d.logger)
+
+	if len(d.channels) == 0 {
+		metrics.NotificationSends.WithLabelValues("dropped").Inc()
+		logger.Info("No notification channels enabled, dropping notification",
+			zap.String("user_id", userID),
+			zap.String("title", title),
+		)
+		return nil
+	}
+
+	delivered := false
+	var errs []error
+	for _, channel := range d.channels {
+		err := d.send(ctx, channel, n)
+		switch {
+		case err == nil:
+			delivered = true
+			metrics.NotificationDeliveries.WithLabelValues(channel.Name(), "sent").Inc()
+		case IsPermanent(err):
+			metrics.NotificationDeliveries.WithLabelValues(channel.Name(), "rejected").Inc()
+			logger.Warn("Notification rejected",
+				zap.String("channel", channel.Name()),
+				zap.String("user_id", userID),
+				zap.Error(err),
+			)
+		default:
+			metrics.NotificationDeliveries.WithLabelValues(channel.Name(), "failed").Inc()
+			errs = append(errs, fmt.Errorf("%s: %w", channel.Name(), err))
+		}
+	}
+
+	switch {
+	case delivered:
+		metrics.NotificationSends.WithLabelValues("delivered").Inc()
+	case len(errs) == 0:
+		metrics.NotificationSends.WithLabelValues("rejected").Inc()
+	default:
+		metrics.NotificationSends.WithLabelValues("failed").Inc()
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	if delivered {
+		logger.Warn("Notification not sent on every channel",
+			zap.String("user_id", userID),
+			zap.Error(errors.Join(errs...)),
+		)
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+func (d *Dispatcher) send(ctx context.Context, channel Channel, n *Notification) error {
+	wait := d.retry.Initial
+	for attempt := 1; ; attempt++ {
+		err := channel.Send(ctx, n)
+		if err == nil || IsPermanent(err) || attempt >= d.retry.Attempts {
+			return err
+		}
+
+		logging.For(ctx, d.logger).Debug("Notification failed, retrying",
+			zap.String("channel", channel.Name()),
+			zap.Int("attempt", attempt),
+			zap.Duration("retry_in", wait),
+			zap.Error(err),
+		)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		wait = min(wait*2, d.retry.Max)
+	}
+}
@@ -0,0 +1,278 @@
+package notification
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/metrics"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeChannel struct {
+	name  string
+	errs  []error
+	calls int
+}
+
+func (c *fakeChannel) Name() string {
+	return c.name
+}
+
+func (c *fakeChannel) Send(_ context.Context, _ *Notification) error {
+	c.calls++
+	if len(c.errs) == 0 {
+		return nil
+	}
+	err := c.errs[0]
+	c.errs = c.errs[1:]
+	return err
+}
+
+func TestDispatcher(t *testing.T) {
+	retry := RetryPolicy{Attempts: 3, Initial: time.Millisecond, Max: time.Millisecond}
+	userID := uuid.New().String()
+	transient := errors.New("connection reset")
+
+	t.Run("retries transient failures", func(t *testing.T) {
+		channel := &fakeChannel{name: "email", errs: []error{transient, transient}}
+		d := NewDispatcher([]Channel{channel}, retry, zap.NewNop())
+
+		require.NoError(t, d.SendNotification(context.Background(), userID, "title", "message"))
+		assert.Equal(t, 3, channel.calls)
+	})
+
+	t.Run("fails after the last attempt", func(t *testing.T) {
+		channel := &fakeChannel{name: "email", errs: []error{transient, transient, transient}}
+		d := NewDispatcher([]Channel{channel}, retry, zap.NewNop())
+
+		err := d.SendNotification(context.Background(), userID, "title", "message")
+		assert.ErrorIs(t, err, transient)
+		assert.Equal(t, 3, channel.calls)
+	})
+
+	t.Run("drops rejected notifications without retrying", func(t *testing.T) {
+		channel := &fakeChannel{name: "email", errs: []error{Permanent(errors.New("no address"))}}
+		d := NewDispatcher([]Channel{channel}, retry, zap.NewNop())
+
+		require.NoError(t, d.SendNotification(context.Background(), userID, "title", "message"))
+		assert.Equal(t, 1, channel.calls)
+	})
+
+	t.Run("succeeds when any channel delivered", func(t *testing.T) {
+		failing := &fakeChannel{name: "email", errs: []error{transient, transient, transient}}
+		working := &fakeChannel{name: "webhook"}
+		d := NewDispatcher([]Channel{failing, working}, retry, zap.NewNop())
+
+		require.NoError(t, d.SendNotification(context.Background(), userID, "title", "message"))
+		assert.Equal(t, 1, working.calls)
+	})
+
+	t.Run("counts sends by result", func(t *testing.T) {
+		sends := func(result string) float64 {
+			return testutil.ToFloat64(metrics.NotificationSends.WithLabelValues(result))
+		}
+		delivered, rejected, failed := sends("delivered"), sends("rejected"), sends("failed")
+
+		d := NewDispatcher([]Channel{&fakeChannel{name: "email"}}, retry, zap.NewNop())
+		require.NoError(t, d.SendNotification(context.Background(), userID, "title", "message"))
+		d = NewDispatcher([]Channel{&fakeChannel{name: "email", errs: []error{Permanent(errors.New("no address"))}}}, retry, zap.NewNop())
+		require.NoError(t, d.SendNotification(context.Background(), userID, "title", "message"))
+		d = NewDispatcher([]Channel{&fakeChannel{name: "email", errs: []error{transient, transient, transient}}}, retry, zap.NewNop())
+		require.Error(t, d.SendNotification(context.Background(), userID, "title", "message"))
+
+		assert.Equal(t, delivered+1, sends("delivered"))
+		assert.Equal(t, rejected+1, sends("rejected"))
+		assert.Equal(t, failed+1, sends("failed"))
+	})
+
+	t.Run("rejects invalid user IDs", func(t *testing.T) {
+		d := NewDispatcher([]Channel{&fakeChannel{name: "email"}}, retry, zap.NewNop())
+		assert.Error(t, d.SendNotification(context.Background(), "not-a-uuid", "title", "message"))
+	})
+}
+
+func TestWebhookChannel(t *testing.T) {
+	var body []byte
+	var signature string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(SignatureHeader)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	channel := NewWebhookChannel(WebhookConfig{URL: server.URL, Secret: "s3cret", Timeout: time.Second})
+	n := &Notification{UserID: uuid.New(), Title: "Poll closed", Message: "Results are in"}
+	require.NoError(t, channel.Send(context.Background(), n))
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, n.UserID.String(), payload["userId"])
+	assert.Equal(t, "Poll closed", payload["title"])
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), signature)
+
+	status = http.StatusGone
+	assert.True(t, IsPermanent(channel.Send(context.Background(), n)))
+	status = http.StatusTooManyRequests
+	err := channel.Send(context.Background(), n)
+	assert.Error(t, err)
+	assert.False(t, IsPermanent(err))
+}
+
+func TestPushChannel(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	tokenRequests := 0
+	var message map[string]map[string]interface{}
+	var sendPath, authorization string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.Form.Get("grant_type"))
+		assert.NotEmpty(t, r.Form.Get("assertion"))
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "token-1", "expires_in": 3600})
+	})
+	mux.HandleFunc("/v1/", func(w http.ResponseWriter, r *http.Request) {
+		sendPath = r.URL.Path
+		authorization = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&message)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	credentials, err := json.Marshal(map[string]string{
+		"project_id":   "vote-test",
+		"client_email": "push@vote-test.iam.gserviceaccount.com",
+		"private_key":  string(keyPEM),
+		"token_uri":    server.URL + "/token",
+	})
+	require.NoError(t, err)
+	file := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(file, credentials, 0o600))
+
+	channel, err := NewPushChannel(PushConfig{CredentialsFile: file, Endpoint: server.URL, Timeout: time.Second})
+	require.NoError(t, err)
+
+	n := &Notification{UserID: uuid.New(), Title: "Poll closed", Message: "Results are in"}
+	require.NoError(t, channel.Send(context.Background(), n))
+	require.NoError(t, channel.Send(context.Background(), n))
+
+	assert.Equal(t, 1, tokenRequests)
+	assert.Equal(t, "/v1/projects/vote-test/messages:send", sendPath)
+	assert.Equal(t, "Bearer token-1", authorization)
+	assert.Equal(t, PushTopic(n.UserID), message["message"]["topic"])
+}
+
+type fakeUserLookup struct {
+	users map[uuid.UUID]*domain.User
+}
+
+func (l *fakeUserLookup) GetUserByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	if user, ok := l.users[id]; ok {
+		return user, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+// fakeSMTPServer accepts one session at a time, answering every command
+// with success, and records the message data.
+func fakeSMTPServer(t *testing.T, messages chan<- string) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			conn.Write([]byte("220 localhost ready\r\n"))
+			var data strings.Builder
+			inData := false
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					break
+				}
+				if inData {
+					if line == ".\r\n" {
+						inData = false
+						messages <- data.String()
+						conn.Write([]byte("250 queued\r\n"))
+						continue
+					}
+					data.WriteString(line)
+					continue
+				}
+				switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+				case "EHLO", "HELO":
+					conn.Write([]byte("250 localhost\r\n"))
+				case "DATA":
+					inData = true
+					conn.Write([]byte("354 go ahead\r\n"))
+				case "QUIT":
+					conn.Write([]byte("221 bye\r\n"))
+				default:
+					conn.Write([]byte("250 ok\r\n"))
+				}
+			}
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestEmailChannel(t *testing.T) {
+	messages := make(chan string, 1)
+	host, port, err := net.SplitHostPort(fakeSMTPServer(t, messages))
+	require.NoError(t, err)
+	portNum, err := net.LookupPort("tcp", port)
+	require.NoError(t, err)
+
+	verified := &domain.User{ID: uuid.New(), Email: "alice@example.com", EmailVerified: true}
+	unverified := &domain.User{ID: uuid.New(), Email: "bob@example.com"}
+	users := &fakeUserLookup{users: map[uuid.UUID]*domain.User{verified.ID: verified, unverified.ID: unverified}}
+	channel := NewEmailChannel(SMTPConfig{Host: host, Port: portNum, From: "Vote <noreply@example.com>", Timeout: time.Second}, users)
+
+	err = channel.Send(context.Background(), &Notification{UserID: verified.ID, Title: "Poll closed", Message: "Results are in\nGo see"})
+	require.NoError(t, err)
+	msg := <-messages
+	assert.Contains(t, msg, "To: alice@example.com\r\n")
+	assert.Contains(t, msg, "Subject: Poll closed\r\n")
+	assert.Contains(t, msg, "Results are in\r\nGo see\r\n")
+
+	assert.True(t, IsPermanent(channel.Send(context.Background(), &Notification{UserID: unverified.ID})))
+	assert.True(t, IsPermanent(channel.Send(context.Background(), &Notification{UserID: uuid.New()})))
+}
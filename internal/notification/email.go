@@ -0,0 +1,148 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+)
+
+// UserLookup finds the address email notifications are sent to.
+type UserLookup interface {
+	GetUserByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration
+}
+
+// EmailChannel mails notifications over SMTP, upgrading the connection with
+// STARTTLS when the server offers it. Only verified addresses are mailed.
+type EmailChannel struct {
+	cfg   SMTPConfig
+	users UserLookup
+}
+
+func NewEmailChannel(cfg SMTPConfig, users UserLookup) *EmailChannel {
+	return &EmailChannel{
+		cfg:   cfg,
+		users: users,
+	}
+}
+
+func (c *EmailChannel) Name() string {
+	return "email"
+}
+
+func (c *EmailChannel) Send(ctx context.Context, n *Notification) error {
+	user, err := c.users.GetUserByID(ctx, n.UserID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return Permanent(err)
+	}
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+	if user.Email == "" || !user.EmailVerified {
+		return Permanent(errors.New("user has no verified email address"))
+	}
+
+	from, err := mail.ParseAddress(c.cfg.From)
+	if err != nil {
+		return Permanent(fmt.Errorf("parse from address: %w", err))
+	}
+	return c.deliver(ctx, from.Address, user.Email, emailMessage(c.cfg.From, user.Email, n, time.Now()))
+}
+
+func (c *EmailChannel) deliver(ctx context.Context, from, to string, msg []byte) error {
+	dialer := net.Dialer{Timeout: c.cfg.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(c.cfg.Host, strconv.Itoa(c.cfg.Port)))
+	if err != nil {
+		return fmt.Errorf("connect to smtp server: %w", err)
+	}
+	if c.cfg.Timeout > 0 {
+		if err := conn.SetDeadline(time.Now().Add(c.cfg.Timeout)); err != nil {
+			conn.Close()
+			return fmt.Errorf("set smtp deadline: %w", err)
+		}
+	}
+
+	client, err := smtp.NewClient(conn, c.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return smtpError("greet smtp server", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.cfg.Host}); err != nil {
+			return smtpError("start tls", err)
+		}
+	}
+	if c.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.cfg.Username, c.cfg.Password, c.cfg.Host)); err != nil {
+			return smtpError("authenticate", err)
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return smtpError("set sender", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return smtpError("set recipient", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return smtpError("start message", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return smtpError("write message", err)
+	}
+	if err := w.Close(); err != nil {
+		return smtpError("send message", err)
+	}
+	return client.Quit()
+}
+
+// smtpError marks replies in the 5xx range, which the server will give
+// again, as permanent.
+func smtpError(op string, err error) error {
+	err = fmt.Errorf("%s: %w", op, err)
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return Permanent(err)
+	}
+	return err
+}
+
+// emailMessage renders a plain text mail. The subject is MIME-encoded when
+// needed, which also keeps line breaks in a title out of the headers.
+func emailMessage(from, to string, n *Notification, date time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", n.Title))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	body := strings.ReplaceAll(strings.ReplaceAll(n.Message, "\r\n", "\n"), "\n", "\r\n")
+	buf.WriteString(body)
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
@@ -2,9 +2,13 @@ package notification
 
 import (
 	"context"
+	"fmt"
+	"sort"
 
 	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
 	"github.com/behzadon/vote/internal/storage/events"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -12,42 +16,182 @@ type NotificationService interface {
 	SendNotification(ctx context.Context, userID string, title, message string) error
 }
 
+type PreferenceStore interface {
+	GetTagFollowers(ctx context.Context, tags []string) ([]uuid.UUID, error)
+	GetUserPreferences(ctx context.Context, userID uuid.UUID) (*domain.UserPreferences, error)
+}
+
+// CreatorStore provides what is needed to tell poll creators about vote
+// milestones and closed polls.
+type CreatorStore interface {
+	GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Poll, error)
+	CountVotes(ctx context.Context, pollID uuid.UUID) (int, error)
+	RecordPollMilestone(ctx context.Context, pollID uuid.UUID, milestone int) (bool, error)
+}
+
 type NotificationHandler struct {
 	notificationService NotificationService
+	preferences         PreferenceStore
+	creators            CreatorStore
+	milestones          []int
 	logger              *zap.Logger
 }
 
-func NewNotificationHandler(notificationService NotificationService, logger *zap.Logger) events.EventHandler {
-	return &NotificationHandler{
+type Option func(*NotificationHandler)
+
+// WithCreatorNotifications notifies poll creators when their poll reaches one
+// of the given vote counts and when it is closed.
+func WithCreatorNotifications(store CreatorStore, milestones []int) Option {
+	return func(h *NotificationHandler) {
+		h.creators = store
+		h.milestones = append([]int(nil), milestones...)
+		sort.Ints(h.milestones)
+	}
+}
+
+func NewNotificationHandler(notificationService NotificationService, preferences PreferenceStore, logger *zap.Logger, opts ...Option) events.EventHandler {
+	h := &NotificationHandler{
 		notificationService: notificationService,
+		preferences:         preferences,
 		logger:              logger,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
-func (h *NotificationHandler) HandlePollCreated(_ context.Context, poll *domain.Poll) error {
+// HandlePollCreated notifies followers of the poll's tags, skipping users who
+// muted one of its tags or a keyword in its title. Polls with a restricted
+// electorate are not announced.
+func (h *NotificationHandler) HandlePollCreated(ctx context.Context, poll *domain.Poll) error {
+	if len(poll.Tags) == 0 || poll.Electorate.Restricted() {
+		return nil
+	}
 
-	for _, tag := range poll.Tags {
-		h.logger.Info("Would notify users following tag",
-			zap.String("tag", tag),
-			zap.String("poll_id", poll.ID.String()),
-			zap.String("poll_title", poll.Title),
-		)
+	followers, err := h.preferences.GetTagFollowers(ctx, poll.Tags)
+	if err != nil {
+		return fmt.Errorf("get tag followers: %w", err)
 	}
 
+	for _, userID := range followers {
+		prefs, err := h.preferences.GetUserPreferences(ctx, userID)
+		if err != nil {
+			logging.For(ctx, h.logger).Warn("Failed to load user preferences",
+				zap.Error(err),
+				zap.String("user_id", userID.String()),
+			)
+			continue
+		}
+		if prefs.Mutes(poll) {
+			logging.For(ctx, h.logger).Debug("Skipping notification for muted poll",
+				zap.String("user_id", userID.String()),
+				zap.String("poll_id", poll.ID.String()),
+			)
+			continue
+		}
+
+		if err := h.notificationService.SendNotification(ctx, userID.String(), "New poll", poll.Title); err != nil {
+			logging.For(ctx, h.logger).Warn("Failed to send new poll notification",
+				zap.Error(err),
+				zap.String("user_id", userID.String()),
+				zap.String("poll_id", poll.ID.String()),
+			)
+		}
+	}
+
+	return nil
+}
+
+func (h *NotificationHandler) HandlePollUpdated(ctx context.Context, poll *domain.Poll) error {
 	return nil
 }
 
 func (h *NotificationHandler) HandlePollVoted(ctx context.Context, vote *domain.Vote) error {
-	h.logger.Info("Would notify poll creator about new vote",
+	logging.For(ctx, h.logger).Info("Would notify poll creator about new vote",
 		zap.String("poll_id", vote.PollID.String()),
 		zap.String("voter_id", vote.UserID.String()),
 	)
 
+	if err := h.sendVoteReceipt(ctx, vote, fmt.Sprintf("Your vote for %q on %q was recorded", vote.OptionText, vote.PollTitle)); err != nil {
+		return err
+	}
+	return h.notifyMilestone(ctx, vote.PollID)
+}
+
+// notifyMilestone tells the creator about the highest milestone the poll has
+// newly reached. Milestones are recorded so redelivered or concurrent events
+// announce each one once.
+func (h *NotificationHandler) notifyMilestone(ctx context.Context, pollID uuid.UUID) error {
+	if h.creators == nil || len(h.milestones) == 0 {
+		return nil
+	}
+
+	count, err := h.creators.CountVotes(ctx, pollID)
+	if err != nil {
+		return fmt.Errorf("count votes: %w", err)
+	}
+	if count < h.milestones[0] {
+		return nil
+	}
+
+	poll, err := h.creators.GetPollByID(ctx, pollID)
+	if err != nil {
+		return fmt.Errorf("get poll: %w", err)
+	}
+	if poll.CreatedBy == nil {
+		return nil
+	}
+
+	reached := 0
+	for _, milestone := range h.milestones {
+		if count < milestone {
+			break
+		}
+		recorded, err := h.creators.RecordPollMilestone(ctx, pollID, milestone)
+		if err != nil {
+			return fmt.Errorf("record milestone: %w", err)
+		}
+		if recorded {
+			reached = milestone
+		}
+	}
+	if reached == 0 {
+		return nil
+	}
+
+	message := fmt.Sprintf("Your poll %q reached %d votes", poll.Title, reached)
+	if err := h.notificationService.SendNotification(ctx, poll.CreatedBy.String(), "Poll milestone", message); err != nil {
+		return fmt.Errorf("send milestone notification: %w", err)
+	}
+	return nil
+}
+
+func (h *NotificationHandler) HandleVoteUpdated(ctx context.Context, vote *domain.Vote) error {
+	return h.sendVoteReceipt(ctx, vote, fmt.Sprintf("Your vote on %q was changed to %q", vote.PollTitle, vote.OptionText))
+}
+
+func (h *NotificationHandler) HandleVoteDeleted(ctx context.Context, vote *domain.Vote) error {
+	return h.sendVoteReceipt(ctx, vote, fmt.Sprintf("Your vote on %q was deleted", vote.PollTitle))
+}
+
+// sendVoteReceipt notifies the voter if they opted into vote receipts.
+func (h *NotificationHandler) sendVoteReceipt(ctx context.Context, vote *domain.Vote, message string) error {
+	prefs, err := h.preferences.GetUserPreferences(ctx, vote.UserID)
+	if err != nil {
+		return fmt.Errorf("get user preferences: %w", err)
+	}
+	if !prefs.VoteReceipts {
+		return nil
+	}
+	if err := h.notificationService.SendNotification(ctx, vote.UserID.String(), "Vote receipt", message); err != nil {
+		return fmt.Errorf("send vote receipt: %w", err)
+	}
 	return nil
 }
 
 func (h *NotificationHandler) HandlePollSkipped(ctx context.Context, skip *domain.Skip) error {
-	h.logger.Info("Poll skipped",
+	logging.For(ctx, h.logger).Info("Poll skipped",
 		zap.String("poll_id", skip.PollID.String()),
 		zap.String("user_id", skip.UserID.String()),
 		zap.Time("timestamp", skip.CreatedAt),
@@ -55,3 +199,42 @@ func (h *NotificationHandler) HandlePollSkipped(ctx context.Context, skip *domai
 
 	return nil
 }
+
+// HandlePollStatusChanged tells the creator when someone else closes their
+// poll, along with its final vote count.
+func (h *NotificationHandler) HandlePollStatusChanged(ctx context.Context, change *domain.PollStatusChange) error {
+	logging.For(ctx, h.logger).Info("Poll status changed",
+		zap.String("poll_id", change.PollID.String()),
+		zap.String("from", string(change.From)),
+		zap.String("to", string(change.To)),
+	)
+
+	if h.creators == nil || change.To != domain.PollStatusClosed {
+		return nil
+	}
+	poll, err := h.creators.GetPollByID(ctx, change.PollID)
+	if err != nil {
+		return fmt.Errorf("get poll: %w", err)
+	}
+	if poll.CreatedBy == nil || *poll.CreatedBy == change.ActorID {
+		return nil
+	}
+	count, err := h.creators.CountVotes(ctx, change.PollID)
+	if err != nil {
+		return fmt.Errorf("count votes: %w", err)
+	}
+
+	message := fmt.Sprintf("Your poll %q was closed with %d votes", poll.Title, count)
+	if err := h.notificationService.SendNotification(ctx, poll.CreatedBy.String(), "Poll closed", message); err != nil {
+		return fmt.Errorf("send poll closed notification: %w", err)
+	}
+	return nil
+}
+
+func (h *NotificationHandler) HandleCollaboratorInvited(ctx context.Context, collaborator *domain.Collaborator) error {
+	message := fmt.Sprintf("You were given %s access to the poll %q", collaborator.Permission, collaborator.PollTitle)
+	if err := h.notificationService.SendNotification(ctx, collaborator.UserID.String(), "Poll collaboration", message); err != nil {
+		return fmt.Errorf("send collaborator invitation: %w", err)
+	}
+	return nil
+}
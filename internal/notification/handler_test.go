@@ -0,0 +1,144 @@
+package notification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakePreferenceStore struct {
+	followers []uuid.UUID
+	prefs     map[uuid.UUID]*domain.UserPreferences
+}
+
+func (s *fakePreferenceStore) GetTagFollowers(_ context.Context, _ []string) ([]uuid.UUID, error) {
+	return s.followers, nil
+}
+
+func (s *fakePreferenceStore) GetUserPreferences(_ context.Context, userID uuid.UUID) (*domain.UserPreferences, error) {
+	if prefs, ok := s.prefs[userID]; ok {
+		return prefs, nil
+	}
+	return &domain.UserPreferences{}, nil
+}
+
+type recordingNotifier struct {
+	sent []string
+}
+
+func (n *recordingNotifier) SendNotification(_ context.Context, userID string, _, _ string) error {
+	n.sent = append(n.sent, userID)
+	return nil
+}
+
+func TestHandlePollCreated_SkipsMutedUsers(t *testing.T) {
+	follower := uuid.New()
+	muter := uuid.New()
+	store := &fakePreferenceStore{
+		followers: []uuid.UUID{follower, muter},
+		prefs: map[uuid.UUID]*domain.UserPreferences{
+			muter: {MutedKeywords: []string{"tabs"}},
+		},
+	}
+	notifier := &recordingNotifier{}
+	handler := NewNotificationHandler(notifier, store, zap.NewNop())
+
+	poll := &domain.Poll{ID: uuid.New(), Title: "Tabs or spaces?", Tags: []string{"golang"}}
+	require.NoError(t, handler.HandlePollCreated(context.Background(), poll))
+	assert.Equal(t, []string{follower.String()}, notifier.sent)
+}
+
+func TestHandlePollCreated_SkipsRestrictedPolls(t *testing.T) {
+	store := &fakePreferenceStore{followers: []uuid.UUID{uuid.New()}}
+	notifier := &recordingNotifier{}
+	handler := NewNotificationHandler(notifier, store, zap.NewNop())
+
+	poll := &domain.Poll{ID: uuid.New(), Title: "Budget", Tags: []string{"team"}, Electorate: domain.ElectorateOrganization}
+	require.NoError(t, handler.HandlePollCreated(context.Background(), poll))
+	assert.Empty(t, notifier.sent)
+}
+
+func TestVoteReceipts_OnlyForOptedInUsers(t *testing.T) {
+	optedIn := uuid.New()
+	store := &fakePreferenceStore{
+		prefs: map[uuid.UUID]*domain.UserPreferences{
+			optedIn: {VoteReceipts: true},
+		},
+	}
+	notifier := &recordingNotifier{}
+	handler := NewNotificationHandler(notifier, store, zap.NewNop())
+	ctx := context.Background()
+
+	require.NoError(t, handler.HandlePollVoted(ctx, &domain.Vote{UserID: optedIn, PollTitle: "Lunch", OptionText: "Pizza"}))
+	require.NoError(t, handler.HandleVoteUpdated(ctx, &domain.Vote{UserID: uuid.New(), PollTitle: "Lunch", OptionText: "Sushi"}))
+	require.NoError(t, handler.HandleVoteDeleted(ctx, &domain.Vote{UserID: optedIn, PollTitle: "Lunch"}))
+	assert.Equal(t, []string{optedIn.String(), optedIn.String()}, notifier.sent)
+}
+
+type fakeCreatorStore struct {
+	poll     *domain.Poll
+	votes    int
+	recorded map[int]bool
+}
+
+func (s *fakeCreatorStore) GetPollByID(_ context.Context, _ uuid.UUID) (*domain.Poll, error) {
+	return s.poll, nil
+}
+
+func (s *fakeCreatorStore) CountVotes(_ context.Context, _ uuid.UUID) (int, error) {
+	return s.votes, nil
+}
+
+func (s *fakeCreatorStore) RecordPollMilestone(_ context.Context, _ uuid.UUID, milestone int) (bool, error) {
+	if s.recorded[milestone] {
+		return false, nil
+	}
+	s.recorded[milestone] = true
+	return true, nil
+}
+
+func TestHandlePollVoted_NotifiesCreatorOncePerMilestone(t *testing.T) {
+	creator := uuid.New()
+	poll := &domain.Poll{ID: uuid.New(), Title: "Lunch", CreatedBy: &creator}
+	creators := &fakeCreatorStore{poll: poll, votes: 9, recorded: map[int]bool{}}
+	notifier := &recordingNotifier{}
+	handler := NewNotificationHandler(notifier, &fakePreferenceStore{}, zap.NewNop(),
+		WithCreatorNotifications(creators, []int{100, 10}),
+	)
+	vote := &domain.Vote{PollID: poll.ID, UserID: uuid.New()}
+	ctx := context.Background()
+
+	require.NoError(t, handler.HandlePollVoted(ctx, vote))
+	assert.Empty(t, notifier.sent)
+
+	creators.votes = 10
+	require.NoError(t, handler.HandlePollVoted(ctx, vote))
+	require.NoError(t, handler.HandlePollVoted(ctx, vote))
+	assert.Equal(t, []string{creator.String()}, notifier.sent)
+	assert.True(t, creators.recorded[10])
+}
+
+func TestHandlePollStatusChanged_NotifiesCreatorOnClose(t *testing.T) {
+	creator := uuid.New()
+	poll := &domain.Poll{ID: uuid.New(), Title: "Lunch", CreatedBy: &creator}
+	notifier := &recordingNotifier{}
+	handler := NewNotificationHandler(notifier, &fakePreferenceStore{}, zap.NewNop(),
+		WithCreatorNotifications(&fakeCreatorStore{poll: poll, votes: 3}, nil),
+	)
+	ctx := context.Background()
+
+	require.NoError(t, handler.HandlePollStatusChanged(ctx, &domain.PollStatusChange{
+		PollID: poll.ID, From: domain.PollStatusLive, To: domain.PollStatusClosed, ActorID: creator,
+	}))
+	assert.Empty(t, notifier.sent)
+
+	require.NoError(t, handler.HandlePollStatusChanged(ctx, &domain.PollStatusChange{
+		PollID: poll.ID, From: domain.PollStatusLive, To: domain.PollStatusClosed, ActorID: uuid.New(),
+	}))
+	assert.Equal(t, []string{creator.String()}, notifier.sent)
+}
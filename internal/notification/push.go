@@ -0,0 +1,190 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// DefaultPushEndpoint is the FCM HTTP v1 API.
+const DefaultPushEndpoint = "https://fcm.googleapis.com"
+
+const pushScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+type PushConfig struct {
+	// CredentialsFile is a Google service account key with access to FCM.
+	CredentialsFile string
+	Endpoint        string
+	Timeout         time.Duration
+}
+
+// serviceAccount is the part of a service account key used to get access
+// tokens.
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// PushChannel sends notifications through Firebase Cloud Messaging to the
+// topic of the user, see PushTopic, which the apps subscribe to on sign in.
+type PushChannel struct {
+	account  serviceAccount
+	endpoint string
+	http     *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func NewPushChannel(cfg PushConfig) (*PushChannel, error) {
+	data, err := os.ReadFile(cfg.CredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("read push credentials: %w", err)
+	}
+	var account serviceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("parse push credentials: %w", err)
+	}
+	if account.ProjectID == "" || account.ClientEmail == "" || account.PrivateKey == "" || account.TokenURI == "" {
+		return nil, fmt.Errorf("push credentials need project_id, client_email, private_key and token_uri")
+	}
+	if _, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey)); err != nil {
+		return nil, fmt.Errorf("parse push private key: %w", err)
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultPushEndpoint
+	}
+	return &PushChannel{
+		account:  account,
+		endpoint: strings.TrimRight(endpoint, "/"),
+		http:     &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// PushTopic is the FCM topic notifications for userID are sent to.
+func PushTopic(userID uuid.UUID) string {
+	return "user_" + userID.String()
+}
+
+func (c *PushChannel) Name() string {
+	return "push"
+}
+
+func (c *PushChannel) Send(ctx context.Context, n *Notification) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"message": map[string]interface{}{
+			"topic": PushTopic(n.UserID),
+			"notification": map[string]string{
+				"title": n.Title,
+				"body":  n.Message,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("marshal push message: %w", err)
+	}
+
+	sendURL := fmt.Sprintf("%s/v1/projects/%s/messages:send", c.endpoint, url.PathEscape(c.account.ProjectID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sendURL, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(fmt.Errorf("create request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("send push message: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		// The token was revoked or expired early; the retry gets a new one.
+		c.mu.Lock()
+		c.token = ""
+		c.mu.Unlock()
+		return fmt.Errorf("send push message: access token rejected")
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("send push message: %w", StatusError(resp))
+	}
+	return nil
+}
+
+// accessToken returns an OAuth access token for FCM, exchanging a signed
+// assertion for a new one when the cached token is about to expire.
+func (c *PushChannel) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.expires.Add(-time.Minute)) {
+		return c.token, nil
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(c.account.PrivateKey))
+	if err != nil {
+		return "", Permanent(fmt.Errorf("parse push private key: %w", err))
+	}
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   c.account.ClientEmail,
+		"scope": pushScope,
+		"aud":   c.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("sign push assertion: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", Permanent(fmt.Errorf("create token request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("get push access token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("get push access token: %w", StatusError(resp))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("decode push access token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("get push access token: empty token")
+	}
+	c.token = token.AccessToken
+	c.expires = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+	return c.token, nil
+}
@@ -0,0 +1,88 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of a webhook body, keyed with the
+// webhook secret, as "sha256=<hex>".
+const SignatureHeader = "X-Vote-Signature"
+
+type WebhookConfig struct {
+	URL     string
+	Secret  string
+	Timeout time.Duration
+}
+
+// WebhookChannel posts each notification as JSON to a URL, for relaying to
+// chat tools or a delivery service of the operator's own.
+type WebhookChannel struct {
+	url    string
+	secret []byte
+	http   *http.Client
+}
+
+func NewWebhookChannel(cfg WebhookConfig) *WebhookChannel {
+	return &WebhookChannel{
+		url:    cfg.URL,
+		secret: []byte(cfg.Secret),
+		http:   &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (c *WebhookChannel) Name() string {
+	return "webhook"
+}
+
+func (c *WebhookChannel) Send(ctx context.Context, n *Notification) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"userId":  n.UserID,
+		"title":   n.Title,
+		"message": n.Message,
+		"sentAt":  time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal webhook body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(fmt.Errorf("create request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(c.secret) > 0 {
+		mac := hmac.New(sha256.New, c.secret)
+		mac.Write(body)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("post webhook: %w", StatusError(resp))
+	}
+	return nil
+}
+
+// StatusError describes a failed response and consumes its body. Client
+// errors are permanent, except timeouts and rate limiting.
+func StatusError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err := fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,213 @@
+// Package pollhook sends closed polls' results to the webhooks their creators
+// attached to them.
+package pollhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/notification"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// EventPollClosed is the event field of every delivery.
+const EventPollClosed = "poll.closed"
+
+const deliveryBatch = 100
+
+// errInternalAddress refuses webhooks that point into the server's own
+// network, which poll creators must not be able to reach through it.
+var errInternalAddress = errors.New("address is not public")
+
+type Store interface {
+	GetDuePollWebhooks(ctx context.Context, now time.Time, limit int) ([]domain.PollWebhook, error)
+	GetPollResultSnapshot(ctx context.Context, pollID uuid.UUID) (*domain.PollResultSnapshot, error)
+	MarkPollWebhookDelivered(ctx context.Context, pollID uuid.UUID) error
+	RecordPollWebhookFailure(ctx context.Context, pollID uuid.UUID, reason string, retryAt *time.Time) error
+}
+
+// Config sets how long a delivery may take and how often a failed one is
+// retried: after InitialBackoff, doubling up to MaxBackoff, until
+// MaxAttempts deliveries have failed.
+type Config struct {
+	Timeout        time.Duration
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+type Deliverer struct {
+	store  Store
+	cfg    Config
+	http   *http.Client
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+func NewDeliverer(store Store, cfg Config, logger *zap.Logger) *Deliverer {
+	return &Deliverer{
+		store:  store,
+		cfg:    cfg,
+		http:   newClient(cfg.Timeout, refuseInternal),
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// CheckURL reports why rawURL can't be a poll webhook. It has to be an
+// absolute https URL without credentials, and a host given as an IP address
+// has to be public. Host names are checked when they are dialed, as they
+// may resolve elsewhere by then.
+func CheckURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil {
+		return errors.New("must be an absolute https URL")
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && isInternal(ip) {
+		return errors.New("must not point to an internal address")
+	}
+	return nil
+}
+
+// newClient posts without following redirects, which would let a public
+// URL send the request anywhere. control vets every address dialed; there
+// is no proxy, so those are the webhooks' own addresses.
+func newClient(timeout time.Duration, control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// refuseInternal is a dialer control refusing internal addresses. It runs
+// after the host name was resolved, for each address tried.
+func refuseInternal(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || isInternal(ip) {
+		return fmt.Errorf("%w: %s", errInternalAddress, host)
+	}
+	return nil
+}
+
+// isInternal reports whether ip is a loopback, private, link-local or
+// unspecified address, such as 127.0.0.1, 10.0.0.1 or the cloud metadata
+// service at 169.254.169.254.
+func isInternal(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
+
+// DeliverDue posts the results of every snapshotted poll whose webhook is
+// due and returns how many were delivered. A failed delivery is recorded for
+// a later retry and doesn't stop the others.
+func (d *Deliverer) DeliverDue(ctx context.Context) (int, error) {
+	webhooks, err := d.store.GetDuePollWebhooks(ctx, d.now().UTC(), deliveryBatch)
+	if err != nil {
+		return 0, err
+	}
+	delivered := 0
+	for _, webhook := range webhooks {
+		snapshot, err := d.store.GetPollResultSnapshot(ctx, webhook.PollID)
+		if err != nil {
+			return delivered, fmt.Errorf("get results of poll %s: %w", webhook.PollID, err)
+		}
+
+		sendErr := d.send(ctx, webhook, snapshot)
+		if sendErr == nil {
+			if err := d.store.MarkPollWebhookDelivered(ctx, webhook.PollID); err != nil {
+				return delivered, err
+			}
+			delivered++
+			continue
+		}
+
+		retryAt := d.retryAt(webhook.Attempts + 1)
+		if notification.IsPermanent(sendErr) || webhook.Attempts+1 >= d.cfg.MaxAttempts {
+			retryAt = nil
+		}
+		d.logger.Warn("Failed to deliver poll webhook",
+			zap.Error(sendErr),
+			zap.String("poll_id", webhook.PollID.String()),
+			zap.Int("attempt", webhook.Attempts+1),
+			zap.Bool("giving_up", retryAt == nil),
+		)
+		if err := d.store.RecordPollWebhookFailure(ctx, webhook.PollID, sendErr.Error(), retryAt); err != nil {
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+func (d *Deliverer) send(ctx context.Context, webhook domain.PollWebhook, snapshot *domain.PollResultSnapshot) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"event":   EventPollClosed,
+		"pollId":  webhook.PollID,
+		"results": snapshot,
+		"sentAt":  d.now().UTC(),
+	})
+	if err != nil {
+		return notification.Permanent(fmt.Errorf("marshal webhook body: %w", err))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return notification.Permanent(fmt.Errorf("create request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if webhook.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(webhook.Secret))
+		mac.Write(body)
+		req.Header.Set(notification.SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := d.http.Do(req)
+	if errors.Is(err, errInternalAddress) {
+		return notification.Permanent(fmt.Errorf("post webhook: %w", err))
+	}
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 3 {
+		return notification.Permanent(fmt.Errorf("post webhook: redirected with status %d", resp.StatusCode))
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("post webhook: %w", notification.StatusError(resp))
+	}
+	return nil
+}
+
+func (d *Deliverer) retryAt(failures int) *time.Time {
+	backoff := d.cfg.InitialBackoff
+	for i := 1; i < failures && backoff < d.cfg.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > d.cfg.MaxBackoff {
+		backoff = d.cfg.MaxBackoff
+	}
+	at := d.now().UTC().Add(backoff)
+	return &at
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/behzadon/vote/internal/domain"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type Repository struct {
@@ -143,7 +143,8 @@ func (r *Repository) GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Pol
 	return &poll, nil
 }
 
-func (r *Repository) GetPollsForFeed(ctx context.Context, userID uuid.UUID, tag string, page, limit int) ([]domain.Poll, int, error) {
+func (r *Repository) GetPollsForFeed(ctx context.Context, q domain.FeedQuery) ([]domain.Poll, int, error) {
+	userID, tag, page, limit := q.UserID, q.Tag, q.Page, q.Limit
 	var polls []domain.Poll
 	var total int
 
@@ -187,17 +188,41 @@ func (r *Repository) GetPollsForFeed(ctx context.Context, userID uuid.UUID, tag
 		return nil, 0, err
 	}
 
+	if len(polls) == 0 {
+		return polls, total, nil
+	}
+
+	ids := make([]uuid.UUID, len(polls))
+	byID := make(map[uuid.UUID]*domain.Poll, len(polls))
 	for i := range polls {
-		optionsQuery := `SELECT * FROM poll_options WHERE poll_id = $1 ORDER BY option_index`
-		err = r.db.SelectContext(ctx, &polls[i].Options, optionsQuery, polls[i].ID)
-		if err != nil {
-			return nil, 0, err
+		ids[i] = polls[i].ID
+		byID[polls[i].ID] = &polls[i]
+	}
+
+	var options []domain.Option
+	optionsQuery := `SELECT * FROM poll_options WHERE poll_id = ANY($1) ORDER BY poll_id, option_index`
+	err = r.db.SelectContext(ctx, &options, optionsQuery, pq.Array(ids))
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, option := range options {
+		if poll, ok := byID[option.PollID]; ok {
+			poll.Options = append(poll.Options, option)
 		}
+	}
 
-		tagsQuery := `SELECT tag FROM poll_tags WHERE poll_id = $1`
-		err = r.db.SelectContext(ctx, &polls[i].Tags, tagsQuery, polls[i].ID)
-		if err != nil {
-			return nil, 0, err
+	var tags []struct {
+		PollID uuid.UUID `db:"poll_id"`
+		Tag    string    `db:"tag"`
+	}
+	tagsQuery := `SELECT poll_id, tag FROM poll_tags WHERE poll_id = ANY($1)`
+	err = r.db.SelectContext(ctx, &tags, tagsQuery, pq.Array(ids))
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, t := range tags {
+		if poll, ok := byID[t.PollID]; ok {
+			poll.Tags = append(poll.Tags, t.Tag)
 		}
 	}
 
@@ -222,15 +247,14 @@ func (r *Repository) GetPollStats(ctx context.Context, pollID uuid.UUID) (*domai
 	return &stats, nil
 }
 
-func (r *Repository) CreateVote(ctx context.Context, pollID, userID, optionID uuid.UUID) error {
+func (r *Repository) CreateVote(ctx context.Context, vote *domain.Vote) error {
 	return r.WithTransaction(ctx, func(ctx context.Context) error {
 		voteQuery := `
 			INSERT INTO votes (id, poll_id, user_id, option_id, created_at)
 			VALUES ($1, $2, $3, $4, $5)
 		`
-		voteID := uuid.New()
 		_, err := r.db.ExecContext(ctx, voteQuery,
-			voteID, pollID, userID, optionID, time.Now().UTC(),
+			vote.ID, vote.PollID, vote.UserID, vote.OptionID, vote.CreatedAt,
 		)
 		if err != nil {
 			return err
@@ -243,7 +267,7 @@ func (r *Repository) CreateVote(ctx context.Context, pollID, userID, optionID uu
 			DO UPDATE SET vote_count = user_daily_votes.vote_count + 1, updated_at = $4
 		`
 		_, err = r.db.ExecContext(ctx, dailyVoteQuery,
-			uuid.New(), userID, time.Now().UTC().Truncate(24*time.Hour), time.Now().UTC(),
+			uuid.New(), vote.UserID, time.Now().UTC().Truncate(24*time.Hour), time.Now().UTC(),
 		)
 		return err
 	})
@@ -283,13 +307,13 @@ func (r *Repository) IncrementUserDailyVoteCount(ctx context.Context, userID uui
 	return err
 }
 
-func (r *Repository) CreateSkip(ctx context.Context, pollID, userID uuid.UUID) error {
+func (r *Repository) CreateSkip(ctx context.Context, skip *domain.Skip) error {
 	query := `
-		INSERT INTO skips (id, poll_id, user_id, created_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO skips (id, poll_id, user_id, reason, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
 	`
 	_, err := r.db.ExecContext(ctx, query,
-		uuid.New(), pollID, userID, time.Now().UTC(),
+		skip.ID, skip.PollID, skip.UserID, skip.Reason, skip.CreatedAt,
 	)
 	return err
 }
@@ -339,7 +363,7 @@ func (r *Repository) DeleteVote(ctx context.Context, voteID, userID uuid.UUID) e
 	return nil
 }
 
-func (r *Repository) GetUserVotes(ctx context.Context, userID uuid.UUID, page, limit int) ([]domain.Vote, int, error) {
+func (r *Repository) GetUserVotes(ctx context.Context, userID uuid.UUID, filter domain.VoteFilter, page, limit int) ([]domain.Vote, int, error) {
 	var total int
 	countQuery := `SELECT COUNT(*) FROM votes WHERE user_id = $1`
 	err := r.db.GetContext(ctx, &total, countQuery, userID)
@@ -395,6 +419,378 @@ func (r *Repository) GetVoteByID(ctx context.Context, voteID uuid.UUID) (*domain
 	return nil, nil
 }
 
+func (r *Repository) GetUserPollVote(ctx context.Context, pollID, userID uuid.UUID) (*domain.Vote, error) {
+	return nil, domain.ErrNotFound
+}
+
 func (r *Repository) UpdateVote(ctx context.Context, voteID, userID, optionID uuid.UUID) error {
 	return nil
 }
+
+func (r *Repository) GetLastVoteChange(ctx context.Context, voteID uuid.UUID) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func (r *Repository) RollupPollStats(ctx context.Context, day time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *Repository) PruneUserDailyVotes(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM user_daily_votes WHERE vote_date < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *Repository) GetTrendingPolls(ctx context.Context, since time.Time, limit int) ([]domain.TrendingPoll, error) {
+	return nil, nil
+}
+
+func (r *Repository) GetTagDailyVotes(ctx context.Context, tag string, from, to time.Time) ([]domain.VoteBucket, error) {
+	return nil, nil
+}
+
+func (r *Repository) GetPollHourlyVotes(ctx context.Context, pollID uuid.UUID, from, to time.Time) ([]domain.VoteBucket, error) {
+	return nil, nil
+}
+
+func (r *Repository) GetUserActivity(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]domain.UserActivity, error) {
+	return nil, nil
+}
+
+func (r *Repository) SetCachedTrendingPolls(ctx context.Context, polls []domain.TrendingPoll) error {
+	return nil
+}
+
+func (r *Repository) GetActiveUserIDs(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func (r *Repository) GetPollAccessCodeHash(ctx context.Context, pollID uuid.UUID) (string, error) {
+	return "", nil
+}
+
+func (r *Repository) IsEligibleVoter(ctx context.Context, pollID, userID uuid.UUID) (bool, error) {
+	return true, nil
+}
+
+func (r *Repository) CreateOrganization(ctx context.Context, org *domain.Organization, ownerID uuid.UUID) error {
+	return nil
+}
+
+func (r *Repository) AddOrganizationMember(ctx context.Context, member *domain.Membership) error {
+	return nil
+}
+
+func (r *Repository) GetOrganizationRole(ctx context.Context, orgID, userID uuid.UUID) (domain.OrganizationRole, error) {
+	return "", domain.ErrNotFound
+}
+
+func (r *Repository) CreateOrganizationVote(ctx context.Context, vote *domain.OrganizationVote) error {
+	return nil
+}
+
+func (r *Repository) GetOrganizationVote(ctx context.Context, pollID, orgID uuid.UUID) (*domain.OrganizationVote, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (r *Repository) UpdateOrganizationVote(ctx context.Context, vote *domain.OrganizationVote) error {
+	return nil
+}
+
+func (r *Repository) GetOrganizationVoteStats(ctx context.Context, pollID uuid.UUID) ([]domain.OptionStats, error) {
+	return []domain.OptionStats{}, nil
+}
+
+func (r *Repository) GetElectionsToCertify(ctx context.Context, closedBefore time.Time) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func (r *Repository) SaveElectionCertification(ctx context.Context, cert *domain.ElectionCertification) error {
+	return nil
+}
+
+func (r *Repository) GetElectionCertification(ctx context.Context, pollID uuid.UUID) (*domain.ElectionCertification, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (r *Repository) GetPollsToSnapshot(ctx context.Context, closedBefore time.Time) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func (r *Repository) SavePollResultSnapshot(ctx context.Context, snapshot *domain.PollResultSnapshot) error {
+	return nil
+}
+
+func (r *Repository) GetPollResultSnapshot(ctx context.Context, pollID uuid.UUID) (*domain.PollResultSnapshot, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (r *Repository) GetPollsToCertify(ctx context.Context) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func (r *Repository) SaveResultCertificate(ctx context.Context, cert *domain.ResultCertificate) error {
+	return nil
+}
+
+func (r *Repository) GetResultCertificate(ctx context.Context, pollID uuid.UUID) (*domain.ResultCertificate, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (r *Repository) GetLastVoteTimes(ctx context.Context, pollID uuid.UUID) (map[string]time.Time, error) {
+	return map[string]time.Time{}, nil
+}
+
+func (r *Repository) GetUserPreferences(ctx context.Context, userID uuid.UUID) (*domain.UserPreferences, error) {
+	return &domain.UserPreferences{}, nil
+}
+
+func (r *Repository) SetUserPreferences(ctx context.Context, userID uuid.UUID, prefs *domain.UserPreferences) error {
+	return nil
+}
+
+func (r *Repository) GetTagFollowers(ctx context.Context, tags []string) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func (r *Repository) UpdatePoll(ctx context.Context, poll *domain.Poll) error {
+	return nil
+}
+
+func (r *Repository) UpdatePollTags(ctx context.Context, pollID uuid.UUID, add, remove []string, updatedAt time.Time, check func(tags []string) error) ([]string, error) {
+	return nil, nil
+}
+
+func (r *Repository) ReorderPollOptions(ctx context.Context, pollID uuid.UUID, optionIDs []uuid.UUID, updatedAt time.Time) error {
+	return nil
+}
+
+func (r *Repository) SetPollStatus(ctx context.Context, pollID uuid.UUID, status domain.PollStatus, changedAt time.Time) error {
+	return nil
+}
+
+func (r *Repository) CountSkips(ctx context.Context, pollID uuid.UUID) (int, error) {
+	return 0, nil
+}
+
+func (r *Repository) CountSkipReasons(ctx context.Context, pollID uuid.UUID) (map[domain.SkipReason]int, error) {
+	return map[domain.SkipReason]int{}, nil
+}
+
+func (r *Repository) AddPollCollaborator(ctx context.Context, collaborator *domain.Collaborator) error {
+	return nil
+}
+
+func (r *Repository) RemovePollCollaborator(ctx context.Context, pollID, userID uuid.UUID) error {
+	return nil
+}
+
+func (r *Repository) GetPollCollaborator(ctx context.Context, pollID, userID uuid.UUID) (*domain.Collaborator, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (r *Repository) GetPollCollaborators(ctx context.Context, pollID uuid.UUID) ([]domain.Collaborator, error) {
+	return nil, nil
+}
+
+func (r *Repository) ResolveTags(ctx context.Context, tags []string) ([]string, error) {
+	return tags, nil
+}
+
+func (r *Repository) CreateTagAlias(ctx context.Context, alias *domain.TagAlias) error {
+	return nil
+}
+
+func (r *Repository) GetTagAliases(ctx context.Context) ([]domain.TagAlias, error) {
+	return nil, nil
+}
+
+func (r *Repository) MergeTags(ctx context.Context, from, to string) (int, error) {
+	return 0, nil
+}
+
+func (r *Repository) GetTagRules(ctx context.Context) ([]domain.TagRule, error) {
+	return nil, nil
+}
+
+func (r *Repository) GetTagRulesFor(ctx context.Context, tags []string) ([]domain.TagRule, error) {
+	return nil, nil
+}
+
+func (r *Repository) SetTagRule(ctx context.Context, rule *domain.TagRule) error {
+	return nil
+}
+
+func (r *Repository) DeleteTagRule(ctx context.Context, tag string) error {
+	return nil
+}
+
+func (r *Repository) GetRecentVoteTimes(ctx context.Context, userID uuid.UUID, since time.Time) ([]time.Time, error) {
+	return nil, nil
+}
+
+func (r *Repository) ReserveRecentVote(ctx context.Context, userID, voteID uuid.UUID, at time.Time, window time.Duration, limit int) error {
+	return nil
+}
+
+func (r *Repository) ReleaseRecentVote(ctx context.Context, userID, voteID uuid.UUID) error {
+	return nil
+}
+
+func (r *Repository) ReconcileRecentVotes(ctx context.Context, window time.Duration) (int, error) {
+	return 0, nil
+}
+
+func (r *Repository) CountVotes(ctx context.Context, pollID uuid.UUID) (int, error) {
+	return 0, nil
+}
+
+func (r *Repository) RecordPollMilestone(ctx context.Context, pollID uuid.UUID, milestone int) (bool, error) {
+	return false, nil
+}
+
+func (r *Repository) StreamUserVotes(ctx context.Context, userID uuid.UUID, filter domain.VoteFilter, fn func(*domain.Vote) error) error {
+	return nil
+}
+
+func (r *Repository) GetPollVotesAfter(ctx context.Context, pollID uuid.UUID, after *domain.VoteKey, limit int) ([]domain.Vote, error) {
+	return nil, nil
+}
+
+func (r *Repository) RepairPollStatsDaily(ctx context.Context, pollID uuid.UUID) ([]domain.StatsDiscrepancy, error) {
+	return nil, nil
+}
+
+func (r *Repository) GetRecentlyActivePollIDs(ctx context.Context, since time.Time, limit int) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func (r *Repository) CountOpenPolls(ctx context.Context, at time.Time) (int, error) {
+	return 0, nil
+}
+
+func (r *Repository) CountActiveVoters(ctx context.Context, day time.Time) (int, error) {
+	return 0, nil
+}
+
+func (r *Repository) GetPollsByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.Poll, error) {
+	return nil, nil
+}
+
+func (r *Repository) SearchPolls(ctx context.Context, query domain.PollSearchQuery) (*domain.PollSearchResult, error) {
+	return &domain.PollSearchResult{Page: query.Page, Limit: query.Limit}, nil
+}
+
+func (r *Repository) SetUserAvatar(ctx context.Context, userID uuid.UUID, key string) (string, error) {
+	return "", nil
+}
+
+func (r *Repository) UpdateOptionMetadata(ctx context.Context, pollID uuid.UUID, optionIndex int, altText, emoji *string) error {
+	return nil
+}
+
+func (r *Repository) SetOptionImage(ctx context.Context, pollID uuid.UUID, optionIndex int, key string) (string, error) {
+	return "", nil
+}
+
+func (r *Repository) UnreferencedMediaKeys(ctx context.Context, keys []string) ([]string, error) {
+	return nil, nil
+}
+
+func (r *Repository) GetPollPreviewCard(ctx context.Context, pollID uuid.UUID) (*domain.PollPreviewCard, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (r *Repository) SetPollPreviewCard(ctx context.Context, card *domain.PollPreviewCard) error {
+	return nil
+}
+
+func (r *Repository) RecordVoteLocation(ctx context.Context, pollID uuid.UUID, location domain.GeoLocation) error {
+	return nil
+}
+
+func (r *Repository) GetPollGeoStats(ctx context.Context, pollID uuid.UUID) ([]domain.CountryStat, error) {
+	return nil, nil
+}
+
+func (r *Repository) CreateModerationFlag(ctx context.Context, flag *domain.ModerationFlag) error {
+	return nil
+}
+
+func (r *Repository) GetModerationFlags(ctx context.Context, status domain.FlagStatus, page, limit int) ([]domain.ModerationFlag, int, error) {
+	return nil, 0, nil
+}
+
+func (r *Repository) ResolveModerationFlag(ctx context.Context, flagID uuid.UUID, status domain.FlagStatus, resolvedBy uuid.UUID, resolvedAt time.Time) (*domain.ModerationFlag, error) {
+	return nil, nil
+}
+
+func (r *Repository) GetPollReviewFlag(ctx context.Context, pollID uuid.UUID) (*domain.ModerationFlag, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (r *Repository) SetUserStanding(ctx context.Context, userID uuid.UUID, standing domain.UserStanding) error {
+	return nil
+}
+
+func (r *Repository) SearchUsers(ctx context.Context, q domain.UserSearchQuery) ([]domain.AdminUser, int, error) {
+	return nil, 0, nil
+}
+
+func (r *Repository) GetPlatformStats(ctx context.Context, since time.Time) (*domain.PlatformStats, error) {
+	return &domain.PlatformStats{}, nil
+}
+
+func (r *Repository) PurgePoll(ctx context.Context, pollID uuid.UUID) error {
+	return nil
+}
+
+func (r *Repository) RecordConsents(ctx context.Context, userID uuid.UUID, consents []domain.Consent) error {
+	return nil
+}
+
+func (r *Repository) GetConsents(ctx context.Context, userID uuid.UUID) ([]domain.Consent, error) {
+	return nil, nil
+}
+
+func (r *Repository) CreateEmailVerificationToken(ctx context.Context, token *domain.EmailVerificationToken) error {
+	return nil
+}
+
+func (r *Repository) VerifyEmail(ctx context.Context, tokenHash string, now time.Time) (uuid.UUID, error) {
+	return uuid.Nil, domain.ErrNotFound
+}
+
+func (r *Repository) CreateEmailChange(ctx context.Context, change *domain.EmailChange) error {
+	return nil
+}
+
+func (r *Repository) GetOpenEmailChange(ctx context.Context, userID uuid.UUID) (*domain.EmailChange, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (r *Repository) GetEmailChangeByToken(ctx context.Context, tokenHash string) (*domain.EmailChange, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (r *Repository) SetEmailChangeStatus(ctx context.Context, id uuid.UUID, from, to domain.EmailChangeStatus, at time.Time) error {
+	return domain.ErrNotFound
+}
+
+func (r *Repository) CompleteEmailChange(ctx context.Context, change *domain.EmailChange, from domain.EmailChangeStatus, at time.Time) error {
+	return domain.ErrNotFound
+}
+
+func (r *Repository) GetUserQuotas(ctx context.Context, userID uuid.UUID) ([]domain.Quota, error) {
+	return nil, nil
+}
+
+func (r *Repository) GetQuotaUsage(ctx context.Context, userID uuid.UUID, action domain.QuotaAction, period domain.QuotaPeriod, periodStart time.Time) (int, error) {
+	return 0, nil
+}
+
+func (r *Repository) IncrementQuotaUsage(ctx context.Context, userID uuid.UUID, action domain.QuotaAction, period domain.QuotaPeriod, periodStart time.Time) error {
+	return nil
+}
@@ -0,0 +1,112 @@
+// Package publicid converts the UUIDs in API paths and links to and from the
+// form clients see.
+package publicid
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidID = errors.New("invalid id")
+
+type Codec interface {
+	Encode(id uuid.UUID) string
+	Decode(s string) (uuid.UUID, error)
+}
+
+// UUIDs shows IDs as they are stored.
+type UUIDs struct{}
+
+func (UUIDs) Encode(id uuid.UUID) string {
+	return id.String()
+}
+
+func (UUIDs) Decode(s string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, ErrInvalidID
+	}
+	return id, nil
+}
+
+const (
+	sqidAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// sqidLength is the number of base62 digits needed for 128 bits.
+	sqidLength = 22
+)
+
+// Sqids shows IDs as short base62 strings. The UUID is encrypted with a key
+// derived from the secret first, so IDs that are close together or share a
+// creation time don't look alike, and nothing in the string can be traced
+// back to the stored ID without the secret.
+type Sqids struct {
+	block cipher.Block
+}
+
+func NewSqids(secret string) *Sqids {
+	key := sha256.Sum256([]byte("vote public id:" + secret))
+	// A 16-byte key always makes a valid AES-128 cipher.
+	block, _ := aes.NewCipher(key[:16])
+	return &Sqids{block: block}
+}
+
+func (s *Sqids) Encode(id uuid.UUID) string {
+	var sealed [16]byte
+	s.block.Encrypt(sealed[:], id[:])
+
+	n := new(big.Int).SetBytes(sealed[:])
+	base, digit := big.NewInt(int64(len(sqidAlphabet))), new(big.Int)
+	out := make([]byte, sqidLength)
+	for i := sqidLength - 1; i >= 0; i-- {
+		n.DivMod(n, base, digit)
+		out[i] = sqidAlphabet[digit.Int64()]
+	}
+	return string(out)
+}
+
+func (s *Sqids) Decode(encoded string) (uuid.UUID, error) {
+	if len(encoded) != sqidLength {
+		return uuid.Nil, ErrInvalidID
+	}
+	n, base := new(big.Int), big.NewInt(int64(len(sqidAlphabet)))
+	for i := 0; i < len(encoded); i++ {
+		digit := strings.IndexByte(sqidAlphabet, encoded[i])
+		if digit < 0 {
+			return uuid.Nil, ErrInvalidID
+		}
+		n.Mul(n, base).Add(n, big.NewInt(int64(digit)))
+	}
+	if n.BitLen() > 128 {
+		return uuid.Nil, ErrInvalidID
+	}
+
+	var sealed [16]byte
+	n.FillBytes(sealed[:])
+	var id uuid.UUID
+	s.block.Decrypt(id[:], sealed[:])
+	return id, nil
+}
+
+// AcceptUUIDs makes codec accept stored UUIDs besides its own form, so links
+// handed out before the encoding was switched on keep working. IDs are still
+// encoded in its form.
+func AcceptUUIDs(codec Codec) Codec {
+	return lenient{codec}
+}
+
+type lenient struct {
+	Codec
+}
+
+func (l lenient) Decode(s string) (uuid.UUID, error) {
+	if id, err := uuid.Parse(s); err == nil {
+		return id, nil
+	}
+	return l.Codec.Decode(s)
+}
@@ -0,0 +1,68 @@
+package publicid
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSqidsRoundTrip(t *testing.T) {
+	codec := NewSqids("secret")
+	for _, id := range []uuid.UUID{uuid.Nil, uuid.MustParse("ffffffff-ffff-ffff-ffff-ffffffffffff"), uuid.New(), uuid.New()} {
+		encoded := codec.Encode(id)
+		assert.Len(t, encoded, sqidLength)
+		assert.NotContains(t, encoded, "-")
+
+		decoded, err := codec.Decode(encoded)
+		require.NoError(t, err)
+		assert.Equal(t, id, decoded)
+	}
+}
+
+func TestSqidsDependOnSecret(t *testing.T) {
+	id := uuid.New()
+	first, second := NewSqids("first").Encode(id), NewSqids("second").Encode(id)
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, first, NewSqids("first").Encode(id))
+
+	decoded, err := NewSqids("second").Decode(first)
+	require.NoError(t, err)
+	assert.NotEqual(t, id, decoded)
+}
+
+func TestSqidsRejectMalformed(t *testing.T) {
+	codec := NewSqids("secret")
+	encoded := codec.Encode(uuid.New())
+	for _, s := range []string{
+		"",
+		encoded[1:],
+		encoded + "0",
+		"-" + encoded[1:],
+		// Larger than 128 bits.
+		strings.Repeat("z", sqidLength),
+	} {
+		_, err := codec.Decode(s)
+		assert.ErrorIs(t, err, ErrInvalidID, s)
+	}
+}
+
+func TestAcceptUUIDs(t *testing.T) {
+	sqids := NewSqids("secret")
+	codec := AcceptUUIDs(sqids)
+	id := uuid.New()
+
+	for _, s := range []string{id.String(), sqids.Encode(id)} {
+		parsed, err := codec.Decode(s)
+		require.NoError(t, err)
+		assert.Equal(t, id, parsed)
+	}
+	assert.Equal(t, sqids.Encode(id), codec.Encode(id))
+
+	_, err := sqids.Decode(id.String())
+	assert.ErrorIs(t, err, ErrInvalidID)
+	_, err = UUIDs{}.Decode(sqids.Encode(id))
+	assert.ErrorIs(t, err, ErrInvalidID)
+}
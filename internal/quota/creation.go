@@ -0,0 +1,99 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// CreationLimits are daily poll creation limits; zero means unlimited.
+// Verified organizations get VerifiedOrganization instead of Organization.
+type CreationLimits struct {
+	User                  int
+	Organization          int
+	VerifiedOrganization  int
+	VerifiedOrganizations []uuid.UUID
+}
+
+// CreationThrottle counts poll creations in daily Redis counters. It is
+// separate from the per-user quotas: it can't be overridden per user and
+// applies to organizations as a whole.
+type CreationThrottle struct {
+	counter  Counter
+	limits   CreationLimits
+	verified map[uuid.UUID]bool
+	logger   *zap.Logger
+	now      func() time.Time
+}
+
+func NewCreationThrottle(counter Counter, limits CreationLimits, logger *zap.Logger) *CreationThrottle {
+	verified := make(map[uuid.UUID]bool, len(limits.VerifiedOrganizations))
+	for _, id := range limits.VerifiedOrganizations {
+		verified[id] = true
+	}
+	return &CreationThrottle{
+		counter:  counter,
+		limits:   limits,
+		verified: verified,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+func (t *CreationThrottle) limit(creatorID uuid.UUID, organizationID *uuid.UUID) (string, int) {
+	if organizationID == nil {
+		return "user:" + creatorID.String(), t.limits.User
+	}
+	if t.verified[*organizationID] {
+		return "org:" + organizationID.String(), t.limits.VerifiedOrganization
+	}
+	return "org:" + organizationID.String(), t.limits.Organization
+}
+
+func (t *CreationThrottle) ReserveCreation(ctx context.Context, creatorID uuid.UUID, organizationID *uuid.UUID) (func(), error) {
+	scope, limit := t.limit(creatorID, organizationID)
+	if limit <= 0 {
+		return func() {}, nil
+	}
+
+	start, reset := PeriodBounds(domain.QuotaDaily, t.now())
+	key := fmt.Sprintf("creation:%s:%s", scope, start.Format("2006-01-02"))
+
+	created, err := t.counter.IncrBy(ctx, key, 1).Result()
+	if err != nil {
+		// Like quotas, the throttle is an accounting limit, so a Redis outage
+		// lets the poll through.
+		logging.For(ctx, t.logger).Warn("Failed to count poll creation, allowing it",
+			zap.Error(err),
+			zap.String("scope", scope),
+		)
+		return func() {}, nil
+	}
+	if created == 1 {
+		if err := t.counter.ExpireAt(ctx, key, reset.Add(keyGracePeriod)).Err(); err != nil {
+			logging.For(ctx, t.logger).Warn("Failed to set creation counter expiry",
+				zap.Error(err),
+				zap.String("key", key),
+			)
+		}
+	}
+
+	release := func() {
+		if err := t.counter.IncrBy(ctx, key, -1).Err(); err != nil {
+			logging.For(ctx, t.logger).Warn("Failed to release poll creation",
+				zap.Error(err),
+				zap.String("key", key),
+			)
+		}
+	}
+	if int(created) > limit {
+		release()
+		return nil, &domain.CreationLimitError{Limit: limit, ResetAt: reset}
+	}
+	return release, nil
+}
@@ -0,0 +1,80 @@
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCreationThrottle(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 20, 15, 0, 0, 0, time.UTC)
+	verifiedID := uuid.New()
+	newThrottle := func() *CreationThrottle {
+		throttle := NewCreationThrottle(&fakeCounter{values: make(map[string]int64)}, CreationLimits{
+			User:                  2,
+			Organization:          1,
+			VerifiedOrganization:  3,
+			VerifiedOrganizations: []uuid.UUID{verifiedID},
+		}, zap.NewNop())
+		throttle.now = func() time.Time { return now }
+		return throttle
+	}
+
+	t.Run("per user", func(t *testing.T) {
+		throttle := newThrottle()
+		userID := uuid.New()
+		for i := 0; i < 2; i++ {
+			_, err := throttle.ReserveCreation(ctx, userID, nil)
+			require.NoError(t, err)
+		}
+
+		_, err := throttle.ReserveCreation(ctx, userID, nil)
+		var exceeded *domain.CreationLimitError
+		require.ErrorAs(t, err, &exceeded)
+		assert.ErrorIs(t, err, domain.ErrCreationLimitExceeded)
+		assert.Equal(t, 2, exceeded.Limit)
+		assert.Equal(t, time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), exceeded.ResetAt)
+
+		_, err = throttle.ReserveCreation(ctx, uuid.New(), nil)
+		assert.NoError(t, err, "other users have their own count")
+	})
+
+	t.Run("organization polls count against the organization", func(t *testing.T) {
+		throttle := newThrottle()
+		orgID := uuid.New()
+		_, err := throttle.ReserveCreation(ctx, uuid.New(), &orgID)
+		require.NoError(t, err)
+		_, err = throttle.ReserveCreation(ctx, uuid.New(), &orgID)
+		assert.ErrorIs(t, err, domain.ErrCreationLimitExceeded)
+	})
+
+	t.Run("verified organizations get the higher limit", func(t *testing.T) {
+		throttle := newThrottle()
+		for i := 0; i < 3; i++ {
+			_, err := throttle.ReserveCreation(ctx, uuid.New(), &verifiedID)
+			require.NoError(t, err)
+		}
+		_, err := throttle.ReserveCreation(ctx, uuid.New(), &verifiedID)
+		assert.ErrorIs(t, err, domain.ErrCreationLimitExceeded)
+	})
+
+	t.Run("release gives the creation back", func(t *testing.T) {
+		throttle := newThrottle()
+		userID := uuid.New()
+		_, err := throttle.ReserveCreation(ctx, userID, nil)
+		require.NoError(t, err)
+		release, err := throttle.ReserveCreation(ctx, userID, nil)
+		require.NoError(t, err)
+		release()
+
+		_, err = throttle.ReserveCreation(ctx, userID, nil)
+		assert.NoError(t, err)
+	})
+}
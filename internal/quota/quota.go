@@ -0,0 +1,228 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const keyGracePeriod = time.Hour
+
+type Counter interface {
+	IncrBy(ctx context.Context, key string, value int64) *redis.IntCmd
+	ExpireAt(ctx context.Context, key string, tm time.Time) *redis.BoolCmd
+}
+
+type Manager struct {
+	counter  Counter
+	repo     domain.Repository
+	defaults []domain.Quota
+	logger   *zap.Logger
+	now      func() time.Time
+}
+
+func NewManager(counter Counter, repo domain.Repository, defaults []domain.Quota, logger *zap.Logger) *Manager {
+	return &Manager{
+		counter:  counter,
+		repo:     repo,
+		defaults: defaults,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+func PeriodBounds(period domain.QuotaPeriod, now time.Time) (start, reset time.Time) {
+	now = now.UTC()
+	switch period {
+	case domain.QuotaMonthly:
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	default:
+		start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 0, 1)
+	}
+}
+
+func counterKey(userID uuid.UUID, action domain.QuotaAction, period domain.QuotaPeriod, start time.Time) string {
+	return fmt.Sprintf("quota:%s:%s:%s:%s", userID, action, period, start.Format("2006-01-02"))
+}
+
+func (m *Manager) Limits(ctx context.Context, userID uuid.UUID, action domain.QuotaAction) ([]domain.Quota, error) {
+	limits := make(map[domain.QuotaPeriod]int)
+	for _, q := range m.defaults {
+		if q.Action == action {
+			limits[q.Period] = q.Limit
+		}
+	}
+
+	overrides, err := m.repo.GetUserQuotas(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user quotas: %w", err)
+	}
+	for _, q := range overrides {
+		if q.Action == action {
+			limits[q.Period] = q.Limit
+		}
+	}
+
+	quotas := make([]domain.Quota, 0, len(limits))
+	for _, period := range []domain.QuotaPeriod{domain.QuotaDaily, domain.QuotaMonthly} {
+		if limit, ok := limits[period]; ok && limit > 0 {
+			quotas = append(quotas, domain.Quota{Action: action, Period: period, Limit: limit})
+		}
+	}
+	return quotas, nil
+}
+
+type Reservation struct {
+	UserID uuid.UUID
+	Usages []domain.QuotaUsage
+	keys   []string
+	starts []time.Time
+}
+
+// Tightest returns the usage with the fewest remaining units, which is the
+// one reported to clients in quota headers.
+func (r *Reservation) Tightest() (domain.QuotaUsage, bool) {
+	if len(r.Usages) == 0 {
+		return domain.QuotaUsage{}, false
+	}
+	tightest := r.Usages[0]
+	for _, u := range r.Usages[1:] {
+		if u.Remaining() < tightest.Remaining() {
+			tightest = u
+		}
+	}
+	return tightest, true
+}
+
+// Reserve counts one unit of action against every quota that applies to the
+// user. If any quota is exhausted the reservation is undone and a
+// *domain.QuotaExceededError is returned.
+func (m *Manager) Reserve(ctx context.Context, userID uuid.UUID, action domain.QuotaAction) (*Reservation, error) {
+	quotas, err := m.Limits(ctx, userID, action)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Reservation{UserID: userID}
+	now := m.now()
+	for _, q := range quotas {
+		start, reset := PeriodBounds(q.Period, now)
+		key := counterKey(userID, action, q.Period, start)
+
+		used, err := m.counter.IncrBy(ctx, key, 1).Result()
+		if err != nil {
+			m.Release(ctx, res)
+			return nil, fmt.Errorf("increment quota counter: %w", err)
+		}
+		if used == 1 {
+			used, err = m.seed(ctx, key, userID, q, start, reset)
+			if err != nil {
+				m.Release(ctx, res)
+				return nil, err
+			}
+		}
+
+		res.keys = append(res.keys, key)
+		res.starts = append(res.starts, start)
+		usage := domain.QuotaUsage{
+			Action:  action,
+			Period:  q.Period,
+			Limit:   q.Limit,
+			Used:    int(used),
+			ResetAt: reset,
+		}
+		res.Usages = append(res.Usages, usage)
+
+		if int(used) > q.Limit {
+			m.Release(ctx, res)
+			usage.Used = q.Limit
+			return nil, &domain.QuotaExceededError{Usage: usage}
+		}
+	}
+
+	return res, nil
+}
+
+// seed sets the expiry of a fresh Redis counter and catches it up with the
+// usage already recorded in Postgres, so an evicted key cannot reset a quota.
+func (m *Manager) seed(ctx context.Context, key string, userID uuid.UUID, q domain.Quota, start, reset time.Time) (int64, error) {
+	if err := m.counter.ExpireAt(ctx, key, reset.Add(keyGracePeriod)).Err(); err != nil {
+		logging.For(ctx, m.logger).Warn("Failed to set quota counter expiry",
+			zap.Error(err),
+			zap.String("key", key),
+		)
+	}
+
+	recorded, err := m.repo.GetQuotaUsage(ctx, userID, q.Action, q.Period, start)
+	if err != nil {
+		return 0, fmt.Errorf("get recorded quota usage: %w", err)
+	}
+	if recorded == 0 {
+		return 1, nil
+	}
+
+	used, err := m.counter.IncrBy(ctx, key, int64(recorded)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("seed quota counter: %w", err)
+	}
+	return used, nil
+}
+
+func (m *Manager) Release(ctx context.Context, res *Reservation) {
+	for _, key := range res.keys {
+		if err := m.counter.IncrBy(ctx, key, -1).Err(); err != nil {
+			logging.For(ctx, m.logger).Warn("Failed to release quota reservation",
+				zap.Error(err),
+				zap.String("key", key),
+			)
+		}
+	}
+	res.keys = nil
+}
+
+func (m *Manager) Commit(ctx context.Context, res *Reservation) {
+	for i, usage := range res.Usages {
+		if err := m.repo.IncrementQuotaUsage(ctx, res.UserID, usage.Action, usage.Period, res.starts[i]); err != nil {
+			logging.For(ctx, m.logger).Error("Failed to record quota usage",
+				zap.Error(err),
+				zap.String("user_id", res.UserID.String()),
+				zap.String("action", string(usage.Action)),
+				zap.String("period", string(usage.Period)),
+			)
+		}
+	}
+}
+
+func (m *Manager) Usage(ctx context.Context, userID uuid.UUID) ([]domain.QuotaUsage, error) {
+	var usages []domain.QuotaUsage
+	now := m.now()
+	for _, action := range []domain.QuotaAction{domain.QuotaPollsCreated, domain.QuotaVotesCast} {
+		quotas, err := m.Limits(ctx, userID, action)
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range quotas {
+			start, reset := PeriodBounds(q.Period, now)
+			used, err := m.repo.GetQuotaUsage(ctx, userID, action, q.Period, start)
+			if err != nil {
+				return nil, fmt.Errorf("get quota usage: %w", err)
+			}
+			usages = append(usages, domain.QuotaUsage{
+				Action:  action,
+				Period:  q.Period,
+				Limit:   q.Limit,
+				Used:    used,
+				ResetAt: reset,
+			})
+		}
+	}
+	return usages, nil
+}
@@ -0,0 +1,147 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeCounter struct {
+	values map[string]int64
+}
+
+func (f *fakeCounter) IncrBy(ctx context.Context, key string, value int64) *redis.IntCmd {
+	f.values[key] += value
+	cmd := redis.NewIntCmd(ctx)
+	cmd.SetVal(f.values[key])
+	return cmd
+}
+
+func (f *fakeCounter) ExpireAt(ctx context.Context, key string, tm time.Time) *redis.BoolCmd {
+	cmd := redis.NewBoolCmd(ctx)
+	cmd.SetVal(true)
+	return cmd
+}
+
+type fakeRepo struct {
+	domain.Repository
+	overrides []domain.Quota
+	recorded  int
+	committed int
+}
+
+func (f *fakeRepo) GetUserQuotas(ctx context.Context, userID uuid.UUID) ([]domain.Quota, error) {
+	return f.overrides, nil
+}
+
+func (f *fakeRepo) GetQuotaUsage(ctx context.Context, userID uuid.UUID, action domain.QuotaAction, period domain.QuotaPeriod, periodStart time.Time) (int, error) {
+	return f.recorded, nil
+}
+
+func (f *fakeRepo) IncrementQuotaUsage(ctx context.Context, userID uuid.UUID, action domain.QuotaAction, period domain.QuotaPeriod, periodStart time.Time) error {
+	f.committed++
+	return nil
+}
+
+func TestPeriodBounds(t *testing.T) {
+	now := time.Date(2024, 1, 31, 15, 4, 5, 0, time.UTC)
+
+	start, reset := PeriodBounds(domain.QuotaDaily, now)
+	assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), reset)
+
+	start, reset = PeriodBounds(domain.QuotaMonthly, now)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), reset)
+}
+
+func TestReserve(t *testing.T) {
+	defaults := []domain.Quota{
+		{Action: domain.QuotaVotesCast, Period: domain.QuotaDaily, Limit: 2},
+		{Action: domain.QuotaVotesCast, Period: domain.QuotaMonthly, Limit: 10},
+	}
+
+	tests := []struct {
+		name         string
+		overrides    []domain.Quota
+		recorded     int
+		reservations int
+		wantPeriod   domain.QuotaPeriod
+	}{
+		{
+			name:         "within limits",
+			reservations: 2,
+		},
+		{
+			name:         "daily limit exceeded",
+			reservations: 3,
+			wantPeriod:   domain.QuotaDaily,
+		},
+		{
+			name:         "counter seeded from recorded usage",
+			overrides:    []domain.Quota{{Action: domain.QuotaVotesCast, Period: domain.QuotaDaily, Limit: 0}},
+			recorded:     10,
+			reservations: 1,
+			wantPeriod:   domain.QuotaMonthly,
+		},
+		{
+			name:         "override raises limit",
+			overrides:    []domain.Quota{{Action: domain.QuotaVotesCast, Period: domain.QuotaDaily, Limit: 5}},
+			reservations: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := &fakeCounter{values: make(map[string]int64)}
+			repo := &fakeRepo{overrides: tt.overrides, recorded: tt.recorded}
+			m := NewManager(counter, repo, defaults, zap.NewNop())
+			userID := uuid.New()
+
+			var err error
+			for i := 0; i < tt.reservations; i++ {
+				var res *Reservation
+				res, err = m.Reserve(context.Background(), userID, domain.QuotaVotesCast)
+				if err != nil {
+					break
+				}
+				m.Commit(context.Background(), res)
+			}
+
+			if tt.wantPeriod == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var exceeded *domain.QuotaExceededError
+			require.True(t, errors.As(err, &exceeded))
+			assert.ErrorIs(t, err, domain.ErrQuotaExceeded)
+			assert.Equal(t, tt.wantPeriod, exceeded.Usage.Period)
+			assert.Equal(t, 0, exceeded.Usage.Remaining())
+		})
+	}
+}
+
+func TestReleaseRefundsReservation(t *testing.T) {
+	counter := &fakeCounter{values: make(map[string]int64)}
+	repo := &fakeRepo{}
+	defaults := []domain.Quota{{Action: domain.QuotaPollsCreated, Period: domain.QuotaDaily, Limit: 1}}
+	m := NewManager(counter, repo, defaults, zap.NewNop())
+	userID := uuid.New()
+
+	res, err := m.Reserve(context.Background(), userID, domain.QuotaPollsCreated)
+	require.NoError(t, err)
+	m.Release(context.Background(), res)
+
+	_, err = m.Reserve(context.Background(), userID, domain.QuotaPollsCreated)
+	require.NoError(t, err)
+	assert.Equal(t, 0, repo.committed)
+}
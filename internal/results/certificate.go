@@ -0,0 +1,163 @@
+package results
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// CertificateAlgorithm signs result certificates. Unlike the HMACs of vote
+// receipts and election tallies, its signatures can be checked with the
+// public key alone, so archived results stay verifiable without the
+// platform.
+const CertificateAlgorithm = "Ed25519"
+
+// Certifier issues signed certificates of closed polls' results.
+type Certifier struct {
+	repo   domain.Repository
+	key    ed25519.PrivateKey
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+// NewCertifier derives the signing key from signingKey, so any secret will
+// do and the same secret always gives the same key.
+func NewCertifier(repo domain.Repository, signingKey string, logger *zap.Logger) *Certifier {
+	seed := sha256.Sum256([]byte(signingKey))
+	return &Certifier{
+		repo:   repo,
+		key:    ed25519.NewKeyFromSeed(seed[:]),
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+func (c *Certifier) SigningKey() *domain.ResultSigningKey {
+	return &domain.ResultSigningKey{
+		Algorithm: CertificateAlgorithm,
+		PublicKey: base64.StdEncoding.EncodeToString(c.key.Public().(ed25519.PublicKey)),
+	}
+}
+
+// Certify signs the results snapshotted for poll. If a certificate was
+// stored first by someone else, that one is returned instead.
+func (c *Certifier) Certify(ctx context.Context, poll *domain.Poll, snapshot *domain.PollResultSnapshot) (*domain.ResultCertificate, error) {
+	results := domain.CertifiedResults{
+		PollID:         poll.ID,
+		Title:          poll.Title,
+		Options:        make([]string, len(poll.Options)),
+		Tags:           poll.Tags,
+		Kind:           poll.Kind,
+		CreatedBy:      poll.CreatedBy,
+		OrganizationID: poll.OrganizationID,
+		CreatedAt:      poll.CreatedAt.UTC(),
+		StartsAt:       poll.StartsAt,
+		Tallies:        snapshot.Options,
+		Total:          snapshot.Total,
+		Winner:         snapshot.Winner,
+		Tied:           snapshot.Tied,
+		TieBreak:       snapshot.TieBreak,
+		Turnout:        snapshot.Turnout,
+		ClosedAt:       snapshot.ClosedAt.UTC(),
+	}
+	for i, option := range poll.Options {
+		results.Options[i] = option.OptionText
+	}
+	if results.Kind == "" {
+		results.Kind = domain.PollKindStandard
+	}
+
+	payload, err := json.Marshal(results)
+	if err != nil {
+		return nil, fmt.Errorf("marshal certified results: %w", err)
+	}
+	signing := c.SigningKey()
+	cert := &domain.ResultCertificate{
+		PollID:    poll.ID,
+		Results:   results,
+		Payload:   base64.StdEncoding.EncodeToString(payload),
+		Algorithm: signing.Algorithm,
+		PublicKey: signing.PublicKey,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(c.key, payload)),
+		IssuedAt:  c.now().UTC(),
+	}
+	if err := c.repo.SaveResultCertificate(ctx, cert); err != nil {
+		return nil, err
+	}
+	c.logger.Info("Certified poll results", zap.String("poll_id", poll.ID.String()))
+	return c.repo.GetResultCertificate(ctx, poll.ID)
+}
+
+// CertifyClosed certifies every snapshotted poll without a certificate and
+// returns how many were certified. A poll that fails is logged and left for
+// the next run without holding up the others; the failures are returned
+// together.
+func (c *Certifier) CertifyClosed(ctx context.Context) (int, error) {
+	ids, err := c.repo.GetPollsToCertify(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("get polls to certify: %w", err)
+	}
+
+	certified := 0
+	var errs []error
+	for _, id := range ids {
+		if err := c.certifyPoll(ctx, id); err != nil {
+			c.logger.Warn("Failed to certify poll results",
+				zap.String("poll_id", id.String()),
+				zap.Error(err),
+			)
+			errs = append(errs, err)
+			continue
+		}
+		certified++
+	}
+	return certified, errors.Join(errs...)
+}
+
+func (c *Certifier) certifyPoll(ctx context.Context, id uuid.UUID) error {
+	poll, err := c.repo.GetPollByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get poll %s: %w", id, err)
+	}
+	snapshot, err := c.repo.GetPollResultSnapshot(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get result snapshot of poll %s: %w", id, err)
+	}
+	if _, err := c.Certify(ctx, poll, snapshot); err != nil {
+		return fmt.Errorf("certify poll %s: %w", id, err)
+	}
+	return nil
+}
+
+// VerifyCertificate reports whether cert was signed with the key it names
+// and its results are the ones signed. It does not tell whose key that is;
+// compare it with the platform's published key for that.
+func VerifyCertificate(cert *domain.ResultCertificate) bool {
+	if cert.Algorithm != CertificateAlgorithm {
+		return false
+	}
+	payload, err := base64.StdEncoding.DecodeString(cert.Payload)
+	if err != nil {
+		return false
+	}
+	key, err := base64.StdEncoding.DecodeString(cert.PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return false
+	}
+	signature, err := base64.StdEncoding.DecodeString(cert.Signature)
+	if err != nil || !ed25519.Verify(key, payload, signature) {
+		return false
+	}
+	results, err := json.Marshal(cert.Results)
+	return err == nil && bytes.Equal(results, payload)
+}
@@ -0,0 +1,187 @@
+package results
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type certRepo struct {
+	domain.Repository
+	polls     map[uuid.UUID]*domain.Poll
+	snapshots map[uuid.UUID]*domain.PollResultSnapshot
+	certs     map[uuid.UUID]*domain.ResultCertificate
+	failing   map[uuid.UUID]bool
+}
+
+func (r *certRepo) GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Poll, error) {
+	return r.polls[id], nil
+}
+
+func (r *certRepo) GetPollResultSnapshot(ctx context.Context, pollID uuid.UUID) (*domain.PollResultSnapshot, error) {
+	return r.snapshots[pollID], nil
+}
+
+func (r *certRepo) GetPollsToCertify(ctx context.Context) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for id := range r.snapshots {
+		if _, ok := r.certs[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	// Failing polls come first, ahead of the ones they must not hold up.
+	sort.SliceStable(ids, func(i, j int) bool { return r.failing[ids[i]] && !r.failing[ids[j]] })
+	return ids, nil
+}
+
+func (r *certRepo) SaveResultCertificate(ctx context.Context, cert *domain.ResultCertificate) error {
+	if r.failing[cert.PollID] {
+		return errors.New("connection reset")
+	}
+	if _, ok := r.certs[cert.PollID]; !ok {
+		r.certs[cert.PollID] = cert
+	}
+	return nil
+}
+
+func (r *certRepo) GetResultCertificate(ctx context.Context, pollID uuid.UUID) (*domain.ResultCertificate, error) {
+	cert, ok := r.certs[pollID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return cert, nil
+}
+
+func newCertRepo(n int) *certRepo {
+	repo := &certRepo{
+		polls:     make(map[uuid.UUID]*domain.Poll),
+		snapshots: make(map[uuid.UUID]*domain.PollResultSnapshot),
+		certs:     make(map[uuid.UUID]*domain.ResultCertificate),
+	}
+	closedAt := time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		id := uuid.New()
+		repo.polls[id] = &domain.Poll{
+			ID:        id,
+			Title:     "Best language?",
+			Options:   []domain.Option{{OptionText: "Go"}, {OptionText: "Rust"}},
+			Tags:      []string{"programming"},
+			CreatedAt: closedAt.Add(-24 * time.Hour),
+		}
+		repo.snapshots[id] = &domain.PollResultSnapshot{
+			PollID: id,
+			Options: []domain.OptionResult{
+				{Option: "Go", Count: 3, Percentage: 75},
+				{Option: "Rust", Count: 1, Percentage: 25},
+			},
+			Total:    4,
+			Winner:   "Go",
+			ClosedAt: closedAt,
+		}
+	}
+	return repo
+}
+
+func TestCertify(t *testing.T) {
+	repo := newCertRepo(1)
+	certifier := NewCertifier(repo, "results-secret", zap.NewNop())
+	var poll *domain.Poll
+	for _, p := range repo.polls {
+		poll = p
+	}
+
+	cert, err := certifier.Certify(context.Background(), poll, repo.snapshots[poll.ID])
+	require.NoError(t, err)
+	assert.Equal(t, CertificateAlgorithm, cert.Algorithm)
+	assert.Equal(t, certifier.SigningKey().PublicKey, cert.PublicKey)
+	assert.Equal(t, []string{"Go", "Rust"}, cert.Results.Options)
+	assert.Equal(t, domain.PollKindStandard, cert.Results.Kind)
+	assert.Equal(t, "Go", cert.Results.Winner)
+	assert.True(t, VerifyCertificate(cert))
+
+	t.Run("same key from the same secret", func(t *testing.T) {
+		other := NewCertifier(repo, "results-secret", zap.NewNop())
+		assert.Equal(t, certifier.SigningKey(), other.SigningKey())
+		assert.NotEqual(t, certifier.SigningKey(), NewCertifier(repo, "other-secret", zap.NewNop()).SigningKey())
+	})
+
+	t.Run("first certificate kept", func(t *testing.T) {
+		again, err := certifier.Certify(context.Background(), poll, repo.snapshots[poll.ID])
+		require.NoError(t, err)
+		assert.Same(t, cert, again)
+	})
+
+	t.Run("tampered results rejected", func(t *testing.T) {
+		tampered := *cert
+		tampered.Results.Winner = "Rust"
+		assert.False(t, VerifyCertificate(&tampered))
+	})
+
+	t.Run("tampered payload rejected", func(t *testing.T) {
+		tampered := *cert
+		payload, err := base64.StdEncoding.DecodeString(cert.Payload)
+		require.NoError(t, err)
+		payload[len(payload)-2] ^= 1
+		tampered.Payload = base64.StdEncoding.EncodeToString(payload)
+		assert.False(t, VerifyCertificate(&tampered))
+	})
+
+	t.Run("other key rejected", func(t *testing.T) {
+		tampered := *cert
+		tampered.PublicKey = NewCertifier(repo, "other-secret", zap.NewNop()).SigningKey().PublicKey
+		assert.False(t, VerifyCertificate(&tampered))
+	})
+}
+
+func TestCertifyClosed(t *testing.T) {
+	repo := newCertRepo(3)
+	certifier := NewCertifier(repo, "results-secret", zap.NewNop())
+
+	certified, err := certifier.CertifyClosed(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, certified)
+	for id := range repo.polls {
+		assert.True(t, VerifyCertificate(repo.certs[id]))
+	}
+
+	certified, err = certifier.CertifyClosed(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, certified)
+
+	t.Run("failed poll does not stop the rest", func(t *testing.T) {
+		repo := newCertRepo(2)
+		certifier := NewCertifier(repo, "results-secret", zap.NewNop())
+		var failing uuid.UUID
+		for id := range repo.polls {
+			failing = id
+			break
+		}
+		repo.failing = map[uuid.UUID]bool{failing: true}
+
+		certified, err := certifier.CertifyClosed(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), failing.String())
+		assert.Equal(t, 1, certified)
+		assert.NotContains(t, repo.certs, failing)
+		for id := range repo.polls {
+			if id != failing {
+				assert.True(t, VerifyCertificate(repo.certs[id]))
+			}
+		}
+
+		repo.failing = nil
+		certified, err = certifier.CertifyClosed(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, certified)
+		assert.True(t, VerifyCertificate(repo.certs[failing]))
+	})
+}
@@ -0,0 +1,124 @@
+package results
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Snapshotter freezes the results of closed polls, breaking tied leads with
+// the configured policy.
+type Snapshotter struct {
+	repo     domain.Repository
+	tieBreak domain.TieBreakPolicy
+	seed     string
+	logger   *zap.Logger
+	now      func() time.Time
+}
+
+// NewSnapshotter returns a Snapshotter applying tieBreak, which defaults to
+// domain.TieBreakReported. seed is only used by domain.TieBreakRandom.
+func NewSnapshotter(repo domain.Repository, tieBreak domain.TieBreakPolicy, seed string, logger *zap.Logger) *Snapshotter {
+	if tieBreak == "" {
+		tieBreak = domain.TieBreakReported
+	}
+	return &Snapshotter{
+		repo:     repo,
+		tieBreak: tieBreak,
+		seed:     seed,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Snapshot stores the current counts of a closed poll as its result. If a
+// snapshot was stored first by someone else, that one is returned instead.
+func (s *Snapshotter) Snapshot(ctx context.Context, poll *domain.Poll) (*domain.PollResultSnapshot, error) {
+	stats, err := s.repo.GetPollStats(ctx, poll.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get poll stats: %w", err)
+	}
+
+	now := s.now().UTC()
+	closedAt := now
+	if poll.EndsAt != nil && poll.EndsAt.Before(now) {
+		closedAt = poll.EndsAt.UTC()
+	}
+	snapshot := domain.NewPollResultSnapshot(stats, closedAt, now)
+	if len(snapshot.Tied) > 0 {
+		if err := s.breakTie(ctx, snapshot); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.SavePollResultSnapshot(ctx, snapshot); err != nil {
+		return nil, err
+	}
+	s.logger.Info("Snapshotted poll results", zap.String("poll_id", poll.ID.String()))
+	return s.repo.GetPollResultSnapshot(ctx, poll.ID)
+}
+
+// SnapshotClosed snapshots every closed poll that has no snapshot yet and
+// returns how many were taken.
+func (s *Snapshotter) SnapshotClosed(ctx context.Context) (int, error) {
+	ids, err := s.repo.GetPollsToSnapshot(ctx, s.now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("get polls to snapshot: %w", err)
+	}
+
+	taken := 0
+	for _, id := range ids {
+		poll, err := s.repo.GetPollByID(ctx, id)
+		if err != nil {
+			return taken, fmt.Errorf("get poll %s: %w", id, err)
+		}
+		if _, err := s.Snapshot(ctx, poll); err != nil {
+			return taken, fmt.Errorf("snapshot poll %s: %w", id, err)
+		}
+		taken++
+	}
+	return taken, nil
+}
+
+func (s *Snapshotter) breakTie(ctx context.Context, snapshot *domain.PollResultSnapshot) error {
+	snapshot.TieBreak = s.tieBreak
+	switch s.tieBreak {
+	case domain.TieBreakEarliestLead:
+		times, err := s.repo.GetLastVoteTimes(ctx, snapshot.PollID)
+		if err != nil {
+			return fmt.Errorf("get last vote times: %w", err)
+		}
+		snapshot.Winner = earliestLead(snapshot.Tied, times)
+	case domain.TieBreakRandom:
+		snapshot.Winner = SeededDraw(s.seed, snapshot.PollID, snapshot.Tied)
+	}
+	return nil
+}
+
+// earliestLead picks the tied option whose last vote, the one that brought
+// it to the winning count, came first. Ties on time go to the earlier
+// option.
+func earliestLead(tied []string, lastVotes map[string]time.Time) string {
+	winner := tied[0]
+	for _, option := range tied[1:] {
+		if lastVotes[option].Before(lastVotes[winner]) {
+			winner = option
+		}
+	}
+	return winner
+}
+
+// SeededDraw picks one of the tied options from a SHA-256 hash of the seed,
+// the poll ID and the tied options, so the draw can be reproduced by anyone
+// who knows the seed.
+func SeededDraw(seed string, pollID uuid.UUID, tied []string) string {
+	sum := sha256.Sum256([]byte(seed + "\n" + pollID.String() + "\n" + strings.Join(tied, "\n")))
+	return tied[binary.BigEndian.Uint64(sum[:8])%uint64(len(tied))]
+}
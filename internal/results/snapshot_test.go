@@ -0,0 +1,133 @@
+package results
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeRepo struct {
+	domain.Repository
+	stats     *domain.PollStats
+	lastVotes map[string]time.Time
+	saved     *domain.PollResultSnapshot
+}
+
+func (f *fakeRepo) GetPollStats(ctx context.Context, pollID uuid.UUID) (*domain.PollStats, error) {
+	return f.stats, nil
+}
+
+func (f *fakeRepo) GetLastVoteTimes(ctx context.Context, pollID uuid.UUID) (map[string]time.Time, error) {
+	return f.lastVotes, nil
+}
+
+func (f *fakeRepo) SavePollResultSnapshot(ctx context.Context, snapshot *domain.PollResultSnapshot) error {
+	if f.saved == nil {
+		f.saved = snapshot
+	}
+	return nil
+}
+
+func (f *fakeRepo) GetPollResultSnapshot(ctx context.Context, pollID uuid.UUID) (*domain.PollResultSnapshot, error) {
+	return f.saved, nil
+}
+
+func TestSnapshot(t *testing.T) {
+	endsAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	poll := &domain.Poll{ID: uuid.New(), EndsAt: &endsAt}
+	tiedStats := &domain.PollStats{PollID: poll.ID, Votes: []domain.OptionStats{
+		{Option: "A", Count: 2},
+		{Option: "B", Count: 2},
+		{Option: "C", Count: 1},
+	}}
+	lastVotes := map[string]time.Time{"A": endsAt.Add(-time.Minute), "B": endsAt.Add(-time.Hour)}
+
+	tests := []struct {
+		name       string
+		policy     domain.TieBreakPolicy
+		stats      *domain.PollStats
+		wantWinner string
+		wantTied   []string
+	}{
+		{
+			name:       "clear winner",
+			policy:     domain.TieBreakEarliestLead,
+			stats:      &domain.PollStats{PollID: poll.ID, Votes: []domain.OptionStats{{Option: "A", Count: 3}, {Option: "B", Count: 1}}},
+			wantWinner: "A",
+		},
+		{
+			name:     "tie reported",
+			policy:   domain.TieBreakReported,
+			stats:    tiedStats,
+			wantTied: []string{"A", "B"},
+		},
+		{
+			name:       "tie broken by earliest lead",
+			policy:     domain.TieBreakEarliestLead,
+			stats:      tiedStats,
+			wantWinner: "B",
+			wantTied:   []string{"A", "B"},
+		},
+		{
+			name:       "tie broken by seeded draw",
+			policy:     domain.TieBreakRandom,
+			stats:      tiedStats,
+			wantWinner: SeededDraw("seed", poll.ID, []string{"A", "B"}),
+			wantTied:   []string{"A", "B"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{stats: tt.stats, lastVotes: lastVotes}
+			s := NewSnapshotter(repo, tt.policy, "seed", zap.NewNop())
+			s.now = func() time.Time { return endsAt.Add(time.Hour) }
+
+			snapshot, err := s.Snapshot(context.Background(), poll)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantWinner, snapshot.Winner)
+			assert.Equal(t, tt.wantTied, snapshot.Tied)
+			assert.Equal(t, endsAt, snapshot.ClosedAt)
+			if tt.wantTied != nil {
+				assert.Equal(t, tt.policy, snapshot.TieBreak)
+			}
+		})
+	}
+}
+
+func TestEarliestLead(t *testing.T) {
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tied := []string{"A", "B", "C"}
+
+	assert.Equal(t, "B", earliestLead(tied, map[string]time.Time{
+		"A": base.Add(2 * time.Minute),
+		"B": base,
+		"C": base.Add(time.Minute),
+	}))
+	assert.Equal(t, "A", earliestLead(tied, map[string]time.Time{
+		"A": base,
+		"B": base,
+		"C": base,
+	}))
+}
+
+func TestSeededDraw(t *testing.T) {
+	pollID := uuid.New()
+	tied := []string{"A", "B", "C"}
+
+	first := SeededDraw("seed", pollID, tied)
+	assert.Contains(t, tied, first)
+	assert.Equal(t, first, SeededDraw("seed", pollID, tied), "the draw must be reproducible")
+
+	drawn := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		drawn[SeededDraw("seed", uuid.New(), tied)] = true
+	}
+	assert.Len(t, drawn, len(tied), "every tied option should be reachable")
+}
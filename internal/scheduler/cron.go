@@ -0,0 +1,181 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchDays bounds how far ahead Next looks for a matching day. Four
+// years and a day always include a 29 February.
+const cronSearchDays = 4*366 + 1
+
+var cronDescriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+}
+
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// Like cron, when both day fields are restricted a day matching either
+	// of them is enough.
+	domAny, dowAny bool
+	loc            *time.Location
+}
+
+// Cron returns a schedule for a standard five-field cron expression
+// (minute, hour, day of month, month, day of week) evaluated on the wall
+// clock in loc. Times skipped by a daylight saving change fire when the
+// clock jumps, and times repeated by one fire once.
+func Cron(expr string, loc *time.Location) (Schedule, error) {
+	if descriptor, ok := cronDescriptors[strings.TrimSpace(expr)]; ok {
+		expr = descriptor
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	s := cronSchedule{loc: loc}
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("cron expression %q: minute: %w", expr, err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("cron expression %q: hour: %w", expr, err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("cron expression %q: day of month: %w", expr, err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("cron expression %q: month: %w", expr, err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("cron expression %q: day of week: %w", expr, err)
+	}
+	// 7 is another name for Sunday.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+	return s, nil
+}
+
+// parseCronField turns a field such as "*/15", "1-5" or "0,30" into a bit
+// set of the values it matches.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = cronValue(from, min, max); err != nil {
+				return 0, err
+			}
+			if hi, err = cronValue(to, min, max); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			n, err := cronValue(rangePart, min, max)
+			if err != nil {
+				return 0, err
+			}
+			lo = n
+			if !hasStep {
+				hi = n
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func cronValue(s string, min, max int) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("value %q must be between %d and %d", s, min, max)
+	}
+	return n, nil
+}
+
+func (s cronSchedule) Next(after time.Time) time.Time {
+	local := after.In(s.loc)
+	year, month, day := local.Date()
+	for i := 0; i < cronSearchDays; i++ {
+		// Noon is never skipped by a daylight saving change, so it safely
+		// identifies the calendar day.
+		date := time.Date(year, month, day+i, 12, 0, 0, 0, s.loc)
+		if !s.matchesDay(date) {
+			continue
+		}
+		for h := 0; h < 24; h++ {
+			if s.hour&(1<<uint(h)) == 0 {
+				continue
+			}
+			for m := 0; m < 60; m++ {
+				if s.minute&(1<<uint(m)) == 0 {
+					continue
+				}
+				if fire := s.at(date, h, m); fire.After(after) {
+					return fire
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func (s cronSchedule) matchesDay(date time.Time) bool {
+	if s.month&(1<<uint(date.Month())) == 0 {
+		return false
+	}
+	domMatch := s.dom&(1<<uint(date.Day())) != 0
+	dowMatch := s.dow&(1<<uint(date.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dowMatch
+	case s.dowAny:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
+
+// at returns the instant the wall clock reads hour:minute on date. A time
+// that doesn't exist because the clock jumped forward maps to the moment of
+// the jump.
+func (s cronSchedule) at(date time.Time, hour, minute int) time.Time {
+	t := time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, s.loc)
+	if t.Hour() != hour || t.Minute() != minute {
+		start, _ := t.ZoneBounds()
+		return start
+	}
+	return t
+}
@@ -0,0 +1,230 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/election"
+	"github.com/behzadon/vote/internal/events"
+	"github.com/behzadon/vote/internal/metrics"
+	"github.com/behzadon/vote/internal/notification"
+	"github.com/behzadon/vote/internal/pollhook"
+	"github.com/behzadon/vote/internal/results"
+	"github.com/behzadon/vote/internal/storage/blob"
+	"go.uber.org/zap"
+)
+
+const (
+	JobStatsRollup       = "stats_rollup"
+	JobRetentionPrune    = "retention_prune"
+	JobTrendingRecompute = "trending_recompute"
+	JobDigestSend        = "digest_send"
+	JobElectionCertify   = "election_certify"
+	JobMediaGC           = "media_gc"
+	JobResultSnapshot    = "result_snapshot"
+	JobResultCertify     = "result_certify"
+	JobOutboxRelay       = "outbox_relay"
+	JobVoteWindowRepair  = "vote_window_repair"
+	JobPollWebhooks      = "poll_webhooks"
+	JobBusinessMetrics   = "business_metrics"
+)
+
+const (
+	trendingWindow = 24 * time.Hour
+	trendingLimit  = 100
+	digestWindow   = 7 * 24 * time.Hour
+	digestPolls    = 5
+	outboxBatch    = 500
+)
+
+func StatsRollup(repo domain.Repository, logger *zap.Logger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+			rows, err := repo.RollupPollStats(ctx, day)
+			if err != nil {
+				return fmt.Errorf("rollup poll stats for %s: %w", day.Format("2006-01-02"), err)
+			}
+			logger.Debug("Rolled up poll stats",
+				zap.String("day", day.Format("2006-01-02")),
+				zap.Int64("rows", rows),
+			)
+		}
+		return nil
+	}
+}
+
+func RetentionPrune(repo domain.Repository, retention time.Duration, logger *zap.Logger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		before := time.Now().UTC().Add(-retention).Truncate(24 * time.Hour)
+		rows, err := repo.PruneUserDailyVotes(ctx, before)
+		if err != nil {
+			return fmt.Errorf("prune user daily votes: %w", err)
+		}
+		logger.Info("Pruned user daily vote counters",
+			zap.Time("before", before),
+			zap.Int64("rows", rows),
+		)
+		return nil
+	}
+}
+
+// VoteWindowRepair corrects the cached vote windows that drifted from the
+// votes table, such as reservations left by a crash between reserving a vote
+// and storing it, or votes stored while Redis was down.
+func VoteWindowRepair(repo domain.Repository, logger *zap.Logger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		fixed, err := repo.ReconcileRecentVotes(ctx, domain.DailyVoteWindow)
+		if err != nil {
+			return fmt.Errorf("reconcile recent votes: %w", err)
+		}
+		logger.Info("Reconciled vote windows", zap.Int("fixed", fixed))
+		return nil
+	}
+}
+
+func TrendingRecompute(repo domain.Repository) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		polls, err := repo.GetTrendingPolls(ctx, time.Now().UTC().Add(-trendingWindow), trendingLimit)
+		if err != nil {
+			return fmt.Errorf("get trending polls: %w", err)
+		}
+		if err := repo.SetCachedTrendingPolls(ctx, polls); err != nil {
+			return fmt.Errorf("cache trending polls: %w", err)
+		}
+		return nil
+	}
+}
+
+func DigestSend(repo domain.Repository, notifier notification.NotificationService, logger *zap.Logger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		since := time.Now().UTC().Add(-digestWindow)
+		polls, err := repo.GetTrendingPolls(ctx, since, digestPolls)
+		if err != nil {
+			return fmt.Errorf("get digest polls: %w", err)
+		}
+		if len(polls) == 0 {
+			return nil
+		}
+
+		titles := make([]string, len(polls))
+		for i, poll := range polls {
+			titles[i] = poll.Title
+		}
+		message := "Popular this week: " + strings.Join(titles, ", ")
+
+		userIDs, err := repo.GetActiveUserIDs(ctx, since)
+		if err != nil {
+			return fmt.Errorf("get digest recipients: %w", err)
+		}
+
+		failed := 0
+		for _, userID := range userIDs {
+			if err := notifier.SendNotification(ctx, userID.String(), "Your weekly digest", message); err != nil {
+				failed++
+				logger.Warn("Failed to send digest",
+					zap.Error(err),
+					zap.String("user_id", userID.String()),
+				)
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("digest failed for %d of %d users", failed, len(userIDs))
+		}
+		return nil
+	}
+}
+
+func ElectionCertify(certifier *election.Certifier, logger *zap.Logger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		certified, err := certifier.CertifyClosed(ctx)
+		if certified > 0 {
+			logger.Info("Certified closed elections", zap.Int("count", certified))
+		}
+		return err
+	}
+}
+
+// ResultSnapshot freezes the results of polls that closed without anyone
+// reading their stats, before later vote changes can alter them.
+func ResultSnapshot(snapshotter *results.Snapshotter, logger *zap.Logger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		taken, err := snapshotter.SnapshotClosed(ctx)
+		if taken > 0 {
+			logger.Info("Snapshotted closed poll results", zap.Int("count", taken))
+		}
+		return err
+	}
+}
+
+// ResultCertify signs the results of polls snapshotted since its last run.
+func ResultCertify(certifier *results.Certifier, logger *zap.Logger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		certified, err := certifier.CertifyClosed(ctx)
+		if certified > 0 {
+			logger.Info("Certified closed poll results", zap.Int("count", certified))
+		}
+		return err
+	}
+}
+
+// PollWebhooks sends the results of closed polls to their creators' webhooks
+// once the results are snapshotted.
+func PollWebhooks(deliverer *pollhook.Deliverer, logger *zap.Logger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		delivered, err := deliverer.DeliverDue(ctx)
+		if delivered > 0 {
+			logger.Info("Delivered poll webhooks", zap.Int("count", delivered))
+		}
+		return err
+	}
+}
+
+// BusinessMetrics sets the KPI gauges that need the database: the polls open
+// for voting and the users who voted today. Only the instance holding the job
+// lock updates them, so dashboards should take the max across instances.
+func BusinessMetrics(repo domain.Repository, logger *zap.Logger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		now := time.Now().UTC()
+		open, err := repo.CountOpenPolls(ctx, now)
+		if err != nil {
+			return fmt.Errorf("count open polls: %w", err)
+		}
+		voters, err := repo.CountActiveVoters(ctx, now)
+		if err != nil {
+			return fmt.Errorf("count active voters: %w", err)
+		}
+
+		metrics.PollsOpen.Set(float64(open))
+		metrics.DailyActiveVoters.Set(float64(voters))
+		logger.Debug("Updated business metrics",
+			zap.Int("open_polls", open),
+			zap.Int("daily_active_voters", voters),
+		)
+		return nil
+	}
+}
+
+func MediaGC(store blob.Store, repo domain.Repository, grace time.Duration, logger *zap.Logger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		deleted, err := blob.CollectGarbage(ctx, store, repo, grace, logger)
+		if deleted > 0 {
+			logger.Info("Deleted orphaned media", zap.Int("count", deleted))
+		}
+		return err
+	}
+}
+
+// OutboxRelay publishes the events the async publisher moved to the outbox.
+func OutboxRelay(store events.OutboxStore, publisher events.Publisher, logger *zap.Logger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		relayed, err := events.RelayOutbox(ctx, store, publisher, outboxBatch, 0, logger)
+		if relayed > 0 {
+			logger.Info("Relayed outbox events", zap.Int("count", relayed))
+		}
+		return err
+	}
+}
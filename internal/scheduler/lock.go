@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type LockClient interface {
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd
+}
+
+type Locker interface {
+	Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error)
+}
+
+type RedisLocker struct {
+	client   LockClient
+	instance string
+}
+
+func NewRedisLocker(client LockClient, instance string) *RedisLocker {
+	return &RedisLocker{
+		client:   client,
+		instance: instance,
+	}
+}
+
+func lockKey(name string) string {
+	return fmt.Sprintf("scheduler:lock:%s", name)
+}
+
+func (l *RedisLocker) Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	ok, err := l.client.SetNX(ctx, lockKey(name), l.instance, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("acquire lock %s: %w", name, err)
+	}
+	return ok, nil
+}
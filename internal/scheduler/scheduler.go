@@ -0,0 +1,228 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/metrics"
+	"go.uber.org/zap"
+)
+
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+// Every returns a schedule that fires on wall-clock multiples of interval, so
+// every instance computes the same tick and competes for the same lock.
+func Every(interval time.Duration) Schedule {
+	return intervalSchedule{interval: interval}
+}
+
+func (s intervalSchedule) Next(after time.Time) time.Time {
+	return after.Truncate(s.interval).Add(s.interval)
+}
+
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      func(ctx context.Context) error
+}
+
+// RunStore persists each job's next run, so restarts neither repeat nor
+// skip a run and instances agree on which of them takes it.
+type RunStore interface {
+	// NextRun reports the job's stored next run, if it has one.
+	NextRun(ctx context.Context, job string) (time.Time, bool, error)
+	// ClaimRun moves the job's next run from prev, or from nothing when prev
+	// is zero, to next. It reports false if another instance got there
+	// first.
+	ClaimRun(ctx context.Context, job string, prev, next time.Time) (bool, error)
+}
+
+// runStoreRetry is how long a job waits before asking the run store again
+// after it failed.
+const runStoreRetry = time.Minute
+
+type Option func(*Scheduler)
+
+// WithRunStore coordinates runs through store instead of per-tick locks.
+func WithRunStore(store RunStore) Option {
+	return func(s *Scheduler) {
+		s.runs = store
+	}
+}
+
+type Scheduler struct {
+	locker  Locker
+	lockTTL time.Duration
+	runs    RunStore
+	logger  *zap.Logger
+	jobs    []Job
+	now     func() time.Time
+
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+}
+
+func New(locker Locker, lockTTL time.Duration, logger *zap.Logger, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		locker:  locker,
+		lockTTL: lockTTL,
+		logger:  logger,
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Scheduler) Register(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+func (s *Scheduler) Jobs() []string {
+	names := make([]string, len(s.jobs))
+	for i, job := range s.jobs {
+		names[i] = job.Name
+	}
+	return names
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("wait for running jobs: %w", ctx.Err())
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+
+	for {
+		due, prev, err := s.nextRun(ctx, job)
+		if err != nil {
+			s.logger.Error("Failed to read next scheduled run",
+				zap.Error(err),
+				zap.String("job", job.Name),
+			)
+			due = s.now().Add(runStoreRetry)
+		}
+
+		timer := time.NewTimer(due.Sub(s.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if err == nil {
+				s.runOnce(ctx, job, due, prev)
+			}
+		}
+	}
+}
+
+// nextRun returns when the job is next due and the stored run to claim it
+// against. A stored run earlier than the schedule's next tick wins, so a run
+// that fell due while no instance was up happens once on start-up rather
+// than being skipped.
+func (s *Scheduler) nextRun(ctx context.Context, job Job) (time.Time, time.Time, error) {
+	due := job.Schedule.Next(s.now())
+	if s.runs == nil {
+		return due, time.Time{}, nil
+	}
+
+	stored, ok, err := s.runs.NextRun(ctx, job.Name)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !ok {
+		return due, time.Time{}, nil
+	}
+	if stored.Before(due) {
+		due = stored
+	}
+	return due, stored, nil
+}
+
+// claim decides whether this instance runs the job's due tick. With a run
+// store the winner also records the following run, computed from now so a
+// late catch-up run isn't followed by a burst of missed ones.
+func (s *Scheduler) claim(ctx context.Context, job Job, due, prev time.Time) (bool, error) {
+	if s.runs == nil {
+		lockName := fmt.Sprintf("%s:%d", job.Name, due.Unix())
+		return s.locker.Acquire(ctx, lockName, s.lockTTL)
+	}
+
+	from := s.now()
+	if from.Before(due) {
+		from = due
+	}
+	return s.runs.ClaimRun(ctx, job.Name, prev, job.Schedule.Next(from))
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, job Job, due, prev time.Time) {
+	acquired, err := s.claim(ctx, job, due, prev)
+	if err != nil {
+		s.logger.Error("Failed to claim scheduled run",
+			zap.Error(err),
+			zap.String("job", job.Name),
+		)
+		metrics.SchedulerJobRuns.WithLabelValues(job.Name, "lock_error").Inc()
+		return
+	}
+	if !acquired {
+		metrics.SchedulerJobRuns.WithLabelValues(job.Name, "skipped").Inc()
+		return
+	}
+
+	// A run in progress is allowed to finish during shutdown; Stop bounds how
+	// long the scheduler waits for it. Each run is traced on its own.
+	runCtx, _ := logging.StartSpan(context.WithoutCancel(ctx))
+	logger := logging.For(runCtx, s.logger)
+	start := time.Now()
+	err = job.Run(runCtx)
+	metrics.SchedulerJobDuration.WithLabelValues(job.Name).Observe(time.Since(start).Seconds())
+	if err != nil {
+		logger.Error("Scheduled job failed",
+			zap.Error(err),
+			zap.String("job", job.Name),
+		)
+		metrics.SchedulerJobRuns.WithLabelValues(job.Name, "error").Inc()
+		return
+	}
+
+	metrics.SchedulerJobRuns.WithLabelValues(job.Name, "success").Inc()
+	metrics.SchedulerJobLastSuccess.WithLabelValues(job.Name).SetToCurrentTime()
+	logger.Info("Scheduled job completed",
+		zap.String("job", job.Name),
+		zap.Duration("duration", time.Since(start)),
+	)
+}
@@ -0,0 +1,198 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type fakeLocker struct {
+	held map[string]bool
+	err  error
+}
+
+func (l *fakeLocker) Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	if l.err != nil {
+		return false, l.err
+	}
+	if l.held[name] {
+		return false, nil
+	}
+	l.held[name] = true
+	return true, nil
+}
+
+func TestEvery_Next(t *testing.T) {
+	schedule := Every(15 * time.Minute)
+	now := time.Date(2024, 3, 20, 10, 7, 30, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2024, 3, 20, 10, 15, 0, 0, time.UTC), schedule.Next(now))
+	assert.Equal(t, time.Date(2024, 3, 20, 10, 30, 0, 0, time.UTC), schedule.Next(schedule.Next(now)))
+}
+
+func TestScheduler_RunOnce(t *testing.T) {
+	tick := time.Date(2024, 3, 20, 10, 15, 0, 0, time.UTC)
+
+	t.Run("only one instance runs a tick", func(t *testing.T) {
+		locker := &fakeLocker{held: map[string]bool{}}
+		runs := 0
+		job := Job{Name: "test", Schedule: Every(time.Minute), Run: func(ctx context.Context) error {
+			runs++
+			return nil
+		}}
+
+		first := New(locker, time.Minute, zap.NewNop())
+		second := New(locker, time.Minute, zap.NewNop())
+		first.runOnce(context.Background(), job, tick, time.Time{})
+		second.runOnce(context.Background(), job, tick, time.Time{})
+
+		assert.Equal(t, 1, runs)
+	})
+
+	t.Run("lock error skips run", func(t *testing.T) {
+		locker := &fakeLocker{held: map[string]bool{}, err: errors.New("redis down")}
+		runs := 0
+		job := Job{Name: "test", Schedule: Every(time.Minute), Run: func(ctx context.Context) error {
+			runs++
+			return nil
+		}}
+
+		New(locker, time.Minute, zap.NewNop()).runOnce(context.Background(), job, tick, time.Time{})
+
+		assert.Equal(t, 0, runs)
+	})
+}
+
+func TestCron_Next(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	t.Run("daily in a timezone", func(t *testing.T) {
+		schedule, err := Cron("30 3 * * *", berlin)
+		assert.NoError(t, err)
+
+		next := schedule.Next(time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC))
+		assert.Equal(t, time.Date(2024, 6, 2, 1, 30, 0, 0, time.UTC), next.UTC())
+	})
+
+	t.Run("clock jumps forward", func(t *testing.T) {
+		schedule, err := Cron("30 2 * * *", berlin)
+		assert.NoError(t, err)
+
+		// 02:30 doesn't exist on 31 March 2024, so the run happens when the
+		// clock jumps to 03:00, and the next one at 02:30 the day after.
+		first := schedule.Next(time.Date(2024, 3, 30, 12, 0, 0, 0, berlin))
+		assert.Equal(t, time.Date(2024, 3, 31, 3, 0, 0, 0, berlin), first)
+		assert.Equal(t, time.Date(2024, 4, 1, 2, 30, 0, 0, berlin), schedule.Next(first))
+	})
+
+	t.Run("clock falls back", func(t *testing.T) {
+		schedule, err := Cron("30 2 * * *", berlin)
+		assert.NoError(t, err)
+
+		// 02:30 happens twice on 27 October 2024 but runs once.
+		first := schedule.Next(time.Date(2024, 10, 26, 12, 0, 0, 0, berlin))
+		second := schedule.Next(first)
+		assert.Equal(t, 27, first.Day())
+		assert.Equal(t, time.Date(2024, 10, 28, 2, 30, 0, 0, berlin), second)
+	})
+
+	t.Run("either day field matches", func(t *testing.T) {
+		schedule, err := Cron("0 9 1 * 1", time.UTC)
+		assert.NoError(t, err)
+
+		// Saturday 1 June, then Monday 3 June.
+		first := schedule.Next(time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC))
+		assert.Equal(t, time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC), first)
+		assert.Equal(t, time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC), schedule.Next(first))
+	})
+
+	t.Run("steps, lists and descriptors", func(t *testing.T) {
+		schedule, err := Cron("5/20 8-10 * * 0,6", time.UTC)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 6, 1, 8, 45, 0, 0, time.UTC), schedule.Next(time.Date(2024, 6, 1, 8, 30, 0, 0, time.UTC)))
+
+		schedule, err = Cron("@weekly", time.UTC)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC), schedule.Next(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)))
+	})
+
+	for _, expr := range []string{"* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		t.Run("invalid "+expr, func(t *testing.T) {
+			_, err := Cron(expr, time.UTC)
+			assert.Error(t, err)
+		})
+	}
+}
+
+type fakeRunStore struct {
+	next map[string]time.Time
+}
+
+func (s *fakeRunStore) NextRun(ctx context.Context, job string) (time.Time, bool, error) {
+	next, ok := s.next[job]
+	return next, ok, nil
+}
+
+func (s *fakeRunStore) ClaimRun(ctx context.Context, job string, prev, next time.Time) (bool, error) {
+	if stored, ok := s.next[job]; ok != !prev.IsZero() || !stored.Equal(prev) {
+		return false, nil
+	}
+	s.next[job] = next
+	return true, nil
+}
+
+func TestScheduler_RunStore(t *testing.T) {
+	now := time.Date(2024, 3, 20, 10, 7, 0, 0, time.UTC)
+	job := Job{Name: "test", Schedule: Every(time.Hour)}
+
+	newScheduler := func(store *fakeRunStore) *Scheduler {
+		s := New(&fakeLocker{held: map[string]bool{}}, time.Minute, zap.NewNop(), WithRunStore(store))
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	t.Run("missed run happens once on start-up", func(t *testing.T) {
+		missed := time.Date(2024, 3, 20, 6, 0, 0, 0, time.UTC)
+		store := &fakeRunStore{next: map[string]time.Time{"test": missed}}
+		s := newScheduler(store)
+
+		due, prev, err := s.nextRun(context.Background(), job)
+		assert.NoError(t, err)
+		assert.Equal(t, missed, due)
+
+		runs := 0
+		job := job
+		job.Run = func(ctx context.Context) error {
+			runs++
+			return nil
+		}
+		s.runOnce(context.Background(), job, due, prev)
+
+		assert.Equal(t, 1, runs)
+		assert.Equal(t, time.Date(2024, 3, 20, 11, 0, 0, 0, time.UTC), store.next["test"])
+	})
+
+	t.Run("only one instance claims a run", func(t *testing.T) {
+		store := &fakeRunStore{next: map[string]time.Time{}}
+		first, second := newScheduler(store), newScheduler(store)
+
+		runs := 0
+		job := job
+		job.Run = func(ctx context.Context) error {
+			runs++
+			return nil
+		}
+		due, prev, _ := first.nextRun(context.Background(), job)
+		first.runOnce(context.Background(), job, due, prev)
+		second.runOnce(context.Background(), job, due, prev)
+
+		assert.Equal(t, 1, runs)
+	})
+}
@@ -0,0 +1,234 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+)
+
+type Config struct {
+	URL      string
+	Index    string
+	Username string
+	Password string
+	Timeout  time.Duration
+}
+
+// Client talks to Elasticsearch or OpenSearch through the part of the REST
+// API the two have in common.
+type Client struct {
+	baseURL  string
+	index    string
+	username string
+	password string
+	http     *http.Client
+}
+
+func NewClient(cfg Config) *Client {
+	return &Client{
+		baseURL:  strings.TrimRight(cfg.URL, "/"),
+		index:    cfg.Index,
+		username: cfg.Username,
+		password: cfg.Password,
+		http:     &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Document is the indexed form of a poll.
+type Document struct {
+	ID        uuid.UUID         `json:"-"`
+	Title     string            `json:"title"`
+	Tags      []string          `json:"tags"`
+	Creator   string            `json:"creator,omitempty"`
+	Status    domain.PollStatus `json:"status"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+var indexMapping = map[string]interface{}{
+	"mappings": map[string]interface{}{
+		"properties": map[string]interface{}{
+			"title":     map[string]string{"type": "text"},
+			"tags":      map[string]string{"type": "keyword"},
+			"creator":   map[string]string{"type": "text"},
+			"status":    map[string]string{"type": "keyword"},
+			"createdAt": map[string]string{"type": "date"},
+		},
+	},
+}
+
+// EnsureIndex creates the index with its mapping unless it already exists.
+func (c *Client) EnsureIndex(ctx context.Context) error {
+	status, err := c.do(ctx, http.MethodHead, c.index, nil, nil)
+	if err != nil {
+		return fmt.Errorf("check index: %w", err)
+	}
+	if status != http.StatusNotFound {
+		return nil
+	}
+	if _, err := c.do(ctx, http.MethodPut, c.index, indexMapping, nil); err != nil {
+		return fmt.Errorf("create index: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) Index(ctx context.Context, doc Document) error {
+	if _, err := c.do(ctx, http.MethodPut, c.index+"/_doc/"+doc.ID.String(), doc, nil); err != nil {
+		return fmt.Errorf("index poll %s: %w", doc.ID, err)
+	}
+	return nil
+}
+
+// Delete removes a poll's document. Deleting a poll that was never indexed
+// is not an error.
+func (c *Client) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, err := c.do(ctx, http.MethodDelete, c.index+"/_doc/"+id.String(), nil, nil); err != nil {
+		return fmt.Errorf("delete poll %s: %w", id, err)
+	}
+	return nil
+}
+
+// Hits is one page of search results, in relevance order.
+type Hits struct {
+	IDs    []uuid.UUID
+	Total  int
+	Facets []domain.TagFacet
+}
+
+// Search runs a fuzzy match of the query against titles, tags and creators,
+// so small typos still find the poll, and counts the tags of all matches.
+func (c *Client) Search(ctx context.Context, q domain.PollSearchQuery) (*Hits, error) {
+	must := map[string]interface{}{"match_all": map[string]interface{}{}}
+	sort := []interface{}{map[string]string{"createdAt": "desc"}}
+	if q.Query != "" {
+		must = map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":     q.Query,
+				"fields":    []string{"title^3", "tags^2", "creator"},
+				"fuzziness": "AUTO",
+			},
+		}
+		sort = append([]interface{}{"_score"}, sort...)
+	}
+	filters := make([]interface{}, 0, len(q.Tags))
+	for _, tag := range q.Tags {
+		filters = append(filters, map[string]interface{}{
+			"term": map[string]string{"tags": tag},
+		})
+	}
+
+	body := map[string]interface{}{
+		"from":             (q.Page - 1) * q.Limit,
+		"size":             q.Limit,
+		"track_total_hits": true,
+		"_source":          false,
+		"sort":             sort,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must":   must,
+				"filter": filters,
+			},
+		},
+		"aggs": map[string]interface{}{
+			"tags": map[string]interface{}{
+				"terms": map[string]interface{}{"field": "tags", "size": domain.MaxTagFacets},
+			},
+		},
+	}
+
+	var resp struct {
+		Hits struct {
+			Total struct {
+				Value int `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				ID string `json:"_id"`
+			} `json:"hits"`
+		} `json:"hits"`
+		Aggregations struct {
+			Tags struct {
+				Buckets []struct {
+					Key      string `json:"key"`
+					DocCount int    `json:"doc_count"`
+				} `json:"buckets"`
+			} `json:"tags"`
+		} `json:"aggregations"`
+	}
+	status, err := c.do(ctx, http.MethodPost, c.index+"/_search", body, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("search polls: %w", err)
+	}
+	if status == http.StatusNotFound {
+		return nil, fmt.Errorf("search polls: index %s not found", c.index)
+	}
+
+	hits := &Hits{
+		IDs:    make([]uuid.UUID, 0, len(resp.Hits.Hits)),
+		Total:  resp.Hits.Total.Value,
+		Facets: make([]domain.TagFacet, 0, len(resp.Aggregations.Tags.Buckets)),
+	}
+	for _, hit := range resp.Hits.Hits {
+		id, err := uuid.Parse(hit.ID)
+		if err != nil {
+			return nil, fmt.Errorf("parse hit id %q: %w", hit.ID, err)
+		}
+		hits.IDs = append(hits.IDs, id)
+	}
+	for _, bucket := range resp.Aggregations.Tags.Buckets {
+		hits.Facets = append(hits.Facets, domain.TagFacet{Tag: bucket.Key, Count: bucket.DocCount})
+	}
+	return hits, nil
+}
+
+// do sends a request and decodes a successful response into out. A 404 is
+// returned as a status rather than an error so callers can decide what a
+// missing index or document means to them.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+"/"+path, reader)
+	if err != nil {
+		return 0, fmt.Errorf("create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("%s %s: status %d: %s", method, path, resp.StatusCode, msg)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
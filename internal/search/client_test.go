@@ -0,0 +1,81 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(Config{URL: server.URL, Index: "polls", Timeout: time.Second})
+}
+
+func TestClient_Search(t *testing.T) {
+	id := uuid.New()
+	var body map[string]interface{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/polls/_search", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		_, _ = w.Write([]byte(`{
+			"hits": {"total": {"value": 21}, "hits": [{"_id": "` + id.String() + `"}]},
+			"aggregations": {"tags": {"buckets": [{"key": "food", "doc_count": 21}]}}
+		}`))
+	})
+
+	hits, err := client.Search(context.Background(), domain.PollSearchQuery{
+		Query: "piza", Tags: []string{"food"}, Page: 3, Limit: 10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{id}, hits.IDs)
+	assert.Equal(t, 21, hits.Total)
+	assert.Equal(t, []domain.TagFacet{{Tag: "food", Count: 21}}, hits.Facets)
+
+	assert.Equal(t, float64(20), body["from"])
+	query := body["query"].(map[string]interface{})["bool"].(map[string]interface{})
+	match := query["must"].(map[string]interface{})["multi_match"].(map[string]interface{})
+	assert.Equal(t, "piza", match["query"])
+	assert.Equal(t, "AUTO", match["fuzziness"])
+	assert.Len(t, query["filter"], 1)
+}
+
+func TestClient_DeleteMissing(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	assert.NoError(t, client.Delete(context.Background(), uuid.New()))
+}
+
+func TestClient_Error(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("cluster unavailable"))
+	})
+	_, err := client.Search(context.Background(), domain.PollSearchQuery{Page: 1, Limit: 10})
+	assert.ErrorContains(t, err, "cluster unavailable")
+}
+
+func TestClient_EnsureIndex(t *testing.T) {
+	var created bool
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodHead:
+			w.WriteHeader(http.StatusNotFound)
+		case http.MethodPut:
+			created = true
+		}
+	})
+	require.NoError(t, client.EnsureIndex(context.Background()))
+	assert.True(t, created)
+}
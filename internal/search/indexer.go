@@ -0,0 +1,128 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type PollSource interface {
+	GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Poll, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+}
+
+// Indexer keeps the search index in step with poll events. Every event
+// reloads the poll, so redelivered or out-of-order events still leave the
+// index matching the database.
+type Indexer struct {
+	client *Client
+	polls  PollSource
+	logger *zap.Logger
+}
+
+func NewIndexer(client *Client, polls PollSource, logger *zap.Logger) *Indexer {
+	return &Indexer{client: client, polls: polls, logger: logger}
+}
+
+// Searchable reports whether poll belongs in search results. It matches the
+// visibility rules of the Postgres fallback.
+func Searchable(poll *domain.Poll) bool {
+	switch poll.Status {
+	case domain.PollStatusScheduled, domain.PollStatusLive, domain.PollStatusClosed:
+		return poll.Electorate == domain.ElectorateOpen
+	default:
+		return false
+	}
+}
+
+// Reindex writes the poll's current document, or removes it when the poll
+// is gone or no longer searchable.
+func (i *Indexer) Reindex(ctx context.Context, pollID uuid.UUID) error {
+	poll, err := i.polls.GetPollByID(ctx, pollID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return i.client.Delete(ctx, pollID)
+	}
+	if err != nil {
+		return fmt.Errorf("get poll: %w", err)
+	}
+	if !Searchable(poll) {
+		return i.client.Delete(ctx, pollID)
+	}
+
+	doc := Document{
+		ID:        poll.ID,
+		Title:     poll.Title,
+		Tags:      poll.Tags,
+		Status:    poll.Status,
+		CreatedAt: poll.CreatedAt,
+	}
+	if poll.CreatedBy != nil {
+		creator, err := i.polls.GetUserByID(ctx, *poll.CreatedBy)
+		switch {
+		case err == nil:
+			doc.Creator = creator.Username
+		case !errors.Is(err, domain.ErrNotFound):
+			return fmt.Errorf("get poll creator: %w", err)
+		}
+	}
+	return i.client.Index(ctx, doc)
+}
+
+// Backfill indexes every searchable poll found by source, batch polls at a
+// time, and returns how many were indexed. It is meant for populating a new
+// index; afterwards events keep it current.
+func (i *Indexer) Backfill(ctx context.Context, source domain.PollSearcher, batch int) (int, error) {
+	indexed := 0
+	for page := 1; ; page++ {
+		result, err := source.SearchPolls(ctx, domain.PollSearchQuery{Page: page, Limit: batch})
+		if err != nil {
+			return indexed, fmt.Errorf("list polls: %w", err)
+		}
+		for _, poll := range result.Polls {
+			if err := i.Reindex(ctx, poll.ID); err != nil {
+				return indexed, err
+			}
+			indexed++
+		}
+		if len(result.Polls) < batch {
+			return indexed, nil
+		}
+		i.logger.Info("Search backfill progress", zap.Int("indexed", indexed), zap.Int("total", result.Total))
+	}
+}
+
+func (i *Indexer) HandlePollCreated(ctx context.Context, poll *domain.Poll) error {
+	return i.Reindex(ctx, poll.ID)
+}
+
+func (i *Indexer) HandlePollUpdated(ctx context.Context, poll *domain.Poll) error {
+	return i.Reindex(ctx, poll.ID)
+}
+
+func (i *Indexer) HandlePollStatusChanged(ctx context.Context, change *domain.PollStatusChange) error {
+	return i.Reindex(ctx, change.PollID)
+}
+
+func (i *Indexer) HandlePollVoted(ctx context.Context, vote *domain.Vote) error {
+	return nil
+}
+
+func (i *Indexer) HandleVoteUpdated(ctx context.Context, vote *domain.Vote) error {
+	return nil
+}
+
+func (i *Indexer) HandleVoteDeleted(ctx context.Context, vote *domain.Vote) error {
+	return nil
+}
+
+func (i *Indexer) HandlePollSkipped(ctx context.Context, skip *domain.Skip) error {
+	return nil
+}
+
+func (i *Indexer) HandleCollaboratorInvited(ctx context.Context, collaborator *domain.Collaborator) error {
+	return nil
+}
@@ -0,0 +1,68 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type stubPolls struct {
+	polls map[uuid.UUID]*domain.Poll
+	users map[uuid.UUID]*domain.User
+}
+
+func (s stubPolls) GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Poll, error) {
+	if poll, ok := s.polls[id]; ok {
+		return poll, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (s stubPolls) GetUserByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	if user, ok := s.users[id]; ok {
+		return user, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func TestIndexer_Reindex(t *testing.T) {
+	creatorID := uuid.New()
+	live := &domain.Poll{ID: uuid.New(), Title: "Best pizza", Tags: []string{"food"},
+		Status: domain.PollStatusLive, Electorate: domain.ElectorateOpen, CreatedBy: &creatorID}
+	draft := &domain.Poll{ID: uuid.New(), Status: domain.PollStatusDraft, Electorate: domain.ElectorateOpen}
+	restricted := &domain.Poll{ID: uuid.New(), Status: domain.PollStatusLive, Electorate: domain.ElectorateList}
+	source := stubPolls{
+		polls: map[uuid.UUID]*domain.Poll{live.ID: live, draft.ID: draft, restricted.ID: restricted},
+		users: map[uuid.UUID]*domain.User{creatorID: {ID: creatorID, Username: "alice"}},
+	}
+
+	var method, path string
+	var doc map[string]interface{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		method, path, doc = r.Method, r.URL.Path, nil
+		if r.Method == http.MethodPut {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&doc))
+		}
+	})
+	indexer := NewIndexer(client, source, zap.NewNop())
+	ctx := context.Background()
+
+	require.NoError(t, indexer.HandlePollCreated(ctx, &domain.Poll{ID: live.ID}))
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/polls/_doc/"+live.ID.String(), path)
+	assert.Equal(t, "Best pizza", doc["title"])
+	assert.Equal(t, "alice", doc["creator"])
+
+	for _, id := range []uuid.UUID{draft.ID, restricted.ID, uuid.New()} {
+		require.NoError(t, indexer.HandlePollStatusChanged(ctx, &domain.PollStatusChange{PollID: id}))
+		assert.Equal(t, http.MethodDelete, method)
+		assert.Equal(t, "/polls/_doc/"+id.String(), path)
+	}
+}
@@ -0,0 +1,45 @@
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+)
+
+type PollLoader interface {
+	GetPollsByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.Poll, error)
+}
+
+// Searcher finds polls in the search index and loads them from the
+// database, so responses carry the same poll data as the rest of the API.
+type Searcher struct {
+	client *Client
+	polls  PollLoader
+}
+
+func NewSearcher(client *Client, polls PollLoader) *Searcher {
+	return &Searcher{client: client, polls: polls}
+}
+
+func (s *Searcher) SearchPolls(ctx context.Context, q domain.PollSearchQuery) (*domain.PollSearchResult, error) {
+	hits, err := s.client.Search(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	polls, err := s.polls.GetPollsByIDs(ctx, hits.IDs)
+	if err != nil {
+		return nil, fmt.Errorf("load search hits: %w", err)
+	}
+	if polls == nil {
+		polls = []domain.Poll{}
+	}
+	return &domain.PollSearchResult{
+		Polls:  polls,
+		Total:  hits.Total,
+		Facets: hits.Facets,
+		Page:   q.Page,
+		Limit:  q.Limit,
+	}, nil
+}
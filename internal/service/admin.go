@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func (s *service) SearchUsers(ctx context.Context, q *domain.UserSearchQuery) (*domain.UserList, error) {
+	if q == nil {
+		q = &domain.UserSearchQuery{}
+	}
+	if q.Role != "" && !q.Role.Valid() {
+		return nil, domain.ErrInvalidInput
+	}
+	if q.Standing != "" && !q.Standing.Valid() {
+		return nil, domain.ErrInvalidInput
+	}
+	if q.Page < 1 {
+		q.Page = domain.DefaultPage
+	}
+	if q.Limit < 1 || q.Limit > domain.MaxPageSize {
+		q.Limit = domain.DefaultLimit
+	}
+
+	users, total, err := s.repo.SearchUsers(ctx, *q)
+	if err != nil {
+		return nil, err
+	}
+	if users == nil {
+		users = []domain.AdminUser{}
+	}
+	return &domain.UserList{
+		Users: users,
+		Total: total,
+		Page:  q.Page,
+		Limit: q.Limit,
+	}, nil
+}
+
+func (s *service) GetPlatformStats(ctx context.Context) (*domain.PlatformStats, error) {
+	now := time.Now().UTC()
+	stats, err := s.repo.GetPlatformStats(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	stats.ComputedAt = now
+	return stats, nil
+}
+
+// ForceDeletePoll permanently removes a poll and its votes, whatever its
+// status. Unlike deleting it through ChangePollStatus, nothing is kept for
+// export or audit. Subscribers are told the poll was deleted so it leaves
+// the search index and live feeds.
+func (s *service) ForceDeletePoll(ctx context.Context, pollID, actorID uuid.UUID) error {
+	poll, err := s.repo.GetPollByID(ctx, pollID)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	from := poll.StatusAt(now)
+
+	if err := s.repo.PurgePoll(ctx, pollID); err != nil {
+		return fmt.Errorf("failed to purge poll: %w", err)
+	}
+
+	change := &domain.PollStatusChange{
+		PollID:    pollID,
+		From:      from,
+		To:        domain.PollStatusDeleted,
+		ActorID:   actorID,
+		ChangedAt: now,
+	}
+	if err := s.publisher.PublishPollStatusChanged(ctx, change); err != nil {
+		logging.For(ctx, s.logger).Error("failed to publish poll status changed event",
+			zap.Error(err),
+			zap.String("poll_id", pollID.String()),
+		)
+	}
+
+	logging.For(ctx, s.logger).Info("Poll force-deleted",
+		zap.String("poll_id", pollID.String()),
+		zap.String("status", string(from)),
+		zap.String("user_id", actorID.String()),
+	)
+	return nil
+}
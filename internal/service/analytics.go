@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+)
+
+// The analytics reads below are served from the projections kept by the
+// analytics consumer, so they lag the votes table by however far behind the
+// consumer is.
+
+// GetTagVoteTrend returns the daily votes on polls carrying tag.
+func (s *service) GetTagVoteTrend(ctx context.Context, tag string, from, to time.Time) ([]domain.VoteBucket, error) {
+	tag, err := s.canonicalTag(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetTagDailyVotes(ctx, tag, from, to)
+}
+
+// GetPollVoteTimeline returns the poll's votes per hour. Like the owner
+// stats, it is limited to the creator and collaborators with stats rights.
+func (s *service) GetPollVoteTimeline(ctx context.Context, pollID, actorID uuid.UUID, from, to time.Time) ([]domain.VoteBucket, error) {
+	poll, err := s.repo.GetPollByID(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requirePollPermission(ctx, poll, actorID, domain.CollaboratorStats); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPollHourlyVotes(ctx, pollID, from, to)
+}
+
+func (s *service) GetUserActivity(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]domain.UserActivity, error) {
+	return s.repo.GetUserActivity(ctx, userID, from, to)
+}
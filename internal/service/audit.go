@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"go.uber.org/zap"
+)
+
+// publishUserEvent sends an account event to the audit stream. Like the poll
+// events, a failed publish is logged and doesn't fail the action.
+func (s *service) publishUserEvent(ctx context.Context, eventType string, publish func(context.Context, *domain.UserEvent) error, user *domain.User) {
+	if err := publish(ctx, newUserEvent(ctx, user)); err != nil {
+		logging.For(ctx, s.logger).Error("Failed to publish user event",
+			zap.Error(err),
+			zap.String("type", eventType),
+			zap.String("user_id", user.ID.String()),
+		)
+	}
+}
+
+func newUserEvent(ctx context.Context, user *domain.User) *domain.UserEvent {
+	client := auth.ClientFrom(ctx)
+	event := &domain.UserEvent{
+		UserID:     user.ID,
+		Username:   user.Username,
+		Email:      user.Email,
+		IP:         client.IP,
+		UserAgent:  client.UserAgent,
+		OccurredAt: time.Now().UTC(),
+	}
+	if principal, ok := auth.CurrentUser(ctx); ok && principal.ID != user.ID {
+		actorID := principal.ID
+		event.ActorID = &actorID
+	}
+	return event
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/metrics"
+	"github.com/google/uuid"
+)
+
+// pollReads coalesces concurrent reads of the same poll: the first caller
+// fetches it and everyone who asks while that fetch is in flight gets a copy
+// of its result.
+type pollReads struct {
+	mu    sync.Mutex
+	calls map[uuid.UUID]*pollRead
+}
+
+type pollRead struct {
+	done chan struct{}
+	poll *domain.Poll
+	err  error
+}
+
+// get returns a copy of the poll that callers may modify. The shared fetch
+// is not cancelled with the caller that started it, so the others still get
+// a result; each caller stops waiting when its own ctx is done.
+func (g *pollReads) get(ctx context.Context, id uuid.UUID, fetch func(ctx context.Context, id uuid.UUID) (*domain.Poll, error)) (*domain.Poll, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[uuid.UUID]*pollRead)
+	}
+	call, ok := g.calls[id]
+	if ok {
+		metrics.PollReadsCoalesced.Inc()
+	} else {
+		call = &pollRead{done: make(chan struct{})}
+		g.calls[id] = call
+		go func() {
+			call.poll, call.err = fetch(context.WithoutCancel(ctx), id)
+			g.mu.Lock()
+			delete(g.calls, id)
+			g.mu.Unlock()
+			close(call.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-call.done:
+	}
+	if call.err != nil {
+		return nil, call.err
+	}
+	return clonePoll(call.poll), nil
+}
+
+// clonePoll copies what the service and handlers change on a poll they
+// return.
+func clonePoll(poll *domain.Poll) *domain.Poll {
+	clone := *poll
+	clone.Options = slices.Clone(poll.Options)
+	clone.Tags = slices.Clone(poll.Tags)
+	return &clone
+}
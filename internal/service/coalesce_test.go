@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPollReadsCoalesce(t *testing.T) {
+	var reads pollReads
+	pollID := uuid.New()
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	fetch := func(ctx context.Context, id uuid.UUID) (*domain.Poll, error) {
+		fetches.Add(1)
+		<-release
+		return &domain.Poll{ID: id, Options: []domain.Option{{OptionText: "Yes"}}}, nil
+	}
+
+	const callers = 10
+	polls := make([]*domain.Poll, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			poll, err := reads.get(context.Background(), pollID, fetch)
+			assert.NoError(t, err)
+			polls[i] = poll
+		}(i)
+	}
+	// Let every caller join the first fetch before it returns.
+	require.Eventually(t, func() bool {
+		reads.mu.Lock()
+		defer reads.mu.Unlock()
+		return reads.calls[pollID] != nil
+	}, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), fetches.Load())
+	polls[0].Options[0].ImageURL = "changed"
+	assert.Empty(t, polls[1].Options[0].ImageURL, "callers get their own copy")
+
+	_, err := reads.get(context.Background(), pollID, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), fetches.Load(), "a finished read is not reused")
+}
+
+func TestPollReadsCallerCancel(t *testing.T) {
+	var reads pollReads
+	pollID := uuid.New()
+	release := make(chan struct{})
+	fetch := func(ctx context.Context, id uuid.UUID) (*domain.Poll, error) {
+		<-release
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return &domain.Poll{ID: id}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := reads.get(ctx, pollID, fetch)
+		first <- err
+	}()
+	require.Eventually(t, func() bool {
+		reads.mu.Lock()
+		defer reads.mu.Unlock()
+		return reads.calls[pollID] != nil
+	}, time.Second, time.Millisecond)
+
+	second := make(chan error, 1)
+	go func() {
+		_, err := reads.get(context.Background(), pollID, fetch)
+		second <- err
+	}()
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled)
+
+	close(release)
+	assert.NoError(t, <-second, "the shared read outlives the caller that started it")
+}
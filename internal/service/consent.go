@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+)
+
+// WithConsentVersions requires users to have accepted the given terms of
+// service and privacy policy versions. Bumping a version makes every user
+// accept it again.
+func WithConsentVersions(versions domain.ConsentVersions) Option {
+	return func(s *service) {
+		s.consentVersions = versions
+	}
+}
+
+func (s *service) requiredConsents() []domain.Consent {
+	var required []domain.Consent
+	if s.consentVersions.Terms != "" {
+		required = append(required, domain.Consent{Document: domain.ConsentTerms, Version: s.consentVersions.Terms})
+	}
+	if s.consentVersions.Privacy != "" {
+		required = append(required, domain.Consent{Document: domain.ConsentPrivacy, Version: s.consentVersions.Privacy})
+	}
+	return required
+}
+
+// GetConsentStatus reports which current documents the user still has to
+// accept along with their full acceptance history. Nothing is looked up
+// when no versions are configured.
+func (s *service) GetConsentStatus(ctx context.Context, userID uuid.UUID) (*domain.ConsentStatus, error) {
+	status := &domain.ConsentStatus{
+		Required: s.consentVersions,
+		Pending:  []domain.ConsentDocument{},
+		History:  []domain.Consent{},
+	}
+	required := s.requiredConsents()
+	if len(required) == 0 {
+		return status, nil
+	}
+
+	history, err := s.repo.GetConsents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if history != nil {
+		status.History = history
+	}
+
+	accepted := make(map[domain.Consent]bool, len(history))
+	for _, consent := range history {
+		accepted[domain.Consent{Document: consent.Document, Version: consent.Version}] = true
+	}
+	for _, consent := range required {
+		if !accepted[consent] {
+			status.Pending = append(status.Pending, consent.Document)
+		}
+	}
+	return status, nil
+}
+
+// AcceptConsents records the user accepting the current versions. Versions
+// that are not current are rejected so a client showing an outdated
+// document cannot accept on the user's behalf.
+func (s *service) AcceptConsents(ctx context.Context, req *domain.AcceptConsentRequest) (*domain.ConsentStatus, error) {
+	if req == nil || (req.Terms == "" && req.Privacy == "") {
+		return nil, domain.ErrInvalidInput
+	}
+
+	accepted := []domain.Consent{
+		{Document: domain.ConsentTerms, Version: req.Terms},
+		{Document: domain.ConsentPrivacy, Version: req.Privacy},
+	}
+	current := map[domain.ConsentDocument]string{
+		domain.ConsentTerms:   s.consentVersions.Terms,
+		domain.ConsentPrivacy: s.consentVersions.Privacy,
+	}
+
+	now := time.Now().UTC()
+	consents := make([]domain.Consent, 0, len(accepted))
+	for _, consent := range accepted {
+		if consent.Version == "" {
+			continue
+		}
+		if consent.Version != current[consent.Document] {
+			return nil, domain.ErrInvalidInput
+		}
+		consent.AcceptedAt = now
+		consent.IPAddress = req.IPAddress
+		consent.UserAgent = req.UserAgent
+		consents = append(consents, consent)
+	}
+
+	if err := s.repo.RecordConsents(ctx, req.UserID, consents); err != nil {
+		return nil, err
+	}
+	return s.GetConsentStatus(ctx, req.UserID)
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type emailChangeEvent string
+
+const (
+	confirmOldEmail   emailChangeEvent = "confirm_old"
+	confirmNewEmail   emailChangeEvent = "confirm_new"
+	cancelEmailChange emailChangeEvent = "cancel"
+)
+
+// emailChangeTransitions is the state machine an email change follows. Each
+// side is confirmed by opening the link sent to that address, in either
+// order; confirming the second side completes the change. Opening a link
+// again leaves the change as it is, and the user can cancel it until it
+// completes.
+var emailChangeTransitions = map[domain.EmailChangeStatus]map[emailChangeEvent]domain.EmailChangeStatus{
+	domain.EmailChangePending: {
+		confirmOldEmail:   domain.EmailChangeOldConfirmed,
+		confirmNewEmail:   domain.EmailChangeNewConfirmed,
+		cancelEmailChange: domain.EmailChangeCancelled,
+	},
+	domain.EmailChangeOldConfirmed: {
+		confirmOldEmail:   domain.EmailChangeOldConfirmed,
+		confirmNewEmail:   domain.EmailChangeCompleted,
+		cancelEmailChange: domain.EmailChangeCancelled,
+	},
+	domain.EmailChangeNewConfirmed: {
+		confirmOldEmail:   domain.EmailChangeCompleted,
+		confirmNewEmail:   domain.EmailChangeNewConfirmed,
+		cancelEmailChange: domain.EmailChangeCancelled,
+	},
+}
+
+func nextEmailChangeStatus(from domain.EmailChangeStatus, event emailChangeEvent) (domain.EmailChangeStatus, bool) {
+	to, ok := emailChangeTransitions[from][event]
+	return to, ok
+}
+
+// RequestEmailChange starts moving the user to a new address, replacing the
+// change already in progress, and mails a confirmation link to both the
+// current and the new address. Links are valid as long as verification links.
+func (s *service) RequestEmailChange(ctx context.Context, userID uuid.UUID, req *domain.EmailChangeRequest) (*domain.EmailChange, error) {
+	if req == nil || strings.TrimSpace(req.Email) == "" {
+		return nil, fmt.Errorf("%w: email is required", domain.ErrInvalidInput)
+	}
+	email := strings.TrimSpace(req.Email)
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(email, user.Email) {
+		return nil, fmt.Errorf("%w: email is already the account's address", domain.ErrInvalidInput)
+	}
+	if _, err := s.repo.GetUserByEmail(ctx, email); err == nil {
+		return nil, domain.ErrEmailAlreadyExists
+	} else if !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	oldToken, err := newMailToken()
+	if err != nil {
+		return nil, err
+	}
+	newToken, err := newMailToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	change := &domain.EmailChange{
+		ID:           uuid.New(),
+		UserID:       user.ID,
+		OldEmail:     user.Email,
+		NewEmail:     email,
+		Status:       domain.EmailChangePending,
+		OldTokenHash: hashVerificationToken(oldToken),
+		NewTokenHash: hashVerificationToken(newToken),
+		ExpiresAt:    now.Add(s.verificationTTL),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := s.repo.CreateEmailChange(ctx, change); err != nil {
+		return nil, err
+	}
+
+	// Without both links the change can never complete, so a failed publish
+	// fails the request; asking again replaces the change.
+	links := []struct {
+		side  domain.EmailChangeSide
+		email string
+		token string
+	}{
+		{domain.EmailChangeOld, change.OldEmail, oldToken},
+		{domain.EmailChangeNew, change.NewEmail, newToken},
+	}
+	for _, link := range links {
+		confirmation := &domain.EmailChangeConfirmation{
+			UserID:    user.ID,
+			Username:  user.Username,
+			Email:     link.email,
+			Side:      link.side,
+			OldEmail:  change.OldEmail,
+			NewEmail:  change.NewEmail,
+			Token:     link.token,
+			ExpiresAt: change.ExpiresAt,
+		}
+		if err := s.publisher.PublishEmailChangeRequested(ctx, confirmation); err != nil {
+			return nil, fmt.Errorf("send email change confirmation: %w", err)
+		}
+	}
+
+	logging.For(ctx, s.logger).Info("Email change requested",
+		zap.String("user_id", user.ID.String()),
+		zap.String("change_id", change.ID.String()),
+	)
+	return change, nil
+}
+
+// GetEmailChange returns the user's change in progress. Expired changes are
+// domain.ErrNotFound.
+func (s *service) GetEmailChange(ctx context.Context, userID uuid.UUID) (*domain.EmailChange, error) {
+	change, err := s.repo.GetOpenEmailChange(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(change.ExpiresAt) {
+		return nil, domain.ErrNotFound
+	}
+	return change, nil
+}
+
+// ConfirmEmailChange records that the link carrying token was opened. Once
+// both addresses are confirmed the account moves to the new address, which
+// counts as verified, and its refresh tokens are revoked. Unknown, expired,
+// cancelled and completed changes are domain.ErrNotFound.
+func (s *service) ConfirmEmailChange(ctx context.Context, token string) (*domain.EmailChange, error) {
+	if token == "" {
+		return nil, fmt.Errorf("%w: token is required", domain.ErrInvalidInput)
+	}
+	tokenHash := hashVerificationToken(token)
+	change, err := s.repo.GetEmailChangeByToken(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if !now.Before(change.ExpiresAt) {
+		return nil, domain.ErrNotFound
+	}
+	event := confirmNewEmail
+	if tokenHash == change.OldTokenHash {
+		event = confirmOldEmail
+	}
+	from := change.Status
+	to, ok := nextEmailChangeStatus(from, event)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if to == from {
+		return change, nil
+	}
+
+	if to == domain.EmailChangeCompleted {
+		err = s.repo.CompleteEmailChange(ctx, change, from, now)
+	} else {
+		err = s.repo.SetEmailChangeStatus(ctx, change.ID, from, to, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	change.Status = to
+	change.UpdatedAt = now
+
+	if to == domain.EmailChangeCompleted {
+		logging.For(ctx, s.logger).Info("Email address changed, sessions revoked",
+			zap.String("user_id", change.UserID.String()),
+			zap.String("change_id", change.ID.String()),
+		)
+	}
+	return change, nil
+}
+
+// CancelEmailChange abandons the user's change in progress.
+func (s *service) CancelEmailChange(ctx context.Context, userID uuid.UUID) error {
+	change, err := s.repo.GetOpenEmailChange(ctx, userID)
+	if err != nil {
+		return err
+	}
+	to, ok := nextEmailChangeStatus(change.Status, cancelEmailChange)
+	if !ok {
+		return domain.ErrNotFound
+	}
+	return s.repo.SetEmailChangeStatus(ctx, change.ID, change.Status, to, time.Now().UTC())
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// normalizeCountries upper-cases and de-duplicates ISO 3166-1 alpha-2 codes.
+func normalizeCountries(codes []string) ([]string, error) {
+	var countries []string
+	seen := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+			return nil, fmt.Errorf("%w: invalid country code %q", domain.ErrInvalidInput, code)
+		}
+		if !seen[code] {
+			seen[code] = true
+			countries = append(countries, code)
+		}
+	}
+	return countries, nil
+}
+
+// locateVote puts the voter's location on the vote so it goes out with the
+// vote event. Anonymous polls keep no location at all.
+func locateVote(poll *domain.Poll, vote *domain.Vote, location *domain.GeoLocation) {
+	if location == nil || poll.Anonymous {
+		return
+	}
+	vote.Country = location.Country
+	vote.Region = location.Region
+}
+
+// recordVoteLocation counts the vote towards its location.
+func (s *service) recordVoteLocation(ctx context.Context, poll *domain.Poll, location *domain.GeoLocation) {
+	if location == nil || poll.Anonymous {
+		return
+	}
+	if err := s.repo.RecordVoteLocation(ctx, poll.ID, *location); err != nil {
+		logging.For(ctx, s.logger).Warn("Failed to record vote location",
+			zap.Error(err),
+			zap.String("poll_id", poll.ID.String()),
+		)
+	}
+}
+
+// getGeoStats leaves out countries and regions with too few votes to hide
+// who cast them. Their votes still count towards the country total.
+func (s *service) getGeoStats(ctx context.Context, pollID uuid.UUID) ([]domain.CountryStat, error) {
+	stats, err := s.repo.GetPollGeoStats(ctx, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geo stats: %w", err)
+	}
+
+	visible := stats[:0]
+	for _, country := range stats {
+		if country.Votes < domain.MinGeoStatsVotes {
+			continue
+		}
+		regions := country.Regions[:0]
+		for _, region := range country.Regions {
+			if region.Votes >= domain.MinGeoStatsVotes {
+				regions = append(regions, region)
+			}
+		}
+		country.Regions = regions
+		visible = append(visible, country)
+	}
+	return visible, nil
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/langdetect"
+)
+
+// normalizeLanguages lower-cases and de-duplicates ISO 639-1 codes,
+// rejecting anything else.
+func normalizeLanguages(codes []string) ([]string, error) {
+	languages := normalizeList(codes)
+	for _, code := range languages {
+		if len(code) != 2 || code[0] < 'a' || code[0] > 'z' || code[1] < 'a' || code[1] > 'z' {
+			return nil, fmt.Errorf("%w: invalid language code %q", domain.ErrInvalidInput, code)
+		}
+	}
+	return languages, nil
+}
+
+// pollLanguage detects the language of a poll from its title and options.
+func pollLanguage(req *domain.CreatePollRequest) string {
+	return langdetect.Detect(req.Title + "\n" + strings.Join(req.Options, "\n"))
+}
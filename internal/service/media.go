@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/storage/blob"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// WithMediaStore enables avatar and option image uploads. Media URLs given
+// to clients, including upload URLs, are signed and stay valid for urlTTL.
+func WithMediaStore(store blob.Store, urlTTL time.Duration) Option {
+	return func(s *service) {
+		s.media = store
+		s.mediaURLTTL = urlTTL
+	}
+}
+
+func (s *service) SetUserAvatar(ctx context.Context, userID uuid.UUID, upload *domain.MediaUpload) (*domain.User, error) {
+	key, err := s.storeMedia(ctx, blob.KindAvatar, upload)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.attachAvatar(ctx, userID, key)
+	if err != nil {
+		s.deleteMedia(ctx, key)
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *service) SetOptionImage(ctx context.Context, pollID uuid.UUID, optionIndex int, actorID uuid.UUID, upload *domain.MediaUpload) (*domain.Poll, error) {
+	if err := s.requireEditableOption(ctx, pollID, optionIndex, actorID); err != nil {
+		return nil, err
+	}
+	key, err := s.storeMedia(ctx, blob.KindOptionImage, upload)
+	if err != nil {
+		return nil, err
+	}
+	poll, err := s.attachOptionImage(ctx, pollID, optionIndex, key)
+	if err != nil {
+		s.deleteMedia(ctx, key)
+		return nil, err
+	}
+	return poll, nil
+}
+
+// UpdateOption sets the alt text and emoji of an option under the same rules
+// as UpdatePoll.
+func (s *service) UpdateOption(ctx context.Context, pollID uuid.UUID, optionIndex int, req *domain.UpdateOptionRequest) (*domain.Poll, error) {
+	if req == nil {
+		return nil, domain.ErrInvalidInput
+	}
+	if err := s.validator.ValidateOptionUpdate(req); err != nil {
+		return nil, err
+	}
+	if err := s.requireEditableOption(ctx, pollID, optionIndex, req.ActorID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateOptionMetadata(ctx, pollID, optionIndex, req.AltText, req.Emoji); err != nil {
+		return nil, fmt.Errorf("failed to update option: %w", err)
+	}
+
+	poll, err := s.getPoll(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.publisher.PublishPollUpdated(ctx, poll); err != nil {
+		logging.For(ctx, s.logger).Error("failed to publish poll updated event",
+			zap.Error(err),
+			zap.String("poll_id", poll.ID.String()),
+		)
+	}
+	return poll, nil
+}
+
+// SignUpload hands out a URL the client can PUT the object to directly.
+// Nothing is attached until the upload is confirmed.
+func (s *service) SignUpload(ctx context.Context, userID uuid.UUID, req *domain.SignUploadRequest) (*domain.SignedUpload, error) {
+	if s.media == nil {
+		return nil, domain.ErrMediaUnavailable
+	}
+	kind, err := s.authorizeUpload(ctx, userID, req.UploadTarget)
+	if err != nil {
+		return nil, err
+	}
+	policy := kind.Policy()
+	if err := policy.Validate(req.Size, req.ContentType); err != nil {
+		return nil, err
+	}
+
+	key := kind.NewKey()
+	url, err := s.media.SignedURL(http.MethodPut, key, s.mediaURLTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign upload: %w", err)
+	}
+	return &domain.SignedUpload{
+		Key:          key,
+		URL:          url,
+		Method:       http.MethodPut,
+		Headers:      map[string]string{"Content-Type": req.ContentType},
+		ExpiresAt:    time.Now().UTC().Add(s.mediaURLTTL),
+		MaxSize:      policy.MaxSize,
+		ContentTypes: policy.ContentTypes,
+	}, nil
+}
+
+// ConfirmUpload checks an object uploaded through a signed URL and attaches
+// it to its target. Objects that break the upload policy are deleted; ones
+// never confirmed are left to the media_gc job.
+func (s *service) ConfirmUpload(ctx context.Context, userID uuid.UUID, req *domain.ConfirmUploadRequest) (*domain.UploadConfirmation, error) {
+	if s.media == nil {
+		return nil, domain.ErrMediaUnavailable
+	}
+	kind, err := s.authorizeUpload(ctx, userID, req.UploadTarget)
+	if err != nil {
+		return nil, err
+	}
+	if !kind.Owns(req.Key) {
+		return nil, fmt.Errorf("%w: key is not a %s upload", domain.ErrInvalidInput, req.Target)
+	}
+
+	obj, err := s.media.Stat(ctx, req.Key)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, fmt.Errorf("%w: upload not found", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check upload: %w", err)
+	}
+	if err := kind.Policy().Validate(obj.Size, obj.ContentType); err != nil {
+		s.deleteMedia(ctx, req.Key)
+		return nil, err
+	}
+
+	unreferenced, err := s.repo.UnreferencedMediaKeys(ctx, []string{req.Key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check upload: %w", err)
+	}
+	if len(unreferenced) == 0 {
+		return nil, fmt.Errorf("%w: upload is already attached", domain.ErrInvalidInput)
+	}
+
+	if kind == blob.KindAvatar {
+		user, err := s.attachAvatar(ctx, userID, req.Key)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.UploadConfirmation{Key: req.Key, URL: user.AvatarURL}, nil
+	}
+	poll, err := s.attachOptionImage(ctx, *req.PollID, *req.OptionIndex, req.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.UploadConfirmation{
+		Key:  req.Key,
+		URL:  poll.Options[*req.OptionIndex].ImageURL,
+		Poll: poll,
+	}, nil
+}
+
+// authorizeUpload maps target to the kind of object it takes and checks
+// userID may change it.
+func (s *service) authorizeUpload(ctx context.Context, userID uuid.UUID, target domain.UploadTarget) (blob.Kind, error) {
+	switch target.Target {
+	case domain.UploadTargetAvatar:
+		return blob.KindAvatar, nil
+	case domain.UploadTargetOptionImage:
+		if target.PollID == nil || target.OptionIndex == nil {
+			return "", fmt.Errorf("%w: pollId and optionIndex are required", domain.ErrInvalidInput)
+		}
+		if err := s.requireEditableOption(ctx, *target.PollID, *target.OptionIndex, userID); err != nil {
+			return "", err
+		}
+		return blob.KindOptionImage, nil
+	default:
+		return "", fmt.Errorf("%w: unknown upload target %q", domain.ErrInvalidInput, target.Target)
+	}
+}
+
+// requireEditableOption applies the same rules as UpdatePoll.
+func (s *service) requireEditableOption(ctx context.Context, pollID uuid.UUID, optionIndex int, actorID uuid.UUID) error {
+	poll, err := s.repo.GetPollByID(ctx, pollID)
+	if err != nil {
+		return err
+	}
+	if err := s.requirePollPermission(ctx, poll, actorID, domain.CollaboratorEdit); err != nil {
+		return err
+	}
+	if optionIndex < 0 || optionIndex >= len(poll.Options) {
+		return domain.ErrInvalidOption
+	}
+	switch poll.StatusAt(time.Now().UTC()) {
+	case domain.PollStatusDraft, domain.PollStatusScheduled, domain.PollStatusLive:
+		return nil
+	default:
+		return domain.ErrPollNotOpen
+	}
+}
+
+// storeMedia checks the upload against the policy for kind, using the
+// sniffed content type rather than trusting the client, and stores it
+// under a new key.
+func (s *service) storeMedia(ctx context.Context, kind blob.Kind, upload *domain.MediaUpload) (string, error) {
+	if s.media == nil {
+		return "", domain.ErrMediaUnavailable
+	}
+	if upload == nil || upload.Body == nil {
+		return "", domain.ErrInvalidInput
+	}
+	if err := kind.Policy().Validate(upload.Size, upload.ContentType); err != nil {
+		return "", err
+	}
+	sniffed, body, err := blob.Sniff(upload.Body)
+	if err != nil {
+		return "", err
+	}
+	if sniffed != upload.ContentType {
+		return "", fmt.Errorf("%w: content is %s", blob.ErrUnsupportedType, sniffed)
+	}
+
+	key := kind.NewKey()
+	if err := s.media.Put(ctx, key, body, upload.Size, upload.ContentType); err != nil {
+		return "", fmt.Errorf("failed to store media: %w", err)
+	}
+	return key, nil
+}
+
+func (s *service) attachAvatar(ctx context.Context, userID uuid.UUID, key string) (*domain.User, error) {
+	previous, err := s.repo.SetUserAvatar(ctx, userID, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set avatar: %w", err)
+	}
+	s.deleteMedia(ctx, previous)
+	return s.GetUserByID(ctx, userID)
+}
+
+func (s *service) attachOptionImage(ctx context.Context, pollID uuid.UUID, optionIndex int, key string) (*domain.Poll, error) {
+	previous, err := s.repo.SetOptionImage(ctx, pollID, optionIndex, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set option image: %w", err)
+	}
+	s.deleteMedia(ctx, previous)
+	return s.getPoll(ctx, pollID)
+}
+
+// deleteMedia removes an object that is no longer needed. Failures only
+// leave an orphan for the media_gc job to collect.
+func (s *service) deleteMedia(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+	if err := s.media.Delete(ctx, key); err != nil {
+		logging.For(ctx, s.logger).Warn("Failed to delete media", zap.Error(err), zap.String("key", key))
+	}
+}
+
+func (s *service) mediaURL(key string) string {
+	if key == "" || s.media == nil {
+		return ""
+	}
+	url, err := s.media.SignedURL(http.MethodGet, key, s.mediaURLTTL)
+	if err != nil {
+		s.logger.Warn("Failed to sign media URL", zap.Error(err), zap.String("key", key))
+		return ""
+	}
+	return url
+}
+
+func (s *service) attachMediaURLs(poll *domain.Poll) {
+	for i := range poll.Options {
+		poll.Options[i].ImageURL = s.mediaURL(poll.Options[i].ImageKey)
+	}
+}
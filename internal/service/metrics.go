@@ -0,0 +1,537 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/metrics"
+	"github.com/google/uuid"
+)
+
+// errorLabel names err in the error metrics by its code in
+// domain.ErrorKinds, the same as the API reports it.
+func errorLabel(err error) string {
+	if kind, ok := domain.ClassifyError(err); ok {
+		return kind.Code
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return "canceled"
+	}
+	return "internal"
+}
+
+func observe(method string, start time.Time, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+		metrics.ServiceErrors.WithLabelValues(method, errorLabel(err)).Inc()
+	}
+	metrics.ServiceDuration.WithLabelValues(method, status).Observe(time.Since(start).Seconds())
+}
+
+type instrumentedService struct {
+	next Service
+}
+
+func NewInstrumentedService(next Service) Service {
+	return &instrumentedService{next: next}
+}
+
+func (s *instrumentedService) CreatePoll(ctx context.Context, req *domain.CreatePollRequest) (*domain.Poll, error) {
+	start := time.Now()
+	poll, err := s.next.CreatePoll(ctx, req)
+	observe("CreatePoll", start, err)
+	return poll, err
+}
+
+func (s *instrumentedService) ValidatePoll(ctx context.Context, req *domain.CreatePollRequest) ([]*domain.ValidationError, error) {
+	start := time.Now()
+	errs, err := s.next.ValidatePoll(ctx, req)
+	observe("ValidatePoll", start, err)
+	return errs, err
+}
+
+func (s *instrumentedService) GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Poll, error) {
+	start := time.Now()
+	poll, err := s.next.GetPollByID(ctx, id)
+	observe("GetPollByID", start, err)
+	return poll, err
+}
+
+func (s *instrumentedService) GetPollsForFeed(ctx context.Context, q domain.FeedQuery) (*domain.PollFeedResponse, error) {
+	start := time.Now()
+	resp, err := s.next.GetPollsForFeed(ctx, q)
+	observe("GetPollsForFeed", start, err)
+	return resp, err
+}
+
+func (s *instrumentedService) SearchPolls(ctx context.Context, q domain.PollSearchQuery) (*domain.PollSearchResult, error) {
+	start := time.Now()
+	result, err := s.next.SearchPolls(ctx, q)
+	observe("SearchPolls", start, err)
+	return result, err
+}
+
+func (s *instrumentedService) UpdateOption(ctx context.Context, pollID uuid.UUID, optionIndex int, req *domain.UpdateOptionRequest) (*domain.Poll, error) {
+	start := time.Now()
+	poll, err := s.next.UpdateOption(ctx, pollID, optionIndex, req)
+	observe("UpdateOption", start, err)
+	return poll, err
+}
+
+func (s *instrumentedService) SetOptionImage(ctx context.Context, pollID uuid.UUID, optionIndex int, actorID uuid.UUID, upload *domain.MediaUpload) (*domain.Poll, error) {
+	start := time.Now()
+	poll, err := s.next.SetOptionImage(ctx, pollID, optionIndex, actorID, upload)
+	observe("SetOptionImage", start, err)
+	return poll, err
+}
+
+func (s *instrumentedService) SetUserAvatar(ctx context.Context, userID uuid.UUID, upload *domain.MediaUpload) (*domain.User, error) {
+	start := time.Now()
+	user, err := s.next.SetUserAvatar(ctx, userID, upload)
+	observe("SetUserAvatar", start, err)
+	return user, err
+}
+
+func (s *instrumentedService) SignUpload(ctx context.Context, userID uuid.UUID, req *domain.SignUploadRequest) (*domain.SignedUpload, error) {
+	start := time.Now()
+	upload, err := s.next.SignUpload(ctx, userID, req)
+	observe("SignUpload", start, err)
+	return upload, err
+}
+
+func (s *instrumentedService) ConfirmUpload(ctx context.Context, userID uuid.UUID, req *domain.ConfirmUploadRequest) (*domain.UploadConfirmation, error) {
+	start := time.Now()
+	confirmation, err := s.next.ConfirmUpload(ctx, userID, req)
+	observe("ConfirmUpload", start, err)
+	return confirmation, err
+}
+
+func (s *instrumentedService) SetUserStanding(ctx context.Context, userID uuid.UUID, standing domain.UserStanding) error {
+	start := time.Now()
+	err := s.next.SetUserStanding(ctx, userID, standing)
+	observe("SetUserStanding", start, err)
+	return err
+}
+
+func (s *instrumentedService) SearchUsers(ctx context.Context, q *domain.UserSearchQuery) (*domain.UserList, error) {
+	start := time.Now()
+	users, err := s.next.SearchUsers(ctx, q)
+	observe("SearchUsers", start, err)
+	return users, err
+}
+
+func (s *instrumentedService) GetPlatformStats(ctx context.Context) (*domain.PlatformStats, error) {
+	start := time.Now()
+	stats, err := s.next.GetPlatformStats(ctx)
+	observe("GetPlatformStats", start, err)
+	return stats, err
+}
+
+func (s *instrumentedService) ForceDeletePoll(ctx context.Context, pollID, actorID uuid.UUID) error {
+	start := time.Now()
+	err := s.next.ForceDeletePoll(ctx, pollID, actorID)
+	observe("ForceDeletePoll", start, err)
+	return err
+}
+
+func (s *instrumentedService) GetConsentStatus(ctx context.Context, userID uuid.UUID) (*domain.ConsentStatus, error) {
+	start := time.Now()
+	status, err := s.next.GetConsentStatus(ctx, userID)
+	observe("GetConsentStatus", start, err)
+	return status, err
+}
+
+func (s *instrumentedService) AcceptConsents(ctx context.Context, req *domain.AcceptConsentRequest) (*domain.ConsentStatus, error) {
+	start := time.Now()
+	status, err := s.next.AcceptConsents(ctx, req)
+	observe("AcceptConsents", start, err)
+	return status, err
+}
+
+func (s *instrumentedService) GetPollStats(ctx context.Context, pollID uuid.UUID, q domain.StatsQuery) (*domain.PollStats, error) {
+	start := time.Now()
+	stats, err := s.next.GetPollStats(ctx, pollID, q)
+	observe("GetPollStats", start, err)
+	return stats, err
+}
+
+func (s *instrumentedService) GetPollWinner(ctx context.Context, pollID uuid.UUID) (*domain.PollWinner, error) {
+	start := time.Now()
+	winner, err := s.next.GetPollWinner(ctx, pollID)
+	observe("GetPollWinner", start, err)
+	return winner, err
+}
+
+func (s *instrumentedService) WaitPollStats(ctx context.Context, pollID, actorID uuid.UUID, since int64, timeout time.Duration) (*domain.StatsUpdate, error) {
+	start := time.Now()
+	update, err := s.next.WaitPollStats(ctx, pollID, actorID, since, timeout)
+	observe("WaitPollStats", start, err)
+	return update, err
+}
+
+func (s *instrumentedService) RecountPollStats(ctx context.Context, pollID uuid.UUID) (*domain.PollRecount, error) {
+	start := time.Now()
+	recount, err := s.next.RecountPollStats(ctx, pollID)
+	observe("RecountPollStats", start, err)
+	return recount, err
+}
+
+func (s *instrumentedService) GetPublicResults(ctx context.Context, pollID uuid.UUID) (*domain.PollResults, error) {
+	start := time.Now()
+	results, err := s.next.GetPublicResults(ctx, pollID)
+	observe("GetPublicResults", start, err)
+	return results, err
+}
+
+func (s *instrumentedService) GetPollPreview(ctx context.Context, pollID uuid.UUID) (*domain.PollPreview, error) {
+	start := time.Now()
+	preview, err := s.next.GetPollPreview(ctx, pollID)
+	observe("GetPollPreview", start, err)
+	return preview, err
+}
+
+func (s *instrumentedService) GetPollPreviewImage(ctx context.Context, pollID uuid.UUID) (*domain.PollPreviewImage, error) {
+	start := time.Now()
+	image, err := s.next.GetPollPreviewImage(ctx, pollID)
+	observe("GetPollPreviewImage", start, err)
+	return image, err
+}
+
+func (s *instrumentedService) UpdatePollTags(ctx context.Context, pollID uuid.UUID, req *domain.UpdatePollTagsRequest) (*domain.Poll, error) {
+	start := time.Now()
+	poll, err := s.next.UpdatePollTags(ctx, pollID, req)
+	observe("UpdatePollTags", start, err)
+	return poll, err
+}
+
+func (s *instrumentedService) ReorderOptions(ctx context.Context, pollID uuid.UUID, req *domain.ReorderOptionsRequest) (*domain.Poll, error) {
+	start := time.Now()
+	poll, err := s.next.ReorderOptions(ctx, pollID, req)
+	observe("ReorderOptions", start, err)
+	return poll, err
+}
+
+func (s *instrumentedService) UpdatePoll(ctx context.Context, pollID uuid.UUID, req *domain.UpdatePollRequest) (*domain.Poll, error) {
+	start := time.Now()
+	poll, err := s.next.UpdatePoll(ctx, pollID, req)
+	observe("UpdatePoll", start, err)
+	return poll, err
+}
+
+func (s *instrumentedService) ClosePoll(ctx context.Context, pollID, actorID uuid.UUID, admin bool) error {
+	start := time.Now()
+	err := s.next.ClosePoll(ctx, pollID, actorID, admin)
+	observe("ClosePoll", start, err)
+	return err
+}
+
+func (s *instrumentedService) ChangePollStatus(ctx context.Context, pollID uuid.UUID, req *domain.PollStatusRequest) (*domain.Poll, error) {
+	start := time.Now()
+	poll, err := s.next.ChangePollStatus(ctx, pollID, req)
+	observe("ChangePollStatus", start, err)
+	return poll, err
+}
+
+func (s *instrumentedService) GetPollOwnerStats(ctx context.Context, pollID, actorID uuid.UUID) (*domain.PollOwnerStats, error) {
+	start := time.Now()
+	stats, err := s.next.GetPollOwnerStats(ctx, pollID, actorID)
+	observe("GetPollOwnerStats", start, err)
+	return stats, err
+}
+
+func (s *instrumentedService) AddPollCollaborator(ctx context.Context, pollID uuid.UUID, req *domain.AddCollaboratorRequest) (*domain.Collaborator, error) {
+	start := time.Now()
+	collaborator, err := s.next.AddPollCollaborator(ctx, pollID, req)
+	observe("AddPollCollaborator", start, err)
+	return collaborator, err
+}
+
+func (s *instrumentedService) RemovePollCollaborator(ctx context.Context, pollID, userID, actorID uuid.UUID) error {
+	start := time.Now()
+	err := s.next.RemovePollCollaborator(ctx, pollID, userID, actorID)
+	observe("RemovePollCollaborator", start, err)
+	return err
+}
+
+func (s *instrumentedService) CreateTagAlias(ctx context.Context, req *domain.TagAliasRequest) (*domain.TagAlias, error) {
+	start := time.Now()
+	alias, err := s.next.CreateTagAlias(ctx, req)
+	observe("CreateTagAlias", start, err)
+	return alias, err
+}
+
+func (s *instrumentedService) GetTagAliases(ctx context.Context) ([]domain.TagAlias, error) {
+	start := time.Now()
+	aliases, err := s.next.GetTagAliases(ctx)
+	observe("GetTagAliases", start, err)
+	return aliases, err
+}
+
+func (s *instrumentedService) MergeTags(ctx context.Context, req *domain.MergeTagsRequest) (*domain.TagMergeResult, error) {
+	start := time.Now()
+	result, err := s.next.MergeTags(ctx, req)
+	observe("MergeTags", start, err)
+	return result, err
+}
+
+func (s *instrumentedService) GetTagRules(ctx context.Context) ([]domain.TagRule, error) {
+	start := time.Now()
+	rules, err := s.next.GetTagRules(ctx)
+	observe("GetTagRules", start, err)
+	return rules, err
+}
+
+func (s *instrumentedService) SetTagRule(ctx context.Context, tag string, req *domain.TagRuleRequest) (*domain.TagRule, error) {
+	start := time.Now()
+	rule, err := s.next.SetTagRule(ctx, tag, req)
+	observe("SetTagRule", start, err)
+	return rule, err
+}
+
+func (s *instrumentedService) DeleteTagRule(ctx context.Context, tag string) error {
+	start := time.Now()
+	err := s.next.DeleteTagRule(ctx, tag)
+	observe("DeleteTagRule", start, err)
+	return err
+}
+
+func (s *instrumentedService) GetModerationQueue(ctx context.Context, status domain.FlagStatus, page, limit int) (*domain.ModerationQueue, error) {
+	start := time.Now()
+	queue, err := s.next.GetModerationQueue(ctx, status, page, limit)
+	observe("GetModerationQueue", start, err)
+	return queue, err
+}
+
+func (s *instrumentedService) ResolveModerationFlag(ctx context.Context, flagID uuid.UUID, req *domain.ResolveFlagRequest) (*domain.ModerationFlag, error) {
+	start := time.Now()
+	flag, err := s.next.ResolveModerationFlag(ctx, flagID, req)
+	observe("ResolveModerationFlag", start, err)
+	return flag, err
+}
+
+func (s *instrumentedService) GetVoteAllowance(ctx context.Context, userID uuid.UUID) (*domain.VoteAllowance, error) {
+	start := time.Now()
+	allowance, err := s.next.GetVoteAllowance(ctx, userID)
+	observe("GetVoteAllowance", start, err)
+	return allowance, err
+}
+
+func (s *instrumentedService) VoteOnPoll(ctx context.Context, pollID uuid.UUID, req *domain.VoteRequest) (*domain.VoteReceipt, error) {
+	start := time.Now()
+	receipt, err := s.next.VoteOnPoll(ctx, pollID, req)
+	observe("VoteOnPoll", start, err)
+	return receipt, err
+}
+
+func (s *instrumentedService) UpdateVote(ctx context.Context, voteID uuid.UUID, req *domain.UpdateVoteRequest) error {
+	start := time.Now()
+	err := s.next.UpdateVote(ctx, voteID, req)
+	observe("UpdateVote", start, err)
+	return err
+}
+
+func (s *instrumentedService) DeleteVote(ctx context.Context, voteID uuid.UUID, userID uuid.UUID) error {
+	start := time.Now()
+	err := s.next.DeleteVote(ctx, voteID, userID)
+	observe("DeleteVote", start, err)
+	return err
+}
+
+func (s *instrumentedService) VerifyVoteReceipt(ctx context.Context, voteID, userID uuid.UUID, signature string) (*domain.VoteVerification, error) {
+	start := time.Now()
+	verification, err := s.next.VerifyVoteReceipt(ctx, voteID, userID, signature)
+	observe("VerifyVoteReceipt", start, err)
+	return verification, err
+}
+
+func (s *instrumentedService) SkipPoll(ctx context.Context, pollID uuid.UUID, req *domain.SkipRequest) error {
+	start := time.Now()
+	err := s.next.SkipPoll(ctx, pollID, req)
+	observe("SkipPoll", start, err)
+	return err
+}
+
+func (s *instrumentedService) GetUserVotes(ctx context.Context, userID uuid.UUID, filter domain.VoteFilter, page, limit int) (*domain.UserVotesResponse, error) {
+	start := time.Now()
+	resp, err := s.next.GetUserVotes(ctx, userID, filter, page, limit)
+	observe("GetUserVotes", start, err)
+	return resp, err
+}
+
+func (s *instrumentedService) ExportUserVotes(ctx context.Context, userID uuid.UUID, filter domain.VoteFilter, fn func(*domain.VoteResponse) error) error {
+	start := time.Now()
+	err := s.next.ExportUserVotes(ctx, userID, filter, fn)
+	observe("ExportUserVotes", start, err)
+	return err
+}
+
+func (s *instrumentedService) ExportPollVotes(ctx context.Context, pollID uuid.UUID, q domain.VoteExportQuery, fn func(*domain.ExportedVote) error) error {
+	start := time.Now()
+	err := s.next.ExportPollVotes(ctx, pollID, q, fn)
+	observe("ExportPollVotes", start, err)
+	return err
+}
+
+func (s *instrumentedService) GetTagVoteTrend(ctx context.Context, tag string, from, to time.Time) ([]domain.VoteBucket, error) {
+	start := time.Now()
+	buckets, err := s.next.GetTagVoteTrend(ctx, tag, from, to)
+	observe("GetTagVoteTrend", start, err)
+	return buckets, err
+}
+
+func (s *instrumentedService) GetPollVoteTimeline(ctx context.Context, pollID, actorID uuid.UUID, from, to time.Time) ([]domain.VoteBucket, error) {
+	start := time.Now()
+	buckets, err := s.next.GetPollVoteTimeline(ctx, pollID, actorID, from, to)
+	observe("GetPollVoteTimeline", start, err)
+	return buckets, err
+}
+
+func (s *instrumentedService) GetUserActivity(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]domain.UserActivity, error) {
+	start := time.Now()
+	activity, err := s.next.GetUserActivity(ctx, userID, from, to)
+	observe("GetUserActivity", start, err)
+	return activity, err
+}
+
+func (s *instrumentedService) CreateUser(ctx context.Context, user *domain.User) error {
+	start := time.Now()
+	err := s.next.CreateUser(ctx, user)
+	observe("CreateUser", start, err)
+	return err
+}
+
+func (s *instrumentedService) GetUserByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	start := time.Now()
+	user, err := s.next.GetUserByID(ctx, id)
+	observe("GetUserByID", start, err)
+	return user, err
+}
+
+func (s *instrumentedService) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	start := time.Now()
+	user, err := s.next.GetUserByEmail(ctx, email)
+	observe("GetUserByEmail", start, err)
+	return user, err
+}
+
+func (s *instrumentedService) UpdateUser(ctx context.Context, user *domain.User) error {
+	start := time.Now()
+	err := s.next.UpdateUser(ctx, user)
+	observe("UpdateUser", start, err)
+	return err
+}
+
+func (s *instrumentedService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	start := time.Now()
+	err := s.next.DeleteUser(ctx, id)
+	observe("DeleteUser", start, err)
+	return err
+}
+
+func (s *instrumentedService) RecordLogin(ctx context.Context, user *domain.User) error {
+	start := time.Now()
+	err := s.next.RecordLogin(ctx, user)
+	observe("RecordLogin", start, err)
+	return err
+}
+
+func (s *instrumentedService) VerifyEmail(ctx context.Context, token string) error {
+	start := time.Now()
+	err := s.next.VerifyEmail(ctx, token)
+	observe("VerifyEmail", start, err)
+	return err
+}
+
+func (s *instrumentedService) ResendEmailVerification(ctx context.Context, userID uuid.UUID) error {
+	start := time.Now()
+	err := s.next.ResendEmailVerification(ctx, userID)
+	observe("ResendEmailVerification", start, err)
+	return err
+}
+
+func (s *instrumentedService) RequestEmailChange(ctx context.Context, userID uuid.UUID, req *domain.EmailChangeRequest) (*domain.EmailChange, error) {
+	start := time.Now()
+	change, err := s.next.RequestEmailChange(ctx, userID, req)
+	observe("RequestEmailChange", start, err)
+	return change, err
+}
+
+func (s *instrumentedService) GetEmailChange(ctx context.Context, userID uuid.UUID) (*domain.EmailChange, error) {
+	start := time.Now()
+	change, err := s.next.GetEmailChange(ctx, userID)
+	observe("GetEmailChange", start, err)
+	return change, err
+}
+
+func (s *instrumentedService) ConfirmEmailChange(ctx context.Context, token string) (*domain.EmailChange, error) {
+	start := time.Now()
+	change, err := s.next.ConfirmEmailChange(ctx, token)
+	observe("ConfirmEmailChange", start, err)
+	return change, err
+}
+
+func (s *instrumentedService) CancelEmailChange(ctx context.Context, userID uuid.UUID) error {
+	start := time.Now()
+	err := s.next.CancelEmailChange(ctx, userID)
+	observe("CancelEmailChange", start, err)
+	return err
+}
+
+func (s *instrumentedService) CreateOrganization(ctx context.Context, req *domain.CreateOrganizationRequest) (*domain.Organization, error) {
+	start := time.Now()
+	org, err := s.next.CreateOrganization(ctx, req)
+	observe("CreateOrganization", start, err)
+	return org, err
+}
+
+func (s *instrumentedService) AddOrganizationMember(ctx context.Context, orgID uuid.UUID, req *domain.AddMemberRequest) error {
+	start := time.Now()
+	err := s.next.AddOrganizationMember(ctx, orgID, req)
+	observe("AddOrganizationMember", start, err)
+	return err
+}
+
+func (s *instrumentedService) CastOrganizationVote(ctx context.Context, pollID uuid.UUID, req *domain.OrganizationVoteRequest) (*domain.OrganizationVote, error) {
+	start := time.Now()
+	vote, err := s.next.CastOrganizationVote(ctx, pollID, req)
+	observe("CastOrganizationVote", start, err)
+	return vote, err
+}
+
+func (s *instrumentedService) GetResultCertificate(ctx context.Context, pollID uuid.UUID) (*domain.ResultCertificate, error) {
+	start := time.Now()
+	cert, err := s.next.GetResultCertificate(ctx, pollID)
+	observe("GetResultCertificate", start, err)
+	return cert, err
+}
+
+func (s *instrumentedService) GetResultSigningKey(ctx context.Context) (*domain.ResultSigningKey, error) {
+	start := time.Now()
+	key, err := s.next.GetResultSigningKey(ctx)
+	observe("GetResultSigningKey", start, err)
+	return key, err
+}
+
+func (s *instrumentedService) GetElectionCertification(ctx context.Context, pollID uuid.UUID) (*domain.ElectionCertification, error) {
+	start := time.Now()
+	cert, err := s.next.GetElectionCertification(ctx, pollID)
+	observe("GetElectionCertification", start, err)
+	return cert, err
+}
+
+func (s *instrumentedService) GetUserPreferences(ctx context.Context, userID uuid.UUID) (*domain.UserPreferences, error) {
+	start := time.Now()
+	prefs, err := s.next.GetUserPreferences(ctx, userID)
+	observe("GetUserPreferences", start, err)
+	return prefs, err
+}
+
+func (s *instrumentedService) UpdateUserPreferences(ctx context.Context, userID uuid.UUID, prefs *domain.UserPreferences) (*domain.UserPreferences, error) {
+	start := time.Now()
+	updated, err := s.next.UpdateUserPreferences(ctx, userID, prefs)
+	observe("UpdateUserPreferences", start, err)
+	return updated, err
+}
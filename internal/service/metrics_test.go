@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorLabel(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"AlreadyVoted", domain.ErrAlreadyVoted, "already_voted"},
+		{"DailyVoteLimitExceeded", domain.ErrDailyVoteLimitExceeded, "daily_vote_limit"},
+		{"QuotaExceeded", &domain.QuotaExceededError{}, "quota_exceeded"},
+		{"ConsentRequired", domain.ErrConsentRequired, "consent_required"},
+		{"TagRestricted", &domain.TagRestrictedError{Tag: "elections"}, "tag_restricted"},
+		{"WrappedNotFound", fmt.Errorf("get poll: %w", domain.ErrNotFound), "not_found"},
+		{"Canceled", context.Canceled, "canceled"},
+		{"Unknown", errors.New("boom"), "internal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, errorLabel(tt.err))
+		})
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/behzadon/vote/internal/domain"
 	"github.com/google/uuid"
@@ -43,9 +44,64 @@ func (m *MockService) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	return args.Error(0)
 }
 
-func (m *MockService) CreatePoll(ctx context.Context, req *domain.CreatePollRequest) (uuid.UUID, error) {
+func (m *MockService) RecordLogin(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockService) VerifyEmail(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockService) ResendEmailVerification(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockService) RequestEmailChange(ctx context.Context, userID uuid.UUID, req *domain.EmailChangeRequest) (*domain.EmailChange, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.EmailChange), args.Error(1)
+}
+
+func (m *MockService) GetEmailChange(ctx context.Context, userID uuid.UUID) (*domain.EmailChange, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.EmailChange), args.Error(1)
+}
+
+func (m *MockService) ConfirmEmailChange(ctx context.Context, token string) (*domain.EmailChange, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.EmailChange), args.Error(1)
+}
+
+func (m *MockService) CancelEmailChange(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockService) CreatePoll(ctx context.Context, req *domain.CreatePollRequest) (*domain.Poll, error) {
 	args := m.Called(ctx, req)
-	return args.Get(0).(uuid.UUID), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Poll), args.Error(1)
+}
+
+func (m *MockService) ValidatePoll(ctx context.Context, req *domain.CreatePollRequest) ([]*domain.ValidationError, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ValidationError), args.Error(1)
 }
 
 func (m *MockService) GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Poll, error) {
@@ -56,22 +112,342 @@ func (m *MockService) GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Po
 	return args.Get(0).(*domain.Poll), args.Error(1)
 }
 
-func (m *MockService) GetPollsForFeed(ctx context.Context, userID uuid.UUID, tag string, page, limit int) (*domain.PollFeedResponse, error) {
-	args := m.Called(ctx, userID, tag, page, limit)
+func (m *MockService) GetPollsForFeed(ctx context.Context, q domain.FeedQuery) (*domain.PollFeedResponse, error) {
+	args := m.Called(ctx, q)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*domain.PollFeedResponse), args.Error(1)
 }
 
-func (m *MockService) GetPollStats(ctx context.Context, pollID uuid.UUID) (*domain.PollStats, error) {
+func (m *MockService) SearchPolls(ctx context.Context, q domain.PollSearchQuery) (*domain.PollSearchResult, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PollSearchResult), args.Error(1)
+}
+
+func (m *MockService) SetOptionImage(ctx context.Context, pollID uuid.UUID, optionIndex int, actorID uuid.UUID, upload *domain.MediaUpload) (*domain.Poll, error) {
+	args := m.Called(ctx, pollID, optionIndex, actorID, upload)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Poll), args.Error(1)
+}
+
+func (m *MockService) UpdateOption(ctx context.Context, pollID uuid.UUID, optionIndex int, req *domain.UpdateOptionRequest) (*domain.Poll, error) {
+	args := m.Called(ctx, pollID, optionIndex, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Poll), args.Error(1)
+}
+
+func (m *MockService) SetUserAvatar(ctx context.Context, userID uuid.UUID, upload *domain.MediaUpload) (*domain.User, error) {
+	args := m.Called(ctx, userID, upload)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockService) SignUpload(ctx context.Context, userID uuid.UUID, req *domain.SignUploadRequest) (*domain.SignedUpload, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SignedUpload), args.Error(1)
+}
+
+func (m *MockService) ConfirmUpload(ctx context.Context, userID uuid.UUID, req *domain.ConfirmUploadRequest) (*domain.UploadConfirmation, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UploadConfirmation), args.Error(1)
+}
+
+func (m *MockService) SetUserStanding(ctx context.Context, userID uuid.UUID, standing domain.UserStanding) error {
+	args := m.Called(ctx, userID, standing)
+	return args.Error(0)
+}
+
+func (m *MockService) SearchUsers(ctx context.Context, q *domain.UserSearchQuery) (*domain.UserList, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserList), args.Error(1)
+}
+
+func (m *MockService) GetPlatformStats(ctx context.Context) (*domain.PlatformStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PlatformStats), args.Error(1)
+}
+
+func (m *MockService) ForceDeletePoll(ctx context.Context, pollID, actorID uuid.UUID) error {
+	args := m.Called(ctx, pollID, actorID)
+	return args.Error(0)
+}
+
+func (m *MockService) GetConsentStatus(ctx context.Context, userID uuid.UUID) (*domain.ConsentStatus, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ConsentStatus), args.Error(1)
+}
+
+func (m *MockService) AcceptConsents(ctx context.Context, req *domain.AcceptConsentRequest) (*domain.ConsentStatus, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ConsentStatus), args.Error(1)
+}
+
+func (m *MockService) GetPollWinner(ctx context.Context, pollID uuid.UUID) (*domain.PollWinner, error) {
 	args := m.Called(ctx, pollID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
+	return args.Get(0).(*domain.PollWinner), args.Error(1)
+}
+
+func (m *MockService) WaitPollStats(ctx context.Context, pollID, actorID uuid.UUID, since int64, timeout time.Duration) (*domain.StatsUpdate, error) {
+	args := m.Called(ctx, pollID, actorID, since, timeout)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.StatsUpdate), args.Error(1)
+}
+
+func (m *MockService) GetPollStats(ctx context.Context, pollID uuid.UUID, q domain.StatsQuery) (*domain.PollStats, error) {
+	args := m.Called(ctx, pollID, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
 	return args.Get(0).(*domain.PollStats), args.Error(1)
 }
 
+func (m *MockService) UpdatePollTags(ctx context.Context, pollID uuid.UUID, req *domain.UpdatePollTagsRequest) (*domain.Poll, error) {
+	args := m.Called(ctx, pollID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Poll), args.Error(1)
+}
+
+func (m *MockService) ReorderOptions(ctx context.Context, pollID uuid.UUID, req *domain.ReorderOptionsRequest) (*domain.Poll, error) {
+	args := m.Called(ctx, pollID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Poll), args.Error(1)
+}
+
+func (m *MockService) UpdatePoll(ctx context.Context, pollID uuid.UUID, req *domain.UpdatePollRequest) (*domain.Poll, error) {
+	args := m.Called(ctx, pollID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Poll), args.Error(1)
+}
+
+func (m *MockService) ClosePoll(ctx context.Context, pollID, actorID uuid.UUID, admin bool) error {
+	args := m.Called(ctx, pollID, actorID, admin)
+	return args.Error(0)
+}
+
+func (m *MockService) ChangePollStatus(ctx context.Context, pollID uuid.UUID, req *domain.PollStatusRequest) (*domain.Poll, error) {
+	args := m.Called(ctx, pollID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Poll), args.Error(1)
+}
+
+func (m *MockService) GetPollOwnerStats(ctx context.Context, pollID, actorID uuid.UUID) (*domain.PollOwnerStats, error) {
+	args := m.Called(ctx, pollID, actorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PollOwnerStats), args.Error(1)
+}
+
+func (m *MockService) AddPollCollaborator(ctx context.Context, pollID uuid.UUID, req *domain.AddCollaboratorRequest) (*domain.Collaborator, error) {
+	args := m.Called(ctx, pollID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Collaborator), args.Error(1)
+}
+
+func (m *MockService) RemovePollCollaborator(ctx context.Context, pollID, userID, actorID uuid.UUID) error {
+	args := m.Called(ctx, pollID, userID, actorID)
+	return args.Error(0)
+}
+
+func (m *MockService) CreateTagAlias(ctx context.Context, req *domain.TagAliasRequest) (*domain.TagAlias, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TagAlias), args.Error(1)
+}
+
+func (m *MockService) GetTagAliases(ctx context.Context) ([]domain.TagAlias, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.TagAlias), args.Error(1)
+}
+
+func (m *MockService) MergeTags(ctx context.Context, req *domain.MergeTagsRequest) (*domain.TagMergeResult, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TagMergeResult), args.Error(1)
+}
+
+func (m *MockService) GetTagRules(ctx context.Context) ([]domain.TagRule, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.TagRule), args.Error(1)
+}
+
+func (m *MockService) SetTagRule(ctx context.Context, tag string, req *domain.TagRuleRequest) (*domain.TagRule, error) {
+	args := m.Called(ctx, tag, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TagRule), args.Error(1)
+}
+
+func (m *MockService) DeleteTagRule(ctx context.Context, tag string) error {
+	args := m.Called(ctx, tag)
+	return args.Error(0)
+}
+
+func (m *MockService) GetModerationQueue(ctx context.Context, status domain.FlagStatus, page, limit int) (*domain.ModerationQueue, error) {
+	args := m.Called(ctx, status, page, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ModerationQueue), args.Error(1)
+}
+
+func (m *MockService) ResolveModerationFlag(ctx context.Context, flagID uuid.UUID, req *domain.ResolveFlagRequest) (*domain.ModerationFlag, error) {
+	args := m.Called(ctx, flagID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ModerationFlag), args.Error(1)
+}
+
+func (m *MockService) GetVoteAllowance(ctx context.Context, userID uuid.UUID) (*domain.VoteAllowance, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.VoteAllowance), args.Error(1)
+}
+
+func (m *MockService) RecountPollStats(ctx context.Context, pollID uuid.UUID) (*domain.PollRecount, error) {
+	args := m.Called(ctx, pollID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PollRecount), args.Error(1)
+}
+
+func (m *MockService) GetPublicResults(ctx context.Context, pollID uuid.UUID) (*domain.PollResults, error) {
+	args := m.Called(ctx, pollID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PollResults), args.Error(1)
+}
+
+func (m *MockService) GetPollPreview(ctx context.Context, pollID uuid.UUID) (*domain.PollPreview, error) {
+	args := m.Called(ctx, pollID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PollPreview), args.Error(1)
+}
+
+func (m *MockService) GetPollPreviewImage(ctx context.Context, pollID uuid.UUID) (*domain.PollPreviewImage, error) {
+	args := m.Called(ctx, pollID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PollPreviewImage), args.Error(1)
+}
+
+func (m *MockService) CreateOrganization(ctx context.Context, req *domain.CreateOrganizationRequest) (*domain.Organization, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Organization), args.Error(1)
+}
+
+func (m *MockService) AddOrganizationMember(ctx context.Context, orgID uuid.UUID, req *domain.AddMemberRequest) error {
+	args := m.Called(ctx, orgID, req)
+	return args.Error(0)
+}
+
+func (m *MockService) CastOrganizationVote(ctx context.Context, pollID uuid.UUID, req *domain.OrganizationVoteRequest) (*domain.OrganizationVote, error) {
+	args := m.Called(ctx, pollID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OrganizationVote), args.Error(1)
+}
+
+func (m *MockService) GetResultCertificate(ctx context.Context, pollID uuid.UUID) (*domain.ResultCertificate, error) {
+	args := m.Called(ctx, pollID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ResultCertificate), args.Error(1)
+}
+
+func (m *MockService) GetResultSigningKey(ctx context.Context) (*domain.ResultSigningKey, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ResultSigningKey), args.Error(1)
+}
+
+func (m *MockService) GetElectionCertification(ctx context.Context, pollID uuid.UUID) (*domain.ElectionCertification, error) {
+	args := m.Called(ctx, pollID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ElectionCertification), args.Error(1)
+}
+
+func (m *MockService) GetUserPreferences(ctx context.Context, userID uuid.UUID) (*domain.UserPreferences, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserPreferences), args.Error(1)
+}
+
+func (m *MockService) UpdateUserPreferences(ctx context.Context, userID uuid.UUID, prefs *domain.UserPreferences) (*domain.UserPreferences, error) {
+	args := m.Called(ctx, userID, prefs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserPreferences), args.Error(1)
+}
+
 func (m *MockService) Vote(ctx context.Context, pollID, userID uuid.UUID, optionIndex int) error {
 	args := m.Called(ctx, pollID, userID, optionIndex)
 	return args.Error(0)
@@ -87,9 +463,12 @@ func (m *MockService) SkipPoll(ctx context.Context, pollID uuid.UUID, req *domai
 	return args.Error(0)
 }
 
-func (m *MockService) VoteOnPoll(ctx context.Context, pollID uuid.UUID, req *domain.VoteRequest) error {
+func (m *MockService) VoteOnPoll(ctx context.Context, pollID uuid.UUID, req *domain.VoteRequest) (*domain.VoteReceipt, error) {
 	args := m.Called(ctx, pollID, req)
-	return args.Error(0)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.VoteReceipt), args.Error(1)
 }
 
 func (m *MockService) DeleteVote(ctx context.Context, voteID uuid.UUID, userID uuid.UUID) error {
@@ -97,14 +476,56 @@ func (m *MockService) DeleteVote(ctx context.Context, voteID uuid.UUID, userID u
 	return args.Error(0)
 }
 
-func (m *MockService) GetUserVotes(ctx context.Context, userID uuid.UUID, page, limit int) (*domain.UserVotesResponse, error) {
-	args := m.Called(ctx, userID, page, limit)
+func (m *MockService) VerifyVoteReceipt(ctx context.Context, voteID, userID uuid.UUID, signature string) (*domain.VoteVerification, error) {
+	args := m.Called(ctx, voteID, userID, signature)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.VoteVerification), args.Error(1)
+}
+
+func (m *MockService) GetUserVotes(ctx context.Context, userID uuid.UUID, filter domain.VoteFilter, page, limit int) (*domain.UserVotesResponse, error) {
+	args := m.Called(ctx, userID, filter, page, limit)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*domain.UserVotesResponse), args.Error(1)
 }
 
+func (m *MockService) ExportUserVotes(ctx context.Context, userID uuid.UUID, filter domain.VoteFilter, fn func(*domain.VoteResponse) error) error {
+	args := m.Called(ctx, userID, filter, fn)
+	return args.Error(0)
+}
+
+func (m *MockService) ExportPollVotes(ctx context.Context, pollID uuid.UUID, q domain.VoteExportQuery, fn func(*domain.ExportedVote) error) error {
+	args := m.Called(ctx, pollID, q, fn)
+	return args.Error(0)
+}
+
+func (m *MockService) GetTagVoteTrend(ctx context.Context, tag string, from, to time.Time) ([]domain.VoteBucket, error) {
+	args := m.Called(ctx, tag, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.VoteBucket), args.Error(1)
+}
+
+func (m *MockService) GetPollVoteTimeline(ctx context.Context, pollID, actorID uuid.UUID, from, to time.Time) ([]domain.VoteBucket, error) {
+	args := m.Called(ctx, pollID, actorID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.VoteBucket), args.Error(1)
+}
+
+func (m *MockService) GetUserActivity(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]domain.UserActivity, error) {
+	args := m.Called(ctx, userID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.UserActivity), args.Error(1)
+}
+
 func (m *MockService) UpdateVote(ctx context.Context, voteID uuid.UUID, req *domain.UpdateVoteRequest) error {
 	args := m.Called(ctx, voteID, req)
 	return args.Error(0)
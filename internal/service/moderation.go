@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/metrics"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// WithContentScorer scores poll titles and options as they are written and
+// queues anything scoring at or above threshold for moderation.
+func WithContentScorer(scorer domain.ContentScorer, threshold float64) Option {
+	return func(s *service) {
+		s.scorer = scorer
+		s.flagThreshold = threshold
+	}
+}
+
+func pollContent(poll *domain.Poll) []domain.Content {
+	contents := make([]domain.Content, 0, len(poll.Options)+1)
+	contents = append(contents, domain.Content{
+		Kind:     domain.ContentPollTitle,
+		Text:     poll.Title,
+		PollID:   &poll.ID,
+		AuthorID: poll.CreatedBy,
+	})
+	for _, option := range poll.Options {
+		contents = append(contents, domain.Content{
+			Kind:     domain.ContentPollOption,
+			Text:     option.OptionText,
+			PollID:   &poll.ID,
+			AuthorID: poll.CreatedBy,
+		})
+	}
+	return contents
+}
+
+// flagContent queues the contents scoring at or above the threshold. It
+// runs after the content is saved and never fails the write: if the scorer
+// errors, the remaining contents are left unscored rather than waiting on a
+// backend that is down.
+func (s *service) flagContent(ctx context.Context, contents ...domain.Content) {
+	if s.scorer == nil {
+		return
+	}
+	for _, content := range contents {
+		score, err := s.scorer.Score(ctx, content)
+		if err != nil {
+			logging.For(ctx, s.logger).Warn("Failed to score content", zap.Error(err), zap.String("kind", string(content.Kind)))
+			return
+		}
+		if score.Score < s.flagThreshold {
+			continue
+		}
+
+		flag := &domain.ModerationFlag{
+			ID:        uuid.New(),
+			Kind:      content.Kind,
+			Content:   content.Text,
+			PollID:    content.PollID,
+			AuthorID:  content.AuthorID,
+			Score:     score.Score,
+			Reasons:   score.Reasons,
+			Status:    domain.FlagOpen,
+			CreatedAt: time.Now().UTC(),
+		}
+		if flag.Reasons == nil {
+			flag.Reasons = []string{}
+		}
+		if err := s.repo.CreateModerationFlag(ctx, flag); err != nil {
+			logging.For(ctx, s.logger).Error("Failed to flag content", zap.Error(err), zap.String("kind", string(content.Kind)))
+			continue
+		}
+		metrics.ContentFlagged.WithLabelValues(string(content.Kind)).Inc()
+	}
+}
+
+// offensiveSkipsToFlag is how many voters have to skip a poll as offensive
+// before it is queued for moderation.
+const offensiveSkipsToFlag = 3
+
+// flagOffensiveSkips queues the poll's title for moderation once enough
+// voters have skipped it as offensive. Like flagContent, it never fails the
+// skip.
+func (s *service) flagOffensiveSkips(ctx context.Context, pollID uuid.UUID) {
+	reasons, err := s.repo.CountSkipReasons(ctx, pollID)
+	if err != nil {
+		logging.For(ctx, s.logger).Warn("Failed to count skip reasons", zap.Error(err), zap.String("poll_id", pollID.String()))
+		return
+	}
+	// Only the skip that reaches the threshold flags the poll, so it is
+	// queued once rather than on every further skip.
+	if reasons[domain.SkipOffensive] != offensiveSkipsToFlag {
+		return
+	}
+	poll, err := s.repo.GetPollByID(ctx, pollID)
+	if err != nil {
+		logging.For(ctx, s.logger).Warn("Failed to get skipped poll", zap.Error(err), zap.String("poll_id", pollID.String()))
+		return
+	}
+
+	flag := &domain.ModerationFlag{
+		ID:        uuid.New(),
+		Kind:      domain.ContentPollTitle,
+		Content:   poll.Title,
+		PollID:    &poll.ID,
+		AuthorID:  poll.CreatedBy,
+		Score:     1,
+		Reasons:   []string{"skipped_as_offensive"},
+		Status:    domain.FlagOpen,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.repo.CreateModerationFlag(ctx, flag); err != nil {
+		logging.For(ctx, s.logger).Error("Failed to flag content", zap.Error(err), zap.String("poll_id", pollID.String()))
+		return
+	}
+	metrics.ContentFlagged.WithLabelValues(string(flag.Kind)).Inc()
+}
+
+func (s *service) GetModerationQueue(ctx context.Context, status domain.FlagStatus, page, limit int) (*domain.ModerationQueue, error) {
+	if status == "" {
+		status = domain.FlagOpen
+	}
+	if !status.Valid() {
+		return nil, domain.ErrInvalidInput
+	}
+	if page < 1 {
+		page = domain.DefaultPage
+	}
+	if limit < 1 || limit > domain.MaxPageSize {
+		limit = domain.DefaultLimit
+	}
+
+	flags, total, err := s.repo.GetModerationFlags(ctx, status, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	if flags == nil {
+		flags = []domain.ModerationFlag{}
+	}
+	return &domain.ModerationQueue{
+		Flags: flags,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	}, nil
+}
+
+// ResolveModerationFlag records a moderator's decision on an open flag.
+// Upholding a flag does not act on the content itself. Dismissing a
+// poll_review flag approves the poll, which is published right away.
+func (s *service) ResolveModerationFlag(ctx context.Context, flagID uuid.UUID, req *domain.ResolveFlagRequest) (*domain.ModerationFlag, error) {
+	if req == nil || (req.Status != domain.FlagDismissed && req.Status != domain.FlagUpheld) {
+		return nil, domain.ErrInvalidInput
+	}
+	flag, err := s.repo.ResolveModerationFlag(ctx, flagID, req.Status, req.ActorID, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if flag.Kind == domain.ContentPollReview && flag.Status == domain.FlagDismissed && flag.PollID != nil {
+		s.publishReviewedPoll(ctx, *flag.PollID, req.ActorID)
+	}
+	return flag, nil
+}
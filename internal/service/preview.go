@@ -0,0 +1,272 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/storage/blob"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Preview cards are 1200x630, the size Slack, Twitter and Facebook crop
+// previews to.
+const (
+	previewImageWidth  = 1200
+	previewImageHeight = 630
+	previewMaxBars     = 6
+
+	defaultCardRefreshShare = 0.02
+)
+
+var (
+	previewBackground = color.RGBA{R: 0x1f, G: 0x29, B: 0x37, A: 0xff}
+	previewAccent     = color.RGBA{R: 0x63, G: 0x66, B: 0xf1, A: 0xff}
+	previewTrack      = color.RGBA{R: 0x37, G: 0x41, B: 0x51, A: 0xff}
+)
+
+// WithPreviewCards sets how far an option's share of the votes has to move,
+// as a fraction of the total, before a stored preview card is re-rendered.
+func WithPreviewCards(refreshShare float64) Option {
+	return func(s *service) {
+		if refreshShare > 0 {
+			s.cardRefreshShare = refreshShare
+		}
+	}
+}
+
+// GetPollPreview describes a poll for link unfurls, which are fetched
+// without authentication, so the breakdown of votes follows what anonymous
+// visitors may see. Polls that are not open to everyone, drafts,
+// deleted polls and polls by shadow-banned users are domain.ErrNotFound.
+func (s *service) GetPollPreview(ctx context.Context, pollID uuid.UUID) (*domain.PollPreview, error) {
+	poll, err := s.repo.GetPollByID(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requirePreviewable(ctx, poll); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.pollStats(ctx, poll, domain.StatsQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	preview := &domain.PollPreview{
+		PollID:      poll.ID,
+		Title:       poll.Title,
+		Status:      poll.StatusAt(now),
+		OptionCount: len(poll.Options),
+		TotalVotes:  stats.TotalVotes,
+	}
+	if poll.PublicResults && s.requireResultsVisible(ctx, poll, uuid.Nil) == nil {
+		preview.Votes = stats.Votes
+	}
+	preview.Description = previewDescription(poll, preview.TotalVotes, now)
+	return preview, nil
+}
+
+// GetPollPreviewImage returns the poll's preview card. With a media store
+// the card is rendered once, kept there and only drawn again after a
+// significant change in the votes; without one it is rendered every time.
+func (s *service) GetPollPreviewImage(ctx context.Context, pollID uuid.UUID) (*domain.PollPreviewImage, error) {
+	preview, err := s.GetPollPreview(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if s.media == nil {
+		data, err := renderPollCard(preview)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.PollPreviewImage{PNG: data}, nil
+	}
+
+	card, err := s.repo.GetPollPreviewCard(ctx, pollID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+	if card == nil || s.cardStale(card, preview) {
+		data, err := renderPollCard(preview)
+		if err != nil {
+			return nil, err
+		}
+		card, err = s.storePollCard(ctx, preview, data)
+		if err != nil {
+			logging.For(ctx, s.logger).Warn("Failed to store poll preview card",
+				zap.Error(err),
+				zap.String("poll_id", pollID.String()),
+			)
+			return &domain.PollPreviewImage{PNG: data}, nil
+		}
+	}
+
+	url, err := s.media.SignedURL(http.MethodGet, card.ImageKey, s.mediaURLTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign preview card: %w", err)
+	}
+	return &domain.PollPreviewImage{URL: url}, nil
+}
+
+// storePollCard uploads a rendered card under a new key and points the poll
+// at it. The card it replaces is left for the media_gc job.
+func (s *service) storePollCard(ctx context.Context, preview *domain.PollPreview, data []byte) (*domain.PollPreviewCard, error) {
+	card := &domain.PollPreviewCard{
+		PollID:      preview.PollID,
+		ImageKey:    blob.KindPollCard.NewKey(),
+		Counts:      previewCounts(preview),
+		OptionCount: preview.OptionCount,
+		RenderedAt:  time.Now().UTC(),
+	}
+	if err := s.media.Put(ctx, card.ImageKey, bytes.NewReader(data), int64(len(data)), "image/png"); err != nil {
+		return nil, fmt.Errorf("failed to store preview card: %w", err)
+	}
+	if err := s.repo.SetPollPreviewCard(ctx, card); err != nil {
+		s.deleteMedia(ctx, card.ImageKey)
+		return nil, fmt.Errorf("failed to set preview card: %w", err)
+	}
+	return card, nil
+}
+
+// cardStale reports whether card no longer matches preview: the options or
+// the visibility of results changed, or some option's share of the votes
+// moved by at least cardRefreshShare.
+func (s *service) cardStale(card *domain.PollPreviewCard, preview *domain.PollPreview) bool {
+	counts := previewCounts(preview)
+	if card.OptionCount != preview.OptionCount || len(card.Counts) != len(counts) {
+		return true
+	}
+	before, after := voteShares(card.Counts), voteShares(counts)
+	for i := range after {
+		if math.Abs(after[i]-before[i]) >= s.cardRefreshShare {
+			return true
+		}
+	}
+	return false
+}
+
+func previewCounts(preview *domain.PollPreview) []int {
+	if preview.Votes == nil {
+		return nil
+	}
+	counts := make([]int, len(preview.Votes))
+	for i, option := range preview.Votes {
+		counts[i] = option.Count
+	}
+	return counts
+}
+
+func voteShares(counts []int) []float64 {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	shares := make([]float64, len(counts))
+	if total == 0 {
+		return shares
+	}
+	for i, count := range counts {
+		shares[i] = float64(count) / float64(total)
+	}
+	return shares
+}
+
+// renderPollCard draws one bar per option, filled by its share of the votes
+// when results are public and left empty otherwise so the card gives
+// nothing away.
+func renderPollCard(preview *domain.PollPreview) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, previewImageWidth, previewImageHeight))
+	fill := func(r image.Rectangle, c color.Color) {
+		draw.Draw(img, r, &image.Uniform{C: c}, image.Point{}, draw.Src)
+	}
+	fill(img.Bounds(), previewBackground)
+	fill(image.Rect(0, 0, previewImageWidth, 24), previewAccent)
+
+	bars := min(preview.OptionCount, previewMaxBars)
+	shares := voteShares(previewCounts(preview))
+	if bars > 0 {
+		const margin, gap = 80, 24
+		left, right := margin, previewImageWidth-margin
+		top := 24 + margin
+		height := (previewImageHeight - top - margin - gap*(bars-1)) / bars
+		for i := 0; i < bars; i++ {
+			y := top + i*(height+gap)
+			fill(image.Rect(left, y, right, y+height), previewTrack)
+			if i < len(shares) {
+				if width := int(shares[i] * float64(right-left)); width > 0 {
+					fill(image.Rect(left, y, left+width, y+height), previewAccent)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encode preview card: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *service) requirePreviewable(ctx context.Context, poll *domain.Poll) error {
+	if poll.Protected || poll.Electorate.Restricted() {
+		return domain.ErrNotFound
+	}
+	switch poll.Status {
+	case domain.PollStatusDraft, domain.PollStatusDeleted:
+		return domain.ErrNotFound
+	}
+	if poll.CreatedBy == nil {
+		return nil
+	}
+	creator, err := s.repo.GetUserByID(ctx, *poll.CreatedBy)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if creator.Standing == domain.StandingShadowBanned {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func previewDescription(poll *domain.Poll, votes int, now time.Time) string {
+	counted := pluralize(votes, "vote")
+	options := pluralize(len(poll.Options), "option")
+	switch poll.StatusAt(now) {
+	case domain.PollStatusScheduled:
+		return fmt.Sprintf("Poll with %s, opening soon.", options)
+	case domain.PollStatusClosed, domain.PollStatusArchived:
+		return fmt.Sprintf("Final results: %s across %s.", counted, options)
+	default:
+		return fmt.Sprintf("%s so far across %s. Cast your vote.", capitalize(counted), options)
+	}
+}
+
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func capitalize(s string) string {
+	if s == "" || s[0] < 'a' || s[0] > 'z' {
+		return s
+	}
+	return string(s[0]-'a'+'A') + s[1:]
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+)
+
+// WithReceiptSigning signs the receipts of new votes with key, so voters
+// can later check their vote was recorded as cast. Without a key receipts
+// go out unsigned and cannot be verified.
+func WithReceiptSigning(key string) Option {
+	return func(s *service) {
+		if key != "" {
+			s.receiptKey = []byte(key)
+		}
+	}
+}
+
+func (s *service) signReceipt(receipt *domain.VoteReceipt) {
+	if len(s.receiptKey) == 0 {
+		return
+	}
+	receipt.Signature = hex.EncodeToString(s.receiptMAC(receipt.VoteID, receipt.PollID, receipt.OptionID, receipt.CreatedAt))
+}
+
+func (s *service) receiptMAC(voteID, pollID, optionID uuid.UUID, createdAt time.Time) []byte {
+	mac := hmac.New(sha256.New, s.receiptKey)
+	mac.Write(domain.ReceiptPayload(voteID, pollID, optionID, createdAt))
+	return mac.Sum(nil)
+}
+
+// VerifyVoteReceipt checks signature, taken from a receipt of userID's,
+// against the vote as it is recorded now. Votes of other users are
+// reported as not found. The recorded vote is returned unsigned; signing it
+// would hand a valid receipt to anyone who sends a forged one.
+func (s *service) VerifyVoteReceipt(ctx context.Context, voteID, userID uuid.UUID, signature string) (*domain.VoteVerification, error) {
+	if len(s.receiptKey) == 0 {
+		return nil, domain.ErrReceiptsUnavailable
+	}
+	vote, err := s.repo.GetVoteByID(ctx, voteID)
+	if err != nil {
+		return nil, err
+	}
+	if vote.UserID != userID {
+		return nil, domain.ErrNotFound
+	}
+	poll, err := s.repo.GetPollByID(ctx, vote.PollID)
+	if err != nil {
+		return nil, err
+	}
+	vote.PollTitle = poll.Title
+	for _, option := range poll.Options {
+		if option.ID == vote.OptionID {
+			vote.OptionIndex = option.OptionIndex
+			vote.OptionText = option.OptionText
+			break
+		}
+	}
+
+	given, err := hex.DecodeString(signature)
+	verification := &domain.VoteVerification{
+		VoteID:   vote.ID,
+		Valid:    err == nil && hmac.Equal(given, s.receiptMAC(vote.ID, vote.PollID, vote.OptionID, vote.CreatedAt)),
+		Recorded: vote.Receipt(),
+	}
+	return verification, nil
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/results"
+	"github.com/google/uuid"
+)
+
+// requireResultsVisible applies the poll's results visibility to viewerID,
+// which is uuid.Nil for anonymous requests. Results of closed polls are
+// visible to everyone.
+func (s *service) requireResultsVisible(ctx context.Context, poll *domain.Poll, viewerID uuid.UUID) error {
+	if poll.ResultsVisibility == "" || poll.ResultsVisibility == domain.ResultsAlways || poll.IsFinal(time.Now().UTC()) {
+		return nil
+	}
+	if viewerID == uuid.Nil {
+		return domain.ErrResultsHidden
+	}
+
+	err := s.requirePollPermission(ctx, poll, viewerID, domain.CollaboratorStats)
+	if !errors.Is(err, domain.ErrForbidden) {
+		return err
+	}
+	if poll.ResultsVisibility == domain.ResultsAfterVote {
+		voted, err := s.repo.HasVoted(ctx, poll.ID, viewerID)
+		if err != nil {
+			return fmt.Errorf("failed to check vote: %w", err)
+		}
+		if voted {
+			return nil
+		}
+	}
+	return domain.ErrResultsHidden
+}
+
+// finalPollStats serves a closed poll's stats from its result snapshot,
+// taking the snapshot first if the poll closed without one.
+func (s *service) finalPollStats(ctx context.Context, poll *domain.Poll) (*domain.PollStats, error) {
+	if cached, err := s.repo.GetCachedPollStats(ctx, poll.ID); err == nil && cached.Results != nil {
+		return cached, nil
+	}
+
+	snapshot, err := s.repo.GetPollResultSnapshot(ctx, poll.ID)
+	if errors.Is(err, domain.ErrNotFound) {
+		snapshot, err = s.snapshotResults(ctx, poll)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stats := snapshot.Stats()
+	if err := s.fillOrganizationVotes(ctx, poll, stats); err != nil {
+		return nil, err
+	}
+	s.cachePollStats(ctx, stats)
+	return stats, nil
+}
+
+// WithTieBreak sets how the winner of a poll with a tied lead is decided
+// when its results are snapshotted. Ties are reported by default.
+func WithTieBreak(policy domain.TieBreakPolicy, seed string) Option {
+	return func(s *service) {
+		s.tieBreak = policy
+		s.tieBreakSeed = seed
+	}
+}
+
+func (s *service) snapshotResults(ctx context.Context, poll *domain.Poll) (*domain.PollResultSnapshot, error) {
+	return results.NewSnapshotter(s.repo, s.tieBreak, s.tieBreakSeed, s.logger).Snapshot(ctx, poll)
+}
+
+// WithResultCertificates signs certificates of closed polls' results with a
+// key derived from signingKey. Without one no certificates are issued.
+func WithResultCertificates(signingKey string) Option {
+	return func(s *service) {
+		s.resultSigningKey = signingKey
+	}
+}
+
+// GetResultCertificate returns the signed results of a closed poll,
+// certifying them first if the poll closed without a certificate.
+func (s *service) GetResultCertificate(ctx context.Context, pollID uuid.UUID) (*domain.ResultCertificate, error) {
+	if s.resultSigningKey == "" {
+		return nil, domain.ErrCertificatesDisabled
+	}
+	poll, err := s.repo.GetPollByID(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if !poll.IsFinal(time.Now().UTC()) {
+		return nil, domain.ErrPollNotClosed
+	}
+
+	cert, err := s.repo.GetResultCertificate(ctx, pollID)
+	if !errors.Is(err, domain.ErrNotFound) {
+		return cert, err
+	}
+	snapshot, err := s.repo.GetPollResultSnapshot(ctx, pollID)
+	if errors.Is(err, domain.ErrNotFound) {
+		snapshot, err = s.snapshotResults(ctx, poll)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.resultCertifier().Certify(ctx, poll, snapshot)
+}
+
+func (s *service) GetResultSigningKey(ctx context.Context) (*domain.ResultSigningKey, error) {
+	if s.resultSigningKey == "" {
+		return nil, domain.ErrCertificatesDisabled
+	}
+	return s.resultCertifier().SigningKey(), nil
+}
+
+func (s *service) resultCertifier() *results.Certifier {
+	return results.NewCertifier(s.repo, s.resultSigningKey, s.logger)
+}
+
+// GetPollWinner returns the winner recorded when the poll closed.
+func (s *service) GetPollWinner(ctx context.Context, pollID uuid.UUID) (*domain.PollWinner, error) {
+	poll, err := s.repo.GetPollByID(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireDraftVisible(ctx, poll); err != nil {
+		return nil, err
+	}
+	if !poll.IsFinal(time.Now().UTC()) {
+		return nil, domain.ErrPollNotClosed
+	}
+
+	stats, err := s.finalPollStats(ctx, poll)
+	if err != nil {
+		return nil, err
+	}
+	return stats.Results.Outcome(), nil
+}
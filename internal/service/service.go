@@ -2,72 +2,287 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/behzadon/vote/internal/auth"
 	"github.com/behzadon/vote/internal/domain"
 	"github.com/behzadon/vote/internal/events"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/storage/blob"
+	"github.com/behzadon/vote/internal/validation"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type Service interface {
-	CreatePoll(ctx context.Context, req *domain.CreatePollRequest) (uuid.UUID, error)
+	CreatePoll(ctx context.Context, req *domain.CreatePollRequest) (*domain.Poll, error)
+	ValidatePoll(ctx context.Context, req *domain.CreatePollRequest) ([]*domain.ValidationError, error)
 	GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Poll, error)
-	GetPollsForFeed(ctx context.Context, userID uuid.UUID, tag string, page, limit int) (*domain.PollFeedResponse, error)
-	GetPollStats(ctx context.Context, pollID uuid.UUID) (*domain.PollStats, error)
+	GetPollsForFeed(ctx context.Context, q domain.FeedQuery) (*domain.PollFeedResponse, error)
+	SearchPolls(ctx context.Context, q domain.PollSearchQuery) (*domain.PollSearchResult, error)
+	GetPollStats(ctx context.Context, pollID uuid.UUID, q domain.StatsQuery) (*domain.PollStats, error)
+	RecountPollStats(ctx context.Context, pollID uuid.UUID) (*domain.PollRecount, error)
+	WaitPollStats(ctx context.Context, pollID, actorID uuid.UUID, since int64, timeout time.Duration) (*domain.StatsUpdate, error)
+	GetPublicResults(ctx context.Context, pollID uuid.UUID) (*domain.PollResults, error)
+	GetPollPreview(ctx context.Context, pollID uuid.UUID) (*domain.PollPreview, error)
+	GetPollPreviewImage(ctx context.Context, pollID uuid.UUID) (*domain.PollPreviewImage, error)
+	UpdatePoll(ctx context.Context, pollID uuid.UUID, req *domain.UpdatePollRequest) (*domain.Poll, error)
+	UpdatePollTags(ctx context.Context, pollID uuid.UUID, req *domain.UpdatePollTagsRequest) (*domain.Poll, error)
+	ReorderOptions(ctx context.Context, pollID uuid.UUID, req *domain.ReorderOptionsRequest) (*domain.Poll, error)
+	SetOptionImage(ctx context.Context, pollID uuid.UUID, optionIndex int, actorID uuid.UUID, upload *domain.MediaUpload) (*domain.Poll, error)
+	UpdateOption(ctx context.Context, pollID uuid.UUID, optionIndex int, req *domain.UpdateOptionRequest) (*domain.Poll, error)
+	SignUpload(ctx context.Context, userID uuid.UUID, req *domain.SignUploadRequest) (*domain.SignedUpload, error)
+	ConfirmUpload(ctx context.Context, userID uuid.UUID, req *domain.ConfirmUploadRequest) (*domain.UploadConfirmation, error)
+	ClosePoll(ctx context.Context, pollID, actorID uuid.UUID, admin bool) error
+	ChangePollStatus(ctx context.Context, pollID uuid.UUID, req *domain.PollStatusRequest) (*domain.Poll, error)
+	GetPollWinner(ctx context.Context, pollID uuid.UUID) (*domain.PollWinner, error)
+	GetPollOwnerStats(ctx context.Context, pollID, actorID uuid.UUID) (*domain.PollOwnerStats, error)
 
-	VoteOnPoll(ctx context.Context, pollID uuid.UUID, req *domain.VoteRequest) error
+	AddPollCollaborator(ctx context.Context, pollID uuid.UUID, req *domain.AddCollaboratorRequest) (*domain.Collaborator, error)
+	RemovePollCollaborator(ctx context.Context, pollID, userID, actorID uuid.UUID) error
+
+	VoteOnPoll(ctx context.Context, pollID uuid.UUID, req *domain.VoteRequest) (*domain.VoteReceipt, error)
 	UpdateVote(ctx context.Context, voteID uuid.UUID, req *domain.UpdateVoteRequest) error
 	DeleteVote(ctx context.Context, voteID uuid.UUID, userID uuid.UUID) error
+	VerifyVoteReceipt(ctx context.Context, voteID, userID uuid.UUID, signature string) (*domain.VoteVerification, error)
 	SkipPoll(ctx context.Context, pollID uuid.UUID, req *domain.SkipRequest) error
-	GetUserVotes(ctx context.Context, userID uuid.UUID, page, limit int) (*domain.UserVotesResponse, error)
+	GetUserVotes(ctx context.Context, userID uuid.UUID, filter domain.VoteFilter, page, limit int) (*domain.UserVotesResponse, error)
+	ExportUserVotes(ctx context.Context, userID uuid.UUID, filter domain.VoteFilter, fn func(*domain.VoteResponse) error) error
+	ExportPollVotes(ctx context.Context, pollID uuid.UUID, q domain.VoteExportQuery, fn func(*domain.ExportedVote) error) error
+	GetTagVoteTrend(ctx context.Context, tag string, from, to time.Time) ([]domain.VoteBucket, error)
+	GetPollVoteTimeline(ctx context.Context, pollID, actorID uuid.UUID, from, to time.Time) ([]domain.VoteBucket, error)
+	GetUserActivity(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]domain.UserActivity, error)
+	GetVoteAllowance(ctx context.Context, userID uuid.UUID) (*domain.VoteAllowance, error)
 
 	CreateUser(ctx context.Context, user *domain.User) error
 	GetUserByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	SetUserAvatar(ctx context.Context, userID uuid.UUID, upload *domain.MediaUpload) (*domain.User, error)
+	SetUserStanding(ctx context.Context, userID uuid.UUID, standing domain.UserStanding) error
+	SearchUsers(ctx context.Context, q *domain.UserSearchQuery) (*domain.UserList, error)
+	GetPlatformStats(ctx context.Context) (*domain.PlatformStats, error)
+	ForceDeletePoll(ctx context.Context, pollID, actorID uuid.UUID) error
+	GetConsentStatus(ctx context.Context, userID uuid.UUID) (*domain.ConsentStatus, error)
+	AcceptConsents(ctx context.Context, req *domain.AcceptConsentRequest) (*domain.ConsentStatus, error)
 	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
 	UpdateUser(ctx context.Context, user *domain.User) error
 	DeleteUser(ctx context.Context, id uuid.UUID) error
+	RecordLogin(ctx context.Context, user *domain.User) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendEmailVerification(ctx context.Context, userID uuid.UUID) error
+	RequestEmailChange(ctx context.Context, userID uuid.UUID, req *domain.EmailChangeRequest) (*domain.EmailChange, error)
+	GetEmailChange(ctx context.Context, userID uuid.UUID) (*domain.EmailChange, error)
+	ConfirmEmailChange(ctx context.Context, token string) (*domain.EmailChange, error)
+	CancelEmailChange(ctx context.Context, userID uuid.UUID) error
+
+	CreateOrganization(ctx context.Context, req *domain.CreateOrganizationRequest) (*domain.Organization, error)
+	AddOrganizationMember(ctx context.Context, orgID uuid.UUID, req *domain.AddMemberRequest) error
+	CastOrganizationVote(ctx context.Context, pollID uuid.UUID, req *domain.OrganizationVoteRequest) (*domain.OrganizationVote, error)
+
+	GetElectionCertification(ctx context.Context, pollID uuid.UUID) (*domain.ElectionCertification, error)
+	GetResultCertificate(ctx context.Context, pollID uuid.UUID) (*domain.ResultCertificate, error)
+	GetResultSigningKey(ctx context.Context) (*domain.ResultSigningKey, error)
+
+	GetUserPreferences(ctx context.Context, userID uuid.UUID) (*domain.UserPreferences, error)
+	UpdateUserPreferences(ctx context.Context, userID uuid.UUID, prefs *domain.UserPreferences) (*domain.UserPreferences, error)
+
+	CreateTagAlias(ctx context.Context, req *domain.TagAliasRequest) (*domain.TagAlias, error)
+	GetTagAliases(ctx context.Context) ([]domain.TagAlias, error)
+	MergeTags(ctx context.Context, req *domain.MergeTagsRequest) (*domain.TagMergeResult, error)
+	GetTagRules(ctx context.Context) ([]domain.TagRule, error)
+	SetTagRule(ctx context.Context, tag string, req *domain.TagRuleRequest) (*domain.TagRule, error)
+	DeleteTagRule(ctx context.Context, tag string) error
+
+	GetModerationQueue(ctx context.Context, status domain.FlagStatus, page, limit int) (*domain.ModerationQueue, error)
+	ResolveModerationFlag(ctx context.Context, flagID uuid.UUID, req *domain.ResolveFlagRequest) (*domain.ModerationFlag, error)
 }
 
 type service struct {
 	repo      domain.Repository
 	publisher events.Publisher
+	validator *validation.PollValidator
+	passwords *validation.PasswordValidator
+	searcher  domain.PollSearcher
 	logger    *zap.Logger
+
+	media       blob.Store
+	mediaURLTTL time.Duration
+
+	scorer        domain.ContentScorer
+	flagThreshold float64
+
+	consentVersions domain.ConsentVersions
+
+	statsWatcher domain.StatsWatcher
+
+	tieBreak     domain.TieBreakPolicy
+	tieBreakSeed string
+
+	creationLimiter domain.CreationLimiter
+
+	verificationTTL      time.Duration
+	verificationRequired bool
+
+	cardRefreshShare float64
+
+	statsRefreshWindow time.Duration
+
+	receiptKey []byte
+
+	resultSigningKey string
+
+	pollReads      pollReads
+	statsRefreshes statsRefreshes
+}
+
+type Option func(*service)
+
+// WithPollValidator replaces the validator used for poll content, which
+// defaults to validation.DefaultLimits without a profanity filter.
+func WithPollValidator(validator *validation.PollValidator) Option {
+	return func(s *service) {
+		s.validator = validator
+	}
+}
+
+// WithPasswordValidator replaces the validator new passwords are checked
+// with, which defaults to validation.DefaultPasswordPolicy without a breach
+// check.
+func WithPasswordValidator(validator *validation.PasswordValidator) Option {
+	return func(s *service) {
+		s.passwords = validator
+	}
+}
+
+// WithPollSearcher serves poll search from a dedicated search backend. The
+// repository's Postgres full-text search is used when it is unset or fails.
+func WithPollSearcher(searcher domain.PollSearcher) Option {
+	return func(s *service) {
+		s.searcher = searcher
+	}
 }
 
-func NewService(repo domain.Repository, publisher events.Publisher, logger *zap.Logger) Service {
-	return &service{
+// WithCreationLimiter throttles how many polls each creator can create a day.
+func WithCreationLimiter(limiter domain.CreationLimiter) Option {
+	return func(s *service) {
+		s.creationLimiter = limiter
+	}
+}
+
+func NewService(repo domain.Repository, publisher events.Publisher, logger *zap.Logger, opts ...Option) Service {
+	s := &service{
 		repo:      repo,
 		publisher: publisher,
+		validator: validation.NewPollValidator(validation.DefaultLimits(), nil),
+		passwords: validation.NewPasswordValidator(validation.DefaultPasswordPolicy(), nil),
 		logger:    logger,
+
+		verificationTTL:  defaultVerificationTTL,
+		cardRefreshShare: defaultCardRefreshShare,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func (s *service) CreatePoll(ctx context.Context, req *domain.CreatePollRequest) (uuid.UUID, error) {
+func (s *service) CreatePoll(ctx context.Context, req *domain.CreatePollRequest) (*domain.Poll, error) {
 	if req == nil {
-		return uuid.Nil, domain.ErrInvalidInput
+		return nil, domain.ErrInvalidInput
+	}
+
+	if err := s.validator.ValidateCreate(req); err != nil {
+		return nil, err
 	}
 
-	if req.Title == "" {
-		return uuid.Nil, domain.ErrInvalidInput
+	if errs := checkPollSettings(req, time.Now()); len(errs) > 0 {
+		return nil, errs[0]
 	}
 
-	if len(req.Options) < 2 {
-		return uuid.Nil, domain.ErrInvalidInput
+	kind := pollKind(req)
+	visibility := req.ResultsVisibility
+	if visibility == "" {
+		visibility = domain.ResultsAlways
+	}
+	voteChange := req.VoteChange
+	switch {
+	case voteChange == "" && kind == domain.PollKindElection:
+		voteChange = domain.VoteChangeDisallowed
+	case voteChange == "":
+		voteChange = domain.VoteChangeUntilClose
 	}
 
-	if len(req.Tags) == 0 {
-		return uuid.Nil, domain.ErrInvalidInput
+	countries, err := normalizeCountries(req.AllowedCountries)
+	if err != nil {
+		return nil, err
 	}
 
 	poll := &domain.Poll{
-		ID:        uuid.New(),
-		Title:     req.Title,
-		Options:   make([]domain.Option, len(req.Options)),
-		Tags:      req.Tags,
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
+		ID:         uuid.New(),
+		Title:      req.Title,
+		Options:    make([]domain.Option, len(req.Options)),
+		Tags:       req.Tags,
+		Electorate: domain.ElectorateOpen,
+		Kind:       kind,
+		StartsAt:   req.StartsAt,
+		EndsAt:     req.EndsAt,
+
+		PublicResults:     req.PublicResults,
+		ResultsVisibility: visibility,
+		VoteChange:        voteChange,
+		OrganizationVotes: req.OrganizationVotes,
+		CreatedAt:         time.Now().UTC(),
+		UpdatedAt:         time.Now().UTC(),
+
+		VoteChangeCooldownSeconds: req.VoteChangeCooldownSeconds,
+
+		Anonymous:        req.Anonymous,
+		AllowedCountries: countries,
+		Language:         pollLanguage(req),
+	}
+	poll.Status = poll.PublishedStatus(poll.CreatedAt)
+	if req.Draft {
+		poll.Status = domain.PollStatusDraft
+	}
+	if req.CreatorID != uuid.Nil {
+		creatorID := req.CreatorID
+		poll.CreatedBy = &creatorID
+	}
+
+	switch {
+	case req.OrganizationID != nil:
+		if err := s.requireOrganizationAdmin(ctx, *req.OrganizationID, req.CreatorID); err != nil {
+			return nil, err
+		}
+		poll.OrganizationID = req.OrganizationID
+		poll.Electorate = domain.ElectorateOrganization
+		if len(req.EligibleEmails) > 0 {
+			poll.Electorate = domain.ElectorateList
+			poll.EligibleEmails = normalizeList(req.EligibleEmails)
+		}
+	case kind == domain.PollKindElection:
+		poll.Electorate = domain.ElectorateList
+		poll.EligibleEmails = normalizeList(req.EligibleEmails)
+	}
+
+	if req.AccessCode != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.AccessCode), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash access code: %w", err)
+		}
+		poll.AccessCodeHash = string(hash)
+		poll.Protected = true
+	}
+
+	if req.Webhook != nil {
+		poll.Webhook = &domain.PollWebhook{
+			PollID: poll.ID,
+			URL:    req.Webhook.URL,
+			Secret: req.Webhook.Secret,
+		}
 	}
 
 	for i, opt := range req.Options {
@@ -78,263 +293,877 @@ func (s *service) CreatePoll(ctx context.Context, req *domain.CreatePollRequest)
 			OptionIndex: i,
 			CreatedAt:   time.Now().UTC(),
 		}
+		if i < len(req.OptionEmojis) {
+			poll.Options[i].Emoji = req.OptionEmojis[i]
+		}
+		poll.Options[i].Default = req.DefaultOption != nil && *req.DefaultOption == i
 	}
 
-	err := s.repo.CreatePoll(ctx, poll, req.Options, req.Tags)
+	tags, err := s.resolveTags(ctx, req.Tags)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("failed to create poll: %w", err)
+		return nil, err
 	}
+	poll.Tags = tags
 
-	if err := s.publisher.PublishPollCreated(ctx, poll); err != nil {
-		s.logger.Error("failed to publish poll created event",
-			zap.Error(err),
-			zap.String("poll_id", poll.ID.String()),
-		)
+	reviewTags, err := s.checkTagRules(ctx, poll, req.CreatorID)
+	if err != nil {
+		return nil, err
+	}
+	// Polls that need review are kept as drafts until a moderator approves
+	// them. Drafts are only queued once they are published.
+	if len(reviewTags) > 0 && poll.Status != domain.PollStatusDraft {
+		poll.Status = domain.PollStatusDraft
+		poll.PendingReview = true
 	}
 
-	return poll.ID, nil
+	release := func() {}
+	if s.creationLimiter != nil && req.CreatorID != uuid.Nil {
+		release, err = s.creationLimiter.ReserveCreation(ctx, req.CreatorID, req.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The repository queues the poll.created event in the same transaction.
+	err = s.repo.CreatePoll(ctx, poll, req.Options, tags)
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("failed to create poll: %w", err)
+	}
+	s.flagContent(ctx, pollContent(poll)...)
+	if poll.PendingReview {
+		// Publishing the draft queues it again if this fails.
+		if err := s.requestReview(ctx, poll, req.CreatorID, reviewTags); err != nil {
+			logging.For(ctx, s.logger).Error("Failed to hold poll for review", zap.Error(err), zap.String("poll_id", poll.ID.String()))
+		}
+	}
+
+	return poll, nil
 }
 
+// GetPollByID returns the poll for the current user. Drafts are only found
+// by their creator, collaborators and admins.
 func (s *service) GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Poll, error) {
-	return s.repo.GetPollByID(ctx, id)
+	poll, err := s.getPoll(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireDraftVisible(ctx, poll); err != nil {
+		return nil, err
+	}
+	return poll, nil
 }
 
-func (s *service) GetPollsForFeed(ctx context.Context, userID uuid.UUID, tag string, page, limit int) (*domain.PollFeedResponse, error) {
-	polls, total, err := s.repo.GetPollsForFeed(ctx, userID, tag, page, limit)
+// getPoll shares one repository read between concurrent requests for the
+// same poll, as hot polls are fetched by many clients at once.
+func (s *service) getPoll(ctx context.Context, id uuid.UUID) (*domain.Poll, error) {
+	poll, err := s.pollReads.get(ctx, id, s.repo.GetPollByID)
 	if err != nil {
 		return nil, err
 	}
+	poll.Status = poll.StatusAt(time.Now().UTC())
+	s.attachMediaURLs(poll)
+	return poll, nil
+}
 
-	return &domain.PollFeedResponse{
-		Polls: polls,
-		Total: total,
-		Page:  page,
-		Limit: limit,
-	}, nil
+// requireDraftVisible hides drafts from anyone who could not manage them,
+// as though they did not exist. Every read of a poll on behalf of a user
+// goes through it.
+func (s *service) requireDraftVisible(ctx context.Context, poll *domain.Poll) error {
+	if poll.Status != domain.PollStatusDraft {
+		return nil
+	}
+	principal, ok := auth.CurrentUser(ctx)
+	if !ok {
+		return domain.ErrNotFound
+	}
+	if principal.Role == domain.RoleAdmin {
+		return nil
+	}
+	err := s.requirePollPermission(ctx, poll, principal.ID, domain.CollaboratorStats)
+	if errors.Is(err, domain.ErrForbidden) {
+		return domain.ErrNotFound
+	}
+	return err
 }
 
-func (s *service) GetPollStats(ctx context.Context, pollID uuid.UUID) (*domain.PollStats, error) {
-	stats, err := s.repo.GetCachedPollStats(ctx, pollID)
-	if err == nil {
-		return stats, nil
+func (s *service) GetPollsForFeed(ctx context.Context, q domain.FeedQuery) (*domain.PollFeedResponse, error) {
+	q.Tag = strings.ToLower(strings.TrimSpace(q.Tag))
+	if q.Tag != "" {
+		resolved, err := s.repo.ResolveTags(ctx, []string{q.Tag})
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve tag: %w", err)
+		}
+		q.Tag = resolved[0]
+	}
+	if len(q.Languages) > 0 {
+		languages, err := normalizeLanguages(q.Languages)
+		if err != nil {
+			return nil, err
+		}
+		q.Languages = languages
 	}
 
-	stats, err = s.repo.GetPollStats(ctx, pollID)
+	polls, total, err := s.repo.GetPollsForFeed(ctx, q)
 	if err != nil {
 		return nil, err
 	}
-
-	if err := s.repo.SetCachedPollStats(ctx, pollID, stats); err != nil {
-		s.logger.Warn("Failed to cache poll stats",
-			zap.String("poll_id", pollID.String()),
-			zap.Error(err),
-		)
+	now := time.Now().UTC()
+	for i := range polls {
+		polls[i].Status = polls[i].StatusAt(now)
+		s.attachMediaURLs(&polls[i])
 	}
 
-	return stats, nil
+	resp := &domain.PollFeedResponse{
+		Polls:          polls,
+		Total:          total,
+		TotalEstimated: q.Total == domain.FeedTotalEstimate,
+		Page:           q.Page,
+		Limit:          q.Limit,
+	}
+	if len(polls) == q.Limit {
+		last := polls[len(polls)-1]
+		resp.NextCursor = domain.FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	return resp, nil
 }
 
-func (s *service) VoteOnPoll(ctx context.Context, pollID uuid.UUID, req *domain.VoteRequest) error {
-	hasVoted, err := s.repo.HasVoted(ctx, pollID, req.UserID)
-	if err != nil {
-		return err
+func (s *service) SearchPolls(ctx context.Context, q domain.PollSearchQuery) (*domain.PollSearchResult, error) {
+	q.Query = strings.TrimSpace(q.Query)
+	if len(q.Query) > domain.MaxSearchQueryLength {
+		return nil, domain.ErrInvalidInput
 	}
-	if hasVoted {
-		return domain.ErrAlreadyVoted
+	tags, err := s.resolveTags(ctx, normalizeList(q.Tags))
+	if err != nil {
+		return nil, err
 	}
+	q.Tags = tags
 
-	poll, err := s.repo.GetPollByID(ctx, pollID)
-	if err != nil {
-		return err
+	var result *domain.PollSearchResult
+	if s.searcher != nil {
+		result, err = s.searcher.SearchPolls(ctx, q)
+		if err != nil {
+			logging.For(ctx, s.logger).Warn("Search backend failed, falling back to Postgres", zap.Error(err))
+		}
+	}
+	if result == nil {
+		result, err = s.repo.SearchPolls(ctx, q)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search polls: %w", err)
+		}
 	}
 
-	if req.OptionIndex < 0 || req.OptionIndex >= len(poll.Options) {
-		return domain.ErrInvalidOption
+	now := time.Now().UTC()
+	for i := range result.Polls {
+		result.Polls[i].Status = result.Polls[i].StatusAt(now)
+		s.attachMediaURLs(&result.Polls[i])
 	}
+	return result, nil
+}
 
-	today := time.Now().UTC().Truncate(24 * time.Hour)
-	voteCount, err := s.repo.GetUserDailyVoteCount(ctx, req.UserID, today)
+// GetPollStats serves stats from the cache unless they are older than
+// q.MaxAge. Bypassing the cache entirely (a zero MaxAge) is reserved for
+// users with stats access so anonymous clients cannot force a recount on
+// every request. Closed polls are served from their result snapshot and
+// ignore q. Polls whose results are hidden from q.ActorID return
+// domain.ErrResultsHidden.
+func (s *service) GetPollStats(ctx context.Context, pollID uuid.UUID, q domain.StatsQuery) (*domain.PollStats, error) {
+	poll, err := s.repo.GetPollByID(ctx, pollID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if voteCount >= domain.MaxDailyVotes {
-		return domain.ErrDailyVoteLimitExceeded
+	if err := s.requireDraftVisible(ctx, poll); err != nil {
+		return nil, err
 	}
-
-	vote := &domain.Vote{
-		ID:        uuid.New(),
-		PollID:    pollID,
-		UserID:    req.UserID,
-		OptionID:  poll.Options[req.OptionIndex].ID,
-		CreatedAt: time.Now().UTC(),
+	if err := s.requireResultsVisible(ctx, poll, q.ActorID); err != nil {
+		return nil, err
+	}
+	stats, err := s.pollStats(ctx, poll, q)
+	if err != nil {
+		return nil, err
 	}
+	s.attachMyOption(ctx, poll, stats, q.ActorID)
+	return stats, nil
+}
 
-	if err := s.repo.CreateVote(ctx, pollID, req.UserID, poll.Options[req.OptionIndex].ID); err != nil {
-		return err
+// pollStats returns a copy of the poll's stats that callers may change,
+// with the total and each option's percentage filled in.
+func (s *service) pollStats(ctx context.Context, poll *domain.Poll, q domain.StatsQuery) (*domain.PollStats, error) {
+	stats, err := s.loadPollStats(ctx, poll, q)
+	if err != nil {
+		return nil, err
 	}
+	return withTotals(stats), nil
+}
 
-	if err := s.repo.InvalidatePollStatsCache(ctx, pollID); err != nil {
-		s.logger.Warn("Failed to invalidate poll stats cache",
-			zap.Error(err),
-			zap.String("poll_id", pollID.String()),
-		)
+// withTotals copies stats, as cached stats are shared between requests, and
+// sums the votes into the copy.
+func withTotals(stats *domain.PollStats) *domain.PollStats {
+	summary := *stats
+	summary.Votes = make([]domain.OptionStats, len(stats.Votes))
+	summary.TotalVotes = 0
+	for _, option := range stats.Votes {
+		summary.TotalVotes += option.Count
 	}
+	for i, option := range stats.Votes {
+		option.Percentage = domain.Percentage(option.Count, summary.TotalVotes)
+		summary.Votes[i] = option
+	}
+	return &summary
+}
 
-	if err := s.publisher.PublishPollVoted(ctx, vote); err != nil {
-		s.logger.Error("Failed to publish poll voted event",
+// attachMyOption sets the option actorID voted for on stats. A failed lookup
+// only leaves it unset.
+func (s *service) attachMyOption(ctx context.Context, poll *domain.Poll, stats *domain.PollStats, actorID uuid.UUID) {
+	if actorID == uuid.Nil {
+		return
+	}
+	vote, err := s.repo.GetUserPollVote(ctx, poll.ID, actorID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return
+	}
+	if err != nil {
+		logging.For(ctx, s.logger).Warn("Failed to get requester's vote",
 			zap.Error(err),
-			zap.String("poll_id", pollID.String()),
-			zap.String("user_id", req.UserID.String()),
+			zap.String("poll_id", poll.ID.String()),
 		)
+		return
+	}
+	for _, option := range poll.Options {
+		if option.OptionIndex == vote.OptionIndex {
+			stats.MyOption = option.OptionText
+			return
+		}
 	}
-
-	return nil
 }
 
-func (s *service) UpdateVote(ctx context.Context, voteID uuid.UUID, req *domain.UpdateVoteRequest) error {
-	if req == nil {
-		return domain.ErrInvalidInput
+func (s *service) loadPollStats(ctx context.Context, poll *domain.Poll, q domain.StatsQuery) (*domain.PollStats, error) {
+	if poll.IsFinal(time.Now().UTC()) {
+		return s.finalPollStats(ctx, poll)
 	}
 
-	vote, err := s.repo.GetVoteByID(ctx, voteID)
+	if q.MaxAge != nil && *q.MaxAge <= 0 {
+		if err := s.requirePollPermission(ctx, poll, q.ActorID, domain.CollaboratorStats); err != nil {
+			return nil, err
+		}
+	} else {
+		stats, err := s.repo.GetCachedPollStats(ctx, poll.ID)
+		if err == nil && statsFreshEnough(stats, q.MaxAge) {
+			s.refreshExpiringStats(ctx, stats)
+			return stats, nil
+		}
+	}
+
+	stats, err := s.repo.GetPollStats(ctx, poll.ID)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	if stats.ComputedAt.IsZero() {
+		stats.ComputedAt = time.Now().UTC()
+	}
+	s.cachePollStats(ctx, stats)
 
-	if vote.UserID != req.UserID {
-		return domain.ErrUnauthorized
+	return stats, nil
+}
+
+func (s *service) cachePollStats(ctx context.Context, stats *domain.PollStats) {
+	if err := s.repo.SetCachedPollStats(ctx, stats.PollID, stats); err != nil {
+		logging.For(ctx, s.logger).Warn("Failed to cache poll stats",
+			zap.String("poll_id", stats.PollID.String()),
+			zap.Error(err),
+		)
 	}
+}
 
-	poll, err := s.repo.GetPollByID(ctx, vote.PollID)
-	if err != nil {
-		return err
+func statsFreshEnough(stats *domain.PollStats, maxAge *time.Duration) bool {
+	if maxAge == nil {
+		return true
 	}
+	if stats.ComputedAt.IsZero() {
+		return false
+	}
+	limit := *maxAge
+	if limit < domain.MinStatsMaxAge {
+		limit = domain.MinStatsMaxAge
+	}
+	return time.Since(stats.ComputedAt) <= limit
+}
 
-	if req.OptionIndex < 0 || req.OptionIndex >= len(poll.Options) {
-		return domain.ErrInvalidOption
+// RecountPollStats recomputes a poll's counts from the votes table, reports
+// where the stats cache and daily rollups disagreed, and rewrites both.
+func (s *service) RecountPollStats(ctx context.Context, pollID uuid.UUID) (*domain.PollRecount, error) {
+	if _, err := s.repo.GetPollByID(ctx, pollID); err != nil {
+		return nil, err
 	}
 
-	err = s.repo.UpdateVote(ctx, voteID, req.UserID, poll.Options[req.OptionIndex].ID)
+	stats, err := s.repo.GetPollStats(ctx, pollID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	updatedVote := &domain.Vote{
-		ID:        voteID,
-		PollID:    vote.PollID,
-		UserID:    req.UserID,
-		OptionID:  poll.Options[req.OptionIndex].ID,
-		CreatedAt: vote.CreatedAt,
+	recount := &domain.PollRecount{
+		PollID:        pollID,
+		Stats:         stats,
+		Discrepancies: make([]domain.StatsDiscrepancy, 0),
+		RecountedAt:   time.Now().UTC(),
 	}
 
-	if err := s.publisher.PublishPollVoteUpdated(ctx, updatedVote); err != nil {
-		s.logger.Error("Failed to publish poll vote updated event",
+	cached, err := s.repo.GetCachedPollStats(ctx, pollID)
+	switch {
+	case err == nil:
+		recount.Discrepancies = append(recount.Discrepancies, diffPollStats(stats, cached)...)
+	case !errors.Is(err, domain.ErrNotFound):
+		logging.For(ctx, s.logger).Warn("Failed to read cached poll stats for recount",
+			zap.String("poll_id", pollID.String()),
 			zap.Error(err),
-			zap.String("vote_id", voteID.String()),
-			zap.String("user_id", req.UserID.String()),
 		)
 	}
 
-	return nil
-}
-
-func (s *service) DeleteVote(ctx context.Context, voteID, userID uuid.UUID) error {
-	vote, err := s.repo.GetVoteByID(ctx, voteID)
+	daily, err := s.repo.RepairPollStatsDaily(ctx, pollID)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	recount.Discrepancies = append(recount.Discrepancies, daily...)
 
-	if vote.UserID != userID {
-		return domain.ErrUnauthorized
+	if err := s.repo.SetCachedPollStats(ctx, pollID, stats); err != nil {
+		return nil, err
 	}
-
-	err = s.repo.DeleteVote(ctx, voteID, userID)
-	if err != nil {
-		return err
+	if len(recount.Discrepancies) > 0 {
+		s.bumpStatsVersion(ctx, pollID)
 	}
 
-	if err := s.publisher.PublishPollVoteDeleted(ctx, vote); err != nil {
-		s.logger.Error("Failed to publish poll vote deleted event",
-			zap.Error(err),
-			zap.String("vote_id", voteID.String()),
-			zap.String("user_id", userID.String()),
-		)
+	return recount, nil
+}
+
+// diffPollStats lists the options whose cached count differs from the
+// recomputed one. Options missing from either side count as zero.
+func diffPollStats(expected, cached *domain.PollStats) []domain.StatsDiscrepancy {
+	found := make(map[string]int, len(cached.Votes))
+	for _, option := range cached.Votes {
+		found[option.Option] = option.Count
 	}
 
-	return nil
+	var discrepancies []domain.StatsDiscrepancy
+	for _, option := range expected.Votes {
+		if found[option.Option] != option.Count {
+			discrepancies = append(discrepancies, domain.StatsDiscrepancy{
+				Source:   domain.StatsSourceCache,
+				Option:   option.Option,
+				Expected: option.Count,
+				Found:    found[option.Option],
+			})
+		}
+		delete(found, option.Option)
+	}
+	for _, option := range cached.Votes {
+		if count, ok := found[option.Option]; ok && count != 0 {
+			discrepancies = append(discrepancies, domain.StatsDiscrepancy{
+				Source: domain.StatsSourceCache,
+				Option: option.Option,
+				Found:  count,
+			})
+		}
+	}
+	return discrepancies
 }
 
-func (s *service) SkipPoll(ctx context.Context, pollID uuid.UUID, req *domain.SkipRequest) error {
-	hasSkipped, err := s.repo.HasSkipped(ctx, pollID, req.UserID)
+// GetPublicResults returns ErrNotFound for polls without public results so
+// that their existence is not disclosed.
+func (s *service) GetPublicResults(ctx context.Context, pollID uuid.UUID) (*domain.PollResults, error) {
+	poll, err := s.repo.GetPollByID(ctx, pollID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if hasSkipped {
-		return domain.ErrAlreadySkipped
+	if err := s.requireDraftVisible(ctx, poll); err != nil {
+		return nil, err
 	}
-
-	skip := &domain.Skip{
-		ID:        uuid.New(),
-		PollID:    pollID,
-		UserID:    req.UserID,
-		CreatedAt: time.Now().UTC(),
+	if !poll.PublicResults {
+		return nil, domain.ErrNotFound
+	}
+	if err := s.requireResultsVisible(ctx, poll, uuid.Nil); err != nil {
+		return nil, err
 	}
 
-	err = s.repo.CreateSkip(ctx, pollID, req.UserID)
+	stats, err := s.pollStats(ctx, poll, domain.StatsQuery{})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := s.publisher.PublishPollSkipped(ctx, skip); err != nil {
-		s.logger.Error("Failed to publish poll skipped event",
-			zap.Error(err),
-			zap.String("poll_id", pollID.String()),
-			zap.String("user_id", req.UserID.String()),
-		)
+	results := &domain.PollResults{
+		PollID:   poll.ID,
+		Title:    poll.Title,
+		Tags:     poll.Tags,
+		Kind:     poll.Kind,
+		StartsAt: poll.StartsAt,
+		EndsAt:   poll.EndsAt,
+		Final:    stats.Results != nil,
+		Votes:    stats.Votes,
+		Total:    stats.TotalVotes,
+		Turnout:  stats.Turnout,
 	}
-
-	return nil
+	return results, nil
 }
 
-func (s *service) GetUserVotes(ctx context.Context, userID uuid.UUID, page, limit int) (*domain.UserVotesResponse, error) {
-	if page < 1 {
-		page = domain.DefaultPage
+func (s *service) VoteOnPoll(ctx context.Context, pollID uuid.UUID, req *domain.VoteRequest) (*domain.VoteReceipt, error) {
+	if req == nil || req.UserID == uuid.Nil {
+		return nil, domain.ErrInvalidUser
 	}
-	if limit < 1 || limit > domain.MaxPageSize {
-		limit = domain.DefaultLimit
+	if err := s.requireVerifiedEmail(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	hasVoted, err := s.repo.HasVoted(ctx, pollID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if hasVoted {
+		return nil, s.alreadyVoted(ctx, pollID, req.UserID)
 	}
 
-	votes, total, err := s.repo.GetUserVotes(ctx, userID, page, limit)
+	poll, err := s.repo.GetPollByID(ctx, pollID)
 	if err != nil {
 		return nil, err
 	}
 
-	voteResponses := make([]domain.VoteResponse, len(votes))
-	for i, vote := range votes {
-		voteResponses[i] = domain.VoteResponse{
-			ID:         vote.ID,
-			PollID:     vote.PollID,
-			OptionID:   vote.OptionID,
-			CreatedAt:  vote.CreatedAt,
-			PollTitle:  vote.PollTitle,
-			OptionText: vote.OptionText,
-		}
+	if !poll.IsOpen(time.Now().UTC()) {
+		return nil, domain.ErrPollNotOpen
 	}
 
-	return &domain.UserVotesResponse{
-		Votes: voteResponses,
-		Total: total,
-		Page:  page,
-		Limit: limit,
-	}, nil
-}
+	optionIndex, err := voteOption(poll, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkVoterAccess(ctx, poll, req.UserID, req.AccessCode, req.Location); err != nil {
+		return nil, err
+	}
+
+	// Postgres keeps microseconds, and the receipt has to match the vote as
+	// it is read back.
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	vote := &domain.Vote{
+		ID:        uuid.New(),
+		PollID:    pollID,
+		UserID:    req.UserID,
+		OptionID:  poll.Options[optionIndex].ID,
+		CreatedAt: now,
+
+		PollTitle:   poll.Title,
+		OptionText:  poll.Options[optionIndex].OptionText,
+		OptionIndex: optionIndex,
+	}
+
+	locateVote(poll, vote, req.Location)
+
+	if err := s.repo.ReserveRecentVote(ctx, req.UserID, vote.ID, now, domain.DailyVoteWindow, domain.MaxDailyVotes); err != nil {
+		return nil, err
+	}
+
+	// The repository queues the poll.voted event in the same transaction.
+	if err := s.repo.CreateVote(ctx, vote); err != nil {
+		if releaseErr := s.repo.ReleaseRecentVote(ctx, req.UserID, vote.ID); releaseErr != nil {
+			logging.For(ctx, s.logger).Warn("Failed to release vote from daily limit",
+				zap.Error(releaseErr),
+				zap.String("user_id", req.UserID.String()),
+			)
+		}
+		if errors.Is(err, domain.ErrAlreadyVoted) {
+			return nil, s.alreadyVoted(ctx, pollID, req.UserID)
+		}
+		return nil, err
+	}
+
+	s.statsChanged(ctx, pollID)
+
+	s.recordVoteLocation(ctx, poll, req.Location)
+
+	receipt := vote.Receipt()
+	s.signReceipt(receipt)
+	return receipt, nil
+}
+
+// alreadyVoted describes the vote the user already cast on the poll. If it
+// cannot be looked up the plain ErrAlreadyVoted is returned instead.
+func (s *service) alreadyVoted(ctx context.Context, pollID, userID uuid.UUID) error {
+	vote, err := s.repo.GetUserPollVote(ctx, pollID, userID)
+	if err != nil {
+		logging.For(ctx, s.logger).Warn("Failed to get existing vote",
+			zap.Error(err),
+			zap.String("poll_id", pollID.String()),
+			zap.String("user_id", userID.String()),
+		)
+		return domain.ErrAlreadyVoted
+	}
+	return &domain.AlreadyVotedError{VoteID: vote.ID, OptionIndex: vote.OptionIndex}
+}
+
+func (s *service) GetVoteAllowance(ctx context.Context, userID uuid.UUID) (*domain.VoteAllowance, error) {
+	recent, err := s.repo.GetRecentVoteTimes(ctx, userID, time.Now().UTC().Add(-domain.DailyVoteWindow))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent votes: %w", err)
+	}
+
+	allowance := &domain.VoteAllowance{
+		Limit: domain.MaxDailyVotes,
+		Used:  len(recent),
+	}
+	if allowance.Used < allowance.Limit {
+		allowance.Remaining = allowance.Limit - allowance.Used
+	}
+	if len(recent) > 0 {
+		resetAt := recent[0].Add(domain.DailyVoteWindow)
+		allowance.ResetAt = &resetAt
+	}
+	return allowance, nil
+}
+
+// checkVoterAccess applies the poll's access code, electorate and country
+// restrictions to userID voting from location.
+func (s *service) checkVoterAccess(ctx context.Context, poll *domain.Poll, userID uuid.UUID, accessCode string, location *domain.GeoLocation) error {
+	if poll.Protected {
+		if err := s.checkAccessCode(ctx, poll.ID, accessCode); err != nil {
+			return err
+		}
+	}
+
+	if poll.Electorate.Restricted() {
+		eligible, err := s.repo.IsEligibleVoter(ctx, poll.ID, userID)
+		if err != nil {
+			return err
+		}
+		if !eligible {
+			return domain.ErrNotEligible
+		}
+	}
+
+	if len(poll.AllowedCountries) > 0 && (location == nil || !poll.AllowsCountry(location.Country)) {
+		return domain.ErrGeoRestricted
+	}
+	return nil
+}
+
+func (s *service) checkAccessCode(ctx context.Context, pollID uuid.UUID, code string) error {
+	if code == "" {
+		return domain.ErrInvalidAccessCode
+	}
+	hash, err := s.repo.GetPollAccessCodeHash(ctx, pollID)
+	if err != nil {
+		return err
+	}
+	if hash == "" {
+		return nil
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(code)); err != nil {
+		return domain.ErrInvalidAccessCode
+	}
+	return nil
+}
+
+func (s *service) UpdateVote(ctx context.Context, voteID uuid.UUID, req *domain.UpdateVoteRequest) error {
+	if req == nil {
+		return domain.ErrInvalidInput
+	}
+	if req.UserID == uuid.Nil {
+		return domain.ErrInvalidUser
+	}
+
+	vote, err := s.repo.GetVoteByID(ctx, voteID)
+	if err != nil {
+		return err
+	}
+
+	if vote.UserID != req.UserID {
+		return domain.ErrUnauthorized
+	}
+
+	poll, err := s.repo.GetPollByID(ctx, vote.PollID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkVoteChangeable(poll); err != nil {
+		return err
+	}
+	if err := s.checkVoteCooldown(ctx, poll, vote); err != nil {
+		return err
+	}
+
+	optionIndex, err := resolveOption(poll, req.OptionID, req.OptionIndex)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.UpdateVote(ctx, voteID, req.UserID, poll.Options[optionIndex].ID)
+	if err != nil {
+		return err
+	}
+
+	updatedVote := &domain.Vote{
+		ID:        voteID,
+		PollID:    vote.PollID,
+		UserID:    req.UserID,
+		OptionID:  poll.Options[optionIndex].ID,
+		CreatedAt: vote.CreatedAt,
+
+		PollTitle:   poll.Title,
+		OptionText:  poll.Options[optionIndex].OptionText,
+		OptionIndex: optionIndex,
+	}
+
+	s.statsChanged(ctx, vote.PollID)
+
+	if err := s.publisher.PublishPollVoteUpdated(ctx, updatedVote); err != nil {
+		logging.For(ctx, s.logger).Error("Failed to publish poll vote updated event",
+			zap.Error(err),
+			zap.String("vote_id", voteID.String()),
+			zap.String("user_id", req.UserID.String()),
+		)
+	}
+
+	return nil
+}
+
+func (s *service) DeleteVote(ctx context.Context, voteID, userID uuid.UUID) error {
+	vote, err := s.repo.GetVoteByID(ctx, voteID)
+	if err != nil {
+		return err
+	}
+
+	if vote.UserID != userID {
+		return domain.ErrUnauthorized
+	}
+
+	poll, err := s.repo.GetPollByID(ctx, vote.PollID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkVoteChangeable(poll); err != nil {
+		return err
+	}
+
+	err = s.repo.DeleteVote(ctx, voteID, userID)
+	if err != nil {
+		return err
+	}
+	s.statsChanged(ctx, vote.PollID)
+	vote.PollTitle = poll.Title
+	for _, opt := range poll.Options {
+		if opt.ID == vote.OptionID {
+			vote.OptionText = opt.OptionText
+			vote.OptionIndex = opt.OptionIndex
+		}
+	}
+
+	if err := s.publisher.PublishPollVoteDeleted(ctx, vote); err != nil {
+		logging.For(ctx, s.logger).Error("Failed to publish poll vote deleted event",
+			zap.Error(err),
+			zap.String("vote_id", voteID.String()),
+			zap.String("user_id", userID.String()),
+		)
+	}
+
+	return nil
+}
+
+// voteOption returns the index of the option req votes for. A request naming
+// no option is only accepted by "tap to vote" polls, which record it for
+// their default option.
+func voteOption(poll *domain.Poll, req *domain.VoteRequest) (int, error) {
+	if !req.UseDefault {
+		return resolveOption(poll, req.OptionID, req.OptionIndex)
+	}
+	index, ok := poll.DefaultOption()
+	if !ok {
+		return 0, domain.ErrOptionRequired
+	}
+	return index, nil
+}
+
+// resolveOption returns the index of the chosen option in poll.Options.
+// The option ID wins over the index when both are given.
+func resolveOption(poll *domain.Poll, optionID *uuid.UUID, index int) (int, error) {
+	if optionID != nil {
+		for i, option := range poll.Options {
+			if option.ID == *optionID {
+				return i, nil
+			}
+		}
+		return 0, domain.ErrInvalidOption
+	}
+	if index < 0 || index >= len(poll.Options) {
+		return 0, domain.ErrInvalidOption
+	}
+	return index, nil
+}
+
+// checkVoteChangeable applies the poll's vote change policy to updates and
+// deletions. Polls without a policy behave as until_close.
+func checkVoteChangeable(poll *domain.Poll) error {
+	switch poll.VoteChange {
+	case domain.VoteChangeAllowed:
+		return nil
+	case domain.VoteChangeDisallowed:
+		return domain.ErrVoteFinal
+	}
+	if poll.Kind == domain.PollKindElection {
+		return domain.ErrVoteFinal
+	}
+	if !poll.IsOpen(time.Now().UTC()) {
+		return domain.ErrPollNotOpen
+	}
+	return nil
+}
+
+// checkVoteCooldown rejects a change within the poll's cooldown of the
+// vote's last change, or of its casting if it was never changed.
+func (s *service) checkVoteCooldown(ctx context.Context, poll *domain.Poll, vote *domain.Vote) error {
+	if poll.VoteChangeCooldownSeconds <= 0 {
+		return nil
+	}
+	last, err := s.repo.GetLastVoteChange(ctx, vote.ID)
+	if err != nil {
+		return err
+	}
+	if last.Before(vote.CreatedAt) {
+		last = vote.CreatedAt
+	}
+	retryAt := last.Add(time.Duration(poll.VoteChangeCooldownSeconds) * time.Second)
+	if time.Now().Before(retryAt) {
+		return &domain.VoteCooldownError{RetryAt: retryAt}
+	}
+	return nil
+}
+
+func (s *service) SkipPoll(ctx context.Context, pollID uuid.UUID, req *domain.SkipRequest) error {
+	if req == nil || req.UserID == uuid.Nil {
+		return domain.ErrInvalidUser
+	}
+	if req.Reason != "" && !req.Reason.Valid() {
+		return domain.ErrInvalidInput
+	}
+	hasSkipped, err := s.repo.HasSkipped(ctx, pollID, req.UserID)
+	if err != nil {
+		return err
+	}
+	if hasSkipped {
+		return domain.ErrAlreadySkipped
+	}
+
+	skip := &domain.Skip{
+		ID:        uuid.New(),
+		PollID:    pollID,
+		UserID:    req.UserID,
+		Reason:    req.Reason,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	err = s.repo.CreateSkip(ctx, skip)
+	if err != nil {
+		return err
+	}
+	if skip.Reason == domain.SkipOffensive {
+		s.flagOffensiveSkips(ctx, pollID)
+	}
+
+	if err := s.publisher.PublishPollSkipped(ctx, skip); err != nil {
+		logging.For(ctx, s.logger).Error("Failed to publish poll skipped event",
+			zap.Error(err),
+			zap.String("poll_id", pollID.String()),
+			zap.String("user_id", req.UserID.String()),
+		)
+	}
+
+	return nil
+}
+
+func (s *service) GetUserVotes(ctx context.Context, userID uuid.UUID, filter domain.VoteFilter, page, limit int) (*domain.UserVotesResponse, error) {
+	if page < 1 {
+		page = domain.DefaultPage
+	}
+	if limit < 1 || limit > domain.MaxPageSize {
+		limit = domain.DefaultLimit
+	}
+
+	votes, total, err := s.repo.GetUserVotes(ctx, userID, filter, page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	voteResponses := make([]domain.VoteResponse, len(votes))
+	for i := range votes {
+		voteResponses[i] = toVoteResponse(&votes[i], now)
+	}
+
+	return &domain.UserVotesResponse{
+		Votes: voteResponses,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	}, nil
+}
+
+func (s *service) ExportUserVotes(ctx context.Context, userID uuid.UUID, filter domain.VoteFilter, fn func(*domain.VoteResponse) error) error {
+	now := time.Now().UTC()
+	return s.repo.StreamUserVotes(ctx, userID, filter, func(vote *domain.Vote) error {
+		resp := toVoteResponse(vote, now)
+		return fn(&resp)
+	})
+}
+
+// toVoteResponse reports the poll's effective status at now, so polls whose
+// window has ended show as closed even before anyone closed them.
+func toVoteResponse(vote *domain.Vote, now time.Time) domain.VoteResponse {
+	poll := domain.Poll{Status: vote.PollStatus, StartsAt: vote.PollStartsAt, EndsAt: vote.PollEndsAt}
+	status := poll.StatusAt(now)
+	return domain.VoteResponse{
+		ID:          vote.ID,
+		PollID:      vote.PollID,
+		OptionID:    vote.OptionID,
+		CreatedAt:   vote.CreatedAt,
+		PollTitle:   vote.PollTitle,
+		OptionText:  vote.OptionText,
+		DeletedAt:   vote.DeletedAt,
+		OptionIndex: vote.OptionIndex,
+		PollStatus:  status,
+		PollOpen:    status == domain.PollStatusLive,
+		PollEndsAt:  vote.PollEndsAt,
+	}
+}
 
 func (s *service) CreateUser(ctx context.Context, user *domain.User) error {
-	return s.repo.CreateUser(ctx, user)
+	if user.ID == uuid.Nil {
+		user.ID = uuid.New()
+	}
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = time.Now()
+		user.UpdatedAt = user.CreatedAt
+	}
+	if err := s.checkPassword(ctx, user.Password); err != nil {
+		return err
+	}
+	if err := s.repo.CreateUser(ctx, user); err != nil {
+		return err
+	}
+
+	s.publishUserEvent(ctx, events.EventUserRegistered, s.publisher.PublishUserRegistered, user)
+	// The account exists either way; the user can ask for another link.
+	if err := s.sendEmailVerification(ctx, user); err != nil {
+		logging.For(ctx, s.logger).Error("Failed to send email verification",
+			zap.Error(err),
+			zap.String("user_id", user.ID.String()),
+		)
+	}
+	return nil
 }
 
 func (s *service) GetUserByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
-	return s.repo.GetUserByID(ctx, id)
+	user, err := s.repo.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user.AvatarURL = s.mediaURL(user.AvatarKey)
+	return user, nil
 }
 
 func (s *service) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
@@ -342,9 +1171,679 @@ func (s *service) GetUserByEmail(ctx context.Context, email string) (*domain.Use
 }
 
 func (s *service) UpdateUser(ctx context.Context, user *domain.User) error {
-	return s.repo.UpdateUser(ctx, user)
-}
-
-func (s *service) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	return s.repo.DeleteUser(ctx, id)
+	existing, err := s.repo.GetUserByID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if user.Password != existing.Password {
+		if err := s.checkPassword(ctx, user.Password); err != nil {
+			return err
+		}
+	}
+	if err := s.repo.UpdateUser(ctx, user); err != nil {
+		return err
+	}
+
+	if user.Password != existing.Password {
+		s.publishUserEvent(ctx, events.EventUserPasswordChanged, s.publisher.PublishUserPasswordChanged, user)
+	}
+	return nil
+}
+
+// checkPassword enforces the password policy on a new password. If the
+// breach check cannot be reached the password is accepted on the other
+// rules, so an outage there does not block sign-ups.
+func (s *service) checkPassword(ctx context.Context, password string) error {
+	violations, err := s.passwords.CheckPassword(ctx, password)
+	if err != nil {
+		logging.For(ctx, s.logger).Warn("Password breach check failed, skipping it", zap.Error(err))
+	}
+	if len(violations) > 0 {
+		return &domain.PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+func (s *service) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	user, err := s.repo.GetUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteUser(ctx, id); err != nil {
+		return err
+	}
+
+	s.publishUserEvent(ctx, events.EventUserDeleted, s.publisher.PublishUserDeleted, user)
+	return nil
+}
+
+func (s *service) RecordLogin(ctx context.Context, user *domain.User) error {
+	s.publishUserEvent(ctx, events.EventUserLoggedIn, s.publisher.PublishUserLoggedIn, user)
+	return nil
+}
+
+func (s *service) CreateOrganization(ctx context.Context, req *domain.CreateOrganizationRequest) (*domain.Organization, error) {
+	if req == nil || strings.TrimSpace(req.Name) == "" {
+		return nil, domain.ErrInvalidInput
+	}
+
+	org := &domain.Organization{
+		ID:        uuid.New(),
+		Name:      strings.TrimSpace(req.Name),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.repo.CreateOrganization(ctx, org, req.OwnerID); err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+	return org, nil
+}
+
+func (s *service) AddOrganizationMember(ctx context.Context, orgID uuid.UUID, req *domain.AddMemberRequest) error {
+	if req == nil || req.Email == "" {
+		return domain.ErrInvalidInput
+	}
+
+	role := req.Role
+	if role == "" {
+		role = domain.OrganizationMember
+	}
+	if role != domain.OrganizationMember && role != domain.OrganizationAdmin {
+		return domain.ErrInvalidInput
+	}
+
+	if err := s.requireOrganizationAdmin(ctx, orgID, req.ActorID); err != nil {
+		return err
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, strings.ToLower(strings.TrimSpace(req.Email)))
+	if err != nil {
+		return err
+	}
+
+	return s.repo.AddOrganizationMember(ctx, &domain.Membership{
+		OrganizationID: orgID,
+		UserID:         user.ID,
+		Role:           role,
+		CreatedAt:      time.Now().UTC(),
+	})
+}
+
+func (s *service) requireOrganizationAdmin(ctx context.Context, orgID, userID uuid.UUID) error {
+	role, err := s.repo.GetOrganizationRole(ctx, orgID, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.ErrForbidden
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get organization role: %w", err)
+	}
+	if role != domain.OrganizationAdmin {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+// normalizeList lower-cases and trims values, dropping blanks and duplicates.
+func normalizeList(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	normalized := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		normalized = append(normalized, value)
+	}
+	return normalized
+}
+
+func (s *service) GetElectionCertification(ctx context.Context, pollID uuid.UUID) (*domain.ElectionCertification, error) {
+	return s.repo.GetElectionCertification(ctx, pollID)
+}
+
+func (s *service) GetUserPreferences(ctx context.Context, userID uuid.UUID) (*domain.UserPreferences, error) {
+	return s.repo.GetUserPreferences(ctx, userID)
+}
+
+func (s *service) UpdateUserPreferences(ctx context.Context, userID uuid.UUID, prefs *domain.UserPreferences) (*domain.UserPreferences, error) {
+	if prefs == nil {
+		return nil, domain.ErrInvalidInput
+	}
+
+	normalized := &domain.UserPreferences{
+		FollowedTags:  normalizeList(prefs.FollowedTags),
+		MutedTags:     normalizeList(prefs.MutedTags),
+		MutedKeywords: normalizeList(prefs.MutedKeywords),
+		VoteReceipts:  prefs.VoteReceipts,
+	}
+	languages, err := normalizeLanguages(prefs.Languages)
+	if err != nil {
+		return nil, err
+	}
+	normalized.Languages = languages
+	for _, list := range [][]string{normalized.FollowedTags, normalized.MutedTags, normalized.MutedKeywords, normalized.Languages} {
+		if len(list) > domain.MaxPreferenceValues {
+			return nil, domain.ErrInvalidInput
+		}
+		for _, value := range list {
+			if len(value) > domain.MaxPreferenceValueLength {
+				return nil, domain.ErrInvalidInput
+			}
+		}
+	}
+
+	if normalized.FollowedTags, err = s.resolveTags(ctx, normalized.FollowedTags); err != nil {
+		return nil, err
+	}
+	if normalized.MutedTags, err = s.resolveTags(ctx, normalized.MutedTags); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetUserPreferences(ctx, userID, normalized); err != nil {
+		return nil, fmt.Errorf("failed to update preferences: %w", err)
+	}
+	return normalized, nil
+}
+
+func (s *service) UpdatePoll(ctx context.Context, pollID uuid.UUID, req *domain.UpdatePollRequest) (*domain.Poll, error) {
+	if req == nil || (req.Title == nil && req.Tags == nil) {
+		return nil, domain.ErrInvalidInput
+	}
+	if err := s.validator.ValidateUpdate(req); err != nil {
+		return nil, err
+	}
+
+	poll, err := s.repo.GetPollByID(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requirePollEditor(ctx, poll, req.ActorID, req.Admin); err != nil {
+		return nil, err
+	}
+	switch poll.StatusAt(time.Now().UTC()) {
+	case domain.PollStatusDraft, domain.PollStatusScheduled, domain.PollStatusLive:
+	default:
+		return nil, domain.ErrPollNotOpen
+	}
+
+	if req.Title != nil {
+		poll.Title = *req.Title
+	}
+	var reviewTags []string
+	if req.Tags != nil {
+		tags, err := s.resolveTags(ctx, req.Tags)
+		if err != nil {
+			return nil, err
+		}
+		before := poll.Tags
+		poll.Tags = tags
+		if reviewTags, err = s.checkTagChange(ctx, poll, before, req.ActorID); err != nil {
+			return nil, err
+		}
+	}
+	poll.UpdatedAt = time.Now().UTC()
+
+	if err := s.repo.UpdatePoll(ctx, poll); err != nil {
+		return nil, fmt.Errorf("failed to update poll: %w", err)
+	}
+	if err := s.holdForReview(ctx, poll, req.ActorID, reviewTags); err != nil {
+		return nil, err
+	}
+	if req.Title != nil {
+		s.flagContent(ctx, domain.Content{
+			Kind:     domain.ContentPollTitle,
+			Text:     poll.Title,
+			PollID:   &poll.ID,
+			AuthorID: &req.ActorID,
+		})
+	}
+
+	if err := s.publisher.PublishPollUpdated(ctx, poll); err != nil {
+		logging.For(ctx, s.logger).Error("failed to publish poll updated event",
+			zap.Error(err),
+			zap.String("poll_id", poll.ID.String()),
+		)
+	}
+	return poll, nil
+}
+
+// UpdatePollTags adds and removes tags under the same rules as UpdatePoll.
+// Tags are resolved through their aliases, and the change is rejected if it
+// would leave the poll with no tags or too many, or break the tag rules.
+func (s *service) UpdatePollTags(ctx context.Context, pollID uuid.UUID, req *domain.UpdatePollTagsRequest) (*domain.Poll, error) {
+	if req == nil {
+		return nil, domain.ErrInvalidInput
+	}
+	if err := s.validator.ValidateTagUpdate(req); err != nil {
+		return nil, err
+	}
+
+	poll, err := s.repo.GetPollByID(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requirePollEditor(ctx, poll, req.ActorID, req.Admin); err != nil {
+		return nil, err
+	}
+	switch poll.StatusAt(time.Now().UTC()) {
+	case domain.PollStatusDraft, domain.PollStatusScheduled, domain.PollStatusLive:
+	default:
+		return nil, domain.ErrPollNotOpen
+	}
+
+	add, err := s.resolveTags(ctx, req.Add)
+	if err != nil {
+		return nil, err
+	}
+	// Polls tagged before an alias existed still carry the alias itself.
+	remove, err := s.resolveTags(ctx, req.Remove)
+	if err != nil {
+		return nil, err
+	}
+	remove = normalizeList(append(remove, req.Remove...))
+
+	// The rules are checked against the tags the change would leave, before
+	// it is committed.
+	var reviewTags []string
+	check := func(tags []string) error {
+		if err := s.validator.CheckTagCount(tags); err != nil {
+			return err
+		}
+		changed := *poll
+		changed.Tags = tags
+		var err error
+		reviewTags, err = s.checkTagChange(ctx, &changed, poll.Tags, req.ActorID)
+		return err
+	}
+
+	poll.UpdatedAt = time.Now().UTC()
+	tags, err := s.repo.UpdatePollTags(ctx, pollID, add, remove, poll.UpdatedAt, check)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) || errors.Is(err, domain.ErrTagRestricted) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update poll tags: %w", err)
+	}
+	poll.Tags = tags
+	poll.Status = poll.StatusAt(poll.UpdatedAt)
+	s.attachMediaURLs(poll)
+	if err := s.holdForReview(ctx, poll, req.ActorID, reviewTags); err != nil {
+		return nil, err
+	}
+
+	if err := s.publisher.PublishPollUpdated(ctx, poll); err != nil {
+		logging.For(ctx, s.logger).Error("failed to publish poll updated event",
+			zap.Error(err),
+			zap.String("poll_id", poll.ID.String()),
+		)
+	}
+	return poll, nil
+}
+
+// ReorderOptions changes the order options are shown in. Only drafts and
+// scheduled polls can be reordered, and req has to list every option of the
+// poll exactly once.
+func (s *service) ReorderOptions(ctx context.Context, pollID uuid.UUID, req *domain.ReorderOptionsRequest) (*domain.Poll, error) {
+	if req == nil {
+		return nil, domain.ErrInvalidInput
+	}
+
+	poll, err := s.repo.GetPollByID(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requirePollEditor(ctx, poll, req.ActorID, req.Admin); err != nil {
+		return nil, err
+	}
+	switch poll.StatusAt(time.Now().UTC()) {
+	case domain.PollStatusDraft, domain.PollStatusScheduled:
+	default:
+		return nil, domain.ErrPollPublished
+	}
+	if err := checkOptionOrder(poll.Options, req.OptionIDs); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ReorderPollOptions(ctx, pollID, req.OptionIDs, time.Now().UTC()); err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to reorder options: %w", err)
+	}
+
+	poll, err = s.getPoll(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.publisher.PublishPollUpdated(ctx, poll); err != nil {
+		logging.For(ctx, s.logger).Error("failed to publish poll updated event",
+			zap.Error(err),
+			zap.String("poll_id", poll.ID.String()),
+		)
+	}
+	return poll, nil
+}
+
+// checkOptionOrder reports whether ids is a permutation of the IDs of
+// options.
+func checkOptionOrder(options []domain.Option, ids []uuid.UUID) error {
+	if len(ids) != len(options) {
+		return &domain.ValidationError{Field: "optionIds", Reason: fmt.Sprintf("must list all %d options", len(options))}
+	}
+	remaining := make(map[uuid.UUID]bool, len(options))
+	for _, option := range options {
+		remaining[option.ID] = true
+	}
+	for _, id := range ids {
+		if !remaining[id] {
+			return &domain.ValidationError{Field: "optionIds", Reason: fmt.Sprintf("option %s is not an option of this poll or is listed twice", id)}
+		}
+		delete(remaining, id)
+	}
+	return nil
+}
+
+// ClosePoll ends voting on a poll immediately by moving its end to now.
+func (s *service) ClosePoll(ctx context.Context, pollID, actorID uuid.UUID, admin bool) error {
+	_, err := s.ChangePollStatus(ctx, pollID, &domain.PollStatusRequest{
+		Status:  domain.PollStatusClosed,
+		ActorID: actorID,
+		Admin:   admin,
+	})
+	return err
+}
+
+// ChangePollStatus moves a poll through its lifecycle. Publishing a draft as
+// scheduled or live picks whichever matches its start time, and only the
+// creator or an admin may delete a poll.
+func (s *service) ChangePollStatus(ctx context.Context, pollID uuid.UUID, req *domain.PollStatusRequest) (*domain.Poll, error) {
+	if req == nil || !req.Status.Valid() {
+		return nil, domain.ErrInvalidInput
+	}
+
+	poll, err := s.repo.GetPollByID(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status == domain.PollStatusDeleted {
+		if !req.Admin && !isPollCreator(poll, req.ActorID) {
+			return nil, domain.ErrForbidden
+		}
+	} else if err := s.requirePollEditor(ctx, poll, req.ActorID, req.Admin); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	from := poll.StatusAt(now)
+	to := req.Status
+	if to == domain.PollStatusScheduled || to == domain.PollStatusLive {
+		to = poll.PublishedStatus(now)
+	}
+	if err := domain.ValidatePollTransition(from, to); err != nil {
+		return nil, err
+	}
+	if from == domain.PollStatusDraft && to != domain.PollStatusDeleted {
+		if err := s.checkPublish(ctx, poll, req.ActorID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.SetPollStatus(ctx, pollID, to, now); err != nil {
+		return nil, fmt.Errorf("failed to change poll status: %w", err)
+	}
+	poll.Status = to
+	poll.UpdatedAt = now
+	if to == domain.PollStatusClosed && (poll.EndsAt == nil || poll.EndsAt.After(now)) {
+		poll.EndsAt = &now
+	}
+	change := &domain.PollStatusChange{
+		PollID:    pollID,
+		From:      from,
+		To:        to,
+		ActorID:   req.ActorID,
+		ChangedAt: now,
+	}
+	if to == domain.PollStatusClosed {
+		// The first stats read or the result_snapshot job retries this.
+		snapshot, err := s.snapshotResults(ctx, poll)
+		if err != nil {
+			logging.For(ctx, s.logger).Warn("Failed to snapshot poll results",
+				zap.Error(err),
+				zap.String("poll_id", pollID.String()),
+			)
+		} else {
+			change.Winner = snapshot.Outcome()
+		}
+	}
+	if err := s.publisher.PublishPollStatusChanged(ctx, change); err != nil {
+		logging.For(ctx, s.logger).Error("failed to publish poll status changed event",
+			zap.Error(err),
+			zap.String("poll_id", pollID.String()),
+		)
+	}
+
+	logging.For(ctx, s.logger).Info("Poll status changed",
+		zap.String("poll_id", pollID.String()),
+		zap.String("from", string(from)),
+		zap.String("to", string(to)),
+		zap.String("user_id", req.ActorID.String()),
+	)
+	return poll, nil
+}
+
+func (s *service) GetPollOwnerStats(ctx context.Context, pollID, actorID uuid.UUID) (*domain.PollOwnerStats, error) {
+	poll, err := s.repo.GetPollByID(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requirePollPermission(ctx, poll, actorID, domain.CollaboratorStats); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.pollStats(ctx, poll, domain.StatsQuery{})
+	if err != nil {
+		return nil, err
+	}
+	skips, err := s.repo.CountSkips(ctx, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count skips: %w", err)
+	}
+	skipReasons, err := s.repo.CountSkipReasons(ctx, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count skip reasons: %w", err)
+	}
+	collaborators, err := s.repo.GetPollCollaborators(ctx, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collaborators: %w", err)
+	}
+	countries, err := s.getGeoStats(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.PollOwnerStats{
+		PollStats:     *stats,
+		Skips:         skips,
+		SkipReasons:   skipReasons,
+		Collaborators: collaborators,
+		Countries:     countries,
+	}, nil
+}
+
+func (s *service) AddPollCollaborator(ctx context.Context, pollID uuid.UUID, req *domain.AddCollaboratorRequest) (*domain.Collaborator, error) {
+	if req == nil || req.Email == "" {
+		return nil, domain.ErrInvalidInput
+	}
+
+	permission := req.Permission
+	if permission == "" {
+		permission = domain.CollaboratorStats
+	}
+	if permission != domain.CollaboratorEdit && permission != domain.CollaboratorStats {
+		return nil, domain.ErrInvalidInput
+	}
+
+	poll, err := s.repo.GetPollByID(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if !isPollCreator(poll, req.ActorID) {
+		return nil, domain.ErrForbidden
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, strings.ToLower(strings.TrimSpace(req.Email)))
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == req.ActorID {
+		return nil, domain.ErrInvalidInput
+	}
+
+	collaborator := &domain.Collaborator{
+		PollID:     pollID,
+		UserID:     user.ID,
+		Permission: permission,
+		InvitedBy:  req.ActorID,
+		CreatedAt:  time.Now().UTC(),
+		PollTitle:  poll.Title,
+	}
+	if err := s.repo.AddPollCollaborator(ctx, collaborator); err != nil {
+		return nil, fmt.Errorf("failed to add collaborator: %w", err)
+	}
+
+	if err := s.publisher.PublishCollaboratorInvited(ctx, collaborator); err != nil {
+		logging.For(ctx, s.logger).Error("Failed to publish collaborator invited event",
+			zap.Error(err),
+			zap.String("poll_id", pollID.String()),
+			zap.String("user_id", user.ID.String()),
+		)
+	}
+
+	return collaborator, nil
+}
+
+func (s *service) RemovePollCollaborator(ctx context.Context, pollID, userID, actorID uuid.UUID) error {
+	poll, err := s.repo.GetPollByID(ctx, pollID)
+	if err != nil {
+		return err
+	}
+	if !isPollCreator(poll, actorID) {
+		return domain.ErrForbidden
+	}
+	return s.repo.RemovePollCollaborator(ctx, pollID, userID)
+}
+
+// requirePollPermission allows the poll's creator and collaborators whose
+// permission covers required.
+func (s *service) requirePollPermission(ctx context.Context, poll *domain.Poll, userID uuid.UUID, required domain.CollaboratorPermission) error {
+	if isPollCreator(poll, userID) {
+		return nil
+	}
+
+	collaborator, err := s.repo.GetPollCollaborator(ctx, poll.ID, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.ErrForbidden
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get poll collaborator: %w", err)
+	}
+	if !collaborator.Permission.Allows(required) {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+// requirePollEditor lets admins change any poll, and everyone else the polls
+// they created or may edit as collaborators.
+func (s *service) requirePollEditor(ctx context.Context, poll *domain.Poll, userID uuid.UUID, admin bool) error {
+	if admin {
+		return nil
+	}
+	return s.requirePollPermission(ctx, poll, userID, domain.CollaboratorEdit)
+}
+
+func isPollCreator(poll *domain.Poll, userID uuid.UUID) bool {
+	return poll.CreatedBy != nil && *poll.CreatedBy == userID
+}
+
+// resolveTags maps aliases to their canonical tags and drops the duplicates
+// this may introduce.
+func (s *service) resolveTags(ctx context.Context, tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return tags, nil
+	}
+	resolved, err := s.repo.ResolveTags(ctx, tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve tags: %w", err)
+	}
+	return normalizeList(resolved), nil
+}
+
+func (s *service) CreateTagAlias(ctx context.Context, req *domain.TagAliasRequest) (*domain.TagAlias, error) {
+	if req == nil {
+		return nil, domain.ErrInvalidInput
+	}
+	alias := strings.ToLower(strings.TrimSpace(req.Alias))
+	tag, err := s.canonicalTag(ctx, req.Tag)
+	if err != nil {
+		return nil, err
+	}
+	if alias == "" || alias == tag || len(alias) > domain.MaxTagLength {
+		return nil, domain.ErrInvalidInput
+	}
+
+	tagAlias := &domain.TagAlias{
+		Alias:     alias,
+		Tag:       tag,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.repo.CreateTagAlias(ctx, tagAlias); err != nil {
+		return nil, fmt.Errorf("failed to create tag alias: %w", err)
+	}
+	return tagAlias, nil
+}
+
+func (s *service) GetTagAliases(ctx context.Context) ([]domain.TagAlias, error) {
+	return s.repo.GetTagAliases(ctx)
+}
+
+func (s *service) MergeTags(ctx context.Context, req *domain.MergeTagsRequest) (*domain.TagMergeResult, error) {
+	if req == nil {
+		return nil, domain.ErrInvalidInput
+	}
+	from := strings.ToLower(strings.TrimSpace(req.From))
+	to, err := s.canonicalTag(ctx, req.To)
+	if err != nil {
+		return nil, err
+	}
+	if from == "" || from == to || len(from) > domain.MaxTagLength {
+		return nil, domain.ErrInvalidInput
+	}
+
+	retagged, err := s.repo.MergeTags(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge tags: %w", err)
+	}
+
+	logging.For(ctx, s.logger).Info("Tags merged",
+		zap.String("from", from),
+		zap.String("to", to),
+		zap.Int("polls_retagged", retagged),
+	)
+	return &domain.TagMergeResult{From: from, To: to, PollsRetagged: retagged}, nil
+}
+
+func (s *service) canonicalTag(ctx context.Context, tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" || len(tag) > domain.MaxTagLength {
+		return "", domain.ErrInvalidInput
+	}
+	resolved, err := s.repo.ResolveTags(ctx, []string{tag})
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve tag: %w", err)
+	}
+	return resolved[0], nil
 }
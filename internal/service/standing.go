@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+)
+
+// UserLookup is the part of the repository the standing check needs.
+type UserLookup interface {
+	GetUserByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+}
+
+// standingService rejects every write by a banned user with
+// domain.ErrBanned before it reaches the wrapped service. Reads pass
+// through, and so do writes by shadow-banned users: their content is stored
+// as usual and hidden from others by the repository's queries.
+type standingService struct {
+	Service
+	users UserLookup
+}
+
+// NewStandingService enforces user standing in front of next.
+func NewStandingService(next Service, users UserLookup) Service {
+	return &standingService{Service: next, users: users}
+}
+
+// requireNotBanned leaves a missing user or ID for the wrapped service to
+// reject as it normally would.
+func (s *standingService) requireNotBanned(ctx context.Context, userID uuid.UUID) error {
+	if userID == uuid.Nil {
+		return nil
+	}
+	user, err := s.users.GetUserByID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.Standing == domain.StandingBanned {
+		return domain.ErrBanned
+	}
+	return nil
+}
+
+func (s *standingService) CreatePoll(ctx context.Context, req *domain.CreatePollRequest) (*domain.Poll, error) {
+	if req != nil {
+		if err := s.requireNotBanned(ctx, req.CreatorID); err != nil {
+			return nil, err
+		}
+	}
+	return s.Service.CreatePoll(ctx, req)
+}
+
+func (s *standingService) UpdatePoll(ctx context.Context, pollID uuid.UUID, req *domain.UpdatePollRequest) (*domain.Poll, error) {
+	if req != nil {
+		if err := s.requireNotBanned(ctx, req.ActorID); err != nil {
+			return nil, err
+		}
+	}
+	return s.Service.UpdatePoll(ctx, pollID, req)
+}
+
+func (s *standingService) UpdatePollTags(ctx context.Context, pollID uuid.UUID, req *domain.UpdatePollTagsRequest) (*domain.Poll, error) {
+	if req != nil {
+		if err := s.requireNotBanned(ctx, req.ActorID); err != nil {
+			return nil, err
+		}
+	}
+	return s.Service.UpdatePollTags(ctx, pollID, req)
+}
+
+func (s *standingService) ReorderOptions(ctx context.Context, pollID uuid.UUID, req *domain.ReorderOptionsRequest) (*domain.Poll, error) {
+	if req != nil {
+		if err := s.requireNotBanned(ctx, req.ActorID); err != nil {
+			return nil, err
+		}
+	}
+	return s.Service.ReorderOptions(ctx, pollID, req)
+}
+
+func (s *standingService) SetOptionImage(ctx context.Context, pollID uuid.UUID, optionIndex int, actorID uuid.UUID, upload *domain.MediaUpload) (*domain.Poll, error) {
+	if err := s.requireNotBanned(ctx, actorID); err != nil {
+		return nil, err
+	}
+	return s.Service.SetOptionImage(ctx, pollID, optionIndex, actorID, upload)
+}
+
+func (s *standingService) UpdateOption(ctx context.Context, pollID uuid.UUID, optionIndex int, req *domain.UpdateOptionRequest) (*domain.Poll, error) {
+	if req != nil {
+		if err := s.requireNotBanned(ctx, req.ActorID); err != nil {
+			return nil, err
+		}
+	}
+	return s.Service.UpdateOption(ctx, pollID, optionIndex, req)
+}
+
+func (s *standingService) SignUpload(ctx context.Context, userID uuid.UUID, req *domain.SignUploadRequest) (*domain.SignedUpload, error) {
+	if err := s.requireNotBanned(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.Service.SignUpload(ctx, userID, req)
+}
+
+func (s *standingService) ConfirmUpload(ctx context.Context, userID uuid.UUID, req *domain.ConfirmUploadRequest) (*domain.UploadConfirmation, error) {
+	if err := s.requireNotBanned(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.Service.ConfirmUpload(ctx, userID, req)
+}
+
+func (s *standingService) ClosePoll(ctx context.Context, pollID, actorID uuid.UUID, admin bool) error {
+	if err := s.requireNotBanned(ctx, actorID); err != nil {
+		return err
+	}
+	return s.Service.ClosePoll(ctx, pollID, actorID, admin)
+}
+
+func (s *standingService) ChangePollStatus(ctx context.Context, pollID uuid.UUID, req *domain.PollStatusRequest) (*domain.Poll, error) {
+	if req != nil {
+		if err := s.requireNotBanned(ctx, req.ActorID); err != nil {
+			return nil, err
+		}
+	}
+	return s.Service.ChangePollStatus(ctx, pollID, req)
+}
+
+func (s *standingService) AddPollCollaborator(ctx context.Context, pollID uuid.UUID, req *domain.AddCollaboratorRequest) (*domain.Collaborator, error) {
+	if req != nil {
+		if err := s.requireNotBanned(ctx, req.ActorID); err != nil {
+			return nil, err
+		}
+	}
+	return s.Service.AddPollCollaborator(ctx, pollID, req)
+}
+
+func (s *standingService) RemovePollCollaborator(ctx context.Context, pollID, userID, actorID uuid.UUID) error {
+	if err := s.requireNotBanned(ctx, actorID); err != nil {
+		return err
+	}
+	return s.Service.RemovePollCollaborator(ctx, pollID, userID, actorID)
+}
+
+func (s *standingService) VoteOnPoll(ctx context.Context, pollID uuid.UUID, req *domain.VoteRequest) (*domain.VoteReceipt, error) {
+	if req != nil {
+		if err := s.requireNotBanned(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+	}
+	return s.Service.VoteOnPoll(ctx, pollID, req)
+}
+
+func (s *standingService) CastOrganizationVote(ctx context.Context, pollID uuid.UUID, req *domain.OrganizationVoteRequest) (*domain.OrganizationVote, error) {
+	if req != nil {
+		if err := s.requireNotBanned(ctx, req.ActorID); err != nil {
+			return nil, err
+		}
+	}
+	return s.Service.CastOrganizationVote(ctx, pollID, req)
+}
+
+func (s *standingService) UpdateVote(ctx context.Context, voteID uuid.UUID, req *domain.UpdateVoteRequest) error {
+	if req != nil {
+		if err := s.requireNotBanned(ctx, req.UserID); err != nil {
+			return err
+		}
+	}
+	return s.Service.UpdateVote(ctx, voteID, req)
+}
+
+func (s *standingService) DeleteVote(ctx context.Context, voteID, userID uuid.UUID) error {
+	if err := s.requireNotBanned(ctx, userID); err != nil {
+		return err
+	}
+	return s.Service.DeleteVote(ctx, voteID, userID)
+}
+
+func (s *standingService) SkipPoll(ctx context.Context, pollID uuid.UUID, req *domain.SkipRequest) error {
+	if req != nil {
+		if err := s.requireNotBanned(ctx, req.UserID); err != nil {
+			return err
+		}
+	}
+	return s.Service.SkipPoll(ctx, pollID, req)
+}
+
+func (s *standingService) SetUserAvatar(ctx context.Context, userID uuid.UUID, upload *domain.MediaUpload) (*domain.User, error) {
+	if err := s.requireNotBanned(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.Service.SetUserAvatar(ctx, userID, upload)
+}
+
+func (s *standingService) UpdateUser(ctx context.Context, user *domain.User) error {
+	if user != nil {
+		if err := s.requireNotBanned(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+	return s.Service.UpdateUser(ctx, user)
+}
+
+func (s *standingService) CreateOrganization(ctx context.Context, req *domain.CreateOrganizationRequest) (*domain.Organization, error) {
+	if req != nil {
+		if err := s.requireNotBanned(ctx, req.OwnerID); err != nil {
+			return nil, err
+		}
+	}
+	return s.Service.CreateOrganization(ctx, req)
+}
+
+func (s *standingService) AddOrganizationMember(ctx context.Context, orgID uuid.UUID, req *domain.AddMemberRequest) error {
+	if req != nil {
+		if err := s.requireNotBanned(ctx, req.ActorID); err != nil {
+			return err
+		}
+	}
+	return s.Service.AddOrganizationMember(ctx, orgID, req)
+}
+
+func (s *standingService) UpdateUserPreferences(ctx context.Context, userID uuid.UUID, prefs *domain.UserPreferences) (*domain.UserPreferences, error) {
+	if err := s.requireNotBanned(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.Service.UpdateUserPreferences(ctx, userID, prefs)
+}
+
+// SetUserStanding bans, shadow-bans or reinstates a user.
+func (s *service) SetUserStanding(ctx context.Context, userID uuid.UUID, standing domain.UserStanding) error {
+	if !standing.Valid() {
+		return domain.ErrInvalidInput
+	}
+	return s.repo.SetUserStanding(ctx, userID, standing)
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/behzadon/vote/internal/metrics"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// WithStatsRefresh recomputes cached poll stats in the background once they
+// are within window of expiring. Readers keep getting the cached stats
+// meanwhile, so busy polls never wait on a recomputation when their entry
+// expires. A window of zero leaves stats to be recomputed on a cache miss.
+func WithStatsRefresh(window time.Duration) Option {
+	return func(s *service) {
+		if window > 0 {
+			s.statsRefreshWindow = window
+		}
+	}
+}
+
+// statsRefreshes tracks the polls whose stats are being refreshed, so a
+// busy poll is only recomputed by one goroutine at a time.
+type statsRefreshes struct {
+	mu      sync.Mutex
+	running map[uuid.UUID]struct{}
+}
+
+// start reports whether the caller should refresh pollID's stats, in which
+// case it must call finish when done.
+func (r *statsRefreshes) start(pollID uuid.UUID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running == nil {
+		r.running = make(map[uuid.UUID]struct{})
+	}
+	if _, ok := r.running[pollID]; ok {
+		return false
+	}
+	r.running[pollID] = struct{}{}
+	return true
+}
+
+func (r *statsRefreshes) finish(pollID uuid.UUID) {
+	r.mu.Lock()
+	delete(r.running, pollID)
+	r.mu.Unlock()
+}
+
+// refreshExpiringStats starts a background refresh of cached stats that are
+// about to expire. The refresh outlives the request that noticed it.
+func (s *service) refreshExpiringStats(ctx context.Context, stats *domain.PollStats) {
+	if s.statsRefreshWindow <= 0 || stats.ComputedAt.IsZero() {
+		return
+	}
+	if time.Since(stats.ComputedAt) < domain.StatsCacheTTL-s.statsRefreshWindow {
+		return
+	}
+	if !s.statsRefreshes.start(stats.PollID) {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer s.statsRefreshes.finish(stats.PollID)
+
+		fresh, err := s.repo.GetPollStats(ctx, stats.PollID)
+		if err != nil {
+			metrics.PollStatsRefreshes.WithLabelValues("failed").Inc()
+			logging.For(ctx, s.logger).Warn("Failed to refresh poll stats",
+				zap.String("poll_id", stats.PollID.String()),
+				zap.Error(err),
+			)
+			return
+		}
+		if fresh.ComputedAt.IsZero() {
+			fresh.ComputedAt = time.Now().UTC()
+		}
+		s.cachePollStats(ctx, fresh)
+		metrics.PollStatsRefreshes.WithLabelValues("refreshed").Inc()
+	}()
+}
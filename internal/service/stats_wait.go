@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// WithStatsWatcher bumps a poll's stats version on every vote change and
+// enables WaitPollStats.
+func WithStatsWatcher(watcher domain.StatsWatcher) Option {
+	return func(s *service) {
+		s.statsWatcher = watcher
+	}
+}
+
+// statsChanged drops the cached stats of a poll whose votes changed and
+// wakes clients waiting on them. Both are best effort: the vote itself has
+// already been stored.
+func (s *service) statsChanged(ctx context.Context, pollID uuid.UUID) {
+	if err := s.repo.InvalidatePollStatsCache(ctx, pollID); err != nil {
+		logging.For(ctx, s.logger).Warn("Failed to invalidate poll stats cache",
+			zap.Error(err),
+			zap.String("poll_id", pollID.String()),
+		)
+	}
+	s.bumpStatsVersion(ctx, pollID)
+}
+
+func (s *service) bumpStatsVersion(ctx context.Context, pollID uuid.UUID) {
+	if s.statsWatcher == nil {
+		return
+	}
+	if _, err := s.statsWatcher.BumpStatsVersion(ctx, pollID); err != nil {
+		logging.For(ctx, s.logger).Warn("Failed to bump poll stats version",
+			zap.Error(err),
+			zap.String("poll_id", pollID.String()),
+		)
+	}
+}
+
+// WaitPollStats blocks until the poll's stats version differs from since or
+// timeout passes. A timeout is not an error: the update then reports the
+// unchanged version without stats. Polls whose results are hidden from
+// actorID are rejected before waiting.
+func (s *service) WaitPollStats(ctx context.Context, pollID, actorID uuid.UUID, since int64, timeout time.Duration) (*domain.StatsUpdate, error) {
+	if s.statsWatcher == nil {
+		return nil, domain.ErrStatsWaitUnavailable
+	}
+	poll, err := s.repo.GetPollByID(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireDraftVisible(ctx, poll); err != nil {
+		return nil, err
+	}
+	if err := s.requireResultsVisible(ctx, poll, actorID); err != nil {
+		return nil, err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	version, err := s.statsWatcher.WaitStatsVersion(waitCtx, pollID, since)
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		return &domain.StatsUpdate{Version: since}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.pollStats(ctx, poll, domain.StatsQuery{})
+	if err != nil {
+		return nil, err
+	}
+	s.attachMyOption(ctx, poll, stats, actorID)
+	return &domain.StatsUpdate{Version: version, Changed: true, Stats: stats}, nil
+}
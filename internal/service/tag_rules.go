@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/behzadon/vote/internal/auth"
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func (s *service) GetTagRules(ctx context.Context) ([]domain.TagRule, error) {
+	return s.repo.GetTagRules(ctx)
+}
+
+// SetTagRule replaces the posting rule of tag. It applies to polls created
+// or published from then on; polls already live are left alone.
+func (s *service) SetTagRule(ctx context.Context, tag string, req *domain.TagRuleRequest) (*domain.TagRule, error) {
+	if req == nil {
+		return nil, domain.ErrInvalidInput
+	}
+	tag, err := s.canonicalTag(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &domain.TagRule{
+		Tag:           tag,
+		Restricted:    req.Restricted,
+		AutoModerated: req.AutoModerated,
+		UpdatedAt:     time.Now().UTC(),
+	}
+	if req.ActorID != uuid.Nil {
+		actorID := req.ActorID
+		rule.UpdatedBy = &actorID
+	}
+	if err := s.repo.SetTagRule(ctx, rule); err != nil {
+		return nil, fmt.Errorf("failed to set tag rule: %w", err)
+	}
+
+	logging.For(ctx, s.logger).Info("Tag rule set",
+		zap.String("tag", tag),
+		zap.Bool("restricted", rule.Restricted),
+		zap.Bool("auto_moderated", rule.AutoModerated),
+		zap.String("user_id", req.ActorID.String()),
+	)
+	return rule, nil
+}
+
+func (s *service) DeleteTagRule(ctx context.Context, tag string) error {
+	tag, err := s.canonicalTag(ctx, tag)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteTagRule(ctx, tag)
+}
+
+// checkTagRules applies the rules of the poll's tags to posterID, who is
+// creating or publishing it, and returns the auto-moderated tags the poll
+// has to be reviewed for. Moderators and admins are exempt from both rules.
+func (s *service) checkTagRules(ctx context.Context, poll *domain.Poll, posterID uuid.UUID) ([]string, error) {
+	rules, err := s.repo.GetTagRulesFor(ctx, poll.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag rules: %w", err)
+	}
+	if len(rules) == 0 || isModerator(ctx, posterID) {
+		return nil, nil
+	}
+
+	var restricted string
+	var reviewTags []string
+	for _, rule := range rules {
+		if rule.Restricted && restricted == "" {
+			restricted = rule.Tag
+		}
+		if rule.AutoModerated {
+			reviewTags = append(reviewTags, rule.Tag)
+		}
+	}
+	if restricted != "" {
+		verified, err := s.hasVerifiedEmail(ctx, posterID)
+		if err != nil {
+			return nil, err
+		}
+		if !verified {
+			return nil, &domain.TagRestrictedError{Tag: restricted}
+		}
+	}
+	return reviewTags, nil
+}
+
+// isModerator reports whether userID is the current user and holds the
+// moderator or admin role.
+func isModerator(ctx context.Context, userID uuid.UUID) bool {
+	principal, ok := auth.CurrentUser(ctx)
+	if !ok || principal.ID != userID {
+		return false
+	}
+	return principal.Role == domain.RoleModerator || principal.Role == domain.RoleAdmin
+}
+
+func (s *service) hasVerifiedEmail(ctx context.Context, userID uuid.UUID) (bool, error) {
+	if userID == uuid.Nil {
+		return false, nil
+	}
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return user.EmailVerified, nil
+}
+
+// checkPublish applies the tag rules to a draft actorID is publishing. A
+// draft posted to an auto-moderated tag is only published once a moderator
+// has approved it; the first attempt queues it for review.
+func (s *service) checkPublish(ctx context.Context, poll *domain.Poll, actorID uuid.UUID) error {
+	reviewTags, err := s.checkTagRules(ctx, poll, actorID)
+	if err != nil || len(reviewTags) == 0 {
+		return err
+	}
+
+	flag, err := s.repo.GetPollReviewFlag(ctx, poll.ID)
+	if errors.Is(err, domain.ErrNotFound) {
+		if err := s.requestReview(ctx, poll, actorID, reviewTags); err != nil {
+			return err
+		}
+		return domain.ErrPollInReview
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get poll review: %w", err)
+	}
+	switch flag.Status {
+	case domain.FlagDismissed:
+		return nil
+	case domain.FlagUpheld:
+		return domain.ErrPollRejected
+	}
+	return domain.ErrPollInReview
+}
+
+// checkTagChange applies the tag rules to a poll whose tags actorID has
+// just changed from before. It returns the auto-moderated tags the poll has
+// to be reviewed for, counting only tags it did not already carry: polls
+// that are live on those were approved, or predate the rule.
+func (s *service) checkTagChange(ctx context.Context, poll *domain.Poll, before []string, actorID uuid.UUID) ([]string, error) {
+	reviewTags, err := s.checkTagRules(ctx, poll, actorID)
+	if err != nil {
+		return nil, err
+	}
+	added := reviewTags[:0]
+	for _, tag := range reviewTags {
+		if !slices.Contains(before, tag) {
+			added = append(added, tag)
+		}
+	}
+	return added, nil
+}
+
+// holdForReview takes a published poll that was just given auto-moderated
+// tags back to draft and queues it for a moderator, as CreatePoll does with
+// new polls. Drafts are left alone; they are queued when published.
+func (s *service) holdForReview(ctx context.Context, poll *domain.Poll, actorID uuid.UUID, reviewTags []string) error {
+	now := time.Now().UTC()
+	if len(reviewTags) == 0 || poll.StatusAt(now) == domain.PollStatusDraft {
+		return nil
+	}
+	if err := s.repo.SetPollStatus(ctx, poll.ID, domain.PollStatusDraft, now); err != nil {
+		return fmt.Errorf("failed to hold poll for review: %w", err)
+	}
+	poll.Status = domain.PollStatusDraft
+	poll.PendingReview = true
+	// Publishing the draft queues it again if this fails.
+	if err := s.requestReview(ctx, poll, actorID, reviewTags); err != nil {
+		logging.For(ctx, s.logger).Error("Failed to hold poll for review", zap.Error(err), zap.String("poll_id", poll.ID.String()))
+	}
+	return nil
+}
+
+// requestReview queues the poll for a moderator, naming the tags that
+// required it.
+func (s *service) requestReview(ctx context.Context, poll *domain.Poll, posterID uuid.UUID, reviewTags []string) error {
+	flag := &domain.ModerationFlag{
+		ID:        uuid.New(),
+		Kind:      domain.ContentPollReview,
+		Content:   poll.Title,
+		PollID:    &poll.ID,
+		Reasons:   make([]string, len(reviewTags)),
+		Status:    domain.FlagOpen,
+		CreatedAt: time.Now().UTC(),
+	}
+	if posterID != uuid.Nil {
+		flag.AuthorID = &posterID
+	}
+	for i, tag := range reviewTags {
+		flag.Reasons[i] = "auto_moderated_tag:" + tag
+	}
+	if err := s.repo.CreateModerationFlag(ctx, flag); err != nil {
+		return fmt.Errorf("failed to queue poll for review: %w", err)
+	}
+
+	logging.For(ctx, s.logger).Info("Poll held for review",
+		zap.String("poll_id", poll.ID.String()),
+		zap.Strings("tags", reviewTags),
+	)
+	return nil
+}
+
+// publishReviewedPoll publishes a draft a moderator approved. The poster
+// asked for it to go live when it was queued, so it is not left for them to
+// publish again. A poll deleted or published in the meantime stays as it is.
+func (s *service) publishReviewedPoll(ctx context.Context, pollID, moderatorID uuid.UUID) {
+	_, err := s.ChangePollStatus(ctx, pollID, &domain.PollStatusRequest{
+		Status:  domain.PollStatusLive,
+		ActorID: moderatorID,
+		Admin:   true,
+	})
+	if err != nil {
+		logging.For(ctx, s.logger).Warn("Failed to publish approved poll",
+			zap.Error(err),
+			zap.String("poll_id", pollID.String()),
+		)
+	}
+}
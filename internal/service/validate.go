@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/pollhook"
+)
+
+// ValidatePoll runs every check CreatePoll applies to req without creating
+// anything, returning all field violations rather than the first.
+func (s *service) ValidatePoll(ctx context.Context, req *domain.CreatePollRequest) ([]*domain.ValidationError, error) {
+	if req == nil {
+		return nil, domain.ErrInvalidInput
+	}
+
+	errs := s.validator.CheckCreate(req)
+	errs = append(errs, checkPollSettings(req, time.Now())...)
+
+	if req.OrganizationID != nil {
+		err := s.requireOrganizationAdmin(ctx, *req.OrganizationID, req.CreatorID)
+		switch {
+		case errors.Is(err, domain.ErrForbidden):
+			errs = append(errs, &domain.ValidationError{Field: "organizationId", Reason: "only organization admins can create polls for it"})
+		case err != nil:
+			return nil, err
+		}
+	}
+	return errs, nil
+}
+
+// checkPollSettings validates the kind, schedule, electorate, vote change
+// policy, results visibility, countries, default option and webhook of req.
+func checkPollSettings(req *domain.CreatePollRequest, now time.Time) []*domain.ValidationError {
+	var errs []*domain.ValidationError
+	invalid := func(field, reason string) {
+		errs = append(errs, &domain.ValidationError{Field: field, Reason: reason})
+	}
+
+	kind := pollKind(req)
+	if kind != domain.PollKindStandard && kind != domain.PollKindElection {
+		invalid("kind", "must be standard or election")
+	}
+
+	switch {
+	case req.EndsAt == nil && kind == domain.PollKindElection:
+		invalid("endsAt", "is required for elections")
+	case req.EndsAt == nil:
+	case !req.EndsAt.After(now):
+		invalid("endsAt", "must be in the future")
+	case req.StartsAt != nil && !req.EndsAt.After(*req.StartsAt):
+		invalid("endsAt", "must be after startsAt")
+	}
+
+	switch {
+	case kind == domain.PollKindElection && len(req.EligibleEmails) == 0:
+		invalid("eligibleEmails", "is required for elections")
+	case kind != domain.PollKindElection && req.OrganizationID == nil && len(req.EligibleEmails) > 0:
+		invalid("eligibleEmails", "is only allowed on elections and organization polls")
+	}
+
+	switch {
+	case req.VoteChange == "":
+	case !req.VoteChange.Valid():
+		invalid("voteChange", "must be allowed, until_close or disallowed")
+	case kind == domain.PollKindElection && req.VoteChange != domain.VoteChangeDisallowed:
+		invalid("voteChange", "elections do not allow changing votes")
+	}
+
+	switch {
+	case req.VoteChangeCooldownSeconds == 0:
+	case req.VoteChangeCooldownSeconds < 0 || time.Duration(req.VoteChangeCooldownSeconds)*time.Second > domain.MaxVoteChangeCooldown:
+		invalid("voteChangeCooldownSeconds", "must be between 0 and one week")
+	case kind == domain.PollKindElection || req.VoteChange == domain.VoteChangeDisallowed:
+		invalid("voteChangeCooldownSeconds", "requires a poll that allows changing votes")
+	}
+
+	if req.ResultsVisibility != "" && !req.ResultsVisibility.Valid() {
+		invalid("resultsVisibility", "must be always, after_vote or after_close")
+	}
+
+	if _, err := normalizeCountries(req.AllowedCountries); err != nil {
+		invalid("allowedCountries", "must be ISO 3166-1 alpha-2 codes")
+	}
+
+	if d := req.DefaultOption; d != nil && (*d < 0 || *d >= len(req.Options)) {
+		invalid("defaultOption", "must be the index of one of the options")
+	}
+
+	if req.Webhook != nil {
+		if err := pollhook.CheckURL(req.Webhook.URL); err != nil {
+			invalid("webhook.url", err.Error())
+		}
+	}
+	return errs
+}
+
+func pollKind(req *domain.CreatePollRequest) domain.PollKind {
+	if req.Kind == "" {
+		return domain.PollKindStandard
+	}
+	return req.Kind
+}
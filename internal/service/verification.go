@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const defaultVerificationTTL = 48 * time.Hour
+
+// WithEmailVerification sets how long verification links stay valid and
+// whether users have to verify their address before they can vote.
+func WithEmailVerification(ttl time.Duration, requiredToVote bool) Option {
+	return func(s *service) {
+		if ttl > 0 {
+			s.verificationTTL = ttl
+		}
+		s.verificationRequired = requiredToVote
+	}
+}
+
+func hashVerificationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newMailToken returns a random token for a link sent by mail.
+func newMailToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate mail token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// sendEmailVerification stores a new verification token for the user and
+// asks the mailer to send it. Earlier tokens stay valid until they expire.
+func (s *service) sendEmailVerification(ctx context.Context, user *domain.User) error {
+	token, err := newMailToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	record := &domain.EmailVerificationToken{
+		UserID:    user.ID,
+		TokenHash: hashVerificationToken(token),
+		ExpiresAt: now.Add(s.verificationTTL),
+		CreatedAt: now,
+	}
+	if err := s.repo.CreateEmailVerificationToken(ctx, record); err != nil {
+		return err
+	}
+
+	return s.publisher.PublishEmailVerificationRequested(ctx, &domain.EmailVerification{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Token:     token,
+		ExpiresAt: record.ExpiresAt,
+	})
+}
+
+// ResendEmailVerification sends the user a new verification link.
+func (s *service) ResendEmailVerification(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return fmt.Errorf("%w: email address is already verified", domain.ErrInvalidInput)
+	}
+	return s.sendEmailVerification(ctx, user)
+}
+
+// VerifyEmail marks the address of the token's owner as verified. Unknown
+// and expired tokens are domain.ErrNotFound.
+func (s *service) VerifyEmail(ctx context.Context, token string) error {
+	if token == "" {
+		return fmt.Errorf("%w: token is required", domain.ErrInvalidInput)
+	}
+	userID, err := s.repo.VerifyEmail(ctx, hashVerificationToken(token), time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	logging.For(ctx, s.logger).Info("Email address verified", zap.String("user_id", userID.String()))
+	return nil
+}
+
+// requireVerifiedEmail leaves a missing user for the caller to reject as it
+// normally would.
+func (s *service) requireVerifiedEmail(ctx context.Context, userID uuid.UUID) error {
+	if !s.verificationRequired {
+		return nil
+	}
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !user.EmailVerified {
+		return domain.ErrEmailNotVerified
+	}
+	return nil
+}
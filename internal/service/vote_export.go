@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+)
+
+// voteExportPageSize is how many votes are read per query while exporting a
+// poll.
+const voteExportPageSize = 500
+
+// ExportPollVotes calls fn for every vote on the poll, oldest first. Voters
+// are left out of anonymous polls. Iteration stops at the first error
+// returned by fn.
+func (s *service) ExportPollVotes(ctx context.Context, pollID uuid.UUID, q domain.VoteExportQuery, fn func(*domain.ExportedVote) error) error {
+	poll, err := s.repo.GetPollByID(ctx, pollID)
+	if err != nil {
+		return err
+	}
+	if !q.Admin && !isPollCreator(poll, q.ActorID) {
+		return domain.ErrForbidden
+	}
+
+	var after *domain.VoteKey
+	for {
+		votes, err := s.repo.GetPollVotesAfter(ctx, pollID, after, voteExportPageSize)
+		if err != nil {
+			return err
+		}
+		for i := range votes {
+			if err := fn(exportedVote(poll, &votes[i])); err != nil {
+				return err
+			}
+		}
+		if len(votes) < voteExportPageSize {
+			return nil
+		}
+		last := votes[len(votes)-1]
+		after = &domain.VoteKey{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+func exportedVote(poll *domain.Poll, vote *domain.Vote) *domain.ExportedVote {
+	exported := &domain.ExportedVote{
+		VoteID:      vote.ID,
+		PollID:      vote.PollID,
+		OptionID:    vote.OptionID,
+		OptionIndex: vote.OptionIndex,
+		OptionText:  vote.OptionText,
+		CreatedAt:   vote.CreatedAt,
+	}
+	if !poll.Anonymous {
+		voterID := vote.UserID
+		exported.VoterID = &voterID
+	}
+	return exported
+}
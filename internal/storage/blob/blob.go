@@ -0,0 +1,146 @@
+// Package blob stores media such as avatars, option images and rendered
+// poll preview cards in object storage: S3, Google Cloud Storage or a local
+// directory.
+package blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+	// The upload checks are reported to clients, so their errors are the
+	// domain's.
+	ErrTooLarge        = domain.ErrTooLarge
+	ErrUnsupportedType = domain.ErrUnsupportedType
+)
+
+// Store is implemented by each storage driver.
+type Store interface {
+	// Put stores exactly size bytes read from body under key.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Stat returns ErrNotFound for a missing object. The content type is
+	// sniffed from the stored bytes, not taken from upload metadata.
+	Stat(ctx context.Context, key string) (*Object, error)
+	// Delete removes the object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// List calls fn for every object whose key starts with prefix.
+	List(ctx context.Context, prefix string, fn func(Object) error) error
+	// SignedURL returns a URL that allows method on the object until it
+	// expires, without further credentials.
+	SignedURL(method, key string, expires time.Duration) (string, error)
+}
+
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Kind is the kind of media an object holds. It determines the key prefix
+// and the upload policy.
+type Kind string
+
+const (
+	KindAvatar      Kind = "avatars"
+	KindOptionImage Kind = "option-images"
+	// KindPollCard holds preview images rendered by the server. Clients
+	// cannot upload them.
+	KindPollCard Kind = "poll-cards"
+)
+
+var Kinds = []Kind{KindAvatar, KindOptionImage, KindPollCard}
+
+type Policy struct {
+	MaxSize      int64
+	ContentTypes []string
+}
+
+var policies = map[Kind]Policy{
+	KindAvatar: {
+		MaxSize:      2 << 20,
+		ContentTypes: []string{"image/jpeg", "image/png", "image/webp"},
+	},
+	KindOptionImage: {
+		MaxSize:      5 << 20,
+		ContentTypes: []string{"image/jpeg", "image/png", "image/webp", "image/gif"},
+	},
+	KindPollCard: {
+		MaxSize:      1 << 20,
+		ContentTypes: []string{"image/png"},
+	},
+}
+
+func (k Kind) Valid() bool {
+	_, ok := policies[k]
+	return ok
+}
+
+func (k Kind) Policy() Policy {
+	return policies[k]
+}
+
+// NewKey returns a fresh, unguessable key for an object of this kind.
+func (k Kind) NewKey() string {
+	return string(k) + "/" + uuid.New().String()
+}
+
+// Owns reports whether key was created by NewKey for this kind.
+func (k Kind) Owns(key string) bool {
+	id, ok := strings.CutPrefix(key, string(k)+"/")
+	if !ok {
+		return false
+	}
+	_, err := uuid.Parse(id)
+	return err == nil
+}
+
+func (p Policy) Validate(size int64, contentType string) error {
+	if size <= 0 || size > p.MaxSize {
+		return fmt.Errorf("%w: %d bytes, at most %d allowed", ErrTooLarge, size, p.MaxSize)
+	}
+	for _, allowed := range p.ContentTypes {
+		if contentType == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrUnsupportedType, contentType)
+}
+
+// Sniff detects the content type of body from its first bytes, the same
+// way browsers do, and returns a reader that still yields the whole body.
+// Uploads are checked against the sniffed type rather than the declared one.
+func Sniff(body io.Reader) (string, io.Reader, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(body, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", nil, fmt.Errorf("read content: %w", err)
+	}
+	head = head[:n]
+	contentType, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	return contentType, io.MultiReader(bytes.NewReader(head), body), nil
+}
+
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
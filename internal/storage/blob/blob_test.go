@@ -0,0 +1,182 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestPolicyValidate(t *testing.T) {
+	policy := KindAvatar.Policy()
+
+	assert.NoError(t, policy.Validate(1024, "image/png"))
+	assert.ErrorIs(t, policy.Validate(policy.MaxSize+1, "image/png"), ErrTooLarge)
+	assert.ErrorIs(t, policy.Validate(0, "image/png"), ErrTooLarge)
+	assert.ErrorIs(t, policy.Validate(1024, "image/gif"), ErrUnsupportedType)
+	assert.NoError(t, KindOptionImage.Policy().Validate(1024, "image/gif"))
+}
+
+func TestSniff(t *testing.T) {
+	contentType, body, err := Sniff(bytes.NewReader(pngHeader))
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+
+	rest, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, pngHeader, rest)
+
+	contentType, _, err = Sniff(bytes.NewReader([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"/>")))
+	require.NoError(t, err)
+	assert.NotEqual(t, "image/png", contentType)
+}
+
+func TestKindKeys(t *testing.T) {
+	key := KindAvatar.NewKey()
+	assert.True(t, KindAvatar.Owns(key))
+	assert.False(t, KindOptionImage.Owns(key))
+	assert.False(t, KindAvatar.Owns("avatars/../secrets"))
+
+	for _, key := range []string{"", "/abs", "a/../b", "a//b", `a\b`} {
+		assert.False(t, validKey(key), key)
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocal(t.TempDir(), "http://localhost/media", "secret")
+	require.NoError(t, err)
+
+	key := KindAvatar.NewKey()
+	require.NoError(t, store.Put(ctx, key, bytes.NewReader(pngHeader), int64(len(pngHeader)), "image/png"))
+
+	obj, err := store.Stat(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(pngHeader)), obj.Size)
+	assert.Equal(t, "image/png", obj.ContentType)
+
+	var listed []string
+	require.NoError(t, store.List(ctx, "avatars/", func(obj Object) error {
+		listed = append(listed, obj.Key)
+		return nil
+	}))
+	assert.Equal(t, []string{key}, listed)
+
+	err = store.Put(ctx, KindAvatar.NewKey(), bytes.NewReader(pngHeader), 4, "image/png")
+	assert.Error(t, err, "size mismatch")
+	_, err = store.Stat(ctx, "../outside")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	require.NoError(t, store.Delete(ctx, key))
+	require.NoError(t, store.Delete(ctx, key))
+	_, err = store.Stat(ctx, key)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLocalStoreHandler(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocal(t.TempDir(), "http://localhost/media", "secret")
+	require.NoError(t, err)
+	handler := http.StripPrefix("/media", store.Handler(1024))
+
+	serve := func(method, rawURL string, body []byte) *httptest.ResponseRecorder {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		req := httptest.NewRequest(method, u.RequestURI(), bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	key := KindOptionImage.NewKey()
+	putURL, err := store.SignedURL(http.MethodPut, key, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, serve(http.MethodPut, putURL, pngHeader).Code)
+
+	_, err = store.Stat(ctx, key)
+	require.NoError(t, err)
+
+	t.Run("signature is bound to the method", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, putURL, nil).Code)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		getURL, err := store.SignedURL(http.MethodGet, key, time.Minute)
+		require.NoError(t, err)
+		w := serve(http.MethodGet, getURL, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, pngHeader, w.Body.Bytes())
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		getURL, err := store.SignedURL(http.MethodGet, key, -time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, getURL, nil).Code)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		bigURL, err := store.SignedURL(http.MethodPut, KindOptionImage.NewKey(), time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, serve(http.MethodPut, bigURL, make([]byte, 2048)).Code)
+	})
+}
+
+type stubReferences struct {
+	referenced map[string]bool
+}
+
+func (s stubReferences) UnreferencedMediaKeys(ctx context.Context, keys []string) ([]string, error) {
+	var orphans []string
+	for _, key := range keys {
+		if !s.referenced[key] {
+			orphans = append(orphans, key)
+		}
+	}
+	return orphans, nil
+}
+
+func TestCollectGarbage(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	store, err := NewLocal(root, "http://localhost/media", "secret")
+	require.NoError(t, err)
+
+	put := func(key string, age time.Duration) {
+		require.NoError(t, store.Put(ctx, key, bytes.NewReader(pngHeader), int64(len(pngHeader)), "image/png"))
+		modTime := time.Now().Add(-age)
+		require.NoError(t, os.Chtimes(filepath.Join(root, filepath.FromSlash(key)), modTime, modTime))
+	}
+
+	kept := KindAvatar.NewKey()
+	orphan := KindAvatar.NewKey()
+	fresh := KindOptionImage.NewKey()
+	foreign := "avatars/not-a-uuid"
+	put(kept, 48*time.Hour)
+	put(orphan, 48*time.Hour)
+	put(fresh, time.Minute)
+	put(foreign, 48*time.Hour)
+
+	refs := stubReferences{referenced: map[string]bool{kept: true}}
+	deleted, err := CollectGarbage(ctx, store, refs, 24*time.Hour, zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	_, err = store.Stat(ctx, orphan)
+	assert.ErrorIs(t, err, ErrNotFound)
+	for _, key := range []string{kept, fresh, foreign} {
+		_, err := store.Stat(ctx, key)
+		assert.NoError(t, err, key)
+	}
+}
@@ -0,0 +1,63 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const gcBatchSize = 100
+
+// ReferenceChecker reports which of the given keys nothing points at.
+type ReferenceChecker interface {
+	UnreferencedMediaKeys(ctx context.Context, keys []string) ([]string, error)
+}
+
+// CollectGarbage deletes media objects that are older than grace and not
+// referenced by any user, poll option or preview card, e.g. replaced
+// avatars, superseded cards or uploads that were never attached. The grace period keeps uploads that are still
+// waiting to be attached. It returns the number of objects deleted.
+func CollectGarbage(ctx context.Context, store Store, refs ReferenceChecker, grace time.Duration, logger *zap.Logger) (int, error) {
+	cutoff := time.Now().Add(-grace)
+	deleted := 0
+
+	sweep := func(batch []string) error {
+		orphans, err := refs.UnreferencedMediaKeys(ctx, batch)
+		if err != nil {
+			return fmt.Errorf("check references: %w", err)
+		}
+		for _, key := range orphans {
+			if err := store.Delete(ctx, key); err != nil {
+				return err
+			}
+			deleted++
+			logger.Debug("Deleted orphaned media", zap.String("key", key))
+		}
+		return nil
+	}
+
+	for _, kind := range Kinds {
+		var batch []string
+		err := store.List(ctx, string(kind)+"/", func(obj Object) error {
+			if obj.ModTime.After(cutoff) || !kind.Owns(obj.Key) {
+				return nil
+			}
+			batch = append(batch, obj.Key)
+			if len(batch) < gcBatchSize {
+				return nil
+			}
+			err := sweep(batch)
+			batch = batch[:0]
+			return err
+		})
+		if err == nil && len(batch) > 0 {
+			err = sweep(batch)
+		}
+		if err != nil {
+			return deleted, fmt.Errorf("collect %s: %w", kind, err)
+		}
+	}
+	return deleted, nil
+}
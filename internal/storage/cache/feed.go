@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// FeedCache keeps the feed pages recently served to each user, so users who
+// refresh often don't rerun the feed query every time. A user's pages share
+// one Redis hash and are dropped together when they vote or skip.
+type FeedCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func NewFeedCache(client *redis.Client, ttl time.Duration) *FeedCache {
+	return &FeedCache{client: client, ttl: ttl}
+}
+
+// FeedPage is one cached page of a user's feed.
+type FeedPage struct {
+	Polls    []domain.Poll `json:"polls"`
+	Total    int           `json:"total"`
+	CachedAt time.Time     `json:"cachedAt"`
+}
+
+// Get returns the cached page for q, or nil if there is none younger than
+// the TTL. Writing a page renews the whole hash, so the age of each page is
+// checked on its own.
+func (c *FeedCache) Get(ctx context.Context, q domain.FeedQuery) (*FeedPage, error) {
+	data, err := c.client.HGet(ctx, UserFeedKey(q.UserID), feedField(q)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get feed page from cache: %w", err)
+	}
+
+	var page FeedPage
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, fmt.Errorf("unmarshal feed page: %w", err)
+	}
+	if time.Since(page.CachedAt) >= c.ttl {
+		return nil, nil
+	}
+	return &page, nil
+}
+
+func (c *FeedCache) Set(ctx context.Context, q domain.FeedQuery, polls []domain.Poll, total int) error {
+	data, err := json.Marshal(FeedPage{Polls: polls, Total: total, CachedAt: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("marshal feed page: %w", err)
+	}
+
+	key := UserFeedKey(q.UserID)
+	_, err = c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, feedField(q), data)
+		pipe.Expire(ctx, key, c.ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("set feed page in cache: %w", err)
+	}
+	return nil
+}
+
+// Invalidate drops every cached page of the user's feed.
+func (c *FeedCache) Invalidate(ctx context.Context, userID uuid.UUID) error {
+	return c.client.Del(ctx, UserFeedKey(userID)).Err()
+}
+
+// feedField identifies the page q asks for within the user's hash.
+func feedField(q domain.FeedQuery) string {
+	position := "p" + strconv.Itoa(q.Page)
+	if q.After != nil {
+		position = "c" + q.After.Encode()
+	}
+	languages := strings.Join(q.Languages, ",")
+	if q.AllLanguages {
+		languages = "*"
+	}
+	return q.Tag + "|" + position + "|" + strconv.Itoa(q.Limit) + "|" + string(q.Total) + "|" + languages
+}
@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeedField_DistinguishesPages(t *testing.T) {
+	base := domain.FeedQuery{UserID: uuid.New(), Page: 1, Limit: 10}
+	cursor := &domain.FeedCursor{CreatedAt: time.Now(), ID: uuid.New()}
+
+	fields := map[string]bool{}
+	for _, edit := range []func(q *domain.FeedQuery){
+		func(q *domain.FeedQuery) {},
+		func(q *domain.FeedQuery) { q.Page = 2 },
+		func(q *domain.FeedQuery) { q.Limit = 20 },
+		func(q *domain.FeedQuery) { q.Tag = "go" },
+		func(q *domain.FeedQuery) { q.Total = domain.FeedTotalNone },
+		func(q *domain.FeedQuery) { q.After = cursor },
+		func(q *domain.FeedQuery) { q.Languages = []string{"en"} },
+		func(q *domain.FeedQuery) { q.AllLanguages = true },
+	} {
+		q := base
+		edit(&q)
+		fields[feedField(q)] = true
+	}
+	assert.Len(t, fields, 8)
+
+	// The page number is ignored once a cursor is given.
+	withCursor := base
+	withCursor.After = cursor
+	otherPage := withCursor
+	otherPage.Page = 3
+	assert.Equal(t, feedField(withCursor), feedField(otherPage))
+}
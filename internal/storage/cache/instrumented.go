@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/behzadon/vote/internal/metrics"
+	"github.com/go-redis/redis/v8"
+)
+
+var keyFamilies = []struct {
+	prefix string
+	family string
+}{
+	{"poll:stats:", "stats"},
+	{"poll:", "poll"},
+	{"feed:", "feed"},
+	{"trending:", "trending"},
+	{"user:daily:votes:", "daily_votes"},
+	{"user:votes:recent:", "daily_votes"},
+	{"rate_limit:", "rate_limit"},
+	{"burst_limit:", "rate_limit"},
+	{"scheduler:", "scheduler"},
+	{"stats_version:", "stats_version"},
+}
+
+func keyFamily(key string) string {
+	key = strings.TrimPrefix(key, KeyVersion+":")
+	for _, f := range keyFamilies {
+		if strings.HasPrefix(key, f.prefix) {
+			return f.family
+		}
+	}
+	return "other"
+}
+
+type startKey struct{}
+
+type metricsHook struct{}
+
+// Instrument attaches latency and hit/miss metrics to every command issued
+// through client, including pipelined ones.
+func Instrument(client *redis.Client) *redis.Client {
+	client.AddHook(metricsHook{})
+	return client
+}
+
+func (metricsHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startKey{}, time.Now()), nil
+}
+
+func (metricsHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	if start, ok := ctx.Value(startKey{}).(time.Time); ok {
+		observeCommand(cmd, time.Since(start))
+	}
+	return nil
+}
+
+func (metricsHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startKey{}, time.Now()), nil
+}
+
+func (metricsHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	start, ok := ctx.Value(startKey{}).(time.Time)
+	if !ok {
+		return nil
+	}
+	elapsed := time.Since(start)
+	for _, cmd := range cmds {
+		observeCommand(cmd, elapsed)
+	}
+	return nil
+}
+
+func observeCommand(cmd redis.Cmder, elapsed time.Duration) {
+	err := cmd.Err()
+	status := "success"
+	if err != nil && !errors.Is(err, redis.Nil) {
+		status = "error"
+	}
+	metrics.RedisCommandDuration.WithLabelValues(cmd.Name(), status).Observe(elapsed.Seconds())
+
+	if (cmd.Name() != "get" && cmd.Name() != "hget") || status == "error" {
+		return
+	}
+	args := cmd.Args()
+	if len(args) < 2 {
+		return
+	}
+	key, ok := args[1].(string)
+	if !ok {
+		return
+	}
+	result := "hit"
+	if errors.Is(err, redis.Nil) {
+		result = "miss"
+	}
+	metrics.CacheLookups.WithLabelValues(keyFamily(key), result).Inc()
+}
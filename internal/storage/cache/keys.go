@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KeyVersion prefixes every cached value's key. Bump it whenever the shape of
+// a cached value changes; entries written under the old version are then
+// never read again and expire on their own.
+//
+// Rate limit, quota and scheduler lock keys are counters and locks rather
+// than cached copies of database state, so they are not versioned.
+const KeyVersion = "v1"
+
+var TrendingPollsKey = versionedKey("trending", "polls")
+
+func versionedKey(parts ...string) string {
+	return KeyVersion + ":" + strings.Join(parts, ":")
+}
+
+func PollKey(id uuid.UUID) string {
+	return versionedKey("poll", id.String())
+}
+
+func PollStatsKey(id uuid.UUID) string {
+	return versionedKey("poll", "stats", id.String())
+}
+
+// PollStatsVersionKey holds a counter bumped on every change to a poll's
+// vote counts. Like the rate limit keys it is not versioned.
+func PollStatsVersionKey(id uuid.UUID) string {
+	return "stats_version:" + id.String()
+}
+
+// UserFeedKey holds the user's cached feed pages, one hash field per page.
+func UserFeedKey(userID uuid.UUID) string {
+	return versionedKey("feed", userID.String())
+}
+
+func UserDailyVotesKey(userID uuid.UUID, date time.Time) string {
+	return versionedKey("user", "daily", "votes", userID.String(), date.Format("2006-01-02"))
+}
+
+func UserRecentVotesKey(userID uuid.UUID) string {
+	return versionedKey("user", "votes", "recent", userID.String())
+}
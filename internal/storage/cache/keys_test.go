@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeys_AreVersioned(t *testing.T) {
+	id := uuid.MustParse("6f1c1a52-4c8e-4c6f-9c5d-2f4a1f0b7e21")
+
+	assert.Equal(t, "v1:poll:6f1c1a52-4c8e-4c6f-9c5d-2f4a1f0b7e21", PollKey(id))
+	assert.Equal(t, "v1:poll:stats:6f1c1a52-4c8e-4c6f-9c5d-2f4a1f0b7e21", PollStatsKey(id))
+	assert.Equal(t, "v1:trending:polls", TrendingPollsKey)
+}
+
+func TestKeyFamily_IgnoresVersion(t *testing.T) {
+	id := uuid.New()
+
+	assert.Equal(t, "poll", keyFamily(PollKey(id)))
+	assert.Equal(t, "stats", keyFamily(PollStatsKey(id)))
+	assert.Equal(t, "daily_votes", keyFamily(UserRecentVotesKey(id)))
+	assert.Equal(t, "feed", keyFamily(UserFeedKey(id)))
+	assert.Equal(t, "rate_limit", keyFamily("rate_limit:"+id.String()))
+}
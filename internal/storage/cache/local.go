@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/behzadon/vote/internal/metrics"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// InvalidationChannel is the Redis pub/sub channel on which instances announce
+// keys that must be dropped from every local cache.
+const InvalidationChannel = "cache:invalidate"
+
+// LocalCache is a size-bounded in-process LRU with a per-entry TTL, kept in
+// front of Redis for the hottest keys. It stores the encoded Redis values so
+// callers always decode a private copy.
+//
+// Invalidate broadcasts on InvalidationChannel and Run applies broadcasts
+// from other instances. Messages missed while the subscription reconnects
+// are only covered by the TTL, which should therefore stay short.
+type LocalCache struct {
+	client *redis.Client
+	size   int
+	ttl    time.Duration
+	logger *zap.Logger
+
+	mu    sync.Mutex
+	order *list.List
+	items map[string]*list.Element
+}
+
+type localEntry struct {
+	key       string
+	data      []byte
+	expiresAt time.Time
+}
+
+func NewLocalCache(client *redis.Client, size int, ttl time.Duration, logger *zap.Logger) *LocalCache {
+	return &LocalCache{
+		client: client,
+		size:   size,
+		ttl:    ttl,
+		logger: logger,
+		order:  list.New(),
+		items:  make(map[string]*list.Element, size),
+	}
+}
+
+func (c *LocalCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if ok && time.Now().After(elem.Value.(*localEntry).expiresAt) {
+		c.removeElement(elem)
+		ok = false
+	}
+	metrics.RecordCacheOperation("local_"+keyFamily(key), ok)
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*localEntry).data, true
+}
+
+func (c *LocalCache) Set(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(c.ttl)
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*localEntry)
+		entry.data = data
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&localEntry{key: key, data: data, expiresAt: expiresAt})
+	for c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// Invalidate drops keys locally and tells the other instances to do the same.
+func (c *LocalCache) Invalidate(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		c.remove(key)
+		if err := c.client.Publish(ctx, InvalidationChannel, key).Err(); err != nil {
+			return fmt.Errorf("publish cache invalidation: %w", err)
+		}
+	}
+	return nil
+}
+
+// Run applies invalidations published by other instances until ctx is done.
+func (c *LocalCache) Run(ctx context.Context) error {
+	pubsub := c.client.Subscribe(ctx, InvalidationChannel)
+	defer func() {
+		if err := pubsub.Close(); err != nil {
+			c.logger.Error("Failed to close cache invalidation subscription", zap.Error(err))
+		}
+	}()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("subscribe to cache invalidations: %w", err)
+	}
+	// Anything cached before the subscription was confirmed may have missed
+	// an invalidation.
+	c.purge()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			c.remove(msg.Payload)
+		}
+	}
+}
+
+func (c *LocalCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+func (c *LocalCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.items = make(map[string]*list.Element, c.size)
+}
+
+func (c *LocalCache) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*localEntry).key)
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestLocalCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLocalCache(nil, 2, time.Minute, zap.NewNop())
+	c.Set("poll:a", []byte("a"))
+	c.Set("poll:b", []byte("b"))
+
+	_, ok := c.Get("poll:a")
+	assert.True(t, ok)
+
+	c.Set("poll:c", []byte("c"))
+
+	_, ok = c.Get("poll:b")
+	assert.False(t, ok)
+	data, ok := c.Get("poll:a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("a"), data)
+	_, ok = c.Get("poll:c")
+	assert.True(t, ok)
+}
+
+func TestLocalCache_ExpiresEntries(t *testing.T) {
+	c := NewLocalCache(nil, 10, time.Millisecond, zap.NewNop())
+	c.Set("poll:a", []byte("a"))
+
+	time.Sleep(5 * time.Millisecond)
+
+	_, ok := c.Get("poll:a")
+	assert.False(t, ok)
+	assert.Empty(t, c.items)
+}
+
+func TestLocalCache_Remove(t *testing.T) {
+	c := NewLocalCache(nil, 10, time.Minute, zap.NewNop())
+	c.Set("poll:a", []byte("a"))
+	c.Set("poll:a", []byte("a2"))
+
+	data, ok := c.Get("poll:a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("a2"), data)
+
+	c.remove("poll:a")
+	_, ok = c.Get("poll:a")
+	assert.False(t, ok)
+}
@@ -3,8 +3,9 @@ package cache
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/behzadon/vote/internal/domain"
@@ -21,20 +22,8 @@ func NewRedisCache(client *redis.Client) *RedisCache {
 	return &RedisCache{client: client}
 }
 
-func pollKey(id uuid.UUID) string {
-	return fmt.Sprintf("poll:%s", id.String())
-}
-
-func pollStatsKey(id uuid.UUID) string {
-	return fmt.Sprintf("poll:stats:%s", id.String())
-}
-
-func userDailyVotesKey(userID uuid.UUID, date time.Time) string {
-	return fmt.Sprintf("user:daily:votes:%s:%s", userID.String(), date.Format("2006-01-02"))
-}
-
 func (c *RedisCache) GetPoll(ctx context.Context, pollID uuid.UUID) (*domain.Poll, error) {
-	key := fmt.Sprintf("poll:%s", pollID.String())
+	key := PollKey(pollID)
 	data, err := c.client.Get(ctx, key).Bytes()
 	if err != nil {
 		if err == redis.Nil {
@@ -54,7 +43,7 @@ func (c *RedisCache) GetPoll(ctx context.Context, pollID uuid.UUID) (*domain.Pol
 }
 
 func (c *RedisCache) SetPoll(ctx context.Context, poll *domain.Poll) error {
-	key := fmt.Sprintf("poll:%s", poll.ID.String())
+	key := PollKey(poll.ID)
 	data, err := json.Marshal(poll)
 	if err != nil {
 		return fmt.Errorf("marshal poll: %w", err)
@@ -69,11 +58,11 @@ func (c *RedisCache) SetPoll(ctx context.Context, poll *domain.Poll) error {
 }
 
 func (c *RedisCache) DeletePoll(ctx context.Context, id uuid.UUID) error {
-	return c.client.Del(ctx, pollKey(id)).Err()
+	return c.client.Del(ctx, PollKey(id)).Err()
 }
 
 func (c *RedisCache) GetPollStats(ctx context.Context, pollID uuid.UUID) (*domain.PollStats, error) {
-	key := fmt.Sprintf("poll_stats:%s", pollID.String())
+	key := PollStatsKey(pollID)
 	data, err := c.client.Get(ctx, key).Bytes()
 	if err != nil {
 		if err == redis.Nil {
@@ -93,7 +82,7 @@ func (c *RedisCache) GetPollStats(ctx context.Context, pollID uuid.UUID) (*domai
 }
 
 func (c *RedisCache) SetPollStats(ctx context.Context, pollID uuid.UUID, stats *domain.PollStats) error {
-	key := fmt.Sprintf("poll_stats:%s", pollID.String())
+	key := PollStatsKey(pollID)
 	data, err := json.Marshal(stats)
 	if err != nil {
 		return fmt.Errorf("marshal poll stats: %w", err)
@@ -108,39 +97,155 @@ func (c *RedisCache) SetPollStats(ctx context.Context, pollID uuid.UUID, stats *
 }
 
 func (c *RedisCache) DeletePollStats(ctx context.Context, pollID uuid.UUID) error {
-	return c.client.Del(ctx, pollStatsKey(pollID)).Err()
+	return c.client.Del(ctx, PollStatsKey(pollID)).Err()
+}
+
+// RecentVote is one vote in a user's rolling vote window.
+type RecentVote struct {
+	ID uuid.UUID
+	At time.Time
 }
 
-func (c *RedisCache) GetUserDailyVoteCount(ctx context.Context, userID uuid.UUID, date time.Time) (int, error) {
-	count, err := c.client.Get(ctx, userDailyVotesKey(userID, date)).Int()
+// reserveRecentVote drops the votes that left the window, then adds the vote
+// unless the window is full. A vote already in the set is accepted again
+// without being counted twice, so a retried request does not use up the
+// allowance. Returns 1 when the vote is in the set, 0 when the limit was hit.
+var reserveRecentVote = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', '(' .. ARGV[3])
+if redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 1
+end
+if redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[4]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return 1
+`)
+
+// HasRecentVotes reports whether the user's vote window is cached. A missing
+// window has to be loaded from Postgres before votes are reserved in it.
+func (c *RedisCache) HasRecentVotes(ctx context.Context, userID uuid.UUID) (bool, error) {
+	n, err := c.client.Exists(ctx, UserRecentVotesKey(userID)).Result()
 	if err != nil {
-		if errors.Is(err, redis.Nil) {
-			return 0, nil
-		}
-		return 0, err
+		return false, fmt.Errorf("check recent votes: %w", err)
 	}
-	return count, nil
+	return n > 0, nil
 }
 
-func (c *RedisCache) SetUserDailyVoteCount(ctx context.Context, userID uuid.UUID, date time.Time, count int) error {
-	return c.client.Set(ctx, userDailyVotesKey(userID, date), count, 24*time.Hour).Err()
+// GetRecentVoteTimes returns when the votes in the user's window since the
+// given time were cast, oldest first.
+func (c *RedisCache) GetRecentVoteTimes(ctx context.Context, userID uuid.UUID, since time.Time) ([]time.Time, error) {
+	members, err := c.client.ZRangeByScoreWithScores(ctx, UserRecentVotesKey(userID), &redis.ZRangeBy{
+		Min: strconv.FormatInt(since.UnixNano(), 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get recent votes: %w", err)
+	}
+	times := make([]time.Time, 0, len(members))
+	for _, m := range members {
+		times = append(times, time.Unix(0, int64(m.Score)).UTC())
+	}
+	return times, nil
 }
 
-func (c *RedisCache) IncrementUserDailyVoteCount(ctx context.Context, userID uuid.UUID, date time.Time) error {
-	key := userDailyVotesKey(userID, date)
-	count, err := c.client.Incr(ctx, key).Result()
+// GetRecentVoteIDs returns the IDs of the votes in the user's window since the
+// given time.
+func (c *RedisCache) GetRecentVoteIDs(ctx context.Context, userID uuid.UUID, since time.Time) ([]string, error) {
+	ids, err := c.client.ZRangeByScore(ctx, UserRecentVotesKey(userID), &redis.ZRangeBy{
+		Min: strconv.FormatInt(since.UnixNano(), 10),
+		Max: "+inf",
+	}).Result()
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("get recent vote ids: %w", err)
+	}
+	return ids, nil
+}
+
+// AddRecentVotes adds votes to the user's window and keeps it for ttl.
+func (c *RedisCache) AddRecentVotes(ctx context.Context, userID uuid.UUID, votes []RecentVote, ttl time.Duration) error {
+	if len(votes) == 0 {
+		return nil
+	}
+	key := UserRecentVotesKey(userID)
+	pipe := c.client.TxPipeline()
+	pipe.ZAdd(ctx, key, recentVoteMembers(votes)...)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("cache recent votes: %w", err)
+	}
+	return nil
+}
+
+// ReplaceRecentVotes overwrites the user's window with votes, or removes it
+// when there are none.
+func (c *RedisCache) ReplaceRecentVotes(ctx context.Context, userID uuid.UUID, votes []RecentVote, ttl time.Duration) error {
+	key := UserRecentVotesKey(userID)
+	pipe := c.client.TxPipeline()
+	pipe.Del(ctx, key)
+	if len(votes) > 0 {
+		pipe.ZAdd(ctx, key, recentVoteMembers(votes)...)
+		pipe.Expire(ctx, key, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("replace recent votes: %w", err)
 	}
+	return nil
+}
 
-	if count > int64(domain.MaxDailyVotes) {
-		c.client.Decr(ctx, key)
+// ReserveRecentVote atomically adds the vote to the user's window if fewer
+// than limit votes were cast in it, and returns
+// domain.ErrDailyVoteLimitExceeded otherwise.
+func (c *RedisCache) ReserveRecentVote(ctx context.Context, userID, voteID uuid.UUID, at time.Time, window time.Duration, limit int) error {
+	reserved, err := reserveRecentVote.Run(ctx, c.client, []string{UserRecentVotesKey(userID)},
+		voteID.String(),
+		at.UnixNano(),
+		at.Add(-window).UnixNano(),
+		limit,
+		window.Milliseconds(),
+	).Int()
+	if err != nil {
+		return fmt.Errorf("reserve recent vote: %w", err)
+	}
+	if reserved == 0 {
 		return domain.ErrDailyVoteLimitExceeded
 	}
+	return nil
+}
 
-	if count == 1 {
-		c.client.Expire(ctx, key, 24*time.Hour)
+// ReleaseRecentVote gives back a reservation for a vote that was not stored.
+func (c *RedisCache) ReleaseRecentVote(ctx context.Context, userID, voteID uuid.UUID) error {
+	if err := c.client.ZRem(ctx, UserRecentVotesKey(userID), voteID.String()).Err(); err != nil {
+		return fmt.Errorf("release recent vote: %w", err)
 	}
+	return nil
+}
 
+// ScanRecentVoteUsers calls fn with the user of every cached vote window.
+func (c *RedisCache) ScanRecentVoteUsers(ctx context.Context, fn func(userID uuid.UUID) error) error {
+	prefix := UserRecentVotesKey(uuid.Nil)
+	prefix = prefix[:len(prefix)-len(uuid.Nil.String())]
+	iter := c.client.Scan(ctx, 0, prefix+"*", 500).Iterator()
+	for iter.Next(ctx) {
+		userID, err := uuid.Parse(strings.TrimPrefix(iter.Val(), prefix))
+		if err != nil {
+			continue
+		}
+		if err := fn(userID); err != nil {
+			return err
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("scan recent vote windows: %w", err)
+	}
 	return nil
 }
+
+func recentVoteMembers(votes []RecentVote) []*redis.Z {
+	members := make([]*redis.Z, 0, len(votes))
+	for _, v := range votes {
+		members = append(members, &redis.Z{Score: float64(v.At.UnixNano()), Member: v.ID.String()})
+	}
+	return members
+}
@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// StatsVersionChannel is the Redis pub/sub channel on which stats version
+// bumps are announced as "<poll id>:<version>".
+const StatsVersionChannel = "stats:version"
+
+// statsVersionTTL bounds how long an idle poll's counter is kept. A counter
+// that expires restarts from one, which waiting clients see as a change.
+const statsVersionTTL = 24 * time.Hour
+
+// StatsVersions keeps a per-poll counter in Redis that is bumped whenever
+// the poll's vote counts change, and lets requests wait for the next bump.
+//
+// Each instance holds a single subscription (see Run) and fans bumps out to
+// its local waiters, so waiting requests do not each need a Redis
+// connection. Bumps published while the subscription reconnects are missed;
+// waiters then return on their timeout and pick the change up on retry.
+type StatsVersions struct {
+	client *redis.Client
+	logger *zap.Logger
+
+	mu      sync.Mutex
+	waiters map[uuid.UUID]map[chan int64]struct{}
+}
+
+func NewStatsVersions(client *redis.Client, logger *zap.Logger) *StatsVersions {
+	return &StatsVersions{
+		client:  client,
+		logger:  logger,
+		waiters: make(map[uuid.UUID]map[chan int64]struct{}),
+	}
+}
+
+// BumpStatsVersion increments the poll's version and announces it to every
+// instance.
+func (v *StatsVersions) BumpStatsVersion(ctx context.Context, pollID uuid.UUID) (int64, error) {
+	key := PollStatsVersionKey(pollID)
+	pipe := v.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, statsVersionTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("bump stats version: %w", err)
+	}
+
+	version := incr.Val()
+	payload := pollID.String() + ":" + strconv.FormatInt(version, 10)
+	if err := v.client.Publish(ctx, StatsVersionChannel, payload).Err(); err != nil {
+		return version, fmt.Errorf("publish stats version: %w", err)
+	}
+	return version, nil
+}
+
+func (v *StatsVersions) StatsVersion(ctx context.Context, pollID uuid.UUID) (int64, error) {
+	version, err := v.client.Get(ctx, PollStatsVersionKey(pollID)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get stats version: %w", err)
+	}
+	return version, nil
+}
+
+// WaitStatsVersion returns the poll's version as soon as it differs from
+// since. If ctx ends first it returns since together with ctx's error.
+func (v *StatsVersions) WaitStatsVersion(ctx context.Context, pollID uuid.UUID, since int64) (int64, error) {
+	// Register before reading the current version so that a bump landing in
+	// between is not lost.
+	ch := v.addWaiter(pollID)
+	defer v.removeWaiter(pollID, ch)
+
+	current, err := v.StatsVersion(ctx, pollID)
+	if err != nil {
+		return 0, err
+	}
+	if current != since {
+		return current, nil
+	}
+
+	select {
+	case version := <-ch:
+		return version, nil
+	case <-ctx.Done():
+		return since, ctx.Err()
+	}
+}
+
+// Run delivers bumps published by any instance to local waiters until ctx
+// is done.
+func (v *StatsVersions) Run(ctx context.Context) error {
+	pubsub := v.client.Subscribe(ctx, StatsVersionChannel)
+	defer func() {
+		if err := pubsub.Close(); err != nil {
+			v.logger.Error("Failed to close stats version subscription", zap.Error(err))
+		}
+	}()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("subscribe to stats versions: %w", err)
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			pollID, version, err := parseStatsVersion(msg.Payload)
+			if err != nil {
+				v.logger.Warn("Ignoring malformed stats version message",
+					zap.String("payload", msg.Payload),
+					zap.Error(err),
+				)
+				continue
+			}
+			v.notify(pollID, version)
+		}
+	}
+}
+
+func parseStatsVersion(payload string) (uuid.UUID, int64, error) {
+	id, version, ok := strings.Cut(payload, ":")
+	if !ok {
+		return uuid.Nil, 0, errors.New("missing version")
+	}
+	pollID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, 0, err
+	}
+	n, err := strconv.ParseInt(version, 10, 64)
+	if err != nil {
+		return uuid.Nil, 0, err
+	}
+	return pollID, n, nil
+}
+
+func (v *StatsVersions) addWaiter(pollID uuid.UUID) chan int64 {
+	ch := make(chan int64, 1)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.waiters[pollID] == nil {
+		v.waiters[pollID] = make(map[chan int64]struct{})
+	}
+	v.waiters[pollID][ch] = struct{}{}
+	return ch
+}
+
+func (v *StatsVersions) removeWaiter(pollID uuid.UUID, ch chan int64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.waiters[pollID], ch)
+	if len(v.waiters[pollID]) == 0 {
+		delete(v.waiters, pollID)
+	}
+}
+
+func (v *StatsVersions) notify(pollID uuid.UUID, version int64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for ch := range v.waiters[pollID] {
+		select {
+		case ch <- version:
+		default:
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestStatsVersions_NotifiesWaitersOfThePoll(t *testing.T) {
+	v := NewStatsVersions(nil, zap.NewNop())
+	pollID := uuid.New()
+	other := uuid.New()
+
+	a := v.addWaiter(pollID)
+	b := v.addWaiter(pollID)
+	c := v.addWaiter(other)
+
+	v.notify(pollID, 3)
+
+	assert.Equal(t, int64(3), <-a)
+	assert.Equal(t, int64(3), <-b)
+	assert.Empty(t, c)
+
+	// A waiter that has not read its last bump is not blocked on.
+	v.notify(pollID, 4)
+	v.notify(pollID, 5)
+	assert.Equal(t, int64(4), <-a)
+
+	v.removeWaiter(pollID, a)
+	v.removeWaiter(pollID, b)
+	v.removeWaiter(other, c)
+	assert.Empty(t, v.waiters)
+}
+
+func TestParseStatsVersion(t *testing.T) {
+	pollID := uuid.New()
+
+	id, version, err := parseStatsVersion(pollID.String() + ":42")
+	assert.NoError(t, err)
+	assert.Equal(t, pollID, id)
+	assert.Equal(t, int64(42), version)
+
+	for _, payload := range []string{"", pollID.String(), "poll:1", pollID.String() + ":x"} {
+		_, _, err := parseStatsVersion(payload)
+		assert.Error(t, err, payload)
+	}
+}
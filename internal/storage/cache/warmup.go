@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// WarmupOptions controls which polls Warmup loads. Polls is the maximum
+// number of polls, taken from those with votes within Window, most recent
+// first.
+type WarmupOptions struct {
+	Polls       int
+	Window      time.Duration
+	Concurrency int
+}
+
+// Warmup loads recently active polls and their stats into Redis so that the
+// first requests after a deploy do not all fall through to the database.
+// Failures for individual polls are logged and skipped; it returns how many
+// polls were warmed.
+func Warmup(ctx context.Context, repo domain.Repository, opts WarmupOptions, logger *zap.Logger) (int, error) {
+	pollIDs, err := repo.GetRecentlyActivePollIDs(ctx, time.Now().UTC().Add(-opts.Window), opts.Polls)
+	if err != nil {
+		return 0, fmt.Errorf("list active polls: %w", err)
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ids := make(chan uuid.UUID)
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		warmed int
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range ids {
+				if err := warmPoll(ctx, repo, id); err != nil {
+					logger.Warn("Failed to warm poll cache",
+						zap.String("poll_id", id.String()),
+						zap.Error(err),
+					)
+					continue
+				}
+				mu.Lock()
+				warmed++
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, id := range pollIDs {
+		select {
+		case ids <- id:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(ids)
+	wg.Wait()
+
+	return warmed, ctx.Err()
+}
+
+func warmPoll(ctx context.Context, repo domain.Repository, pollID uuid.UUID) error {
+	// GetPollByID caches the poll on a miss.
+	if _, err := repo.GetPollByID(ctx, pollID); err != nil {
+		return err
+	}
+	stats, err := repo.GetPollStats(ctx, pollID)
+	if err != nil {
+		return err
+	}
+	return repo.SetCachedPollStats(ctx, pollID, stats)
+}
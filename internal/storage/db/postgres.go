@@ -145,15 +145,71 @@ func (r *PostgresRepository) GetPollsForFeed(ctx context.Context, query domain.F
 		if err != nil {
 			return nil, err
 		}
+		polls = append(polls, poll)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadFeedDetails(ctx, polls); err != nil {
+		return nil, err
+	}
+	return polls, nil
+}
+
+// loadFeedDetails fills in the options and tags of a feed page with one
+// query each instead of a round trip per poll.
+func (r *PostgresRepository) loadFeedDetails(ctx context.Context, polls []*domain.Poll) error {
+	if len(polls) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(polls))
+	byID := make(map[uuid.UUID]*domain.Poll, len(polls))
+	for i, poll := range polls {
+		ids[i] = poll.ID
+		byID[poll.ID] = poll
+	}
+
+	optionsQuery := `SELECT id, poll_id, option_text, option_index, created_at FROM poll_options WHERE poll_id = ANY($1) ORDER BY poll_id, option_index`
+	rows, err := r.db.QueryContext(ctx, optionsQuery, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer closeRows(rows, r.logger)
 
-		poll, err = r.GetPollByID(ctx, poll.ID)
+	for rows.Next() {
+		var option domain.Option
+		err := rows.Scan(&option.ID, &option.PollID, &option.OptionText, &option.OptionIndex, &option.CreatedAt)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if poll, ok := byID[option.PollID]; ok {
+			poll.Options = append(poll.Options, option)
 		}
-		polls = append(polls, poll)
+	}
+	if err := rows.Err(); err != nil {
+		return err
 	}
 
-	return polls, nil
+	tagsQuery := `SELECT poll_id, tag FROM poll_tags WHERE poll_id = ANY($1)`
+	tagRows, err := r.db.QueryContext(ctx, tagsQuery, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer closeRows(tagRows, r.logger)
+
+	for tagRows.Next() {
+		var pollID uuid.UUID
+		var tag string
+		if err := tagRows.Scan(&pollID, &tag); err != nil {
+			return err
+		}
+		if poll, ok := byID[pollID]; ok {
+			poll.Tags = append(poll.Tags, tag)
+		}
+	}
+	return tagRows.Err()
 }
 
 func (r *PostgresRepository) GetPollStats(ctx context.Context, pollID uuid.UUID) (*domain.PollStats, error) {
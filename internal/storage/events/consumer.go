@@ -3,25 +3,37 @@ package events
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
+	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"go.uber.org/zap"
 )
 
 type EventHandler interface {
 	HandlePollCreated(ctx context.Context, poll *domain.Poll) error
+	HandlePollUpdated(ctx context.Context, poll *domain.Poll) error
 	HandlePollVoted(ctx context.Context, vote *domain.Vote) error
+	HandleVoteUpdated(ctx context.Context, vote *domain.Vote) error
+	HandleVoteDeleted(ctx context.Context, vote *domain.Vote) error
 	HandlePollSkipped(ctx context.Context, skip *domain.Skip) error
+	HandleCollaboratorInvited(ctx context.Context, collaborator *domain.Collaborator) error
+	HandlePollStatusChanged(ctx context.Context, change *domain.PollStatusChange) error
 }
 
 type RabbitMQConsumer struct {
-	conn      *amqp.Connection
-	channel   *amqp.Channel
-	handler   EventHandler
-	logger    *zap.Logger
-	queueName string
+	conn        *amqp.Connection
+	channel     *amqp.Channel
+	handler     EventHandler
+	logger      *zap.Logger
+	queueName   string
+	consumerTag string
+	done        chan struct{}
+	inFlight    atomic.Int32
 }
 
 func NewRabbitMQConsumer(
@@ -55,18 +67,66 @@ func NewRabbitMQConsumer(
 	}
 
 	return &RabbitMQConsumer{
-		conn:      conn,
-		channel:   ch,
-		handler:   handler,
-		logger:    logger,
-		queueName: queueName,
+		conn:        conn,
+		channel:     ch,
+		handler:     handler,
+		logger:      logger,
+		queueName:   queueName,
+		consumerTag: fmt.Sprintf("%s-%s", queueName, uuid.New().String()),
+		done:        make(chan struct{}),
 	}, nil
 }
 
+// NewRabbitMQBroadcastConsumer consumes the events matching routingKey through
+// a queue of its own, so every instance sees every event instead of sharing
+// them with other consumers. The broker deletes the queue on disconnect.
+func NewRabbitMQBroadcastConsumer(
+	host string,
+	port int,
+	user, password, vhost string,
+	routingKey string,
+	handler EventHandler,
+	logger *zap.Logger,
+) (*RabbitMQConsumer, error) {
+	c, err := NewRabbitMQConsumer(host, port, user, password, vhost, "", handler, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	queue, err := c.channel.QueueDeclare(
+		"",
+		false,
+		true,
+		true,
+		false,
+		nil,
+	)
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("declare queue: %w", err)
+	}
+
+	err = c.channel.QueueBind(
+		queue.Name,
+		routingKey,
+		"vote",
+		false,
+		nil,
+	)
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("bind queue %s: %w", queue.Name, err)
+	}
+
+	c.queueName = queue.Name
+	c.consumerTag = fmt.Sprintf("%s-%s", queue.Name, uuid.New().String())
+	return c, nil
+}
+
 func (c *RabbitMQConsumer) Start(ctx context.Context) error {
 	msgs, err := c.channel.Consume(
 		c.queueName,
-		"",
+		c.consumerTag,
 		false,
 		false,
 		false,
@@ -78,30 +138,18 @@ func (c *RabbitMQConsumer) Start(ctx context.Context) error {
 	}
 
 	go func() {
+		defer close(c.done)
 		for {
 			select {
 			case <-ctx.Done():
 				return
 			case msg, ok := <-msgs:
 				if !ok {
-					c.logger.Error("Consumer channel closed")
+					c.logger.Info("Consumer channel closed")
 					return
 				}
 
-				if err := c.handleMessage(ctx, msg); err != nil {
-					c.logger.Error("Failed to handle message",
-						zap.Error(err),
-						zap.String("routing_key", msg.RoutingKey),
-					)
-					if err := msg.Nack(false, true); err != nil {
-						c.logger.Error("Failed to nack message", zap.Error(err))
-					}
-					continue
-				}
-
-				if err := msg.Ack(false); err != nil {
-					c.logger.Error("Failed to ack message", zap.Error(err))
-				}
+				c.deliver(ctx, msg)
 			}
 		}
 	}()
@@ -109,6 +157,46 @@ func (c *RabbitMQConsumer) Start(ctx context.Context) error {
 	return nil
 }
 
+// deliver handles msg and acks it, or nacks it for redelivery on failure.
+func (c *RabbitMQConsumer) deliver(ctx context.Context, msg amqp.Delivery) {
+	c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+
+	msgCtx := messageContext(ctx, msg)
+	if err := c.handleMessage(msgCtx, msg); err != nil {
+		logging.For(msgCtx, c.logger).Error("Failed to handle message",
+			zap.Error(err),
+			zap.String("routing_key", msg.RoutingKey),
+		)
+		if err := msg.Nack(false, true); err != nil {
+			c.logger.Error("Failed to nack message", zap.Error(err))
+		}
+		return
+	}
+
+	if err := msg.Ack(false); err != nil {
+		c.logger.Error("Failed to ack message", zap.Error(err))
+	}
+}
+
+// InFlight reports the messages taken from the queue and not yet acked or
+// nacked.
+func (c *RabbitMQConsumer) InFlight(context.Context) (int, error) {
+	return int(c.inFlight.Load()), nil
+}
+
+// messageContext starts a span for handling msg, in the trace of the request
+// that published it when the message carries a traceparent header.
+func messageContext(ctx context.Context, msg amqp.Delivery) context.Context {
+	if header, ok := msg.Headers[logging.HeaderTraceparent].(string); ok {
+		if parent, ok := logging.ParseTraceparent(header); ok {
+			ctx = logging.ContextWithSpan(ctx, parent)
+		}
+	}
+	ctx, _ = logging.StartSpan(ctx)
+	return ctx
+}
+
 func (c *RabbitMQConsumer) handleMessage(ctx context.Context, msg amqp.Delivery) error {
 	var event struct {
 		Type      string          `json:"type"`
@@ -128,6 +216,13 @@ func (c *RabbitMQConsumer) handleMessage(ctx context.Context, msg amqp.Delivery)
 		}
 		return c.handler.HandlePollCreated(ctx, &poll)
 
+	case "poll.updated":
+		var poll domain.Poll
+		if err := json.Unmarshal(event.Data, &poll); err != nil {
+			return fmt.Errorf("unmarshal poll: %w", err)
+		}
+		return c.handler.HandlePollUpdated(ctx, &poll)
+
 	case "poll.voted":
 		var vote domain.Vote
 		if err := json.Unmarshal(event.Data, &vote); err != nil {
@@ -135,6 +230,20 @@ func (c *RabbitMQConsumer) handleMessage(ctx context.Context, msg amqp.Delivery)
 		}
 		return c.handler.HandlePollVoted(ctx, &vote)
 
+	case "poll.vote.updated":
+		var vote domain.Vote
+		if err := json.Unmarshal(event.Data, &vote); err != nil {
+			return fmt.Errorf("unmarshal vote: %w", err)
+		}
+		return c.handler.HandleVoteUpdated(ctx, &vote)
+
+	case "poll.vote.deleted":
+		var vote domain.Vote
+		if err := json.Unmarshal(event.Data, &vote); err != nil {
+			return fmt.Errorf("unmarshal vote: %w", err)
+		}
+		return c.handler.HandleVoteDeleted(ctx, &vote)
+
 	case "poll.skipped":
 		var skip domain.Skip
 		if err := json.Unmarshal(event.Data, &skip); err != nil {
@@ -142,20 +251,60 @@ func (c *RabbitMQConsumer) handleMessage(ctx context.Context, msg amqp.Delivery)
 		}
 		return c.handler.HandlePollSkipped(ctx, &skip)
 
+	case "poll.collaborator_invited":
+		var collaborator domain.Collaborator
+		if err := json.Unmarshal(event.Data, &collaborator); err != nil {
+			return fmt.Errorf("unmarshal collaborator: %w", err)
+		}
+		return c.handler.HandleCollaboratorInvited(ctx, &collaborator)
+
+	case "poll.status_changed":
+		var change domain.PollStatusChange
+		if err := json.Unmarshal(event.Data, &change); err != nil {
+			return fmt.Errorf("unmarshal poll status change: %w", err)
+		}
+		return c.handler.HandlePollStatusChanged(ctx, &change)
+
 	default:
 		return fmt.Errorf("unknown event type: %s", event.Type)
 	}
 }
 
+// Stop cancels the subscription so the broker sends no further deliveries,
+// then waits for the message being handled to be acked or nacked. Unacked
+// prefetched messages are requeued by the broker. If the broker already
+// closed the channel there is nothing left to cancel and Stop only waits for
+// the consumer goroutine and releases the connection.
+func (c *RabbitMQConsumer) Stop(ctx context.Context) error {
+	select {
+	case <-c.done:
+		return c.Close()
+	default:
+	}
+
+	if err := c.channel.Cancel(c.consumerTag, false); err != nil && !errors.Is(err, amqp.ErrClosed) {
+		return fmt.Errorf("cancel consumer: %w", err)
+	}
+
+	select {
+	case <-c.done:
+		c.logger.Info("Consumer drained", zap.String("queue", c.queueName))
+	case <-ctx.Done():
+		return fmt.Errorf("drain in-flight messages: %w", ctx.Err())
+	}
+
+	return c.Close()
+}
+
 func (c *RabbitMQConsumer) Close() error {
 	var errs []error
 
-	if err := c.channel.Close(); err != nil {
+	if err := c.channel.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
 		c.logger.Error("Failed to close RabbitMQ channel", zap.Error(err))
 		errs = append(errs, fmt.Errorf("close channel: %w", err))
 	}
 
-	if err := c.conn.Close(); err != nil {
+	if err := c.conn.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
 		c.logger.Error("Failed to close RabbitMQ connection", zap.Error(err))
 		errs = append(errs, fmt.Errorf("close connection: %w", err))
 	}
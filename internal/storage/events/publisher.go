@@ -10,5 +10,7 @@ type Publisher interface {
 	PublishPollCreated(ctx context.Context, poll *domain.Poll) error
 	PublishPollVoted(ctx context.Context, vote *domain.Vote) error
 	PublishPollSkipped(ctx context.Context, skip *domain.Skip) error
+	PublishCollaboratorInvited(ctx context.Context, collaborator *domain.Collaborator) error
+	PublishPollStatusChanged(ctx context.Context, change *domain.PollStatusChange) error
 	Close() error
 }
@@ -3,18 +3,28 @@ package events
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/logging"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"go.uber.org/zap"
 )
 
+// errPublisherClosed is returned while the connection is down.
+var errPublisherClosed = errors.New("rabbitmq connection is closed")
+
 type RabbitMQPublisher struct {
+	url    string
+	logger *zap.Logger
+
+	// mu guards conn and channel, which Reconnect replaces.
+	mu      sync.RWMutex
 	conn    *amqp.Connection
 	channel *amqp.Channel
-	logger  *zap.Logger
 }
 
 func cleanup(ch *amqp.Channel, conn *amqp.Connection, logger *zap.Logger) {
@@ -31,16 +41,35 @@ func cleanup(ch *amqp.Channel, conn *amqp.Connection, logger *zap.Logger) {
 }
 
 func NewRabbitMQPublisher(host string, port int, user, password, vhost string, logger *zap.Logger) (*RabbitMQPublisher, error) {
-	url := fmt.Sprintf("amqp://%s:%s@%s:%d/%s", user, password, host, port, vhost)
-	conn, err := amqp.Dial(url)
+	p := &RabbitMQPublisher{
+		url:    fmt.Sprintf("amqp://%s:%s@%s:%d/%s", user, password, host, port, vhost),
+		logger: logger,
+	}
+	conn, ch, err := p.dial(context.Background())
 	if err != nil {
-		return nil, fmt.Errorf("connect to rabbitmq: %w", err)
+		return nil, err
+	}
+	p.conn, p.channel = conn, ch
+	return p, nil
+}
+
+// dial connects and declares the exchange and the queues events are routed
+// to. The connection attempt ends by ctx's deadline, if it has one.
+func (p *RabbitMQPublisher) dial(ctx context.Context) (*amqp.Connection, *amqp.Channel, error) {
+	logger := p.logger
+	config := amqp.Config{Heartbeat: 10 * time.Second, Locale: "en_US"}
+	if deadline, ok := ctx.Deadline(); ok {
+		config.Dial = amqp.DefaultDial(time.Until(deadline))
+	}
+	conn, err := amqp.DialConfig(p.url, config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect to rabbitmq: %w", err)
 	}
 
 	ch, err := conn.Channel()
 	if err != nil {
 		cleanup(nil, conn, logger)
-		return nil, fmt.Errorf("open channel: %w", err)
+		return nil, nil, fmt.Errorf("open channel: %w", err)
 	}
 
 	err = ch.ExchangeDeclare(
@@ -54,13 +83,26 @@ func NewRabbitMQPublisher(host string, port int, user, password, vhost string, l
 	)
 	if err != nil {
 		cleanup(ch, conn, logger)
-		return nil, fmt.Errorf("declare exchange: %w", err)
+		return nil, nil, fmt.Errorf("declare exchange: %w", err)
 	}
 
-	queues := []string{"vote_events", "poll_updates"}
+	// audit_events carries account activity for security tooling to stream
+	// into a SIEM, and mail_events the verification and email change links
+	// for the mailer to send; nothing in this service consumes either.
+	queues := []struct {
+		name       string
+		routingKey string
+	}{
+		{"vote_events", "poll.#"},
+		{"poll_updates", "poll.#"},
+		{"search_index", "poll.#"},
+		{"analytics_events", "poll.#"},
+		{"audit_events", "user.#"},
+		{"mail_events", "mail.#"},
+	}
 	for _, queue := range queues {
 		_, err = ch.QueueDeclare(
-			queue,
+			queue.name,
 			true,
 			false,
 			false,
@@ -69,30 +111,61 @@ func NewRabbitMQPublisher(host string, port int, user, password, vhost string, l
 		)
 		if err != nil {
 			cleanup(ch, conn, logger)
-			return nil, fmt.Errorf("declare queue %s: %w", queue, err)
+			return nil, nil, fmt.Errorf("declare queue %s: %w", queue.name, err)
 		}
 
 		err = ch.QueueBind(
-			queue,
-			"poll.*",
+			queue.name,
+			queue.routingKey,
 			"vote",
 			false,
 			nil,
 		)
 		if err != nil {
 			cleanup(ch, conn, logger)
-			return nil, fmt.Errorf("bind queue %s: %w", queue, err)
+			return nil, nil, fmt.Errorf("bind queue %s: %w", queue.name, err)
 		}
 	}
 
-	return &RabbitMQPublisher{
-		conn:    conn,
-		channel: ch,
-		logger:  logger,
-	}, nil
+	return conn, ch, nil
+}
+
+// Check reports whether the connection and channel are open.
+func (p *RabbitMQPublisher) Check(ctx context.Context) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.conn.IsClosed() || p.channel.IsClosed() {
+		return errPublisherClosed
+	}
+	return nil
+}
+
+// Reconnect replaces the connection and channel with new ones. Publishes
+// fail fast with an error until it succeeds.
+func (p *RabbitMQPublisher) Reconnect(ctx context.Context) error {
+	conn, ch, err := p.dial(ctx)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	old := p.conn
+	p.conn, p.channel = conn, ch
+	p.mu.Unlock()
+
+	// Closing the connection closes its channel too.
+	if !old.IsClosed() {
+		if err := old.Close(); err != nil {
+			p.logger.Error("Failed to close RabbitMQ connection", zap.Error(err))
+		}
+	}
+	return nil
 }
 
 func (p *RabbitMQPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	var errs []error
 
 	if err := p.channel.Close(); err != nil {
@@ -125,6 +198,20 @@ func (p *RabbitMQPublisher) PublishPollCreated(ctx context.Context, poll *domain
 	return p.publishEvent(ctx, event, "poll.created")
 }
 
+func (p *RabbitMQPublisher) PublishPollUpdated(ctx context.Context, poll *domain.Poll) error {
+	event := struct {
+		Type      string       `json:"type"`
+		Timestamp string       `json:"timestamp"`
+		Data      *domain.Poll `json:"data"`
+	}{
+		Type:      "poll.updated",
+		Timestamp: poll.UpdatedAt.Format(time.RFC3339),
+		Data:      poll,
+	}
+
+	return p.publishEvent(ctx, event, "poll.updated")
+}
+
 func (p *RabbitMQPublisher) PublishPollVoted(ctx context.Context, vote *domain.Vote) error {
 	event := struct {
 		Type      string       `json:"type"`
@@ -179,26 +266,120 @@ func (p *RabbitMQPublisher) PublishPollVoteUpdated(ctx context.Context, vote *do
 	return p.publishEvent(ctx, event, "poll.vote.updated")
 }
 
+func (p *RabbitMQPublisher) PublishCollaboratorInvited(ctx context.Context, collaborator *domain.Collaborator) error {
+	event := struct {
+		Type      string               `json:"type"`
+		Timestamp string               `json:"timestamp"`
+		Data      *domain.Collaborator `json:"data"`
+	}{
+		Type:      "poll.collaborator_invited",
+		Timestamp: collaborator.CreatedAt.Format(time.RFC3339),
+		Data:      collaborator,
+	}
+	return p.publishEvent(ctx, event, "poll.collaborator_invited")
+}
+
+func (p *RabbitMQPublisher) PublishPollStatusChanged(ctx context.Context, change *domain.PollStatusChange) error {
+	event := struct {
+		Type      string                   `json:"type"`
+		Timestamp string                   `json:"timestamp"`
+		Data      *domain.PollStatusChange `json:"data"`
+	}{
+		Type:      "poll.status_changed",
+		Timestamp: change.ChangedAt.Format(time.RFC3339),
+		Data:      change,
+	}
+	return p.publishEvent(ctx, event, "poll.status_changed")
+}
+
+func (p *RabbitMQPublisher) PublishUserRegistered(ctx context.Context, userEvent *domain.UserEvent) error {
+	return p.publishUserEvent(ctx, "user.registered", userEvent)
+}
+
+func (p *RabbitMQPublisher) PublishUserLoggedIn(ctx context.Context, userEvent *domain.UserEvent) error {
+	return p.publishUserEvent(ctx, "user.login", userEvent)
+}
+
+func (p *RabbitMQPublisher) PublishUserPasswordChanged(ctx context.Context, userEvent *domain.UserEvent) error {
+	return p.publishUserEvent(ctx, "user.password_changed", userEvent)
+}
+
+func (p *RabbitMQPublisher) PublishUserDeleted(ctx context.Context, userEvent *domain.UserEvent) error {
+	return p.publishUserEvent(ctx, "user.deleted", userEvent)
+}
+
+func (p *RabbitMQPublisher) PublishEmailVerificationRequested(ctx context.Context, verification *domain.EmailVerification) error {
+	event := struct {
+		Type      string                    `json:"type"`
+		Timestamp string                    `json:"timestamp"`
+		Data      *domain.EmailVerification `json:"data"`
+	}{
+		Type:      "mail.verification_requested",
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data:      verification,
+	}
+	return p.publishEvent(ctx, event, "mail.verification_requested")
+}
+
+func (p *RabbitMQPublisher) PublishEmailChangeRequested(ctx context.Context, confirmation *domain.EmailChangeConfirmation) error {
+	event := struct {
+		Type      string                          `json:"type"`
+		Timestamp string                          `json:"timestamp"`
+		Data      *domain.EmailChangeConfirmation `json:"data"`
+	}{
+		Type:      "mail.email_change_requested",
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data:      confirmation,
+	}
+	return p.publishEvent(ctx, event, "mail.email_change_requested")
+}
+
+func (p *RabbitMQPublisher) publishUserEvent(ctx context.Context, eventType string, userEvent *domain.UserEvent) error {
+	event := struct {
+		Type      string            `json:"type"`
+		Timestamp string            `json:"timestamp"`
+		Data      *domain.UserEvent `json:"data"`
+	}{
+		Type:      eventType,
+		Timestamp: userEvent.OccurredAt.Format(time.RFC3339),
+		Data:      userEvent,
+	}
+	return p.publishEvent(ctx, event, eventType)
+}
+
 func (p *RabbitMQPublisher) publishEvent(ctx context.Context, event interface{}, routingKey string) error {
 	data, err := json.Marshal(event)
 	if err != nil {
 		return fmt.Errorf("marshal event: %w", err)
 	}
 
-	err = p.channel.PublishWithContext(ctx,
+	var headers amqp.Table
+	if sc, ok := logging.SpanFromContext(ctx); ok {
+		headers = amqp.Table{logging.HeaderTraceparent: sc.Traceparent()}
+	}
+
+	p.mu.RLock()
+	channel := p.channel
+	p.mu.RUnlock()
+	if channel.IsClosed() {
+		return fmt.Errorf("publish message: %w", errPublisherClosed)
+	}
+
+	err = channel.PublishWithContext(ctx,
 		"vote",
 		routingKey,
 		false,
 		false,
 		amqp.Publishing{
 			ContentType:  "application/json",
+			Headers:      headers,
 			Body:         data,
 			DeliveryMode: amqp.Persistent,
 			Timestamp:    time.Now(),
 		},
 	)
 	if err != nil {
-		p.logger.Error("Failed to publish message to RabbitMQ",
+		logging.For(ctx, p.logger).Error("Failed to publish message to RabbitMQ",
 			zap.Error(err),
 			zap.String("event_type", fmt.Sprintf("%T", event)),
 			zap.String("routing_key", routingKey),
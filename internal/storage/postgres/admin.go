@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/storage/cache"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchUsers pages through the users matching q, newest first.
+func (r *Repository) SearchUsers(ctx context.Context, q domain.UserSearchQuery) ([]domain.AdminUser, int, error) {
+	var args []interface{}
+	var conditions []string
+	if q.Query != "" {
+		args = append(args, "%"+likeEscaper.Replace(q.Query)+"%")
+		conditions = append(conditions, fmt.Sprintf("(username ILIKE $%d OR email ILIKE $%d)", len(args), len(args)))
+	}
+	if q.Role != "" {
+		args = append(args, q.Role)
+		conditions = append(conditions, fmt.Sprintf("role = $%d", len(args)))
+	}
+	if q.Standing != "" {
+		args = append(args, q.Standing)
+		conditions = append(conditions, fmt.Sprintf("standing = $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count users: %w", err)
+	}
+
+	args = append(args, q.Limit, (q.Page-1)*q.Limit)
+	query := fmt.Sprintf(`
+		SELECT id, username, email, role, standing, created_at
+		FROM users
+		%s
+		ORDER BY created_at DESC, id
+		LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args))
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("search users: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	var users []domain.AdminUser
+	for rows.Next() {
+		var user domain.AdminUser
+		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.Standing, &user.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterate users: %w", err)
+	}
+	return users, total, nil
+}
+
+// GetPlatformStats counts users, polls that are not deleted, votes, and the
+// votes cast since since.
+func (r *Repository) GetPlatformStats(ctx context.Context, since time.Time) (*domain.PlatformStats, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM users),
+			(SELECT COUNT(*) FROM polls WHERE status <> 'deleted'),
+			(SELECT COUNT(*) FROM votes),
+			(SELECT COUNT(*) FROM votes WHERE created_at >= $1)`
+	var stats domain.PlatformStats
+	err := r.db.QueryRowContext(ctx, query, since).Scan(&stats.Users, &stats.Polls, &stats.Votes, &stats.VotesLast24h)
+	if err != nil {
+		return nil, fmt.Errorf("get platform stats: %w", err)
+	}
+	return &stats, nil
+}
+
+// PurgePoll deletes the poll row, which cascades to its options, votes,
+// rollups, flags and webhook, along with the analytics projections that have no
+// foreign key, and drops its cached copies.
+func (r *Repository) PurgePoll(ctx context.Context, pollID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM analytics_poll_hourly_votes WHERE poll_id = $1`, pollID); err != nil {
+		return fmt.Errorf("delete poll analytics: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM polls WHERE id = $1`, pollID)
+	if err != nil {
+		return fmt.Errorf("delete poll: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	} else if rows == 0 {
+		return domain.ErrNotFound
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
+
+	r.invalidateCachedPoll(ctx, pollID)
+	if err := r.deleteCached(ctx, cache.PollStatsKey(pollID)); err != nil {
+		r.logger.Warn("Failed to invalidate cached poll stats",
+			zap.Error(err),
+			zap.String("poll_id", pollID.String()),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const analyticsDayLayout = "2006-01-02"
+
+// ApplyAnalyticsDelta adds delta to the analytics projections. It reports
+// false, changing nothing, when the event was already applied.
+func (r *Repository) ApplyAnalyticsDelta(ctx context.Context, delta domain.AnalyticsDelta) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO analytics_processed_events (event_key)
+		VALUES ($1)
+		ON CONFLICT (event_key) DO NOTHING`, delta.EventKey)
+	if err != nil {
+		return false, fmt.Errorf("record analytics event: %w", err)
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("get rows affected: %w", err)
+	}
+	if inserted == 0 {
+		return false, nil
+	}
+
+	// Buckets are computed here in UTC so they don't depend on the session
+	// time zone.
+	at := delta.At.UTC()
+	day := at.Format(analyticsDayLayout)
+
+	if delta.Votes != 0 {
+		if len(delta.Tags) > 0 {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO analytics_tag_daily_votes (tag, day, votes)
+				SELECT tag, $2, $3 FROM unnest($1::text[]) AS tag
+				ON CONFLICT (tag, day) DO UPDATE
+				SET votes = analytics_tag_daily_votes.votes + EXCLUDED.votes`,
+				pq.Array(delta.Tags), day, delta.Votes)
+			if err != nil {
+				return false, fmt.Errorf("update tag daily votes: %w", err)
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO analytics_poll_hourly_votes (poll_id, hour, votes)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (poll_id, hour) DO UPDATE
+			SET votes = analytics_poll_hourly_votes.votes + EXCLUDED.votes`,
+			delta.PollID, at.Truncate(time.Hour), delta.Votes)
+		if err != nil {
+			return false, fmt.Errorf("update poll hourly votes: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO analytics_user_activity (user_id, day, votes, skips, polls_created)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, day) DO UPDATE
+		SET votes = analytics_user_activity.votes + EXCLUDED.votes,
+			skips = analytics_user_activity.skips + EXCLUDED.skips,
+			polls_created = analytics_user_activity.polls_created + EXCLUDED.polls_created`,
+		delta.UserID, day, delta.Votes, delta.Skips, delta.PollsCreated)
+	if err != nil {
+		return false, fmt.Errorf("update user activity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
+	return true, nil
+}
+
+func (r *Repository) GetTagDailyVotes(ctx context.Context, tag string, from, to time.Time) ([]domain.VoteBucket, error) {
+	query := `
+		SELECT day, votes
+		FROM analytics_tag_daily_votes
+		WHERE tag = $1 AND day >= $2 AND day < $3
+		ORDER BY day`
+	return r.queryVoteBuckets(ctx, query, tag, from.UTC().Format(analyticsDayLayout), to.UTC().Format(analyticsDayLayout))
+}
+
+func (r *Repository) GetPollHourlyVotes(ctx context.Context, pollID uuid.UUID, from, to time.Time) ([]domain.VoteBucket, error) {
+	query := `
+		SELECT hour, votes
+		FROM analytics_poll_hourly_votes
+		WHERE poll_id = $1 AND hour >= $2 AND hour < $3
+		ORDER BY hour`
+	return r.queryVoteBuckets(ctx, query, pollID, from, to)
+}
+
+func (r *Repository) queryVoteBuckets(ctx context.Context, query string, args ...interface{}) ([]domain.VoteBucket, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get vote buckets: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	buckets := make([]domain.VoteBucket, 0)
+	for rows.Next() {
+		var bucket domain.VoteBucket
+		if err := rows.Scan(&bucket.Start, &bucket.Votes); err != nil {
+			return nil, fmt.Errorf("scan vote bucket: %w", err)
+		}
+		bucket.Start = bucket.Start.UTC()
+		buckets = append(buckets, bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate vote buckets: %w", err)
+	}
+	return buckets, nil
+}
+
+func (r *Repository) GetUserActivity(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]domain.UserActivity, error) {
+	query := `
+		SELECT day, votes, skips, polls_created
+		FROM analytics_user_activity
+		WHERE user_id = $1 AND day >= $2 AND day < $3
+		ORDER BY day`
+	rows, err := r.db.QueryContext(ctx, query, userID, from.UTC().Format(analyticsDayLayout), to.UTC().Format(analyticsDayLayout))
+	if err != nil {
+		return nil, fmt.Errorf("get user activity: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	activity := make([]domain.UserActivity, 0)
+	for rows.Next() {
+		var day domain.UserActivity
+		if err := rows.Scan(&day.Day, &day.Votes, &day.Skips, &day.PollsCreated); err != nil {
+			return nil, fmt.Errorf("scan user activity: %w", err)
+		}
+		day.Day = day.Day.UTC()
+		activity = append(activity, day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate user activity: %w", err)
+	}
+	return activity, nil
+}
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/behzadon/vote/internal/storage/cache"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type Option func(*Repository)
+
+// WithLocalCache serves poll and stats reads from an in-process cache before
+// going to Redis.
+func WithLocalCache(local *cache.LocalCache) Option {
+	return func(r *Repository) {
+		r.local = local
+	}
+}
+
+// WithFeedCache serves repeated feed requests from cached pages. A user's
+// pages are dropped when they vote, skip, delete a vote or change their
+// preferences.
+func WithFeedCache(feed *cache.FeedCache) Option {
+	return func(r *Repository) {
+		r.feed = feed
+	}
+}
+
+// invalidateFeed drops the user's cached feed pages after a change that
+// adds or removes polls from it.
+func (r *Repository) invalidateFeed(ctx context.Context, userID uuid.UUID) {
+	if r.feed == nil {
+		return
+	}
+	if err := r.feed.Invalidate(ctx, userID); err != nil {
+		r.logger.Warn("Failed to invalidate cached feed",
+			zap.Error(err),
+			zap.String("user_id", userID.String()),
+		)
+	}
+}
+
+// getCached returns redis.Nil on a miss, like the Redis client.
+func (r *Repository) getCached(ctx context.Context, key string) ([]byte, error) {
+	if r.local != nil {
+		if data, ok := r.local.Get(key); ok {
+			return data, nil
+		}
+	}
+	data, err := r.redis.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	if r.local != nil {
+		r.local.Set(key, data)
+	}
+	return data, nil
+}
+
+func (r *Repository) setCached(ctx context.Context, key string, data []byte, expiration time.Duration) error {
+	if err := r.redis.Set(ctx, key, data, expiration).Err(); err != nil {
+		return err
+	}
+	if r.local != nil {
+		r.local.Set(key, data)
+	}
+	return nil
+}
+
+func (r *Repository) deleteCached(ctx context.Context, key string) error {
+	if err := r.redis.Del(ctx, key).Err(); err != nil {
+		return err
+	}
+	if r.local != nil {
+		return r.local.Invalidate(ctx, key)
+	}
+	return nil
+}
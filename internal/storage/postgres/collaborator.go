@@ -0,0 +1,368 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/storage/cache"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+func (r *Repository) UpdatePoll(ctx context.Context, poll *domain.Poll) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	query := `UPDATE polls SET title = $2, updated_at = $3 WHERE id = $1`
+	result, err := tx.ExecContext(ctx, query, poll.ID, poll.Title, poll.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("update poll: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	} else if rows == 0 {
+		return domain.ErrNotFound
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM poll_tags WHERE poll_id = $1`, poll.ID); err != nil {
+		return fmt.Errorf("delete tags: %w", err)
+	}
+	for _, tag := range poll.Tags {
+		if _, err = tx.ExecContext(ctx, `INSERT INTO poll_tags (poll_id, tag) VALUES ($1, $2)`, poll.ID, tag); err != nil {
+			return fmt.Errorf("insert tag %s: %w", tag, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
+
+	r.invalidateCachedPoll(ctx, poll.ID)
+	return nil
+}
+
+// UpdatePollTags adds and removes tags in one transaction. Touching the poll
+// row first locks it, so concurrent tag changes apply one after the other.
+// check sees the resulting tags before they are committed and can reject
+// them.
+func (r *Repository) UpdatePollTags(ctx context.Context, pollID uuid.UUID, add, remove []string, updatedAt time.Time, check func(tags []string) error) ([]string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, `UPDATE polls SET updated_at = $2 WHERE id = $1`, pollID, updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("update poll: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("get affected rows: %w", err)
+	} else if rows == 0 {
+		return nil, domain.ErrNotFound
+	}
+
+	if len(remove) > 0 {
+		if _, err = tx.ExecContext(ctx, `DELETE FROM poll_tags WHERE poll_id = $1 AND tag = ANY($2)`, pollID, pq.Array(remove)); err != nil {
+			return nil, fmt.Errorf("delete tags: %w", err)
+		}
+	}
+	for _, tag := range add {
+		if _, err = tx.ExecContext(ctx, `INSERT INTO poll_tags (poll_id, tag) VALUES ($1, $2) ON CONFLICT DO NOTHING`, pollID, tag); err != nil {
+			return nil, fmt.Errorf("insert tag %s: %w", tag, err)
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT tag FROM poll_tags WHERE poll_id = $1 ORDER BY tag`, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("get tags: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err = rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate tags: %w", err)
+	}
+	if err = check(tags); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
+
+	r.invalidateCachedPoll(ctx, pollID)
+	return tags, nil
+}
+
+// ReorderPollOptions rewrites option_index so the options of the poll follow
+// the order of optionIDs. The indexes are first moved out of the way so the
+// unique (poll_id, option_index) constraint holds between the two updates.
+// optionIDs has to name every option of the poll.
+func (r *Repository) ReorderPollOptions(ctx context.Context, pollID uuid.UUID, optionIDs []uuid.UUID, updatedAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, `UPDATE polls SET updated_at = $2 WHERE id = $1`, pollID, updatedAt)
+	if err != nil {
+		return fmt.Errorf("update poll: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	} else if rows == 0 {
+		return domain.ErrNotFound
+	}
+
+	result, err = tx.ExecContext(ctx, `UPDATE poll_options SET option_index = -option_index - 1 WHERE poll_id = $1`, pollID)
+	if err != nil {
+		return fmt.Errorf("move option indexes: %w", err)
+	}
+	total, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+
+	query := `
+		UPDATE poll_options o
+		SET option_index = t.position - 1
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS t(id, position)
+		WHERE o.poll_id = $1 AND o.id = t.id`
+	result, err = tx.ExecContext(ctx, query, pollID, pq.Array(optionIDs))
+	if err != nil {
+		return fmt.Errorf("reorder options: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if rows != total || int(rows) != len(optionIDs) {
+		return domain.ErrInvalidInput
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
+
+	r.invalidateCachedPoll(ctx, pollID)
+	return nil
+}
+
+// SetPollStatus stores status; closing a poll also ends its voting window at
+// changedAt unless it already ended.
+func (r *Repository) SetPollStatus(ctx context.Context, pollID uuid.UUID, status domain.PollStatus, changedAt time.Time) error {
+	query := `
+		UPDATE polls
+		SET status = $2::VARCHAR,
+			ends_at = CASE WHEN $2::VARCHAR = 'closed' THEN LEAST(COALESCE(ends_at, $3), $3) ELSE ends_at END,
+			updated_at = $3
+		WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, pollID, status, changedAt)
+	if err != nil {
+		return fmt.Errorf("set poll status: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+
+	r.invalidateCachedPoll(ctx, pollID)
+	return nil
+}
+
+func (r *Repository) CountSkips(ctx context.Context, pollID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM skips WHERE poll_id = $1`, pollID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count skips: %w", err)
+	}
+	return count, nil
+}
+
+func (r *Repository) CountSkipReasons(ctx context.Context, pollID uuid.UUID) (map[domain.SkipReason]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT reason, COUNT(*) FROM skips
+		WHERE poll_id = $1 AND reason IS NOT NULL
+		GROUP BY reason`, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("count skip reasons: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	counts := make(map[domain.SkipReason]int)
+	for rows.Next() {
+		var reason domain.SkipReason
+		var count int
+		if err := rows.Scan(&reason, &count); err != nil {
+			return nil, fmt.Errorf("scan skip reason: %w", err)
+		}
+		counts[reason] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate skip reasons: %w", err)
+	}
+	return counts, nil
+}
+
+func (r *Repository) CountVotes(ctx context.Context, pollID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM votes v WHERE v.poll_id = $1 AND NOT `+shadowBannedVoter, pollID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count votes: %w", err)
+	}
+	return count, nil
+}
+
+// RecordPollMilestone reports whether the milestone was recorded now, so that
+// each one is announced once even when vote events are redelivered.
+func (r *Repository) RecordPollMilestone(ctx context.Context, pollID uuid.UUID, milestone int) (bool, error) {
+	query := `
+		INSERT INTO poll_milestones (poll_id, milestone, reached_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`
+	result, err := r.db.ExecContext(ctx, query, pollID, milestone, time.Now().UTC())
+	if err != nil {
+		return false, fmt.Errorf("record poll milestone: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("record poll milestone: %w", err)
+	}
+	return rows > 0, nil
+}
+
+func (r *Repository) invalidateCachedPoll(ctx context.Context, pollID uuid.UUID) {
+	if err := r.deleteCached(ctx, cache.PollKey(pollID)); err != nil {
+		r.logger.Warn("Failed to invalidate cached poll",
+			zap.Error(err),
+			zap.String("poll_id", pollID.String()),
+		)
+	}
+}
+
+func (r *Repository) AddPollCollaborator(ctx context.Context, collaborator *domain.Collaborator) error {
+	query := `
+		INSERT INTO poll_collaborators (poll_id, user_id, permission, invited_by, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (poll_id, user_id) DO UPDATE
+		SET permission = EXCLUDED.permission`
+	_, err := r.db.ExecContext(ctx, query,
+		collaborator.PollID, collaborator.UserID, collaborator.Permission,
+		collaborator.InvitedBy, collaborator.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("add poll collaborator: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) RemovePollCollaborator(ctx context.Context, pollID, userID uuid.UUID) error {
+	query := `DELETE FROM poll_collaborators WHERE poll_id = $1 AND user_id = $2`
+	result, err := r.db.ExecContext(ctx, query, pollID, userID)
+	if err != nil {
+		return fmt.Errorf("remove poll collaborator: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repository) GetPollCollaborator(ctx context.Context, pollID, userID uuid.UUID) (*domain.Collaborator, error) {
+	query := `
+		SELECT poll_id, user_id, permission, invited_by, created_at
+		FROM poll_collaborators
+		WHERE poll_id = $1 AND user_id = $2`
+	var collaborator domain.Collaborator
+	err := r.db.QueryRowContext(ctx, query, pollID, userID).Scan(
+		&collaborator.PollID, &collaborator.UserID, &collaborator.Permission,
+		&collaborator.InvitedBy, &collaborator.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get poll collaborator: %w", err)
+	}
+	return &collaborator, nil
+}
+
+func (r *Repository) GetPollCollaborators(ctx context.Context, pollID uuid.UUID) ([]domain.Collaborator, error) {
+	query := `
+		SELECT poll_id, user_id, permission, invited_by, created_at
+		FROM poll_collaborators
+		WHERE poll_id = $1
+		ORDER BY created_at`
+	rows, err := r.db.QueryContext(ctx, query, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("get poll collaborators: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	collaborators := []domain.Collaborator{}
+	for rows.Next() {
+		var collaborator domain.Collaborator
+		err := rows.Scan(
+			&collaborator.PollID, &collaborator.UserID, &collaborator.Permission,
+			&collaborator.InvitedBy, &collaborator.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan poll collaborator: %w", err)
+		}
+		collaborators = append(collaborators, collaborator)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate poll collaborators: %w", err)
+	}
+	return collaborators, nil
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// RecordConsents stores the consents in one transaction. Accepting a version
+// again keeps the original acceptance.
+func (r *Repository) RecordConsents(ctx context.Context, userID uuid.UUID, consents []domain.Consent) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	query := `
+		INSERT INTO user_consents (user_id, document, version, accepted_at, ip_address, user_agent)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''))
+		ON CONFLICT (user_id, document, version) DO NOTHING`
+	for _, consent := range consents {
+		_, err := tx.ExecContext(ctx, query,
+			userID, consent.Document, consent.Version, consent.AcceptedAt, consent.IPAddress, consent.UserAgent,
+		)
+		if err != nil {
+			return fmt.Errorf("record %s consent: %w", consent.Document, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
+	return nil
+}
+
+func (r *Repository) GetConsents(ctx context.Context, userID uuid.UUID) ([]domain.Consent, error) {
+	query := `
+		SELECT document, version, accepted_at, COALESCE(ip_address, ''), COALESCE(user_agent, '')
+		FROM user_consents
+		WHERE user_id = $1
+		ORDER BY accepted_at DESC, document`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get consents: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	var consents []domain.Consent
+	for rows.Next() {
+		var consent domain.Consent
+		if err := rows.Scan(&consent.Document, &consent.Version, &consent.AcceptedAt, &consent.IPAddress, &consent.UserAgent); err != nil {
+			return nil, fmt.Errorf("scan consent: %w", err)
+		}
+		consents = append(consents, consent)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate consents: %w", err)
+	}
+	return consents, nil
+}
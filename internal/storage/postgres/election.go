@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+)
+
+func (r *Repository) GetElectionsToCertify(ctx context.Context, closedBefore time.Time) ([]uuid.UUID, error) {
+	query := `
+		SELECT p.id
+		FROM polls p
+		LEFT JOIN election_certifications ec ON ec.poll_id = p.id
+		WHERE p.kind = 'election' AND p.status NOT IN ('draft', 'deleted')
+		AND p.ends_at <= $1 AND ec.poll_id IS NULL
+		ORDER BY p.ends_at`
+	rows, err := r.db.QueryContext(ctx, query, closedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("get elections to certify: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan election id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate elections: %w", err)
+	}
+	return ids, nil
+}
+
+func (r *Repository) SaveElectionCertification(ctx context.Context, cert *domain.ElectionCertification) error {
+	query := `
+		INSERT INTO election_certifications (poll_id, tally, algorithm, signature, certified_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (poll_id) DO NOTHING`
+	_, err := r.db.ExecContext(ctx, query,
+		cert.PollID, string(cert.Tally), cert.Algorithm, cert.Signature, cert.CertifiedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("save election certification: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetElectionCertification(ctx context.Context, pollID uuid.UUID) (*domain.ElectionCertification, error) {
+	query := `
+		SELECT poll_id, tally, algorithm, signature, certified_at
+		FROM election_certifications
+		WHERE poll_id = $1`
+	var cert domain.ElectionCertification
+	var tally string
+	err := r.db.QueryRowContext(ctx, query, pollID).Scan(
+		&cert.PollID, &tally, &cert.Algorithm, &cert.Signature, &cert.CertifiedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get election certification: %w", err)
+	}
+	cert.Tally = []byte(tally)
+	return &cert, nil
+}
@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const emailChangeColumns = `id, user_id, old_email, new_email, status, old_token_hash, new_token_hash, expires_at, created_at, updated_at`
+
+func scanEmailChange(row rowScanner) (*domain.EmailChange, error) {
+	var change domain.EmailChange
+	err := row.Scan(
+		&change.ID, &change.UserID, &change.OldEmail, &change.NewEmail, &change.Status,
+		&change.OldTokenHash, &change.NewTokenHash, &change.ExpiresAt, &change.CreatedAt, &change.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+func (r *Repository) CreateEmailChange(ctx context.Context, change *domain.EmailChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE email_changes SET status = $2, updated_at = $3
+		WHERE user_id = $1 AND status IN ($4, $5, $6)`,
+		change.UserID, domain.EmailChangeCancelled, change.CreatedAt,
+		domain.EmailChangePending, domain.EmailChangeOldConfirmed, domain.EmailChangeNewConfirmed,
+	)
+	if err != nil {
+		return fmt.Errorf("cancel email changes: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO email_changes (`+emailChangeColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		change.ID, change.UserID, change.OldEmail, change.NewEmail, change.Status,
+		change.OldTokenHash, change.NewTokenHash, change.ExpiresAt, change.CreatedAt, change.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("create email change: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
+	return nil
+}
+
+func (r *Repository) GetOpenEmailChange(ctx context.Context, userID uuid.UUID) (*domain.EmailChange, error) {
+	query := `
+		SELECT ` + emailChangeColumns + `
+		FROM email_changes
+		WHERE user_id = $1 AND status IN ($2, $3, $4)`
+	change, err := scanEmailChange(r.db.QueryRowContext(ctx, query, userID,
+		domain.EmailChangePending, domain.EmailChangeOldConfirmed, domain.EmailChangeNewConfirmed,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get open email change: %w", err)
+	}
+	return change, nil
+}
+
+func (r *Repository) GetEmailChangeByToken(ctx context.Context, tokenHash string) (*domain.EmailChange, error) {
+	query := `
+		SELECT ` + emailChangeColumns + `
+		FROM email_changes
+		WHERE old_token_hash = $1 OR new_token_hash = $1`
+	change, err := scanEmailChange(r.db.QueryRowContext(ctx, query, tokenHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get email change: %w", err)
+	}
+	return change, nil
+}
+
+func (r *Repository) SetEmailChangeStatus(ctx context.Context, id uuid.UUID, from, to domain.EmailChangeStatus, at time.Time) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE email_changes SET status = $3, updated_at = $4 WHERE id = $1 AND status = $2`,
+		id, from, to, at,
+	)
+	if err != nil {
+		return fmt.Errorf("set email change status: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	} else if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repository) CompleteEmailChange(ctx context.Context, change *domain.EmailChange, from domain.EmailChangeStatus, at time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE email_changes SET status = $3, updated_at = $4 WHERE id = $1 AND status = $2`,
+		change.ID, from, domain.EmailChangeCompleted, at,
+	)
+	if err != nil {
+		return fmt.Errorf("complete email change: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	} else if rows == 0 {
+		return domain.ErrNotFound
+	}
+
+	// Both links were opened, so the new address is verified as well.
+	_, err = tx.ExecContext(ctx,
+		`UPDATE users SET email = $2, email_verified = TRUE, updated_at = $3 WHERE id = $1`,
+		change.UserID, change.NewEmail, at,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return domain.ErrEmailAlreadyExists
+		}
+		return fmt.Errorf("update user email: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE refresh_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`,
+		change.UserID, at,
+	)
+	if err != nil {
+		return fmt.Errorf("revoke user refresh tokens: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
+	return nil
+}
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func (r *Repository) CreateEmailVerificationToken(ctx context.Context, token *domain.EmailVerificationToken) error {
+	query := `
+		INSERT INTO email_verification_tokens (token_hash, user_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)`
+	_, err := r.db.ExecContext(ctx, query, token.TokenHash, token.UserID, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create email verification token: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) VerifyEmail(ctx context.Context, tokenHash string, now time.Time) (uuid.UUID, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	var userID uuid.UUID
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM email_verification_tokens
+		WHERE token_hash = $1 AND expires_at > $2
+		RETURNING user_id`, tokenHash, now,
+	).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("consume email verification token: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET email_verified = TRUE WHERE id = $1`, userID); err != nil {
+		return uuid.Nil, fmt.Errorf("mark email verified: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM email_verification_tokens WHERE user_id = $1`, userID); err != nil {
+		return uuid.Nil, fmt.Errorf("delete email verification tokens: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return uuid.Nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
+	return userID, nil
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+)
+
+func (r *Repository) RecordVoteLocation(ctx context.Context, pollID uuid.UUID, location domain.GeoLocation) error {
+	query := `
+		INSERT INTO poll_geo_votes (poll_id, country, region, votes)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (poll_id, country, region) DO UPDATE SET votes = poll_geo_votes.votes + 1`
+	if _, err := r.db.ExecContext(ctx, query, pollID, location.Country, location.Region); err != nil {
+		return fmt.Errorf("record vote location: %w", err)
+	}
+	return nil
+}
+
+// GetPollGeoStats returns vote counts per country, most votes first, with
+// the regions that are known inside each.
+func (r *Repository) GetPollGeoStats(ctx context.Context, pollID uuid.UUID) ([]domain.CountryStat, error) {
+	query := `
+		SELECT country, region, votes,
+			SUM(votes) OVER (PARTITION BY country) AS country_votes
+		FROM poll_geo_votes
+		WHERE poll_id = $1
+		ORDER BY country_votes DESC, country, votes DESC, region`
+	rows, err := r.db.QueryContext(ctx, query, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("query poll geo stats: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	var stats []domain.CountryStat
+	for rows.Next() {
+		var country, region string
+		var votes, countryVotes int
+		if err := rows.Scan(&country, &region, &votes, &countryVotes); err != nil {
+			return nil, fmt.Errorf("scan poll geo stats: %w", err)
+		}
+		if len(stats) == 0 || stats[len(stats)-1].Country != country {
+			stats = append(stats, domain.CountryStat{Country: country, Votes: countryVotes})
+		}
+		if region != "" {
+			last := &stats[len(stats)-1]
+			last.Regions = append(last.Regions, domain.RegionStat{Region: region, Votes: votes})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate poll geo stats: %w", err)
+	}
+	return stats, nil
+}
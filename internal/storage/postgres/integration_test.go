@@ -0,0 +1,263 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// The integration tests run the repository against real Postgres and Redis:
+//
+//	go test -tags=integration ./internal/storage/postgres/...
+//
+// VOTE_TEST_DATABASE_URL and VOTE_TEST_REDIS_ADDR point them at running
+// servers. Whichever is unset is started as a throwaway container with the
+// docker CLI and removed when the tests finish. The database is migrated
+// from migrations/ like `vote migrate up` does and shared by every test, so
+// tests create their own users and polls and never assume an empty table.
+var (
+	testDB    *sql.DB
+	testRedis *redis.Client
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(runIntegrationTests(m))
+}
+
+func runIntegrationTests(m *testing.M) int {
+	var containers []string
+	defer func() {
+		for _, id := range containers {
+			_ = exec.Command("docker", "rm", "-f", id).Run()
+		}
+	}()
+
+	dsn := os.Getenv("VOTE_TEST_DATABASE_URL")
+	if dsn == "" {
+		id, addr, err := startContainer("postgres:15-alpine", "5432/tcp",
+			"-e", "POSTGRES_USER=vote", "-e", "POSTGRES_PASSWORD=vote", "-e", "POSTGRES_DB=vote")
+		if id != "" {
+			containers = append(containers, id)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "start postgres: %v\n", err)
+			return 1
+		}
+		dsn = fmt.Sprintf("postgres://vote:vote@%s/vote?sslmode=disable", addr)
+	}
+	redisAddr := os.Getenv("VOTE_TEST_REDIS_ADDR")
+	if redisAddr == "" {
+		id, addr, err := startContainer("redis:7-alpine", "6379/tcp")
+		if id != "" {
+			containers = append(containers, id)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "start redis: %v\n", err)
+			return 1
+		}
+		redisAddr = addr
+	}
+
+	ctx := context.Background()
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "open database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+	if err := waitFor(func() error { return db.PingContext(ctx) }); err != nil {
+		fmt.Fprintf(os.Stderr, "connect to database: %v\n", err)
+		return 1
+	}
+	if err := migrate(db); err != nil {
+		fmt.Fprintf(os.Stderr, "migrate database: %v\n", err)
+		return 1
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: redisAddr})
+	defer client.Close()
+	if err := waitFor(func() error { return client.Ping(ctx).Err() }); err != nil {
+		fmt.Fprintf(os.Stderr, "connect to redis: %v\n", err)
+		return 1
+	}
+	if err := client.FlushDB(ctx).Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "flush redis: %v\n", err)
+		return 1
+	}
+
+	testDB, testRedis = db, client
+	return m.Run()
+}
+
+// startContainer runs image with port published on a random host port and
+// returns the container ID and the host address of the port.
+func startContainer(image, port string, args ...string) (string, string, error) {
+	runArgs := append([]string{"run", "-d", "--rm", "-p", "127.0.0.1::" + strings.TrimSuffix(port, "/tcp")}, args...)
+	out, err := exec.Command("docker", append(runArgs, image)...).Output()
+	if err != nil {
+		return "", "", fmt.Errorf("docker run %s: %w", image, err)
+	}
+	id := strings.TrimSpace(string(out))
+
+	out, err = exec.Command("docker", "port", id, port).Output()
+	if err != nil {
+		return id, "", fmt.Errorf("docker port %s: %w", image, err)
+	}
+	addr, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return id, addr, nil
+}
+
+func waitFor(ping func() error) error {
+	deadline := time.Now().Add(60 * time.Second)
+	for {
+		err := ping()
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// migrate applies the up part of every migration not applied yet, each in
+// its own transaction, and records it in the migrations table.
+func migrate(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS migrations (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(255) NOT NULL UNIQUE,
+			applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return fmt.Errorf("create migrations table: %w", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join("..", "..", "..", "migrations", "*.sql"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		name := filepath.Base(file)
+		var applied bool
+		if err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM migrations WHERE name = $1)`, name).Scan(&applied); err != nil {
+			return fmt.Errorf("check migration %s: %w", name, err)
+		}
+		if applied {
+			continue
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		up, _, ok := strings.Cut(string(content), "-- Down Migration")
+		if !ok {
+			return fmt.Errorf("migration %s has no down section", name)
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(up); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("apply migration %s: %w", name, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO migrations (name) VALUES ($1)`, name); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("record migration %s: %w", name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newTestRepository(t *testing.T) *Repository {
+	t.Helper()
+	return NewRepository(testDB, testRedis, zap.NewNop())
+}
+
+// uniqueName returns prefix followed by random characters, short enough for
+// usernames and tags.
+func uniqueName(prefix string) string {
+	return prefix + strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+}
+
+func createTestUser(t *testing.T, repo *Repository) *domain.User {
+	t.Helper()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	name := uniqueName("it_")
+	user := &domain.User{
+		ID:        uuid.New(),
+		Username:  name,
+		Email:     name + "@integration.test",
+		Password:  "hash",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	require.NoError(t, repo.CreateUser(context.Background(), user))
+	return user
+}
+
+// createTestPoll creates a live, open poll by creator with options "a", "b"
+// and "c" and one unique tag. edit, if given, adjusts the poll first.
+func createTestPoll(t *testing.T, repo *Repository, creator *domain.User, edit func(*domain.Poll)) *domain.Poll {
+	t.Helper()
+	poll := &domain.Poll{
+		ID:        uuid.New(),
+		Title:     "Integration poll " + uniqueName(""),
+		CreatedBy: &creator.ID,
+	}
+	if edit != nil {
+		edit(poll)
+	}
+	tags := poll.Tags
+	if tags == nil {
+		tags = []string{uniqueName("tag")}
+	}
+	require.NoError(t, repo.CreatePoll(context.Background(), poll, []string{"a", "b", "c"}, tags))
+	return poll
+}
+
+func castTestVote(t *testing.T, repo *Repository, poll *domain.Poll, voter *domain.User, option int, at time.Time) *domain.Vote {
+	t.Helper()
+	vote := &domain.Vote{
+		ID:        uuid.New(),
+		PollID:    poll.ID,
+		UserID:    voter.ID,
+		OptionID:  poll.Options[option].ID,
+		CreatedAt: at,
+	}
+	require.NoError(t, repo.CreateVote(context.Background(), vote))
+	return vote
+}
+
+// countOutboxEvents counts the queued events of eventType whose payload has
+// the given id.
+func countOutboxEvents(t *testing.T, eventType string, id uuid.UUID) int {
+	t.Helper()
+	var count int
+	err := testDB.QueryRow(`
+		SELECT COUNT(*) FROM event_outbox
+		WHERE event_type = $1 AND payload->>'id' = $2`, eventType, id.String(),
+	).Scan(&count)
+	require.NoError(t, err)
+	return count
+}
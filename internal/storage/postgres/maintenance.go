@@ -0,0 +1,249 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/storage/cache"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func (r *Repository) RollupPollStats(ctx context.Context, day time.Time) (int64, error) {
+	query := `
+		INSERT INTO poll_stats_daily (poll_id, option_id, stat_date, vote_count, updated_at)
+		SELECT v.poll_id, v.option_id, $1::date, COUNT(*), $3
+		FROM votes v
+		WHERE v.created_at >= $1 AND v.created_at < $2
+		GROUP BY v.poll_id, v.option_id
+		ON CONFLICT (poll_id, option_id, stat_date) DO UPDATE
+		SET vote_count = EXCLUDED.vote_count,
+			updated_at = EXCLUDED.updated_at`
+	result, err := r.db.ExecContext(ctx, query, day, day.AddDate(0, 0, 1), time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("rollup poll stats: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rollup poll stats rows affected: %w", err)
+	}
+	return rows, nil
+}
+
+// RepairPollStatsDaily compares a poll's daily rollups with the votes table
+// and, if any differ, rebuilds them. It returns the rows that were wrong.
+func (r *Repository) RepairPollStatsDaily(ctx context.Context, pollID uuid.UUID) ([]domain.StatsDiscrepancy, error) {
+	query := `
+		WITH expected AS (
+			SELECT v.option_id, (v.created_at AT TIME ZONE 'UTC')::date AS stat_date, COUNT(*) AS vote_count
+			FROM votes v
+			WHERE v.poll_id = $1
+			GROUP BY v.option_id, (v.created_at AT TIME ZONE 'UTC')::date
+		), stored AS (
+			SELECT option_id, stat_date, vote_count
+			FROM poll_stats_daily
+			WHERE poll_id = $1
+		)
+		SELECT po.option_text, COALESCE(e.stat_date, s.stat_date),
+			   COALESCE(e.vote_count, 0), COALESCE(s.vote_count, 0)
+		FROM expected e
+		FULL OUTER JOIN stored s ON s.option_id = e.option_id AND s.stat_date = e.stat_date
+		JOIN poll_options po ON po.id = COALESCE(e.option_id, s.option_id)
+		WHERE COALESCE(e.vote_count, 0) <> COALESCE(s.vote_count, 0)
+		ORDER BY 2, po.option_index`
+	rows, err := r.db.QueryContext(ctx, query, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("compare poll stats daily: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	discrepancies := make([]domain.StatsDiscrepancy, 0)
+	for rows.Next() {
+		d := domain.StatsDiscrepancy{Source: domain.StatsSourceDailyRollup}
+		var day time.Time
+		if err := rows.Scan(&d.Option, &day, &d.Expected, &d.Found); err != nil {
+			return nil, fmt.Errorf("scan poll stats daily discrepancy: %w", err)
+		}
+		d.Day = &day
+		discrepancies = append(discrepancies, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate poll stats daily discrepancies: %w", err)
+	}
+	if len(discrepancies) == 0 {
+		return discrepancies, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM poll_stats_daily WHERE poll_id = $1`, pollID); err != nil {
+		return nil, fmt.Errorf("clear poll stats daily: %w", err)
+	}
+	rebuildQuery := `
+		INSERT INTO poll_stats_daily (poll_id, option_id, stat_date, vote_count, updated_at)
+		SELECT v.poll_id, v.option_id, (v.created_at AT TIME ZONE 'UTC')::date, COUNT(*), $2
+		FROM votes v
+		WHERE v.poll_id = $1
+		GROUP BY v.poll_id, v.option_id, (v.created_at AT TIME ZONE 'UTC')::date`
+	if _, err = tx.ExecContext(ctx, rebuildQuery, pollID, time.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("rebuild poll stats daily: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
+	return discrepancies, nil
+}
+
+func (r *Repository) PruneUserDailyVotes(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM user_daily_votes WHERE vote_date < $1`
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("prune user daily votes: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("prune user daily votes rows affected: %w", err)
+	}
+	return rows, nil
+}
+
+func (r *Repository) GetTrendingPolls(ctx context.Context, since time.Time, limit int) ([]domain.TrendingPoll, error) {
+	query := `
+		SELECT p.id, p.title, COUNT(v.id) AS vote_count
+		FROM votes v
+		JOIN polls p ON p.id = v.poll_id
+		WHERE v.created_at >= $1 AND NOT ` + shadowBannedVoter + ` AND NOT ` + shadowBannedCreator + `
+		GROUP BY p.id, p.title
+		ORDER BY vote_count DESC, p.id
+		LIMIT $2`
+	rows, err := r.db.QueryContext(ctx, query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get trending polls: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	polls := make([]domain.TrendingPoll, 0)
+	for rows.Next() {
+		var poll domain.TrendingPoll
+		if err := rows.Scan(&poll.PollID, &poll.Title, &poll.VoteCount); err != nil {
+			return nil, fmt.Errorf("scan trending poll: %w", err)
+		}
+		polls = append(polls, poll)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate trending polls: %w", err)
+	}
+	return polls, nil
+}
+
+func (r *Repository) SetCachedTrendingPolls(ctx context.Context, polls []domain.TrendingPoll) error {
+	data, err := json.Marshal(polls)
+	if err != nil {
+		return fmt.Errorf("marshal trending polls: %w", err)
+	}
+	if err := r.redis.Set(ctx, cache.TrendingPollsKey, data, 0).Err(); err != nil {
+		return fmt.Errorf("cache trending polls: %w", err)
+	}
+	return nil
+}
+
+// GetRecentlyActivePollIDs returns up to limit polls voted on since the given
+// time, most recently voted first.
+func (r *Repository) GetRecentlyActivePollIDs(ctx context.Context, since time.Time, limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT poll_id
+		FROM votes
+		WHERE created_at >= $1
+		GROUP BY poll_id
+		ORDER BY MAX(created_at) DESC
+		LIMIT $2`
+	rows, err := r.db.QueryContext(ctx, query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get recently active polls: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	var pollIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan recently active poll: %w", err)
+		}
+		pollIDs = append(pollIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate recently active polls: %w", err)
+	}
+	return pollIDs, nil
+}
+
+func (r *Repository) GetActiveUserIDs(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	query := `
+		SELECT DISTINCT user_id
+		FROM votes
+		WHERE created_at >= $1`
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("get active users: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan active user: %w", err)
+		}
+		userIDs = append(userIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate active users: %w", err)
+	}
+	return userIDs, nil
+}
+
+// CountOpenPolls counts the published polls whose voting window includes at,
+// the way Poll.IsOpen decides it.
+func (r *Repository) CountOpenPolls(ctx context.Context, at time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM polls
+		WHERE status IN ('scheduled', 'live')
+		AND (starts_at IS NULL OR starts_at <= $1)
+		AND (ends_at IS NULL OR ends_at > $1)`
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, at).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count open polls: %w", err)
+	}
+	return count, nil
+}
+
+func (r *Repository) CountActiveVoters(ctx context.Context, day time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM analytics_user_activity
+		WHERE day = $1 AND votes > 0`
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, day.UTC().Format(analyticsDayLayout)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count active voters: %w", err)
+	}
+	return count, nil
+}
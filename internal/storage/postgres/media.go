@@ -0,0 +1,160 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// SetUserAvatar points the user's avatar at key and returns the key it
+// replaced, if any, so the caller can delete the old object.
+func (r *Repository) SetUserAvatar(ctx context.Context, userID uuid.UUID, key string) (string, error) {
+	query := `
+		UPDATE users u
+		SET avatar_key = $2, updated_at = NOW()
+		FROM (SELECT avatar_key FROM users WHERE id = $1 FOR UPDATE) old
+		WHERE u.id = $1
+		RETURNING old.avatar_key`
+	var previous sql.NullString
+	err := r.db.QueryRowContext(ctx, query, userID, key).Scan(&previous)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", domain.ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("set user avatar: %w", err)
+	}
+	return previous.String, nil
+}
+
+// SetOptionImage points the option's image at key and returns the key it
+// replaced, if any.
+func (r *Repository) SetOptionImage(ctx context.Context, pollID uuid.UUID, optionIndex int, key string) (string, error) {
+	query := `
+		UPDATE poll_options po
+		SET image_key = $3
+		FROM (
+			SELECT image_key FROM poll_options
+			WHERE poll_id = $1 AND option_index = $2
+			FOR UPDATE
+		) old
+		WHERE po.poll_id = $1 AND po.option_index = $2
+		RETURNING old.image_key`
+	var previous sql.NullString
+	err := r.db.QueryRowContext(ctx, query, pollID, optionIndex, key).Scan(&previous)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", domain.ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("set option image: %w", err)
+	}
+	r.invalidateCachedPoll(ctx, pollID)
+	return previous.String, nil
+}
+
+// UpdateOptionMetadata sets the alt text and emoji of an option, leaving nil
+// fields unchanged and clearing empty ones.
+func (r *Repository) UpdateOptionMetadata(ctx context.Context, pollID uuid.UUID, optionIndex int, altText, emoji *string) error {
+	query := `
+		UPDATE poll_options
+		SET alt_text = CASE WHEN $3 THEN NULLIF($4, '') ELSE alt_text END,
+			emoji = CASE WHEN $5 THEN NULLIF($6, '') ELSE emoji END
+		WHERE poll_id = $1 AND option_index = $2`
+	result, err := r.db.ExecContext(ctx, query, pollID, optionIndex,
+		altText != nil, stringOrEmpty(altText), emoji != nil, stringOrEmpty(emoji))
+	if err != nil {
+		return fmt.Errorf("update option metadata: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	r.invalidateCachedPoll(ctx, pollID)
+	return nil
+}
+
+func (r *Repository) GetPollPreviewCard(ctx context.Context, pollID uuid.UUID) (*domain.PollPreviewCard, error) {
+	query := `
+		SELECT image_key, option_counts, option_count, rendered_at
+		FROM poll_preview_cards
+		WHERE poll_id = $1`
+	card := &domain.PollPreviewCard{PollID: pollID}
+	var counts []int64
+	err := r.db.QueryRowContext(ctx, query, pollID).Scan(&card.ImageKey, pq.Array(&counts), &card.OptionCount, &card.RenderedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get poll preview card: %w", err)
+	}
+	for _, count := range counts {
+		card.Counts = append(card.Counts, int(count))
+	}
+	return card, nil
+}
+
+// SetPollPreviewCard records the card stored for a poll. The image it
+// replaces is left for media_gc, so URLs already handed out keep working
+// until then.
+func (r *Repository) SetPollPreviewCard(ctx context.Context, card *domain.PollPreviewCard) error {
+	counts := make([]int64, len(card.Counts))
+	for i, count := range card.Counts {
+		counts[i] = int64(count)
+	}
+	query := `
+		INSERT INTO poll_preview_cards (poll_id, image_key, option_counts, option_count, rendered_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (poll_id) DO UPDATE
+		SET image_key = EXCLUDED.image_key,
+			option_counts = EXCLUDED.option_counts,
+			option_count = EXCLUDED.option_count,
+			rendered_at = EXCLUDED.rendered_at`
+	_, err := r.db.ExecContext(ctx, query, card.PollID, card.ImageKey, pq.Array(counts), card.OptionCount, card.RenderedAt)
+	if err != nil {
+		return fmt.Errorf("set poll preview card: %w", err)
+	}
+	return nil
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func (r *Repository) UnreferencedMediaKeys(ctx context.Context, keys []string) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	query := `
+		SELECT k FROM unnest($1::text[]) AS k
+		WHERE NOT EXISTS (SELECT 1 FROM users WHERE avatar_key = k)
+		AND NOT EXISTS (SELECT 1 FROM poll_options WHERE image_key = k)
+		AND NOT EXISTS (SELECT 1 FROM poll_preview_cards WHERE image_key = k)`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(keys))
+	if err != nil {
+		return nil, fmt.Errorf("find unreferenced media: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	var orphans []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("scan media key: %w", err)
+		}
+		orphans = append(orphans, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate media keys: %w", err)
+	}
+	return orphans, nil
+}
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const moderationFlagColumns = `id, kind, content, poll_id, author_id, score, reasons, status, created_at, resolved_by, resolved_at`
+
+func scanModerationFlag(row rowScanner) (*domain.ModerationFlag, error) {
+	var flag domain.ModerationFlag
+	var pollID, authorID, resolvedBy uuid.NullUUID
+	var resolvedAt sql.NullTime
+	err := row.Scan(
+		&flag.ID, &flag.Kind, &flag.Content, &pollID, &authorID, &flag.Score,
+		pq.Array(&flag.Reasons), &flag.Status, &flag.CreatedAt, &resolvedBy, &resolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if pollID.Valid {
+		flag.PollID = &pollID.UUID
+	}
+	if authorID.Valid {
+		flag.AuthorID = &authorID.UUID
+	}
+	if resolvedBy.Valid {
+		flag.ResolvedBy = &resolvedBy.UUID
+	}
+	if resolvedAt.Valid {
+		flag.ResolvedAt = &resolvedAt.Time
+	}
+	return &flag, nil
+}
+
+func (r *Repository) CreateModerationFlag(ctx context.Context, flag *domain.ModerationFlag) error {
+	query := `
+		INSERT INTO moderation_flags (id, kind, content, poll_id, author_id, score, reasons, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	var pollID, authorID uuid.NullUUID
+	if flag.PollID != nil {
+		pollID = uuid.NullUUID{UUID: *flag.PollID, Valid: true}
+	}
+	if flag.AuthorID != nil {
+		authorID = uuid.NullUUID{UUID: *flag.AuthorID, Valid: true}
+	}
+	_, err := r.db.ExecContext(ctx, query,
+		flag.ID, flag.Kind, flag.Content, pollID, authorID, flag.Score,
+		pq.Array(flag.Reasons), flag.Status, flag.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("create moderation flag: %w", err)
+	}
+	return nil
+}
+
+// GetModerationFlags pages through the flags with status, oldest first so
+// the queue is worked in the order content was flagged.
+func (r *Repository) GetModerationFlags(ctx context.Context, status domain.FlagStatus, page, limit int) ([]domain.ModerationFlag, int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM moderation_flags WHERE status = $1`, status).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count moderation flags: %w", err)
+	}
+
+	query := `
+		SELECT ` + moderationFlagColumns + `
+		FROM moderation_flags
+		WHERE status = $1
+		ORDER BY created_at, id
+		LIMIT $2 OFFSET $3`
+	rows, err := r.db.QueryContext(ctx, query, status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get moderation flags: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	var flags []domain.ModerationFlag
+	for rows.Next() {
+		flag, err := scanModerationFlag(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan moderation flag: %w", err)
+		}
+		flags = append(flags, *flag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterate moderation flags: %w", err)
+	}
+	return flags, total, nil
+}
+
+// ResolveModerationFlag closes an open flag. A flag that does not exist or
+// was already resolved is reported as domain.ErrNotFound.
+func (r *Repository) ResolveModerationFlag(ctx context.Context, flagID uuid.UUID, status domain.FlagStatus, resolvedBy uuid.UUID, resolvedAt time.Time) (*domain.ModerationFlag, error) {
+	query := `
+		UPDATE moderation_flags
+		SET status = $2, resolved_by = $3, resolved_at = $4
+		WHERE id = $1 AND status = 'open'
+		RETURNING ` + moderationFlagColumns
+	flag, err := scanModerationFlag(r.db.QueryRowContext(ctx, query, flagID, status, resolvedBy, resolvedAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolve moderation flag: %w", err)
+	}
+	return flag, nil
+}
+
+// GetPollReviewFlag returns the most recent poll_review flag of the poll, or
+// domain.ErrNotFound if it was never held for review.
+func (r *Repository) GetPollReviewFlag(ctx context.Context, pollID uuid.UUID) (*domain.ModerationFlag, error) {
+	query := `
+		SELECT ` + moderationFlagColumns + `
+		FROM moderation_flags
+		WHERE poll_id = $1 AND kind = $2
+		ORDER BY created_at DESC, id
+		LIMIT 1`
+	flag, err := scanModerationFlag(r.db.QueryRowContext(ctx, query, pollID, domain.ContentPollReview))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get poll review flag: %w", err)
+	}
+	return flag, nil
+}
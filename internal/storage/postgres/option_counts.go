@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// adjustOptionCount adds delta to the vote count of the option, unless the
+// voter is shadow-banned and so isn't counted. It must run in the
+// transaction that changes the vote.
+func adjustOptionCount(ctx context.Context, tx *sql.Tx, optionID, userID uuid.UUID, delta int) error {
+	query := `
+		INSERT INTO poll_option_counts (option_id, poll_id, votes)
+		SELECT po.id, po.poll_id, $3
+		FROM poll_options po, (SELECT $2::uuid AS user_id) v
+		WHERE po.id = $1 AND NOT ` + shadowBannedVoter + `
+		ON CONFLICT (option_id) DO UPDATE
+		SET votes = poll_option_counts.votes + EXCLUDED.votes`
+	if _, err := tx.ExecContext(ctx, query, optionID, userID, delta); err != nil {
+		return fmt.Errorf("update option count: %w", err)
+	}
+	return nil
+}
+
+// adjustUserOptionCounts adds delta to the count of every option the user
+// voted for, whatever their standing. Options only a shadow-banned user voted
+// for have no count row yet, so one is created for them.
+func adjustUserOptionCounts(ctx context.Context, tx *sql.Tx, userID uuid.UUID, delta int) error {
+	query := `
+		INSERT INTO poll_option_counts (option_id, poll_id, votes)
+		SELECT po.id, po.poll_id, count(*) * $2
+		FROM votes v
+		JOIN poll_options po ON po.id = v.option_id
+		WHERE v.user_id = $1
+		GROUP BY po.id, po.poll_id
+		ON CONFLICT (option_id) DO UPDATE
+		SET votes = poll_option_counts.votes + EXCLUDED.votes`
+	if _, err := tx.ExecContext(ctx, query, userID, delta); err != nil {
+		return fmt.Errorf("update option counts: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// eligibleVoterCondition restricts polls aliased p to those the user bound to
+// $1 may vote on.
+const eligibleVoterCondition = `(
+			p.electorate = 'open'
+			OR (p.electorate = 'organization' AND EXISTS (
+				SELECT 1 FROM organization_members om
+				WHERE om.organization_id = p.organization_id AND om.user_id = $1
+			))
+			OR (p.electorate = 'list' AND EXISTS (
+				SELECT 1 FROM poll_electorate pe
+				JOIN users u ON LOWER(u.email) = pe.email
+				WHERE pe.poll_id = p.id AND u.id = $1
+			))
+		)`
+
+func (r *Repository) CreateOrganization(ctx context.Context, org *domain.Organization, ownerID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	query := `INSERT INTO organizations (id, name, created_at) VALUES ($1, $2, $3)`
+	if _, err = tx.ExecContext(ctx, query, org.ID, org.Name, org.CreatedAt); err != nil {
+		return fmt.Errorf("insert organization: %w", err)
+	}
+
+	memberQuery := `
+		INSERT INTO organization_members (organization_id, user_id, role, created_at)
+		VALUES ($1, $2, $3, $4)`
+	if _, err = tx.ExecContext(ctx, memberQuery, org.ID, ownerID, domain.OrganizationAdmin, org.CreatedAt); err != nil {
+		return fmt.Errorf("insert organization owner: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
+	return nil
+}
+
+func (r *Repository) AddOrganizationMember(ctx context.Context, member *domain.Membership) error {
+	query := `
+		INSERT INTO organization_members (organization_id, user_id, role, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (organization_id, user_id) DO UPDATE
+		SET role = EXCLUDED.role`
+	_, err := r.db.ExecContext(ctx, query, member.OrganizationID, member.UserID, member.Role, member.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("add organization member: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetOrganizationRole(ctx context.Context, orgID, userID uuid.UUID) (domain.OrganizationRole, error) {
+	query := `SELECT role FROM organization_members WHERE organization_id = $1 AND user_id = $2`
+	var role domain.OrganizationRole
+	err := r.db.QueryRowContext(ctx, query, orgID, userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", domain.ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("get organization role: %w", err)
+	}
+	return role, nil
+}
+
+func (r *Repository) IsEligibleVoter(ctx context.Context, pollID, userID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM polls p WHERE p.id = $2 AND ` + eligibleVoterCondition + `)`
+	var eligible bool
+	if err := r.db.QueryRowContext(ctx, query, userID, pollID).Scan(&eligible); err != nil {
+		return false, fmt.Errorf("check voter eligibility: %w", err)
+	}
+	return eligible, nil
+}
+
+// fillTurnout sets stats.Turnout for polls with a restricted electorate.
+func (r *Repository) fillTurnout(ctx context.Context, stats *domain.PollStats) error {
+	query := `
+		SELECT CASE p.electorate
+			WHEN 'organization' THEN (
+				SELECT COUNT(*) FROM organization_members om WHERE om.organization_id = p.organization_id
+			)
+			WHEN 'list' THEN (
+				SELECT COUNT(*) FROM poll_electorate pe WHERE pe.poll_id = p.id
+			)
+		END
+		FROM polls p
+		WHERE p.id = $1`
+	var eligible sql.NullInt64
+	err := r.db.QueryRowContext(ctx, query, stats.PollID).Scan(&eligible)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !eligible.Valid) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get eligible voter count: %w", err)
+	}
+
+	voted := 0
+	for _, option := range stats.Votes {
+		voted += option.Count
+	}
+	stats.Turnout = &domain.Turnout{Voted: voted, Eligible: int(eligible.Int64)}
+	return nil
+}
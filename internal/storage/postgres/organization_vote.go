@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func (r *Repository) CreateOrganizationVote(ctx context.Context, vote *domain.OrganizationVote) error {
+	query := `
+		INSERT INTO organization_votes (id, poll_id, organization_id, option_id, cast_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := r.db.ExecContext(ctx, query,
+		vote.ID, vote.PollID, vote.OrganizationID, vote.OptionID, castBy(vote), vote.CreatedAt, vote.UpdatedAt,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return domain.ErrAlreadyVoted
+		}
+		return fmt.Errorf("create organization vote: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetOrganizationVote(ctx context.Context, pollID, orgID uuid.UUID) (*domain.OrganizationVote, error) {
+	query := `
+		SELECT ov.id, ov.poll_id, ov.organization_id, ov.option_id, ov.cast_by, ov.created_at, ov.updated_at, po.option_text
+		FROM organization_votes ov
+		JOIN poll_options po ON po.id = ov.option_id
+		WHERE ov.poll_id = $1 AND ov.organization_id = $2`
+	var vote domain.OrganizationVote
+	var actor uuid.NullUUID
+	err := r.db.QueryRowContext(ctx, query, pollID, orgID).Scan(
+		&vote.ID, &vote.PollID, &vote.OrganizationID, &vote.OptionID, &actor, &vote.CreatedAt, &vote.UpdatedAt, &vote.OptionText,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get organization vote: %w", err)
+	}
+	if actor.Valid {
+		vote.CastBy = &actor.UUID
+	}
+	return &vote, nil
+}
+
+func castBy(vote *domain.OrganizationVote) uuid.NullUUID {
+	if vote.CastBy == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *vote.CastBy, Valid: true}
+}
+
+func (r *Repository) UpdateOrganizationVote(ctx context.Context, vote *domain.OrganizationVote) error {
+	query := `
+		UPDATE organization_votes
+		SET option_id = $1, cast_by = $2, updated_at = $3
+		WHERE id = $4`
+	result, err := r.db.ExecContext(ctx, query, vote.OptionID, castBy(vote), vote.UpdatedAt, vote.ID)
+	if err != nil {
+		return fmt.Errorf("update organization vote: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	} else if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// fillOrganizationVotes sets stats.OrganizationVotes for polls that accept
+// organization votes.
+func (r *Repository) fillOrganizationVotes(ctx context.Context, stats *domain.PollStats) error {
+	var accepts bool
+	err := r.db.QueryRowContext(ctx, `SELECT organization_votes FROM polls WHERE id = $1`, stats.PollID).Scan(&accepts)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !accepts) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get poll organization votes: %w", err)
+	}
+
+	counts, err := r.GetOrganizationVoteStats(ctx, stats.PollID)
+	if err != nil {
+		return err
+	}
+	stats.OrganizationVotes = counts
+	return nil
+}
+
+// GetOrganizationVoteStats counts the organization votes for each option of
+// the poll, in option order.
+func (r *Repository) GetOrganizationVoteStats(ctx context.Context, pollID uuid.UUID) ([]domain.OptionStats, error) {
+	query := `
+		SELECT po.option_text, COUNT(ov.id)
+		FROM poll_options po
+		LEFT JOIN organization_votes ov ON ov.option_id = po.id
+		WHERE po.poll_id = $1
+		GROUP BY po.option_text, po.created_at
+		ORDER BY po.created_at`
+	rows, err := r.db.QueryContext(ctx, query, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("get organization vote stats: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	stats := make([]domain.OptionStats, 0)
+	for rows.Next() {
+		var option domain.OptionStats
+		if err := rows.Scan(&option.Option, &option.Count); err != nil {
+			return nil, fmt.Errorf("scan organization vote stats: %w", err)
+		}
+		stats = append(stats, option)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate organization vote stats: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+)
+
+// AddOutboxEvent, ClaimOutboxEvents and DeleteOutboxEvent make the
+// repository an events.OutboxStore.
+
+func (r *Repository) AddOutboxEvent(ctx context.Context, eventType string, payload []byte) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO event_outbox (event_type, payload)
+		VALUES ($1, $2)`, eventType, payload)
+	if err != nil {
+		return fmt.Errorf("add outbox event: %w", err)
+	}
+	return nil
+}
+
+// addOutboxEvent queues data as an event within tx, so that the event is
+// published if and only if the change it describes is committed.
+func addOutboxEvent(ctx context.Context, tx *sql.Tx, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal %s event: %w", eventType, err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO event_outbox (event_type, payload)
+		VALUES ($1, $2)`, eventType, payload)
+	if err != nil {
+		return fmt.Errorf("add %s to outbox: %w", eventType, err)
+	}
+	return nil
+}
+
+// ClaimOutboxEvents returns up to limit unclaimed events, oldest first, and
+// claims them for lease. Rows claimed by another relay are skipped, so
+// relays on several instances don't publish the same events.
+func (r *Repository) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error) {
+	now := time.Now().UTC()
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE event_outbox
+		SET claimed_until = $2
+		WHERE id IN (
+			SELECT id FROM event_outbox
+			WHERE claimed_until IS NULL OR claimed_until < $1
+			ORDER BY id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, event_type, payload, created_at`, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim outbox events: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	var pending []domain.OutboxEvent
+	for rows.Next() {
+		var event domain.OutboxEvent
+		if err := rows.Scan(&event.ID, &event.Type, &event.Payload, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan outbox event: %w", err)
+		}
+		pending = append(pending, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate outbox events: %w", err)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].ID < pending[j].ID })
+	return pending, nil
+}
+
+func (r *Repository) DeleteOutboxEvent(ctx context.Context, id int64) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM event_outbox WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("delete outbox event: %w", err)
+	}
+	return nil
+}
+
+// CountOutboxEvents returns the number of events waiting to be published,
+// claimed or not.
+func (r *Repository) CountOutboxEvents(ctx context.Context) (int, error) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM event_outbox`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count outbox events: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+)
+
+// GetDuePollWebhooks, MarkPollWebhookDelivered and RecordPollWebhookFailure
+// make the repository a pollhook.Store.
+
+func addPollWebhook(ctx context.Context, tx *sql.Tx, webhook *domain.PollWebhook) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO poll_webhooks (poll_id, url, secret, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4)`,
+		webhook.PollID, webhook.URL, webhook.Secret, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("insert poll webhook: %w", err)
+	}
+	return nil
+}
+
+// GetDuePollWebhooks returns up to limit webhooks of polls whose results have
+// been snapshotted, that were neither delivered nor given up on and are not
+// waiting out a retry at now.
+func (r *Repository) GetDuePollWebhooks(ctx context.Context, now time.Time, limit int) ([]domain.PollWebhook, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT w.poll_id, w.url, COALESCE(w.secret, ''), w.attempts
+		FROM poll_webhooks w
+		JOIN poll_results pr ON pr.poll_id = w.poll_id
+		WHERE w.delivered_at IS NULL AND w.failed_at IS NULL
+			AND (w.next_attempt_at IS NULL OR w.next_attempt_at <= $1)
+		ORDER BY pr.created_at
+		LIMIT $2`, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get due poll webhooks: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	var webhooks []domain.PollWebhook
+	for rows.Next() {
+		var webhook domain.PollWebhook
+		if err := rows.Scan(&webhook.PollID, &webhook.URL, &webhook.Secret, &webhook.Attempts); err != nil {
+			return nil, fmt.Errorf("scan poll webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate poll webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+func (r *Repository) MarkPollWebhookDelivered(ctx context.Context, pollID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE poll_webhooks
+		SET attempts = attempts + 1, delivered_at = $2, last_error = NULL
+		WHERE poll_id = $1`, pollID, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("mark poll webhook delivered: %w", err)
+	}
+	return nil
+}
+
+// RecordPollWebhookFailure counts a failed delivery. It is tried again at
+// retryAt, or never again when retryAt is nil.
+func (r *Repository) RecordPollWebhookFailure(ctx context.Context, pollID uuid.UUID, reason string, retryAt *time.Time) error {
+	var failedAt *time.Time
+	if retryAt == nil {
+		now := time.Now().UTC()
+		failedAt = &now
+	}
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE poll_webhooks
+		SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3, failed_at = $4
+		WHERE poll_id = $1`, pollID, reason, retryAt, failedAt)
+	if err != nil {
+		return fmt.Errorf("record poll webhook failure: %w", err)
+	}
+	return nil
+}
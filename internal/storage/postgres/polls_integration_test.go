@@ -0,0 +1,947 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/events"
+	"github.com/behzadon/vote/internal/results"
+	"github.com/behzadon/vote/internal/storage/cache"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestIntegrationCreatePoll(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+
+	t.Run("stores the poll and queues its event", func(t *testing.T) {
+		tag := uniqueName("tag")
+		poll := createTestPoll(t, repo, creator, func(p *domain.Poll) {
+			p.Tags = []string{tag}
+			p.AccessCodeHash = "code-hash"
+			p.VoteChange = domain.VoteChangeAllowed
+			p.VoteChangeCooldownSeconds = 60
+			p.AllowedCountries = []string{"DE", "NL"}
+		})
+
+		got, err := repo.GetPollByID(ctx, poll.ID)
+		require.NoError(t, err)
+		assert.Equal(t, poll.Title, got.Title)
+		assert.Equal(t, domain.PollStatusLive, got.Status)
+		assert.Equal(t, domain.ElectorateOpen, got.Electorate)
+		assert.Equal(t, domain.PollKindStandard, got.Kind)
+		assert.Equal(t, domain.VoteChangeAllowed, got.VoteChange)
+		assert.Equal(t, 60, got.VoteChangeCooldownSeconds)
+		assert.Equal(t, []string{"DE", "NL"}, got.AllowedCountries)
+		assert.True(t, got.Protected)
+		assert.Equal(t, []string{tag}, got.Tags)
+		require.Len(t, got.Options, 3)
+		for i, option := range got.Options {
+			assert.Equal(t, poll.Options[i].ID, option.ID)
+			assert.Equal(t, i, option.OptionIndex)
+		}
+
+		hash, err := repo.GetPollAccessCodeHash(ctx, poll.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "code-hash", hash)
+		_, err = repo.GetPollAccessCodeHash(ctx, uuid.New())
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+
+		assert.Equal(t, 1, countOutboxEvents(t, events.EventPollCreated, poll.ID))
+	})
+
+	t.Run("default option follows its option", func(t *testing.T) {
+		poll := createTestPoll(t, repo, creator, func(p *domain.Poll) {
+			p.Options = make([]domain.Option, 3)
+			p.Options[2].Default = true
+		})
+
+		got, err := repo.GetPollByID(ctx, poll.ID)
+		require.NoError(t, err)
+		index, ok := got.DefaultOption()
+		require.True(t, ok)
+		assert.Equal(t, 2, index)
+
+		ids := []uuid.UUID{poll.Options[2].ID, poll.Options[0].ID, poll.Options[1].ID}
+		require.NoError(t, repo.ReorderPollOptions(ctx, poll.ID, ids, time.Now().UTC()))
+		got, err = repo.GetPollByID(ctx, poll.ID)
+		require.NoError(t, err)
+		index, ok = got.DefaultOption()
+		require.True(t, ok)
+		assert.Equal(t, 0, index)
+		assert.Equal(t, poll.Options[2].ID, got.Options[0].ID)
+	})
+
+	t.Run("failure rolls back the poll and its event", func(t *testing.T) {
+		tag := uniqueName("tag")
+		poll := &domain.Poll{ID: uuid.New(), Title: "Rolled back", CreatedBy: &creator.ID}
+		err := repo.CreatePoll(ctx, poll, []string{"a", "b"}, []string{tag, tag})
+		require.Error(t, err)
+
+		_, err = repo.GetPollByID(ctx, poll.ID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		var options int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM poll_options WHERE poll_id = $1`, poll.ID).Scan(&options))
+		assert.Zero(t, options)
+		assert.Zero(t, countOutboxEvents(t, events.EventPollCreated, poll.ID))
+	})
+
+	t.Run("reads go through the cache", func(t *testing.T) {
+		poll := createTestPoll(t, repo, creator, nil)
+		_, err := repo.GetCachedPoll(ctx, poll.ID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+
+		got, err := repo.GetPollByID(ctx, poll.ID)
+		require.NoError(t, err)
+		cached, err := repo.GetCachedPoll(ctx, poll.ID)
+		require.NoError(t, err)
+		assert.Equal(t, got.Title, cached.Title)
+
+		cached.Title = "From the cache"
+		require.NoError(t, repo.SetCachedPoll(ctx, cached))
+		got, err = repo.GetPollByID(ctx, poll.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "From the cache", got.Title)
+	})
+}
+
+func TestIntegrationUpdatePoll(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+
+	t.Run("title and tags", func(t *testing.T) {
+		poll := createTestPoll(t, repo, creator, nil)
+		_, err := repo.GetPollByID(ctx, poll.ID)
+		require.NoError(t, err)
+
+		tag := uniqueName("tag")
+		poll.Title = "Renamed"
+		poll.Tags = []string{tag}
+		poll.UpdatedAt = time.Now().UTC()
+		require.NoError(t, repo.UpdatePoll(ctx, poll))
+
+		got, err := repo.GetPollByID(ctx, poll.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Renamed", got.Title)
+		assert.Equal(t, []string{tag}, got.Tags)
+
+		assert.ErrorIs(t, repo.UpdatePoll(ctx, &domain.Poll{ID: uuid.New(), Title: "x"}), domain.ErrNotFound)
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		kept, added := uniqueName("a"), uniqueName("b")
+		removed := uniqueName("c")
+		poll := createTestPoll(t, repo, creator, func(p *domain.Poll) {
+			p.Tags = []string{kept, removed}
+		})
+		allow := func([]string) error { return nil }
+
+		tags, err := repo.UpdatePollTags(ctx, poll.ID, []string{added, kept}, []string{removed}, time.Now().UTC(), allow)
+		require.NoError(t, err)
+		assert.Equal(t, []string{kept, added}, tags)
+
+		// A rejected change is rolled back.
+		errTooMany := errors.New("too many tags")
+		var seen []string
+		_, err = repo.UpdatePollTags(ctx, poll.ID, []string{uniqueName("d")}, []string{kept}, time.Now().UTC(), func(tags []string) error {
+			seen = tags
+			return errTooMany
+		})
+		assert.ErrorIs(t, err, errTooMany)
+		assert.Len(t, seen, 2)
+		got, err := repo.GetPollByID(ctx, poll.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{kept, added}, got.Tags)
+
+		_, err = repo.UpdatePollTags(ctx, uuid.New(), []string{added}, nil, time.Now().UTC(), allow)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("status", func(t *testing.T) {
+		poll := createTestPoll(t, repo, creator, nil)
+		_, err := repo.GetPollByID(ctx, poll.ID)
+		require.NoError(t, err)
+
+		closedAt := time.Now().UTC().Truncate(time.Microsecond)
+		require.NoError(t, repo.SetPollStatus(ctx, poll.ID, domain.PollStatusClosed, closedAt))
+		got, err := repo.GetPollByID(ctx, poll.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PollStatusClosed, got.Status)
+		require.NotNil(t, got.EndsAt)
+		assert.True(t, closedAt.Equal(*got.EndsAt))
+
+		require.NoError(t, repo.SetPollStatus(ctx, poll.ID, domain.PollStatusDeleted, closedAt))
+		_, err = repo.GetPollByID(ctx, poll.ID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+
+		assert.ErrorIs(t, repo.SetPollStatus(ctx, uuid.New(), domain.PollStatusClosed, closedAt), domain.ErrNotFound)
+	})
+}
+
+func TestIntegrationCollaborators(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+	collaborator := createTestUser(t, repo)
+	poll := createTestPoll(t, repo, creator, nil)
+
+	_, err := repo.GetPollCollaborator(ctx, poll.ID, collaborator.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	add := func(permission domain.CollaboratorPermission) {
+		require.NoError(t, repo.AddPollCollaborator(ctx, &domain.Collaborator{
+			PollID: poll.ID, UserID: collaborator.ID, Permission: permission, InvitedBy: creator.ID, CreatedAt: time.Now().UTC(),
+		}))
+	}
+	add(domain.CollaboratorStats)
+	// Adding an existing collaborator changes their permission.
+	add(domain.CollaboratorEdit)
+
+	got, err := repo.GetPollCollaborator(ctx, poll.ID, collaborator.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CollaboratorEdit, got.Permission)
+	assert.Equal(t, creator.ID, got.InvitedBy)
+
+	all, err := repo.GetPollCollaborators(ctx, poll.ID)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, collaborator.ID, all[0].UserID)
+
+	require.NoError(t, repo.RemovePollCollaborator(ctx, poll.ID, collaborator.ID))
+	assert.ErrorIs(t, repo.RemovePollCollaborator(ctx, poll.ID, collaborator.ID), domain.ErrNotFound)
+	all, err = repo.GetPollCollaborators(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
+
+func TestIntegrationSkipsAndMilestones(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+	poll := createTestPoll(t, repo, creator, nil)
+
+	skip := func(user *domain.User, reason domain.SkipReason) error {
+		return repo.CreateSkip(ctx, &domain.Skip{ID: uuid.New(), PollID: poll.ID, UserID: user.ID, Reason: reason, CreatedAt: time.Now().UTC()})
+	}
+	first, second := createTestUser(t, repo), createTestUser(t, repo)
+	require.NoError(t, skip(first, domain.SkipOffensive))
+	require.NoError(t, skip(second, ""))
+	assert.ErrorIs(t, skip(first, domain.SkipSeenBefore), domain.ErrAlreadySkipped)
+
+	skipped, err := repo.HasSkipped(ctx, poll.ID, first.ID)
+	require.NoError(t, err)
+	assert.True(t, skipped)
+	skipped, err = repo.HasSkipped(ctx, poll.ID, creator.ID)
+	require.NoError(t, err)
+	assert.False(t, skipped)
+
+	count, err := repo.CountSkips(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	reasons, err := repo.CountSkipReasons(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[domain.SkipReason]int{domain.SkipOffensive: 1}, reasons)
+
+	recorded, err := repo.RecordPollMilestone(ctx, poll.ID, 100)
+	require.NoError(t, err)
+	assert.True(t, recorded)
+	recorded, err = repo.RecordPollMilestone(ctx, poll.ID, 100)
+	require.NoError(t, err)
+	assert.False(t, recorded)
+}
+
+func TestIntegrationFeed(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	viewer := createTestUser(t, repo)
+	creator := createTestUser(t, repo)
+	tag, muted, alias := uniqueName("tag"), uniqueName("mute"), uniqueName("alias")
+	require.NoError(t, repo.CreateTagAlias(ctx, &domain.TagAlias{Alias: alias, Tag: tag, CreatedAt: time.Now().UTC()}))
+	require.NoError(t, repo.SetUserPreferences(ctx, viewer.ID, &domain.UserPreferences{MutedTags: []string{muted}}))
+
+	tagged := func(tags ...string) func(*domain.Poll) {
+		return func(p *domain.Poll) { p.Tags = tags }
+	}
+	older := createTestPoll(t, repo, creator, tagged(tag))
+	voted := createTestPoll(t, repo, creator, tagged(tag))
+	castTestVote(t, repo, voted, viewer, 0, time.Now().UTC())
+	skipped := createTestPoll(t, repo, creator, tagged(tag))
+	require.NoError(t, repo.CreateSkip(ctx, &domain.Skip{ID: uuid.New(), PollID: skipped.ID, UserID: viewer.ID, CreatedAt: time.Now().UTC()}))
+	for _, status := range []domain.PollStatus{domain.PollStatusDraft, domain.PollStatusClosed, domain.PollStatusArchived} {
+		status := status
+		createTestPoll(t, repo, creator, func(p *domain.Poll) {
+			p.Tags = []string{tag}
+			p.Status = status
+		})
+	}
+	createTestPoll(t, repo, creator, func(p *domain.Poll) {
+		startsAt := time.Now().UTC().Add(time.Hour)
+		p.Tags = []string{tag}
+		p.Status = domain.PollStatusScheduled
+		p.StartsAt = &startsAt
+	})
+	createTestPoll(t, repo, creator, func(p *domain.Poll) {
+		endsAt := time.Now().UTC().Add(-time.Hour)
+		p.Tags = []string{tag}
+		p.EndsAt = &endsAt
+	})
+	createTestPoll(t, repo, creator, tagged(tag, muted))
+	org := &domain.Organization{ID: uuid.New(), Name: uniqueName("org"), CreatedAt: time.Now().UTC()}
+	require.NoError(t, repo.CreateOrganization(ctx, org, creator.ID))
+	createTestPoll(t, repo, creator, func(p *domain.Poll) {
+		p.Tags = []string{tag}
+		p.Electorate = domain.ElectorateOrganization
+		p.OrganizationID = &org.ID
+	})
+	banned := createTestUser(t, repo)
+	own := createTestPoll(t, repo, banned, tagged(tag))
+	require.NoError(t, repo.SetUserStanding(ctx, banned.ID, domain.StandingShadowBanned))
+	// Polls tagged with an alias of the tag are part of its feed.
+	newer := createTestPoll(t, repo, creator, tagged(alias))
+
+	polls, total, err := repo.GetPollsForFeed(ctx, domain.FeedQuery{Tag: tag, Page: 1, Limit: 10, UserID: viewer.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, polls, 2)
+	assert.Equal(t, newer.ID, polls[0].ID)
+	assert.Equal(t, older.ID, polls[1].ID)
+	assert.Len(t, polls[1].Options, 3)
+	assert.Equal(t, []string{tag}, polls[1].Tags)
+
+	after := &domain.FeedCursor{CreatedAt: polls[0].CreatedAt, ID: polls[0].ID}
+	polls, total, err = repo.GetPollsForFeed(ctx, domain.FeedQuery{Tag: tag, Limit: 10, UserID: viewer.ID, After: after, Total: domain.FeedTotalNone})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	require.Len(t, polls, 1)
+	assert.Equal(t, older.ID, polls[0].ID)
+
+	// The shadow-banned creator still sees their own poll.
+	polls, _, err = repo.GetPollsForFeed(ctx, domain.FeedQuery{Tag: tag, Page: 1, Limit: 10, UserID: banned.ID, Total: domain.FeedTotalNone})
+	require.NoError(t, err)
+	ids := make([]uuid.UUID, len(polls))
+	for i, poll := range polls {
+		ids[i] = poll.ID
+	}
+	assert.Contains(t, ids, own.ID)
+	assert.Contains(t, ids, older.ID)
+}
+
+func TestIntegrationFeedLanguages(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	viewer := createTestUser(t, repo)
+	creator := createTestUser(t, repo)
+	tag := uniqueName("lang")
+	inLanguage := func(language string) *domain.Poll {
+		return createTestPoll(t, repo, creator, func(p *domain.Poll) {
+			p.Tags = []string{tag}
+			p.Language = language
+		})
+	}
+	unknown := inLanguage("")
+	english := inLanguage("en")
+	french := inLanguage("fr")
+
+	feed := func(q domain.FeedQuery) []uuid.UUID {
+		q.Tag, q.Page, q.Limit, q.UserID = tag, 1, 10, viewer.ID
+		polls, _, err := repo.GetPollsForFeed(ctx, q)
+		require.NoError(t, err)
+		ids := make([]uuid.UUID, len(polls))
+		for i, poll := range polls {
+			ids[i] = poll.ID
+		}
+		return ids
+	}
+
+	// Without preferred languages every language is shown.
+	assert.Equal(t, []uuid.UUID{french.ID, english.ID, unknown.ID}, feed(domain.FeedQuery{}))
+	assert.Equal(t, []uuid.UUID{english.ID, unknown.ID}, feed(domain.FeedQuery{Languages: []string{"en"}}))
+
+	require.NoError(t, repo.SetUserPreferences(ctx, viewer.ID, &domain.UserPreferences{Languages: []string{"fr"}}))
+	prefs, err := repo.GetUserPreferences(ctx, viewer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fr"}, prefs.Languages)
+	assert.Equal(t, []uuid.UUID{french.ID, unknown.ID}, feed(domain.FeedQuery{}))
+	assert.Equal(t, []uuid.UUID{english.ID, unknown.ID}, feed(domain.FeedQuery{Languages: []string{"en"}}))
+	assert.Equal(t, []uuid.UUID{french.ID, english.ID, unknown.ID}, feed(domain.FeedQuery{AllLanguages: true}))
+
+	got, err := repo.GetPollByID(ctx, english.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "en", got.Language)
+}
+
+func TestIntegrationFeedCache(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepository(testDB, testRedis, zap.NewNop(), WithFeedCache(cache.NewFeedCache(testRedis, time.Minute)))
+	creator := createTestUser(t, repo)
+	viewer := createTestUser(t, repo)
+	tag := uniqueName("feed")
+	first := createTestPoll(t, repo, creator, func(p *domain.Poll) { p.Tags = []string{tag} })
+	q := domain.FeedQuery{Tag: tag, Page: 1, Limit: 10, UserID: viewer.ID}
+
+	polls, total, err := repo.GetPollsForFeed(ctx, q)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, polls, 1)
+
+	// The cached page is served until the viewer votes or skips.
+	second := createTestPoll(t, repo, creator, func(p *domain.Poll) { p.Tags = []string{tag} })
+	polls, _, err = repo.GetPollsForFeed(ctx, q)
+	require.NoError(t, err)
+	require.Len(t, polls, 1)
+	assert.Equal(t, first.ID, polls[0].ID)
+	assert.Len(t, polls[0].Options, 3)
+
+	vote := castTestVote(t, repo, first, viewer, 0, time.Now().UTC())
+	polls, _, err = repo.GetPollsForFeed(ctx, q)
+	require.NoError(t, err)
+	require.Len(t, polls, 1)
+	assert.Equal(t, second.ID, polls[0].ID)
+
+	require.NoError(t, repo.CreateSkip(ctx, &domain.Skip{ID: uuid.New(), PollID: second.ID, UserID: viewer.ID, CreatedAt: time.Now().UTC()}))
+	polls, _, err = repo.GetPollsForFeed(ctx, q)
+	require.NoError(t, err)
+	assert.Empty(t, polls)
+
+	require.NoError(t, repo.DeleteVote(ctx, vote.ID, viewer.ID))
+	polls, _, err = repo.GetPollsForFeed(ctx, q)
+	require.NoError(t, err)
+	require.Len(t, polls, 1)
+	assert.Equal(t, first.ID, polls[0].ID)
+}
+
+func TestIntegrationSearch(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+	tag, other := uniqueName("tag"), uniqueName("other")
+
+	match := createTestPoll(t, repo, creator, func(p *domain.Poll) {
+		p.Title = "Which quokka is the happiest?"
+		p.Tags = []string{tag, other}
+	})
+	second := createTestPoll(t, repo, creator, func(p *domain.Poll) {
+		p.Title = "Favourite breakfast"
+		p.Tags = []string{tag}
+	})
+	listed := createTestPoll(t, repo, creator, func(p *domain.Poll) {
+		p.Title = "Quokka members only"
+		p.Tags = []string{tag}
+		p.Electorate = domain.ElectorateList
+		p.EligibleEmails = []string{creator.Email}
+	})
+	draft := createTestPoll(t, repo, creator, func(p *domain.Poll) {
+		p.Title = "Quokka draft"
+		p.Tags = []string{tag}
+		p.Status = domain.PollStatusDraft
+	})
+	banned := createTestUser(t, repo)
+	hidden := createTestPoll(t, repo, banned, func(p *domain.Poll) {
+		p.Title = "Quokka from a shadow-banned user"
+		p.Tags = []string{tag}
+	})
+	require.NoError(t, repo.SetUserStanding(ctx, banned.ID, domain.StandingShadowBanned))
+
+	result, err := repo.SearchPolls(ctx, domain.PollSearchQuery{Query: "quokka", Tags: []string{tag}, Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+	require.Len(t, result.Polls, 1)
+	assert.Equal(t, match.ID, result.Polls[0].ID)
+	assert.Len(t, result.Polls[0].Options, 3)
+
+	result, err = repo.SearchPolls(ctx, domain.PollSearchQuery{Query: creator.Username, Tags: []string{tag}, Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Total)
+	assert.Contains(t, result.Facets, domain.TagFacet{Tag: tag, Count: 2})
+	assert.Contains(t, result.Facets, domain.TagFacet{Tag: other, Count: 1})
+
+	result, err = repo.SearchPolls(ctx, domain.PollSearchQuery{Query: uniqueName("nothing"), Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Zero(t, result.Total)
+	assert.Empty(t, result.Polls)
+
+	polls, err := repo.GetPollsByIDs(ctx, []uuid.UUID{second.ID, hidden.ID, uuid.New(), listed.ID, draft.ID, match.ID})
+	require.NoError(t, err)
+	ids := make([]uuid.UUID, len(polls))
+	for i, poll := range polls {
+		ids[i] = poll.ID
+	}
+	assert.Equal(t, []uuid.UUID{second.ID, listed.ID, draft.ID, match.ID}, ids)
+	assert.Equal(t, []string{tag}, polls[0].Tags)
+}
+
+func TestIntegrationTags(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+	follower := createTestUser(t, repo)
+	from, to, chained := uniqueName("from"), uniqueName("to"), uniqueName("old")
+
+	// Aliasing the alias target repoints existing aliases to the new tag.
+	require.NoError(t, repo.CreateTagAlias(ctx, &domain.TagAlias{Alias: chained, Tag: from, CreatedAt: time.Now().UTC()}))
+	resolved, err := repo.ResolveTags(ctx, []string{chained, to})
+	require.NoError(t, err)
+	assert.Equal(t, []string{from, to}, resolved)
+
+	onlyFrom := createTestPoll(t, repo, creator, func(p *domain.Poll) { p.Tags = []string{from} })
+	both := createTestPoll(t, repo, creator, func(p *domain.Poll) { p.Tags = []string{from, to} })
+	require.NoError(t, repo.SetUserPreferences(ctx, follower.ID, &domain.UserPreferences{FollowedTags: []string{from}}))
+
+	merged, err := repo.MergeTags(ctx, from, to)
+	require.NoError(t, err)
+	assert.Equal(t, 2, merged)
+
+	for _, poll := range []*domain.Poll{onlyFrom, both} {
+		got, err := repo.GetPollByID(ctx, poll.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{to}, got.Tags)
+	}
+	prefs, err := repo.GetUserPreferences(ctx, follower.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{to}, prefs.FollowedTags)
+
+	resolved, err = repo.ResolveTags(ctx, []string{from, chained})
+	require.NoError(t, err)
+	assert.Equal(t, []string{to, to}, resolved)
+
+	aliases, err := repo.GetTagAliases(ctx)
+	require.NoError(t, err)
+	var found []string
+	for _, alias := range aliases {
+		if alias.Tag == to {
+			found = append(found, alias.Alias)
+		}
+	}
+	assert.ElementsMatch(t, []string{from, chained}, found)
+}
+
+func TestIntegrationTagRules(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	admin := createTestUser(t, repo)
+	tag, from, other := uniqueName("rule"), uniqueName("from"), uniqueName("other")
+
+	rule := &domain.TagRule{Tag: tag, Restricted: true, UpdatedBy: &admin.ID, UpdatedAt: time.Now().UTC()}
+	require.NoError(t, repo.SetTagRule(ctx, rule))
+	rule.AutoModerated = true
+	require.NoError(t, repo.SetTagRule(ctx, rule))
+
+	rules, err := repo.GetTagRulesFor(ctx, []string{tag, other})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.True(t, rules[0].Restricted)
+	assert.True(t, rules[0].AutoModerated)
+	assert.Equal(t, admin.ID, *rules[0].UpdatedBy)
+
+	all, err := repo.GetTagRules(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, all, rules[0])
+	assert.NotContains(t, tagsOf(all), other)
+
+	// A merged tag's rule moves to the tag it was merged into.
+	require.NoError(t, repo.SetTagRule(ctx, &domain.TagRule{Tag: from, AutoModerated: true, UpdatedAt: time.Now().UTC()}))
+	_, err = repo.MergeTags(ctx, from, other)
+	require.NoError(t, err)
+	rules, err = repo.GetTagRulesFor(ctx, []string{from, other})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, other, rules[0].Tag)
+
+	require.NoError(t, repo.DeleteTagRule(ctx, tag))
+	assert.ErrorIs(t, repo.DeleteTagRule(ctx, tag), domain.ErrNotFound)
+	require.NoError(t, repo.DeleteTagRule(ctx, other))
+	all, err = repo.GetTagRules(ctx)
+	require.NoError(t, err)
+	assert.NotContains(t, tagsOf(all), tag)
+}
+
+func tagsOf(rules []domain.TagRule) []string {
+	tags := make([]string, len(rules))
+	for i, rule := range rules {
+		tags[i] = rule.Tag
+	}
+	return tags
+}
+
+func TestIntegrationReorderPollOptions(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+	poll := createTestPoll(t, repo, creator, nil)
+	a, b, c := poll.Options[0].ID, poll.Options[1].ID, poll.Options[2].ID
+
+	require.NoError(t, repo.ReorderPollOptions(ctx, poll.ID, []uuid.UUID{c, a, b}, time.Now().UTC()))
+	got, err := repo.GetPollByID(ctx, poll.ID)
+	require.NoError(t, err)
+	require.Len(t, got.Options, 3)
+	for i, want := range []uuid.UUID{c, a, b} {
+		assert.Equal(t, want, got.Options[i].ID)
+		assert.Equal(t, i, got.Options[i].OptionIndex)
+	}
+
+	// A list that leaves an option out changes nothing.
+	err = repo.ReorderPollOptions(ctx, poll.ID, []uuid.UUID{a, b}, time.Now().UTC())
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	got, err = repo.GetPollByID(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Equal(t, c, got.Options[0].ID)
+
+	err = repo.ReorderPollOptions(ctx, uuid.New(), []uuid.UUID{a}, time.Now().UTC())
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestIntegrationMedia(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+	poll := createTestPoll(t, repo, creator, nil)
+	_, err := repo.GetPollByID(ctx, poll.ID)
+	require.NoError(t, err)
+
+	first, second := "options/"+uniqueName(""), "options/"+uniqueName("")
+	previous, err := repo.SetOptionImage(ctx, poll.ID, 1, first)
+	require.NoError(t, err)
+	assert.Empty(t, previous)
+	previous, err = repo.SetOptionImage(ctx, poll.ID, 1, second)
+	require.NoError(t, err)
+	assert.Equal(t, first, previous)
+	_, err = repo.SetOptionImage(ctx, poll.ID, 7, first)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	altText, emoji := "A cat", "🐱"
+	require.NoError(t, repo.UpdateOptionMetadata(ctx, poll.ID, 1, &altText, &emoji))
+	empty := ""
+	require.NoError(t, repo.UpdateOptionMetadata(ctx, poll.ID, 1, nil, &empty))
+	assert.ErrorIs(t, repo.UpdateOptionMetadata(ctx, poll.ID, 7, &altText, nil), domain.ErrNotFound)
+
+	got, err := repo.GetPollByID(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Equal(t, second, got.Options[1].ImageKey)
+	assert.Equal(t, "A cat", got.Options[1].AltText)
+	assert.Empty(t, got.Options[1].Emoji)
+
+	_, err = repo.GetPollPreviewCard(ctx, poll.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	card := &domain.PollPreviewCard{PollID: poll.ID, ImageKey: "cards/" + uniqueName(""), Counts: []int{1, 0, 2}, OptionCount: 3, RenderedAt: time.Now().UTC().Truncate(time.Microsecond)}
+	require.NoError(t, repo.SetPollPreviewCard(ctx, card))
+	card.Counts = []int{2, 0, 2}
+	require.NoError(t, repo.SetPollPreviewCard(ctx, card))
+	gotCard, err := repo.GetPollPreviewCard(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Equal(t, card.ImageKey, gotCard.ImageKey)
+	assert.Equal(t, []int{2, 0, 2}, gotCard.Counts)
+	assert.True(t, card.RenderedAt.Equal(gotCard.RenderedAt))
+
+	orphan := "options/" + uniqueName("")
+	orphans, err := repo.UnreferencedMediaKeys(ctx, []string{first, second, card.ImageKey, orphan})
+	require.NoError(t, err)
+	assert.Equal(t, []string{first, orphan}, orphans)
+}
+
+func TestIntegrationModerationFlags(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	author := createTestUser(t, repo)
+	moderator := createTestUser(t, repo)
+	poll := createTestPoll(t, repo, author, nil)
+
+	flag := &domain.ModerationFlag{
+		ID:        uuid.New(),
+		Kind:      domain.ContentPollTitle,
+		Content:   poll.Title,
+		PollID:    &poll.ID,
+		AuthorID:  &author.ID,
+		Score:     0.9,
+		Reasons:   []string{"spam_keywords"},
+		Status:    domain.FlagOpen,
+		CreatedAt: time.Now().UTC(),
+	}
+	require.NoError(t, repo.CreateModerationFlag(ctx, flag))
+
+	flags, _, err := repo.GetModerationFlags(ctx, domain.FlagOpen, 1, domain.MaxPageSize)
+	require.NoError(t, err)
+	var open []uuid.UUID
+	for _, f := range flags {
+		open = append(open, f.ID)
+	}
+	assert.Contains(t, open, flag.ID)
+
+	resolvedAt := time.Now().UTC()
+	resolved, err := repo.ResolveModerationFlag(ctx, flag.ID, domain.FlagUpheld, moderator.ID, resolvedAt)
+	require.NoError(t, err)
+	assert.Equal(t, domain.FlagUpheld, resolved.Status)
+	assert.Equal(t, []string{"spam_keywords"}, resolved.Reasons)
+	require.NotNil(t, resolved.ResolvedBy)
+	assert.Equal(t, moderator.ID, *resolved.ResolvedBy)
+	assert.Equal(t, poll.ID, *resolved.PollID)
+
+	_, err = repo.ResolveModerationFlag(ctx, flag.ID, domain.FlagDismissed, moderator.ID, resolvedAt)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repo.ResolveModerationFlag(ctx, uuid.New(), domain.FlagDismissed, moderator.ID, resolvedAt)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, upheld, err := repo.GetModerationFlags(ctx, domain.FlagUpheld, 1, 1)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, upheld, 1)
+
+	_, err = repo.GetPollReviewFlag(ctx, poll.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	review := &domain.ModerationFlag{
+		ID:        uuid.New(),
+		Kind:      domain.ContentPollReview,
+		Content:   poll.Title,
+		PollID:    &poll.ID,
+		Reasons:   []string{"auto_moderated_tag:news"},
+		Status:    domain.FlagOpen,
+		CreatedAt: time.Now().UTC(),
+	}
+	require.NoError(t, repo.CreateModerationFlag(ctx, review))
+	got, err := repo.GetPollReviewFlag(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Equal(t, review.ID, got.ID)
+}
+
+func TestIntegrationElections(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+	startsAt := time.Now().UTC().Add(-2 * time.Hour)
+	endsAt := time.Now().UTC().Add(-time.Hour)
+	election := createTestPoll(t, repo, creator, func(p *domain.Poll) {
+		p.Kind = domain.PollKindElection
+		p.StartsAt = &startsAt
+		p.EndsAt = &endsAt
+	})
+
+	ids, err := repo.GetElectionsToCertify(ctx, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Contains(t, ids, election.ID)
+
+	_, err = repo.GetElectionCertification(ctx, election.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	cert := &domain.ElectionCertification{
+		PollID:      election.ID,
+		Tally:       json.RawMessage(`{"pollId":"` + election.ID.String() + `","ballotsCast":0}`),
+		Algorithm:   "ed25519",
+		Signature:   "first",
+		CertifiedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	require.NoError(t, repo.SaveElectionCertification(ctx, cert))
+	// The first certification stored wins.
+	require.NoError(t, repo.SaveElectionCertification(ctx, &domain.ElectionCertification{
+		PollID: election.ID, Tally: json.RawMessage(`{}`), Algorithm: "ed25519", Signature: "second", CertifiedAt: time.Now().UTC(),
+	}))
+
+	got, err := repo.GetElectionCertification(ctx, election.ID)
+	require.NoError(t, err)
+	assert.Equal(t, string(cert.Tally), string(got.Tally))
+	assert.Equal(t, "first", got.Signature)
+	assert.True(t, cert.CertifiedAt.Equal(got.CertifiedAt))
+
+	ids, err = repo.GetElectionsToCertify(ctx, time.Now().UTC())
+	require.NoError(t, err)
+	assert.NotContains(t, ids, election.ID)
+}
+
+func TestIntegrationResultSnapshots(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+	poll := createTestPoll(t, repo, creator, nil)
+	draft := createTestPoll(t, repo, creator, func(p *domain.Poll) { p.Status = domain.PollStatusDraft })
+
+	first := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	voters := []*domain.User{createTestUser(t, repo), createTestUser(t, repo), createTestUser(t, repo)}
+	castTestVote(t, repo, poll, voters[0], 0, first)
+	castTestVote(t, repo, poll, voters[1], 0, first.Add(time.Hour))
+	castTestVote(t, repo, poll, voters[2], 1, first.Add(2*time.Hour))
+
+	last, err := repo.GetLastVoteTimes(ctx, poll.ID)
+	require.NoError(t, err)
+	require.Len(t, last, 2)
+	assert.True(t, first.Add(time.Hour).Equal(last["a"]))
+	assert.True(t, first.Add(2*time.Hour).Equal(last["b"]))
+
+	closedAt := time.Now().UTC().Truncate(time.Microsecond)
+	require.NoError(t, repo.SetPollStatus(ctx, poll.ID, domain.PollStatusClosed, closedAt))
+	ids, err := repo.GetPollsToSnapshot(ctx, closedAt)
+	require.NoError(t, err)
+	assert.Contains(t, ids, poll.ID)
+	assert.NotContains(t, ids, draft.ID)
+
+	_, err = repo.GetPollResultSnapshot(ctx, poll.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	stats, err := repo.GetPollStats(ctx, poll.ID)
+	require.NoError(t, err)
+	snapshot := domain.NewPollResultSnapshot(stats, closedAt, closedAt)
+	require.NoError(t, repo.SavePollResultSnapshot(ctx, snapshot))
+	// Later snapshots don't replace the first one.
+	require.NoError(t, repo.SavePollResultSnapshot(ctx, &domain.PollResultSnapshot{
+		PollID: poll.ID, Options: []domain.OptionResult{}, Tied: []string{"a", "b"}, TieBreak: domain.TieBreakReported, ClosedAt: closedAt, CreatedAt: closedAt,
+	}))
+
+	got, err := repo.GetPollResultSnapshot(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, got.Total)
+	assert.Equal(t, "a", got.Winner)
+	assert.Empty(t, got.Tied)
+	assert.Equal(t, snapshot.Options, got.Options)
+	assert.Nil(t, got.Turnout)
+
+	ids, err = repo.GetPollsToSnapshot(ctx, closedAt)
+	require.NoError(t, err)
+	assert.NotContains(t, ids, poll.ID)
+}
+
+func TestIntegrationResultCertificates(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+	poll := createTestPoll(t, repo, creator, nil)
+	castTestVote(t, repo, poll, createTestUser(t, repo), 0, time.Now().UTC())
+
+	closedAt := time.Now().UTC().Truncate(time.Microsecond)
+	require.NoError(t, repo.SetPollStatus(ctx, poll.ID, domain.PollStatusClosed, closedAt))
+	ids, err := repo.GetPollsToCertify(ctx)
+	require.NoError(t, err)
+	assert.NotContains(t, ids, poll.ID)
+
+	stats, err := repo.GetPollStats(ctx, poll.ID)
+	require.NoError(t, err)
+	require.NoError(t, repo.SavePollResultSnapshot(ctx, domain.NewPollResultSnapshot(stats, closedAt, closedAt)))
+	ids, err = repo.GetPollsToCertify(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, ids, poll.ID)
+
+	_, err = repo.GetResultCertificate(ctx, poll.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	certifier := results.NewCertifier(repo, "results-key", zap.NewNop())
+	certified, err := certifier.CertifyClosed(ctx)
+	require.NoError(t, err)
+	assert.Positive(t, certified)
+
+	cert, err := repo.GetResultCertificate(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.True(t, results.VerifyCertificate(cert))
+	assert.Equal(t, 1, cert.Results.Total)
+	assert.True(t, closedAt.Equal(cert.Results.ClosedAt))
+
+	// Certificates are issued once; a later one doesn't replace the first.
+	loaded, err := repo.GetPollByID(ctx, poll.ID)
+	require.NoError(t, err)
+	snapshot, err := repo.GetPollResultSnapshot(ctx, poll.ID)
+	require.NoError(t, err)
+	again, err := results.NewCertifier(repo, "other-key", zap.NewNop()).Certify(ctx, loaded, snapshot)
+	require.NoError(t, err)
+	assert.Equal(t, cert.Signature, again.Signature)
+	assert.Equal(t, cert.Payload, again.Payload)
+
+	ids, err = repo.GetPollsToCertify(ctx)
+	require.NoError(t, err)
+	assert.NotContains(t, ids, poll.ID)
+}
+
+func TestIntegrationSaveResultCertificate(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+	poll := createTestPoll(t, repo, creator, nil)
+
+	certified := domain.CertifiedResults{PollID: poll.ID, Title: poll.Title, Options: []string{"a", "b", "c"}, Total: 0}
+	payload, err := json.Marshal(certified)
+	require.NoError(t, err)
+	cert := &domain.ResultCertificate{
+		PollID:    poll.ID,
+		Payload:   base64.StdEncoding.EncodeToString(payload),
+		Algorithm: "ed25519",
+		PublicKey: "public-key",
+		Signature: "signature",
+		IssuedAt:  time.Now().UTC().Truncate(time.Microsecond),
+	}
+	require.NoError(t, repo.SaveResultCertificate(ctx, cert))
+
+	got, err := repo.GetResultCertificate(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Equal(t, cert.Payload, got.Payload)
+	assert.Equal(t, certified.Title, got.Results.Title)
+	assert.Equal(t, certified.Options, got.Results.Options)
+	assert.Equal(t, "ed25519", got.Algorithm)
+	assert.Equal(t, "public-key", got.PublicKey)
+	assert.Equal(t, "signature", got.Signature)
+	assert.True(t, cert.IssuedAt.Equal(got.IssuedAt))
+
+	// The first certificate of a poll is kept.
+	second := *cert
+	second.Signature = "other-signature"
+	require.NoError(t, repo.SaveResultCertificate(ctx, &second))
+	got, err = repo.GetResultCertificate(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "signature", got.Signature)
+
+	invalid := *cert
+	invalid.PollID = createTestPoll(t, repo, creator, nil).ID
+	invalid.Payload = "not base64!"
+	assert.Error(t, repo.SaveResultCertificate(ctx, &invalid))
+	_, err = repo.GetResultCertificate(ctx, invalid.PollID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestIntegrationPollWebhooks(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+	poll := createTestPoll(t, repo, creator, func(p *domain.Poll) {
+		p.Webhook = &domain.PollWebhook{URL: "https://example.com/hook", Secret: "s3cret"}
+	})
+	createTestPoll(t, repo, creator, nil)
+
+	due := func(now time.Time) []domain.PollWebhook {
+		t.Helper()
+		webhooks, err := repo.GetDuePollWebhooks(ctx, now, 1000)
+		require.NoError(t, err)
+		var own []domain.PollWebhook
+		for _, webhook := range webhooks {
+			if webhook.PollID == poll.ID {
+				own = append(own, webhook)
+			}
+		}
+		return own
+	}
+
+	// Nothing is due before the results are snapshotted.
+	now := time.Now().UTC()
+	assert.Empty(t, due(now))
+	stats, err := repo.GetPollStats(ctx, poll.ID)
+	require.NoError(t, err)
+	require.NoError(t, repo.SavePollResultSnapshot(ctx, domain.NewPollResultSnapshot(stats, now, now)))
+	assert.Equal(t, []domain.PollWebhook{{PollID: poll.ID, URL: "https://example.com/hook", Secret: "s3cret"}}, due(now))
+
+	retryAt := now.Add(time.Minute)
+	require.NoError(t, repo.RecordPollWebhookFailure(ctx, poll.ID, "status 502", &retryAt))
+	assert.Empty(t, due(now))
+	require.Len(t, due(retryAt), 1)
+	assert.Equal(t, 1, due(retryAt)[0].Attempts)
+
+	require.NoError(t, repo.MarkPollWebhookDelivered(ctx, poll.ID))
+	assert.Empty(t, due(retryAt))
+
+	require.NoError(t, repo.PurgePoll(ctx, poll.ID))
+	var left int
+	require.NoError(t, testDB.QueryRowContext(ctx, `SELECT COUNT(*) FROM poll_webhooks WHERE poll_id = $1`, poll.ID).Scan(&left))
+	assert.Zero(t, left)
+}
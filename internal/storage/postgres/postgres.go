@@ -6,9 +6,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/events"
+	"github.com/behzadon/vote/internal/storage/cache"
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -16,17 +19,25 @@ import (
 )
 
 type Repository struct {
-	db     *sql.DB
-	redis  *redis.Client
-	logger *zap.Logger
+	db          *sql.DB
+	redis       *redis.Client
+	recentVotes *cache.RedisCache
+	local       *cache.LocalCache
+	feed        *cache.FeedCache
+	logger      *zap.Logger
 }
 
-func NewRepository(db *sql.DB, redis *redis.Client, logger *zap.Logger) *Repository {
-	return &Repository{
-		db:     db,
-		redis:  redis,
-		logger: logger,
+func NewRepository(db *sql.DB, redis *redis.Client, logger *zap.Logger, opts ...Option) *Repository {
+	r := &Repository{
+		db:          db,
+		redis:       redis,
+		recentVotes: cache.NewRedisCache(redis),
+		logger:      logger,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 func (r *Repository) CreateUser(ctx context.Context, user *domain.User) error {
@@ -50,10 +61,11 @@ func (r *Repository) CreateUser(ctx context.Context, user *domain.User) error {
 
 func (r *Repository) GetUserByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
 	var user domain.User
-	query := `SELECT id, username, email, password, created_at, updated_at FROM users WHERE id = $1`
+	var avatarKey sql.NullString
+	query := `SELECT id, username, email, password, created_at, updated_at, avatar_key, standing, role, email_verified FROM users WHERE id = $1`
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&user.ID, &user.Username, &user.Email, &user.Password,
-		&user.CreatedAt, &user.UpdatedAt,
+		&user.CreatedAt, &user.UpdatedAt, &avatarKey, &user.Standing, &user.Role, &user.EmailVerified,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, domain.ErrNotFound
@@ -61,15 +73,17 @@ func (r *Repository) GetUserByID(ctx context.Context, id uuid.UUID) (*domain.Use
 	if err != nil {
 		return nil, fmt.Errorf("get user by id: %w", err)
 	}
+	user.AvatarKey = avatarKey.String
 	return &user, nil
 }
 
 func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var user domain.User
-	query := `SELECT id, username, email, password, created_at, updated_at FROM users WHERE email = $1`
+	var avatarKey sql.NullString
+	query := `SELECT id, username, email, password, created_at, updated_at, avatar_key, standing, role, email_verified FROM users WHERE email = $1`
 	err := r.db.QueryRowContext(ctx, query, email).Scan(
 		&user.ID, &user.Username, &user.Email, &user.Password,
-		&user.CreatedAt, &user.UpdatedAt,
+		&user.CreatedAt, &user.UpdatedAt, &avatarKey, &user.Standing, &user.Role, &user.EmailVerified,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, domain.ErrNotFound
@@ -77,13 +91,16 @@ func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*domain.
 	if err != nil {
 		return nil, fmt.Errorf("get user by email: %w", err)
 	}
+	user.AvatarKey = avatarKey.String
 	return &user, nil
 }
 
 func (r *Repository) UpdateUser(ctx context.Context, user *domain.User) error {
+	// A changed address has to be verified again.
 	query := `
 		UPDATE users 
-		SET username = $1, email = $2, password = $3, updated_at = $4
+		SET username = $1, email = $2, password = $3, updated_at = $4,
+			email_verified = email_verified AND email = $2
 		WHERE id = $5
 	`
 	_, err := r.db.ExecContext(ctx, query,
@@ -100,12 +117,44 @@ func (r *Repository) UpdateUser(ctx context.Context, user *domain.User) error {
 	return nil
 }
 
+// DeleteUser deletes the user along with their votes, taking the votes out
+// of the option counts first.
 func (r *Repository) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM users WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	var standing domain.UserStanding
+	err = tx.QueryRowContext(ctx, `SELECT standing FROM users WHERE id = $1 FOR UPDATE`, id).Scan(&standing)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
 	if err != nil {
+		return fmt.Errorf("lock user: %w", err)
+	}
+	if standing != domain.StandingShadowBanned {
+		if err = adjustUserOptionCounts(ctx, tx, id, -1); err != nil {
+			return err
+		}
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id); err != nil {
 		return fmt.Errorf("delete user: %w", err)
 	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
 	return nil
 }
 
@@ -125,36 +174,66 @@ func (r *Repository) CreatePoll(ctx context.Context, poll *domain.Poll, options
 	}()
 
 	query := `
-		INSERT INTO polls (id, title, created_at, updated_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO polls (id, title, access_code_hash, status, created_by, organization_id, electorate, kind, starts_at, ends_at, public_results, results_visibility, vote_change, vote_change_cooldown, organization_votes, anonymous, allowed_countries, language, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
 		RETURNING id`
+	accessCodeHash := sql.NullString{String: poll.AccessCodeHash, Valid: poll.AccessCodeHash != ""}
+	var createdBy, organizationID uuid.NullUUID
+	if poll.CreatedBy != nil {
+		createdBy = uuid.NullUUID{UUID: *poll.CreatedBy, Valid: true}
+	}
+	if poll.OrganizationID != nil {
+		organizationID = uuid.NullUUID{UUID: *poll.OrganizationID, Valid: true}
+	}
+	if poll.Electorate == "" {
+		poll.Electorate = domain.ElectorateOpen
+	}
+	if poll.Kind == "" {
+		poll.Kind = domain.PollKindStandard
+	}
+	if poll.ResultsVisibility == "" {
+		poll.ResultsVisibility = domain.ResultsAlways
+	}
+	if poll.VoteChange == "" {
+		poll.VoteChange = domain.VoteChangeUntilClose
+	}
+	if poll.Status == "" {
+		poll.Status = poll.PublishedStatus(time.Now().UTC())
+	}
 	err = tx.QueryRowContext(ctx, query,
-		poll.ID, poll.Title, time.Now().UTC(), time.Now().UTC(),
+		poll.ID, poll.Title, accessCodeHash, poll.Status, createdBy, organizationID, poll.Electorate,
+		poll.Kind, poll.StartsAt, poll.EndsAt, poll.PublicResults, poll.ResultsVisibility, poll.VoteChange, poll.VoteChangeCooldownSeconds, poll.OrganizationVotes, poll.Anonymous, pq.Array(poll.AllowedCountries),
+		poll.Language, time.Now().UTC(), time.Now().UTC(),
 	).Scan(&poll.ID)
 	if err != nil {
 		return fmt.Errorf("insert poll: %w", err)
 	}
 
+	// Options already on the poll are kept so that their IDs and metadata
+	// survive; otherwise they are built from the option texts.
+	if len(poll.Options) != len(options) {
+		poll.Options = make([]domain.Option, len(options))
+	}
 	optionsQuery := `
-		INSERT INTO poll_options (id, poll_id, option_text, option_index, created_at)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id`
+		INSERT INTO poll_options (id, poll_id, option_text, option_index, alt_text, emoji, is_default, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8)`
 	for i, optionText := range options {
-		optionID := uuid.New()
-		var id uuid.UUID
-		err = tx.QueryRowContext(ctx, optionsQuery,
-			optionID, poll.ID, optionText, i, time.Now().UTC(),
-		).Scan(&id)
+		option := &poll.Options[i]
+		if option.ID == uuid.Nil {
+			option.ID = uuid.New()
+		}
+		if option.CreatedAt.IsZero() {
+			option.CreatedAt = time.Now().UTC()
+		}
+		option.PollID = poll.ID
+		option.OptionText = optionText
+		option.OptionIndex = i
+		_, err = tx.ExecContext(ctx, optionsQuery,
+			option.ID, poll.ID, optionText, i, option.AltText, option.Emoji, option.Default, option.CreatedAt,
+		)
 		if err != nil {
 			return fmt.Errorf("insert option %d: %w", i, err)
 		}
-		poll.Options = append(poll.Options, domain.Option{
-			ID:          id,
-			PollID:      poll.ID,
-			OptionText:  optionText,
-			OptionIndex: i,
-			CreatedAt:   time.Now().UTC(),
-		})
 	}
 
 	if len(tags) > 0 {
@@ -170,6 +249,29 @@ func (r *Repository) CreatePoll(ctx context.Context, poll *domain.Poll, options
 		poll.Tags = tags
 	}
 
+	if len(poll.EligibleEmails) > 0 {
+		electorateQuery := `
+			INSERT INTO poll_electorate (poll_id, email)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING`
+		for _, email := range poll.EligibleEmails {
+			if _, err = tx.ExecContext(ctx, electorateQuery, poll.ID, email); err != nil {
+				return fmt.Errorf("insert eligible email: %w", err)
+			}
+		}
+	}
+
+	if poll.Webhook != nil {
+		poll.Webhook.PollID = poll.ID
+		if err = addPollWebhook(ctx, tx, poll.Webhook); err != nil {
+			return err
+		}
+	}
+
+	if err = addOutboxEvent(ctx, tx, events.EventPollCreated, poll); err != nil {
+		return err
+	}
+
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("commit transaction: %w", err)
 	}
@@ -177,9 +279,42 @@ func (r *Repository) CreatePoll(ctx context.Context, poll *domain.Poll, options
 	return nil
 }
 
+const pollColumns = `p.id, p.title, p.access_code_hash IS NOT NULL, p.status, p.created_by, p.organization_id, p.electorate,
+		p.kind, p.starts_at, p.ends_at, p.public_results, p.results_visibility, p.vote_change, p.vote_change_cooldown, p.organization_votes, p.anonymous, p.allowed_countries, p.language, p.created_at, p.updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPoll(row rowScanner, poll *domain.Poll) error {
+	var createdBy, organizationID uuid.NullUUID
+	var startsAt, endsAt sql.NullTime
+	err := row.Scan(
+		&poll.ID, &poll.Title, &poll.Protected, &poll.Status, &createdBy, &organizationID, &poll.Electorate,
+		&poll.Kind, &startsAt, &endsAt, &poll.PublicResults, &poll.ResultsVisibility, &poll.VoteChange, &poll.VoteChangeCooldownSeconds, &poll.OrganizationVotes, &poll.Anonymous, pq.Array(&poll.AllowedCountries),
+		&poll.Language, &poll.CreatedAt, &poll.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if createdBy.Valid {
+		poll.CreatedBy = &createdBy.UUID
+	}
+	if organizationID.Valid {
+		poll.OrganizationID = &organizationID.UUID
+	}
+	if startsAt.Valid {
+		poll.StartsAt = &startsAt.Time
+	}
+	if endsAt.Valid {
+		poll.EndsAt = &endsAt.Time
+	}
+	return nil
+}
+
 func (r *Repository) GetCachedPoll(ctx context.Context, id uuid.UUID) (*domain.Poll, error) {
-	key := "poll:" + id.String()
-	data, err := r.redis.Get(ctx, key).Bytes()
+	key := cache.PollKey(id)
+	data, err := r.getCached(ctx, key)
 	if errors.Is(err, redis.Nil) {
 		return nil, domain.ErrNotFound
 	}
@@ -194,12 +329,12 @@ func (r *Repository) GetCachedPoll(ctx context.Context, id uuid.UUID) (*domain.P
 }
 
 func (r *Repository) SetCachedPoll(ctx context.Context, poll *domain.Poll) error {
-	key := "poll:" + poll.ID.String()
+	key := cache.PollKey(poll.ID)
 	data, err := json.Marshal(poll)
 	if err != nil {
 		return fmt.Errorf("marshal poll: %w", err)
 	}
-	if err := r.redis.Set(ctx, key, data, 24*time.Hour).Err(); err != nil {
+	if err := r.setCached(ctx, key, data, 24*time.Hour); err != nil {
 		return fmt.Errorf("cache poll: %w", err)
 	}
 	return nil
@@ -211,13 +346,11 @@ func (r *Repository) GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Pol
 		return poll, nil
 	}
 	query := `
-		SELECT p.id, p.title, p.created_at, p.updated_at
+		SELECT ` + pollColumns + `
 		FROM polls p
-		WHERE p.id = $1`
+		WHERE p.id = $1 AND p.status <> 'deleted'`
 	poll = &domain.Poll{ID: id}
-	err = r.db.QueryRowContext(ctx, query, id).Scan(
-		&poll.ID, &poll.Title, &poll.CreatedAt, &poll.UpdatedAt,
-	)
+	err = scanPoll(r.db.QueryRowContext(ctx, query, id), poll)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, domain.ErrNotFound
 	}
@@ -226,10 +359,10 @@ func (r *Repository) GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Pol
 	}
 
 	optionsQuery := `
-		SELECT id, option_text, created_at
+		SELECT id, option_text, option_index, created_at, image_key, alt_text, emoji, is_default
 		FROM poll_options
 		WHERE poll_id = $1
-		ORDER BY created_at`
+		ORDER BY option_index`
 	rows, err := r.db.QueryContext(ctx, optionsQuery, id)
 	if err != nil {
 		return nil, fmt.Errorf("get options: %w", err)
@@ -238,11 +371,15 @@ func (r *Repository) GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Pol
 
 	for rows.Next() {
 		var option domain.Option
-		err = rows.Scan(&option.ID, &option.OptionText, &option.CreatedAt)
+		var imageKey, altText, emoji sql.NullString
+		err = rows.Scan(&option.ID, &option.OptionText, &option.OptionIndex, &option.CreatedAt, &imageKey, &altText, &emoji, &option.Default)
 		if err != nil {
 			return nil, fmt.Errorf("scan option: %w", err)
 		}
 		option.PollID = id
+		option.ImageKey = imageKey.String
+		option.AltText = altText.String
+		option.Emoji = emoji.String
 		poll.Options = append(poll.Options, option)
 	}
 	if err = rows.Err(); err != nil {
@@ -277,43 +414,129 @@ func (r *Repository) GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Pol
 	return poll, nil
 }
 
-func (r *Repository) GetPollsForFeed(ctx context.Context, userID uuid.UUID, tag string, page, limit int) ([]domain.Poll, int, error) {
+// GetPollAccessCodeHash always reads from the database; the hash is never
+// part of the cached poll.
+func (r *Repository) GetPollAccessCodeHash(ctx context.Context, pollID uuid.UUID) (string, error) {
+	query := `SELECT access_code_hash FROM polls WHERE id = $1`
+	var hash sql.NullString
+	err := r.db.QueryRowContext(ctx, query, pollID).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", domain.ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("get poll access code: %w", err)
+	}
+	return hash.String, nil
+}
+
+func (r *Repository) GetPollsForFeed(ctx context.Context, q domain.FeedQuery) ([]domain.Poll, int, error) {
+	if r.feed == nil {
+		return r.queryFeed(ctx, q)
+	}
+
+	page, err := r.feed.Get(ctx, q)
+	if err != nil {
+		r.logger.Warn("Failed to read cached feed", zap.Error(err))
+	}
+	if page != nil {
+		return page.Polls, page.Total, nil
+	}
+
+	polls, total, err := r.queryFeed(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := r.feed.Set(ctx, q, polls, total); err != nil {
+		r.logger.Warn("Failed to cache feed", zap.Error(err))
+	}
+	return polls, total, nil
+}
+
+// livePollCondition matches the polls open for voting now, the way
+// Poll.IsOpen decides it: scheduled polls count from startsAt on, since their
+// stored status isn't advanced when it passes.
+const livePollCondition = `p.status IN ('scheduled', 'live')
+		AND (p.starts_at IS NULL OR p.starts_at <= NOW())
+		AND (p.ends_at IS NULL OR p.ends_at > NOW())`
+
+func (r *Repository) queryFeed(ctx context.Context, q domain.FeedQuery) ([]domain.Poll, int, error) {
 	baseQuery := `
 		FROM polls p
-		WHERE NOT EXISTS (
-			SELECT 1 FROM votes v WHERE v.poll_id = p.id AND v.user_id = $1
+		WHERE ` + livePollCondition + `
+		AND NOT EXISTS (
+			SELECT 1 FROM votes v WHERE v.user_id = $1 AND v.poll_id = p.id
 		)
 		AND NOT EXISTS (
-			SELECT 1 FROM skips s WHERE s.poll_id = p.id AND s.user_id = $1
-		)`
-	args := []interface{}{userID}
-	argCount := 1
-
-	if tag != "" {
-		argCount++
+			SELECT 1 FROM skips s WHERE s.user_id = $1 AND s.poll_id = p.id
+		)
+		AND ` + eligibleVoterCondition + `
+		AND ` + notMutedCondition + `
+		AND ` + offensiveCreatorCondition + `
+		AND (p.created_by = $1 OR NOT ` + shadowBannedCreator + `)`
+	args := []interface{}{q.UserID}
+
+	if q.Tag != "" {
+		args = append(args, q.Tag)
 		baseQuery += fmt.Sprintf(`
 			AND EXISTS (
-				SELECT 1 FROM poll_tags pt WHERE pt.poll_id = p.id AND pt.tag = $%d
-			)`, argCount)
-		args = append(args, tag)
+				SELECT 1 FROM poll_tags pt WHERE pt.poll_id = p.id AND (
+					pt.tag = $%[1]d
+					OR pt.tag IN (SELECT ta.alias FROM tag_aliases ta WHERE ta.tag = $%[1]d)
+				)
+			)`, len(args))
+	} else {
+		// Browsing a tag explicitly overrides having lost interest in it.
+		baseQuery += `
+		AND ` + notInterestedTagCondition
+	}
+
+	switch {
+	case q.AllLanguages:
+	case len(q.Languages) > 0:
+		args = append(args, pq.Array(q.Languages))
+		baseQuery += fmt.Sprintf(`
+		AND (p.language = '' OR p.language = ANY($%d))`, len(args))
+	default:
+		baseQuery += `
+		AND ` + preferredLanguageCondition
 	}
 
-	countQuery := `SELECT COUNT(*) ` + baseQuery
 	var total int
-	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
-	if err != nil {
-		return nil, 0, fmt.Errorf("get total count: %w", err)
+	var err error
+	switch q.Total {
+	case domain.FeedTotalNone:
+	case domain.FeedTotalEstimate:
+		total, err = r.estimateRows(ctx, baseQuery, args)
+		if err != nil {
+			return nil, 0, fmt.Errorf("estimate total count: %w", err)
+		}
+	default:
+		err = r.db.QueryRowContext(ctx, `SELECT COUNT(*) `+baseQuery, args...).Scan(&total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("get total count: %w", err)
+		}
 	}
 
-	query := `
-		SELECT p.id, p.title, p.created_at, p.updated_at
-		` + baseQuery + `
-		ORDER BY p.created_at DESC
-		LIMIT $` + fmt.Sprintf("%d", argCount+1) + `
-		OFFSET $` + fmt.Sprintf("%d", argCount+2)
-	args = append(args, limit, (page-1)*limit)
+	// With a cursor the page starts right after the last poll the client
+	// saw, so deep pages cost the same as the first one. Without a cursor
+	// we fall back to OFFSET for clients still paging by number.
+	pageQuery := baseQuery
+	if q.After != nil {
+		args = append(args, q.After.CreatedAt, q.After.ID)
+		pageQuery += fmt.Sprintf(`
+		AND (p.created_at, p.id) < ($%d, $%d)`, len(args)-1, len(args))
+	}
+	args = append(args, q.Limit)
+	pageQuery += fmt.Sprintf(`
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $%d`, len(args))
+	if q.After == nil {
+		args = append(args, (q.Page-1)*q.Limit)
+		pageQuery += fmt.Sprintf(`
+		OFFSET $%d`, len(args))
+	}
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := r.db.QueryContext(ctx, `SELECT `+pollColumns+pageQuery, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("get polls: %w", err)
 	}
@@ -322,75 +545,121 @@ func (r *Repository) GetPollsForFeed(ctx context.Context, userID uuid.UUID, tag
 	var polls []domain.Poll
 	for rows.Next() {
 		var poll domain.Poll
-		err = rows.Scan(&poll.ID, &poll.Title, &poll.CreatedAt, &poll.UpdatedAt)
-		if err != nil {
+		if err = scanPoll(rows, &poll); err != nil {
 			return nil, 0, fmt.Errorf("scan poll: %w", err)
 		}
+		polls = append(polls, poll)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterate polls: %w", err)
+	}
+
+	if err = r.loadPollDetails(ctx, polls); err != nil {
+		return nil, 0, err
+	}
+	return polls, total, nil
+}
+
+// estimateRows returns the planner's row estimate for fromQuery, which is
+// read from table statistics instead of executing the query.
+func (r *Repository) estimateRows(ctx context.Context, fromQuery string, args []interface{}) (int, error) {
+	var raw []byte
+	err := r.db.QueryRowContext(ctx, `EXPLAIN (FORMAT JSON) SELECT 1 `+fromQuery, args...).Scan(&raw)
+	if err != nil {
+		return 0, err
+	}
+	var plans []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err = json.Unmarshal(raw, &plans); err != nil {
+		return 0, fmt.Errorf("decode plan: %w", err)
+	}
+	if len(plans) == 0 {
+		return 0, errors.New("empty plan")
+	}
+	return int(plans[0].Plan.Rows), nil
+}
+
+// loadPollDetails fills in the options and tags of polls with one query each
+// rather than two per poll.
+func (r *Repository) loadPollDetails(ctx context.Context, polls []domain.Poll) error {
+	if len(polls) == 0 {
+		return nil
+	}
+	ids := make([]string, len(polls))
+	byID := make(map[uuid.UUID]*domain.Poll, len(polls))
+	for i := range polls {
+		ids[i] = polls[i].ID.String()
+		byID[polls[i].ID] = &polls[i]
+	}
+
+	optionsQuery := `
+		SELECT id, poll_id, option_text, option_index, created_at, image_key, alt_text, emoji, is_default
+		FROM poll_options
+		WHERE poll_id = ANY($1::uuid[])
+		ORDER BY poll_id, option_index`
+	rows, err := r.db.QueryContext(ctx, optionsQuery, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("get options: %w", err)
+	}
+	defer closeRows(rows, r.logger)
 
-		optionsQuery := `
-			SELECT id, option_text, created_at
-			FROM poll_options
-			WHERE poll_id = $1
-			ORDER BY created_at`
-		optionRows, err := r.db.QueryContext(ctx, optionsQuery, poll.ID)
+	for rows.Next() {
+		var option domain.Option
+		var imageKey, altText, emoji sql.NullString
+		err = rows.Scan(&option.ID, &option.PollID, &option.OptionText, &option.OptionIndex, &option.CreatedAt, &imageKey, &altText, &emoji, &option.Default)
 		if err != nil {
-			return nil, 0, fmt.Errorf("get options: %w", err)
+			return fmt.Errorf("scan option: %w", err)
 		}
-		defer closeRows(optionRows, r.logger)
-
-		for optionRows.Next() {
-			var option domain.Option
-			err = optionRows.Scan(&option.ID, &option.OptionText, &option.CreatedAt)
-			if err != nil {
-				return nil, 0, fmt.Errorf("scan option: %w", err)
-			}
-			option.PollID = poll.ID
+		option.ImageKey = imageKey.String
+		option.AltText = altText.String
+		option.Emoji = emoji.String
+		if poll, ok := byID[option.PollID]; ok {
 			poll.Options = append(poll.Options, option)
 		}
-		if err = optionRows.Err(); err != nil {
-			return nil, 0, fmt.Errorf("iterate options: %w", err)
-		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("iterate options: %w", err)
+	}
 
-		tagsQuery := `
-			SELECT tag
-			FROM poll_tags
-			WHERE poll_id = $1
-			ORDER BY tag`
-		tagRows, err := r.db.QueryContext(ctx, tagsQuery, poll.ID)
-		if err != nil {
-			return nil, 0, fmt.Errorf("get tags: %w", err)
-		}
-		defer closeRows(tagRows, r.logger)
+	tagsQuery := `
+		SELECT poll_id, tag
+		FROM poll_tags
+		WHERE poll_id = ANY($1::uuid[])
+		ORDER BY poll_id, tag`
+	tagRows, err := r.db.QueryContext(ctx, tagsQuery, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("get tags: %w", err)
+	}
+	defer closeRows(tagRows, r.logger)
 
-		for tagRows.Next() {
-			var tag string
-			err = tagRows.Scan(&tag)
-			if err != nil {
-				return nil, 0, fmt.Errorf("scan tag: %w", err)
-			}
-			poll.Tags = append(poll.Tags, tag)
+	for tagRows.Next() {
+		var pollID uuid.UUID
+		var tag string
+		if err = tagRows.Scan(&pollID, &tag); err != nil {
+			return fmt.Errorf("scan tag: %w", err)
 		}
-		if err = tagRows.Err(); err != nil {
-			return nil, 0, fmt.Errorf("iterate tags: %w", err)
+		if poll, ok := byID[pollID]; ok {
+			poll.Tags = append(poll.Tags, tag)
 		}
-
-		polls = append(polls, poll)
 	}
-	if err = rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("iterate polls: %w", err)
+	if err = tagRows.Err(); err != nil {
+		return fmt.Errorf("iterate tags: %w", err)
 	}
-
-	return polls, total, nil
+	return nil
 }
 
 func (r *Repository) GetPollStats(ctx context.Context, pollID uuid.UUID) (*domain.PollStats, error) {
+	// Counts are kept in poll_option_counts as votes change, so no need to
+	// count the votes themselves.
 	query := `
-		SELECT po.option_text, COUNT(v.id) as vote_count
+		SELECT po.option_text, COALESCE(c.votes, 0)
 		FROM poll_options po
-		LEFT JOIN votes v ON v.option_id = po.id
+		LEFT JOIN poll_option_counts c ON c.option_id = po.id
 		WHERE po.poll_id = $1
-		GROUP BY po.option_text, po.created_at
-		ORDER BY po.created_at`
+		ORDER BY po.created_at, po.option_index`
 	rows, err := r.db.QueryContext(ctx, query, pollID)
 	if err != nil {
 		return nil, fmt.Errorf("get poll stats: %w", err)
@@ -398,8 +667,9 @@ func (r *Repository) GetPollStats(ctx context.Context, pollID uuid.UUID) (*domai
 	defer closeRows(rows, r.logger)
 
 	stats := &domain.PollStats{
-		PollID: pollID,
-		Votes:  make([]domain.OptionStats, 0),
+		PollID:     pollID,
+		Votes:      make([]domain.OptionStats, 0),
+		ComputedAt: time.Now().UTC(),
 	}
 	for rows.Next() {
 		var optionStats domain.OptionStats
@@ -413,15 +683,36 @@ func (r *Repository) GetPollStats(ctx context.Context, pollID uuid.UUID) (*domai
 		return nil, fmt.Errorf("iterate option stats: %w", err)
 	}
 
+	if err := r.fillTurnout(ctx, stats); err != nil {
+		return nil, err
+	}
+	if err := r.fillOrganizationVotes(ctx, stats); err != nil {
+		return nil, err
+	}
+
 	return stats, nil
 }
 
-func (r *Repository) CreateVote(ctx context.Context, pollID, userID, optionID uuid.UUID) error {
+func (r *Repository) CreateVote(ctx context.Context, vote *domain.Vote) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
 	query := `
 		INSERT INTO votes (id, poll_id, user_id, option_id, created_at)
 		VALUES ($1, $2, $3, $4, $5)`
-	_, err := r.db.ExecContext(ctx, query,
-		uuid.New(), pollID, userID, optionID, time.Now().UTC(),
+	_, err = tx.ExecContext(ctx, query,
+		vote.ID, vote.PollID, vote.UserID, vote.OptionID, vote.CreatedAt,
 	)
 	if err != nil {
 		var pqErr *pq.Error
@@ -430,8 +721,21 @@ func (r *Repository) CreateVote(ctx context.Context, pollID, userID, optionID uu
 		}
 		return fmt.Errorf("create vote: %w", err)
 	}
+	if err = adjustOptionCount(ctx, tx, vote.OptionID, vote.UserID, 1); err != nil {
+		return err
+	}
+
+	if err = addOutboxEvent(ctx, tx, events.EventPollVoted, vote); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
+	r.invalidateFeed(ctx, vote.UserID)
 
-	poll, err := r.GetPollByID(ctx, pollID)
+	poll, err := r.GetPollByID(ctx, vote.PollID)
 	if err == nil {
 		_ = r.SetCachedPoll(ctx, poll)
 	} else {
@@ -485,12 +789,12 @@ func (r *Repository) IncrementUserDailyVoteCount(ctx context.Context, userID uui
 	return nil
 }
 
-func (r *Repository) CreateSkip(ctx context.Context, pollID, userID uuid.UUID) error {
+func (r *Repository) CreateSkip(ctx context.Context, skip *domain.Skip) error {
 	query := `
-		INSERT INTO skips (id, poll_id, user_id, created_at)
-		VALUES ($1, $2, $3, $4)`
+		INSERT INTO skips (id, poll_id, user_id, reason, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)`
 	_, err := r.db.ExecContext(ctx, query,
-		uuid.New(), pollID, userID, time.Now().UTC(),
+		skip.ID, skip.PollID, skip.UserID, skip.Reason, skip.CreatedAt,
 	)
 	if err != nil {
 		var pqErr *pq.Error
@@ -499,6 +803,7 @@ func (r *Repository) CreateSkip(ctx context.Context, pollID, userID uuid.UUID) e
 		}
 		return fmt.Errorf("create skip: %w", err)
 	}
+	r.invalidateFeed(ctx, skip.UserID)
 	return nil
 }
 
@@ -517,8 +822,8 @@ func (r *Repository) HasSkipped(ctx context.Context, pollID, userID uuid.UUID) (
 }
 
 func (r *Repository) GetCachedPollStats(ctx context.Context, pollID uuid.UUID) (*domain.PollStats, error) {
-	key := fmt.Sprintf("poll:stats:%s", pollID)
-	data, err := r.redis.Get(ctx, key).Bytes()
+	key := cache.PollStatsKey(pollID)
+	data, err := r.getCached(ctx, key)
 	if errors.Is(err, redis.Nil) {
 		return nil, domain.ErrNotFound
 	}
@@ -535,13 +840,13 @@ func (r *Repository) GetCachedPollStats(ctx context.Context, pollID uuid.UUID) (
 }
 
 func (r *Repository) SetCachedPollStats(ctx context.Context, pollID uuid.UUID, stats *domain.PollStats) error {
-	key := fmt.Sprintf("poll:stats:%s", pollID)
+	key := cache.PollStatsKey(pollID)
 	data, err := json.Marshal(stats)
 	if err != nil {
 		return fmt.Errorf("marshal stats: %w", err)
 	}
 
-	err = r.redis.Set(ctx, key, data, 5*time.Minute).Err()
+	err = r.setCached(ctx, key, data, domain.StatsCacheTTL)
 	if err != nil {
 		return fmt.Errorf("cache stats: %w", err)
 	}
@@ -549,8 +854,8 @@ func (r *Repository) SetCachedPollStats(ctx context.Context, pollID uuid.UUID, s
 }
 
 func (r *Repository) InvalidatePollStatsCache(ctx context.Context, pollID uuid.UUID) error {
-	key := fmt.Sprintf("poll:stats:%s", pollID)
-	err := r.redis.Del(ctx, key).Err()
+	key := cache.PollStatsKey(pollID)
+	err := r.deleteCached(ctx, key)
 	if err != nil {
 		return fmt.Errorf("invalidate cache: %w", err)
 	}
@@ -593,29 +898,78 @@ func closeRows(rows *sql.Rows, logger *zap.Logger) {
 
 type txKey struct{}
 
-func (r *Repository) GetUserVotes(ctx context.Context, userID uuid.UUID, page, limit int) ([]domain.Vote, int, error) {
-	countQuery := `
-		SELECT COUNT(*)
-		FROM votes
-		WHERE user_id = $1`
+// userVotesQuery builds the shared FROM/WHERE part of the vote history
+// queries. Deleted votes live in deleted_votes and are unioned in on request.
+func userVotesQuery(userID uuid.UUID, filter domain.VoteFilter) (string, []interface{}) {
+	source := `SELECT id, poll_id, user_id, option_id, created_at, NULL::timestamptz AS deleted_at FROM votes`
+	if filter.IncludeDeleted {
+		source += `
+			UNION ALL
+			SELECT id, poll_id, user_id, option_id, created_at, deleted_at FROM deleted_votes`
+	}
+
+	args := []interface{}{userID}
+	conditions := []string{"v.user_id = $1"}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("v.created_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("v.created_at < $%d", len(args)))
+	}
+
+	query := fmt.Sprintf(`
+		FROM (%s) v
+		JOIN polls p ON v.poll_id = p.id
+		JOIN poll_options po ON v.option_id = po.id
+		WHERE %s`, source, strings.Join(conditions, " AND "))
+	return query, args
+}
+
+const userVoteColumns = `v.id, v.poll_id, v.user_id, v.option_id, v.created_at, v.deleted_at,
+			   p.title AS poll_title, p.status, p.starts_at, p.ends_at,
+			   po.option_text AS option_text, po.option_index`
+
+func scanUserVote(rows *sql.Rows) (*domain.Vote, error) {
+	var vote domain.Vote
+	var deletedAt, startsAt, endsAt sql.NullTime
+	err := rows.Scan(
+		&vote.ID, &vote.PollID, &vote.UserID, &vote.OptionID, &vote.CreatedAt, &deletedAt,
+		&vote.PollTitle, &vote.PollStatus, &startsAt, &endsAt,
+		&vote.OptionText, &vote.OptionIndex,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if deletedAt.Valid {
+		vote.DeletedAt = &deletedAt.Time
+	}
+	if startsAt.Valid {
+		vote.PollStartsAt = &startsAt.Time
+	}
+	if endsAt.Valid {
+		vote.PollEndsAt = &endsAt.Time
+	}
+	return &vote, nil
+}
+
+func (r *Repository) GetUserVotes(ctx context.Context, userID uuid.UUID, filter domain.VoteFilter, page, limit int) ([]domain.Vote, int, error) {
+	from, args := userVotesQuery(userID, filter)
+
 	var total int
-	err := r.db.QueryRowContext(ctx, countQuery, userID).Scan(&total)
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*)"+from, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("get vote count: %w", err)
 	}
 
-	query := `
-		SELECT v.id, v.poll_id, v.user_id, v.option_id, v.created_at,
-			   p.title as poll_title,
-			   po.option_text as option_text
-		FROM votes v
-		JOIN polls p ON v.poll_id = p.id
-		JOIN poll_options po ON v.option_id = po.id
-		WHERE v.user_id = $1
+	query := fmt.Sprintf(`
+		SELECT %s%s
 		ORDER BY v.created_at DESC
-		LIMIT $2 OFFSET $3`
+		LIMIT $%d OFFSET $%d`, userVoteColumns, from, len(args)+1, len(args)+2)
+	args = append(args, limit, (page-1)*limit)
 
-	rows, err := r.db.QueryContext(ctx, query, userID, limit, (page-1)*limit)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("get votes: %w", err)
 	}
@@ -623,16 +977,11 @@ func (r *Repository) GetUserVotes(ctx context.Context, userID uuid.UUID, page, l
 
 	var votes []domain.Vote
 	for rows.Next() {
-		var vote domain.Vote
-		var pollTitle, optionText string
-		err = rows.Scan(
-			&vote.ID, &vote.PollID, &vote.UserID, &vote.OptionID, &vote.CreatedAt,
-			&pollTitle, &optionText,
-		)
+		vote, err := scanUserVote(rows)
 		if err != nil {
 			return nil, 0, fmt.Errorf("scan vote: %w", err)
 		}
-		votes = append(votes, vote)
+		votes = append(votes, *vote)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, 0, fmt.Errorf("iterate votes: %w", err)
@@ -641,6 +990,36 @@ func (r *Repository) GetUserVotes(ctx context.Context, userID uuid.UUID, page, l
 	return votes, total, nil
 }
 
+// StreamUserVotes calls fn for every vote matching filter, newest first,
+// without loading the whole history into memory. Iteration stops at the
+// first error returned by fn.
+func (r *Repository) StreamUserVotes(ctx context.Context, userID uuid.UUID, filter domain.VoteFilter, fn func(*domain.Vote) error) error {
+	from, args := userVotesQuery(userID, filter)
+	query := fmt.Sprintf(`
+		SELECT %s%s
+		ORDER BY v.created_at DESC`, userVoteColumns, from)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("stream votes: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	for rows.Next() {
+		vote, err := scanUserVote(rows)
+		if err != nil {
+			return fmt.Errorf("scan vote: %w", err)
+		}
+		if err := fn(vote); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate votes: %w", err)
+	}
+	return nil
+}
+
 func (r *Repository) GetVoteByID(ctx context.Context, voteID uuid.UUID) (*domain.Vote, error) {
 	query := `
 		SELECT v.id, v.poll_id, v.user_id, v.option_id, v.created_at
@@ -660,6 +1039,26 @@ func (r *Repository) GetVoteByID(ctx context.Context, voteID uuid.UUID) (*domain
 	return &vote, nil
 }
 
+func (r *Repository) GetUserPollVote(ctx context.Context, pollID, userID uuid.UUID) (*domain.Vote, error) {
+	query := `
+		SELECT v.id, v.poll_id, v.user_id, v.option_id, v.created_at, po.option_index
+		FROM votes v
+		JOIN poll_options po ON po.id = v.option_id
+		WHERE v.poll_id = $1 AND v.user_id = $2`
+
+	var vote domain.Vote
+	err := r.db.QueryRowContext(ctx, query, pollID, userID).Scan(
+		&vote.ID, &vote.PollID, &vote.UserID, &vote.OptionID, &vote.CreatedAt, &vote.OptionIndex,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get user poll vote: %w", err)
+	}
+	return &vote, nil
+}
+
 func (r *Repository) UpdateVote(ctx context.Context, voteID, userID, optionID uuid.UUID) error {
 	vote, err := r.GetVoteByID(ctx, voteID)
 	if err != nil {
@@ -685,23 +1084,61 @@ func (r *Repository) UpdateVote(ctx context.Context, voteID, userID, optionID uu
 		return domain.ErrInvalidOption
 	}
 
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	// The option is read again under lock so concurrent changes to the vote
+	// move the counts from the option it really had.
+	var previous uuid.UUID
+	err = tx.QueryRowContext(ctx, `SELECT option_id FROM votes WHERE id = $1 AND user_id = $2 FOR UPDATE`, voteID, userID).Scan(&previous)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("lock vote: %w", err)
+	}
+
 	updateQuery := `
 		UPDATE votes
 		SET option_id = $1
 		WHERE id = $2 AND user_id = $3`
 
-	result, err := r.db.ExecContext(ctx, updateQuery, optionID, voteID, userID)
-	if err != nil {
+	if _, err = tx.ExecContext(ctx, updateQuery, optionID, voteID, userID); err != nil {
 		return fmt.Errorf("update vote: %w", err)
 	}
+	if previous != optionID {
+		if err = adjustOptionCount(ctx, tx, previous, userID, -1); err != nil {
+			return err
+		}
+		if err = adjustOptionCount(ctx, tx, optionID, userID, 1); err != nil {
+			return err
+		}
+	}
 
-	rows, err := result.RowsAffected()
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO vote_history (vote_id, previous_option_id, option_id, changed_at)
+		VALUES ($1, $2, $3, $4)`,
+		voteID, previous, optionID, time.Now().UTC(),
+	)
 	if err != nil {
-		return fmt.Errorf("get rows affected: %w", err)
+		return fmt.Errorf("record vote change: %w", err)
 	}
-	if rows == 0 {
-		return domain.ErrNotFound
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
 	}
+	committed = true
 
 	if err := r.InvalidatePollStatsCache(ctx, vote.PollID); err != nil {
 		r.logger.Warn("Failed to invalidate poll stats cache after vote update",
@@ -713,9 +1150,33 @@ func (r *Repository) UpdateVote(ctx context.Context, voteID, userID, optionID uu
 	return nil
 }
 
+func (r *Repository) GetLastVoteChange(ctx context.Context, voteID uuid.UUID) (time.Time, error) {
+	var changedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+		SELECT MAX(changed_at) FROM vote_history WHERE vote_id = $1`, voteID,
+	).Scan(&changedAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("get last vote change: %w", err)
+	}
+	return changedAt.Time, nil
+}
+
 func (r *Repository) DeleteVote(ctx context.Context, voteID, userID uuid.UUID) error {
-	query := `DELETE FROM votes WHERE id = $1 AND user_id = $2`
-	result, err := r.db.ExecContext(ctx, query, voteID, userID)
+	// The vote is moved to deleted_votes rather than dropped so it can still
+	// appear in the user's exported history.
+	query := `
+		WITH removed AS (
+			DELETE FROM votes WHERE id = $1 AND user_id = $2
+			RETURNING id, poll_id, user_id, option_id, created_at
+		), uncounted AS (
+			UPDATE poll_option_counts c
+			SET votes = c.votes - 1
+			FROM removed v
+			WHERE c.option_id = v.option_id AND NOT ` + shadowBannedVoter + `
+		)
+		INSERT INTO deleted_votes (id, poll_id, user_id, option_id, created_at, deleted_at)
+		SELECT id, poll_id, user_id, option_id, created_at, $3 FROM removed`
+	result, err := r.db.ExecContext(ctx, query, voteID, userID, time.Now())
 	if err != nil {
 		return fmt.Errorf("delete vote: %w", err)
 	}
@@ -726,5 +1187,6 @@ func (r *Repository) DeleteVote(ctx context.Context, voteID, userID uuid.UUID) e
 	if rowsAffected == 0 {
 		return domain.ErrUnauthorized
 	}
+	r.invalidateFeed(ctx, userID)
 	return nil
 }
@@ -0,0 +1,182 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const (
+	preferenceFollowTag   = "follow_tag"
+	preferenceMuteTag     = "mute_tag"
+	preferenceMuteKeyword = "mute_keyword"
+	preferenceLanguage    = "language"
+)
+
+// notMutedCondition excludes polls aliased p that the user bound to $1 has
+// muted by tag or by a keyword contained in the title.
+const notMutedCondition = `NOT EXISTS (
+			SELECT 1 FROM user_topic_preferences utp
+			JOIN poll_tags mpt ON mpt.tag = utp.value
+			WHERE utp.user_id = $1 AND utp.kind = 'mute_tag' AND mpt.poll_id = p.id
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM user_topic_preferences utp
+			WHERE utp.user_id = $1 AND utp.kind = 'mute_keyword'
+			AND POSITION(utp.value IN LOWER(p.title)) > 0
+		)`
+
+// preferredLanguageCondition keeps polls aliased p that are in one of the
+// languages the user bound to $1 reads, or in an unknown language. Users
+// without preferred languages see every language.
+const preferredLanguageCondition = `(p.language = ''
+			OR NOT EXISTS (
+				SELECT 1 FROM user_topic_preferences ulp
+				WHERE ulp.user_id = $1 AND ulp.kind = 'language'
+			)
+			OR EXISTS (
+				SELECT 1 FROM user_topic_preferences ulp
+				WHERE ulp.user_id = $1 AND ulp.kind = 'language' AND ulp.value = p.language
+			))`
+
+// Skip reasons personalize the feed: polls from a creator the user has
+// skipped as offensive are hidden, and so are polls tagged with a tag the
+// user has skipped as not interesting at least three times.
+const (
+	offensiveCreatorCondition = `NOT EXISTS (
+			SELECT 1 FROM skips os
+			JOIN polls op ON op.id = os.poll_id
+			WHERE os.user_id = $1 AND os.reason = 'offensive' AND op.created_by = p.created_by
+		)`
+	notInterestedTagCondition = `NOT EXISTS (
+			SELECT 1 FROM poll_tags npt
+			WHERE npt.poll_id = p.id AND npt.tag IN (
+				SELECT st.tag FROM skips ns
+				JOIN poll_tags st ON st.poll_id = ns.poll_id
+				WHERE ns.user_id = $1 AND ns.reason = 'not_interested'
+				GROUP BY st.tag
+				HAVING COUNT(*) >= 3
+			)
+		)`
+)
+
+func (r *Repository) GetUserPreferences(ctx context.Context, userID uuid.UUID) (*domain.UserPreferences, error) {
+	query := `SELECT kind, value FROM user_topic_preferences WHERE user_id = $1 ORDER BY kind, value`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user preferences: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	prefs := &domain.UserPreferences{
+		FollowedTags:  []string{},
+		MutedTags:     []string{},
+		MutedKeywords: []string{},
+		Languages:     []string{},
+	}
+	for rows.Next() {
+		var kind, value string
+		if err := rows.Scan(&kind, &value); err != nil {
+			return nil, fmt.Errorf("scan user preference: %w", err)
+		}
+		switch kind {
+		case preferenceFollowTag:
+			prefs.FollowedTags = append(prefs.FollowedTags, value)
+		case preferenceMuteTag:
+			prefs.MutedTags = append(prefs.MutedTags, value)
+		case preferenceMuteKeyword:
+			prefs.MutedKeywords = append(prefs.MutedKeywords, value)
+		case preferenceLanguage:
+			prefs.Languages = append(prefs.Languages, value)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate user preferences: %w", err)
+	}
+
+	err = r.db.QueryRowContext(ctx, `SELECT vote_receipts FROM users WHERE id = $1`, userID).Scan(&prefs.VoteReceipts)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("get vote receipts preference: %w", err)
+	}
+	return prefs, nil
+}
+
+func (r *Repository) SetUserPreferences(ctx context.Context, userID uuid.UUID, prefs *domain.UserPreferences) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM user_topic_preferences WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("clear user preferences: %w", err)
+	}
+
+	insertQuery := `
+		INSERT INTO user_topic_preferences (user_id, kind, value)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`
+	values := map[string][]string{
+		preferenceFollowTag:   prefs.FollowedTags,
+		preferenceMuteTag:     prefs.MutedTags,
+		preferenceMuteKeyword: prefs.MutedKeywords,
+		preferenceLanguage:    prefs.Languages,
+	}
+	for kind, list := range values {
+		for _, value := range list {
+			if _, err = tx.ExecContext(ctx, insertQuery, userID, kind, value); err != nil {
+				return fmt.Errorf("insert user preference: %w", err)
+			}
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE users SET vote_receipts = $2 WHERE id = $1`, userID, prefs.VoteReceipts); err != nil {
+		return fmt.Errorf("update vote receipts preference: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
+	r.invalidateFeed(ctx, userID)
+	return nil
+}
+
+func (r *Repository) GetTagFollowers(ctx context.Context, tags []string) ([]uuid.UUID, error) {
+	query := `
+		SELECT DISTINCT user_id
+		FROM user_topic_preferences
+		WHERE kind = 'follow_tag' AND value = ANY($1)`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(tags))
+	if err != nil {
+		return nil, fmt.Errorf("get tag followers: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	userIDs := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("scan tag follower: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate tag followers: %w", err)
+	}
+	return userIDs, nil
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+)
+
+func (r *Repository) GetUserQuotas(ctx context.Context, userID uuid.UUID) ([]domain.Quota, error) {
+	query := `SELECT action, period, quota_limit FROM user_quotas WHERE user_id = $1`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user quotas: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	quotas := make([]domain.Quota, 0)
+	for rows.Next() {
+		var quota domain.Quota
+		if err := rows.Scan(&quota.Action, &quota.Period, &quota.Limit); err != nil {
+			return nil, fmt.Errorf("scan user quota: %w", err)
+		}
+		quotas = append(quotas, quota)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate user quotas: %w", err)
+	}
+	return quotas, nil
+}
+
+func (r *Repository) GetQuotaUsage(ctx context.Context, userID uuid.UUID, action domain.QuotaAction, period domain.QuotaPeriod, periodStart time.Time) (int, error) {
+	query := `
+		SELECT used FROM quota_usage
+		WHERE user_id = $1 AND action = $2 AND period = $3 AND period_start = $4`
+	var used int
+	err := r.db.QueryRowContext(ctx, query, userID, action, period, periodStart).Scan(&used)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get quota usage: %w", err)
+	}
+	return used, nil
+}
+
+func (r *Repository) IncrementQuotaUsage(ctx context.Context, userID uuid.UUID, action domain.QuotaAction, period domain.QuotaPeriod, periodStart time.Time) error {
+	query := `
+		INSERT INTO quota_usage (user_id, action, period, period_start, used, updated_at)
+		VALUES ($1, $2, $3, $4, 1, $5)
+		ON CONFLICT (user_id, action, period, period_start) DO UPDATE
+		SET used = quota_usage.used + 1,
+			updated_at = EXCLUDED.updated_at`
+	if _, err := r.db.ExecContext(ctx, query, userID, action, period, periodStart, time.Now().UTC()); err != nil {
+		return fmt.Errorf("increment quota usage: %w", err)
+	}
+	return nil
+}
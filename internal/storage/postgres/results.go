@@ -0,0 +1,212 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+)
+
+func (r *Repository) GetPollsToSnapshot(ctx context.Context, closedBefore time.Time) ([]uuid.UUID, error) {
+	query := `
+		SELECT p.id
+		FROM polls p
+		LEFT JOIN poll_results pr ON pr.poll_id = p.id
+		WHERE p.status NOT IN ('draft', 'deleted')
+		AND (p.status IN ('closed', 'archived') OR p.ends_at <= $1)
+		AND pr.poll_id IS NULL
+		ORDER BY p.ends_at NULLS LAST`
+	rows, err := r.db.QueryContext(ctx, query, closedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("get polls to snapshot: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan poll id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate polls to snapshot: %w", err)
+	}
+	return ids, nil
+}
+
+func (r *Repository) SavePollResultSnapshot(ctx context.Context, snapshot *domain.PollResultSnapshot) error {
+	options, err := json.Marshal(snapshot.Options)
+	if err != nil {
+		return fmt.Errorf("marshal result options: %w", err)
+	}
+	var winner sql.NullString
+	if snapshot.Winner != "" {
+		winner = sql.NullString{String: snapshot.Winner, Valid: true}
+	}
+	var voted, eligible sql.NullInt64
+	if snapshot.Turnout != nil {
+		voted = sql.NullInt64{Int64: int64(snapshot.Turnout.Voted), Valid: true}
+		eligible = sql.NullInt64{Int64: int64(snapshot.Turnout.Eligible), Valid: true}
+	}
+	var tied, tieBreak sql.NullString
+	if len(snapshot.Tied) > 0 {
+		data, err := json.Marshal(snapshot.Tied)
+		if err != nil {
+			return fmt.Errorf("marshal tied options: %w", err)
+		}
+		tied = sql.NullString{String: string(data), Valid: true}
+		tieBreak = sql.NullString{String: string(snapshot.TieBreak), Valid: true}
+	}
+
+	query := `
+		INSERT INTO poll_results (poll_id, options, total_votes, winner, tied, tie_break, turnout_voted, turnout_eligible, closed_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (poll_id) DO NOTHING`
+	_, err = r.db.ExecContext(ctx, query,
+		snapshot.PollID, string(options), snapshot.Total, winner, tied, tieBreak, voted, eligible, snapshot.ClosedAt, snapshot.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("save poll result snapshot: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetPollResultSnapshot(ctx context.Context, pollID uuid.UUID) (*domain.PollResultSnapshot, error) {
+	query := `
+		SELECT poll_id, options, total_votes, winner, tied, tie_break, turnout_voted, turnout_eligible, closed_at, created_at
+		FROM poll_results
+		WHERE poll_id = $1`
+	var snapshot domain.PollResultSnapshot
+	var options string
+	var winner, tied, tieBreak sql.NullString
+	var voted, eligible sql.NullInt64
+	err := r.db.QueryRowContext(ctx, query, pollID).Scan(
+		&snapshot.PollID, &options, &snapshot.Total, &winner, &tied, &tieBreak, &voted, &eligible, &snapshot.ClosedAt, &snapshot.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get poll result snapshot: %w", err)
+	}
+	if err := json.Unmarshal([]byte(options), &snapshot.Options); err != nil {
+		return nil, fmt.Errorf("unmarshal result options: %w", err)
+	}
+	snapshot.Winner = winner.String
+	if tied.Valid {
+		if err := json.Unmarshal([]byte(tied.String), &snapshot.Tied); err != nil {
+			return nil, fmt.Errorf("unmarshal tied options: %w", err)
+		}
+		snapshot.TieBreak = domain.TieBreakPolicy(tieBreak.String)
+	}
+	if voted.Valid {
+		snapshot.Turnout = &domain.Turnout{Voted: int(voted.Int64), Eligible: int(eligible.Int64)}
+	}
+	return &snapshot, nil
+}
+
+func (r *Repository) GetPollsToCertify(ctx context.Context) ([]uuid.UUID, error) {
+	query := `
+		SELECT pr.poll_id
+		FROM poll_results pr
+		JOIN polls p ON p.id = pr.poll_id
+		LEFT JOIN poll_result_certificates c ON c.poll_id = pr.poll_id
+		WHERE p.status <> 'deleted'
+		AND c.poll_id IS NULL
+		ORDER BY pr.closed_at`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get polls to certify: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan poll id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate polls to certify: %w", err)
+	}
+	return ids, nil
+}
+
+func (r *Repository) SaveResultCertificate(ctx context.Context, cert *domain.ResultCertificate) error {
+	payload, err := base64.StdEncoding.DecodeString(cert.Payload)
+	if err != nil {
+		return fmt.Errorf("decode certificate payload: %w", err)
+	}
+	query := `
+		INSERT INTO poll_result_certificates (poll_id, payload, algorithm, public_key, signature, issued_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (poll_id) DO NOTHING`
+	_, err = r.db.ExecContext(ctx, query,
+		cert.PollID, string(payload), cert.Algorithm, cert.PublicKey, cert.Signature, cert.IssuedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("save result certificate: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetResultCertificate(ctx context.Context, pollID uuid.UUID) (*domain.ResultCertificate, error) {
+	query := `
+		SELECT poll_id, payload, algorithm, public_key, signature, issued_at
+		FROM poll_result_certificates
+		WHERE poll_id = $1`
+	var cert domain.ResultCertificate
+	var payload string
+	err := r.db.QueryRowContext(ctx, query, pollID).Scan(
+		&cert.PollID, &payload, &cert.Algorithm, &cert.PublicKey, &cert.Signature, &cert.IssuedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get result certificate: %w", err)
+	}
+	if err := json.Unmarshal([]byte(payload), &cert.Results); err != nil {
+		return nil, fmt.Errorf("unmarshal certified results: %w", err)
+	}
+	cert.Payload = base64.StdEncoding.EncodeToString([]byte(payload))
+	return &cert, nil
+}
+
+func (r *Repository) GetLastVoteTimes(ctx context.Context, pollID uuid.UUID) (map[string]time.Time, error) {
+	query := `
+		SELECT po.option_text, MAX(v.created_at)
+		FROM poll_options po
+		JOIN votes v ON v.option_id = po.id AND NOT ` + shadowBannedVoter + `
+		WHERE po.poll_id = $1
+		GROUP BY po.option_text`
+	rows, err := r.db.QueryContext(ctx, query, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("get last vote times: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	times := make(map[string]time.Time)
+	for rows.Next() {
+		var option string
+		var at time.Time
+		if err := rows.Scan(&option, &at); err != nil {
+			return nil, fmt.Errorf("scan last vote time: %w", err)
+		}
+		times[option] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate last vote times: %w", err)
+	}
+	return times, nil
+}
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// NextRun and ClaimRun make the repository a scheduler.RunStore.
+
+func (r *Repository) NextRun(ctx context.Context, job string) (time.Time, bool, error) {
+	var next time.Time
+	err := r.db.QueryRowContext(ctx, `SELECT next_run_at FROM scheduler_runs WHERE job = $1`, job).Scan(&next)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("get next run: %w", err)
+	}
+	return next, true, nil
+}
+
+func (r *Repository) ClaimRun(ctx context.Context, job string, prev, next time.Time) (bool, error) {
+	var (
+		result sql.Result
+		err    error
+	)
+	if prev.IsZero() {
+		result, err = r.db.ExecContext(ctx, `
+			INSERT INTO scheduler_runs (job, next_run_at)
+			VALUES ($1, $2)
+			ON CONFLICT (job) DO NOTHING`, job, next)
+	} else {
+		result, err = r.db.ExecContext(ctx, `
+			UPDATE scheduler_runs
+			SET next_run_at = $3, claimed_at = CURRENT_TIMESTAMP
+			WHERE job = $1 AND next_run_at = $2`, job, prev, next)
+	}
+	if err != nil {
+		return false, fmt.Errorf("claim run: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("get rows affected: %w", err)
+	}
+	return rows > 0, nil
+}
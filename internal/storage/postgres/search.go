@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// searchablePollCondition limits search to polls anyone may see: drafts,
+// archived and deleted polls, restricted electorates and polls by
+// shadow-banned users are left out.
+const searchablePollCondition = `p.status IN ('scheduled', 'live', 'closed') AND p.electorate = 'open' AND NOT ` + shadowBannedCreator
+
+// GetPollsByIDs loads the polls with the given IDs in the order given.
+// Deleted and unknown polls and polls by shadow-banned users are skipped.
+func (r *Repository) GetPollsByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.Poll, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	strIDs := make([]string, len(ids))
+	for i, id := range ids {
+		strIDs[i] = id.String()
+	}
+
+	query := `
+		SELECT ` + pollColumns + `
+		FROM polls p
+		WHERE p.id = ANY($1::uuid[]) AND p.status <> 'deleted' AND NOT ` + shadowBannedCreator
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(strIDs))
+	if err != nil {
+		return nil, fmt.Errorf("get polls by ids: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	byID := make(map[uuid.UUID]domain.Poll, len(ids))
+	for rows.Next() {
+		var poll domain.Poll
+		if err := scanPoll(rows, &poll); err != nil {
+			return nil, fmt.Errorf("scan poll: %w", err)
+		}
+		byID[poll.ID] = poll
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate polls: %w", err)
+	}
+
+	polls := make([]domain.Poll, 0, len(byID))
+	for _, id := range ids {
+		if poll, ok := byID[id]; ok {
+			polls = append(polls, poll)
+		}
+	}
+	if err := r.loadPollDetails(ctx, polls); err != nil {
+		return nil, err
+	}
+	return polls, nil
+}
+
+// SearchPolls matches the query against poll titles with full-text search
+// and exactly against tags and creator usernames. Unlike the search backend
+// it has no typo tolerance.
+func (r *Repository) SearchPolls(ctx context.Context, q domain.PollSearchQuery) (*domain.PollSearchResult, error) {
+	baseQuery := `
+		FROM polls p
+		LEFT JOIN users u ON u.id = p.created_by
+		WHERE ` + searchablePollCondition
+	var args []interface{}
+	order := `p.created_at DESC, p.id DESC`
+
+	if q.Query != "" {
+		args = append(args, q.Query)
+		baseQuery += `
+		AND (
+			to_tsvector('simple', p.title) @@ websearch_to_tsquery('simple', $1)
+			OR EXISTS (SELECT 1 FROM poll_tags pt WHERE pt.poll_id = p.id AND pt.tag = lower($1))
+			OR lower(u.username) = lower($1)
+		)`
+		order = `ts_rank(to_tsvector('simple', p.title), websearch_to_tsquery('simple', $1)) DESC, ` + order
+	}
+	for _, tag := range q.Tags {
+		args = append(args, tag)
+		baseQuery += fmt.Sprintf(`
+		AND EXISTS (SELECT 1 FROM poll_tags pt WHERE pt.poll_id = p.id AND pt.tag = $%d)`, len(args))
+	}
+
+	result := &domain.PollSearchResult{
+		Polls:  []domain.Poll{},
+		Facets: []domain.TagFacet{},
+		Page:   q.Page,
+		Limit:  q.Limit,
+	}
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) `+baseQuery, args...).Scan(&result.Total)
+	if err != nil {
+		return nil, fmt.Errorf("count search results: %w", err)
+	}
+	if result.Total == 0 {
+		return result, nil
+	}
+
+	facetsQuery := fmt.Sprintf(`
+		SELECT pt.tag, COUNT(*)
+		FROM poll_tags pt
+		WHERE pt.poll_id IN (SELECT p.id %s)
+		GROUP BY pt.tag
+		ORDER BY COUNT(*) DESC, pt.tag
+		LIMIT %d`, baseQuery, domain.MaxTagFacets)
+	rows, err := r.db.QueryContext(ctx, facetsQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get search facets: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	for rows.Next() {
+		var facet domain.TagFacet
+		if err = rows.Scan(&facet.Tag, &facet.Count); err != nil {
+			return nil, fmt.Errorf("scan search facet: %w", err)
+		}
+		result.Facets = append(result.Facets, facet)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate search facets: %w", err)
+	}
+
+	pollsQuery := fmt.Sprintf(`
+		SELECT %s
+		%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d`, pollColumns, baseQuery, order, len(args)+1, len(args)+2)
+	args = append(args, q.Limit, (q.Page-1)*q.Limit)
+	pollRows, err := r.db.QueryContext(ctx, pollsQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search polls: %w", err)
+	}
+	defer closeRows(pollRows, r.logger)
+
+	for pollRows.Next() {
+		var poll domain.Poll
+		if err = scanPoll(pollRows, &poll); err != nil {
+			return nil, fmt.Errorf("scan poll: %w", err)
+		}
+		result.Polls = append(result.Polls, poll)
+	}
+	if err = pollRows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate polls: %w", err)
+	}
+
+	if err = r.loadPollDetails(ctx, result.Polls); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
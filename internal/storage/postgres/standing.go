@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// shadowBannedCreator matches polls aliased p created by a shadow-banned
+// user, and shadowBannedVoter votes aliased v cast by one. Both are hidden
+// from everyone but their author.
+const (
+	shadowBannedCreator = `EXISTS (SELECT 1 FROM users sbu WHERE sbu.id = p.created_by AND sbu.standing = 'shadow_banned')`
+	shadowBannedVoter   = `EXISTS (SELECT 1 FROM users sbu WHERE sbu.id = v.user_id AND sbu.standing = 'shadow_banned')`
+)
+
+// SetUserStanding also takes the user's votes out of the option counts when
+// they are shadow-banned, and puts them back when the ban is lifted.
+func (r *Repository) SetUserStanding(ctx context.Context, userID uuid.UUID, standing domain.UserStanding) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	var previous domain.UserStanding
+	err = tx.QueryRowContext(ctx, `SELECT standing FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&previous)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("lock user: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE users SET standing = $2, updated_at = NOW() WHERE id = $1`, userID, standing); err != nil {
+		return fmt.Errorf("set user standing: %w", err)
+	}
+
+	wasBanned, banned := previous == domain.StandingShadowBanned, standing == domain.StandingShadowBanned
+	switch {
+	case banned && !wasBanned:
+		err = adjustUserOptionCounts(ctx, tx, userID, -1)
+	case wasBanned && !banned:
+		err = adjustUserOptionCounts(ctx, tx, userID, 1)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
+	return nil
+}
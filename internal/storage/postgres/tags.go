@@ -0,0 +1,280 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// ResolveTags maps each tag to its canonical tag, keeping the input order.
+func (r *Repository) ResolveTags(ctx context.Context, tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return tags, nil
+	}
+
+	query := `SELECT alias, tag FROM tag_aliases WHERE alias = ANY($1)`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(tags))
+	if err != nil {
+		return nil, fmt.Errorf("resolve tags: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	canonical := make(map[string]string)
+	for rows.Next() {
+		var alias, tag string
+		if err := rows.Scan(&alias, &tag); err != nil {
+			return nil, fmt.Errorf("scan tag alias: %w", err)
+		}
+		canonical[alias] = tag
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate tag aliases: %w", err)
+	}
+
+	resolved := make([]string, len(tags))
+	for i, tag := range tags {
+		if target, ok := canonical[tag]; ok {
+			tag = target
+		}
+		resolved[i] = tag
+	}
+	return resolved, nil
+}
+
+// CreateTagAlias records the alias and repoints aliases of the alias itself so
+// that resolution never needs more than one step.
+func (r *Repository) CreateTagAlias(ctx context.Context, alias *domain.TagAlias) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	if err = createTagAlias(ctx, tx, alias); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
+	return nil
+}
+
+func createTagAlias(ctx context.Context, tx *sql.Tx, alias *domain.TagAlias) error {
+	query := `
+		INSERT INTO tag_aliases (alias, tag, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (alias) DO UPDATE
+		SET tag = EXCLUDED.tag`
+	if _, err := tx.ExecContext(ctx, query, alias.Alias, alias.Tag, alias.CreatedAt); err != nil {
+		return fmt.Errorf("insert tag alias: %w", err)
+	}
+
+	repointQuery := `UPDATE tag_aliases SET tag = $2 WHERE tag = $1`
+	if _, err := tx.ExecContext(ctx, repointQuery, alias.Alias, alias.Tag); err != nil {
+		return fmt.Errorf("repoint tag aliases: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetTagAliases(ctx context.Context) ([]domain.TagAlias, error) {
+	query := `SELECT alias, tag, created_at FROM tag_aliases ORDER BY tag, alias`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get tag aliases: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	aliases := []domain.TagAlias{}
+	for rows.Next() {
+		var alias domain.TagAlias
+		if err := rows.Scan(&alias.Alias, &alias.Tag, &alias.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan tag alias: %w", err)
+		}
+		aliases = append(aliases, alias)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate tag aliases: %w", err)
+	}
+	return aliases, nil
+}
+
+// MergeTags retags every poll tagged from with to, moves followed and muted
+// tags and the tag's rule over, and leaves from behind as an alias of to. It
+// returns the number of polls retagged.
+func (r *Repository) MergeTags(ctx context.Context, from, to string) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	retagQuery := `
+		INSERT INTO poll_tags (poll_id, tag)
+		SELECT poll_id, $2 FROM poll_tags WHERE tag = $1
+		ON CONFLICT DO NOTHING`
+	if _, err = tx.ExecContext(ctx, retagQuery, from, to); err != nil {
+		return 0, fmt.Errorf("retag polls: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `DELETE FROM poll_tags WHERE tag = $1 RETURNING poll_id`, from)
+	if err != nil {
+		return 0, fmt.Errorf("delete merged tag: %w", err)
+	}
+	var pollIDs []uuid.UUID
+	for rows.Next() {
+		var pollID uuid.UUID
+		if err = rows.Scan(&pollID); err != nil {
+			closeRows(rows, r.logger)
+			return 0, fmt.Errorf("scan retagged poll: %w", err)
+		}
+		pollIDs = append(pollIDs, pollID)
+	}
+	closeRows(rows, r.logger)
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("iterate retagged polls: %w", err)
+	}
+
+	preferencesQuery := `
+		INSERT INTO user_topic_preferences (user_id, kind, value)
+		SELECT user_id, kind, $2 FROM user_topic_preferences
+		WHERE value = $1 AND kind IN ('follow_tag', 'mute_tag')
+		ON CONFLICT DO NOTHING`
+	if _, err = tx.ExecContext(ctx, preferencesQuery, from, to); err != nil {
+		return 0, fmt.Errorf("move tag preferences: %w", err)
+	}
+	deletePreferencesQuery := `
+		DELETE FROM user_topic_preferences
+		WHERE value = $1 AND kind IN ('follow_tag', 'mute_tag')`
+	if _, err = tx.ExecContext(ctx, deletePreferencesQuery, from); err != nil {
+		return 0, fmt.Errorf("delete merged tag preferences: %w", err)
+	}
+
+	// from's rule carries over unless to has one of its own.
+	rulesQuery := `
+		INSERT INTO tag_rules (tag, restricted, auto_moderated, updated_by, updated_at)
+		SELECT $2, restricted, auto_moderated, updated_by, updated_at FROM tag_rules WHERE tag = $1
+		ON CONFLICT DO NOTHING`
+	if _, err = tx.ExecContext(ctx, rulesQuery, from, to); err != nil {
+		return 0, fmt.Errorf("move tag rule: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM tag_rules WHERE tag = $1`, from); err != nil {
+		return 0, fmt.Errorf("delete merged tag rule: %w", err)
+	}
+
+	if err = createTagAlias(ctx, tx, &domain.TagAlias{Alias: from, Tag: to, CreatedAt: time.Now().UTC()}); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
+
+	for _, pollID := range pollIDs {
+		r.invalidateCachedPoll(ctx, pollID)
+	}
+	return len(pollIDs), nil
+}
+
+const tagRuleColumns = `tag, restricted, auto_moderated, updated_by, updated_at`
+
+func scanTagRules(rows *sql.Rows) ([]domain.TagRule, error) {
+	rules := []domain.TagRule{}
+	for rows.Next() {
+		var rule domain.TagRule
+		var updatedBy uuid.NullUUID
+		if err := rows.Scan(&rule.Tag, &rule.Restricted, &rule.AutoModerated, &updatedBy, &rule.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan tag rule: %w", err)
+		}
+		if updatedBy.Valid {
+			rule.UpdatedBy = &updatedBy.UUID
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate tag rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *Repository) GetTagRules(ctx context.Context) ([]domain.TagRule, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+tagRuleColumns+` FROM tag_rules ORDER BY tag`)
+	if err != nil {
+		return nil, fmt.Errorf("get tag rules: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+	return scanTagRules(rows)
+}
+
+// GetTagRulesFor returns the rules of those of tags that have one.
+func (r *Repository) GetTagRulesFor(ctx context.Context, tags []string) ([]domain.TagRule, error) {
+	if len(tags) == 0 {
+		return []domain.TagRule{}, nil
+	}
+	query := `SELECT ` + tagRuleColumns + ` FROM tag_rules WHERE tag = ANY($1) ORDER BY tag`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(tags))
+	if err != nil {
+		return nil, fmt.Errorf("get tag rules: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+	return scanTagRules(rows)
+}
+
+func (r *Repository) SetTagRule(ctx context.Context, rule *domain.TagRule) error {
+	query := `
+		INSERT INTO tag_rules (tag, restricted, auto_moderated, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tag) DO UPDATE
+		SET restricted = EXCLUDED.restricted,
+			auto_moderated = EXCLUDED.auto_moderated,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at`
+	var updatedBy uuid.NullUUID
+	if rule.UpdatedBy != nil {
+		updatedBy = uuid.NullUUID{UUID: *rule.UpdatedBy, Valid: true}
+	}
+	_, err := r.db.ExecContext(ctx, query, rule.Tag, rule.Restricted, rule.AutoModerated, updatedBy, rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("set tag rule: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) DeleteTagRule(ctx context.Context, tag string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM tag_rules WHERE tag = $1`, tag)
+	if err != nil {
+		return fmt.Errorf("delete tag rule: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if deleted == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,373 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationUsers(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	user := createTestUser(t, repo)
+
+	t.Run("get by id and email", func(t *testing.T) {
+		got, err := repo.GetUserByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, user.Username, got.Username)
+		assert.Equal(t, domain.StandingActive, got.Standing)
+		assert.Equal(t, domain.RoleUser, got.Role)
+		assert.False(t, got.EmailVerified)
+
+		got, err = repo.GetUserByEmail(ctx, user.Email)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, got.ID)
+
+		_, err = repo.GetUserByID(ctx, uuid.New())
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		_, err = repo.GetUserByEmail(ctx, "missing-"+user.Email)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("duplicate email or username", func(t *testing.T) {
+		now := time.Now().UTC()
+		dup := &domain.User{ID: uuid.New(), Username: uniqueName("it_"), Email: user.Email, Password: "x", CreatedAt: now, UpdatedAt: now}
+		assert.ErrorIs(t, repo.CreateUser(ctx, dup), domain.ErrEmailAlreadyExists)
+
+		dup = &domain.User{ID: uuid.New(), Username: user.Username, Email: uniqueName("it_") + "@integration.test", Password: "x", CreatedAt: now, UpdatedAt: now}
+		assert.ErrorIs(t, repo.CreateUser(ctx, dup), domain.ErrEmailAlreadyExists)
+
+		_, err := repo.GetUserByID(ctx, dup.ID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("update resets verification on a new address", func(t *testing.T) {
+		other := createTestUser(t, repo)
+		_, err := testDB.Exec(`UPDATE users SET email_verified = TRUE WHERE id = $1`, other.ID)
+		require.NoError(t, err)
+
+		other.Password = "new-hash"
+		other.UpdatedAt = time.Now().UTC()
+		require.NoError(t, repo.UpdateUser(ctx, other))
+		got, err := repo.GetUserByID(ctx, other.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "new-hash", got.Password)
+		assert.True(t, got.EmailVerified)
+
+		other.Email = uniqueName("it_") + "@integration.test"
+		require.NoError(t, repo.UpdateUser(ctx, other))
+		got, err = repo.GetUserByID(ctx, other.ID)
+		require.NoError(t, err)
+		assert.Equal(t, other.Email, got.Email)
+		assert.False(t, got.EmailVerified)
+
+		other.Email = user.Email
+		assert.ErrorIs(t, repo.UpdateUser(ctx, other), domain.ErrEmailAlreadyExists)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		other := createTestUser(t, repo)
+		require.NoError(t, repo.DeleteUser(ctx, other.ID))
+		_, err := repo.GetUserByID(ctx, other.ID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("avatar", func(t *testing.T) {
+		previous, err := repo.SetUserAvatar(ctx, user.ID, "avatars/one.png")
+		require.NoError(t, err)
+		assert.Empty(t, previous)
+
+		previous, err = repo.SetUserAvatar(ctx, user.ID, "avatars/two.png")
+		require.NoError(t, err)
+		assert.Equal(t, "avatars/one.png", previous)
+
+		got, err := repo.GetUserByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "avatars/two.png", got.AvatarKey)
+
+		_, err = repo.SetUserAvatar(ctx, uuid.New(), "avatars/three.png")
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("standing", func(t *testing.T) {
+		other := createTestUser(t, repo)
+		require.NoError(t, repo.SetUserStanding(ctx, other.ID, domain.StandingBanned))
+		got, err := repo.GetUserByID(ctx, other.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.StandingBanned, got.Standing)
+
+		assert.ErrorIs(t, repo.SetUserStanding(ctx, uuid.New(), domain.StandingBanned), domain.ErrNotFound)
+	})
+}
+
+func TestIntegrationAdmin(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	t.Run("search users", func(t *testing.T) {
+		user := createTestUser(t, repo)
+		_, err := testDB.Exec(`UPDATE users SET role = 'moderator', standing = 'shadow_banned' WHERE id = $1`, user.ID)
+		require.NoError(t, err)
+
+		users, total, err := repo.SearchUsers(ctx, domain.UserSearchQuery{Query: user.Username[3:], Page: 1, Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, users, 1)
+		assert.Equal(t, user.ID, users[0].ID)
+		assert.Equal(t, domain.RoleModerator, users[0].Role)
+		assert.Equal(t, domain.StandingShadowBanned, users[0].Standing)
+
+		_, total, err = repo.SearchUsers(ctx, domain.UserSearchQuery{Query: user.Username, Role: domain.RoleAdmin, Page: 1, Limit: 10})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+
+		// LIKE wildcards in the query are matched literally.
+		_, total, err = repo.SearchUsers(ctx, domain.UserSearchQuery{Query: "it%" + user.Username[3:], Page: 1, Limit: 10})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+	})
+
+	t.Run("platform stats and purge", func(t *testing.T) {
+		since := time.Now().UTC().Add(-time.Hour)
+		before, err := repo.GetPlatformStats(ctx, since)
+		require.NoError(t, err)
+
+		creator := createTestUser(t, repo)
+		poll := createTestPoll(t, repo, creator, nil)
+		castTestVote(t, repo, poll, creator, 0, time.Now().UTC())
+
+		after, err := repo.GetPlatformStats(ctx, since)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, after.Users, before.Users+1)
+		assert.GreaterOrEqual(t, after.Polls, before.Polls+1)
+		assert.GreaterOrEqual(t, after.Votes, before.Votes+1)
+		assert.GreaterOrEqual(t, after.VotesLast24h, before.VotesLast24h+1)
+
+		_, err = repo.ApplyAnalyticsDelta(ctx, domain.AnalyticsDelta{
+			EventKey: uuid.NewString(), PollID: poll.ID, UserID: creator.ID, At: time.Now().UTC(), Votes: 1,
+		})
+		require.NoError(t, err)
+		require.NoError(t, repo.PurgePoll(ctx, poll.ID))
+
+		_, err = repo.GetPollByID(ctx, poll.ID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		voted, err := repo.HasVoted(ctx, poll.ID, creator.ID)
+		require.NoError(t, err)
+		assert.False(t, voted)
+		buckets, err := repo.GetPollHourlyVotes(ctx, poll.ID, time.Now().Add(-48*time.Hour), time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, buckets)
+
+		assert.ErrorIs(t, repo.PurgePoll(ctx, poll.ID), domain.ErrNotFound)
+	})
+}
+
+func TestIntegrationEmailVerification(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	user := createTestUser(t, repo)
+	now := time.Now().UTC()
+
+	expired := &domain.EmailVerificationToken{UserID: user.ID, TokenHash: uniqueName("expired"), ExpiresAt: now.Add(-time.Minute), CreatedAt: now.Add(-time.Hour)}
+	first := &domain.EmailVerificationToken{UserID: user.ID, TokenHash: uniqueName("first"), ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+	second := &domain.EmailVerificationToken{UserID: user.ID, TokenHash: uniqueName("second"), ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+	for _, token := range []*domain.EmailVerificationToken{expired, first, second} {
+		require.NoError(t, repo.CreateEmailVerificationToken(ctx, token))
+	}
+
+	_, err := repo.VerifyEmail(ctx, expired.TokenHash, now)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	userID, err := repo.VerifyEmail(ctx, first.TokenHash, now)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, userID)
+
+	got, err := repo.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, got.EmailVerified)
+
+	// Verifying drops the user's other outstanding tokens.
+	_, err = repo.VerifyEmail(ctx, second.TokenHash, now)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestIntegrationEmailChange(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	newChange := func(user *domain.User, email string) *domain.EmailChange {
+		return &domain.EmailChange{
+			ID:           uuid.New(),
+			UserID:       user.ID,
+			OldEmail:     user.Email,
+			NewEmail:     email,
+			Status:       domain.EmailChangePending,
+			OldTokenHash: uniqueName("old"),
+			NewTokenHash: uniqueName("new"),
+			ExpiresAt:    now.Add(time.Hour),
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+	}
+
+	t.Run("complete", func(t *testing.T) {
+		user := createTestUser(t, repo)
+		first := newChange(user, uniqueName("it_")+"@integration.test")
+		require.NoError(t, repo.CreateEmailChange(ctx, first))
+
+		// A new request cancels the one in progress.
+		change := newChange(user, uniqueName("it_")+"@integration.test")
+		require.NoError(t, repo.CreateEmailChange(ctx, change))
+		open, err := repo.GetOpenEmailChange(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, change.ID, open.ID)
+		cancelled, err := repo.GetEmailChangeByToken(ctx, first.OldTokenHash)
+		require.NoError(t, err)
+		assert.Equal(t, domain.EmailChangeCancelled, cancelled.Status)
+
+		byToken, err := repo.GetEmailChangeByToken(ctx, change.NewTokenHash)
+		require.NoError(t, err)
+		assert.Equal(t, change.ID, byToken.ID)
+		_, err = repo.GetEmailChangeByToken(ctx, uniqueName("unknown"))
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+
+		require.NoError(t, repo.SetEmailChangeStatus(ctx, change.ID, domain.EmailChangePending, domain.EmailChangeOldConfirmed, now))
+		assert.ErrorIs(t, repo.SetEmailChangeStatus(ctx, change.ID, domain.EmailChangePending, domain.EmailChangeOldConfirmed, now), domain.ErrNotFound)
+
+		token := &domain.RefreshToken{ID: uuid.New(), UserID: user.ID, TokenHash: uniqueName("refresh"), ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+		require.NoError(t, repo.CreateRefreshToken(ctx, token))
+
+		require.NoError(t, repo.CompleteEmailChange(ctx, change, domain.EmailChangeOldConfirmed, now))
+		got, err := repo.GetUserByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, change.NewEmail, got.Email)
+		assert.True(t, got.EmailVerified)
+		refresh, err := repo.GetRefreshToken(ctx, token.TokenHash)
+		require.NoError(t, err)
+		assert.NotNil(t, refresh.RevokedAt)
+
+		_, err = repo.GetOpenEmailChange(ctx, user.ID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.ErrorIs(t, repo.CompleteEmailChange(ctx, change, domain.EmailChangeOldConfirmed, now), domain.ErrNotFound)
+	})
+
+	t.Run("address taken rolls back", func(t *testing.T) {
+		user := createTestUser(t, repo)
+		taken := createTestUser(t, repo)
+		change := newChange(user, taken.Email)
+		require.NoError(t, repo.CreateEmailChange(ctx, change))
+		require.NoError(t, repo.SetEmailChangeStatus(ctx, change.ID, domain.EmailChangePending, domain.EmailChangeNewConfirmed, now))
+
+		err := repo.CompleteEmailChange(ctx, change, domain.EmailChangeNewConfirmed, now)
+		assert.ErrorIs(t, err, domain.ErrEmailAlreadyExists)
+
+		// The status change made before the failure was rolled back too.
+		open, err := repo.GetOpenEmailChange(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.EmailChangeNewConfirmed, open.Status)
+		got, err := repo.GetUserByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, user.Email, got.Email)
+	})
+}
+
+func TestIntegrationConsents(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	user := createTestUser(t, repo)
+	accepted := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	require.NoError(t, repo.RecordConsents(ctx, user.ID, []domain.Consent{
+		{Document: domain.ConsentTerms, Version: "1", AcceptedAt: accepted, IPAddress: "192.0.2.1", UserAgent: "test"},
+		{Document: domain.ConsentPrivacy, Version: "1", AcceptedAt: accepted},
+	}))
+	// Accepting the same version again keeps the first acceptance.
+	require.NoError(t, repo.RecordConsents(ctx, user.ID, []domain.Consent{
+		{Document: domain.ConsentTerms, Version: "1", AcceptedAt: accepted.Add(time.Hour)},
+		{Document: domain.ConsentTerms, Version: "2", AcceptedAt: accepted.Add(2 * time.Hour)},
+	}))
+
+	consents, err := repo.GetConsents(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, consents, 3)
+	assert.Equal(t, "2", consents[0].Version)
+	assert.Equal(t, domain.ConsentPrivacy, consents[1].Document)
+	assert.Equal(t, domain.ConsentTerms, consents[2].Document)
+	assert.True(t, accepted.Equal(consents[2].AcceptedAt))
+	assert.Equal(t, "192.0.2.1", consents[2].IPAddress)
+	assert.Equal(t, "test", consents[2].UserAgent)
+}
+
+func TestIntegrationPreferences(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	user := createTestUser(t, repo)
+	followed := uniqueName("tag")
+
+	prefs, err := repo.GetUserPreferences(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, prefs.FollowedTags)
+	assert.False(t, prefs.VoteReceipts)
+
+	require.NoError(t, repo.SetUserPreferences(ctx, user.ID, &domain.UserPreferences{
+		FollowedTags:  []string{followed},
+		MutedTags:     []string{"muted"},
+		MutedKeywords: []string{"spoiler"},
+		VoteReceipts:  true,
+	}))
+	prefs, err = repo.GetUserPreferences(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{followed}, prefs.FollowedTags)
+	assert.Equal(t, []string{"muted"}, prefs.MutedTags)
+	assert.Equal(t, []string{"spoiler"}, prefs.MutedKeywords)
+	assert.True(t, prefs.VoteReceipts)
+
+	followers, err := repo.GetTagFollowers(ctx, []string{followed})
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{user.ID}, followers)
+
+	// Setting preferences replaces the previous ones.
+	require.NoError(t, repo.SetUserPreferences(ctx, user.ID, &domain.UserPreferences{}))
+	followers, err = repo.GetTagFollowers(ctx, []string{followed})
+	require.NoError(t, err)
+	assert.Empty(t, followers)
+}
+
+func TestIntegrationQuotas(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	user := createTestUser(t, repo)
+	day := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+
+	quotas, err := repo.GetUserQuotas(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, quotas)
+	_, err = testDB.Exec(`INSERT INTO user_quotas (user_id, action, period, quota_limit) VALUES ($1, $2, $3, 5)`,
+		user.ID, domain.QuotaVotesCast, domain.QuotaDaily)
+	require.NoError(t, err)
+	quotas, err = repo.GetUserQuotas(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Quota{{Action: domain.QuotaVotesCast, Period: domain.QuotaDaily, Limit: 5}}, quotas)
+
+	used, err := repo.GetQuotaUsage(ctx, user.ID, domain.QuotaVotesCast, domain.QuotaDaily, day)
+	require.NoError(t, err)
+	assert.Zero(t, used)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, repo.IncrementQuotaUsage(ctx, user.ID, domain.QuotaVotesCast, domain.QuotaDaily, day))
+	}
+	used, err = repo.GetQuotaUsage(ctx, user.ID, domain.QuotaVotesCast, domain.QuotaDaily, day)
+	require.NoError(t, err)
+	assert.Equal(t, 2, used)
+	used, err = repo.GetQuotaUsage(ctx, user.ID, domain.QuotaVotesCast, domain.QuotaDaily, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Zero(t, used)
+}
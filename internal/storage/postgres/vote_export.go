@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+)
+
+// GetPollVotesAfter returns up to limit of the poll's votes that come after
+// the given key, oldest first. Paging by key rather than OFFSET keeps every
+// page of a large export as cheap as the first.
+func (r *Repository) GetPollVotesAfter(ctx context.Context, pollID uuid.UUID, after *domain.VoteKey, limit int) ([]domain.Vote, error) {
+	query := `
+		SELECT v.id, v.poll_id, v.user_id, v.option_id, v.created_at,
+			   po.option_text, po.option_index
+		FROM votes v
+		JOIN poll_options po ON po.id = v.option_id
+		WHERE v.poll_id = $1`
+	args := []interface{}{pollID}
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		query += `
+		AND (v.created_at, v.id) > ($2, $3)`
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(`
+		ORDER BY v.created_at, v.id
+		LIMIT $%d`, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get poll votes: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	votes := make([]domain.Vote, 0, limit)
+	for rows.Next() {
+		var vote domain.Vote
+		err := rows.Scan(
+			&vote.ID, &vote.PollID, &vote.UserID, &vote.OptionID, &vote.CreatedAt,
+			&vote.OptionText, &vote.OptionIndex,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan poll vote: %w", err)
+		}
+		votes = append(votes, vote)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate poll votes: %w", err)
+	}
+	return votes, nil
+}
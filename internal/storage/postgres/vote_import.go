@@ -0,0 +1,212 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// ImportVotes stores a batch of historical votes in one transaction, along
+// with the daily stats and analytics counters the votes would have produced
+// had they been cast here. Closed polls lose their result snapshot so it is
+// taken again with the imported votes.
+func (r *Repository) ImportVotes(ctx context.Context, votes []domain.Vote) (*domain.VoteImportBatch, error) {
+	batch := &domain.VoteImportBatch{}
+	if len(votes) == 0 {
+		return batch, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				r.logger.Error("Failed to rollback transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	voterIDs := make([]string, len(votes))
+	pollIDs := make([]string, len(votes))
+	for i, vote := range votes {
+		voterIDs[i] = vote.UserID.String()
+		pollIDs[i] = vote.PollID.String()
+	}
+
+	known, err := r.queryUUIDSet(ctx, tx, `SELECT id FROM users WHERE id = ANY($1::uuid[])`, pq.Array(voterIDs))
+	if err != nil {
+		return nil, fmt.Errorf("get voters: %w", err)
+	}
+
+	type voterKey struct{ pollID, userID uuid.UUID }
+	voted := make(map[voterKey]bool)
+	rows, err := tx.QueryContext(ctx, `
+		SELECT v.poll_id, v.user_id
+		FROM votes v
+		JOIN unnest($1::uuid[], $2::uuid[]) AS i(poll_id, user_id)
+			ON v.poll_id = i.poll_id AND v.user_id = i.user_id`,
+		pq.Array(pollIDs), pq.Array(voterIDs))
+	if err != nil {
+		return nil, fmt.Errorf("get existing votes: %w", err)
+	}
+	for rows.Next() {
+		var key voterKey
+		if err := rows.Scan(&key.pollID, &key.userID); err != nil {
+			closeRows(rows, r.logger)
+			return nil, fmt.Errorf("scan existing vote: %w", err)
+		}
+		voted[key] = true
+	}
+	closeRows(rows, r.logger)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate existing votes: %w", err)
+	}
+
+	var ids, insertPolls, insertUsers, insertOptions, insertTimes []string
+	for _, vote := range votes {
+		switch {
+		case !known[vote.UserID]:
+			batch.UnknownVoters = append(batch.UnknownVoters, vote.ID)
+		case voted[voterKey{vote.PollID, vote.UserID}]:
+			batch.Duplicates = append(batch.Duplicates, vote.ID)
+		default:
+			ids = append(ids, vote.ID.String())
+			insertPolls = append(insertPolls, vote.PollID.String())
+			insertUsers = append(insertUsers, vote.UserID.String())
+			insertOptions = append(insertOptions, vote.OptionID.String())
+			insertTimes = append(insertTimes, vote.CreatedAt.UTC().Format(time.RFC3339Nano))
+		}
+	}
+	if len(ids) == 0 {
+		return batch, nil
+	}
+
+	// A vote cast since the check above is a duplicate too.
+	inserted, err := r.queryUUIDSet(ctx, tx, `
+		INSERT INTO votes (id, poll_id, user_id, option_id, created_at)
+		SELECT * FROM unnest($1::uuid[], $2::uuid[], $3::uuid[], $4::uuid[], $5::timestamptz[])
+		ON CONFLICT (poll_id, user_id) DO NOTHING
+		RETURNING id`,
+		pq.Array(ids), pq.Array(insertPolls), pq.Array(insertUsers), pq.Array(insertOptions), pq.Array(insertTimes))
+	if err != nil {
+		return nil, fmt.Errorf("insert votes: %w", err)
+	}
+	for _, vote := range votes {
+		if known[vote.UserID] && !voted[voterKey{vote.PollID, vote.UserID}] && !inserted[vote.ID] {
+			batch.Duplicates = append(batch.Duplicates, vote.ID)
+		}
+	}
+	batch.Imported = len(inserted)
+	if batch.Imported == 0 {
+		return batch, nil
+	}
+
+	insertedIDs := make([]string, 0, len(inserted))
+	for id := range inserted {
+		insertedIDs = append(insertedIDs, id.String())
+	}
+	if err := updateImportedVoteCounters(ctx, tx, pq.Array(insertedIDs)); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
+
+	touched := make(map[uuid.UUID]bool)
+	for _, vote := range votes {
+		if inserted[vote.ID] && !touched[vote.PollID] {
+			touched[vote.PollID] = true
+			r.invalidateCachedPoll(ctx, vote.PollID)
+		}
+	}
+	return batch, nil
+}
+
+// updateImportedVoteCounters adds the votes with the given IDs to the option
+// counts, the daily stats rollups and the analytics projections, bucketed in
+// UTC.
+func updateImportedVoteCounters(ctx context.Context, tx *sql.Tx, ids interface{}) error {
+	statements := []struct {
+		name  string
+		query string
+	}{
+		{"poll stats", `
+			INSERT INTO poll_stats_daily (poll_id, option_id, stat_date, vote_count, updated_at)
+			SELECT poll_id, option_id, (created_at AT TIME ZONE 'UTC')::date, COUNT(*), NOW()
+			FROM votes WHERE id = ANY($1::uuid[])
+			GROUP BY 1, 2, 3
+			ON CONFLICT (poll_id, option_id, stat_date) DO UPDATE
+			SET vote_count = poll_stats_daily.vote_count + EXCLUDED.vote_count,
+				updated_at = EXCLUDED.updated_at`},
+		{"tag daily votes", `
+			INSERT INTO analytics_tag_daily_votes (tag, day, votes)
+			SELECT pt.tag, (v.created_at AT TIME ZONE 'UTC')::date, COUNT(*)
+			FROM votes v
+			JOIN poll_tags pt ON pt.poll_id = v.poll_id
+			WHERE v.id = ANY($1::uuid[])
+			GROUP BY 1, 2
+			ON CONFLICT (tag, day) DO UPDATE
+			SET votes = analytics_tag_daily_votes.votes + EXCLUDED.votes`},
+		{"poll hourly votes", `
+			INSERT INTO analytics_poll_hourly_votes (poll_id, hour, votes)
+			SELECT poll_id, date_trunc('hour', created_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC', COUNT(*)
+			FROM votes WHERE id = ANY($1::uuid[])
+			GROUP BY 1, 2
+			ON CONFLICT (poll_id, hour) DO UPDATE
+			SET votes = analytics_poll_hourly_votes.votes + EXCLUDED.votes`},
+		{"user activity", `
+			INSERT INTO analytics_user_activity (user_id, day, votes)
+			SELECT user_id, (created_at AT TIME ZONE 'UTC')::date, COUNT(*)
+			FROM votes WHERE id = ANY($1::uuid[])
+			GROUP BY 1, 2
+			ON CONFLICT (user_id, day) DO UPDATE
+			SET votes = analytics_user_activity.votes + EXCLUDED.votes`},
+		{"option counts", `
+			INSERT INTO poll_option_counts (option_id, poll_id, votes)
+			SELECT v.option_id, v.poll_id, COUNT(*)
+			FROM votes v
+			WHERE v.id = ANY($1::uuid[]) AND NOT ` + shadowBannedVoter + `
+			GROUP BY 1, 2
+			ON CONFLICT (option_id) DO UPDATE
+			SET votes = poll_option_counts.votes + EXCLUDED.votes`},
+		{"result snapshots", `
+			DELETE FROM poll_results
+			WHERE poll_id IN (SELECT poll_id FROM votes WHERE id = ANY($1::uuid[]))`},
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt.query, ids); err != nil {
+			return fmt.Errorf("update %s: %w", stmt.name, err)
+		}
+	}
+	return nil
+}
+
+func (r *Repository) queryUUIDSet(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) (map[uuid.UUID]bool, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows, r.logger)
+
+	set := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		set[id] = true
+	}
+	return set, rows.Err()
+}
@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/storage/cache"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// GetRecentVoteTimes returns when the user's votes since the given time were
+// cast, oldest first. The times are kept in a Redis sorted set scored by
+// timestamp; a missing set is rebuilt from the votes table, which is also
+// read directly while Redis is unreachable.
+func (r *Repository) GetRecentVoteTimes(ctx context.Context, userID uuid.UUID, since time.Time) ([]time.Time, error) {
+	exists, err := r.recentVotes.HasRecentVotes(ctx, userID)
+	if err != nil {
+		r.logger.Warn("Redis unavailable, reading recent votes from Postgres", zap.Error(err))
+		return voteTimes(r.queryRecentVotes(ctx, userID, since))
+	}
+	if exists {
+		return r.recentVotes.GetRecentVoteTimes(ctx, userID, since)
+	}
+	return voteTimes(r.loadRecentVotes(ctx, userID, since))
+}
+
+// ReserveRecentVote counts a vote against the user's rolling limit before it
+// is stored, returning domain.ErrDailyVoteLimitExceeded when the window is
+// full. The check and the increment are one Redis script, so concurrent
+// votes can't both take the last slot, and reserving the same vote ID twice
+// counts it once. While Redis is unreachable the votes table is counted
+// instead, which is not atomic but keeps voting available.
+func (r *Repository) ReserveRecentVote(ctx context.Context, userID, voteID uuid.UUID, at time.Time, window time.Duration, limit int) error {
+	since := at.Add(-window)
+	exists, err := r.recentVotes.HasRecentVotes(ctx, userID)
+	if err == nil && !exists {
+		_, err = r.loadRecentVotes(ctx, userID, since)
+	}
+	if err == nil {
+		err = r.recentVotes.ReserveRecentVote(ctx, userID, voteID, at, window, limit)
+	}
+	if err == nil || errors.Is(err, domain.ErrDailyVoteLimitExceeded) {
+		return err
+	}
+
+	r.logger.Warn("Redis unavailable, counting recent votes in Postgres", zap.Error(err))
+	votes, err := r.queryRecentVotes(ctx, userID, since)
+	if err != nil {
+		return err
+	}
+	if len(votes) >= limit {
+		return domain.ErrDailyVoteLimitExceeded
+	}
+	return nil
+}
+
+// ReleaseRecentVote gives back the reservation of a vote that was not stored.
+func (r *Repository) ReleaseRecentVote(ctx context.Context, userID, voteID uuid.UUID) error {
+	return r.recentVotes.ReleaseRecentVote(ctx, userID, voteID)
+}
+
+// ReconcileRecentVotes rewrites every cached vote window that disagrees with
+// the votes table, dropping reservations whose vote was never stored or was
+// deleted and adding votes Redis missed while it was down. It returns the
+// number of windows rewritten.
+func (r *Repository) ReconcileRecentVotes(ctx context.Context, window time.Duration) (int, error) {
+	since := time.Now().UTC().Add(-window)
+	fixed := 0
+	err := r.recentVotes.ScanRecentVoteUsers(ctx, func(userID uuid.UUID) error {
+		cached, err := r.recentVotes.GetRecentVoteIDs(ctx, userID, since)
+		if err != nil {
+			return err
+		}
+		stored, err := r.queryRecentVotes(ctx, userID, since)
+		if err != nil {
+			return err
+		}
+		if sameVotes(cached, stored) {
+			return nil
+		}
+		if err := r.recentVotes.ReplaceRecentVotes(ctx, userID, stored, window); err != nil {
+			return err
+		}
+		fixed++
+		return nil
+	})
+	return fixed, err
+}
+
+// loadRecentVotes reads the user's votes since the given time and caches them
+// as the user's window.
+func (r *Repository) loadRecentVotes(ctx context.Context, userID uuid.UUID, since time.Time) ([]cache.RecentVote, error) {
+	votes, err := r.queryRecentVotes(ctx, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.recentVotes.AddRecentVotes(ctx, userID, votes, time.Now().UTC().Sub(since)); err != nil {
+		return nil, err
+	}
+	return votes, nil
+}
+
+func (r *Repository) queryRecentVotes(ctx context.Context, userID uuid.UUID, since time.Time) ([]cache.RecentVote, error) {
+	query := `
+		SELECT id, created_at FROM votes
+		WHERE user_id = $1 AND created_at >= $2
+		ORDER BY created_at`
+	rows, err := r.db.QueryContext(ctx, query, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("load recent votes: %w", err)
+	}
+	defer closeRows(rows, r.logger)
+
+	votes := make([]cache.RecentVote, 0)
+	for rows.Next() {
+		var vote cache.RecentVote
+		if err := rows.Scan(&vote.ID, &vote.At); err != nil {
+			return nil, fmt.Errorf("scan recent vote: %w", err)
+		}
+		vote.At = vote.At.UTC()
+		votes = append(votes, vote)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate recent votes: %w", err)
+	}
+	return votes, nil
+}
+
+func voteTimes(votes []cache.RecentVote, err error) ([]time.Time, error) {
+	if err != nil {
+		return nil, err
+	}
+	times := make([]time.Time, 0, len(votes))
+	for _, vote := range votes {
+		times = append(times, vote.At)
+	}
+	return times, nil
+}
+
+func sameVotes(cached []string, stored []cache.RecentVote) bool {
+	if len(cached) != len(stored) {
+		return false
+	}
+	ids := make(map[string]bool, len(cached))
+	for _, id := range cached {
+		ids[id] = true
+	}
+	for _, vote := range stored {
+		if !ids[vote.ID.String()] {
+			return false
+		}
+	}
+	return true
+}
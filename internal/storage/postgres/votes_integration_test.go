@@ -0,0 +1,751 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/behzadon/vote/internal/events"
+	"github.com/behzadon/vote/internal/storage/cache"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestIntegrationVotes(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+
+	t.Run("create queues its event once", func(t *testing.T) {
+		poll := createTestPoll(t, repo, creator, nil)
+		voter := createTestUser(t, repo)
+		vote := castTestVote(t, repo, poll, voter, 0, time.Now().UTC())
+		assert.Equal(t, 1, countOutboxEvents(t, events.EventPollVoted, vote.ID))
+
+		again := &domain.Vote{ID: uuid.New(), PollID: poll.ID, UserID: voter.ID, OptionID: poll.Options[1].ID, CreatedAt: time.Now().UTC()}
+		assert.ErrorIs(t, repo.CreateVote(ctx, again), domain.ErrAlreadyVoted)
+		assert.Zero(t, countOutboxEvents(t, events.EventPollVoted, again.ID))
+
+		voted, err := repo.HasVoted(ctx, poll.ID, voter.ID)
+		require.NoError(t, err)
+		assert.True(t, voted)
+		voted, err = repo.HasVoted(ctx, poll.ID, creator.ID)
+		require.NoError(t, err)
+		assert.False(t, voted)
+
+		got, err := repo.GetVoteByID(ctx, vote.ID)
+		require.NoError(t, err)
+		assert.Equal(t, poll.Options[0].ID, got.OptionID)
+		_, err = repo.GetVoteByID(ctx, uuid.New())
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+
+		existing, err := repo.GetUserPollVote(ctx, poll.ID, voter.ID)
+		require.NoError(t, err)
+		assert.Equal(t, vote.ID, existing.ID)
+		assert.Equal(t, 0, existing.OptionIndex)
+		_, err = repo.GetUserPollVote(ctx, poll.ID, creator.ID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+
+		count, err := repo.CountVotes(ctx, poll.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("outbox count includes pending events", func(t *testing.T) {
+		poll := createTestPoll(t, repo, creator, nil)
+		voter := createTestUser(t, repo)
+		before, err := repo.CountOutboxEvents(ctx)
+		require.NoError(t, err)
+		castTestVote(t, repo, poll, voter, 0, time.Now().UTC())
+
+		after, err := repo.CountOutboxEvents(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, before+1, after)
+	})
+
+	t.Run("option counts follow votes", func(t *testing.T) {
+		poll := createTestPoll(t, repo, creator, nil)
+		counts := func() []int {
+			t.Helper()
+			stats, err := repo.GetPollStats(ctx, poll.ID)
+			require.NoError(t, err)
+			var got []int
+			for _, option := range stats.Votes {
+				got = append(got, option.Count)
+			}
+			return got
+		}
+		assert.Equal(t, []int{0, 0, 0}, counts())
+
+		first, second, leaving := createTestUser(t, repo), createTestUser(t, repo), createTestUser(t, repo)
+		vote := castTestVote(t, repo, poll, first, 0, time.Now().UTC())
+		castTestVote(t, repo, poll, second, 0, time.Now().UTC())
+		castTestVote(t, repo, poll, leaving, 2, time.Now().UTC())
+		assert.Equal(t, []int{2, 0, 1}, counts())
+
+		require.NoError(t, repo.UpdateVote(ctx, vote.ID, first.ID, poll.Options[1].ID))
+		assert.Equal(t, []int{1, 1, 1}, counts())
+
+		require.NoError(t, repo.SetUserStanding(ctx, second.ID, domain.StandingShadowBanned))
+		assert.Equal(t, []int{0, 1, 1}, counts())
+		require.NoError(t, repo.SetUserStanding(ctx, second.ID, domain.StandingBanned))
+		assert.Equal(t, []int{1, 1, 1}, counts())
+
+		require.NoError(t, repo.DeleteUser(ctx, leaving.ID))
+		assert.Equal(t, []int{1, 1, 0}, counts())
+
+		require.NoError(t, repo.DeleteVote(ctx, vote.ID, first.ID))
+		assert.Equal(t, []int{1, 0, 0}, counts())
+	})
+
+	t.Run("lifting a shadow ban counts options without a count row", func(t *testing.T) {
+		poll := createTestPoll(t, repo, creator, nil)
+		hidden := createTestUser(t, repo)
+		require.NoError(t, repo.SetUserStanding(ctx, hidden.ID, domain.StandingShadowBanned))
+		castTestVote(t, repo, poll, hidden, 1, time.Now().UTC())
+
+		var rows int
+		require.NoError(t, testDB.QueryRowContext(ctx, `SELECT count(*) FROM poll_option_counts WHERE poll_id = $1`, poll.ID).Scan(&rows))
+		require.Zero(t, rows)
+
+		require.NoError(t, repo.SetUserStanding(ctx, hidden.ID, domain.StandingActive))
+		stats, err := repo.GetPollStats(ctx, poll.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.OptionStats{{Option: "a", Count: 0}, {Option: "b", Count: 1}, {Option: "c", Count: 0}}, stats.Votes)
+	})
+
+	t.Run("update records history", func(t *testing.T) {
+		poll := createTestPoll(t, repo, creator, nil)
+		other := createTestPoll(t, repo, creator, nil)
+		voter := createTestUser(t, repo)
+		vote := castTestVote(t, repo, poll, voter, 0, time.Now().UTC())
+		require.NoError(t, repo.SetCachedPollStats(ctx, poll.ID, &domain.PollStats{PollID: poll.ID}))
+
+		changed, err := repo.GetLastVoteChange(ctx, vote.ID)
+		require.NoError(t, err)
+		assert.True(t, changed.IsZero())
+
+		assert.ErrorIs(t, repo.UpdateVote(ctx, vote.ID, creator.ID, poll.Options[1].ID), domain.ErrUnauthorized)
+		assert.ErrorIs(t, repo.UpdateVote(ctx, vote.ID, voter.ID, other.Options[1].ID), domain.ErrInvalidOption)
+		assert.ErrorIs(t, repo.UpdateVote(ctx, uuid.New(), voter.ID, poll.Options[1].ID), domain.ErrNotFound)
+
+		before := time.Now().UTC()
+		require.NoError(t, repo.UpdateVote(ctx, vote.ID, voter.ID, poll.Options[1].ID))
+		changed, err = repo.GetLastVoteChange(ctx, vote.ID)
+		require.NoError(t, err)
+		assert.WithinDuration(t, before, changed, time.Minute)
+
+		_, err = repo.GetCachedPollStats(ctx, poll.ID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		stats, err := repo.GetPollStats(ctx, poll.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.OptionStats{{Option: "a", Count: 0}, {Option: "b", Count: 1}, {Option: "c", Count: 0}}, stats.Votes)
+		assert.Nil(t, stats.Turnout)
+		assert.Nil(t, stats.OrganizationVotes)
+	})
+
+	t.Run("delete keeps the vote in the history", func(t *testing.T) {
+		poll := createTestPoll(t, repo, creator, nil)
+		voter := createTestUser(t, repo)
+		vote := castTestVote(t, repo, poll, voter, 2, time.Now().UTC())
+
+		assert.ErrorIs(t, repo.DeleteVote(ctx, vote.ID, creator.ID), domain.ErrUnauthorized)
+		require.NoError(t, repo.DeleteVote(ctx, vote.ID, voter.ID))
+		assert.ErrorIs(t, repo.DeleteVote(ctx, vote.ID, voter.ID), domain.ErrUnauthorized)
+
+		voted, err := repo.HasVoted(ctx, poll.ID, voter.ID)
+		require.NoError(t, err)
+		assert.False(t, voted)
+
+		votes, total, err := repo.GetUserVotes(ctx, voter.ID, domain.VoteFilter{}, 1, 10)
+		require.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Empty(t, votes)
+
+		votes, total, err = repo.GetUserVotes(ctx, voter.ID, domain.VoteFilter{IncludeDeleted: true}, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, votes, 1)
+		assert.Equal(t, vote.ID, votes[0].ID)
+		assert.NotNil(t, votes[0].DeletedAt)
+		assert.Equal(t, "c", votes[0].OptionText)
+		assert.Equal(t, poll.Title, votes[0].PollTitle)
+	})
+}
+
+func TestIntegrationUserVotes(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+	voter := createTestUser(t, repo)
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var votes []*domain.Vote
+	for i := 0; i < 3; i++ {
+		poll := createTestPoll(t, repo, creator, nil)
+		votes = append(votes, castTestVote(t, repo, poll, voter, i, start.Add(time.Duration(i)*24*time.Hour)))
+	}
+
+	page, total, err := repo.GetUserVotes(ctx, voter.ID, domain.VoteFilter{}, 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, page, 1)
+	assert.Equal(t, votes[0].ID, page[0].ID)
+
+	from, to := start.Add(time.Hour), start.Add(48*time.Hour)
+	page, total, err = repo.GetUserVotes(ctx, voter.ID, domain.VoteFilter{From: &from, To: &to}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, page, 1)
+	assert.Equal(t, votes[1].ID, page[0].ID)
+
+	var streamed []uuid.UUID
+	err = repo.StreamUserVotes(ctx, voter.ID, domain.VoteFilter{}, func(vote *domain.Vote) error {
+		streamed = append(streamed, vote.ID)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{votes[2].ID, votes[1].ID, votes[0].ID}, streamed)
+
+	errStop := errors.New("stop")
+	calls := 0
+	err = repo.StreamUserVotes(ctx, voter.ID, domain.VoteFilter{}, func(*domain.Vote) error {
+		calls++
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIntegrationPollVotesAfter(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+	poll := createTestPoll(t, repo, creator, nil)
+
+	// Votes cast at the same instant are ordered by ID.
+	at := time.Now().UTC().Truncate(time.Microsecond)
+	for i := 0; i < 5; i++ {
+		castTestVote(t, repo, poll, createTestUser(t, repo), i%3, at)
+	}
+
+	var all []domain.Vote
+	var after *domain.VoteKey
+	for {
+		page, err := repo.GetPollVotesAfter(ctx, poll.ID, after, 2)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		all = append(all, page...)
+		last := page[len(page)-1]
+		after = &domain.VoteKey{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	require.Len(t, all, 5)
+	seen := make(map[uuid.UUID]bool)
+	for i, vote := range all {
+		assert.False(t, seen[vote.ID])
+		seen[vote.ID] = true
+		if i > 0 {
+			assert.Less(t, all[i-1].ID.String(), vote.ID.String())
+		}
+		assert.Equal(t, poll.Options[vote.OptionIndex].OptionText, vote.OptionText)
+	}
+}
+
+func TestIntegrationPollStats(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+
+	t.Run("shadow-banned voters are not counted", func(t *testing.T) {
+		poll := createTestPoll(t, repo, creator, nil)
+		since := time.Now().UTC().Add(-time.Minute)
+		castTestVote(t, repo, poll, createTestUser(t, repo), 0, time.Now().UTC())
+		banned := createTestUser(t, repo)
+		castTestVote(t, repo, poll, banned, 1, time.Now().UTC())
+		require.NoError(t, repo.SetUserStanding(ctx, banned.ID, domain.StandingShadowBanned))
+
+		stats, err := repo.GetPollStats(ctx, poll.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.OptionStats{{Option: "a", Count: 1}, {Option: "b", Count: 0}, {Option: "c", Count: 0}}, stats.Votes)
+
+		count, err := repo.CountVotes(ctx, poll.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		last, err := repo.GetLastVoteTimes(ctx, poll.ID)
+		require.NoError(t, err)
+		assert.NotContains(t, last, "b")
+
+		trending, err := repo.GetTrendingPolls(ctx, since, 1000)
+		require.NoError(t, err)
+		for _, p := range trending {
+			if p.PollID == poll.ID {
+				assert.Equal(t, 1, p.VoteCount)
+			}
+		}
+	})
+
+	t.Run("list electorate", func(t *testing.T) {
+		voter, outsider := createTestUser(t, repo), createTestUser(t, repo)
+		poll := createTestPoll(t, repo, creator, func(p *domain.Poll) {
+			p.Electorate = domain.ElectorateList
+			p.EligibleEmails = []string{voter.Email, "someone@integration.test"}
+		})
+
+		eligible, err := repo.IsEligibleVoter(ctx, poll.ID, voter.ID)
+		require.NoError(t, err)
+		assert.True(t, eligible)
+		eligible, err = repo.IsEligibleVoter(ctx, poll.ID, outsider.ID)
+		require.NoError(t, err)
+		assert.False(t, eligible)
+
+		castTestVote(t, repo, poll, voter, 0, time.Now().UTC())
+		stats, err := repo.GetPollStats(ctx, poll.ID)
+		require.NoError(t, err)
+		assert.Equal(t, &domain.Turnout{Voted: 1, Eligible: 2}, stats.Turnout)
+	})
+
+	t.Run("open electorate", func(t *testing.T) {
+		poll := createTestPoll(t, repo, creator, nil)
+		eligible, err := repo.IsEligibleVoter(ctx, poll.ID, createTestUser(t, repo).ID)
+		require.NoError(t, err)
+		assert.True(t, eligible)
+	})
+}
+
+func TestIntegrationOrganizations(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	owner := createTestUser(t, repo)
+	member := createTestUser(t, repo)
+	outsider := createTestUser(t, repo)
+
+	org := &domain.Organization{ID: uuid.New(), Name: uniqueName("org"), CreatedAt: time.Now().UTC()}
+	require.NoError(t, repo.CreateOrganization(ctx, org, owner.ID))
+	role, err := repo.GetOrganizationRole(ctx, org.ID, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OrganizationAdmin, role)
+
+	addMember := func(role domain.OrganizationRole) {
+		require.NoError(t, repo.AddOrganizationMember(ctx, &domain.Membership{
+			OrganizationID: org.ID, UserID: member.ID, Role: role, CreatedAt: time.Now().UTC(),
+		}))
+	}
+	addMember(domain.OrganizationAdmin)
+	// Adding an existing member changes their role.
+	addMember(domain.OrganizationMember)
+	role, err = repo.GetOrganizationRole(ctx, org.ID, member.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OrganizationMember, role)
+	_, err = repo.GetOrganizationRole(ctx, org.ID, outsider.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	poll := createTestPoll(t, repo, owner, func(p *domain.Poll) {
+		p.Electorate = domain.ElectorateOrganization
+		p.OrganizationID = &org.ID
+		p.OrganizationVotes = true
+	})
+	eligible, err := repo.IsEligibleVoter(ctx, poll.ID, member.ID)
+	require.NoError(t, err)
+	assert.True(t, eligible)
+	eligible, err = repo.IsEligibleVoter(ctx, poll.ID, outsider.ID)
+	require.NoError(t, err)
+	assert.False(t, eligible)
+
+	castTestVote(t, repo, poll, member, 0, time.Now().UTC())
+
+	_, err = repo.GetOrganizationVote(ctx, poll.ID, org.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	now := time.Now().UTC()
+	vote := &domain.OrganizationVote{
+		ID: uuid.New(), PollID: poll.ID, OrganizationID: org.ID, OptionID: poll.Options[2].ID, CastBy: &owner.ID, CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, repo.CreateOrganizationVote(ctx, vote))
+	duplicate := *vote
+	duplicate.ID = uuid.New()
+	assert.ErrorIs(t, repo.CreateOrganizationVote(ctx, &duplicate), domain.ErrAlreadyVoted)
+
+	vote.OptionID = poll.Options[1].ID
+	vote.CastBy = &member.ID
+	vote.UpdatedAt = time.Now().UTC()
+	require.NoError(t, repo.UpdateOrganizationVote(ctx, vote))
+	assert.ErrorIs(t, repo.UpdateOrganizationVote(ctx, &duplicate), domain.ErrNotFound)
+
+	got, err := repo.GetOrganizationVote(ctx, poll.ID, org.ID)
+	require.NoError(t, err)
+	assert.Equal(t, vote.ID, got.ID)
+	assert.Equal(t, "b", got.OptionText)
+	require.NotNil(t, got.CastBy)
+	assert.Equal(t, member.ID, *got.CastBy)
+
+	stats, err := repo.GetPollStats(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &domain.Turnout{Voted: 1, Eligible: 2}, stats.Turnout)
+	assert.Equal(t, []domain.OptionStats{{Option: "a", Count: 0}, {Option: "b", Count: 1}, {Option: "c", Count: 0}}, stats.OrganizationVotes)
+
+	// Each organization's vote counts once, whoever cast it.
+	other := &domain.Organization{ID: uuid.New(), Name: uniqueName("org"), CreatedAt: time.Now().UTC()}
+	require.NoError(t, repo.CreateOrganization(ctx, other, outsider.ID))
+	require.NoError(t, repo.CreateOrganizationVote(ctx, &domain.OrganizationVote{
+		ID: uuid.New(), PollID: poll.ID, OrganizationID: other.ID, OptionID: poll.Options[1].ID, CastBy: &outsider.ID, CreatedAt: now, UpdatedAt: now,
+	}))
+	counts, err := repo.GetOrganizationVoteStats(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.OptionStats{{Option: "a", Count: 0}, {Option: "b", Count: 2}, {Option: "c", Count: 0}}, counts)
+
+	counts, err = repo.GetOrganizationVoteStats(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+}
+
+func TestIntegrationDailyVoteCounts(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	user := createTestUser(t, repo)
+	day := time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC)
+
+	count, err := repo.GetUserDailyVoteCount(ctx, user.ID, day)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	require.NoError(t, repo.IncrementUserDailyVoteCount(ctx, user.ID, day))
+	require.NoError(t, repo.IncrementUserDailyVoteCount(ctx, user.ID, day))
+	count, err = repo.GetUserDailyVoteCount(ctx, user.ID, day)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	pruned, err := repo.PruneUserDailyVotes(ctx, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, pruned, int64(1))
+	count, err = repo.GetUserDailyVoteCount(ctx, user.ID, day)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestIntegrationRecentVoteTimes(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+	voter := createTestUser(t, repo)
+
+	now := time.Now().UTC()
+	older := castTestVote(t, repo, createTestPoll(t, repo, creator, nil), voter, 0, now.Add(-2*time.Hour))
+	newer := castTestVote(t, repo, createTestPoll(t, repo, creator, nil), voter, 0, now.Add(-time.Hour))
+
+	// A miss is filled from the votes table.
+	times, err := repo.GetRecentVoteTimes(ctx, voter.ID, now.Add(-3*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, times, 2)
+	assert.WithinDuration(t, older.CreatedAt, times[0], time.Microsecond)
+	assert.WithinDuration(t, newer.CreatedAt, times[1], time.Microsecond)
+	exists, err := testRedis.Exists(ctx, cache.UserRecentVotesKey(voter.ID)).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), exists)
+
+	// Reserving a vote drops the ones that fell out of the window.
+	reserved := uuid.New()
+	require.NoError(t, repo.ReserveRecentVote(ctx, voter.ID, reserved, now, 90*time.Minute, 3))
+	times, err = repo.GetRecentVoteTimes(ctx, voter.ID, now.Add(-3*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, times, 2)
+	assert.WithinDuration(t, newer.CreatedAt, times[0], time.Microsecond)
+	assert.WithinDuration(t, now, times[1], time.Microsecond)
+
+	// A replayed reservation is not counted twice; a new one over the limit is
+	// refused.
+	require.NoError(t, repo.ReserveRecentVote(ctx, voter.ID, reserved, now, 90*time.Minute, 2))
+	assert.ErrorIs(t, repo.ReserveRecentVote(ctx, voter.ID, uuid.New(), now, 90*time.Minute, 2), domain.ErrDailyVoteLimitExceeded)
+
+	// Reconciling drops the reservation that never became a vote and keeps
+	// the stored votes still in the window.
+	fixed, err := repo.ReconcileRecentVotes(ctx, 90*time.Minute)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, fixed, 1)
+	times, err = repo.GetRecentVoteTimes(ctx, voter.ID, now.Add(-3*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, times, 1)
+	assert.WithinDuration(t, newer.CreatedAt, times[0], time.Microsecond)
+
+	require.NoError(t, repo.ReserveRecentVote(ctx, voter.ID, reserved, now, 90*time.Minute, 3))
+	require.NoError(t, repo.ReleaseRecentVote(ctx, voter.ID, reserved))
+	times, err = repo.GetRecentVoteTimes(ctx, voter.ID, now.Add(-3*time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, times, 1)
+}
+
+func TestIntegrationRecentVotesWithoutRedis(t *testing.T) {
+	ctx := context.Background()
+	up := newTestRepository(t)
+	creator := createTestUser(t, up)
+	voter := createTestUser(t, up)
+	now := time.Now().UTC()
+	castTestVote(t, up, createTestPoll(t, up, creator, nil), voter, 0, now.Add(-time.Hour))
+
+	down := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	t.Cleanup(func() { _ = down.Close() })
+	repo := NewRepository(testDB, down, zap.NewNop())
+
+	times, err := repo.GetRecentVoteTimes(ctx, voter.ID, now.Add(-domain.DailyVoteWindow))
+	require.NoError(t, err)
+	assert.Len(t, times, 1)
+	require.NoError(t, repo.ReserveRecentVote(ctx, voter.ID, uuid.New(), now, domain.DailyVoteWindow, 2))
+	assert.ErrorIs(t, repo.ReserveRecentVote(ctx, voter.ID, uuid.New(), now, domain.DailyVoteWindow, 1), domain.ErrDailyVoteLimitExceeded)
+}
+
+func TestIntegrationStatsMaintenance(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+	poll := createTestPoll(t, repo, creator, nil)
+
+	day := time.Date(2002, 3, 4, 0, 0, 0, 0, time.UTC)
+	castTestVote(t, repo, poll, createTestUser(t, repo), 0, day.Add(time.Hour))
+	castTestVote(t, repo, poll, createTestUser(t, repo), 0, day.Add(2*time.Hour))
+	castTestVote(t, repo, poll, createTestUser(t, repo), 1, day.Add(3*time.Hour))
+	castTestVote(t, repo, poll, createTestUser(t, repo), 1, day.Add(25*time.Hour))
+
+	rows, err := repo.RollupPollStats(ctx, day)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, rows, int64(2))
+
+	// The next day was never rolled up.
+	discrepancies, err := repo.RepairPollStatsDaily(ctx, poll.ID)
+	require.NoError(t, err)
+	require.Len(t, discrepancies, 1)
+	assert.Equal(t, "b", discrepancies[0].Option)
+	assert.True(t, day.AddDate(0, 0, 1).Equal(*discrepancies[0].Day))
+	assert.Equal(t, 1, discrepancies[0].Expected)
+	assert.Zero(t, discrepancies[0].Found)
+
+	_, err = testDB.Exec(`UPDATE poll_stats_daily SET vote_count = 5 WHERE option_id = $1 AND stat_date = $2`, poll.Options[0].ID, day)
+	require.NoError(t, err)
+	discrepancies, err = repo.RepairPollStatsDaily(ctx, poll.ID)
+	require.NoError(t, err)
+	require.Len(t, discrepancies, 1)
+	assert.Equal(t, domain.StatsDiscrepancy{Source: domain.StatsSourceDailyRollup, Option: "a", Day: discrepancies[0].Day, Expected: 2, Found: 5}, discrepancies[0])
+
+	discrepancies, err = repo.RepairPollStatsDaily(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Empty(t, discrepancies)
+}
+
+func TestIntegrationActivity(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	creator := createTestUser(t, repo)
+	banned := createTestUser(t, repo)
+	busy := createTestPoll(t, repo, creator, nil)
+	quiet := createTestPoll(t, repo, creator, nil)
+	hidden := createTestPoll(t, repo, banned, nil)
+	require.NoError(t, repo.SetUserStanding(ctx, banned.ID, domain.StandingShadowBanned))
+
+	since := time.Now().UTC().Add(-time.Second)
+	voters := []*domain.User{createTestUser(t, repo), createTestUser(t, repo)}
+	castTestVote(t, repo, busy, voters[0], 0, time.Now().UTC())
+	castTestVote(t, repo, busy, voters[1], 1, time.Now().UTC())
+	castTestVote(t, repo, hidden, voters[0], 0, time.Now().UTC())
+	castTestVote(t, repo, quiet, voters[1], 0, time.Now().UTC())
+
+	trending, err := repo.GetTrendingPolls(ctx, since, 1000)
+	require.NoError(t, err)
+	position := make(map[uuid.UUID]int)
+	for i, p := range trending {
+		position[p.PollID] = i
+	}
+	require.Contains(t, position, busy.ID)
+	require.Contains(t, position, quiet.ID)
+	assert.NotContains(t, position, hidden.ID)
+	assert.Less(t, position[busy.ID], position[quiet.ID])
+	assert.Equal(t, 2, trending[position[busy.ID]].VoteCount)
+
+	require.NoError(t, repo.SetCachedTrendingPolls(ctx, trending))
+	data, err := testRedis.Get(ctx, cache.TrendingPollsKey).Bytes()
+	require.NoError(t, err)
+	var cached []domain.TrendingPoll
+	require.NoError(t, json.Unmarshal(data, &cached))
+	assert.Equal(t, trending, cached)
+
+	active, err := repo.GetRecentlyActivePollIDs(ctx, since, 1000)
+	require.NoError(t, err)
+	assert.Subset(t, active, []uuid.UUID{busy.ID, quiet.ID, hidden.ID})
+	users, err := repo.GetActiveUserIDs(ctx, since)
+	require.NoError(t, err)
+	assert.Subset(t, users, []uuid.UUID{voters[0].ID, voters[1].ID})
+	assert.NotContains(t, users, creator.ID)
+}
+
+func TestIntegrationAnalytics(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	user := createTestUser(t, repo)
+	poll := createTestPoll(t, repo, user, nil)
+	tag := uniqueName("tag")
+	at := time.Date(2024, 6, 1, 10, 30, 0, 0, time.UTC)
+	day := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	apply := func(key string, delta domain.AnalyticsDelta) bool {
+		delta.EventKey = key
+		delta.PollID, delta.UserID = poll.ID, user.ID
+		applied, err := repo.ApplyAnalyticsDelta(ctx, delta)
+		require.NoError(t, err)
+		return applied
+	}
+	first := uniqueName("event")
+	assert.True(t, apply(first, domain.AnalyticsDelta{Tags: []string{tag}, At: at, Votes: 1}))
+	// A redelivered event is applied once.
+	assert.False(t, apply(first, domain.AnalyticsDelta{Tags: []string{tag}, At: at, Votes: 1}))
+	assert.True(t, apply(uniqueName("event"), domain.AnalyticsDelta{Tags: []string{tag}, At: at.Add(time.Hour), Votes: 1}))
+	assert.True(t, apply(uniqueName("event"), domain.AnalyticsDelta{At: at, Skips: 1, PollsCreated: 1}))
+
+	tagVotes, err := repo.GetTagDailyVotes(ctx, tag, day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, tagVotes, 1)
+	assert.True(t, day.Equal(tagVotes[0].Start))
+	assert.Equal(t, 2, tagVotes[0].Votes)
+
+	hourly, err := repo.GetPollHourlyVotes(ctx, poll.ID, day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, hourly, 2)
+	assert.True(t, at.Truncate(time.Hour).Equal(hourly[0].Start))
+	assert.Equal(t, 1, hourly[0].Votes)
+	assert.True(t, at.Add(time.Hour).Truncate(time.Hour).Equal(hourly[1].Start))
+
+	activity, err := repo.GetUserActivity(ctx, user.ID, day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, activity, 1)
+	assert.Equal(t, 2, activity[0].Votes)
+	assert.Equal(t, 1, activity[0].Skips)
+	assert.Equal(t, 1, activity[0].PollsCreated)
+}
+
+func TestIntegrationBusinessMetrics(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	user := createTestUser(t, repo)
+	now := time.Now().UTC()
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+
+	open, err := repo.CountOpenPolls(ctx, now)
+	require.NoError(t, err)
+	createTestPoll(t, repo, user, func(p *domain.Poll) { p.EndsAt = &later })
+	createTestPoll(t, repo, user, func(p *domain.Poll) { p.EndsAt = &earlier })
+	createTestPoll(t, repo, user, func(p *domain.Poll) { p.Status, p.StartsAt = domain.PollStatusScheduled, &later })
+	createTestPoll(t, repo, user, func(p *domain.Poll) { p.Status = domain.PollStatusDraft })
+	got, err := repo.CountOpenPolls(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, open+1, got)
+
+	day := time.Date(2031, 3, 7, 12, 0, 0, 0, time.UTC)
+	voters, err := repo.CountActiveVoters(ctx, day)
+	require.NoError(t, err)
+	for _, delta := range []domain.AnalyticsDelta{
+		{UserID: user.ID, Votes: 1},
+		{UserID: user.ID, Votes: 1},
+		{UserID: createTestUser(t, repo).ID, Skips: 1},
+		{UserID: createTestUser(t, repo).ID, Votes: 1, At: day.AddDate(0, 0, 1)},
+	} {
+		delta.EventKey = uniqueName("event")
+		if delta.At.IsZero() {
+			delta.At = day
+		}
+		_, err := repo.ApplyAnalyticsDelta(ctx, delta)
+		require.NoError(t, err)
+	}
+	got, err = repo.CountActiveVoters(ctx, day)
+	require.NoError(t, err)
+	assert.Equal(t, voters+1, got)
+}
+
+func TestIntegrationGeoStats(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	poll := createTestPoll(t, repo, createTestUser(t, repo), nil)
+
+	for _, location := range []domain.GeoLocation{
+		{Country: "US", Region: "CA"},
+		{Country: "DE"},
+		{Country: "US", Region: "CA"},
+		{Country: "US"},
+		{Country: "US", Region: "NY"},
+	} {
+		require.NoError(t, repo.RecordVoteLocation(ctx, poll.ID, location))
+	}
+
+	stats, err := repo.GetPollGeoStats(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.CountryStat{
+		{Country: "US", Votes: 4, Regions: []domain.RegionStat{{Region: "CA", Votes: 2}, {Region: "NY", Votes: 1}}},
+		{Country: "DE", Votes: 1},
+	}, stats)
+}
+
+func TestIntegrationStatsCache(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	pollID := uuid.New()
+
+	_, err := repo.GetCachedPollStats(ctx, pollID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	stats := &domain.PollStats{
+		PollID:     pollID,
+		Votes:      []domain.OptionStats{{Option: "a", Count: 3}},
+		ComputedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	require.NoError(t, repo.SetCachedPollStats(ctx, pollID, stats))
+	got, err := repo.GetCachedPollStats(ctx, pollID)
+	require.NoError(t, err)
+	assert.Equal(t, stats.Votes, got.Votes)
+	assert.True(t, stats.ComputedAt.Equal(got.ComputedAt))
+
+	require.NoError(t, repo.InvalidatePollStatsCache(ctx, pollID))
+	_, err = repo.GetCachedPollStats(ctx, pollID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestIntegrationWithTransaction(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	tag := uniqueName("tag")
+
+	insertAlias := func(ctx context.Context, alias string) error {
+		tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+		require.True(t, ok)
+		_, err := tx.ExecContext(ctx, `INSERT INTO tag_aliases (alias, tag, created_at) VALUES ($1, $2, NOW())`, alias, tag)
+		return err
+	}
+	aliases := func() []string {
+		resolved, err := repo.ResolveTags(ctx, []string{tag + "-rolled-back", tag + "-committed"})
+		require.NoError(t, err)
+		return resolved
+	}
+
+	errAbort := errors.New("abort")
+	err := repo.WithTransaction(ctx, func(ctx context.Context) error {
+		require.NoError(t, insertAlias(ctx, tag+"-rolled-back"))
+		return errAbort
+	})
+	assert.Equal(t, errAbort, err)
+
+	err = repo.WithTransaction(ctx, func(ctx context.Context) error {
+		return insertAlias(ctx, tag+"-committed")
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{tag + "-rolled-back", tag}, aliases())
+
+	// A failing statement aborts the transaction and its error is returned.
+	err = repo.WithTransaction(ctx, func(ctx context.Context) error {
+		return insertAlias(ctx, tag+"-committed")
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,82 @@
+package validation
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultPwnedPasswordsURL = "https://api.pwnedpasswords.com/range"
+
+type PwnedPasswordsConfig struct {
+	URL     string
+	Timeout time.Duration
+}
+
+// PwnedPasswords is a BreachChecker backed by the Have I Been Pwned range
+// API. Only the first five characters of the password's SHA-1 hash are
+// sent; the returned suffixes are matched locally (k-anonymity). Responses
+// are padded so their size does not give the prefix away either.
+type PwnedPasswords struct {
+	url  string
+	http *http.Client
+}
+
+func NewPwnedPasswords(cfg PwnedPasswordsConfig) *PwnedPasswords {
+	url := cfg.URL
+	if url == "" {
+		url = DefaultPwnedPasswordsURL
+	}
+	return &PwnedPasswords{
+		url:  strings.TrimRight(url, "/"),
+		http: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (p *PwnedPasswords) Breached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url+"/"+prefix, nil)
+	if err != nil {
+		return false, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("query breached passwords: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return false, fmt.Errorf("query breached passwords: status %d: %s", resp.StatusCode, msg)
+	}
+
+	// Each line is SUFFIX:COUNT. Padding lines have a count of zero.
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		candidate, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || candidate != suffix {
+			continue
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return false, fmt.Errorf("parse breach count %q: %w", count, err)
+		}
+		return n > 0, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("read breached passwords: %w", err)
+	}
+	return false, nil
+}
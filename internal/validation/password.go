@@ -0,0 +1,99 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/behzadon/vote/internal/domain"
+)
+
+// PasswordPolicy sets the rules new passwords have to follow. Lengths count
+// characters, not bytes; a zero MaxLength means no maximum.
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireLower  bool
+	RequireUpper  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength: 8,
+		MaxLength: 128,
+	}
+}
+
+// BreachChecker reports whether a password appears in known data breaches.
+type BreachChecker interface {
+	Breached(ctx context.Context, password string) (bool, error)
+}
+
+type PasswordValidator struct {
+	policy   PasswordPolicy
+	breaches BreachChecker
+}
+
+// NewPasswordValidator returns a validator enforcing policy. breaches may be
+// nil to skip the breach check.
+func NewPasswordValidator(policy PasswordPolicy, breaches BreachChecker) *PasswordValidator {
+	return &PasswordValidator{
+		policy:   policy,
+		breaches: breaches,
+	}
+}
+
+// CheckPassword reports every rule password breaks. The breach check only
+// runs once the other rules pass; if it fails, its error is returned and the
+// password is judged on the other rules alone.
+func (v *PasswordValidator) CheckPassword(ctx context.Context, password string) ([]*domain.ValidationError, error) {
+	var errs []*domain.ValidationError
+	length := utf8.RuneCountInString(password)
+	if length < v.policy.MinLength {
+		errs = append(errs, invalid("password", fmt.Sprintf("must be at least %d characters", v.policy.MinLength)))
+	}
+	if v.policy.MaxLength > 0 && length > v.policy.MaxLength {
+		errs = append(errs, invalid("password", fmt.Sprintf("must be at most %d characters", v.policy.MaxLength)))
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r):
+			symbol = true
+		}
+	}
+	if v.policy.RequireLower && !lower {
+		errs = append(errs, invalid("password", "must contain a lowercase letter"))
+	}
+	if v.policy.RequireUpper && !upper {
+		errs = append(errs, invalid("password", "must contain an uppercase letter"))
+	}
+	if v.policy.RequireDigit && !digit {
+		errs = append(errs, invalid("password", "must contain a digit"))
+	}
+	if v.policy.RequireSymbol && !symbol {
+		errs = append(errs, invalid("password", "must contain a symbol"))
+	}
+
+	if len(errs) > 0 || v.breaches == nil {
+		return errs, nil
+	}
+	breached, err := v.breaches.Breached(ctx, password)
+	if err != nil {
+		return nil, fmt.Errorf("check breached passwords: %w", err)
+	}
+	if breached {
+		errs = append(errs, invalid("password", "appears in a known data breach"))
+	}
+	return errs, nil
+}
@@ -0,0 +1,301 @@
+// Package validation checks and normalizes user-submitted poll content
+// before it reaches the repository, and checks new passwords against the
+// password policy.
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/behzadon/vote/internal/domain"
+)
+
+type Limits struct {
+	MinOptions      int
+	MaxOptions      int
+	MaxTitleLength  int
+	MaxOptionLength int
+	MaxTags         int
+	MaxTagLength    int
+
+	MaxAltTextLength int
+	MaxEmojiLength   int
+}
+
+// DefaultLimits match the column sizes of the polls, poll_options and
+// poll_tags tables.
+func DefaultLimits() Limits {
+	return Limits{
+		MinOptions:      2,
+		MaxOptions:      10,
+		MaxTitleLength:  255,
+		MaxOptionLength: 200,
+		MaxTags:         10,
+		MaxTagLength:    domain.MaxTagLength,
+
+		MaxAltTextLength: domain.MaxAltTextLength,
+		MaxEmojiLength:   domain.MaxEmojiLength,
+	}
+}
+
+// ProfanityFilter reports the first disallowed word found in text.
+type ProfanityFilter interface {
+	Match(text string) (string, bool)
+}
+
+type PollValidator struct {
+	limits Limits
+	filter ProfanityFilter
+}
+
+// NewPollValidator returns a validator enforcing limits. filter may be nil to
+// disable profanity checks.
+func NewPollValidator(limits Limits, filter ProfanityFilter) *PollValidator {
+	return &PollValidator{
+		limits: limits,
+		filter: filter,
+	}
+}
+
+// ValidateCreate trims the title and options and normalizes the tags of req
+// in place, returning a *domain.ValidationError for the first violation.
+func (v *PollValidator) ValidateCreate(req *domain.CreatePollRequest) error {
+	if errs := v.CheckCreate(req); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// CheckCreate is ValidateCreate reporting every violation instead of stopping
+// at the first. Fields that fail are left as submitted.
+func (v *PollValidator) CheckCreate(req *domain.CreatePollRequest) []*domain.ValidationError {
+	var errs []*domain.ValidationError
+
+	title, err := v.text("title", req.Title, v.limits.MaxTitleLength)
+	if err != nil {
+		errs = append(errs, err)
+	} else {
+		req.Title = title
+	}
+
+	options, optionErrs := v.options(req.Options)
+	if len(optionErrs) > 0 {
+		errs = append(errs, optionErrs...)
+	} else {
+		req.Options = options
+	}
+
+	tags, err := v.tags(req.Tags)
+	if err != nil {
+		errs = append(errs, err)
+	} else {
+		req.Tags = tags
+	}
+
+	if len(req.OptionEmojis) > len(req.Options) {
+		errs = append(errs, invalid("optionEmojis", "must not have more entries than options"))
+	}
+	emojis := make([]string, len(req.OptionEmojis))
+	emojisValid := true
+	for i, value := range req.OptionEmojis {
+		emoji, err := v.emoji("optionEmojis", value)
+		if err != nil {
+			errs = append(errs, err)
+			emojisValid = false
+			continue
+		}
+		emojis[i] = emoji
+	}
+	if emojisValid {
+		req.OptionEmojis = emojis
+	}
+	return errs
+}
+
+// ValidateTagUpdate normalizes the tags to add and remove like those of a
+// new poll. A tag may not be both added and removed.
+func (v *PollValidator) ValidateTagUpdate(req *domain.UpdatePollTagsRequest) error {
+	add, err := v.normalizeTags("add", req.Add)
+	if err != nil {
+		return err
+	}
+	remove, err := v.normalizeTags("remove", req.Remove)
+	if err != nil {
+		return err
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		return invalid("add", "at least one tag to add or remove is required")
+	}
+	for _, tag := range remove {
+		for _, added := range add {
+			if tag == added {
+				return invalid("remove", fmt.Sprintf("tag %q is also being added", tag))
+			}
+		}
+	}
+	req.Add = add
+	req.Remove = remove
+	return nil
+}
+
+// CheckTagCount reports whether a poll may end up with tags.
+func (v *PollValidator) CheckTagCount(tags []string) error {
+	if err := v.tagCount(tags); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ValidateOptionUpdate trims the alt text and emoji present in req. Either
+// may be empty to clear it.
+func (v *PollValidator) ValidateOptionUpdate(req *domain.UpdateOptionRequest) error {
+	if req.AltText != nil {
+		altText := strings.TrimSpace(*req.AltText)
+		if altText != "" {
+			checked, err := v.text("altText", altText, v.limits.MaxAltTextLength)
+			if err != nil {
+				return err
+			}
+			altText = checked
+		}
+		req.AltText = &altText
+	}
+	if req.Emoji != nil {
+		emoji, err := v.emoji("emoji", *req.Emoji)
+		if err != nil {
+			return err
+		}
+		req.Emoji = &emoji
+	}
+	return nil
+}
+
+// ValidateUpdate applies the creation rules to the fields present in req.
+func (v *PollValidator) ValidateUpdate(req *domain.UpdatePollRequest) error {
+	if req.Title != nil {
+		title, err := v.text("title", *req.Title, v.limits.MaxTitleLength)
+		if err != nil {
+			return err
+		}
+		req.Title = &title
+	}
+	if req.Tags != nil {
+		tags, err := v.tags(req.Tags)
+		if err != nil {
+			return err
+		}
+		req.Tags = tags
+	}
+	return nil
+}
+
+// options trims options and rejects blank, overlong and case-insensitively
+// duplicated ones.
+func (v *PollValidator) options(options []string) ([]string, []*domain.ValidationError) {
+	var errs []*domain.ValidationError
+	if len(options) < v.limits.MinOptions {
+		errs = append(errs, invalid("options", fmt.Sprintf("at least %d options are required", v.limits.MinOptions)))
+	}
+	if v.limits.MaxOptions > 0 && len(options) > v.limits.MaxOptions {
+		errs = append(errs, invalid("options", fmt.Sprintf("at most %d options are allowed", v.limits.MaxOptions)))
+	}
+	seen := make(map[string]struct{}, len(options))
+	trimmed := make([]string, len(options))
+	for i, option := range options {
+		option, err := v.text("options", option, v.limits.MaxOptionLength)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		key := strings.ToLower(option)
+		if _, ok := seen[key]; ok {
+			errs = append(errs, invalid("options", fmt.Sprintf("duplicate option %q", option)))
+			continue
+		}
+		seen[key] = struct{}{}
+		trimmed[i] = option
+	}
+	return trimmed, errs
+}
+
+func (v *PollValidator) text(field, value string, maxLength int) (string, *domain.ValidationError) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", invalid(field, "must not be empty")
+	}
+	if maxLength > 0 && utf8.RuneCountInString(value) > maxLength {
+		return "", invalid(field, fmt.Sprintf("must be at most %d characters", maxLength))
+	}
+	if v.filter != nil {
+		if _, found := v.filter.Match(value); found {
+			return "", invalid(field, "contains disallowed language")
+		}
+	}
+	return value, nil
+}
+
+// emoji trims value and rejects anything containing letters or spaces, which
+// would be text rather than a symbol to show next to an option.
+func (v *PollValidator) emoji(field, value string) (string, *domain.ValidationError) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if v.limits.MaxEmojiLength > 0 && utf8.RuneCountInString(value) > v.limits.MaxEmojiLength {
+		return "", invalid(field, fmt.Sprintf("must be at most %d characters", v.limits.MaxEmojiLength))
+	}
+	for _, r := range value {
+		if unicode.IsLetter(r) || unicode.IsSpace(r) {
+			return "", invalid(field, "must be an emoji")
+		}
+	}
+	return value, nil
+}
+
+// tags lower-cases, trims and dedupes tags.
+func (v *PollValidator) tags(tags []string) ([]string, *domain.ValidationError) {
+	normalized, err := v.normalizeTags("tags", tags)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.tagCount(normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+func (v *PollValidator) normalizeTags(field string, tags []string) ([]string, *domain.ValidationError) {
+	seen := make(map[string]struct{}, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		if _, err := v.text(field, tag, v.limits.MaxTagLength); err != nil {
+			return nil, err
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+	return normalized, nil
+}
+
+func (v *PollValidator) tagCount(tags []string) *domain.ValidationError {
+	if len(tags) == 0 {
+		return invalid("tags", "at least one tag is required")
+	}
+	if v.limits.MaxTags > 0 && len(tags) > v.limits.MaxTags {
+		return invalid("tags", fmt.Sprintf("at most %d tags are allowed", v.limits.MaxTags))
+	}
+	return nil
+}
+
+func invalid(field, reason string) *domain.ValidationError {
+	return &domain.ValidationError{Field: field, Reason: reason}
+}
@@ -0,0 +1,172 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCreate(t *testing.T) {
+	validator := NewPollValidator(DefaultLimits(), NewWordList([]string{"darn"}))
+
+	tests := []struct {
+		name  string
+		req   domain.CreatePollRequest
+		field string
+	}{
+		{"empty title", domain.CreatePollRequest{Title: "  ", Options: []string{"a", "b"}, Tags: []string{"t"}}, "title"},
+		{"long title", domain.CreatePollRequest{Title: strings.Repeat("x", 256), Options: []string{"a", "b"}, Tags: []string{"t"}}, "title"},
+		{"too few options", domain.CreatePollRequest{Title: "Poll", Options: []string{"a"}, Tags: []string{"t"}}, "options"},
+		{"empty option", domain.CreatePollRequest{Title: "Poll", Options: []string{"a", " "}, Tags: []string{"t"}}, "options"},
+		{"duplicate option", domain.CreatePollRequest{Title: "Poll", Options: []string{"Yes", "yes "}, Tags: []string{"t"}}, "options"},
+		{"no tags", domain.CreatePollRequest{Title: "Poll", Options: []string{"a", "b"}, Tags: []string{" "}}, "tags"},
+		{"profanity", domain.CreatePollRequest{Title: "Darn it?", Options: []string{"a", "b"}, Tags: []string{"t"}}, "title"},
+		{"emoji text", domain.CreatePollRequest{Title: "Poll", Options: []string{"a", "b"}, Tags: []string{"t"}, OptionEmojis: []string{"🍕", "yum"}}, "optionEmojis"},
+		{"too many emojis", domain.CreatePollRequest{Title: "Poll", Options: []string{"a", "b"}, Tags: []string{"t"}, OptionEmojis: []string{"🍕", "🍔", "🌮"}}, "optionEmojis"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateCreate(&tt.req)
+			require.ErrorIs(t, err, domain.ErrInvalidInput)
+			var validationErr *domain.ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			assert.Equal(t, tt.field, validationErr.Field)
+		})
+	}
+}
+
+func TestCheckCreate_CollectsAllViolations(t *testing.T) {
+	validator := NewPollValidator(DefaultLimits(), nil)
+	req := &domain.CreatePollRequest{
+		Title:   " ",
+		Options: []string{"Yes", "", "yes"},
+		Tags:    []string{strings.Repeat("x", 51)},
+	}
+
+	errs := validator.CheckCreate(req)
+	fields := make([]string, len(errs))
+	for i, err := range errs {
+		fields[i] = err.Field
+	}
+	assert.Equal(t, []string{"title", "options", "options", "tags"}, fields)
+}
+
+func TestValidateCreate_Normalizes(t *testing.T) {
+	validator := NewPollValidator(DefaultLimits(), nil)
+	req := &domain.CreatePollRequest{
+		Title:   "  Favourite language? ",
+		Options: []string{" Go", "Rust "},
+		Tags:    []string{"Programming", "programming ", "go"},
+	}
+
+	require.NoError(t, validator.ValidateCreate(req))
+	assert.Equal(t, "Favourite language?", req.Title)
+	assert.Equal(t, []string{"Go", "Rust"}, req.Options)
+	assert.Equal(t, []string{"programming", "go"}, req.Tags)
+}
+
+func TestValidateOptionUpdate(t *testing.T) {
+	validator := NewPollValidator(DefaultLimits(), NewWordList([]string{"darn"}))
+	strPtr := func(s string) *string { return &s }
+
+	req := &domain.UpdateOptionRequest{AltText: strPtr(" A red apple "), Emoji: strPtr(" 🍎 ")}
+	require.NoError(t, validator.ValidateOptionUpdate(req))
+	assert.Equal(t, "A red apple", *req.AltText)
+	assert.Equal(t, "🍎", *req.Emoji)
+
+	req = &domain.UpdateOptionRequest{AltText: strPtr(" "), Emoji: strPtr("")}
+	require.NoError(t, validator.ValidateOptionUpdate(req))
+	assert.Empty(t, *req.AltText)
+	assert.Empty(t, *req.Emoji)
+
+	err := validator.ValidateOptionUpdate(&domain.UpdateOptionRequest{AltText: strPtr(strings.Repeat("x", domain.MaxAltTextLength+1))})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	err = validator.ValidateOptionUpdate(&domain.UpdateOptionRequest{AltText: strPtr("darn apple")})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	err = validator.ValidateOptionUpdate(&domain.UpdateOptionRequest{Emoji: strPtr(strings.Repeat("🍎", domain.MaxEmojiLength+1))})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestWordList_MatchesWholeWords(t *testing.T) {
+	words := NewWordList([]string{"ass"})
+
+	_, found := words.Match("Pass the salt")
+	assert.False(t, found)
+
+	word, found := words.Match("What an ASS!")
+	assert.True(t, found)
+	assert.Equal(t, "ass", word)
+}
+
+func TestCheckPassword(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8, MaxLength: 16, RequireLower: true, RequireUpper: true, RequireDigit: true, RequireSymbol: true}
+	validator := NewPasswordValidator(policy, nil)
+
+	tests := []struct {
+		password string
+		reasons  []string
+	}{
+		{"Tr0ub4dor&3", nil},
+		{"Åbcdéf1!", nil},
+		{"Ab1!", []string{"must be at least 8 characters"}},
+		{"Ab1!" + strings.Repeat("x", 13), []string{"must be at most 16 characters"}},
+		{"password", []string{"must contain an uppercase letter", "must contain a digit", "must contain a symbol"}},
+		{"PASSWORD1!", []string{"must contain a lowercase letter"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			errs, err := validator.CheckPassword(context.Background(), tt.password)
+			require.NoError(t, err)
+			var reasons []string
+			for _, e := range errs {
+				assert.Equal(t, "password", e.Field)
+				reasons = append(reasons, e.Reason)
+			}
+			assert.Equal(t, tt.reasons, reasons)
+		})
+	}
+}
+
+func TestPwnedPasswords(t *testing.T) {
+	// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8.
+	var prefixes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefixes = append(prefixes, strings.TrimPrefix(r.URL.Path, "/range/"))
+		assert.Equal(t, "true", r.Header.Get("Add-Padding"))
+		fmt.Fprint(w, "003D68EB55068C33ACE09247EE4C639306B:3\r\n")
+		fmt.Fprint(w, "1E4C9B93F3F0682250B6CF8331B7EE68FD8:9659365\r\n")
+		fmt.Fprint(w, "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:0\r\n")
+	}))
+	defer server.Close()
+	checker := NewPwnedPasswords(PwnedPasswordsConfig{URL: server.URL + "/range/", Timeout: time.Second})
+
+	breached, err := checker.Breached(context.Background(), "password")
+	require.NoError(t, err)
+	assert.True(t, breached)
+	breached, err = checker.Breached(context.Background(), "correct horse battery staple")
+	require.NoError(t, err)
+	assert.False(t, breached)
+	assert.Equal(t, "5BAA6", prefixes[0])
+
+	validator := NewPasswordValidator(DefaultPasswordPolicy(), checker)
+	errs, err := validator.CheckPassword(context.Background(), "password")
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "appears in a known data breach", errs[0].Reason)
+
+	server.Close()
+	errs, err = validator.CheckPassword(context.Background(), "password")
+	assert.Error(t, err)
+	assert.Empty(t, errs)
+}
@@ -0,0 +1,61 @@
+package validation
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// WordList is a ProfanityFilter matching whole words case-insensitively.
+type WordList struct {
+	words map[string]struct{}
+}
+
+func NewWordList(words []string) *WordList {
+	list := &WordList{words: make(map[string]struct{}, len(words))}
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" {
+			list.words[word] = struct{}{}
+		}
+	}
+	return list
+}
+
+// LoadWordList reads one word per line; blank lines and lines starting with #
+// are ignored.
+func LoadWordList(path string) (*WordList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open word list: %w", err)
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read word list: %w", err)
+	}
+	return NewWordList(words), nil
+}
+
+func (l *WordList) Match(text string) (string, bool) {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, field := range fields {
+		if _, ok := l.words[field]; ok {
+			return field, true
+		}
+	}
+	return "", false
+}
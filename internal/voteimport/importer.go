@@ -0,0 +1,251 @@
+package voteimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const DefaultBatchSize = 500
+
+// Store is where the importer reads polls and writes votes.
+type Store interface {
+	GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Poll, error)
+	ImportVotes(ctx context.Context, votes []domain.Vote) (*domain.VoteImportBatch, error)
+	InvalidatePollStatsCache(ctx context.Context, pollID uuid.UUID) error
+}
+
+// Importer loads historical votes from other systems. Imported votes count
+// toward stats and analytics like any other vote, but publish no events, so
+// nobody is notified about them, and they don't use up daily vote limits.
+type Importer struct {
+	store     Store
+	watcher   domain.StatsWatcher
+	batchSize int
+	logger    *zap.Logger
+	now       func() time.Time
+}
+
+type Option func(*Importer)
+
+// WithStatsWatcher wakes clients waiting on the stats of polls that received
+// votes.
+func WithStatsWatcher(watcher domain.StatsWatcher) Option {
+	return func(i *Importer) {
+		i.watcher = watcher
+	}
+}
+
+// WithBatchSize sets how many votes are stored per transaction.
+func WithBatchSize(n int) Option {
+	return func(i *Importer) {
+		if n > 0 {
+			i.batchSize = n
+		}
+	}
+}
+
+func NewImporter(store Store, logger *zap.Logger, opts ...Option) *Importer {
+	i := &Importer{
+		store:     store,
+		batchSize: DefaultBatchSize,
+		logger:    logger,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+type importRun struct {
+	*Importer
+	result  *domain.VoteImportResult
+	polls   map[uuid.UUID]*domain.Poll
+	seen    map[[2]uuid.UUID]bool
+	touched map[uuid.UUID]bool
+	pending []domain.Vote
+	lines   map[uuid.UUID]int
+}
+
+// Import reads every vote from src and stores the valid ones in batches, each
+// in its own transaction. Rows that are invalid, repeat a voter's vote on a
+// poll or belong to an election are reported rather than stored. An error
+// other than a rejected row stops the import; batches stored by then stay.
+func (i *Importer) Import(ctx context.Context, src Source) (*domain.VoteImportResult, error) {
+	run := &importRun{
+		Importer: i,
+		result:   &domain.VoteImportResult{Errors: []domain.VoteImportError{}},
+		polls:    make(map[uuid.UUID]*domain.Poll),
+		seen:     make(map[[2]uuid.UUID]bool),
+		touched:  make(map[uuid.UUID]bool),
+		lines:    make(map[uuid.UUID]int),
+	}
+	defer run.statsChanged(ctx)
+
+	for {
+		imported, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *domain.VoteImportError
+		if errors.As(err, &rowErr) {
+			run.reject(rowErr.Line, rowErr.Reason)
+			continue
+		}
+		if err != nil {
+			return run.result, fmt.Errorf("read votes: %w", err)
+		}
+
+		if err := run.add(ctx, imported); err != nil {
+			return run.result, err
+		}
+		if len(run.pending) >= i.batchSize {
+			if err := run.flush(ctx); err != nil {
+				return run.result, err
+			}
+		}
+	}
+	if err := run.flush(ctx); err != nil {
+		return run.result, err
+	}
+	return run.result, nil
+}
+
+func (r *importRun) reject(line int, reason string) {
+	r.result.Rejected++
+	if len(r.result.Errors) < domain.MaxVoteImportErrors {
+		r.result.Errors = append(r.result.Errors, domain.VoteImportError{Line: line, Reason: reason})
+	}
+}
+
+func (r *importRun) poll(ctx context.Context, id uuid.UUID) (*domain.Poll, error) {
+	if poll, ok := r.polls[id]; ok {
+		return poll, nil
+	}
+	poll, err := r.store.GetPollByID(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		poll = nil
+	} else if err != nil {
+		return nil, fmt.Errorf("get poll %s: %w", id, err)
+	}
+	r.polls[id] = poll
+	return poll, nil
+}
+
+func (r *importRun) add(ctx context.Context, imported *domain.ImportedVote) error {
+	if imported.VoterID == uuid.Nil {
+		r.reject(imported.Line, "voterId is required")
+		return nil
+	}
+	if imported.CreatedAt.IsZero() {
+		r.reject(imported.Line, "createdAt is required")
+		return nil
+	}
+	if imported.CreatedAt.After(r.now()) {
+		r.reject(imported.Line, "createdAt is in the future")
+		return nil
+	}
+
+	poll, err := r.poll(ctx, imported.PollID)
+	if err != nil {
+		return err
+	}
+	if poll == nil {
+		r.reject(imported.Line, "unknown poll")
+		return nil
+	}
+	if poll.Kind == domain.PollKindElection {
+		r.reject(imported.Line, "votes cannot be imported into elections")
+		return nil
+	}
+	option, ok := findOption(poll, imported)
+	if !ok {
+		r.reject(imported.Line, "unknown option")
+		return nil
+	}
+
+	key := [2]uuid.UUID{poll.ID, imported.VoterID}
+	if r.seen[key] {
+		r.result.Duplicates++
+		return nil
+	}
+	r.seen[key] = true
+
+	vote := domain.Vote{
+		ID:        uuid.New(),
+		PollID:    poll.ID,
+		UserID:    imported.VoterID,
+		OptionID:  option.ID,
+		CreatedAt: imported.CreatedAt.UTC(),
+	}
+	r.pending = append(r.pending, vote)
+	r.lines[vote.ID] = imported.Line
+	return nil
+}
+
+func findOption(poll *domain.Poll, imported *domain.ImportedVote) (domain.Option, bool) {
+	if imported.OptionID != nil {
+		for _, option := range poll.Options {
+			if option.ID == *imported.OptionID {
+				return option, true
+			}
+		}
+		return domain.Option{}, false
+	}
+	if imported.OptionIndex == nil || *imported.OptionIndex < 0 || *imported.OptionIndex >= len(poll.Options) {
+		return domain.Option{}, false
+	}
+	return poll.Options[*imported.OptionIndex], true
+}
+
+func (r *importRun) flush(ctx context.Context) error {
+	if len(r.pending) == 0 {
+		return nil
+	}
+	batch, err := r.store.ImportVotes(ctx, r.pending)
+	if err != nil {
+		return fmt.Errorf("import votes: %w", err)
+	}
+
+	r.result.Imported += batch.Imported
+	r.result.Duplicates += len(batch.Duplicates)
+	for _, id := range batch.UnknownVoters {
+		r.reject(r.lines[id], "unknown voter")
+	}
+	if batch.Imported > 0 {
+		for _, vote := range r.pending {
+			r.touched[vote.PollID] = true
+		}
+	}
+
+	r.pending = r.pending[:0]
+	r.lines = make(map[uuid.UUID]int)
+	return nil
+}
+
+func (r *importRun) statsChanged(ctx context.Context) {
+	for pollID := range r.touched {
+		if err := r.store.InvalidatePollStatsCache(ctx, pollID); err != nil {
+			r.logger.Warn("Failed to invalidate poll stats cache",
+				zap.Error(err),
+				zap.String("poll_id", pollID.String()),
+			)
+		}
+		if r.watcher == nil {
+			continue
+		}
+		if _, err := r.watcher.BumpStatsVersion(ctx, pollID); err != nil {
+			r.logger.Warn("Failed to bump poll stats version",
+				zap.Error(err),
+				zap.String("poll_id", pollID.String()),
+			)
+		}
+	}
+}
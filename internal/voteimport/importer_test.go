@@ -0,0 +1,115 @@
+package voteimport
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeStore struct {
+	polls       map[uuid.UUID]*domain.Poll
+	voters      map[uuid.UUID]bool
+	voted       map[[2]uuid.UUID]bool
+	batches     [][]domain.Vote
+	invalidated []uuid.UUID
+}
+
+func (s *fakeStore) GetPollByID(ctx context.Context, id uuid.UUID) (*domain.Poll, error) {
+	if poll, ok := s.polls[id]; ok {
+		return poll, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (s *fakeStore) ImportVotes(ctx context.Context, votes []domain.Vote) (*domain.VoteImportBatch, error) {
+	s.batches = append(s.batches, append([]domain.Vote(nil), votes...))
+	batch := &domain.VoteImportBatch{}
+	for _, vote := range votes {
+		key := [2]uuid.UUID{vote.PollID, vote.UserID}
+		switch {
+		case !s.voters[vote.UserID]:
+			batch.UnknownVoters = append(batch.UnknownVoters, vote.ID)
+		case s.voted[key]:
+			batch.Duplicates = append(batch.Duplicates, vote.ID)
+		default:
+			s.voted[key] = true
+			batch.Imported++
+		}
+	}
+	return batch, nil
+}
+
+func (s *fakeStore) InvalidatePollStatsCache(ctx context.Context, pollID uuid.UUID) error {
+	s.invalidated = append(s.invalidated, pollID)
+	return nil
+}
+
+type sliceSource struct {
+	votes []*domain.ImportedVote
+}
+
+func (s *sliceSource) Next() (*domain.ImportedVote, error) {
+	if len(s.votes) == 0 {
+		return nil, io.EOF
+	}
+	vote := s.votes[0]
+	s.votes = s.votes[1:]
+	return vote, nil
+}
+
+func TestImport(t *testing.T) {
+	now := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	poll := &domain.Poll{ID: uuid.New(), Options: []domain.Option{{ID: uuid.New()}, {ID: uuid.New()}}}
+	election := &domain.Poll{ID: uuid.New(), Kind: domain.PollKindElection, Options: poll.Options}
+	alice, bob, carol, ghost := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	store := &fakeStore{
+		polls:  map[uuid.UUID]*domain.Poll{poll.ID: poll, election.ID: election},
+		voters: map[uuid.UUID]bool{alice: true, bob: true, carol: true},
+		voted:  map[[2]uuid.UUID]bool{{poll.ID, carol}: true},
+	}
+	importer := NewImporter(store, zap.NewNop(), WithBatchSize(2))
+	importer.now = func() time.Time { return now }
+
+	index := func(i int) *int { return &i }
+	past := now.Add(-time.Hour)
+	src := &sliceSource{votes: []*domain.ImportedVote{
+		{Line: 1, PollID: poll.ID, VoterID: alice, OptionIndex: index(0), CreatedAt: past},
+		{Line: 2, PollID: poll.ID, VoterID: bob, OptionID: &poll.Options[1].ID, CreatedAt: past},
+		{Line: 3, PollID: poll.ID, VoterID: alice, OptionIndex: index(1), CreatedAt: past},
+		{Line: 4, PollID: poll.ID, VoterID: carol, OptionIndex: index(0), CreatedAt: past},
+		{Line: 5, PollID: poll.ID, VoterID: ghost, OptionIndex: index(0), CreatedAt: past},
+		{Line: 6, PollID: poll.ID, VoterID: bob, OptionIndex: index(5), CreatedAt: past},
+		{Line: 7, PollID: uuid.New(), VoterID: bob, OptionIndex: index(0), CreatedAt: past},
+		{Line: 8, PollID: election.ID, VoterID: bob, OptionIndex: index(0), CreatedAt: past},
+		{Line: 9, PollID: poll.ID, VoterID: bob, OptionIndex: index(0), CreatedAt: now.Add(time.Hour)},
+		{Line: 10, PollID: poll.ID, OptionIndex: index(0), CreatedAt: past},
+	}}
+
+	result, err := importer.Import(context.Background(), src)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, result.Imported)
+	assert.Equal(t, 2, result.Duplicates, "alice twice in the file, carol already voted")
+	assert.Equal(t, 6, result.Rejected)
+	assert.ElementsMatch(t, []domain.VoteImportError{
+		{Line: 5, Reason: "unknown voter"},
+		{Line: 6, Reason: "unknown option"},
+		{Line: 7, Reason: "unknown poll"},
+		{Line: 8, Reason: "votes cannot be imported into elections"},
+		{Line: 9, Reason: "createdAt is in the future"},
+		{Line: 10, Reason: "voterId is required"},
+	}, result.Errors)
+
+	require.Len(t, store.batches, 2)
+	assert.Len(t, store.batches[0], 2)
+	assert.Equal(t, poll.Options[1].ID, store.batches[0][1].OptionID)
+	assert.Equal(t, []uuid.UUID{poll.ID}, store.invalidated)
+}
@@ -0,0 +1,159 @@
+package voteimport
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/google/uuid"
+)
+
+// maxLineSize bounds a single NDJSON line.
+const maxLineSize = 64 * 1024
+
+// Source yields the votes of an import in order, and io.EOF after the last.
+// A *domain.VoteImportError rejects one row; reading may continue after it.
+type Source interface {
+	Next() (*domain.ImportedVote, error)
+}
+
+// NewSource reads votes in the given format, "csv" or "ndjson".
+func NewSource(r io.Reader, format string) (Source, error) {
+	switch format {
+	case "csv":
+		return NewCSVSource(r)
+	case "ndjson":
+		return NewNDJSONSource(r), nil
+	default:
+		return nil, errors.New("format must be csv or ndjson")
+	}
+}
+
+type csvSource struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+// NewCSVSource reads CSV with a header row naming the columns, as written by
+// the poll vote export. poll_id, voter_id, created_at and one of option_id or
+// option_index are required; other columns are ignored.
+func NewCSVSource(r io.Reader) (Source, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("missing header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	for _, required := range []string{"poll_id", "voter_id", "created_at"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing %s column", required)
+		}
+	}
+	_, hasID := columns["option_id"]
+	_, hasIndex := columns["option_index"]
+	if !hasID && !hasIndex {
+		return nil, errors.New("missing option_id or option_index column")
+	}
+
+	return &csvSource{r: reader, columns: columns}, nil
+}
+
+func (s *csvSource) Next() (*domain.ImportedVote, error) {
+	record, err := s.r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &domain.VoteImportError{Line: parseErr.StartLine, Reason: parseErr.Err.Error()}
+		}
+		return nil, err
+	}
+	line, _ := s.r.FieldPos(0)
+
+	field := func(name string) string {
+		if i, ok := s.columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	reject := func(reason string) (*domain.ImportedVote, error) {
+		return nil, &domain.VoteImportError{Line: line, Reason: reason}
+	}
+
+	vote := &domain.ImportedVote{Line: line}
+	if vote.PollID, err = uuid.Parse(field("poll_id")); err != nil {
+		return reject("invalid poll_id")
+	}
+	if vote.VoterID, err = uuid.Parse(field("voter_id")); err != nil {
+		return reject("invalid voter_id")
+	}
+	if raw := field("option_id"); raw != "" {
+		optionID, err := uuid.Parse(raw)
+		if err != nil {
+			return reject("invalid option_id")
+		}
+		vote.OptionID = &optionID
+	}
+	if raw := field("option_index"); raw != "" {
+		index, err := strconv.Atoi(raw)
+		if err != nil {
+			return reject("invalid option_index")
+		}
+		vote.OptionIndex = &index
+	}
+	if vote.CreatedAt, err = time.Parse(time.RFC3339Nano, field("created_at")); err != nil {
+		return reject("invalid created_at, expected an RFC 3339 timestamp")
+	}
+	return vote, nil
+}
+
+type ndjsonSource struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewNDJSONSource reads one JSON object per line, as written by the poll vote
+// export. Blank lines are skipped.
+func NewNDJSONSource(r io.Reader) Source {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+	return &ndjsonSource{scanner: scanner}
+}
+
+func (s *ndjsonSource) Next() (*domain.ImportedVote, error) {
+	for s.scanner.Scan() {
+		s.line++
+		line := strings.TrimSpace(s.scanner.Text())
+		if line == "" {
+			continue
+		}
+		var vote domain.ImportedVote
+		if err := json.Unmarshal([]byte(line), &vote); err != nil {
+			return nil, &domain.VoteImportError{Line: s.line, Reason: "invalid JSON"}
+		}
+		vote.Line = s.line
+		return &vote, nil
+	}
+	if err := s.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("line %d: %w", s.line+1, err)
+	}
+	return nil, io.EOF
+}
@@ -0,0 +1,91 @@
+package voteimport
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/behzadon/vote/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testPollID  = "7b0e5f1a-3c2d-4e5f-8a9b-0c1d2e3f4a5b"
+	testVoterID = "1a2b3c4d-5e6f-4a8b-9c0d-1e2f3a4b5c6d"
+)
+
+func readAll(t *testing.T, src Source) ([]*domain.ImportedVote, []domain.VoteImportError) {
+	t.Helper()
+	var votes []*domain.ImportedVote
+	var rejects []domain.VoteImportError
+	for {
+		vote, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			return votes, rejects
+		}
+		var rowErr *domain.VoteImportError
+		if errors.As(err, &rowErr) {
+			rejects = append(rejects, *rowErr)
+			continue
+		}
+		require.NoError(t, err)
+		votes = append(votes, vote)
+	}
+}
+
+func TestCSVSource(t *testing.T) {
+	input := "vote_id,poll_id,voter_id,option_id,option_index,option_text,created_at\n" +
+		"x," + testPollID + "," + testVoterID + ",,1,Pizza,2024-06-01T09:30:00Z\n" +
+		"x,not-a-uuid," + testVoterID + ",,1,Pizza,2024-06-01T09:30:00Z\n" +
+		"x," + testPollID + "," + testVoterID + ",,1,Pizza,yesterday\n"
+
+	src, err := NewSource(strings.NewReader(input), "csv")
+	require.NoError(t, err)
+	votes, rejects := readAll(t, src)
+
+	require.Len(t, votes, 1)
+	assert.Equal(t, testPollID, votes[0].PollID.String())
+	assert.Equal(t, testVoterID, votes[0].VoterID.String())
+	assert.Nil(t, votes[0].OptionID)
+	assert.Equal(t, 1, *votes[0].OptionIndex)
+	assert.True(t, votes[0].CreatedAt.Equal(time.Date(2024, 6, 1, 9, 30, 0, 0, time.UTC)))
+	assert.Equal(t, 2, votes[0].Line)
+	assert.Equal(t, []domain.VoteImportError{
+		{Line: 3, Reason: "invalid poll_id"},
+		{Line: 4, Reason: "invalid created_at, expected an RFC 3339 timestamp"},
+	}, rejects)
+}
+
+func TestCSVSourceRequiresColumns(t *testing.T) {
+	_, err := NewCSVSource(strings.NewReader("poll_id,voter_id,created_at\n"))
+	assert.EqualError(t, err, "missing option_id or option_index column")
+
+	_, err = NewCSVSource(strings.NewReader("poll_id,option_id,created_at\n"))
+	assert.EqualError(t, err, "missing voter_id column")
+
+	_, err = NewCSVSource(strings.NewReader(""))
+	assert.EqualError(t, err, "missing header row")
+}
+
+func TestNDJSONSource(t *testing.T) {
+	input := `{"pollId":"` + testPollID + `","voterId":"` + testVoterID + `","optionIndex":0,"createdAt":"2024-06-01T09:30:00Z"}` + "\n" +
+		"\n" +
+		"{not json\n"
+
+	src, err := NewSource(strings.NewReader(input), "ndjson")
+	require.NoError(t, err)
+	votes, rejects := readAll(t, src)
+
+	require.Len(t, votes, 1)
+	assert.Equal(t, 1, votes[0].Line)
+	assert.Equal(t, 0, *votes[0].OptionIndex)
+	assert.Equal(t, []domain.VoteImportError{{Line: 3, Reason: "invalid JSON"}}, rejects)
+}
+
+func TestNewSourceRejectsUnknownFormat(t *testing.T) {
+	_, err := NewSource(strings.NewReader(""), "xml")
+	assert.EqualError(t, err, "format must be csv or ndjson")
+}
@@ -0,0 +1,21 @@
+-- Migration: poll_stats_daily
+-- Created at: 2024-04-02
+
+-- Up Migration
+-- Daily per-option vote rollups maintained by the stats_rollup job
+CREATE TABLE IF NOT EXISTS poll_stats_daily (
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    option_id UUID NOT NULL REFERENCES poll_options(id) ON DELETE CASCADE,
+    stat_date DATE NOT NULL,
+    vote_count INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (poll_id, option_id, stat_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_poll_stats_daily_stat_date ON poll_stats_daily(stat_date);
+CREATE INDEX IF NOT EXISTS idx_votes_created_at ON votes(created_at);
+
+-- Down Migration
+DROP INDEX IF EXISTS idx_votes_created_at;
+DROP INDEX IF EXISTS idx_poll_stats_daily_stat_date;
+DROP TABLE IF EXISTS poll_stats_daily;
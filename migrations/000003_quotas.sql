@@ -0,0 +1,30 @@
+-- Migration: quotas
+-- Created at: 2024-04-09
+
+-- Up Migration
+-- Per-user overrides of the configured default quotas
+CREATE TABLE IF NOT EXISTS user_quotas (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    action VARCHAR(32) NOT NULL,
+    period VARCHAR(16) NOT NULL,
+    quota_limit INTEGER NOT NULL,
+    PRIMARY KEY (user_id, action, period)
+);
+
+-- Durable quota usage; Redis counters are seeded from here when they expire
+CREATE TABLE IF NOT EXISTS quota_usage (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    action VARCHAR(32) NOT NULL,
+    period VARCHAR(16) NOT NULL,
+    period_start DATE NOT NULL,
+    used INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, action, period, period_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_quota_usage_period_start ON quota_usage(period_start);
+
+-- Down Migration
+DROP INDEX IF EXISTS idx_quota_usage_period_start;
+DROP TABLE IF EXISTS quota_usage;
+DROP TABLE IF EXISTS user_quotas;
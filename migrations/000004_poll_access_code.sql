@@ -0,0 +1,9 @@
+-- Migration: poll_access_code
+-- Created at: 2024-04-12
+
+-- Up Migration
+-- bcrypt hash of the optional passphrase required to vote on a poll
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS access_code_hash VARCHAR(255);
+
+-- Down Migration
+ALTER TABLE polls DROP COLUMN IF EXISTS access_code_hash;
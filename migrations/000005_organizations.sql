@@ -0,0 +1,39 @@
+-- Migration: organizations
+-- Created at: 2024-04-16
+
+-- Up Migration
+CREATE TABLE IF NOT EXISTS organizations (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS organization_members (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_members_user_id ON organization_members(user_id);
+
+-- Who may vote: everyone ('open'), members of organization_id ('organization')
+-- or the addresses in poll_electorate ('list')
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organizations(id) ON DELETE SET NULL;
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS electorate VARCHAR(16) NOT NULL DEFAULT 'open';
+
+-- Lower-cased email addresses eligible to vote on a 'list' poll
+CREATE TABLE IF NOT EXISTS poll_electorate (
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    PRIMARY KEY (poll_id, email)
+);
+
+-- Down Migration
+DROP TABLE IF EXISTS poll_electorate;
+ALTER TABLE polls DROP COLUMN IF EXISTS electorate;
+ALTER TABLE polls DROP COLUMN IF EXISTS organization_id;
+DROP INDEX IF EXISTS idx_organization_members_user_id;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
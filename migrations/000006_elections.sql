@@ -0,0 +1,25 @@
+-- Migration: elections
+-- Created at: 2024-04-22
+
+-- Up Migration
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS kind VARCHAR(16) NOT NULL DEFAULT 'standard';
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS starts_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS ends_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_polls_kind_ends_at ON polls(kind, ends_at);
+
+-- Signed final tallies of closed elections; tally is stored verbatim as signed
+CREATE TABLE IF NOT EXISTS election_certifications (
+    poll_id UUID PRIMARY KEY REFERENCES polls(id) ON DELETE CASCADE,
+    tally TEXT NOT NULL,
+    algorithm VARCHAR(32) NOT NULL,
+    signature VARCHAR(128) NOT NULL,
+    certified_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Down Migration
+DROP TABLE IF EXISTS election_certifications;
+DROP INDEX IF EXISTS idx_polls_kind_ends_at;
+ALTER TABLE polls DROP COLUMN IF EXISTS ends_at;
+ALTER TABLE polls DROP COLUMN IF EXISTS starts_at;
+ALTER TABLE polls DROP COLUMN IF EXISTS kind;
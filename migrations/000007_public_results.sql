@@ -0,0 +1,9 @@
+-- Migration: public_results
+-- Created at: 2024-04-25
+
+-- Up Migration
+-- Polls whose results may be read without authentication
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS public_results BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Down Migration
+ALTER TABLE polls DROP COLUMN IF EXISTS public_results;
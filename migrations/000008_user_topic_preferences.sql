@@ -0,0 +1,17 @@
+-- Migration: user_topic_preferences
+-- Created at: 2024-04-29
+
+-- Up Migration
+-- Followed tags, muted tags and muted title keywords, one row per value
+CREATE TABLE IF NOT EXISTS user_topic_preferences (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(16) NOT NULL,
+    value VARCHAR(100) NOT NULL,
+    PRIMARY KEY (user_id, kind, value)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_topic_preferences_kind_value ON user_topic_preferences(kind, value);
+
+-- Down Migration
+DROP INDEX IF EXISTS idx_user_topic_preferences_kind_value;
+DROP TABLE IF EXISTS user_topic_preferences;
@@ -0,0 +1,10 @@
+-- Migration: vote_change_policy
+-- Created at: 2024-04-29
+
+-- Up Migration
+-- Whether voters may update or delete their vote: allowed, disallowed or until_close
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS vote_change VARCHAR(16) NOT NULL DEFAULT 'until_close';
+UPDATE polls SET vote_change = 'disallowed' WHERE kind = 'election';
+
+-- Down Migration
+ALTER TABLE polls DROP COLUMN IF EXISTS vote_change;
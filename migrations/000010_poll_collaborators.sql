@@ -0,0 +1,22 @@
+-- Migration: poll_collaborators
+-- Created at: 2024-05-02
+
+-- Up Migration
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS created_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- Users the creator granted 'edit' (update, close and stats) or 'stats' rights
+CREATE TABLE IF NOT EXISTS poll_collaborators (
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    permission VARCHAR(16) NOT NULL,
+    invited_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (poll_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_poll_collaborators_user_id ON poll_collaborators(user_id);
+
+-- Down Migration
+DROP INDEX IF EXISTS idx_poll_collaborators_user_id;
+DROP TABLE IF EXISTS poll_collaborators;
+ALTER TABLE polls DROP COLUMN IF EXISTS created_by;
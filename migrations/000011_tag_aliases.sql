@@ -0,0 +1,18 @@
+-- Migration: tag_aliases
+-- Created at: 2024-05-06
+
+-- Up Migration
+-- Alias tags resolve to their canonical tag on poll creation, in feeds and
+-- in user preferences
+CREATE TABLE IF NOT EXISTS tag_aliases (
+    alias VARCHAR(50) PRIMARY KEY,
+    tag VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CHECK (alias <> tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tag_aliases_tag ON tag_aliases(tag);
+
+-- Down Migration
+DROP INDEX IF EXISTS idx_tag_aliases_tag;
+DROP TABLE IF EXISTS tag_aliases;
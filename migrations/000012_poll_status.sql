@@ -0,0 +1,15 @@
+-- Migration: poll_status
+-- Created at: 2024-05-09
+
+-- Up Migration
+-- Explicit lifecycle status: draft, scheduled, live, closed, archived or
+-- deleted. scheduled and live polls advance with starts_at / ends_at.
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'live';
+UPDATE polls SET status = 'scheduled' WHERE starts_at > NOW();
+UPDATE polls SET status = 'closed' WHERE ends_at <= NOW();
+
+CREATE INDEX IF NOT EXISTS idx_polls_status_created_at ON polls(status, created_at DESC);
+
+-- Down Migration
+DROP INDEX IF EXISTS idx_polls_status_created_at;
+ALTER TABLE polls DROP COLUMN IF EXISTS status;
@@ -0,0 +1,9 @@
+-- Migration: vote_receipts
+-- Created at: 2024-05-10
+
+-- Up Migration
+-- Opt-in notifications when a user's vote is recorded, changed or deleted.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS vote_receipts BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Down Migration
+ALTER TABLE users DROP COLUMN IF EXISTS vote_receipts;
@@ -0,0 +1,14 @@
+-- Migration: poll_milestones
+-- Created at: 2024-05-11
+
+-- Up Migration
+-- Vote milestones already announced to a poll's creator.
+CREATE TABLE IF NOT EXISTS poll_milestones (
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    milestone INTEGER NOT NULL,
+    reached_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (poll_id, milestone)
+);
+
+-- Down Migration
+DROP TABLE IF EXISTS poll_milestones;
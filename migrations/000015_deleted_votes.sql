@@ -0,0 +1,18 @@
+-- Migration: deleted_votes
+-- Created at: 2024-05-12
+
+-- Up Migration
+-- Votes withdrawn by their owner, kept so they can still be exported.
+CREATE TABLE IF NOT EXISTS deleted_votes (
+    id UUID PRIMARY KEY,
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    option_id UUID NOT NULL REFERENCES poll_options(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_deleted_votes_user_created ON deleted_votes(user_id, created_at DESC);
+
+-- Down Migration
+DROP TABLE IF EXISTS deleted_votes;
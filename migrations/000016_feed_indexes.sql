@@ -0,0 +1,27 @@
+-- Migration: feed_indexes
+-- Created at: 2024-05-20
+
+-- Up Migration
+-- The feed anti-joins votes and skips by (user_id, poll_id), filters tags by
+-- (tag, poll_id) and pages through polls by (created_at, id). These composite
+-- indexes cover each of those lookups and supersede the single-column ones.
+CREATE INDEX IF NOT EXISTS idx_votes_user_poll ON votes(user_id, poll_id);
+CREATE INDEX IF NOT EXISTS idx_skips_user_poll ON skips(user_id, poll_id);
+CREATE INDEX IF NOT EXISTS idx_polls_created_at_id ON polls(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_poll_tags_tag_poll ON poll_tags(tag, poll_id);
+
+DROP INDEX IF EXISTS idx_votes_user_id;
+DROP INDEX IF EXISTS idx_skips_user_id;
+DROP INDEX IF EXISTS idx_polls_created_at;
+DROP INDEX IF EXISTS idx_poll_tags_tag;
+
+-- Down Migration
+CREATE INDEX IF NOT EXISTS idx_poll_tags_tag ON poll_tags(tag);
+CREATE INDEX IF NOT EXISTS idx_polls_created_at ON polls(created_at);
+CREATE INDEX IF NOT EXISTS idx_skips_user_id ON skips(user_id);
+CREATE INDEX IF NOT EXISTS idx_votes_user_id ON votes(user_id);
+
+DROP INDEX IF EXISTS idx_poll_tags_tag_poll;
+DROP INDEX IF EXISTS idx_polls_created_at_id;
+DROP INDEX IF EXISTS idx_skips_user_poll;
+DROP INDEX IF EXISTS idx_votes_user_poll;
@@ -0,0 +1,10 @@
+-- Migration: poll_search
+-- Created at: 2024-05-24
+
+-- Up Migration
+-- Backs the Postgres fallback for poll search. The expression must match the
+-- one used in SearchPolls for the planner to pick the index.
+CREATE INDEX IF NOT EXISTS idx_polls_title_fts ON polls USING GIN (to_tsvector('simple', title));
+
+-- Down Migration
+DROP INDEX IF EXISTS idx_polls_title_fts;
@@ -0,0 +1,17 @@
+-- Migration: media
+-- Created at: 2024-05-28
+
+-- Up Migration
+-- Object storage keys of uploaded media. Objects no row points at are
+-- removed by the media_gc job.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_key TEXT;
+ALTER TABLE poll_options ADD COLUMN IF NOT EXISTS image_key TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_users_avatar_key ON users(avatar_key) WHERE avatar_key IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_poll_options_image_key ON poll_options(image_key) WHERE image_key IS NOT NULL;
+
+-- Down Migration
+DROP INDEX IF EXISTS idx_poll_options_image_key;
+DROP INDEX IF EXISTS idx_users_avatar_key;
+ALTER TABLE poll_options DROP COLUMN IF EXISTS image_key;
+ALTER TABLE users DROP COLUMN IF EXISTS avatar_key;
@@ -0,0 +1,21 @@
+-- Migration: vote_geo
+-- Created at: 2024-05-31
+
+-- Up Migration
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS anonymous BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS allowed_countries TEXT[];
+
+-- Votes per voter location, kept only as counts so locations are never
+-- tied to individual votes. Region is '' when the database has none.
+CREATE TABLE IF NOT EXISTS poll_geo_votes (
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    country CHAR(2) NOT NULL,
+    region TEXT NOT NULL DEFAULT '',
+    votes INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (poll_id, country, region)
+);
+
+-- Down Migration
+DROP TABLE IF EXISTS poll_geo_votes;
+ALTER TABLE polls DROP COLUMN IF EXISTS allowed_countries;
+ALTER TABLE polls DROP COLUMN IF EXISTS anonymous;
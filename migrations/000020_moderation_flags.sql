@@ -0,0 +1,24 @@
+-- Migration: moderation_flags
+-- Created at: 2024-06-03
+
+-- Up Migration
+-- Content the scorer rated at or above the flagging threshold, waiting for
+-- a moderator. The text is copied so the flag survives later edits.
+CREATE TABLE IF NOT EXISTS moderation_flags (
+    id UUID PRIMARY KEY,
+    kind VARCHAR(32) NOT NULL,
+    content TEXT NOT NULL,
+    poll_id UUID REFERENCES polls(id) ON DELETE CASCADE,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    score DOUBLE PRECISION NOT NULL,
+    reasons TEXT[] NOT NULL DEFAULT '{}',
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_flags_status ON moderation_flags(status, created_at);
+
+-- Down Migration
+DROP TABLE IF EXISTS moderation_flags;
@@ -0,0 +1,13 @@
+-- Migration: user_standing
+-- Created at: 2024-06-05
+
+-- Up Migration
+ALTER TABLE users ADD COLUMN IF NOT EXISTS standing VARCHAR(16) NOT NULL DEFAULT 'active';
+
+-- Shadow-banned users are looked up from the feed, search and stats
+-- queries; they are few, so a partial index keeps those lookups cheap.
+CREATE INDEX IF NOT EXISTS idx_users_shadow_banned ON users(id) WHERE standing = 'shadow_banned';
+
+-- Down Migration
+DROP INDEX IF EXISTS idx_users_shadow_banned;
+ALTER TABLE users DROP COLUMN IF EXISTS standing;
@@ -0,0 +1,19 @@
+-- Migration: user_consents
+-- Created at: 2024-06-07
+
+-- Up Migration
+-- Every version of the terms of service and privacy policy a user accepted.
+-- Rows are never updated or deleted while the user exists, so the table
+-- doubles as the audit trail.
+CREATE TABLE IF NOT EXISTS user_consents (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    document VARCHAR(16) NOT NULL,
+    version VARCHAR(64) NOT NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ip_address TEXT,
+    user_agent TEXT,
+    PRIMARY KEY (user_id, document, version)
+);
+
+-- Down Migration
+DROP TABLE IF EXISTS user_consents;
@@ -0,0 +1,22 @@
+-- Migration: poll_results
+-- Created at: 2024-06-10
+
+-- Up Migration
+-- Frozen results of closed polls; options holds the per-option counts and
+-- percentages as JSON
+CREATE TABLE IF NOT EXISTS poll_results (
+    poll_id UUID PRIMARY KEY REFERENCES polls(id) ON DELETE CASCADE,
+    options TEXT NOT NULL,
+    total_votes INTEGER NOT NULL,
+    winner TEXT,
+    turnout_voted INTEGER,
+    turnout_eligible INTEGER,
+    closed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_polls_ends_at ON polls(ends_at) WHERE ends_at IS NOT NULL;
+
+-- Down Migration
+DROP INDEX IF EXISTS idx_polls_ends_at;
+DROP TABLE IF EXISTS poll_results;
@@ -0,0 +1,12 @@
+-- Migration: poll_result_ties
+-- Created at: 2024-06-12
+
+-- Up Migration
+-- Options sharing the lead of a closed poll (JSON) and the tie-break policy
+-- applied to them
+ALTER TABLE poll_results ADD COLUMN IF NOT EXISTS tied TEXT;
+ALTER TABLE poll_results ADD COLUMN IF NOT EXISTS tie_break VARCHAR(16);
+
+-- Down Migration
+ALTER TABLE poll_results DROP COLUMN IF EXISTS tie_break;
+ALTER TABLE poll_results DROP COLUMN IF EXISTS tied;
@@ -0,0 +1,9 @@
+-- Migration: votes_poll_keyset
+-- Created at: 2024-06-14
+
+-- Up Migration
+-- Poll vote exports page through a poll's votes by (created_at, id).
+CREATE INDEX IF NOT EXISTS idx_votes_poll_created_id ON votes(poll_id, created_at, id);
+
+-- Down Migration
+DROP INDEX IF EXISTS idx_votes_poll_created_id;
@@ -0,0 +1,40 @@
+-- Migration: analytics_projections
+-- Created at: 2024-06-17
+
+-- Up Migration
+-- Projections built by `vote analytics-consumer` from the event stream. The
+-- analytics endpoints read these instead of scanning votes.
+CREATE TABLE IF NOT EXISTS analytics_tag_daily_votes (
+    tag VARCHAR(50) NOT NULL,
+    day DATE NOT NULL,
+    votes INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (tag, day)
+);
+
+CREATE TABLE IF NOT EXISTS analytics_poll_hourly_votes (
+    poll_id UUID NOT NULL,
+    hour TIMESTAMP WITH TIME ZONE NOT NULL,
+    votes INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (poll_id, hour)
+);
+
+CREATE TABLE IF NOT EXISTS analytics_user_activity (
+    user_id UUID NOT NULL,
+    day DATE NOT NULL,
+    votes INTEGER NOT NULL DEFAULT 0,
+    skips INTEGER NOT NULL DEFAULT 0,
+    polls_created INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day)
+);
+
+-- Every applied event is recorded so redelivered events are counted once.
+CREATE TABLE IF NOT EXISTS analytics_processed_events (
+    event_key VARCHAR(100) PRIMARY KEY,
+    processed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Down Migration
+DROP TABLE IF EXISTS analytics_processed_events;
+DROP TABLE IF EXISTS analytics_user_activity;
+DROP TABLE IF EXISTS analytics_poll_hourly_votes;
+DROP TABLE IF EXISTS analytics_tag_daily_votes;
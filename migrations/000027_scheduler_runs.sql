@@ -0,0 +1,14 @@
+-- Migration: scheduler_runs
+-- Created at: 2024-06-19
+
+-- Up Migration
+-- The next run of each scheduled job, so restarts and daylight saving
+-- changes neither repeat nor skip runs.
+CREATE TABLE IF NOT EXISTS scheduler_runs (
+    job VARCHAR(50) PRIMARY KEY,
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    claimed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Down Migration
+DROP TABLE IF EXISTS scheduler_runs;
@@ -0,0 +1,15 @@
+-- Migration: event_outbox
+-- Created at: 2024-06-24
+
+-- Up Migration
+-- Events that couldn't be handed to the broker when they happened, waiting
+-- for the outbox relay to publish them.
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Down Migration
+DROP TABLE IF EXISTS event_outbox;
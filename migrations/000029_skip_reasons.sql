@@ -0,0 +1,16 @@
+-- Migration: skip_reasons
+-- Created at: 2024-06-26
+
+-- Up Migration
+ALTER TABLE skips ADD COLUMN IF NOT EXISTS reason VARCHAR(20)
+    CHECK (reason IN ('not_interested', 'seen_before', 'offensive'));
+
+-- The feed looks up a user's offensive and not-interested skips, and owner
+-- stats count reasons per poll.
+CREATE INDEX IF NOT EXISTS idx_skips_user_reason ON skips(user_id, reason) WHERE reason IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_skips_poll_reason ON skips(poll_id, reason) WHERE reason IS NOT NULL;
+
+-- Down Migration
+DROP INDEX IF EXISTS idx_skips_poll_reason;
+DROP INDEX IF EXISTS idx_skips_user_reason;
+ALTER TABLE skips DROP COLUMN IF EXISTS reason;
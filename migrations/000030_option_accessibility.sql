@@ -0,0 +1,10 @@
+-- Migration: option_accessibility
+-- Created at: 2024-06-27
+
+-- Up Migration
+ALTER TABLE poll_options ADD COLUMN IF NOT EXISTS alt_text VARCHAR(250);
+ALTER TABLE poll_options ADD COLUMN IF NOT EXISTS emoji VARCHAR(16);
+
+-- Down Migration
+ALTER TABLE poll_options DROP COLUMN IF EXISTS emoji;
+ALTER TABLE poll_options DROP COLUMN IF EXISTS alt_text;
@@ -0,0 +1,9 @@
+-- Migration: user_roles
+-- Created at: 2024-07-02
+
+-- Up Migration
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'user'
+    CHECK (role IN ('user', 'moderator', 'admin'));
+
+-- Down Migration
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,14 @@
+-- Migration: poll_preview_cards
+-- Created at: 2024-07-09
+
+-- Up Migration
+CREATE TABLE IF NOT EXISTS poll_preview_cards (
+    poll_id UUID PRIMARY KEY REFERENCES polls(id) ON DELETE CASCADE,
+    image_key TEXT NOT NULL,
+    option_counts INTEGER[] NOT NULL DEFAULT '{}',
+    option_count INTEGER NOT NULL,
+    rendered_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Down Migration
+DROP TABLE IF EXISTS poll_preview_cards;
@@ -0,0 +1,8 @@
+-- Migration: results_visibility
+-- Created at: 2024-07-12
+
+-- Up Migration
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS results_visibility VARCHAR(16) NOT NULL DEFAULT 'always';
+
+-- Down Migration
+ALTER TABLE polls DROP COLUMN IF EXISTS results_visibility;
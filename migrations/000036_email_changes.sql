@@ -0,0 +1,23 @@
+-- Migration: email_changes
+-- Created at: 2024-07-15
+
+-- Up Migration
+CREATE TABLE IF NOT EXISTS email_changes (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    old_email VARCHAR(255) NOT NULL,
+    new_email VARCHAR(255) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    old_token_hash VARCHAR(64) NOT NULL UNIQUE,
+    new_token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A user has at most one change in progress.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_email_changes_open ON email_changes(user_id)
+    WHERE status IN ('pending', 'old_confirmed', 'new_confirmed');
+
+-- Down Migration
+DROP TABLE IF EXISTS email_changes;
@@ -0,0 +1,26 @@
+-- Migration: organization_votes
+-- Created at: 2024-07-20
+
+-- Up Migration
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS organization_votes BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Official votes cast by an organization admin on behalf of the
+-- organization, one per organization and poll; cast_by is the admin who
+-- cast or last changed the vote
+CREATE TABLE IF NOT EXISTS organization_votes (
+    id UUID PRIMARY KEY,
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    option_id UUID NOT NULL REFERENCES poll_options(id) ON DELETE CASCADE,
+    cast_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE (poll_id, organization_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_votes_option_id ON organization_votes(option_id);
+
+-- Down Migration
+DROP INDEX IF EXISTS idx_organization_votes_option_id;
+DROP TABLE IF EXISTS organization_votes;
+ALTER TABLE polls DROP COLUMN IF EXISTS organization_votes;
@@ -0,0 +1,10 @@
+-- Migration: outbox_claims
+-- Created at: 2024-07-24
+
+-- Up Migration
+-- Set while a relay publishes the event; once it passes, the relay is
+-- presumed dead and another one may claim the event.
+ALTER TABLE event_outbox ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMP WITH TIME ZONE;
+
+-- Down Migration
+ALTER TABLE event_outbox DROP COLUMN IF EXISTS claimed_until;
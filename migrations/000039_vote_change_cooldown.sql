@@ -0,0 +1,22 @@
+-- Migration: vote_change_cooldown
+-- Created at: 2024-07-26
+
+-- Up Migration
+-- Seconds a voter must wait between changes of their vote; 0 means no wait
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS vote_change_cooldown INTEGER NOT NULL DEFAULT 0;
+
+-- One row per change of a vote, written with the change
+CREATE TABLE IF NOT EXISTS vote_history (
+    id BIGSERIAL PRIMARY KEY,
+    vote_id UUID NOT NULL REFERENCES votes(id) ON DELETE CASCADE,
+    previous_option_id UUID REFERENCES poll_options(id) ON DELETE SET NULL,
+    option_id UUID REFERENCES poll_options(id) ON DELETE SET NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_vote_history_vote_id ON vote_history(vote_id, changed_at);
+
+-- Down Migration
+DROP INDEX IF EXISTS idx_vote_history_vote_id;
+DROP TABLE IF EXISTS vote_history;
+ALTER TABLE polls DROP COLUMN IF EXISTS vote_change_cooldown;
@@ -0,0 +1,24 @@
+-- Migration: poll_webhooks
+-- Created at: 2024-07-28
+
+-- Up Migration
+-- An endpoint of the poll creator's that is sent the results once the poll
+-- closes and its result snapshot is taken. Deleted with the poll.
+CREATE TABLE IF NOT EXISTS poll_webhooks (
+    poll_id UUID PRIMARY KEY REFERENCES polls(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    failed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_poll_webhooks_pending ON poll_webhooks(next_attempt_at)
+    WHERE delivered_at IS NULL AND failed_at IS NULL;
+
+-- Down Migration
+DROP INDEX IF EXISTS idx_poll_webhooks_pending;
+DROP TABLE IF EXISTS poll_webhooks;
@@ -0,0 +1,25 @@
+-- Migration: poll_option_counts
+-- Created at: 2024-08-02
+
+-- Up Migration
+-- Vote counts per option, kept up to date in the transactions that change
+-- votes so stats don't have to count the votes table. Votes of
+-- shadow-banned users are not counted, like in the stats they replace.
+CREATE TABLE IF NOT EXISTS poll_option_counts (
+    option_id UUID PRIMARY KEY REFERENCES poll_options(id) ON DELETE CASCADE,
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    votes INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_poll_option_counts_poll_id ON poll_option_counts(poll_id);
+
+INSERT INTO poll_option_counts (option_id, poll_id, votes)
+SELECT v.option_id, v.poll_id, COUNT(*)
+FROM votes v
+WHERE NOT EXISTS (SELECT 1 FROM users sbu WHERE sbu.id = v.user_id AND sbu.standing = 'shadow_banned')
+GROUP BY v.option_id, v.poll_id
+ON CONFLICT (option_id) DO NOTHING;
+
+-- Down Migration
+DROP INDEX IF EXISTS idx_poll_option_counts_poll_id;
+DROP TABLE IF EXISTS poll_option_counts;
@@ -0,0 +1,20 @@
+-- Migration: tag_rules
+-- Created at: 2024-08-05
+
+-- Up Migration
+-- Posting rules for tags. Restricted tags only take polls from verified
+-- users and moderators; polls posted to auto-moderated tags are held as
+-- drafts behind a poll_review moderation flag.
+CREATE TABLE IF NOT EXISTS tag_rules (
+    tag VARCHAR(50) PRIMARY KEY,
+    restricted BOOLEAN NOT NULL DEFAULT FALSE,
+    auto_moderated BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_flags_poll_kind ON moderation_flags(poll_id, kind, created_at);
+
+-- Down Migration
+DROP INDEX IF EXISTS idx_moderation_flags_poll_kind;
+DROP TABLE IF EXISTS tag_rules;
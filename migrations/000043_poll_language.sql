@@ -0,0 +1,11 @@
+-- Migration: poll_language
+-- Created at: 2024-08-07
+
+-- Up Migration
+-- The language detected from a poll's text when it is created. Polls
+-- created before, and polls whose language could not be told, keep an
+-- empty language and are shown whatever languages a feed is limited to.
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS language VARCHAR(8) NOT NULL DEFAULT '';
+
+-- Down Migration
+ALTER TABLE polls DROP COLUMN IF EXISTS language;
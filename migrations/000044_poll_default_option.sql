@@ -0,0 +1,13 @@
+-- Migration: poll_default_option
+-- Created at: 2024-08-12
+
+-- Up Migration
+-- The option a "tap to vote" poll records when a vote names none. The flag
+-- lives on the option so that it follows the option when options are
+-- reordered.
+ALTER TABLE poll_options ADD COLUMN IF NOT EXISTS is_default BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_poll_options_default ON poll_options (poll_id) WHERE is_default;
+
+-- Down Migration
+DROP INDEX IF EXISTS idx_poll_options_default;
+ALTER TABLE poll_options DROP COLUMN IF EXISTS is_default;
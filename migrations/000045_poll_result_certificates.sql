@@ -0,0 +1,17 @@
+-- Migration: poll_result_certificates
+-- Created at: 2024-08-19
+
+-- Up Migration
+-- Signed certificates of closed polls' results; payload is stored verbatim
+-- as signed
+CREATE TABLE IF NOT EXISTS poll_result_certificates (
+    poll_id UUID PRIMARY KEY REFERENCES polls(id) ON DELETE CASCADE,
+    payload TEXT NOT NULL,
+    algorithm VARCHAR(32) NOT NULL,
+    public_key VARCHAR(128) NOT NULL,
+    signature VARCHAR(128) NOT NULL,
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Down Migration
+DROP TABLE IF EXISTS poll_result_certificates;